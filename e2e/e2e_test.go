@@ -3,6 +3,7 @@
 package integration_test
 
 import (
+	"TestTaskJustPay/pkg/money"
 	"TestTaskJustPay/services/paymanager/internal/dispute"
 	"TestTaskJustPay/services/paymanager/internal/order"
 	"bytes"
@@ -214,6 +215,9 @@ func TestE2E_ChargebackFlow(t *testing.T) {
 	if foundDispute.Reason != "fraud" {
 		t.Errorf("Expected dispute reason to be 'fraud', got %v", foundDispute.Reason)
 	}
+	if foundDispute.Amount != 10050 {
+		t.Errorf("Expected dispute amount to be 10050 minor units, got %v", foundDispute.Amount)
+	}
 
 	// Close dispute as won
 	closeChargeback := map[string]interface{}{
@@ -568,15 +572,15 @@ func TestE2E_OrderCaptureFlow(t *testing.T) {
 
 	// Test successful capture
 	captureRequest := map[string]interface{}{
-		"amount":          100.50,
+		"amount":          10050,
 		"currency":        "USD",
 		"idempotency_key": "capture-test-key-1",
 	}
 
 	captureResp := POST[order.CaptureResponse](t, apiURL(), "/orders/"+orderID+"/capture", captureRequest, http.StatusOK)
 	require.Equal(t, orderID, captureResp.OrderID)
-	require.Equal(t, 100.50, captureResp.Amount)
-	require.Equal(t, "USD", captureResp.Currency)
+	require.Equal(t, int64(10050), captureResp.Amount)
+	require.Equal(t, money.Currency("USD"), captureResp.Currency)
 	require.Equal(t, "success", captureResp.Status)
 	require.Equal(t, "txn-capture-123", captureResp.ProviderTxID)
 	require.NotZero(t, captureResp.CapturedAt)
@@ -607,7 +611,7 @@ func TestE2E_OrderCaptureFlow(t *testing.T) {
 
 	// Try to capture held order — should fail with 409
 	heldCaptureRequest := map[string]interface{}{
-		"amount":          50.25,
+		"amount":          5025,
 		"currency":        "USD",
 		"idempotency_key": "capture-held-key-1",
 	}
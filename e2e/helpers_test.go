@@ -3,12 +3,15 @@
 package integration_test
 
 import (
+	"TestTaskJustPay/pkg/testinfra"
+	"TestTaskJustPay/pkg/webhooksig"
 	"TestTaskJustPay/services/paymanager/internal/dispute"
 	"TestTaskJustPay/services/paymanager/internal/order"
 	"bytes"
 	"context"
 	_ "embed"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/url"
 	"testing"
@@ -122,12 +125,37 @@ func POST[T any](t *testing.T, baseUrl, path string, payload any, expectedStatus
 
 // --- Domain-specific helpers ---
 
-func sendOrderWebhook(t *testing.T, payload map[string]interface{}) {
+// tokenizeCard vaults a test Visa card at Silvergate for the provider merchant and returns its token.
+func tokenizeCard(t *testing.T, providerMerchantID string) string {
 	t.Helper()
-	orderPayload, _ := json.Marshal(payload)
-	resp, err := http.Post(ingestURL()+"/webhooks/payments/orders", "application/json", bytes.NewBuffer(orderPayload))
+	body, err := json.Marshal(map[string]any{
+		"number":    "4242424242424242",
+		"exp_month": 12,
+		"exp_year":  time.Now().Year() + 2,
+		"cvc":       "123",
+	})
 	require.NoError(t, err)
-	resp.Body.Close()
+	req, err := http.NewRequest(http.MethodPost, suite.Silvergate.BaseURL+"/api/v1/cards", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Merchant-ID", providerMerchantID)
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("tok_%d", time.Now().UnixNano()))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var card struct {
+		Token string `json:"token"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&card))
+	return card.Token
+}
+
+func sendOrderWebhook(t *testing.T, payload map[string]interface{}) {
+	t.Helper()
+	resp := postSignedWebhook(t, "/webhooks/payments/orders", payload)
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted {
 		t.Errorf("Expected status 200, 201, or 202 for webhooks, got %d", resp.StatusCode)
@@ -136,16 +164,28 @@ func sendOrderWebhook(t *testing.T, payload map[string]interface{}) {
 
 func sendChargebackWebhook(t *testing.T, payload map[string]interface{}) {
 	t.Helper()
-	chargebackPayload, _ := json.Marshal(payload)
-	resp, err := http.Post(ingestURL()+"/webhooks/payments/chargebacks", "application/json", bytes.NewBuffer(chargebackPayload))
-	require.NoError(t, err)
-	resp.Body.Close()
+	resp := postSignedWebhook(t, "/webhooks/payments/chargebacks", payload)
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
 		t.Errorf("Expected status 200 or 202 for chargeback, got %d", resp.StatusCode)
 	}
 }
 
+// postSignedWebhook posts payload to Ingest signed as the payments provider.
+func postSignedWebhook(t *testing.T, path string, payload map[string]interface{}) *http.Response {
+	t.Helper()
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequest(http.MethodPost, ingestURL()+path, bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooksig.Header, webhooksig.HeaderValue([]string{testinfra.PaymentsWebhookSecret}, time.Now().Unix(), body))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
 func getOrders(t *testing.T) []order.Order {
 	return GET[[]order.Order](t, apiURL(), "/orders", nil, http.StatusOK)
 }
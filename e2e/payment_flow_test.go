@@ -33,6 +33,7 @@ func TestE2E_PaymentFlow(t *testing.T) {
 
 	results := make([]result, 0, iterations)
 	var authorizedPaymentIDs []string
+	cardToken := tokenizeCard(t, "merchant_1")
 
 	for i := range iterations {
 		amount := int64((i + 1) * 1000)
@@ -42,7 +43,7 @@ func TestE2E_PaymentFlow(t *testing.T) {
 			payment.CreatePaymentRequest{
 				Amount:    amount,
 				Currency:  "USD",
-				CardToken: fmt.Sprintf("tok_%03d", i+1),
+				CardToken: cardToken,
 			}, http.StatusOK)
 
 		r := result{
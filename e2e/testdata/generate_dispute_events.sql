@@ -23,9 +23,10 @@ EXECUTE 'SET LOCAL synchronous_commit = off';
 -- EXECUTE 'TRUNCATE dispute_events, disputes, orders RESTART IDENTITY CASCADE';
 
 -- ----------- (1) orders -----------
-INSERT INTO orders (id, user_id, status, created_at, updated_at)
+INSERT INTO orders (id, merchant_id, user_id, status, created_at, updated_at)
 SELECT
     'ord-' || lpad(g::text, 8, '0')                                       AS id,
+    'merchant_1'                                                           AS merchant_id,
     gen_random_uuid()                                                      AS user_id,
     (ARRAY['created','updated','failed','success'])[1+floor(random()*4)::int],
     ts                                                                     AS created_at,
@@ -37,15 +38,16 @@ FROM (
     ) s;
 
 -- ----------- (2) disputes -----------
-INSERT INTO disputes (id, order_id, submitting_id, status, reason, amount, currency,
+INSERT INTO disputes (id, order_id, merchant_id, submitting_id, status, reason, amount, currency,
                       opened_at, evidence_due_at, submitted_at, closed_at)
 SELECT
     'disp-' || lpad(g::text, 9, '0')                                       AS id,
     'ord-' || lpad((1 + floor(random()*ORDERS_CNT))::int::text, 8, '0')    AS order_id,
+    'merchant_1'                                                           AS merchant_id,
     CASE WHEN random() < 0.8 THEN 'sg-subm-'|| (1 + floor(random()*20))::int ELSE NULL END,
     (ARRAY['open','under_review','submitted','won','lost', 'closed', 'canceled'])[1+floor(random()*7)::int],
     (ARRAY['fraud','product_not_received','duplicate','other'])[1+floor(random()*4)::int],
-    round((random()*500 + 5)::numeric, 2)                                   AS amount,
+    (500 + floor(random()*50000))::bigint                                   AS amount,
     (ARRAY['USD','EUR','UAH','GBP'])[1+floor(random()*4)::int]              AS currency,
     ts_open                                                                  AS opened_at,
     ts_open + (random()*20) * interval '1 day'                             AS evidence_due_at,
@@ -2,82 +2,82 @@
 -- Natural data: 50 orders, only 30% have disputes (15 disputes), 80% disputes are final, 100+ events
 
 -- Insert 50 orders across multiple days
-INSERT INTO orders (id, user_id, status, on_hold, hold_reason, created_at, updated_at) VALUES
+INSERT INTO orders (id, merchant_id, user_id, status, on_hold, hold_reason, created_at, updated_at) VALUES
 -- Week 1: 2024-01-15 to 2024-01-21 (35 orders)
-('order_001', '550e8400-e29b-41d4-a716-446655440001', 'completed', false, NULL, '2024-01-15 08:00:00', '2024-01-15 08:30:00'),
-('order_002', '550e8400-e29b-41d4-a716-446655440002', 'completed', false, NULL, '2024-01-15 10:15:00', '2024-01-15 10:45:00'),
-('order_003', '550e8400-e29b-41d4-a716-446655440003', 'completed', false, NULL, '2024-01-15 14:30:00', '2024-01-15 15:00:00'),
-('order_004', '550e8400-e29b-41d4-a716-446655440004', 'completed', false, NULL, '2024-01-15 16:20:00', '2024-01-15 16:50:00'),
-('order_005', '550e8400-e29b-41d4-a716-446655440005', 'completed', false, NULL, '2024-01-15 18:45:00', '2024-01-15 19:15:00'),
-('order_006', '550e8400-e29b-41d4-a716-446655440006', 'completed', false, NULL, '2024-01-16 09:00:00', '2024-01-16 09:30:00'),
-('order_007', '550e8400-e29b-41d4-a716-446655440007', 'completed', false, NULL, '2024-01-16 11:30:00', '2024-01-16 12:00:00'),
-('order_008', '550e8400-e29b-41d4-a716-446655440008', 'completed', false, NULL, '2024-01-16 13:15:00', '2024-01-16 13:45:00'),
-('order_009', '550e8400-e29b-41d4-a716-446655440009', 'completed', false, NULL, '2024-01-16 15:45:00', '2024-01-16 16:15:00'),
-('order_010', '550e8400-e29b-41d4-a716-446655440010', 'completed', false, NULL, '2024-01-16 17:20:00', '2024-01-16 17:50:00'),
-('order_011', '550e8400-e29b-41d4-a716-446655440011', 'completed', false, NULL, '2024-01-17 08:30:00', '2024-01-17 09:00:00'),
-('order_012', '550e8400-e29b-41d4-a716-446655440012', 'completed', false, NULL, '2024-01-17 12:00:00', '2024-01-17 12:30:00'),
-('order_013', '550e8400-e29b-41d4-a716-446655440013', 'completed', false, NULL, '2024-01-17 17:30:00', '2024-01-17 18:00:00'),
-('order_014', '550e8400-e29b-41d4-a716-446655440014', 'completed', false, NULL, '2024-01-17 19:15:00', '2024-01-17 19:45:00'),
-('order_015', '550e8400-e29b-41d4-a716-446655440015', 'completed', false, NULL, '2024-01-18 10:15:00', '2024-01-18 10:45:00'),
-('order_016', '550e8400-e29b-41d4-a716-446655440016', 'completed', false, NULL, '2024-01-18 14:30:00', '2024-01-18 15:00:00'),
-('order_017', '550e8400-e29b-41d4-a716-446655440017', 'completed', false, NULL, '2024-01-18 19:00:00', '2024-01-18 19:30:00'),
-('order_018', '550e8400-e29b-41d4-a716-446655440018', 'completed', false, NULL, '2024-01-19 09:45:00', '2024-01-19 10:15:00'),
-('order_019', '550e8400-e29b-41d4-a716-446655440019', 'completed', false, NULL, '2024-01-19 13:20:00', '2024-01-19 13:50:00'),
-('order_020', '550e8400-e29b-41d4-a716-446655440020', 'completed', false, NULL, '2024-01-19 16:30:00', '2024-01-19 17:00:00'),
-('order_021', '550e8400-e29b-41d4-a716-446655440021', 'completed', false, NULL, '2024-01-19 18:45:00', '2024-01-19 19:15:00'),
-('order_022', '550e8400-e29b-41d4-a716-446655440022', 'completed', false, NULL, '2024-01-20 08:00:00', '2024-01-20 08:30:00'),
-('order_023', '550e8400-e29b-41d4-a716-446655440023', 'pending', true, 'manual_review', '2024-01-20 11:30:00', '2024-01-20 11:30:00'),
-('order_024', '550e8400-e29b-41d4-a716-446655440024', 'completed', false, NULL, '2024-01-20 15:45:00', '2024-01-20 16:15:00'),
-('order_025', '550e8400-e29b-41d4-a716-446655440025', 'completed', false, NULL, '2024-01-20 17:30:00', '2024-01-20 18:00:00'),
-('order_026', '550e8400-e29b-41d4-a716-446655440026', 'completed', false, NULL, '2024-01-21 10:00:00', '2024-01-21 10:30:00'),
-('order_027', '550e8400-e29b-41d4-a716-446655440027', 'completed', false, NULL, '2024-01-21 14:15:00', '2024-01-21 14:45:00'),
-('order_028', '550e8400-e29b-41d4-a716-446655440028', 'completed', false, NULL, '2024-01-21 18:30:00', '2024-01-21 19:00:00'),
-('order_029', '550e8400-e29b-41d4-a716-446655440029', 'completed', false, NULL, '2024-01-21 20:15:00', '2024-01-21 20:45:00'),
-('order_030', '550e8400-e29b-41d4-a716-446655440030', 'completed', false, NULL, '2024-01-21 21:30:00', '2024-01-21 22:00:00'),
-('order_031', '550e8400-e29b-41d4-a716-446655440031', 'completed', false, NULL, '2024-01-21 22:45:00', '2024-01-21 23:15:00'),
-('order_032', '550e8400-e29b-41d4-a716-446655440032', 'completed', false, NULL, '2024-01-21 23:30:00', '2024-01-22 00:00:00'),
-('order_033', '550e8400-e29b-41d4-a716-446655440033', 'completed', false, NULL, '2024-01-22 01:15:00', '2024-01-22 01:45:00'),
-('order_034', '550e8400-e29b-41d4-a716-446655440034', 'completed', false, NULL, '2024-01-22 03:30:00', '2024-01-22 04:00:00'),
-('order_035', '550e8400-e29b-41d4-a716-446655440035', 'completed', false, NULL, '2024-01-22 09:15:00', '2024-01-22 09:45:00'),
+('order_001', 'merchant_1', '550e8400-e29b-41d4-a716-446655440001', 'completed', false, NULL, '2024-01-15 08:00:00', '2024-01-15 08:30:00'),
+('order_002', 'merchant_1', '550e8400-e29b-41d4-a716-446655440002', 'completed', false, NULL, '2024-01-15 10:15:00', '2024-01-15 10:45:00'),
+('order_003', 'merchant_1', '550e8400-e29b-41d4-a716-446655440003', 'completed', false, NULL, '2024-01-15 14:30:00', '2024-01-15 15:00:00'),
+('order_004', 'merchant_1', '550e8400-e29b-41d4-a716-446655440004', 'completed', false, NULL, '2024-01-15 16:20:00', '2024-01-15 16:50:00'),
+('order_005', 'merchant_1', '550e8400-e29b-41d4-a716-446655440005', 'completed', false, NULL, '2024-01-15 18:45:00', '2024-01-15 19:15:00'),
+('order_006', 'merchant_1', '550e8400-e29b-41d4-a716-446655440006', 'completed', false, NULL, '2024-01-16 09:00:00', '2024-01-16 09:30:00'),
+('order_007', 'merchant_1', '550e8400-e29b-41d4-a716-446655440007', 'completed', false, NULL, '2024-01-16 11:30:00', '2024-01-16 12:00:00'),
+('order_008', 'merchant_1', '550e8400-e29b-41d4-a716-446655440008', 'completed', false, NULL, '2024-01-16 13:15:00', '2024-01-16 13:45:00'),
+('order_009', 'merchant_1', '550e8400-e29b-41d4-a716-446655440009', 'completed', false, NULL, '2024-01-16 15:45:00', '2024-01-16 16:15:00'),
+('order_010', 'merchant_1', '550e8400-e29b-41d4-a716-446655440010', 'completed', false, NULL, '2024-01-16 17:20:00', '2024-01-16 17:50:00'),
+('order_011', 'merchant_1', '550e8400-e29b-41d4-a716-446655440011', 'completed', false, NULL, '2024-01-17 08:30:00', '2024-01-17 09:00:00'),
+('order_012', 'merchant_1', '550e8400-e29b-41d4-a716-446655440012', 'completed', false, NULL, '2024-01-17 12:00:00', '2024-01-17 12:30:00'),
+('order_013', 'merchant_1', '550e8400-e29b-41d4-a716-446655440013', 'completed', false, NULL, '2024-01-17 17:30:00', '2024-01-17 18:00:00'),
+('order_014', 'merchant_1', '550e8400-e29b-41d4-a716-446655440014', 'completed', false, NULL, '2024-01-17 19:15:00', '2024-01-17 19:45:00'),
+('order_015', 'merchant_1', '550e8400-e29b-41d4-a716-446655440015', 'completed', false, NULL, '2024-01-18 10:15:00', '2024-01-18 10:45:00'),
+('order_016', 'merchant_1', '550e8400-e29b-41d4-a716-446655440016', 'completed', false, NULL, '2024-01-18 14:30:00', '2024-01-18 15:00:00'),
+('order_017', 'merchant_1', '550e8400-e29b-41d4-a716-446655440017', 'completed', false, NULL, '2024-01-18 19:00:00', '2024-01-18 19:30:00'),
+('order_018', 'merchant_1', '550e8400-e29b-41d4-a716-446655440018', 'completed', false, NULL, '2024-01-19 09:45:00', '2024-01-19 10:15:00'),
+('order_019', 'merchant_1', '550e8400-e29b-41d4-a716-446655440019', 'completed', false, NULL, '2024-01-19 13:20:00', '2024-01-19 13:50:00'),
+('order_020', 'merchant_1', '550e8400-e29b-41d4-a716-446655440020', 'completed', false, NULL, '2024-01-19 16:30:00', '2024-01-19 17:00:00'),
+('order_021', 'merchant_1', '550e8400-e29b-41d4-a716-446655440021', 'completed', false, NULL, '2024-01-19 18:45:00', '2024-01-19 19:15:00'),
+('order_022', 'merchant_1', '550e8400-e29b-41d4-a716-446655440022', 'completed', false, NULL, '2024-01-20 08:00:00', '2024-01-20 08:30:00'),
+('order_023', 'merchant_1', '550e8400-e29b-41d4-a716-446655440023', 'pending', true, 'manual_review', '2024-01-20 11:30:00', '2024-01-20 11:30:00'),
+('order_024', 'merchant_1', '550e8400-e29b-41d4-a716-446655440024', 'completed', false, NULL, '2024-01-20 15:45:00', '2024-01-20 16:15:00'),
+('order_025', 'merchant_1', '550e8400-e29b-41d4-a716-446655440025', 'completed', false, NULL, '2024-01-20 17:30:00', '2024-01-20 18:00:00'),
+('order_026', 'merchant_1', '550e8400-e29b-41d4-a716-446655440026', 'completed', false, NULL, '2024-01-21 10:00:00', '2024-01-21 10:30:00'),
+('order_027', 'merchant_1', '550e8400-e29b-41d4-a716-446655440027', 'completed', false, NULL, '2024-01-21 14:15:00', '2024-01-21 14:45:00'),
+('order_028', 'merchant_1', '550e8400-e29b-41d4-a716-446655440028', 'completed', false, NULL, '2024-01-21 18:30:00', '2024-01-21 19:00:00'),
+('order_029', 'merchant_1', '550e8400-e29b-41d4-a716-446655440029', 'completed', false, NULL, '2024-01-21 20:15:00', '2024-01-21 20:45:00'),
+('order_030', 'merchant_1', '550e8400-e29b-41d4-a716-446655440030', 'completed', false, NULL, '2024-01-21 21:30:00', '2024-01-21 22:00:00'),
+('order_031', 'merchant_1', '550e8400-e29b-41d4-a716-446655440031', 'completed', false, NULL, '2024-01-21 22:45:00', '2024-01-21 23:15:00'),
+('order_032', 'merchant_1', '550e8400-e29b-41d4-a716-446655440032', 'completed', false, NULL, '2024-01-21 23:30:00', '2024-01-22 00:00:00'),
+('order_033', 'merchant_1', '550e8400-e29b-41d4-a716-446655440033', 'completed', false, NULL, '2024-01-22 01:15:00', '2024-01-22 01:45:00'),
+('order_034', 'merchant_1', '550e8400-e29b-41d4-a716-446655440034', 'completed', false, NULL, '2024-01-22 03:30:00', '2024-01-22 04:00:00'),
+('order_035', 'merchant_1', '550e8400-e29b-41d4-a716-446655440035', 'completed', false, NULL, '2024-01-22 09:15:00', '2024-01-22 09:45:00'),
 
 -- Week 2: 2024-01-22 to 2024-01-28 (15 orders)
-('order_036', '550e8400-e29b-41d4-a716-446655440036', 'completed', false, NULL, '2024-01-22 12:45:00', '2024-01-22 13:15:00'),
-('order_037', '550e8400-e29b-41d4-a716-446655440037', 'completed', false, NULL, '2024-01-23 11:30:00', '2024-01-23 12:00:00'),
-('order_038', '550e8400-e29b-41d4-a716-446655440038', 'completed', false, NULL, '2024-01-23 16:00:00', '2024-01-23 16:30:00'),
-('order_039', '550e8400-e29b-41d4-a716-446655440039', 'completed', false, NULL, '2024-01-24 08:45:00', '2024-01-24 09:15:00'),
-('order_040', '550e8400-e29b-41d4-a716-446655440040', 'pending', true, 'risk', '2024-01-24 17:30:00', '2024-01-24 17:30:00'),
-('order_041', '550e8400-e29b-41d4-a716-446655440041', 'completed', false, NULL, '2024-01-25 10:15:00', '2024-01-25 10:45:00'),
-('order_042', '550e8400-e29b-41d4-a716-446655440042', 'completed', false, NULL, '2024-01-25 14:30:00', '2024-01-25 15:00:00'),
-('order_043', '550e8400-e29b-41d4-a716-446655440043', 'completed', false, NULL, '2024-01-26 09:00:00', '2024-01-26 09:30:00'),
-('order_044', '550e8400-e29b-41d4-a716-446655440044', 'completed', false, NULL, '2024-01-26 13:15:00', '2024-01-26 13:45:00'),
-('order_045', '550e8400-e29b-41d4-a716-446655440045', 'completed', false, NULL, '2024-01-27 11:20:00', '2024-01-27 11:50:00'),
-('order_046', '550e8400-e29b-41d4-a716-446655440046', 'completed', false, NULL, '2024-01-27 15:30:00', '2024-01-27 16:00:00'),
-('order_047', '550e8400-e29b-41d4-a716-446655440047', 'completed', false, NULL, '2024-01-28 08:45:00', '2024-01-28 09:15:00'),
-('order_048', '550e8400-e29b-41d4-a716-446655440048', 'completed', false, NULL, '2024-01-28 12:30:00', '2024-01-28 13:00:00'),
-('order_049', '550e8400-e29b-41d4-a716-446655440049', 'completed', false, NULL, '2024-01-28 16:45:00', '2024-01-28 17:15:00'),
-('order_050', '550e8400-e29b-41d4-a716-446655440050', 'pending', false, NULL, '2024-01-28 19:20:00', '2024-01-28 19:20:00')
+('order_036', 'merchant_1', '550e8400-e29b-41d4-a716-446655440036', 'completed', false, NULL, '2024-01-22 12:45:00', '2024-01-22 13:15:00'),
+('order_037', 'merchant_1', '550e8400-e29b-41d4-a716-446655440037', 'completed', false, NULL, '2024-01-23 11:30:00', '2024-01-23 12:00:00'),
+('order_038', 'merchant_1', '550e8400-e29b-41d4-a716-446655440038', 'completed', false, NULL, '2024-01-23 16:00:00', '2024-01-23 16:30:00'),
+('order_039', 'merchant_1', '550e8400-e29b-41d4-a716-446655440039', 'completed', false, NULL, '2024-01-24 08:45:00', '2024-01-24 09:15:00'),
+('order_040', 'merchant_1', '550e8400-e29b-41d4-a716-446655440040', 'pending', true, 'risk', '2024-01-24 17:30:00', '2024-01-24 17:30:00'),
+('order_041', 'merchant_1', '550e8400-e29b-41d4-a716-446655440041', 'completed', false, NULL, '2024-01-25 10:15:00', '2024-01-25 10:45:00'),
+('order_042', 'merchant_1', '550e8400-e29b-41d4-a716-446655440042', 'completed', false, NULL, '2024-01-25 14:30:00', '2024-01-25 15:00:00'),
+('order_043', 'merchant_1', '550e8400-e29b-41d4-a716-446655440043', 'completed', false, NULL, '2024-01-26 09:00:00', '2024-01-26 09:30:00'),
+('order_044', 'merchant_1', '550e8400-e29b-41d4-a716-446655440044', 'completed', false, NULL, '2024-01-26 13:15:00', '2024-01-26 13:45:00'),
+('order_045', 'merchant_1', '550e8400-e29b-41d4-a716-446655440045', 'completed', false, NULL, '2024-01-27 11:20:00', '2024-01-27 11:50:00'),
+('order_046', 'merchant_1', '550e8400-e29b-41d4-a716-446655440046', 'completed', false, NULL, '2024-01-27 15:30:00', '2024-01-27 16:00:00'),
+('order_047', 'merchant_1', '550e8400-e29b-41d4-a716-446655440047', 'completed', false, NULL, '2024-01-28 08:45:00', '2024-01-28 09:15:00'),
+('order_048', 'merchant_1', '550e8400-e29b-41d4-a716-446655440048', 'completed', false, NULL, '2024-01-28 12:30:00', '2024-01-28 13:00:00'),
+('order_049', 'merchant_1', '550e8400-e29b-41d4-a716-446655440049', 'completed', false, NULL, '2024-01-28 16:45:00', '2024-01-28 17:15:00'),
+('order_050', 'merchant_1', '550e8400-e29b-41d4-a716-446655440050', 'pending', false, NULL, '2024-01-28 19:20:00', '2024-01-28 19:20:00')
     ON CONFLICT (id) DO NOTHING;
 
 -- Insert 15 disputes (30% of 50 orders), 12 in final state (80%)
-INSERT INTO disputes (id, order_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at) VALUES
+INSERT INTO disputes (id, order_id, merchant_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at) VALUES
 -- Final state disputes (12 total - 80%)
-('dispute_001', 'order_003', 'sub_001', 'won', 'Product not received', 99.99, 'USD', '2024-01-16 09:00:00', '2024-01-23 23:59:59', '2024-01-18 14:30:00', '2024-01-25 16:45:00'),
-('dispute_002', 'order_007', 'sub_002', 'lost', 'Product damaged', 149.50, 'USD', '2024-01-17 11:30:00', '2024-01-24 23:59:59', '2024-01-19 10:20:00', '2024-01-26 09:15:00'),
-('dispute_003', 'order_012', 'sub_003', 'won', 'Unauthorized transaction', 75.25, 'EUR', '2024-01-18 15:20:00', '2024-01-25 23:59:59', '2024-01-20 11:00:00', '2024-01-27 13:30:00'),
-('dispute_004', 'order_015', 'sub_004', 'closed', 'Service not provided', 200.00, 'GBP', '2024-01-19 08:15:00', '2024-01-26 23:59:59', '2024-01-21 16:45:00', '2024-01-28 10:20:00'),
-('dispute_005', 'order_018', 'sub_005', 'won', 'Product defective', 89.99, 'USD', '2024-01-20 14:45:00', '2024-01-27 23:59:59', '2024-01-22 09:30:00', '2024-01-29 11:15:00'),
-('dispute_006', 'order_024', 'sub_006', 'lost', 'Duplicate charge', 45.50, 'USD', '2024-01-21 10:30:00', '2024-01-28 23:59:59', '2024-01-23 15:15:00', '2024-01-30 14:20:00'),
-('dispute_007', 'order_026', 'sub_007', 'won', 'Product not as described', 125.75, 'EUR', '2024-01-22 16:00:00', '2024-01-29 23:59:59', '2024-01-24 12:30:00', '2024-01-31 17:45:00'),
-('dispute_008', 'order_031', 'sub_008', 'canceled', 'Billing error', 67.80, 'USD', '2024-01-23 09:45:00', '2024-01-30 23:59:59', NULL, '2024-01-25 08:30:00'),
-('dispute_009', 'order_035', 'sub_009', 'won', 'Service cancelled', 180.00, 'GBP', '2024-01-24 13:30:00', '2024-01-31 23:59:59', '2024-01-26 11:20:00', '2024-02-02 15:45:00'),
-('dispute_010', 'order_037', 'sub_010', 'lost', 'Wrong amount charged', 55.25, 'USD', '2024-01-25 17:15:00', '2024-02-01 23:59:59', '2024-01-27 14:45:00', '2024-02-03 10:30:00'),
-('dispute_011', 'order_041', 'sub_011', 'won', 'Product quality issue', 95.40, 'EUR', '2024-01-26 08:30:00', '2024-02-02 23:59:59', '2024-01-28 16:20:00', '2024-02-04 12:15:00'),
-('dispute_012', 'order_045', 'sub_012', 'closed', 'Refund not processed', 120.00, 'USD', '2024-01-27 12:45:00', '2024-02-03 23:59:59', '2024-01-29 09:30:00', '2024-02-05 11:45:00'),
+('dispute_001', 'order_003', 'merchant_1', 'sub_001', 'won', 'Product not received', 9999, 'USD', '2024-01-16 09:00:00', '2024-01-23 23:59:59', '2024-01-18 14:30:00', '2024-01-25 16:45:00'),
+('dispute_002', 'order_007', 'merchant_1', 'sub_002', 'lost', 'Product damaged', 14950, 'USD', '2024-01-17 11:30:00', '2024-01-24 23:59:59', '2024-01-19 10:20:00', '2024-01-26 09:15:00'),
+('dispute_003', 'order_012', 'merchant_1', 'sub_003', 'won', 'Unauthorized transaction', 7525, 'EUR', '2024-01-18 15:20:00', '2024-01-25 23:59:59', '2024-01-20 11:00:00', '2024-01-27 13:30:00'),
+('dispute_004', 'order_015', 'merchant_1', 'sub_004', 'closed', 'Service not provided', 20000, 'GBP', '2024-01-19 08:15:00', '2024-01-26 23:59:59', '2024-01-21 16:45:00', '2024-01-28 10:20:00'),
+('dispute_005', 'order_018', 'merchant_1', 'sub_005', 'won', 'Product defective', 8999, 'USD', '2024-01-20 14:45:00', '2024-01-27 23:59:59', '2024-01-22 09:30:00', '2024-01-29 11:15:00'),
+('dispute_006', 'order_024', 'merchant_1', 'sub_006', 'lost', 'Duplicate charge', 4550, 'USD', '2024-01-21 10:30:00', '2024-01-28 23:59:59', '2024-01-23 15:15:00', '2024-01-30 14:20:00'),
+('dispute_007', 'order_026', 'merchant_1', 'sub_007', 'won', 'Product not as described', 12575, 'EUR', '2024-01-22 16:00:00', '2024-01-29 23:59:59', '2024-01-24 12:30:00', '2024-01-31 17:45:00'),
+('dispute_008', 'order_031', 'merchant_1', 'sub_008', 'canceled', 'Billing error', 6780, 'USD', '2024-01-23 09:45:00', '2024-01-30 23:59:59', NULL, '2024-01-25 08:30:00'),
+('dispute_009', 'order_035', 'merchant_1', 'sub_009', 'won', 'Service cancelled', 18000, 'GBP', '2024-01-24 13:30:00', '2024-01-31 23:59:59', '2024-01-26 11:20:00', '2024-02-02 15:45:00'),
+('dispute_010', 'order_037', 'merchant_1', 'sub_010', 'lost', 'Wrong amount charged', 5525, 'USD', '2024-01-25 17:15:00', '2024-02-01 23:59:59', '2024-01-27 14:45:00', '2024-02-03 10:30:00'),
+('dispute_011', 'order_041', 'merchant_1', 'sub_011', 'won', 'Product quality issue', 9540, 'EUR', '2024-01-26 08:30:00', '2024-02-02 23:59:59', '2024-01-28 16:20:00', '2024-02-04 12:15:00'),
+('dispute_012', 'order_045', 'merchant_1', 'sub_012', 'closed', 'Refund not processed', 12000, 'USD', '2024-01-27 12:45:00', '2024-02-03 23:59:59', '2024-01-29 09:30:00', '2024-02-05 11:45:00'),
 
 -- Active disputes (3 total - 20%)
-('dispute_013', 'order_047', 'sub_013', 'open', 'Delivery never received', 78.90, 'USD', '2024-01-28 11:20:00', '2024-02-04 23:59:59', NULL, NULL),
-('dispute_014', 'order_049', 'sub_014', 'submitted', 'Incorrect item shipped', 165.30, 'GBP', '2024-01-29 15:30:00', '2024-02-05 23:59:59', '2024-01-31 10:45:00', NULL),
-('dispute_015', 'order_050', 'sub_015', 'under_review', 'Late delivery penalty', 35.00, 'GBP', '2024-01-30 09:30:00', '2024-02-06 23:59:59', '2024-02-01 14:20:00', NULL)
+('dispute_013', 'order_047', 'merchant_1', 'sub_013', 'open', 'Delivery never received', 7890, 'USD', '2024-01-28 11:20:00', '2024-02-04 23:59:59', NULL, NULL),
+('dispute_014', 'order_049', 'merchant_1', 'sub_014', 'submitted', 'Incorrect item shipped', 16530, 'GBP', '2024-01-29 15:30:00', '2024-02-05 23:59:59', '2024-01-31 10:45:00', NULL),
+('dispute_015', 'order_050', 'merchant_1', 'sub_015', 'under_review', 'Late delivery penalty', 3500, 'GBP', '2024-01-30 09:30:00', '2024-02-06 23:59:59', '2024-02-01 14:20:00', NULL)
     ON CONFLICT (id) DO NOTHING;
 
 -- Insert 100+ dispute events using proper domain event kinds
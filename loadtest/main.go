@@ -3,6 +3,9 @@ package main
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -72,6 +75,10 @@ func (s *Stats) Record(r Result) {
 
 var disputeRatio = flag.Float64("dispute-ratio", 0.3, "Probability of dispute per successful order")
 
+var apiKey = flag.String("api-key", "", "Merchant API key sent as X-API-Key on read queries (empty relies on MERCHANT_DEFAULT_FALLBACK)")
+
+var webhookSecret = flag.String("webhook-secret", "whsec_dev_payments", "Secret signing webhooks (one of Ingest's PAYMENTS_WEBHOOK_SECRETS)")
+
 func main() {
 	target := flag.String("target", "http://localhost:3001", "Ingest service URL")
 	apiTarget := flag.String("api", "http://localhost:3000", "API service URL (for read queries)")
@@ -254,8 +261,16 @@ func runReadQueries(ctx context.Context, client *http.Client, apiTarget, orderID
 func sendGET(client *http.Client, target, endpoint string) Result {
 	url := target + endpoint
 
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return Result{Endpoint: endpoint, Error: err}
+	}
+	if *apiKey != "" {
+		req.Header.Set("X-API-Key", *apiKey)
+	}
+
 	start := time.Now()
-	resp, err := client.Get(url)
+	resp, err := client.Do(req)
 	duration := time.Since(start)
 
 	result := Result{Endpoint: endpoint, Duration: duration, Error: err}
@@ -302,8 +317,15 @@ func sendWebhook(client *http.Client, target, endpoint string, payload map[strin
 	body, _ := json.Marshal(payload)
 	url := target + endpoint
 
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return Result{Endpoint: endpoint, Error: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Signature", signature(*webhookSecret, time.Now().Unix(), body))
+
 	start := time.Now()
-	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	resp, err := client.Do(req)
 	duration := time.Since(start)
 
 	result := Result{Endpoint: endpoint, Duration: duration, Error: err}
@@ -314,6 +336,15 @@ func sendWebhook(client *http.Client, target, endpoint string, payload map[strin
 	return result
 }
 
+// signature formats X-Webhook-Signature (mirrors pkg/webhooksig):
+// "t=<unix>,v1=hex(HMAC-SHA256(secret, "<t>.<body>"))".
+func signature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
 func printSummary(stats *Stats, elapsed time.Duration) {
 	total := stats.Total.Load()
 	success := stats.Success.Load()
@@ -22,6 +22,19 @@ var skipLogPaths = map[string]bool{
 	"/health/ready": true,
 }
 
+// redactedFields are JSON keys whose values never reach the logs, at any nesting depth:
+// card data and secrets.
+var redactedFields = map[string]bool{
+	"card_token": true,
+	"number":     true,
+	"pan":        true,
+	"cvc":        true,
+	"cvv":        true,
+	"secret":     true,
+}
+
+const redacted = "[REDACTED]"
+
 func shouldSkipLog(path string) bool {
 	return skipLogPaths[path]
 }
@@ -113,10 +126,29 @@ func bodyAttr(key string, b []byte) []any {
 		// For valid JSON, log as raw JSON by unmarshaling first
 		var v any
 		if err := json.Unmarshal(bb, &v); err == nil {
-			return []any{key, v}
+			return []any{key, redact(v)}
 		}
 	}
 
 	// Not JSON or unmarshal failed - log as string
 	return []any{key, string(bb)}
 }
+
+// redact replaces the values of redactedFields in a decoded JSON value.
+func redact(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, val := range t {
+			if redactedFields[k] {
+				t[k] = redacted
+				continue
+			}
+			t[k] = redact(val)
+		}
+	case []any:
+		for i, val := range t {
+			t[i] = redact(val)
+		}
+	}
+	return v
+}
@@ -3,10 +3,10 @@ package messaging
 import (
 	"context"
 	"errors"
-	"math/rand"
 	"time"
 
 	"TestTaskJustPay/pkg/metrics"
+	"TestTaskJustPay/pkg/resilience"
 )
 
 const dlqPublishTimeout = 5 * time.Second
@@ -32,33 +32,19 @@ var ErrMaxRetriesExceeded = errors.New("max retries exceeded")
 
 // WithRetry wraps a handler with exponential backoff + jitter retry logic.
 func WithRetry(handler MessageHandler, cfg RetryConfig) MessageHandler {
+	retryCfg := resilience.RetryConfig{
+		MaxAttempts:    cfg.MaxAttempts,
+		InitialBackoff: cfg.InitialBackoff,
+		MaxBackoff:     cfg.MaxBackoff,
+	}
 	return func(ctx context.Context, key, value []byte) error {
-		backoff := cfg.InitialBackoff
-
-		var lastErr error
-		for attempt := 0; attempt < cfg.MaxAttempts; attempt++ {
-			lastErr = handler(ctx, key, value)
-			if lastErr == nil {
-				return nil
-			}
-
-			// Don't sleep after last attempt
-			if attempt < cfg.MaxAttempts-1 {
-				// Add jitter: backoff + random(0-100ms)
-				jitter := time.Duration(rand.Intn(100)) * time.Millisecond
-				sleepTime := min(backoff+jitter, cfg.MaxBackoff)
-
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case <-time.After(sleepTime):
-				}
-
-				backoff *= 2 // exponential
-			}
+		err := resilience.Retry(ctx, retryCfg, nil, func(ctx context.Context) error {
+			return handler(ctx, key, value)
+		})
+		if err == nil || errors.Is(err, ctx.Err()) {
+			return err
 		}
-
-		return errors.Join(ErrMaxRetriesExceeded, lastErr)
+		return errors.Join(ErrMaxRetriesExceeded, err)
 	}
 }
 
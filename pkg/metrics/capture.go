@@ -0,0 +1,39 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	CaptureSchedulerClaimsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "capture_scheduler",
+			Name:      "claims_total",
+			Help:      "Total number of payments claimed by the capture scheduler",
+		},
+		[]string{"source"},
+	)
+
+	CaptureSchedulerAttemptsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "capture_scheduler",
+			Name:      "attempts_total",
+			Help:      "Total number of provider capture attempts made by the capture scheduler",
+		},
+		[]string{"status"},
+	)
+
+	CaptureSchedulerLag = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "dpm",
+			Subsystem: "capture_scheduler",
+			Name:      "lag_seconds",
+			Help:      "Delay between a payment's capture_at and the moment it was claimed",
+			Buckets:   []float64{.1, .5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+		},
+	)
+)
+
+func init() {
+	Registry.MustRegister(CaptureSchedulerClaimsTotal, CaptureSchedulerAttemptsTotal, CaptureSchedulerLag)
+}
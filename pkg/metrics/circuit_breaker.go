@@ -0,0 +1,39 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	CircuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "dpm",
+			Subsystem: "circuit_breaker",
+			Name:      "state",
+			Help:      "Current circuit breaker state (0 = closed, 1 = half-open, 2 = open)",
+		},
+		[]string{"name"},
+	)
+
+	CircuitBreakerTransitionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "circuit_breaker",
+			Name:      "transitions_total",
+			Help:      "Total number of circuit breaker state changes, by new state",
+		},
+		[]string{"name", "state"},
+	)
+
+	CircuitBreakerRejectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "circuit_breaker",
+			Name:      "rejected_total",
+			Help:      "Total number of calls rejected without reaching the dependency because the breaker was open",
+		},
+		[]string{"name"},
+	)
+)
+
+func init() {
+	Registry.MustRegister(CircuitBreakerState, CircuitBreakerTransitionsTotal, CircuitBreakerRejectedTotal)
+}
@@ -0,0 +1,17 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var ProviderFailoversTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "dpm",
+		Subsystem: "provider_routing",
+		Name:      "failovers_total",
+		Help:      "Total number of payment authorizations moved to the next provider because the routed one was unavailable",
+	},
+	[]string{"from", "to"},
+)
+
+func init() {
+	Registry.MustRegister(ProviderFailoversTotal)
+}
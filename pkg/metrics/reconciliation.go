@@ -0,0 +1,29 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	ReconciliationRunsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "reconciliation",
+			Name:      "runs_total",
+			Help:      "Total number of reconciliation runs",
+		},
+		[]string{"status"},
+	)
+
+	ReconciliationItemsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "reconciliation",
+			Name:      "items_total",
+			Help:      "Total number of mismatches found by reconciliation, by outcome",
+		},
+		[]string{"kind"},
+	)
+)
+
+func init() {
+	Registry.MustRegister(ReconciliationRunsTotal, ReconciliationItemsTotal)
+}
@@ -0,0 +1,29 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	RiskDecisionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "risk",
+			Name:      "decisions_total",
+			Help:      "Total number of pre-authorization risk decisions by outcome",
+		},
+		[]string{"outcome"},
+	)
+
+	RiskRuleHitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "risk",
+			Name:      "rule_hits_total",
+			Help:      "Total number of risk rule hits by rule name and action",
+		},
+		[]string{"rule", "action"},
+	)
+)
+
+func init() {
+	Registry.MustRegister(RiskDecisionsTotal, RiskRuleHitsTotal)
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	WebhookDeliveriesCreatedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "webhook_dispatcher",
+			Name:      "deliveries_created_total",
+			Help:      "Total number of merchant webhook deliveries created from the events outbox",
+		},
+	)
+
+	WebhookDeliveryAttemptsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "dpm",
+			Subsystem: "webhook_dispatcher",
+			Name:      "attempts_total",
+			Help:      "Total number of merchant webhook delivery attempts, by resulting delivery status",
+		},
+		[]string{"status"},
+	)
+
+	WebhookDeliveryDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Namespace: "dpm",
+			Subsystem: "webhook_dispatcher",
+			Name:      "attempt_duration_seconds",
+			Help:      "Duration of merchant webhook HTTP calls",
+			Buckets:   prometheus.DefBuckets,
+		},
+	)
+)
+
+func init() {
+	Registry.MustRegister(WebhookDeliveriesCreatedTotal, WebhookDeliveryAttemptsTotal, WebhookDeliveryDuration)
+}
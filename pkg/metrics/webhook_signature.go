@@ -0,0 +1,17 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var WebhookSignatureFailuresTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "dpm",
+		Subsystem: "webhook_signature",
+		Name:      "failures_total",
+		Help:      "Total number of inbound webhooks rejected for a missing, malformed, stale or mismatched signature, by provider",
+	},
+	[]string{"provider", "reason"},
+)
+
+func init() {
+	Registry.MustRegister(WebhookSignatureFailuresTotal)
+}
@@ -0,0 +1,145 @@
+// Package money provides an integer minor-unit amount paired with its ISO 4217 currency.
+// Amounts are never floating point: 10.50 USD is Money{Amount: 1050, Currency: "USD"}.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrOverflow         = errors.New("amount overflow")
+	ErrInvalidAmount    = errors.New("invalid amount")
+)
+
+// Money is an amount in minor units of Currency. It encodes to JSON as
+// {"amount": <minor units>, "currency": "<ISO 4217>"}, so embedding it keeps the
+// amount/currency fields flat in the enclosing struct.
+type Money struct {
+	Amount   int64    `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// New validates currency and returns amount minor units of it.
+func New(amount int64, currency string) (Money, error) {
+	c, err := ParseCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: c}, nil
+}
+
+// ParseMajor converts a decimal amount in major units ("10.5") to Money without going
+// through floating point. More fractional digits than the currency has is an error.
+func ParseMajor(amount string, currency string) (Money, error) {
+	c, err := ParseCurrency(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	s := strings.TrimSpace(amount)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+	}
+	exp := c.Exponent()
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places for %s", ErrInvalidAmount, amount, exp, c)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	digits := whole + frac
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("%w: %q", ErrInvalidAmount, amount)
+		}
+	}
+	minor, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, amount)
+	}
+	if neg {
+		minor = -minor
+	}
+	return Money{Amount: minor, Currency: c}, nil
+}
+
+// Validate reports whether the currency is a known ISO 4217 code.
+func (m Money) Validate() error {
+	if !m.Currency.IsValid() {
+		return fmt.Errorf("%w: %q", ErrUnknownCurrency, string(m.Currency))
+	}
+	return nil
+}
+
+func (m Money) IsZero() bool     { return m.Amount == 0 }
+func (m Money) IsPositive() bool { return m.Amount > 0 }
+func (m Money) IsNegative() bool { return m.Amount < 0 }
+
+// Add returns m+o. Both must be in the same currency.
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) || (o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m-o. Both must be in the same currency.
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	if (o.Amount < 0 && m.Amount > math.MaxInt64+o.Amount) || (o.Amount > 0 && m.Amount < math.MinInt64+o.Amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+// Major formats the amount in major units with the currency's exponent, e.g. "10.50".
+func (m Money) Major() string {
+	exp := m.Currency.Exponent()
+	sign := ""
+	u := uint64(m.Amount)
+	if m.Amount < 0 {
+		sign = "-"
+		u = uint64(-(m.Amount + 1)) + 1
+	}
+	s := strconv.FormatUint(u, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+func (m Money) String() string {
+	return m.Major() + " " + string(m.Currency)
+}
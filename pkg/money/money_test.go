@@ -0,0 +1,105 @@
+package money
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMajor(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     int64
+		wantErr  error
+	}{
+		{name: "whole amount", amount: "10", currency: "USD", want: 1000},
+		{name: "short fraction is padded", amount: "10.5", currency: "USD", want: 1050},
+		{name: "full fraction", amount: "0.01", currency: "USD", want: 1},
+		{name: "leading dot", amount: ".5", currency: "USD", want: 50},
+		{name: "negative", amount: "-2.25", currency: "EUR", want: -225},
+		{name: "zero-exponent currency", amount: "1500", currency: "JPY", want: 1500},
+		{name: "three-exponent currency", amount: "1.234", currency: "KWD", want: 1234},
+		{name: "four-exponent currency", amount: "1.2345", currency: "CLF", want: 12345},
+		{name: "lower-case currency", amount: "1", currency: "usd", want: 100},
+		{name: "too many decimals", amount: "10.505", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "fraction on zero-exponent currency", amount: "1.5", currency: "JPY", wantErr: ErrInvalidAmount},
+		{name: "empty", amount: "", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "not a number", amount: "1e3", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "double sign", amount: "--1", currency: "USD", wantErr: ErrInvalidAmount},
+		{name: "overflow", amount: "92233720368547758.08", currency: "USD", wantErr: ErrOverflow},
+		{name: "unknown currency", amount: "1", currency: "XXX", wantErr: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMajor(tt.amount, tt.currency)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Amount)
+		})
+	}
+}
+
+func TestAddSub_Overflow(t *testing.T) {
+	maxUSD := Money{Amount: math.MaxInt64, Currency: "USD"}
+	minUSD := Money{Amount: math.MinInt64, Currency: "USD"}
+	one := Money{Amount: 1, Currency: "USD"}
+
+	_, err := maxUSD.Add(one)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = minUSD.Sub(one)
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = maxUSD.Sub(Money{Amount: -1, Currency: "USD"})
+	assert.ErrorIs(t, err, ErrOverflow)
+	_, err = minUSD.Add(Money{Amount: -1, Currency: "USD"})
+	assert.ErrorIs(t, err, ErrOverflow)
+
+	sum, err := maxUSD.Add(Money{Amount: -1, Currency: "USD"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt64-1), sum.Amount)
+	diff, err := minUSD.Sub(Money{Amount: -1, Currency: "USD"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(math.MinInt64+1), diff.Amount)
+}
+
+func TestAddSub_CurrencyMismatch(t *testing.T) {
+	usd := Money{Amount: 100, Currency: "USD"}
+	eur := Money{Amount: 100, Currency: "EUR"}
+
+	_, err := usd.Add(eur)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+	_, err = usd.Sub(eur)
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestMajor(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{Money{Amount: 1050, Currency: "USD"}, "10.50"},
+		{Money{Amount: 5, Currency: "USD"}, "0.05"},
+		{Money{Amount: -5, Currency: "USD"}, "-0.05"},
+		{Money{Amount: 0, Currency: "USD"}, "0.00"},
+		{Money{Amount: 1500, Currency: "JPY"}, "1500"},
+		{Money{Amount: -1500, Currency: "JPY"}, "-1500"},
+		{Money{Amount: 1234, Currency: "KWD"}, "1.234"},
+		{Money{Amount: 7, Currency: "KWD"}, "0.007"},
+		{Money{Amount: 12345, Currency: "CLF"}, "1.2345"},
+		{Money{Amount: -1, Currency: "CLF"}, "-0.0001"},
+		{Money{Amount: math.MinInt64, Currency: "USD"}, "-92233720368547758.08"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+" "+string(tt.money.Currency), func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.money.Major())
+		})
+	}
+}
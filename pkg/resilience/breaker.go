@@ -0,0 +1,198 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"TestTaskJustPay/pkg/health"
+	"TestTaskJustPay/pkg/metrics"
+)
+
+// ErrCircuitOpen is returned without calling the protected operation while the breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	StateClosed State = iota
+	StateHalfOpen
+	StateOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig configures a circuit breaker.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker fails fast before letting a probe through.
+	OpenTimeout time.Duration
+	// HalfOpenMaxCalls is the number of concurrent probes allowed while half-open.
+	HalfOpenMaxCalls int
+	// IsFailure decides which errors count against the dependency. Nil counts every error;
+	// use it to ignore errors that say nothing about the dependency's health, such as 4xx responses.
+	IsFailure func(error) bool
+}
+
+// DefaultBreakerConfig returns sensible defaults.
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenMaxCalls: 1,
+	}
+}
+
+// CircuitBreaker fails fast while a dependency is unhealthy. It opens after
+// FailureThreshold consecutive failures, lets probes through after OpenTimeout and
+// closes again on the first successful probe.
+//
+// The breaker doubles as a health.Checker reporting down while open, and publishes
+// its state to the dpm_circuit_breaker_* metrics under its name.
+type CircuitBreaker struct {
+	name string
+	cfg  BreakerConfig
+	now  func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probes   int
+	// generation counts state changes. A call only records its outcome if the state has not
+	// changed since it was admitted, so a slow call cannot act on a later window.
+	generation uint64
+}
+
+// admission is what acquire hands a call so record can attribute its outcome.
+type admission struct {
+	generation uint64
+	probe      bool
+}
+
+// NewCircuitBreaker creates a closed breaker. name labels its metrics and health check.
+func NewCircuitBreaker(name string, cfg BreakerConfig) *CircuitBreaker {
+	b := &CircuitBreaker{name: name, cfg: cfg, now: time.Now}
+	metrics.CircuitBreakerState.WithLabelValues(name).Set(float64(StateClosed))
+	return b
+}
+
+// Execute runs fn unless the breaker is open, and records its outcome.
+func (b *CircuitBreaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	adm, err := b.acquire()
+	if err != nil {
+		return err
+	}
+
+	err = fn(ctx)
+	b.record(adm, err)
+	return err
+}
+
+// State returns the current state, moving an open breaker to half-open once OpenTimeout elapsed.
+func (b *CircuitBreaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+	return b.state
+}
+
+// Name returns the breaker name.
+func (b *CircuitBreaker) Name() string {
+	return b.name
+}
+
+// Check reports down while the breaker is open.
+func (b *CircuitBreaker) Check(_ context.Context) health.Result {
+	switch state := b.State(); state {
+	case StateOpen:
+		return health.Result{Status: health.StatusDown, Message: "circuit breaker open"}
+	case StateHalfOpen:
+		return health.Result{Status: health.StatusUp, Message: "circuit breaker half-open"}
+	default:
+		return health.Result{Status: health.StatusUp}
+	}
+}
+
+func (b *CircuitBreaker) acquire() (admission, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refresh()
+
+	adm := admission{generation: b.generation}
+	switch b.state {
+	case StateOpen:
+		metrics.CircuitBreakerRejectedTotal.WithLabelValues(b.name).Inc()
+		return admission{}, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= max(b.cfg.HalfOpenMaxCalls, 1) {
+			metrics.CircuitBreakerRejectedTotal.WithLabelValues(b.name).Inc()
+			return admission{}, ErrCircuitOpen
+		}
+		b.probes++
+		adm.probe = true
+	}
+	return adm, nil
+}
+
+func (b *CircuitBreaker) record(adm admission, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The state changed while the call ran: its probe slot, if any, was already reset and
+	// its outcome says nothing about the current window.
+	if adm.generation != b.generation {
+		return
+	}
+	if adm.probe {
+		b.probes = max(b.probes-1, 0)
+	}
+
+	failed := err != nil && (b.cfg.IsFailure == nil || b.cfg.IsFailure(err))
+	if !failed {
+		b.failures = 0
+		if b.state == StateHalfOpen {
+			b.setState(StateClosed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.cfg.FailureThreshold {
+		b.openedAt = b.now()
+		b.setState(StateOpen)
+	}
+}
+
+// refresh must be called with mu held.
+func (b *CircuitBreaker) refresh() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		b.setState(StateHalfOpen)
+	}
+}
+
+// setState must be called with mu held.
+func (b *CircuitBreaker) setState(s State) {
+	if b.state == s {
+		return
+	}
+	b.state = s
+	b.probes = 0
+	b.generation++
+	metrics.CircuitBreakerState.WithLabelValues(b.name).Set(float64(s))
+	metrics.CircuitBreakerTransitionsTotal.WithLabelValues(b.name, s.String()).Inc()
+}
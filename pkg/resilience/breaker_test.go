@@ -0,0 +1,84 @@
+package resilience
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDependency = errors.New("dependency failed")
+
+func newTestBreaker(cfg BreakerConfig) (*CircuitBreaker, *time.Time) {
+	now := time.Unix(0, 0)
+	b := NewCircuitBreaker("test", cfg)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	b, now := newTestBreaker(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute, HalfOpenMaxCalls: 1})
+
+	for range 2 {
+		adm, err := b.acquire()
+		require.NoError(t, err)
+		b.record(adm, errDependency)
+	}
+	assert.Equal(t, StateOpen, b.State())
+	_, err := b.acquire()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	*now = now.Add(time.Minute)
+	adm, err := b.acquire()
+	require.NoError(t, err)
+	assert.True(t, adm.probe)
+	b.record(adm, nil)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestCircuitBreaker_IgnoresStaleProbes(t *testing.T) {
+	b, now := newTestBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenMaxCalls: 2})
+
+	adm, err := b.acquire()
+	require.NoError(t, err)
+	b.record(adm, errDependency)
+	*now = now.Add(time.Minute)
+	require.Equal(t, StateHalfOpen, b.State())
+
+	first, err := b.acquire()
+	require.NoError(t, err)
+	second, err := b.acquire()
+	require.NoError(t, err)
+
+	// The first probe fails and reopens the breaker while the second is still running.
+	b.record(first, errDependency)
+	require.Equal(t, StateOpen, b.State())
+	b.record(second, nil)
+	assert.Equal(t, StateOpen, b.State(), "a probe from the previous window does not close the breaker")
+	assert.Equal(t, 0, b.probes)
+
+	// The next half-open window admits exactly HalfOpenMaxCalls probes.
+	*now = now.Add(time.Minute)
+	for range 2 {
+		_, err := b.acquire()
+		require.NoError(t, err)
+	}
+	_, err = b.acquire()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestCircuitBreaker_IgnoresCallsAdmittedBeforeOpening(t *testing.T) {
+	b, _ := newTestBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+
+	slow, err := b.acquire()
+	require.NoError(t, err)
+	failing, err := b.acquire()
+	require.NoError(t, err)
+
+	b.record(failing, errDependency)
+	b.record(slow, nil)
+
+	assert.Equal(t, StateOpen, b.State(), "a success admitted while closed does not reset the open breaker")
+}
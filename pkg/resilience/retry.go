@@ -0,0 +1,64 @@
+package resilience
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// RetryConfig configures retry with exponential backoff and jitter.
+type RetryConfig struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryConfig returns sensible defaults.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
+}
+
+// Retry calls fn until it succeeds, returns an error retryable rejects, or cfg.MaxAttempts
+// is reached, and returns the last error. A nil retryable retries every error.
+func Retry(ctx context.Context, cfg RetryConfig, retryable func(error) bool, fn func(ctx context.Context) error) error {
+	attempts := max(cfg.MaxAttempts, 1)
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		lastErr = fn(ctx)
+		if lastErr == nil {
+			return nil
+		}
+		if retryable != nil && !retryable(lastErr) {
+			return lastErr
+		}
+
+		// Don't wait after the last attempt
+		if attempt == attempts-1 {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(Backoff(attempt, cfg.InitialBackoff, cfg.MaxBackoff)):
+		}
+	}
+	return lastErr
+}
+
+// Backoff returns initial·2^attempt with ±25% jitter, capped at maxBackoff.
+func Backoff(attempt int, initial, maxBackoff time.Duration) time.Duration {
+	delay := float64(initial) * math.Pow(2, float64(attempt))
+	delay += delay * 0.25 * (rand.Float64()*2 - 1)
+
+	if delay > float64(maxBackoff) {
+		delay = float64(maxBackoff)
+	}
+	return time.Duration(delay)
+}
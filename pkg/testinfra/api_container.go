@@ -44,6 +44,7 @@ func NewAPIContainer(ctx context.Context, cfg APIContainerConfig) (*APIContainer
 		"SILVERGATE_AUTH_PATH":                 "/api/v1/auth",
 		"HTTP_SILVERGATE_CLIENT_TIMEOUT":       "20s",
 		"MERCHANT_ID":                          "merchant_e2e",
+		"MERCHANT_DEFAULT_FALLBACK":            "true",
 	}
 
 	// Add Kafka config when webhook processing is via Kafka
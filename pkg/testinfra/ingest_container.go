@@ -12,6 +12,12 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
+// Webhook signing secrets shared by the test containers and the tests that post to Ingest.
+const (
+	PaymentsWebhookSecret   = "whsec_test_payments"
+	SilvergateWebhookSecret = "whsec_test_silvergate"
+)
+
 // IngestContainer wraps a Docker container running the Ingest service.
 type IngestContainer struct {
 	Container testcontainers.Container
@@ -35,6 +41,9 @@ func NewIngestContainer(ctx context.Context, cfg IngestContainerConfig) (*Ingest
 		"LOG_LEVEL":    "debug",
 		"LOG_FORMAT":   "console",
 		"WEBHOOK_MODE": cfg.WebhookMode,
+
+		"PAYMENTS_WEBHOOK_SECRETS":   PaymentsWebhookSecret,
+		"SILVERGATE_WEBHOOK_SECRETS": SilvergateWebhookSecret,
 	}
 
 	switch cfg.WebhookMode {
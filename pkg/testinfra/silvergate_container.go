@@ -30,6 +30,10 @@ func NewSilvergateContainer(ctx context.Context, cfg SilvergateContainerConfig)
 		"SILVERGATE_PG_URL":            cfg.PgDSN,
 		"LOG_LEVEL":                    "debug",
 		"WEBHOOK_CALLBACK_URL":         cfg.WebhookCallbackURL,
+		"WEBHOOK_SIGNING_SECRETS":      SilvergateWebhookSecret,
+		"VAULT_KEYS":                   "k1:AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=",
+		"VAULT_ACTIVE_KEY_ID":          "k1",
+		"VAULT_FINGERPRINT_KEY":        "ICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8=",
 		"ACQUIRER_AUTH_APPROVE_RATE":   "0.85",
 		"ACQUIRER_SETTLE_SUCCESS_RATE": "0.90",
 		"ACQUIRER_SETTLE_DELAY":        "200ms",
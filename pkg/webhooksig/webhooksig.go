@@ -0,0 +1,93 @@
+// Package webhooksig signs and verifies webhook payloads.
+//
+// The signature header is "t=<unix seconds>,v1=<hex HMAC-SHA256(secret, "<t>.<body>")>".
+// While a secret is being rotated the sender signs with every active secret and the
+// header carries one v1 per secret; the receiver accepts the payload if any of them
+// matches any of its secrets.
+package webhooksig
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const Header = "X-Webhook-Signature"
+
+var (
+	ErrMissing   = errors.New("signature header missing")
+	ErrMalformed = errors.New("signature header malformed")
+	// ErrStale means the signature timestamp is outside the tolerance, which blocks
+	// replays of captured requests.
+	ErrStale    = errors.New("signature timestamp outside tolerance")
+	ErrMismatch = errors.New("signature mismatch")
+)
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// HeaderValue formats the signature header for body, signed with each of secrets.
+func HeaderValue(secrets []string, timestamp int64, body []byte) string {
+	var b strings.Builder
+	b.WriteString("t=" + strconv.FormatInt(timestamp, 10))
+	for _, secret := range secrets {
+		b.WriteString(",v1=" + Sign(secret, timestamp, body))
+	}
+	return b.String()
+}
+
+// Verify checks header against body. It fails with ErrStale when the timestamp is more
+// than tolerance away from now, and with ErrMismatch unless a v1 signature matches one
+// of secrets.
+func Verify(header string, body []byte, secrets []string, now time.Time, tolerance time.Duration) error {
+	if header == "" {
+		return ErrMissing
+	}
+
+	var (
+		timestamp  int64
+		signatures []string
+		err        error
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrMalformed
+		}
+		switch key {
+		case "t":
+			if timestamp, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return ErrMalformed
+			}
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrMalformed
+	}
+
+	if age := now.Sub(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return ErrStale
+	}
+
+	for _, secret := range secrets {
+		expected := []byte(Sign(secret, timestamp, body))
+		for _, sig := range signatures {
+			if hmac.Equal(expected, []byte(sig)) {
+				return nil
+			}
+		}
+	}
+	return ErrMismatch
+}
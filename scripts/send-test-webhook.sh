@@ -10,8 +10,16 @@ if [ -z "$INGEST_PORT" ]; then
     set +a
 fi
 
+if [ -z "$PAYMENTS_WEBHOOK_SECRETS" ]; then
+    set -a
+    source env/ingest.env 2>/dev/null || true
+    set +a
+fi
+
 STATUS=${1:-created}
 : ${INGEST_PORT:=3001}
+# Sign with the first active secret
+SECRET="${PAYMENTS_WEBHOOK_SECRETS%%,*}"
 
 # Generate random IDs
 ORDER_ID="$(uuidgen)"
@@ -27,9 +35,7 @@ echo "  order_id: $ORDER_ID"
 echo "  status: $STATUS"
 echo ""
 
-curl -s -X POST "$URL" \
-  -H "Content-Type: application/json" \
-  -d "{
+BODY="{
     \"provider_event_id\": \"$EVENT_ID\",
     \"order_id\": \"$ORDER_ID\",
     \"user_id\": \"$USER_ID\",
@@ -37,6 +43,13 @@ curl -s -X POST "$URL" \
     \"updated_at\": \"$NOW\",
     \"created_at\": \"$NOW\",
     \"meta\": {\"source\": \"test-script\"}
-  }" | jq . 2>/dev/null || cat
+  }"
+TS=$(date +%s)
+SIG=$(printf '%s.%s' "$TS" "$BODY" | openssl dgst -sha256 -hmac "$SECRET" | sed 's/^.* //')
+
+curl -s -X POST "$URL" \
+  -H "Content-Type: application/json" \
+  -H "X-Webhook-Signature: t=$TS,v1=$SIG" \
+  -d "$BODY" | jq . 2>/dev/null || cat
 
 echo ""
@@ -38,6 +38,11 @@ func Run(cfg config.Config) {
 	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer cancel()
 
+	if len(cfg.PaymentsWebhookSecrets) == 0 || len(cfg.SilvergateWebhookSecrets) == 0 {
+		slog.Error("PAYMENTS_WEBHOOK_SECRETS and SILVERGATE_WEBHOOK_SECRETS are required to verify webhook signatures")
+		os.Exit(1)
+	}
+
 	// Setup Gin engine
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
@@ -156,7 +161,9 @@ func Run(cfg config.Config) {
 	healthRegistry := health.NewRegistry(healthCheckers...)
 
 	// Webhook-only routes
-	router := NewRouter(orderHandler, chargebackHandler, paymentHandler, healthRegistry)
+	router := NewRouter(orderHandler, chargebackHandler, paymentHandler, healthRegistry,
+		handlers.VerifySignature("payments", cfg.PaymentsWebhookSecrets, cfg.WebhookSignatureTolerance),
+		handlers.VerifySignature("silvergate", cfg.SilvergateWebhookSecrets, cfg.WebhookSignatureTolerance))
 	router.SetUp(engine)
 
 	// Start HTTP server
@@ -14,6 +14,13 @@ type Config struct {
 	// Webhook processing mode: "kafka" (async via Kafka) or "http" (sync via HTTP to API)
 	WebhookMode string `env:"WEBHOOK_MODE" envDefault:"kafka"`
 
+	// Webhook signatures. Each provider signs with X-Webhook-Signature; a webhook is accepted when
+	// it verifies against one of the provider's secrets and is at most WEBHOOK_SIGNATURE_TOLERANCE old.
+	// To rotate, add the new secret here and at the provider, then drop the old one from both.
+	SilvergateWebhookSecrets  []string      `env:"SILVERGATE_WEBHOOK_SECRETS" envSeparator:","`
+	PaymentsWebhookSecrets    []string      `env:"PAYMENTS_WEBHOOK_SECRETS" envSeparator:","`
+	WebhookSignatureTolerance time.Duration `env:"WEBHOOK_SIGNATURE_TOLERANCE" envDefault:"5m"`
+
 	// Kafka configuration (required for kafka mode)
 	KafkaBrokers       []string `env:"KAFKA_BROKERS" envSeparator:","`
 	KafkaOrdersTopic   string   `env:"KAFKA_ORDERS_TOPIC" envDefault:"webhooks.orders"`
@@ -1,16 +1,59 @@
 package dto
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/money"
+)
+
+// ChargebackWebhook is the chargeback payload as the provider sends it. Amount is a decimal
+// in major units of Currency and is kept verbatim until it is converted to minor units.
+type ChargebackWebhook struct {
+	ProviderEventID string            `json:"provider_event_id"`
+	OrderID         string            `json:"order_id"`
+	UserID          string            `json:"user_id"`
+	Status          string            `json:"status"`
+	Reason          string            `json:"reason"`
+	Amount          json.Number       `json:"amount"`
+	Currency        string            `json:"currency"`
+	OccurredAt      time.Time         `json:"occurred_at"`
+	EvidenceDueAt   *time.Time        `json:"evidence_due_at,omitempty"`
+	Meta            map[string]string `json:"meta,omitempty"`
+}
+
+// DisputeUpdate converts the webhook into the update forwarded to the API service, with
+// the amount in minor units and without float rounding.
+func (w ChargebackWebhook) DisputeUpdate() (DisputeUpdateRequest, error) {
+	m, err := money.ParseMajor(w.Amount.String(), w.Currency)
+	if err != nil {
+		return DisputeUpdateRequest{}, fmt.Errorf("convert amount: %w", err)
+	}
+	return DisputeUpdateRequest{
+		ProviderEventID: w.ProviderEventID,
+		OrderID:         w.OrderID,
+		UserID:          w.UserID,
+		Status:          w.Status,
+		Reason:          w.Reason,
+		Amount:          m.Amount,
+		Currency:        m.Currency.String(),
+		OccurredAt:      w.OccurredAt,
+		EvidenceDueAt:   w.EvidenceDueAt,
+		Meta:            w.Meta,
+	}, nil
+}
 
 // DisputeUpdateRequest represents a dispute/chargeback update request from Ingest to API service.
-// It mirrors dispute.ChargebackWebhook but is decoupled from domain types.
+// It mirrors dispute.ChargebackWebhook but is decoupled from domain types. Amount is in
+// minor units of Currency.
 type DisputeUpdateRequest struct {
 	ProviderEventID string            `json:"provider_event_id"`
 	OrderID         string            `json:"order_id"`
 	UserID          string            `json:"user_id"`
 	Status          string            `json:"status"`
 	Reason          string            `json:"reason"`
-	Amount          float64           `json:"amount"`
+	Amount          int64             `json:"amount"`
 	Currency        string            `json:"currency"`
 	OccurredAt      time.Time         `json:"occurred_at"`
 	EvidenceDueAt   *time.Time        `json:"evidence_due_at,omitempty"`
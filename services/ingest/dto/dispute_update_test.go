@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChargebackWebhook_DisputeUpdate(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{"two decimals", "10.50", "USD", 1050, false},
+		{"no float rounding", "19.99", "EUR", 1999, false},
+		{"zero-decimal currency", "1000", "JPY", 1000, false},
+		{"three-decimal currency", "1.234", "KWD", 1234, false},
+		{"too many decimals", "1.234", "USD", 0, true},
+		{"unknown currency", "10", "XXX", 0, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := ChargebackWebhook{OrderID: "order_1", Amount: json.Number(tc.amount), Currency: tc.currency}
+
+			req, err := w.DisputeUpdate()
+
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, req.Amount)
+			assert.Equal(t, "order_1", req.OrderID)
+		})
+	}
+}
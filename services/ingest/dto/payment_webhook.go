@@ -1,13 +1,19 @@
 package dto
 
 type PaymentWebhookRequest struct {
-	Event         string `json:"event"`
-	TransactionID string `json:"transaction_id"`
-	RefundID      string `json:"refund_id,omitempty"`
-	OrderID       string `json:"order_id"`
-	MerchantID    string `json:"merchant_id"`
-	Status        string `json:"status"`
-	Amount        int64  `json:"amount"`
-	Currency      string `json:"currency"`
-	Timestamp     string `json:"timestamp"`
+	// Provider is taken from the ?provider= query parameter; empty for the default provider.
+	Provider       string `json:"provider,omitempty"`
+	Event          string `json:"event"`
+	TransactionID  string `json:"transaction_id"`
+	RefundID       string `json:"refund_id,omitempty"`
+	CaptureID      string `json:"capture_id,omitempty"`
+	OrderID        string `json:"order_id"`
+	MerchantID     string `json:"merchant_id"`
+	Status         string `json:"status"`
+	Amount         int64  `json:"amount"`
+	CapturedAmount int64  `json:"captured_amount"`
+	FinalCapture   bool   `json:"final_capture,omitempty"`
+	Currency       string `json:"currency"`
+	DeclineReason  string `json:"decline_reason,omitempty"`
+	Timestamp      string `json:"timestamp"`
 }
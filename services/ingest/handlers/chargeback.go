@@ -19,13 +19,18 @@ func NewChargebackHandler(p webhook.Processor) *ChargebackHandler {
 }
 
 func (h *ChargebackHandler) Webhook(c *gin.Context) {
-	var req dto.DisputeUpdateRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var payload dto.ChargebackWebhook
+	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid payload"})
 		return
 	}
+	req, err := payload.DisputeUpdate()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
 
-	err := h.processor.ProcessDisputeUpdate(c.Request.Context(), req)
+	err = h.processor.ProcessDisputeUpdate(c.Request.Context(), req)
 	if err != nil {
 		if errors.Is(err, apiclient.ErrInvalidStatus) {
 			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
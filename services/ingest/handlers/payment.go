@@ -23,6 +23,7 @@ func (h *PaymentHandler) Webhook(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid payload"})
 		return
 	}
+	req.Provider = c.Query("provider")
 
 	if err := h.processor.ProcessPaymentWebhook(c.Request.Context(), req); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"TestTaskJustPay/pkg/metrics"
+	"TestTaskJustPay/pkg/webhooksig"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBody caps how much of an unauthenticated request is read before its
+// signature is checked.
+const maxWebhookBody = 1 << 20
+
+// VerifySignature rejects webhooks whose X-Webhook-Signature does not verify against one
+// of the provider's secrets or whose timestamp is more than tolerance old, before the
+// body reaches the handler. Failures are counted per provider and reason.
+func VerifySignature(provider string, secrets []string, tolerance time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBody))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Payload too large"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "Invalid payload"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		err = webhooksig.Verify(c.GetHeader(webhooksig.Header), body, secrets, time.Now(), tolerance)
+		if err != nil {
+			reason := failureReason(err)
+			metrics.WebhookSignatureFailuresTotal.WithLabelValues(provider, reason).Inc()
+			slog.WarnContext(c.Request.Context(), "Webhook signature rejected",
+				"provider", provider, "reason", reason)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid signature"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func failureReason(err error) string {
+	switch {
+	case errors.Is(err, webhooksig.ErrMissing):
+		return "missing"
+	case errors.Is(err, webhooksig.ErrMalformed):
+		return "malformed"
+	case errors.Is(err, webhooksig.ErrStale):
+		return "stale"
+	default:
+		return "mismatch"
+	}
+}
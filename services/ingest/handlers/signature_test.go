@@ -0,0 +1,93 @@
+//go:build !integration
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/pkg/metrics"
+	"TestTaskJustPay/pkg/webhooksig"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const testBody = `{"order_id":"ord_1","status":"created"}`
+
+func newSignedEngine(secrets []string) (*gin.Engine, *bool) {
+	gin.SetMode(gin.TestMode)
+	reached := false
+	engine := gin.New()
+	engine.POST("/webhooks/test", VerifySignature("test", secrets, 5*time.Minute), func(c *gin.Context) {
+		reached = true
+		c.Status(http.StatusAccepted)
+	})
+	return engine, &reached
+}
+
+func post(engine *gin.Engine, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/test", strings.NewReader(testBody))
+	if signature != "" {
+		req.Header.Set(webhooksig.Header, signature)
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestVerifySignature_AcceptsValidSignature(t *testing.T) {
+	engine, reached := newSignedEngine([]string{"whsec_new", "whsec_old"})
+
+	// Signed only with the old secret while it is still active during rotation.
+	rec := post(engine, webhooksig.HeaderValue([]string{"whsec_old"}, time.Now().Unix(), []byte(testBody)))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.True(t, *reached)
+}
+
+func TestVerifySignature_Rejects(t *testing.T) {
+	now := time.Now().Unix()
+	tests := []struct {
+		name      string
+		signature string
+		reason    string
+	}{
+		{name: "missing header", signature: "", reason: "missing"},
+		{name: "malformed header", signature: "garbage", reason: "malformed"},
+		{name: "stale timestamp", signature: webhooksig.HeaderValue([]string{"whsec_new"}, now-3600, []byte(testBody)), reason: "stale"},
+		{name: "wrong secret", signature: webhooksig.HeaderValue([]string{"whsec_other"}, now, []byte(testBody)), reason: "mismatch"},
+		{name: "tampered body", signature: "t=" + strconv.FormatInt(now, 10) + ",v1=" + webhooksig.Sign("whsec_new", now, []byte(`{}`)), reason: "mismatch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine, reached := newSignedEngine([]string{"whsec_new"})
+			before := testutil.ToFloat64(metrics.WebhookSignatureFailuresTotal.WithLabelValues("test", tt.reason))
+
+			rec := post(engine, tt.signature)
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.False(t, *reached)
+			assert.Equal(t, before+1, testutil.ToFloat64(metrics.WebhookSignatureFailuresTotal.WithLabelValues("test", tt.reason)))
+		})
+	}
+}
+
+func TestVerifySignature_RejectsOversizedBody(t *testing.T) {
+	engine, reached := newSignedEngine([]string{"whsec_new"})
+	body := strings.Repeat("x", maxWebhookBody+1)
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/test", strings.NewReader(body))
+	req.Header.Set(webhooksig.Header, webhooksig.HeaderValue([]string{"whsec_new"}, time.Now().Unix(), []byte(body)))
+	rec := httptest.NewRecorder()
+
+	engine.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.False(t, *reached)
+}
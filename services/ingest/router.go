@@ -14,6 +14,9 @@ type Router struct {
 	chargeback     *handlers.ChargebackHandler
 	payment        *handlers.PaymentHandler
 	healthRegistry *health.Registry
+
+	verifyPayments   gin.HandlerFunc
+	verifySilvergate gin.HandlerFunc
 }
 
 func (r *Router) SetUp(engine *gin.Engine) {
@@ -23,17 +26,25 @@ func (r *Router) SetUp(engine *gin.Engine) {
 
 	engine.GET("/metrics", gin.WrapH(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
 
-	// Webhook endpoints only
-	engine.POST("/webhooks/payments/orders", r.order.Webhook)
-	engine.POST("/webhooks/payments/chargebacks", r.chargeback.Webhook)
-	engine.POST("/webhooks/silvergate", r.payment.Webhook)
+	// Webhook endpoints only; signatures are verified before the processor sees the payload
+	engine.POST("/webhooks/payments/orders", r.verifyPayments, r.order.Webhook)
+	engine.POST("/webhooks/payments/chargebacks", r.verifyPayments, r.chargeback.Webhook)
+	engine.POST("/webhooks/silvergate", r.verifySilvergate, r.payment.Webhook)
 }
 
-func NewRouter(order *handlers.OrderHandler, chargeback *handlers.ChargebackHandler, payment *handlers.PaymentHandler, healthRegistry *health.Registry) *Router {
+func NewRouter(
+	order *handlers.OrderHandler,
+	chargeback *handlers.ChargebackHandler,
+	payment *handlers.PaymentHandler,
+	healthRegistry *health.Registry,
+	verifyPayments, verifySilvergate gin.HandlerFunc,
+) *Router {
 	return &Router{
-		order:          order,
-		chargeback:     chargeback,
-		payment:        payment,
-		healthRegistry: healthRegistry,
+		order:            order,
+		chargeback:       chargeback,
+		payment:          payment,
+		healthRegistry:   healthRegistry,
+		verifyPayments:   verifyPayments,
+		verifySilvergate: verifySilvergate,
 	}
 }
@@ -101,7 +101,7 @@ func TestHTTPSyncProcessor_ProcessDisputeUpdate(t *testing.T) {
 			UserID:          "user-YYY",
 			Status:          "opened",
 			Reason:          "fraud",
-			Amount:          250.75,
+			Amount:          25075,
 			Currency:        "USD",
 			OccurredAt:      now,
 			EvidenceDueAt:   &dueAt,
@@ -118,7 +118,7 @@ func TestHTTPSyncProcessor_ProcessDisputeUpdate(t *testing.T) {
 		assert.Equal(t, "user-YYY", mock.lastDisputeReq.UserID)
 		assert.Equal(t, "opened", mock.lastDisputeReq.Status)
 		assert.Equal(t, "fraud", mock.lastDisputeReq.Reason)
-		assert.Equal(t, 250.75, mock.lastDisputeReq.Amount)
+		assert.Equal(t, int64(25075), mock.lastDisputeReq.Amount)
 		assert.Equal(t, "USD", mock.lastDisputeReq.Currency)
 		assert.Equal(t, now, mock.lastDisputeReq.OccurredAt)
 		assert.Equal(t, &dueAt, mock.lastDisputeReq.EvidenceDueAt)
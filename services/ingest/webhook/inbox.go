@@ -60,7 +60,7 @@ func (p *InboxProcessor) ProcessPaymentWebhook(ctx context.Context, req dto.Paym
 	}
 
 	err = p.repo.Store(ctx, inbox.NewInboxMessage{
-		IdempotencyKey: "payment_webhook:" + req.TransactionID + ":" + req.Event,
+		IdempotencyKey: paymentWebhookKey(req),
 		WebhookType:    "payment_webhook",
 		Payload:        payload,
 	})
@@ -69,3 +69,21 @@ func (p *InboxProcessor) ProcessPaymentWebhook(ctx context.Context, req dto.Paym
 	}
 	return err
 }
+
+// paymentWebhookKey identifies a payment webhook for deduplication. Transaction ids are only
+// unique per provider, and a transaction can be partially captured or refunded several times,
+// so the capture or refund id is part of the key when present.
+func paymentWebhookKey(req dto.PaymentWebhookRequest) string {
+	key := "payment_webhook:"
+	if req.Provider != "" {
+		key += req.Provider + ":"
+	}
+	key += req.TransactionID + ":" + req.Event
+	if req.CaptureID != "" {
+		key += ":capture:" + req.CaptureID
+	}
+	if req.RefundID != "" {
+		key += ":refund:" + req.RefundID
+	}
+	return key
+}
@@ -131,3 +131,73 @@ func TestInboxProcessor_ProcessDisputeUpdate(t *testing.T) {
 		assert.NoError(t, err)
 	})
 }
+
+// dedupInboxRepo mimics the unique idempotency_key constraint of the inbox table.
+type dedupInboxRepo struct {
+	mockInboxRepo
+	stored map[string]inbox.NewInboxMessage
+}
+
+func (m *dedupInboxRepo) Store(_ context.Context, msg inbox.NewInboxMessage) error {
+	if _, ok := m.stored[msg.IdempotencyKey]; ok {
+		return inbox.ErrAlreadyExists
+	}
+	m.stored[msg.IdempotencyKey] = msg
+	return nil
+}
+
+func TestInboxProcessor_ProcessPaymentWebhook(t *testing.T) {
+	t.Run("keeps each partial capture of a transaction", func(t *testing.T) {
+		repo := &dedupInboxRepo{stored: map[string]inbox.NewInboxMessage{}}
+		processor := NewInboxProcessor(repo)
+
+		for _, captureID := range []string{"cap-1", "cap-2"} {
+			err := processor.ProcessPaymentWebhook(context.Background(), dto.PaymentWebhookRequest{
+				Provider:       "silvergate",
+				Event:          "transaction.partially_captured",
+				TransactionID:  "txn-1",
+				CaptureID:      captureID,
+				Status:         "partially_captured",
+				Amount:         1000,
+				CapturedAmount: 400,
+			})
+			require.NoError(t, err)
+		}
+
+		assert.Len(t, repo.stored, 2)
+		assert.Contains(t, repo.stored, "payment_webhook:silvergate:txn-1:transaction.partially_captured:capture:cap-1")
+		assert.Contains(t, repo.stored, "payment_webhook:silvergate:txn-1:transaction.partially_captured:capture:cap-2")
+	})
+
+	t.Run("keeps each refund of a transaction", func(t *testing.T) {
+		repo := &dedupInboxRepo{stored: map[string]inbox.NewInboxMessage{}}
+		processor := NewInboxProcessor(repo)
+
+		for _, refundID := range []string{"ref-1", "ref-2"} {
+			err := processor.ProcessPaymentWebhook(context.Background(), dto.PaymentWebhookRequest{
+				Event:         "transaction.refunded",
+				TransactionID: "txn-1",
+				RefundID:      refundID,
+			})
+			require.NoError(t, err)
+		}
+
+		assert.Len(t, repo.stored, 2)
+	})
+
+	t.Run("drops a redelivered webhook", func(t *testing.T) {
+		repo := &dedupInboxRepo{stored: map[string]inbox.NewInboxMessage{}}
+		processor := NewInboxProcessor(repo)
+		req := dto.PaymentWebhookRequest{
+			Event:         "transaction.partially_captured",
+			TransactionID: "txn-1",
+			CaptureID:     "cap-1",
+		}
+
+		require.NoError(t, processor.ProcessPaymentWebhook(context.Background(), req))
+		require.NoError(t, processor.ProcessPaymentWebhook(context.Background(), req))
+
+		assert.Len(t, repo.stored, 1)
+		assert.Contains(t, repo.stored, "payment_webhook:txn-1:transaction.partially_captured:capture:cap-1")
+	})
+}
@@ -47,7 +47,7 @@ func disputePayload(t *testing.T) json.RawMessage {
 		UserID:          "user_001",
 		Status:          "open",
 		Reason:          "fraud",
-		Amount:          100.0,
+		Amount:          10000,
 		Currency:        "USD",
 		OccurredAt:      time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
 	}
@@ -14,17 +14,32 @@ import (
 	"TestTaskJustPay/pkg/health"
 	"TestTaskJustPay/pkg/logger"
 	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/pkg/resilience"
 	"TestTaskJustPay/services/paymanager/config"
+	"TestTaskJustPay/services/paymanager/internal/adminauth"
 	"TestTaskJustPay/services/paymanager/internal/dispute"
 	"TestTaskJustPay/services/paymanager/internal/dispute/disputecontroller"
 	"TestTaskJustPay/services/paymanager/internal/dispute/disputerepo"
 	"TestTaskJustPay/services/paymanager/internal/eventstore"
+	"TestTaskJustPay/services/paymanager/internal/idempotency"
+	"TestTaskJustPay/services/paymanager/internal/merchant"
+	"TestTaskJustPay/services/paymanager/internal/merchant/merchantrepo"
+	"TestTaskJustPay/services/paymanager/internal/merchantauth"
+	"TestTaskJustPay/services/paymanager/internal/notification"
+	"TestTaskJustPay/services/paymanager/internal/notification/notificationcontroller"
+	"TestTaskJustPay/services/paymanager/internal/notification/notificationrepo"
 	"TestTaskJustPay/services/paymanager/internal/order"
 	"TestTaskJustPay/services/paymanager/internal/order/ordercontroller"
 	"TestTaskJustPay/services/paymanager/internal/order/orderrepo"
 	"TestTaskJustPay/services/paymanager/internal/payment"
 	"TestTaskJustPay/services/paymanager/internal/payment/paymentcontroller"
 	"TestTaskJustPay/services/paymanager/internal/payment/paymentrepo"
+	"TestTaskJustPay/services/paymanager/internal/reconciliation"
+	"TestTaskJustPay/services/paymanager/internal/reconciliation/reconciliationcontroller"
+	"TestTaskJustPay/services/paymanager/internal/reconciliation/reconciliationrepo"
+	"TestTaskJustPay/services/paymanager/internal/risk"
+	"TestTaskJustPay/services/paymanager/internal/risk/riskrepo"
+	"TestTaskJustPay/services/paymanager/internal/routing"
 	"TestTaskJustPay/services/paymanager/internal/silvergateclient"
 )
 
@@ -68,15 +83,55 @@ func Run(cfg config.Config) {
 	disputeRepo := disputerepo.New(pool, readDB)
 	disputeEvents := disputerepo.NewEventSink(pool.Pool, readDB, pool.Builder)
 	paymentRepo := paymentrepo.New(pool, readDB)
+	refundRepo := paymentrepo.NewRefundRepo(pool, readDB)
+	merchantRepo := merchantrepo.New(pool)
+	notificationRepo := notificationrepo.New(pool, readDB)
 
-	silvergateClient := silvergateclient.New(
-		cfg.SilvergateBaseURL,
-		cfg.SilvergateSubmitRepresentmentPath,
-		cfg.SilvergateCapturePath,
-		cfg.SilvergateAuthPath,
-		cfg.SilvergateVoidPath,
-		&http.Client{Timeout: cfg.HTTPSilvergateClientTimeout},
-	)
+	merchantService := merchant.NewMerchantService(merchantRepo, cfg.MerchantID)
+
+	// Payment providers: orders and disputes stay on the primary Silvergate instance;
+	// payments are routed per PROVIDER_ROUTING_RULES and remember the provider they used.
+	silvergateClient := newSilvergateClient(cfg, silvergateclient.DefaultName, cfg.SilvergateBaseURL, merchantService)
+	providerClients := []*silvergateclient.Client{silvergateClient}
+	if cfg.SilvergateSecondaryBaseURL != "" {
+		providerClients = append(providerClients,
+			newSilvergateClient(cfg, cfg.SilvergateSecondaryName, cfg.SilvergateSecondaryBaseURL, merchantService))
+	}
+
+	routingRules, err := routing.ParseRules(cfg.ProviderRoutingRules)
+	if err != nil {
+		slog.Error("Invalid provider routing rules", slog.Any("error", err))
+		os.Exit(1)
+	}
+	paymentProviders := make(map[string]payment.Provider, len(providerClients))
+	reconciliationProviders := make(map[string]reconciliation.Provider, len(providerClients))
+	for _, c := range providerClients {
+		paymentProviders[c.Name] = c
+		reconciliationProviders[c.Name] = c
+	}
+	paymentRouter, err := routing.New(silvergateclient.DefaultName, paymentProviders, routingRules)
+	if err != nil {
+		slog.Error("Invalid provider routing configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+	reconciliationRouter, err := routing.New(silvergateclient.DefaultName, reconciliationProviders, routingRules)
+	if err != nil {
+		slog.Error("Invalid provider routing configuration", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	// Risk engine: rules come from RISK_RULES_PATH and are hot-reloaded; without a file
+	// every payment is allowed, but decisions are still recorded for velocity rules.
+	var riskRules []risk.Rule
+	var riskRulesRaw []byte
+	if cfg.RiskRulesPath != "" {
+		riskRules, riskRulesRaw, err = risk.LoadRulesFile(cfg.RiskRulesPath)
+		if err != nil {
+			slog.Error("Invalid risk rules", slog.Any("error", err))
+			os.Exit(1)
+		}
+	}
+	riskEngine := risk.NewEngine(pool, riskrepo.TxRepoFactory(pool.Builder), riskRules)
 
 	// Event store factory (shared across services)
 	eventStoreFactory := eventstore.TxStoreFactory(pool.Builder)
@@ -89,6 +144,7 @@ func Run(cfg config.Config) {
 		orderRepo,
 		silvergateClient,
 		orderEvents,
+		merchantService,
 	)
 	disputeService := dispute.NewDisputeService(
 		pool,
@@ -102,19 +158,71 @@ func Run(cfg config.Config) {
 		pool,
 		paymentrepo.TxRepoFactory(pool.Builder),
 		eventStoreFactory,
+		paymentrepo.RefundTxRepoFactory(pool.Builder),
 		paymentRepo,
-		silvergateClient,
-		cfg.MerchantID,
+		refundRepo,
+		eventstore.NewPgEventStore(readDB, pool.Builder),
+		paymentRouter,
+		riskEngine,
+	)
+
+	captureScheduler := payment.NewCaptureScheduler(
+		paymentrepo.NewCaptureRepo(pool),
+		paymentRouter,
+		payment.CaptureSchedulerConfig{
+			PollInterval: cfg.CaptureSchedulerPollInterval,
+			BatchSize:    cfg.CaptureSchedulerBatchSize,
+			LeaseTimeout: cfg.CaptureSchedulerLeaseTimeout,
+			MaxAttempts:  cfg.CaptureSchedulerMaxAttempts,
+			RetryBackoff: cfg.CaptureSchedulerRetryBackoff,
+		},
+	)
+
+	challengeExpirer := payment.NewChallengeExpirer(paymentService, payment.ChallengeExpirerConfig{
+		PollInterval: cfg.ChallengeExpirerPollInterval,
+		BatchSize:    cfg.ChallengeExpirerBatchSize,
+		TTL:          cfg.PaymentChallengeTTL,
+	})
+
+	reconciler := reconciliation.NewReconciler(
+		reconciliationrepo.New(pool, readDB),
+		reconciliationRouter,
+		paymentService,
+		reconciliation.Config{
+			Interval:   cfg.ReconciliationInterval,
+			BatchSize:  cfg.ReconciliationBatchSize,
+			StaleAfter: cfg.ReconciliationStaleAfter,
+		},
+	)
+
+	notificationService := notification.NewNotificationService(notificationRepo)
+	webhookDispatcher := notification.NewDispatcher(
+		notificationRepo,
+		notification.NewHTTPClient(cfg.NotificationHTTPTimeout),
+		notification.DispatcherConfig{
+			PollInterval: cfg.NotificationPollInterval,
+			BatchSize:    cfg.NotificationBatchSize,
+			SettleDelay:  cfg.NotificationSettleDelay,
+			LeaseTimeout: cfg.NotificationLeaseTimeout,
+			MaxAttempts:  cfg.NotificationMaxAttempts,
+			RetryBackoff: cfg.NotificationRetryBackoff,
+			MaxBackoff:   cfg.NotificationMaxBackoff,
+		},
 	)
 
 	// Handlers
 	orderH := ordercontroller.NewHTTPHandler(orderService)
 	disputeH := disputecontroller.NewHTTPHandler(disputeService)
 	paymentH := paymentcontroller.NewHTTPHandler(paymentService)
+	reconciliationH := reconciliationcontroller.NewHTTPHandler(reconciler)
+	notificationH := notificationcontroller.NewHTTPHandler(notificationService)
 
 	// Health checks
 	var healthCheckers []health.Checker
 	healthCheckers = append(healthCheckers, health.NewPostgresChecker(pool.Pool))
+	for _, c := range providerClients {
+		healthCheckers = append(healthCheckers, c.Breaker)
+	}
 	if readPG != nil {
 		healthCheckers = append(healthCheckers, health.NewPostgresChecker(readPG.Pool))
 	}
@@ -123,8 +231,22 @@ func Run(cfg config.Config) {
 	}
 	healthRegistry := health.NewRegistry(healthCheckers...)
 
+	merchantAuthMW := merchantauth.Middleware(merchantService, merchantauth.Config{
+		DefaultMerchantID:    cfg.MerchantID,
+		AllowDefaultMerchant: cfg.MerchantDefaultFallback,
+		TrustMerchantHeader:  cfg.MerchantHeaderTrusted,
+	})
+	idempotencyMW := idempotency.Middleware(
+		idempotency.NewPgStore(pool.Pool, pool.Builder),
+		idempotency.Config{
+			LockTimeout: cfg.IdempotencyLockTimeout,
+		},
+	)
+
+	adminAuthMW := adminauth.Middleware(cfg.AdminAPIKey)
+
 	// Routers
-	router := NewRouter(orderH, disputeH, paymentH, healthRegistry)
+	router := NewRouter(orderH, disputeH, paymentH, reconciliationH, notificationH, healthRegistry, merchantAuthMW, idempotencyMW, adminAuthMW)
 	router.SetUp(engine)
 
 	internalRouter := NewInternalRouter(orderH, disputeH, paymentH)
@@ -143,6 +265,14 @@ func Run(cfg config.Config) {
 		StartWorkers(ctx, cfg, orderService, disputeService, paymentService)
 	}
 
+	StartCaptureScheduler(ctx, captureScheduler)
+	StartChallengeExpirer(ctx, challengeExpirer)
+	if cfg.RiskRulesPath != "" {
+		StartRiskRulesReloader(ctx, risk.NewReloader(riskEngine, cfg.RiskRulesPath, cfg.RiskRulesReloadInterval, riskRulesRaw))
+	}
+	StartReconciler(ctx, reconciler)
+	StartWebhookDispatcher(ctx, webhookDispatcher)
+
 	go func() {
 		slog.Info("Starting API HTTP server", "port", cfg.Port)
 		if err := engine.Run(fmt.Sprintf(":%d", cfg.Port)); err != nil {
@@ -154,9 +284,46 @@ func Run(cfg config.Config) {
 	slog.Info("Shutting down API service gracefully...")
 }
 
+// newSilvergateClient builds the client for one Silvergate instance. Instances share
+// paths, timeouts and retry settings but each has its own circuit breaker.
+func newSilvergateClient(cfg config.Config, name, baseURL string, credentials silvergateclient.CredentialsResolver) *silvergateclient.Client {
+	c := silvergateclient.New(
+		baseURL,
+		cfg.SilvergateSubmitRepresentmentPath,
+		cfg.SilvergateCapturePath,
+		cfg.SilvergateAuthPath,
+		cfg.SilvergateVoidPath,
+		cfg.SilvergateTransactionsPath,
+		&http.Client{Timeout: cfg.HTTPSilvergateClientTimeout},
+	)
+	c.Name = name
+	c.Credentials = credentials
+	c.Timeouts = silvergateclient.Timeouts{
+		Auth:          cfg.SilvergateAuthTimeout,
+		Capture:       cfg.SilvergateCaptureTimeout,
+		Void:          cfg.SilvergateVoidTimeout,
+		Refund:        cfg.SilvergateRefundTimeout,
+		Representment: cfg.SilvergateRepresentmentTimeout,
+		Transactions:  cfg.SilvergateTransactionsTimeout,
+	}
+	c.Retry = resilience.RetryConfig{
+		MaxAttempts:    cfg.SilvergateRetryMaxAttempts,
+		InitialBackoff: cfg.SilvergateRetryInitialBackoff,
+		MaxBackoff:     cfg.SilvergateRetryMaxBackoff,
+	}
+	c.Breaker = silvergateclient.NewBreaker(name, resilience.BreakerConfig{
+		FailureThreshold: cfg.SilvergateBreakerFailureThreshold,
+		OpenTimeout:      cfg.SilvergateBreakerOpenTimeout,
+		HalfOpenMaxCalls: 1,
+	})
+	return c
+}
+
 // Compile-time checks: silvergate client must satisfy all domain Provider interfaces.
 var (
 	_ order.Provider   = (*silvergateclient.Client)(nil)
 	_ dispute.Provider = (*silvergateclient.Client)(nil)
 	_ payment.Provider = (*silvergateclient.Client)(nil)
+
+	_ reconciliation.Provider = (*silvergateclient.Client)(nil)
 )
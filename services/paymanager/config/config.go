@@ -19,9 +19,74 @@ type Config struct {
 	SilvergateCapturePath             string        `env:"SILVERGATE_CAPTURE_PATH" required:"true"`
 	SilvergateAuthPath                string        `env:"SILVERGATE_AUTH_PATH" envDefault:"/api/v1/auth"`
 	SilvergateVoidPath                string        `env:"SILVERGATE_VOID_PATH" envDefault:"/api/v1/void"`
+	SilvergateTransactionsPath        string        `env:"SILVERGATE_TRANSACTIONS_PATH" envDefault:"/api/v1/transactions"`
 	HTTPSilvergateClientTimeout       time.Duration `env:"HTTP_SILVERGATE_CLIENT_TIMEOUT" envDefault:"20s"`
 
-	MerchantID string `env:"MERCHANT_ID" envDefault:"merchant_1"`
+	// Silvergate client resilience: per-attempt timeouts by operation, retries for idempotent
+	// operations (capture, refund, void, transaction queries) and a circuit breaker
+	SilvergateAuthTimeout             time.Duration `env:"SILVERGATE_AUTH_TIMEOUT" envDefault:"10s"`
+	SilvergateCaptureTimeout          time.Duration `env:"SILVERGATE_CAPTURE_TIMEOUT" envDefault:"10s"`
+	SilvergateVoidTimeout             time.Duration `env:"SILVERGATE_VOID_TIMEOUT" envDefault:"10s"`
+	SilvergateRefundTimeout           time.Duration `env:"SILVERGATE_REFUND_TIMEOUT" envDefault:"10s"`
+	SilvergateRepresentmentTimeout    time.Duration `env:"SILVERGATE_REPRESENTMENT_TIMEOUT" envDefault:"20s"`
+	SilvergateTransactionsTimeout     time.Duration `env:"SILVERGATE_TRANSACTIONS_TIMEOUT" envDefault:"15s"`
+	SilvergateRetryMaxAttempts        int           `env:"SILVERGATE_RETRY_MAX_ATTEMPTS" envDefault:"3"`
+	SilvergateRetryInitialBackoff     time.Duration `env:"SILVERGATE_RETRY_INITIAL_BACKOFF" envDefault:"200ms"`
+	SilvergateRetryMaxBackoff         time.Duration `env:"SILVERGATE_RETRY_MAX_BACKOFF" envDefault:"2s"`
+	SilvergateBreakerFailureThreshold int           `env:"SILVERGATE_BREAKER_FAILURE_THRESHOLD" envDefault:"5"`
+	SilvergateBreakerOpenTimeout      time.Duration `env:"SILVERGATE_BREAKER_OPEN_TIMEOUT" envDefault:"30s"`
+
+	// Provider routing: an optional second Silvergate instance (same paths and resilience settings)
+	// and JSON rules picking the provider per payment, see routing.Rule. Without rules every
+	// payment goes to the primary instance, named "silvergate".
+	SilvergateSecondaryName    string `env:"SILVERGATE_SECONDARY_NAME" envDefault:"silvergate-secondary"`
+	SilvergateSecondaryBaseURL string `env:"SILVERGATE_SECONDARY_BASE_URL"`
+	ProviderRoutingRules       string `env:"PROVIDER_ROUTING_RULES"`
+
+	// Merchant resolution: requests without credentials get 401 unless MERCHANT_DEFAULT_FALLBACK
+	// lets them act as MERCHANT_ID, which is for local development only.
+	// MERCHANT_HEADER_TRUSTED accepts X-Merchant-ID without an API key, for deployments behind an authenticating gateway.
+	MerchantID              string `env:"MERCHANT_ID" envDefault:"merchant_1"`
+	MerchantDefaultFallback bool   `env:"MERCHANT_DEFAULT_FALLBACK" envDefault:"false"`
+	MerchantHeaderTrusted   bool   `env:"MERCHANT_HEADER_TRUSTED" envDefault:"false"`
+
+	// Operator endpoints spanning every merchant (reconciliation reports) require X-Admin-Key
+	// to match ADMIN_API_KEY. Empty disables them.
+	AdminAPIKey string `env:"ADMIN_API_KEY"`
+
+	// Capture scheduler: polls payments with a due capture_at and captures them at the provider
+	CaptureSchedulerPollInterval time.Duration `env:"CAPTURE_SCHEDULER_POLL_INTERVAL" envDefault:"1s"`
+	CaptureSchedulerBatchSize    int           `env:"CAPTURE_SCHEDULER_BATCH_SIZE" envDefault:"50"`
+	CaptureSchedulerLeaseTimeout time.Duration `env:"CAPTURE_SCHEDULER_LEASE_TIMEOUT" envDefault:"1m"`
+	CaptureSchedulerMaxAttempts  int           `env:"CAPTURE_SCHEDULER_MAX_ATTEMPTS" envDefault:"5"`
+	CaptureSchedulerRetryBackoff time.Duration `env:"CAPTURE_SCHEDULER_RETRY_BACKOFF" envDefault:"10s"`
+
+	// Risk engine: YAML rules evaluated before every authorization, re-read when the file changes
+	RiskRulesPath           string        `env:"RISK_RULES_PATH"`
+	RiskRulesReloadInterval time.Duration `env:"RISK_RULES_RELOAD_INTERVAL" envDefault:"10s"`
+
+	// Challenge expirer: expires payments left in requires_action longer than PAYMENT_CHALLENGE_TTL
+	PaymentChallengeTTL          time.Duration `env:"PAYMENT_CHALLENGE_TTL" envDefault:"20m"`
+	ChallengeExpirerPollInterval time.Duration `env:"CHALLENGE_EXPIRER_POLL_INTERVAL" envDefault:"1m"`
+	ChallengeExpirerBatchSize    int           `env:"CHALLENGE_EXPIRER_BATCH_SIZE" envDefault:"100"`
+
+	// Reconciliation: compares open payments with Silvergate transaction state and replays missed webhooks
+	ReconciliationInterval   time.Duration `env:"RECONCILIATION_INTERVAL" envDefault:"5m"`
+	ReconciliationBatchSize  int           `env:"RECONCILIATION_BATCH_SIZE" envDefault:"100"`
+	ReconciliationStaleAfter time.Duration `env:"RECONCILIATION_STALE_AFTER" envDefault:"10m"`
+
+	// Merchant webhooks: fans payment events out to merchant endpoints and delivers them with retries
+	NotificationPollInterval time.Duration `env:"NOTIFICATION_POLL_INTERVAL" envDefault:"1s"`
+	NotificationBatchSize    int           `env:"NOTIFICATION_BATCH_SIZE" envDefault:"100"`
+	NotificationSettleDelay  time.Duration `env:"NOTIFICATION_SETTLE_DELAY" envDefault:"5s"`
+	NotificationLeaseTimeout time.Duration `env:"NOTIFICATION_LEASE_TIMEOUT" envDefault:"1m"`
+	NotificationMaxAttempts  int           `env:"NOTIFICATION_MAX_ATTEMPTS" envDefault:"8"`
+	NotificationRetryBackoff time.Duration `env:"NOTIFICATION_RETRY_BACKOFF" envDefault:"30s"`
+	NotificationMaxBackoff   time.Duration `env:"NOTIFICATION_MAX_BACKOFF" envDefault:"6h"`
+	NotificationHTTPTimeout  time.Duration `env:"NOTIFICATION_HTTP_TIMEOUT" envDefault:"10s"`
+
+	// Idempotency-Key handling on write endpoints: how long an unfinished request holds its key
+	IdempotencyLockTimeout time.Duration `env:"IDEMPOTENCY_LOCK_TIMEOUT" envDefault:"1m"`
 
 	// Webhook processing mode: "sync" (direct) or "kafka" (async via Kafka)
 	WebhookMode string `env:"WEBHOOK_MODE" envDefault:"sync"`
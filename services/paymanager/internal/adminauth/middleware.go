@@ -0,0 +1,28 @@
+// Package adminauth guards operator endpoints whose data spans every merchant.
+package adminauth
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries the operator API key.
+const APIKeyHeader = "X-Admin-Key"
+
+// Middleware admits requests whose X-Admin-Key matches apiKey and rejects the rest with
+// 401. An empty apiKey disables the guarded endpoints: every request gets 403.
+func Middleware(apiKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey == "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin endpoints are disabled"})
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader(APIKeyHeader)), []byte(apiKey)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin credentials"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,47 @@
+package adminauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEngine(apiKey string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/admin", Middleware(apiKey), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return engine
+}
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiKey string
+		header string
+		want   int
+	}{
+		{name: "matching key", apiKey: "admin-key", header: "admin-key", want: http.StatusOK},
+		{name: "missing key", apiKey: "admin-key", header: "", want: http.StatusUnauthorized},
+		{name: "wrong key", apiKey: "admin-key", header: "merchant-key", want: http.StatusUnauthorized},
+		{name: "disabled", apiKey: "", header: "", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set(APIKeyHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			newTestEngine(tt.apiKey).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Code)
+		})
+	}
+}
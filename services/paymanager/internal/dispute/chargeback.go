@@ -1,20 +1,26 @@
 package dispute
 
 import (
+	"fmt"
 	"strings"
 	"time"
+
+	"TestTaskJustPay/pkg/money"
 )
 
+// ChargebackWebhook is a provider chargeback as forwarded by Ingest, which converts the
+// provider's decimal amount to minor units of Currency.
 type ChargebackWebhook struct {
-	ProviderEventID string           `json:"provider_event_id"`
-	OrderID         string           `json:"order_id"`
-	UserID          string           `json:"user_id"`
-	Status          ChargebackStatus `json:"status"`
-	Reason          string           `json:"reason"`
-	Money
-	OccurredAt    time.Time         `json:"occurred_at"`
-	EvidenceDueAt *time.Time        `json:"evidence_due_at,omitempty"`
-	Meta          map[string]string `json:"meta"`
+	ProviderEventID string            `json:"provider_event_id"`
+	OrderID         string            `json:"order_id"`
+	UserID          string            `json:"user_id"`
+	Status          ChargebackStatus  `json:"status"`
+	Reason          string            `json:"reason"`
+	Amount          int64             `json:"amount"`
+	Currency        string            `json:"currency"`
+	OccurredAt      time.Time         `json:"occurred_at"`
+	EvidenceDueAt   *time.Time        `json:"evidence_due_at,omitempty"`
+	Meta            map[string]string `json:"meta"`
 }
 
 type ChargebackStatus string
@@ -46,3 +52,12 @@ func (e ChargebackWebhook) Resolution() (ChargebackResolution, bool) {
 		return "", false
 	}
 }
+
+// Money returns the disputed amount, validating the currency.
+func (e ChargebackWebhook) Money() (money.Money, error) {
+	m, err := money.New(e.Amount, e.Currency)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("%w: %w", ErrInvalidAmount, err)
+	}
+	return m, nil
+}
@@ -5,7 +5,7 @@ import (
 	"net/http"
 
 	"TestTaskJustPay/services/paymanager/internal/dispute"
-	"TestTaskJustPay/services/paymanager/internal/order"
+	"TestTaskJustPay/services/paymanager/internal/merchantauth"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +19,13 @@ func NewHTTPHandler(s *dispute.DisputeService) *HTTPHandler {
 }
 
 func (h *HTTPHandler) GetDisputes(c *gin.Context) {
-	disputes, err := h.service.GetDisputes(c)
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "merchant context missing"})
+		return
+	}
+
+	disputes, err := h.service.GetDisputes(c, m.ID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
@@ -29,15 +35,21 @@ func (h *HTTPHandler) GetDisputes(c *gin.Context) {
 }
 
 func (h *HTTPHandler) GetDispute(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "merchant context missing"})
+		return
+	}
+
 	disputeID := c.Param("dispute_id")
 	if disputeID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing dispute_id"})
 		return
 	}
 
-	d, err := h.service.GetDisputeByID(c, disputeID)
+	d, err := h.service.GetDisputeByID(c, m.ID, disputeID)
 	if err != nil {
-		if errors.Is(err, order.ErrNotFound) {
+		if errors.Is(err, dispute.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"message": "Dispute not found"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
@@ -49,14 +61,24 @@ func (h *HTTPHandler) GetDispute(c *gin.Context) {
 }
 
 func (h *HTTPHandler) Submit(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "merchant context missing"})
+		return
+	}
+
 	disputeID := c.Param("dispute_id")
 	if disputeID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "dispute_id is required"})
 		return
 	}
 
-	err := h.service.Submit(c, disputeID)
+	err := h.service.Submit(c, m.ID, disputeID)
 	if err != nil {
+		if errors.Is(err, dispute.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Dispute not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
@@ -64,6 +86,12 @@ func (h *HTTPHandler) Submit(c *gin.Context) {
 }
 
 func (h *HTTPHandler) UpsertEvidence(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "merchant context missing"})
+		return
+	}
+
 	disputeID := c.Param("dispute_id")
 	if disputeID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "dispute_id is required"})
@@ -76,9 +104,9 @@ func (h *HTTPHandler) UpsertEvidence(c *gin.Context) {
 		return
 	}
 
-	evidence, err := h.service.UpsertEvidence(c, disputeID, upsert)
+	evidence, err := h.service.UpsertEvidence(c, m.ID, disputeID, upsert)
 	if err != nil {
-		if err.Error() == "dispute not found" {
+		if errors.Is(err, dispute.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"message": "Dispute not found"})
 			return
 		}
@@ -90,12 +118,18 @@ func (h *HTTPHandler) UpsertEvidence(c *gin.Context) {
 }
 
 func (h *HTTPHandler) GetEvents(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "merchant context missing"})
+		return
+	}
+
 	var query dispute.DisputeEventQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
-	res, err := h.service.GetEvents(c, query)
+	res, err := h.service.GetEvents(c, m.ID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
@@ -105,14 +139,24 @@ func (h *HTTPHandler) GetEvents(c *gin.Context) {
 }
 
 func (h *HTTPHandler) GetEvidence(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"message": "merchant context missing"})
+		return
+	}
+
 	disputeID := c.Param("dispute_id")
 	if disputeID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing dispute_id"})
 		return
 	}
 
-	evidence, err := h.service.GetEvidence(c, disputeID)
+	evidence, err := h.service.GetEvidence(c, m.ID, disputeID)
 	if err != nil {
+		if errors.Is(err, dispute.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Dispute not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
 	}
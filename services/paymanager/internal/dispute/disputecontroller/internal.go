@@ -17,7 +17,7 @@ type disputeUpdateRequest struct {
 	UserID          string            `json:"user_id"`
 	Status          string            `json:"status"`
 	Reason          string            `json:"reason"`
-	Amount          float64           `json:"amount"`
+	Amount          int64             `json:"amount"`
 	Currency        string            `json:"currency"`
 	OccurredAt      time.Time         `json:"occurred_at"`
 	EvidenceDueAt   *time.Time        `json:"evidence_due_at,omitempty"`
@@ -46,13 +46,11 @@ func (h *HTTPHandler) HandleUpdate(c *gin.Context) {
 		UserID:          req.UserID,
 		Status:          dispute.ChargebackStatus(req.Status),
 		Reason:          req.Reason,
-		Money: dispute.Money{
-			Amount:   req.Amount,
-			Currency: req.Currency,
-		},
-		OccurredAt:    req.OccurredAt,
-		EvidenceDueAt: req.EvidenceDueAt,
-		Meta:          req.Meta,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		OccurredAt:      req.OccurredAt,
+		EvidenceDueAt:   req.EvidenceDueAt,
+		Meta:            req.Meta,
 	}
 
 	err := h.service.ProcessChargeback(c.Request.Context(), webhook)
@@ -60,6 +58,8 @@ func (h *HTTPHandler) HandleUpdate(c *gin.Context) {
 		switch {
 		case errors.Is(err, dispute.ErrEventAlreadyStored):
 			c.JSON(http.StatusOK, disputeUpdateResponse{Success: true, Message: "event already processed"})
+		case errors.Is(err, dispute.ErrInvalidAmount):
+			c.JSON(http.StatusBadRequest, disputeUpdateResponse{Success: false, Message: err.Error()})
 		default:
 			c.JSON(http.StatusInternalServerError, disputeUpdateResponse{Success: false, Message: err.Error()})
 		}
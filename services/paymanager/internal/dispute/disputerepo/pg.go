@@ -16,7 +16,7 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
-var disputeColumns = []string{"id", "order_id", "submitting_id", "status", "reason", "amount", "currency", "opened_at", "evidence_due_at", "submitted_at", "closed_at"}
+var disputeColumns = []string{"id", "order_id", "merchant_id", "submitting_id", "status", "reason", "amount", "currency", "opened_at", "evidence_due_at", "submitted_at", "closed_at"}
 
 type PgDisputeRepo struct {
 	pg *postgres.Postgres
@@ -42,8 +42,8 @@ type repo struct {
 	builder squirrel.StatementBuilderType
 }
 
-func (r *repo) GetDisputes(ctx context.Context) ([]dispute.Dispute, error) {
-	query, args := r.buildDisputesQuery()
+func (r *repo) GetDisputes(ctx context.Context, merchantID string) ([]dispute.Dispute, error) {
+	query, args := r.buildDisputesQuery(merchantID)
 	rows, err := r.readDB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query disputes: %w", err)
@@ -53,8 +53,8 @@ func (r *repo) GetDisputes(ctx context.Context) ([]dispute.Dispute, error) {
 	return parseDisputeRows(rows)
 }
 
-func (r *repo) GetDisputeByID(ctx context.Context, disputeID string) (*dispute.Dispute, error) {
-	query, args := r.buildDisputeByIDQuery(disputeID)
+func (r *repo) GetDisputeByID(ctx context.Context, merchantID, disputeID string) (*dispute.Dispute, error) {
+	query, args := r.buildDisputeByIDQuery(merchantID, disputeID)
 	rows, err := r.readDB.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("query dispute by id: %w", err)
@@ -94,19 +94,22 @@ func (r *repo) CreateDispute(ctx context.Context, newDispute dispute.NewDispute)
 
 	query, args, err := r.builder.Insert("disputes").
 		Columns(disputeColumns...).
-		Values(id, newDispute.OrderID, newDispute.SubmittingId, newDispute.Status, newDispute.Reason, newDispute.Amount, newDispute.Currency, newDispute.OpenedAt, newDispute.EvidenceDueAt, newDispute.SubmittedAt, newDispute.ClosedAt).
+		Values(id, newDispute.OrderID, squirrel.Expr("(SELECT merchant_id FROM orders WHERE id = ?)", newDispute.OrderID),
+			newDispute.SubmittingId, newDispute.Status, newDispute.Reason, newDispute.Amount, newDispute.Currency, newDispute.OpenedAt, newDispute.EvidenceDueAt, newDispute.SubmittedAt, newDispute.ClosedAt).
+		Suffix("RETURNING merchant_id").
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("build insert query: %w", err)
 	}
 
-	_, err = r.db.Exec(ctx, query, args...)
-	if err != nil {
+	var merchantID string
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&merchantID); err != nil {
 		return nil, fmt.Errorf("create dispute: %w", err)
 	}
 
 	return &dispute.Dispute{
 		ID:          id,
+		MerchantID:  merchantID,
 		Status:      newDispute.Status,
 		DisputeInfo: newDispute.DisputeInfo,
 	}, nil
@@ -204,19 +207,20 @@ func (r *repo) buildGetEvidenceQuery(disputeID string) (string, []any) {
 	return sql, args
 }
 
-func (r *repo) buildDisputesQuery() (string, []any) {
+func (r *repo) buildDisputesQuery(merchantID string) (string, []any) {
 	query := r.builder.Select(disputeColumns...).
 		From("disputes").
+		Where(squirrel.Eq{"merchant_id": merchantID}).
 		OrderBy("opened_at DESC")
 
 	sql, args, _ := query.ToSql()
 	return sql, args
 }
 
-func (r *repo) buildDisputeByIDQuery(disputeID string) (string, []any) {
+func (r *repo) buildDisputeByIDQuery(merchantID, disputeID string) (string, []any) {
 	query := r.builder.Select(disputeColumns...).
 		From("disputes").
-		Where(squirrel.Eq{"id": disputeID})
+		Where(squirrel.Eq{"id": disputeID, "merchant_id": merchantID})
 
 	sql, args, _ := query.ToSql()
 	return sql, args
@@ -237,7 +241,7 @@ func parseDisputeRows(rows pgx.Rows) ([]dispute.Dispute, error) {
 		var d dispute.Dispute
 		var rawStatus string
 		var evidenceDueAt, submittedAt, closedAt sql.NullTime
-		err := rows.Scan(&d.ID, &d.OrderID, &d.SubmittingId, &rawStatus, &d.Reason, &d.Amount, &d.Currency, &d.OpenedAt, &evidenceDueAt, &submittedAt, &closedAt)
+		err := rows.Scan(&d.ID, &d.OrderID, &d.MerchantID, &d.SubmittingId, &rawStatus, &d.Reason, &d.Amount, &d.Currency, &d.OpenedAt, &evidenceDueAt, &submittedAt, &closedAt)
 		if err != nil {
 			return nil, fmt.Errorf("scan dispute row: %w", err)
 		}
@@ -123,6 +123,10 @@ func (r *PgDisputeEventRepo) buildDisputeEventPageQuery(q dispute.DisputeEventQu
 	b := r.builder.Select("id", "dispute_id", "kind", "provider_event_id", "data", "created_at").
 		From("dispute_events")
 
+	if q.MerchantID != "" {
+		b = b.Where("dispute_id IN (SELECT id FROM disputes WHERE merchant_id = ?)", q.MerchantID)
+	}
+
 	if len(q.DisputeIDs) > 0 {
 		b = b.Where(squirrel.Eq{"dispute_id": q.DisputeIDs})
 	}
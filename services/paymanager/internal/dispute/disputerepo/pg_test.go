@@ -1,6 +1,7 @@
 package disputerepo
 
 import (
+	"TestTaskJustPay/pkg/money"
 	"TestTaskJustPay/services/paymanager/internal/dispute"
 	"TestTaskJustPay/services/paymanager/internal/gateway"
 	"context"
@@ -25,23 +26,24 @@ func TestGetDisputeByID(t *testing.T) {
 		disputeID := "dispute-1"
 		expectedTime := time.Now()
 
-		rows := mock.NewRows([]string{"id", "order_id", "submitting_id", "status", "reason", "amount", "currency", "opened_at", "evidence_due_at", "submitted_at", "closed_at"}).
-			AddRow(disputeID, "order-1", nil, "open", "fraud", 100.50, "USD", expectedTime, nil, nil, nil)
+		rows := mock.NewRows([]string{"id", "order_id", "merchant_id", "submitting_id", "status", "reason", "amount", "currency", "opened_at", "evidence_due_at", "submitted_at", "closed_at"}).
+			AddRow(disputeID, "order-1", "merchant_1", nil, "open", "fraud", int64(10050), "USD", expectedTime, nil, nil, nil)
 
-		mock.ExpectQuery(`SELECT id, order_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at FROM disputes WHERE id = \$1`).
-			WithArgs(disputeID).
+		mock.ExpectQuery(`SELECT id, order_id, merchant_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at FROM disputes WHERE id = \$1 AND merchant_id = \$2`).
+			WithArgs(disputeID, "merchant_1").
 			WillReturnRows(rows)
 
-		result, err := r.GetDisputeByID(ctx, disputeID)
+		result, err := r.GetDisputeByID(ctx, "merchant_1", disputeID)
 
 		require.NoError(t, err)
 		require.NotNil(t, result)
 		assert.Equal(t, disputeID, result.ID)
 		assert.Equal(t, "order-1", result.OrderID)
+		assert.Equal(t, "merchant_1", result.MerchantID)
 		assert.Equal(t, dispute.DisputeOpen, result.Status)
 		assert.Equal(t, "fraud", result.Reason)
-		assert.Equal(t, 100.50, result.Amount)
-		assert.Equal(t, "USD", result.Currency)
+		assert.Equal(t, int64(10050), result.Amount)
+		assert.Equal(t, money.Currency("USD"), result.Currency)
 		assert.Nil(t, result.EvidenceDueAt)
 		assert.Nil(t, result.SubmittedAt)
 		assert.Nil(t, result.ClosedAt)
@@ -50,11 +52,11 @@ func TestGetDisputeByID(t *testing.T) {
 	t.Run("should return nil when dispute not found", func(t *testing.T) {
 		disputeID := "nonexistent"
 
-		mock.ExpectQuery(`SELECT id, order_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at FROM disputes WHERE id = \$1`).
-			WithArgs(disputeID).
-			WillReturnRows(pgxmock.NewRows([]string{"id", "order_id", "submitting_id", "status", "reason", "amount", "currency", "opened_at", "evidence_due_at", "submitted_at", "closed_at"}))
+		mock.ExpectQuery(`SELECT id, order_id, merchant_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at FROM disputes WHERE id = \$1 AND merchant_id = \$2`).
+			WithArgs(disputeID, "merchant_1").
+			WillReturnRows(pgxmock.NewRows([]string{"id", "order_id", "merchant_id", "submitting_id", "status", "reason", "amount", "currency", "opened_at", "evidence_due_at", "submitted_at", "closed_at"}))
 
-		result, err := r.GetDisputeByID(ctx, disputeID)
+		result, err := r.GetDisputeByID(ctx, "merchant_1", disputeID)
 
 		require.NoError(t, err)
 		assert.Nil(t, result)
@@ -63,11 +65,11 @@ func TestGetDisputeByID(t *testing.T) {
 	t.Run("should handle database error", func(t *testing.T) {
 		disputeID := "dispute-1"
 
-		mock.ExpectQuery(`SELECT id, order_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at FROM disputes WHERE id = \$1`).
-			WithArgs(disputeID).
+		mock.ExpectQuery(`SELECT id, order_id, merchant_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at FROM disputes WHERE id = \$1 AND merchant_id = \$2`).
+			WithArgs(disputeID, "merchant_1").
 			WillReturnError(assert.AnError)
 
-		result, err := r.GetDisputeByID(ctx, disputeID)
+		result, err := r.GetDisputeByID(ctx, "merchant_1", disputeID)
 
 		require.Error(t, err)
 		assert.Nil(t, result)
@@ -88,10 +90,10 @@ func TestGetDisputeByOrderID(t *testing.T) {
 		expectedTime := time.Now()
 		evidenceTime := expectedTime.Add(7 * 24 * time.Hour)
 
-		rows := mock.NewRows([]string{"id", "order_id", "submitting_id", "status", "reason", "amount", "currency", "opened_at", "evidence_due_at", "submitted_at", "closed_at"}).
-			AddRow("dispute-1", orderID, nil, "open", "fraud", 100.50, "USD", expectedTime, evidenceTime, nil, nil)
+		rows := mock.NewRows([]string{"id", "order_id", "merchant_id", "submitting_id", "status", "reason", "amount", "currency", "opened_at", "evidence_due_at", "submitted_at", "closed_at"}).
+			AddRow("dispute-1", orderID, "merchant_1", nil, "open", "fraud", int64(10050), "USD", expectedTime, evidenceTime, nil, nil)
 
-		mock.ExpectQuery(`SELECT id, order_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at FROM disputes WHERE order_id = \$1`).
+		mock.ExpectQuery(`SELECT id, order_id, merchant_id, submitting_id, status, reason, amount, currency, opened_at, evidence_due_at, submitted_at, closed_at FROM disputes WHERE order_id = \$1`).
 			WithArgs(orderID).
 			WillReturnRows(rows)
 
@@ -123,8 +125,8 @@ func TestCreateDispute(t *testing.T) {
 			DisputeInfo: dispute.DisputeInfo{
 				OrderID: "order-1",
 				Reason:  "fraud",
-				Money: dispute.Money{
-					Amount:   100.50,
+				Money: money.Money{
+					Amount:   10050,
 					Currency: "USD",
 				},
 				OpenedAt:      openedAt,
@@ -132,9 +134,9 @@ func TestCreateDispute(t *testing.T) {
 			},
 		}
 
-		mock.ExpectExec(`INSERT INTO disputes \(id,order_id,submitting_id,status,reason,amount,currency,opened_at,evidence_due_at,submitted_at,closed_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11\)`).
-			WithArgs(pgxmock.AnyArg(), "order-1", (*string)(nil), dispute.DisputeOpen, "fraud", 100.50, "USD", openedAt, &evidenceDueAt, (*time.Time)(nil), (*time.Time)(nil)).
-			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectQuery(`INSERT INTO disputes \(id,order_id,merchant_id,submitting_id,status,reason,amount,currency,opened_at,evidence_due_at,submitted_at,closed_at\) VALUES \(\$1,\$2,\(SELECT merchant_id FROM orders WHERE id = \$3\),\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12\) RETURNING merchant_id`).
+			WithArgs(pgxmock.AnyArg(), "order-1", "order-1", (*string)(nil), dispute.DisputeOpen, "fraud", int64(10050), money.Currency("USD"), openedAt, &evidenceDueAt, (*time.Time)(nil), (*time.Time)(nil)).
+			WillReturnRows(pgxmock.NewRows([]string{"merchant_id"}).AddRow("merchant_1"))
 
 		result, err := r.CreateDispute(ctx, newDispute)
 
@@ -143,6 +145,7 @@ func TestCreateDispute(t *testing.T) {
 		assert.NotEmpty(t, result.ID)
 		assert.Equal(t, dispute.DisputeOpen, result.Status)
 		assert.Equal(t, "order-1", result.OrderID)
+		assert.Equal(t, "merchant_1", result.MerchantID)
 	})
 }
 
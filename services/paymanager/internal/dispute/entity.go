@@ -3,11 +3,14 @@ package dispute
 import (
 	"fmt"
 	"time"
+
+	"TestTaskJustPay/pkg/money"
 )
 
 type Dispute struct {
-	ID     string        `json:"dispute_id"`
-	Status DisputeStatus `json:"status"`
+	ID         string        `json:"dispute_id"`
+	MerchantID string        `json:"merchant_id"`
+	Status     DisputeStatus `json:"status"`
 	DisputeInfo
 }
 
@@ -20,18 +23,13 @@ type DisputeInfo struct {
 	OrderID      string  `json:"order_id"`
 	SubmittingId *string `json:"submitting_id,omitempty"`
 	Reason       string  `json:"reason"`
-	Money
+	money.Money
 	OpenedAt      time.Time  `json:"opened_at"`
 	EvidenceDueAt *time.Time `json:"evidence_due_at,omitempty"`
 	SubmittedAt   *time.Time `json:"submitted_at,omitempty"`
 	ClosedAt      *time.Time `json:"closed_at,omitempty"`
 }
 
-type Money struct {
-	Amount   float64 `json:"amount"`
-	Currency string  `json:"currency"`
-}
-
 type DisputeStatus string
 
 const (
@@ -3,6 +3,8 @@ package dispute
 import "errors"
 
 var (
+	ErrNotFound           = errors.New("dispute not found")
 	ErrAlreadyExists      = errors.New("dispute already exists")
 	ErrEventAlreadyStored = errors.New("event already stored")
+	ErrInvalidAmount      = errors.New("invalid chargeback amount")
 )
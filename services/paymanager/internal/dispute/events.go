@@ -35,6 +35,9 @@ type DisputeEventPage struct {
 }
 
 type DisputeEventQuery struct {
+	// MerchantID restricts events to the merchant's disputes; set from the authenticated merchant.
+	MerchantID string `json:"-" url:"-" form:"-"`
+
 	DisputeIDs []string           `json:"dispute_ids" url:"dispute_ids" form:"dispute_ids,omitempty"`
 	Kinds      []DisputeEventKind `json:"kinds" url:"kinds" form:"kinds,omitempty"`
 
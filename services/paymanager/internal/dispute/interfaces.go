@@ -8,10 +8,13 @@ import (
 
 // DisputeRepo is the persistence contract for disputes.
 type DisputeRepo interface {
-	GetDisputes(ctx context.Context) ([]Dispute, error)
-	GetDisputeByID(ctx context.Context, disputeID string) (*Dispute, error)
+	// GetDisputes and GetDisputeByID only see disputes of merchantID.
+	GetDisputes(ctx context.Context, merchantID string) ([]Dispute, error)
+	GetDisputeByID(ctx context.Context, merchantID, disputeID string) (*Dispute, error)
+	// GetDisputeByOrderID serves provider webhooks and is not merchant-scoped.
 	GetDisputeByOrderID(ctx context.Context, orderID string) (*Dispute, error)
 
+	// CreateDispute assigns the dispute to the merchant owning its order.
 	CreateDispute(ctx context.Context, dispute NewDispute) (*Dispute, error)
 	UpdateDispute(ctx context.Context, dispute Dispute) error
 
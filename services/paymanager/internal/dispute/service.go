@@ -43,23 +43,28 @@ func NewDisputeService(
 	}
 }
 
-func (s *DisputeService) GetDisputes(ctx context.Context) ([]Dispute, error) {
-	disputes, err := s.disputeRepo.GetDisputes(ctx)
+func (s *DisputeService) GetDisputes(ctx context.Context, merchantID string) ([]Dispute, error) {
+	disputes, err := s.disputeRepo.GetDisputes(ctx, merchantID)
 	if err != nil {
 		return nil, fmt.Errorf("get disputes: %w", err)
 	}
 	return disputes, nil
 }
 
-func (s *DisputeService) GetDisputeByID(ctx context.Context, disputeID string) (*Dispute, error) {
-	dispute, err := s.disputeRepo.GetDisputeByID(ctx, disputeID)
+// GetDisputeByID returns ErrNotFound for disputes of other merchants.
+func (s *DisputeService) GetDisputeByID(ctx context.Context, merchantID, disputeID string) (*Dispute, error) {
+	dispute, err := s.disputeRepo.GetDisputeByID(ctx, merchantID, disputeID)
 	if err != nil {
 		return nil, fmt.Errorf("get dispute by id: %w", err)
 	}
+	if dispute == nil {
+		return nil, ErrNotFound
+	}
 	return dispute, err
 }
 
-func (s *DisputeService) GetEvents(ctx context.Context, query DisputeEventQuery) (DisputeEventPage, error) {
+func (s *DisputeService) GetEvents(ctx context.Context, merchantID string, query DisputeEventQuery) (DisputeEventPage, error) {
+	query.MerchantID = merchantID
 	if query.Limit <= 0 {
 		query.Limit = 10
 	}
@@ -71,7 +76,11 @@ func (s *DisputeService) GetEvents(ctx context.Context, query DisputeEventQuery)
 	return eventPage, nil
 }
 
-func (s *DisputeService) GetEvidence(ctx context.Context, disputeID string) (*Evidence, error) {
+func (s *DisputeService) GetEvidence(ctx context.Context, merchantID, disputeID string) (*Evidence, error) {
+	if _, err := s.GetDisputeByID(ctx, merchantID, disputeID); err != nil {
+		return nil, err
+	}
+
 	evidence, err := s.disputeRepo.GetEvidence(ctx, disputeID)
 	if err != nil {
 		return nil, fmt.Errorf("get evidence for dispute %s: %w", disputeID, err)
@@ -95,12 +104,17 @@ func (s *DisputeService) ProcessChargeback(ctx context.Context, webhook Chargeba
 				return fmt.Errorf("dispute not found for order_id: %s", webhook.OrderID)
 			}
 
+			amount, err := webhook.Money()
+			if err != nil {
+				return err
+			}
+
 			newDispute := NewDispute{
 				Status: DisputeOpen,
 				DisputeInfo: DisputeInfo{
 					OrderID:       webhook.OrderID,
 					Reason:        webhook.Reason,
-					Money:         webhook.Money,
+					Money:         amount,
 					OpenedAt:      webhook.OccurredAt,
 					EvidenceDueAt: webhook.EvidenceDueAt,
 				},
@@ -148,19 +162,19 @@ func (s *DisputeService) ProcessChargeback(ctx context.Context, webhook Chargeba
 	return nil
 }
 
-func (s *DisputeService) UpsertEvidence(ctx context.Context, disputeID string, upsert EvidenceUpsert) (*Evidence, error) {
+func (s *DisputeService) UpsertEvidence(ctx context.Context, merchantID, disputeID string, upsert EvidenceUpsert) (*Evidence, error) {
 	var result *Evidence
 
 	err := s.transactor.InTransaction(ctx, pgx.RepeatableRead, func(tx postgres.Executor) error {
 		txRepo := s.txDisputeRepo(tx)
 		txEvents := s.txEventStore(tx)
 
-		dispute, err := txRepo.GetDisputeByID(ctx, disputeID)
+		dispute, err := txRepo.GetDisputeByID(ctx, merchantID, disputeID)
 		if err != nil {
 			return fmt.Errorf("get dispute by id: %w", err)
 		}
 		if dispute == nil {
-			return fmt.Errorf("dispute not found")
+			return ErrNotFound
 		}
 
 		if !IsDisputeEditable(dispute.Status) {
@@ -199,18 +213,18 @@ func (s *DisputeService) UpsertEvidence(ctx context.Context, disputeID string, u
 	return result, nil
 }
 
-func (s *DisputeService) Submit(ctx context.Context, disputeID string) error {
+func (s *DisputeService) Submit(ctx context.Context, merchantID, disputeID string) error {
 	var result *gateway.RepresentmentResult
 	err := s.transactor.InTransaction(ctx, pgx.RepeatableRead, func(tx postgres.Executor) error {
 		txRepo := s.txDisputeRepo(tx)
 		txEvents := s.txEventStore(tx)
 
-		d, err := txRepo.GetDisputeByID(ctx, disputeID)
+		d, err := txRepo.GetDisputeByID(ctx, merchantID, disputeID)
 		if err != nil {
 			return fmt.Errorf("get dispute: %w", err)
 		}
 		if d == nil {
-			return fmt.Errorf("dispute not found")
+			return ErrNotFound
 		}
 
 		if d.Status != DisputeOpen && d.Status != DisputeUnderReview {
@@ -227,8 +241,9 @@ func (s *DisputeService) Submit(ctx context.Context, disputeID string) error {
 		}
 
 		res, err := s.provider.SubmitRepresentment(ctx, gateway.RepresentmentRequest{
-			OrderId:  d.OrderID,
-			Evidence: evidence.Evidence,
+			MerchantID: d.MerchantID,
+			OrderId:    d.OrderID,
+			Evidence:   evidence.Evidence,
 		})
 		if err != nil {
 			return fmt.Errorf("provider submit: %w", err)
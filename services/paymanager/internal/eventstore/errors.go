@@ -2,4 +2,7 @@ package eventstore
 
 import "errors"
 
-var ErrEventAlreadyStored = errors.New("event already stored")
+var (
+	ErrEventAlreadyStored = errors.New("event already stored")
+	ErrInvalidCursor      = errors.New("invalid cursor")
+)
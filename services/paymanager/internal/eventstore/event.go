@@ -11,6 +11,7 @@ type AggregateType string
 const (
 	AggregateOrder   AggregateType = "order"
 	AggregateDispute AggregateType = "dispute"
+	AggregatePayment AggregateType = "payment"
 )
 
 type NewEvent struct {
@@ -30,3 +31,24 @@ type Event struct {
 type Store interface {
 	CreateEvent(ctx context.Context, event NewEvent) (*Event, error)
 }
+
+// Query selects the events of one aggregate, optionally narrowed to event types.
+type Query struct {
+	AggregateType AggregateType
+	AggregateID   string
+	EventTypes    []string
+
+	Limit   int
+	Cursor  string
+	SortAsc bool
+}
+
+type Page struct {
+	Items      []Event
+	NextCursor string
+	HasMore    bool
+}
+
+type Reader interface {
+	ListEvents(ctx context.Context, query Query) (Page, error)
+}
@@ -2,7 +2,10 @@ package eventstore
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"TestTaskJustPay/pkg/postgres"
 
@@ -15,7 +18,10 @@ type PgEventStore struct {
 	builder squirrel.StatementBuilderType
 }
 
-var _ Store = (*PgEventStore)(nil)
+var (
+	_ Store  = (*PgEventStore)(nil)
+	_ Reader = (*PgEventStore)(nil)
+)
 
 func NewPgEventStore(db postgres.Executor, builder squirrel.StatementBuilderType) *PgEventStore {
 	return &PgEventStore{
@@ -54,3 +60,116 @@ func (s *PgEventStore) CreateEvent(ctx context.Context, event NewEvent) (*Event,
 		NewEvent: event,
 	}, nil
 }
+
+// ListEvents pages through an aggregate's events by (created_at, id).
+// Construct the store with the read replica when serving API reads.
+func (s *PgEventStore) ListEvents(ctx context.Context, query Query) (Page, error) {
+	if query.Limit <= 0 {
+		query.Limit = 10
+	}
+	if query.Limit > 1000 {
+		query.Limit = 1000
+	}
+
+	sqlQuery, args, err := s.buildPageQuery(query)
+	if err != nil {
+		return Page{}, err
+	}
+
+	rows, err := s.db.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return Page{}, fmt.Errorf("query events: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]Event, 0)
+	for rows.Next() {
+		var e Event
+		var aggregateType string
+		err := rows.Scan(&e.ID, &aggregateType, &e.AggregateID, &e.EventType, &e.IdempotencyKey, &e.Payload, &e.CreatedAt)
+		if err != nil {
+			return Page{}, fmt.Errorf("scan event row: %w", err)
+		}
+		e.AggregateType = AggregateType(aggregateType)
+		items = append(items, e)
+	}
+	if err := rows.Err(); err != nil {
+		return Page{}, fmt.Errorf("iterate event rows: %w", err)
+	}
+
+	hasMore := len(items) > query.Limit
+	if hasMore {
+		items = items[:query.Limit]
+	}
+
+	var nextCursor string
+	if hasMore && len(items) > 0 {
+		lastItem := items[len(items)-1]
+		nextCursor = encodeEventCursor(eventCursor{
+			EventID:   lastItem.ID,
+			CreatedAt: lastItem.CreatedAt,
+		})
+	}
+
+	return Page{
+		Items:      items,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+type eventCursor struct {
+	EventID   string    `json:"event_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func encodeEventCursor(c eventCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func decodeEventCursor(s string) (eventCursor, error) {
+	var c eventCursor
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	return c, json.Unmarshal(b, &c)
+}
+
+func (s *PgEventStore) buildPageQuery(q Query) (string, []any, error) {
+	b := s.builder.Select("id", "aggregate_type", "aggregate_id", "event_type", "idempotency_key", "payload", "created_at").
+		From("events").
+		Where(squirrel.Eq{"aggregate_type": q.AggregateType, "aggregate_id": q.AggregateID})
+
+	if len(q.EventTypes) > 0 {
+		b = b.Where(squirrel.Eq{"event_type": q.EventTypes})
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodeEventCursor(q.Cursor)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+
+		if q.SortAsc {
+			b = b.Where("(created_at, id) > (?, ?)", cursor.CreatedAt.UTC(), cursor.EventID)
+		} else {
+			b = b.Where("(created_at, id) < (?, ?)", cursor.CreatedAt.UTC(), cursor.EventID)
+		}
+	}
+
+	if q.SortAsc {
+		b = b.OrderBy("created_at ASC", "id ASC")
+	} else {
+		b = b.OrderBy("created_at DESC", "id DESC")
+	}
+
+	b = b.Limit(uint64(q.Limit + 1))
+
+	sql, args, err := b.ToSql()
+	if err != nil {
+		return "", nil, fmt.Errorf("build event page query: %w", err)
+	}
+	return sql, args, nil
+}
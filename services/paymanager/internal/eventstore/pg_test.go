@@ -0,0 +1,59 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEvents(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := NewPgEventStore(mock, squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar))
+	ctx := context.Background()
+	columns := []string{"id", "aggregate_type", "aggregate_id", "event_type", "idempotency_key", "payload", "created_at"}
+
+	t.Run("filters by aggregate and event types, pages with cursor", func(t *testing.T) {
+		now := time.Now().UTC()
+		rows := mock.NewRows(columns).
+			AddRow("evt-1", "payment", "pay-1", "transaction.captured", "k1", json.RawMessage(`{}`), now).
+			AddRow("evt-2", "payment", "pay-1", "transaction.captured", "k2", json.RawMessage(`{}`), now.Add(time.Second))
+
+		mock.ExpectQuery(`SELECT .* FROM events WHERE aggregate_id = \$1 AND aggregate_type = \$2 AND event_type IN \(\$3\) ORDER BY created_at ASC, id ASC LIMIT 2`).
+			WithArgs("pay-1", AggregatePayment, "transaction.captured").
+			WillReturnRows(rows)
+
+		page, err := store.ListEvents(ctx, Query{
+			AggregateType: AggregatePayment,
+			AggregateID:   "pay-1",
+			EventTypes:    []string{"transaction.captured"},
+			Limit:         1,
+			SortAsc:       true,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "evt-1", page.Items[0].ID)
+		assert.Equal(t, AggregatePayment, page.Items[0].AggregateType)
+		assert.True(t, page.HasMore)
+
+		cursor, err := decodeEventCursor(page.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, "evt-1", cursor.EventID)
+	})
+
+	t.Run("malformed cursor returns ErrInvalidCursor", func(t *testing.T) {
+		_, err := store.ListEvents(ctx, Query{AggregateType: AggregatePayment, AggregateID: "pay-1", Cursor: "%%%"})
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
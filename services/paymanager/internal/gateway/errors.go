@@ -0,0 +1,15 @@
+package gateway
+
+import "errors"
+
+// ErrUnknownCard means the provider does not know the card token, e.g. it was issued by
+// another provider's vault or for another merchant.
+var ErrUnknownCard = errors.New("card token not recognized by provider")
+
+// ErrProviderUnavailable marks failures where the provider did not process the request
+// (connection refused, circuit open). Such calls are safe to fail over to another provider.
+var ErrProviderUnavailable = errors.New("provider unavailable")
+
+// ErrRequestRejected marks a request the provider refused outright (a 4xx response): it
+// was not acted on and will not be, so the caller can treat the operation as failed.
+var ErrRequestRejected = errors.New("request rejected by provider")
@@ -1,10 +1,26 @@
 package gateway
 
+import (
+	"time"
+
+	"TestTaskJustPay/pkg/money"
+)
+
 // Shared request/response types for the payment provider (Silvergate).
 // Provider interfaces are defined in each domain package separately (ISP).
+//
+// MerchantID on requests is the paymanager merchant on whose behalf the call is made;
+// the gateway client translates it into that merchant's provider Credentials.
+
+// Credentials identify a merchant at the provider.
+type Credentials struct {
+	MerchantID string
+	APIKey     string
+}
 
 type RepresentmentRequest struct {
-	OrderId string
+	MerchantID string
+	OrderId    string
 	Evidence
 }
 
@@ -25,39 +41,51 @@ type RepresentmentResult struct {
 }
 
 type CaptureRequest struct {
-	OrderID        string
-	Amount         float64
-	Currency       string
+	MerchantID string
+	OrderID    string
+	money.Money
+	// Final closes the authorization after this capture; the uncaptured remainder is released.
+	Final          bool
 	IdempotencyKey string
 }
 
 type CaptureResult struct {
-	ProviderTxID string
-	Status       CaptureStatus
+	ProviderTxID   string
+	CaptureID      string
+	CapturedAmount int64
+	Status         CaptureStatus
 }
 
 type AuthRequest struct {
 	MerchantID string
 	OrderID    string
-	Amount     int64
-	Currency   string
-	CardToken  string
+	money.Money
+	CardToken string
 }
 
 type AuthResult struct {
 	TransactionID string
 	Status        AuthStatus
 	DeclineReason string
+	// CardFingerprint identifies the card behind the token at the provider; empty when
+	// the provider does not report one.
+	CardFingerprint string
+	// ChallengeID and ChallengeURL are set for AuthStatusRequiresAction: the cardholder
+	// completes the challenge at ChallengeURL and the outcome arrives by webhook.
+	ChallengeID  string
+	ChallengeURL string
 }
 
 type AuthStatus string
 
 const (
-	AuthStatusAuthorized AuthStatus = "authorized"
-	AuthStatusDeclined   AuthStatus = "declined"
+	AuthStatusAuthorized     AuthStatus = "authorized"
+	AuthStatusDeclined       AuthStatus = "declined"
+	AuthStatusRequiresAction AuthStatus = "requires_action"
 )
 
 type VoidRequest struct {
+	MerchantID    string
 	TransactionID string
 }
 
@@ -67,8 +95,9 @@ type VoidResult struct {
 }
 
 type RefundRequest struct {
-	TransactionID  string
-	Amount         int64
+	MerchantID    string
+	TransactionID string
+	money.Money
 	IdempotencyKey string
 }
 
@@ -85,3 +114,24 @@ const (
 	CaptureStatusSuccess CaptureStatus = "success"
 	CaptureStatusFailed  CaptureStatus = "failed"
 )
+
+// TransactionState is the provider's view of a transaction, used for reconciliation.
+// Status carries the provider's raw status string.
+type TransactionState struct {
+	TransactionID  string
+	Status         string
+	Amount         int64
+	CapturedAmount int64
+	// RefundedAmount includes refunds still pending at the provider.
+	RefundedAmount int64
+	Currency       string
+	UpdatedAt      time.Time
+	Refunds        []RefundState
+}
+
+type RefundState struct {
+	RefundID  string
+	Amount    int64
+	Status    string
+	UpdatedAt time.Time
+}
@@ -0,0 +1,150 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/merchantauth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderName is the request header carrying the client-chosen key.
+	HeaderName = "Idempotency-Key"
+	// ReplayedHeader is set on responses served from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+type Config struct {
+	// LockTimeout bounds how long an unfinished request holds its key.
+	LockTimeout time.Duration
+}
+
+// Middleware makes a write endpoint safe to retry. The first request with a given
+// Idempotency-Key runs the handler and its response is stored; retries with the same
+// body replay that response, retries with a different body get 422, and retries while
+// the first request is still running get 409. Requests without the header pass through.
+// Keys are scoped to the merchant resolved by merchantauth, which must run first.
+//
+// A 5xx response is not stored: the key is released so the client can retry. Handlers
+// that may have reached the provider before failing deduplicate the retry themselves
+// through the idempotency keys they send to the provider.
+func Middleware(store Store, cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderName)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		m, ok := merchantauth.FromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "merchant not resolved"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := Record{
+			MerchantID:  m.ID,
+			Key:         key,
+			Endpoint:    c.Request.Method + " " + c.Request.URL.Path,
+			RequestHash: hashBody(body),
+		}
+
+		ctx := c.Request.Context()
+		existing, acquired, err := store.Acquire(ctx, rec, cfg.LockTimeout)
+		if errors.Is(err, ErrNotFound) {
+			// The in-flight holder released the key between our insert and lookup.
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is in progress"})
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "idempotency key acquire failed", "key", key, "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "idempotency check failed"})
+			return
+		}
+
+		if !acquired {
+			switch {
+			case !existing.Matches(rec.Endpoint, rec.RequestHash):
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity,
+					gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case !existing.Completed():
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is in progress"})
+			default:
+				c.Header(ReplayedHeader, "true")
+				c.Data(*existing.ResponseStatus, "application/json; charset=utf-8", existing.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		// Completion must not depend on the client staying connected.
+		storeCtx := context.WithoutCancel(ctx)
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		defer func() {
+			if p := recover(); p != nil {
+				if err := store.Release(storeCtx, rec.MerchantID, key); err != nil {
+					slog.ErrorContext(ctx, "idempotency key release failed", "key", key, "error", err)
+				}
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(storeCtx, rec.MerchantID, key); err != nil {
+				slog.ErrorContext(ctx, "idempotency key release failed", "key", key, "error", err)
+			}
+			return
+		}
+		if err := store.Complete(storeCtx, rec.MerchantID, key, status, recorder.body.Bytes()); err != nil {
+			slog.ErrorContext(ctx, "idempotency key completion failed", "key", key, "error", err)
+		}
+	}
+}
+
+func hashBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// responseRecorder tees the response body so it can be stored for replays.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,160 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/merchant"
+	"TestTaskJustPay/services/paymanager/internal/merchantauth"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- fakes ---
+
+type fakeStore struct {
+	records map[string]*Record
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{records: make(map[string]*Record)}
+}
+
+func (f *fakeStore) Acquire(_ context.Context, rec Record, _ time.Duration) (*Record, bool, error) {
+	if existing, ok := f.records[rec.MerchantID+"/"+rec.Key]; ok {
+		return existing, false, nil
+	}
+	f.records[rec.MerchantID+"/"+rec.Key] = &rec
+	return nil, true, nil
+}
+
+func (f *fakeStore) Complete(_ context.Context, merchantID, key string, status int, body []byte) error {
+	rec, ok := f.records[merchantID+"/"+key]
+	if !ok {
+		return ErrNotFound
+	}
+	rec.ResponseStatus = &status
+	rec.ResponseBody = append([]byte(nil), body...)
+	return nil
+}
+
+func (f *fakeStore) Release(_ context.Context, merchantID, key string) error {
+	delete(f.records, merchantID+"/"+key)
+	return nil
+}
+
+func newTestEngine(store Store, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(merchantauth.WithMerchant(c.Request.Context(), merchant.Merchant{ID: "merchant_1"}))
+	})
+	engine.POST("/payments", Middleware(store, Config{LockTimeout: time.Minute}),
+		func(c *gin.Context) {
+			*calls++
+			c.JSON(http.StatusOK, gin.H{"call": *calls})
+		})
+	return engine
+}
+
+func doPost(engine *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/payments", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(HeaderName, key)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// --- tests ---
+
+func TestMiddleware_ReplaysStoredResponse(t *testing.T) {
+	var calls int
+	engine := newTestEngine(newFakeStore(), &calls)
+
+	first := doPost(engine, "k1", `{"amount":100}`)
+	second := doPost(engine, "k1", `{"amount":100}`)
+
+	require.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(ReplayedHeader))
+}
+
+func TestMiddleware_RejectsKeyReuseWithDifferentBody(t *testing.T) {
+	var calls int
+	engine := newTestEngine(newFakeStore(), &calls)
+
+	doPost(engine, "k1", `{"amount":100}`)
+	w := doPost(engine, "k1", `{"amount":200}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestMiddleware_ConflictWhileInFlight(t *testing.T) {
+	var calls int
+	store := newFakeStore()
+	engine := newTestEngine(store, &calls)
+
+	body := `{"amount":100}`
+	store.records["merchant_1/k1"] = &Record{
+		MerchantID:  "merchant_1",
+		Key:         "k1",
+		Endpoint:    "POST /payments",
+		RequestHash: hashBody([]byte(body)),
+	}
+
+	w := doPost(engine, "k1", body)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestMiddleware_ReleasesKeyOnServerError(t *testing.T) {
+	store := newFakeStore()
+	var calls int
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(merchantauth.WithMerchant(c.Request.Context(), merchant.Merchant{ID: "merchant_1"}))
+	})
+	engine.POST("/payments", Middleware(store, Config{LockTimeout: time.Minute}),
+		func(c *gin.Context) {
+			calls++
+			if calls == 1 {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "provider unavailable"})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"call": calls})
+		})
+
+	first := doPost(engine, "k1", `{"amount":100}`)
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Empty(t, store.records, "a 5xx releases the key")
+
+	second := doPost(engine, "k1", `{"amount":100}`)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Empty(t, second.Header().Get(ReplayedHeader))
+	assert.Equal(t, 2, calls)
+}
+
+func TestMiddleware_WithoutKeyPassesThrough(t *testing.T) {
+	var calls int
+	store := newFakeStore()
+	engine := newTestEngine(store, &calls)
+
+	doPost(engine, "", `{"amount":100}`)
+	doPost(engine, "", `{"amount":100}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Empty(t, store.records)
+}
@@ -0,0 +1,127 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+type PgStore struct {
+	db      postgres.Executor
+	builder squirrel.StatementBuilderType
+}
+
+var _ Store = (*PgStore)(nil)
+
+func NewPgStore(db postgres.Executor, builder squirrel.StatementBuilderType) *PgStore {
+	return &PgStore{
+		db:      db,
+		builder: builder,
+	}
+}
+
+func (s *PgStore) Acquire(ctx context.Context, rec Record, lockTimeout time.Duration) (*Record, bool, error) {
+	query, args, err := s.builder.Insert("idempotency_keys").
+		Columns("merchant_id", "key", "endpoint", "request_hash", "locked_at", "created_at", "updated_at").
+		Values(rec.MerchantID, rec.Key, rec.Endpoint, rec.RequestHash,
+			squirrel.Expr("now()"), squirrel.Expr("now()"), squirrel.Expr("now()")).
+		Suffix("ON CONFLICT (merchant_id, key) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("build insert query: %w", err)
+	}
+
+	tag, err := s.db.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("insert idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+
+	// Take over a lock abandoned by a request that never completed (crash, lost connection).
+	tag, err = s.db.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET locked_at = now(), updated_at = now()
+		WHERE merchant_id = $1 AND key = $2
+		  AND endpoint = $3 AND request_hash = $4
+		  AND response_status IS NULL
+		  AND locked_at < now() - make_interval(secs => $5)`,
+		rec.MerchantID, rec.Key, rec.Endpoint, rec.RequestHash, lockTimeout.Seconds())
+	if err != nil {
+		return nil, false, fmt.Errorf("take over idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+
+	existing, err := s.get(ctx, rec.MerchantID, rec.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+func (s *PgStore) Complete(ctx context.Context, merchantID, key string, status int, body []byte) error {
+	query, args, err := s.builder.Update("idempotency_keys").
+		Set("response_status", status).
+		Set("response_body", body).
+		Set("locked_at", nil).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"merchant_id": merchantID, "key": key}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update query: %w", err)
+	}
+
+	tag, err := s.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *PgStore) Release(ctx context.Context, merchantID, key string) error {
+	query, args, err := s.builder.Delete("idempotency_keys").
+		Where(squirrel.Eq{"merchant_id": merchantID, "key": key, "response_status": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build delete query: %w", err)
+	}
+
+	if _, err := s.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *PgStore) get(ctx context.Context, merchantID, key string) (*Record, error) {
+	query, args, err := s.builder.Select("merchant_id", "key", "endpoint", "request_hash",
+		"response_status", "response_body", "locked_at", "created_at", "updated_at").
+		From("idempotency_keys").
+		Where(squirrel.Eq{"merchant_id": merchantID, "key": key}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select query: %w", err)
+	}
+
+	var r Record
+	err = s.db.QueryRow(ctx, query, args...).Scan(&r.MerchantID, &r.Key, &r.Endpoint, &r.RequestHash,
+		&r.ResponseStatus, &r.ResponseBody, &r.LockedAt, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("scan idempotency key: %w", err)
+	}
+	return &r, nil
+}
@@ -0,0 +1,41 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Record is a stored Idempotency-Key. ResponseStatus is nil while the first
+// request holding the key is still in flight.
+type Record struct {
+	MerchantID     string
+	Key            string
+	Endpoint       string
+	RequestHash    string
+	ResponseStatus *int
+	ResponseBody   []byte
+	LockedAt       *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Completed reports whether a response has been stored for the key.
+func (r *Record) Completed() bool {
+	return r.ResponseStatus != nil
+}
+
+// Matches reports whether a request with the given endpoint and body hash is a retry of this record.
+func (r *Record) Matches(endpoint, requestHash string) bool {
+	return r.Endpoint == endpoint && r.RequestHash == requestHash
+}
+
+type Store interface {
+	// Acquire locks the key for a new request. When the key already exists it returns
+	// the stored record and acquired=false. An in-flight lock older than lockTimeout is
+	// taken over, so a crashed request does not block its key forever.
+	Acquire(ctx context.Context, rec Record, lockTimeout time.Duration) (existing *Record, acquired bool, err error)
+	// Complete stores the response and releases the lock.
+	Complete(ctx context.Context, merchantID, key string, status int, body []byte) error
+	// Release drops an in-flight key without a response so the request can be retried.
+	Release(ctx context.Context, merchantID, key string) error
+}
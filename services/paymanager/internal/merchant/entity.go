@@ -0,0 +1,48 @@
+package merchant
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+)
+
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusDisabled Status = "disabled"
+)
+
+// Merchant is a tenant of paymanager together with its Silvergate account.
+type Merchant struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// ProviderMerchantID is the merchant's identity at Silvergate.
+	ProviderMerchantID string `json:"provider_merchant_id"`
+	ProviderAPIKey     string `json:"-"`
+	// DefaultCaptureDelay applies to payments created without an explicit capture_delay.
+	DefaultCaptureDelay time.Duration `json:"default_capture_delay"`
+	CreatedAt           time.Time     `json:"created_at"`
+	UpdatedAt           time.Time     `json:"updated_at"`
+}
+
+func (m Merchant) IsActive() bool {
+	return m.Status == StatusActive
+}
+
+// ProviderCredentials returns what the gateway client presents to Silvergate on the merchant's behalf.
+func (m Merchant) ProviderCredentials() gateway.Credentials {
+	return gateway.Credentials{
+		MerchantID: m.ProviderMerchantID,
+		APIKey:     m.ProviderAPIKey,
+	}
+}
+
+// HashAPIKey is the hex SHA-256 under which merchant API keys are stored.
+func HashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,8 @@
+package merchant
+
+import "errors"
+
+var (
+	ErrNotFound = errors.New("merchant not found")
+	ErrDisabled = errors.New("merchant is disabled")
+)
@@ -0,0 +1,10 @@
+package merchant
+
+import "context"
+
+// Repo is the persistence contract for merchants. Lookups return ErrNotFound when absent.
+type Repo interface {
+	GetMerchantByID(ctx context.Context, id string) (*Merchant, error)
+	GetMerchantByAPIKeyHash(ctx context.Context, apiKeyHash string) (*Merchant, error)
+	GetMerchantByProviderMerchantID(ctx context.Context, providerMerchantID string) (*Merchant, error)
+}
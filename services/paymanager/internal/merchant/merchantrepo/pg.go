@@ -0,0 +1,68 @@
+package merchantrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/paymanager/internal/merchant"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var merchantColumns = []string{"id", "name", "status", "provider_merchant_id", "provider_api_key",
+	"default_capture_delay_seconds", "created_at", "updated_at"}
+
+type PgMerchantRepo struct {
+	db      postgres.Executor
+	builder squirrel.StatementBuilderType
+}
+
+// New returns the merchant repository. Reads go to the primary: merchant lookups
+// authenticate requests and must see key rotations and disabled merchants immediately.
+func New(pg *postgres.Postgres) merchant.Repo {
+	return &PgMerchantRepo{db: pg.Pool, builder: pg.Builder}
+}
+
+func (r *PgMerchantRepo) GetMerchantByID(ctx context.Context, id string) (*merchant.Merchant, error) {
+	return r.getBy(ctx, squirrel.Eq{"id": id})
+}
+
+func (r *PgMerchantRepo) GetMerchantByAPIKeyHash(ctx context.Context, apiKeyHash string) (*merchant.Merchant, error) {
+	return r.getBy(ctx, squirrel.Eq{"api_key_hash": apiKeyHash})
+}
+
+func (r *PgMerchantRepo) GetMerchantByProviderMerchantID(ctx context.Context, providerMerchantID string) (*merchant.Merchant, error) {
+	return r.getBy(ctx, squirrel.Eq{"provider_merchant_id": providerMerchantID})
+}
+
+func (r *PgMerchantRepo) getBy(ctx context.Context, pred squirrel.Eq) (*merchant.Merchant, error) {
+	query, args, err := r.builder.
+		Select(merchantColumns...).
+		From("merchants").
+		Where(pred).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select merchant: %w", err)
+	}
+
+	var m merchant.Merchant
+	var apiKey *string
+	var delaySeconds int64
+	err = r.db.QueryRow(ctx, query, args...).Scan(&m.ID, &m.Name, &m.Status, &m.ProviderMerchantID, &apiKey,
+		&delaySeconds, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, merchant.ErrNotFound
+		}
+		return nil, fmt.Errorf("scan merchant: %w", err)
+	}
+	if apiKey != nil {
+		m.ProviderAPIKey = *apiKey
+	}
+	m.DefaultCaptureDelay = time.Duration(delaySeconds) * time.Second
+	return &m, nil
+}
@@ -0,0 +1,60 @@
+package merchant
+
+import (
+	"context"
+	"fmt"
+
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+)
+
+type MerchantService struct {
+	repo Repo
+	// defaultMerchantID owns provider webhooks that carry no merchant id.
+	defaultMerchantID string
+}
+
+func NewMerchantService(repo Repo, defaultMerchantID string) *MerchantService {
+	return &MerchantService{repo: repo, defaultMerchantID: defaultMerchantID}
+}
+
+func (s *MerchantService) GetMerchant(ctx context.Context, id string) (*Merchant, error) {
+	return s.repo.GetMerchantByID(ctx, id)
+}
+
+// Authenticate resolves the merchant owning apiKey. Returns ErrNotFound for unknown
+// keys and ErrDisabled for merchants that may no longer use the API.
+func (s *MerchantService) Authenticate(ctx context.Context, apiKey string) (*Merchant, error) {
+	m, err := s.repo.GetMerchantByAPIKeyHash(ctx, HashAPIKey(apiKey))
+	if err != nil {
+		return nil, err
+	}
+	if !m.IsActive() {
+		return nil, ErrDisabled
+	}
+	return m, nil
+}
+
+// ProviderCredentials returns the Silvergate credentials of merchantID.
+func (s *MerchantService) ProviderCredentials(ctx context.Context, merchantID string) (gateway.Credentials, error) {
+	m, err := s.repo.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		return gateway.Credentials{}, fmt.Errorf("load merchant %s: %w", merchantID, err)
+	}
+	return m.ProviderCredentials(), nil
+}
+
+// ResolveProviderMerchant maps the merchant id a provider webhook carries to our
+// merchant id. Webhooks without one belong to the default merchant.
+func (s *MerchantService) ResolveProviderMerchant(ctx context.Context, providerMerchantID string) (string, error) {
+	if providerMerchantID == "" {
+		if s.defaultMerchantID == "" {
+			return "", ErrNotFound
+		}
+		return s.defaultMerchantID, nil
+	}
+	m, err := s.repo.GetMerchantByProviderMerchantID(ctx, providerMerchantID)
+	if err != nil {
+		return "", fmt.Errorf("resolve provider merchant %s: %w", providerMerchantID, err)
+	}
+	return m.ID, nil
+}
@@ -0,0 +1,111 @@
+// Package merchantauth resolves the merchant a request acts for and surfaces it
+// via context, so handlers and repositories can scope every read and write to it.
+package merchantauth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"TestTaskJustPay/services/paymanager/internal/merchant"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// APIKeyHeader carries the merchant's API key.
+	APIKeyHeader = "X-API-Key"
+	// MerchantHeader names the merchant directly; honoured only when Config.TrustMerchantHeader is set.
+	MerchantHeader = "X-Merchant-ID"
+)
+
+type contextKey struct{}
+
+// FromContext returns the merchant stored in ctx by Middleware.
+// The boolean is false when no merchant was set.
+func FromContext(ctx context.Context) (merchant.Merchant, bool) {
+	m, ok := ctx.Value(contextKey{}).(merchant.Merchant)
+	if !ok || m.ID == "" {
+		return merchant.Merchant{}, false
+	}
+	return m, true
+}
+
+// WithMerchant returns a new context carrying m.
+// Exposed so tests can stub merchant identity without invoking the middleware.
+func WithMerchant(ctx context.Context, m merchant.Merchant) context.Context {
+	return context.WithValue(ctx, contextKey{}, m)
+}
+
+// Merchants is the lookup contract the middleware needs.
+type Merchants interface {
+	Authenticate(ctx context.Context, apiKey string) (*merchant.Merchant, error)
+	GetMerchant(ctx context.Context, id string) (*merchant.Merchant, error)
+}
+
+type Config struct {
+	// DefaultMerchantID serves requests that carry no credentials when AllowDefaultMerchant
+	// is set. Otherwise such requests get 401.
+	DefaultMerchantID string
+	// AllowDefaultMerchant lets unauthenticated callers act as DefaultMerchantID. Only for
+	// local development: anyone reaching the API can then use that merchant's payments.
+	AllowDefaultMerchant bool
+	// TrustMerchantHeader accepts X-Merchant-ID without an API key. Only for deployments
+	// behind a gateway that authenticates merchants itself.
+	TrustMerchantHeader bool
+}
+
+// Middleware resolves the merchant from X-API-Key (or a Bearer token), then from
+// X-Merchant-ID when trusted, then falls back to the default merchant when that is
+// allowed. Missing or unknown credentials get 401 and disabled merchants 403.
+func Middleware(merchants Merchants, cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+
+		var m *merchant.Merchant
+		var err error
+		switch apiKey, merchantID := requestAPIKey(c), c.GetHeader(MerchantHeader); {
+		case apiKey != "":
+			m, err = merchants.Authenticate(ctx, apiKey)
+		case merchantID != "" && cfg.TrustMerchantHeader:
+			m, err = merchants.GetMerchant(ctx, merchantID)
+		case cfg.AllowDefaultMerchant && cfg.DefaultMerchantID != "":
+			m, err = merchants.GetMerchant(ctx, cfg.DefaultMerchantID)
+		default:
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing merchant credentials"})
+			return
+		}
+
+		switch {
+		case errors.Is(err, merchant.ErrNotFound):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid merchant credentials"})
+			return
+		case errors.Is(err, merchant.ErrDisabled):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "merchant is disabled"})
+			return
+		case err != nil:
+			slog.ErrorContext(ctx, "merchant lookup failed", "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "merchant lookup failed"})
+			return
+		}
+		if !m.IsActive() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "merchant is disabled"})
+			return
+		}
+
+		c.Request = c.Request.WithContext(WithMerchant(ctx, *m))
+		c.Next()
+	}
+}
+
+func requestAPIKey(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
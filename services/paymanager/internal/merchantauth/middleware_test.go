@@ -0,0 +1,152 @@
+package merchantauth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"TestTaskJustPay/services/paymanager/internal/merchant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// --- fakes ---
+
+type fakeMerchants struct {
+	byID  map[string]merchant.Merchant
+	byKey map[string]string
+}
+
+func (f *fakeMerchants) Authenticate(_ context.Context, apiKey string) (*merchant.Merchant, error) {
+	id, ok := f.byKey[apiKey]
+	if !ok {
+		return nil, merchant.ErrNotFound
+	}
+	m := f.byID[id]
+	if !m.IsActive() {
+		return nil, merchant.ErrDisabled
+	}
+	return &m, nil
+}
+
+func (f *fakeMerchants) GetMerchant(_ context.Context, id string) (*merchant.Merchant, error) {
+	m, ok := f.byID[id]
+	if !ok {
+		return nil, merchant.ErrNotFound
+	}
+	return &m, nil
+}
+
+func newTestEngine(cfg Config) *gin.Engine {
+	merchants := &fakeMerchants{
+		byID: map[string]merchant.Merchant{
+			"merchant_1": {ID: "merchant_1", Status: merchant.StatusActive},
+			"merchant_2": {ID: "merchant_2", Status: merchant.StatusActive},
+			"merchant_3": {ID: "merchant_3", Status: merchant.StatusDisabled},
+		},
+		byKey: map[string]string{"key-2": "merchant_2", "key-3": "merchant_3"},
+	}
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/whoami", Middleware(merchants, cfg), func(c *gin.Context) {
+		m, _ := FromContext(c.Request.Context())
+		c.String(http.StatusOK, m.ID)
+	})
+	return engine
+}
+
+func doGet(engine *gin.Engine, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+// --- tests ---
+
+func TestMiddleware_ResolvesMerchant(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		headers  map[string]string
+		wantCode int
+		wantID   string
+	}{
+		{
+			name:     "api key",
+			cfg:      Config{DefaultMerchantID: "merchant_1"},
+			headers:  map[string]string{APIKeyHeader: "key-2"},
+			wantCode: http.StatusOK,
+			wantID:   "merchant_2",
+		},
+		{
+			name:     "bearer token",
+			headers:  map[string]string{"Authorization": "Bearer key-2"},
+			wantCode: http.StatusOK,
+			wantID:   "merchant_2",
+		},
+		{
+			name:     "default merchant without credentials",
+			cfg:      Config{DefaultMerchantID: "merchant_1", AllowDefaultMerchant: true},
+			wantCode: http.StatusOK,
+			wantID:   "merchant_1",
+		},
+		{
+			name:     "no credentials and default merchant not allowed",
+			cfg:      Config{DefaultMerchantID: "merchant_1"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "trusted merchant header",
+			cfg:      Config{DefaultMerchantID: "merchant_1", TrustMerchantHeader: true},
+			headers:  map[string]string{MerchantHeader: "merchant_2"},
+			wantCode: http.StatusOK,
+			wantID:   "merchant_2",
+		},
+		{
+			name:     "untrusted merchant header falls back to default",
+			cfg:      Config{DefaultMerchantID: "merchant_1", AllowDefaultMerchant: true},
+			headers:  map[string]string{MerchantHeader: "merchant_2"},
+			wantCode: http.StatusOK,
+			wantID:   "merchant_1",
+		},
+		{
+			name:     "unknown api key",
+			cfg:      Config{DefaultMerchantID: "merchant_1"},
+			headers:  map[string]string{APIKeyHeader: "nope"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "no credentials and no default",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "disabled merchant",
+			headers:  map[string]string{APIKeyHeader: "key-3"},
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "disabled merchant via trusted header",
+			cfg:      Config{TrustMerchantHeader: true},
+			headers:  map[string]string{MerchantHeader: "merchant_3"},
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doGet(newTestEngine(tt.cfg), tt.headers)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantID != "" {
+				assert.Equal(t, tt.wantID, w.Body.String())
+			}
+		})
+	}
+}
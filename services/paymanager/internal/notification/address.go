@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// publicIP reports whether ip is routable on the public internet. Merchant endpoints
+// must not point the dispatcher at loopback, private, link-local (cloud metadata) or
+// otherwise internal addresses.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// checkHost rejects endpoint hosts that are internal by name or by literal address.
+// Hostnames are resolved at dial time, where NewHTTPClient repeats the check against
+// the address actually connected to.
+func checkHost(host string) error {
+	h := strings.TrimSuffix(strings.ToLower(host), ".")
+	if h == "localhost" || strings.HasSuffix(h, ".localhost") {
+		return fmt.Errorf("%w: host %q is not publicly routable", ErrInvalidEndpoint, host)
+	}
+	if ip := net.ParseIP(h); ip != nil && !publicIP(ip) {
+		return fmt.Errorf("%w: host %q is not publicly routable", ErrInvalidEndpoint, host)
+	}
+	return nil
+}
+
+// NewHTTPClient returns the client used to deliver webhooks. It refuses to connect to
+// non-public addresses, so a hostname that resolves (or is re-pointed) to an internal
+// address cannot be used to reach internal services. Proxies are not used, as the check
+// would then apply to the proxy instead of the endpoint.
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("%w: address %s is not publicly routable", ErrInvalidEndpoint, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,193 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"TestTaskJustPay/pkg/metrics"
+	"TestTaskJustPay/pkg/webhooksig"
+
+	"github.com/google/uuid"
+)
+
+// Headers set on every delivery. The signature is the webhooksig header value, so
+// merchants verify it with the same scheme as provider webhooks.
+const (
+	HeaderSignature = webhooksig.Header
+	HeaderID        = "X-Webhook-Id"
+	HeaderEvent     = "X-Webhook-Event"
+)
+
+// DispatcherConfig holds configuration for the merchant webhook dispatcher.
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// SettleDelay holds back events younger than this from fan-out, so a transaction that
+	// stamped an earlier created_at can commit before the cursor moves past it.
+	SettleDelay time.Duration
+	// LeaseTimeout is how long a claimed delivery stays owned by one replica
+	// before another replica may retry it.
+	LeaseTimeout time.Duration
+	MaxAttempts  int
+	// RetryBackoff is the delay after the first failed attempt; it doubles per attempt up to MaxBackoff.
+	RetryBackoff time.Duration
+	MaxBackoff   time.Duration
+}
+
+// Dispatcher uses the unified events table as an outbox: payment events are fanned
+// out into per-endpoint deliveries, which are then POSTed with an HMAC signature and
+// retried with exponential backoff. All state lives in Postgres, so several replicas
+// can run the dispatcher concurrently.
+type Dispatcher struct {
+	repo   OutboxRepo
+	client *http.Client
+	cfg    DispatcherConfig
+}
+
+func NewDispatcher(repo OutboxRepo, client *http.Client, cfg DispatcherConfig) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		client: client,
+		cfg:    cfg,
+	}
+}
+
+// Start begins the polling loop. Blocks until ctx is cancelled.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	slog.Info("Webhook dispatcher started",
+		"poll_interval", d.cfg.PollInterval,
+		"batch_size", d.cfg.BatchSize,
+		"max_attempts", d.cfg.MaxAttempts)
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Webhook dispatcher stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			d.poll(ctx)
+		}
+	}
+}
+
+func (d *Dispatcher) poll(ctx context.Context) {
+	created, err := d.repo.FanOut(ctx, Routes, time.Now().UTC().Add(-d.cfg.SettleDelay), d.cfg.BatchSize)
+	if err != nil {
+		slog.Error("Failed to fan out webhook events", slog.Any("error", err))
+	}
+	metrics.WebhookDeliveriesCreatedTotal.Add(float64(created))
+
+	claims, err := d.repo.ClaimDueDeliveries(ctx, d.cfg.BatchSize, d.cfg.LeaseTimeout)
+	if err != nil {
+		slog.Error("Failed to claim webhook deliveries", slog.Any("error", err))
+		return
+	}
+	for _, c := range claims {
+		d.deliver(ctx, c)
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, c DeliveryClaim) {
+	started := time.Now()
+	statusCode, sendErr := d.send(ctx, c)
+	elapsed := time.Since(started)
+
+	result := AttemptResult{
+		Attempt: Attempt{
+			ID:         uuid.New().String(),
+			DeliveryID: c.ID,
+			Attempt:    c.Attempts,
+			DurationMS: elapsed.Milliseconds(),
+			CreatedAt:  time.Now().UTC(),
+		},
+	}
+	if statusCode != 0 {
+		result.StatusCode = &statusCode
+	}
+
+	switch {
+	case sendErr == nil:
+		result.Status = DeliverySucceeded
+	case c.Attempts >= d.cfg.MaxAttempts:
+		result.Status = DeliveryFailed
+		result.Error = sendErr.Error()
+	default:
+		result.Status = DeliveryPending
+		result.Error = sendErr.Error()
+		retryAt := time.Now().UTC().Add(d.backoff(c.Attempts))
+		result.NextAttemptAt = &retryAt
+	}
+	metrics.WebhookDeliveryAttemptsTotal.WithLabelValues(string(result.Status)).Inc()
+	metrics.WebhookDeliveryDuration.Observe(elapsed.Seconds())
+
+	if result.Status != DeliverySucceeded {
+		slog.Warn("Webhook delivery attempt failed",
+			"delivery_id", c.ID,
+			"endpoint_id", c.EndpointID,
+			"event_type", c.EventType,
+			"attempt", c.Attempts,
+			"status", result.Status,
+			"error", sendErr)
+	}
+
+	if err := d.repo.CompleteAttempt(ctx, result); err != nil {
+		slog.Error("Failed to record webhook delivery attempt",
+			"delivery_id", c.ID, slog.Any("error", err))
+	}
+}
+
+// send POSTs the delivery. Any non-2xx response counts as a failure.
+func (d *Dispatcher) send(ctx context.Context, c DeliveryClaim) (int, error) {
+	body, err := json.Marshal(Message{
+		ID:        c.EventID,
+		Type:      c.EventType,
+		PaymentID: c.PaymentID,
+		CreatedAt: c.EventCreatedAt,
+		Data:      c.Payload,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("marshal message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, c.ID)
+	req.Header.Set(HeaderEvent, string(c.EventType))
+	req.Header.Set(HeaderSignature, webhooksig.HeaderValue([]string{c.Secret}, time.Now().Unix(), body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff returns RetryBackoff * 2^(attempt-1), capped at MaxBackoff.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.cfg.RetryBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= d.cfg.MaxBackoff {
+			return d.cfg.MaxBackoff
+		}
+	}
+	return min(delay, d.cfg.MaxBackoff)
+}
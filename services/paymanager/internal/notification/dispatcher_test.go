@@ -0,0 +1,179 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/pkg/webhooksig"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- fakes ---
+
+type fakeOutbox struct {
+	claims  []DeliveryClaim
+	results []AttemptResult
+}
+
+func (f *fakeOutbox) FanOut(_ context.Context, _ map[string]EventType, _ time.Time, _ int) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeOutbox) ClaimDueDeliveries(_ context.Context, _ int, _ time.Duration) ([]DeliveryClaim, error) {
+	claims := f.claims
+	f.claims = nil
+	return claims, nil
+}
+
+func (f *fakeOutbox) CompleteAttempt(_ context.Context, res AttemptResult) error {
+	f.results = append(f.results, res)
+	return nil
+}
+
+func newTestDispatcher(repo OutboxRepo) *Dispatcher {
+	return NewDispatcher(repo, http.DefaultClient, DispatcherConfig{
+		PollInterval: time.Second,
+		BatchSize:    10,
+		SettleDelay:  time.Second,
+		LeaseTimeout: time.Minute,
+		MaxAttempts:  3,
+		RetryBackoff: 10 * time.Second,
+		MaxBackoff:   time.Minute,
+	})
+}
+
+func testClaim(url string, attempts int) DeliveryClaim {
+	return DeliveryClaim{
+		Delivery: Delivery{
+			ID:         "del-1",
+			EndpointID: "ep-1",
+			EventID:    "evt-1",
+			EventType:  EventPaymentCaptured,
+			Status:     DeliveryPending,
+			Attempts:   attempts,
+		},
+		URL:            url,
+		Secret:         "whsec_test",
+		PaymentID:      "pay-1",
+		Payload:        json.RawMessage(`{"new_status":"captured","captured_amount":1000}`),
+		EventCreatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+// --- tests ---
+
+func TestDispatcher_DeliversSignedMessage(t *testing.T) {
+	var gotHeaders http.Header
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header.Clone()
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := &fakeOutbox{claims: []DeliveryClaim{testClaim(server.URL, 1)}}
+	newTestDispatcher(repo).poll(context.Background())
+
+	require.Len(t, repo.results, 1)
+	res := repo.results[0]
+	assert.Equal(t, DeliverySucceeded, res.Status)
+	assert.Equal(t, "del-1", res.DeliveryID)
+	assert.Equal(t, 1, res.Attempt.Attempt)
+	require.NotNil(t, res.StatusCode)
+	assert.Equal(t, http.StatusNoContent, *res.StatusCode)
+	assert.Nil(t, res.NextAttemptAt)
+
+	var msg Message
+	require.NoError(t, json.Unmarshal(gotBody, &msg))
+	assert.Equal(t, "evt-1", msg.ID)
+	assert.Equal(t, EventPaymentCaptured, msg.Type)
+	assert.Equal(t, "pay-1", msg.PaymentID)
+	assert.JSONEq(t, `{"new_status":"captured","captured_amount":1000}`, string(msg.Data))
+
+	assert.Equal(t, "del-1", gotHeaders.Get(HeaderID))
+	assert.Equal(t, "payment.captured", gotHeaders.Get(HeaderEvent))
+
+	assert.NoError(t, webhooksig.Verify(gotHeaders.Get(HeaderSignature), gotBody, []string{"whsec_test"}, time.Now(), time.Minute))
+}
+
+func TestDispatcher_SchedulesRetryWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := &fakeOutbox{claims: []DeliveryClaim{testClaim(server.URL, 2)}}
+	before := time.Now().UTC()
+	newTestDispatcher(repo).poll(context.Background())
+
+	require.Len(t, repo.results, 1)
+	res := repo.results[0]
+	assert.Equal(t, DeliveryPending, res.Status)
+	require.NotNil(t, res.StatusCode)
+	assert.Equal(t, http.StatusServiceUnavailable, *res.StatusCode)
+	assert.Contains(t, res.Error, "503")
+	require.NotNil(t, res.NextAttemptAt)
+	// second attempt failed: 10s * 2
+	assert.WithinDuration(t, before.Add(20*time.Second), *res.NextAttemptAt, 2*time.Second)
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	repo := &fakeOutbox{claims: []DeliveryClaim{testClaim("http://127.0.0.1:1", 3)}}
+	newTestDispatcher(repo).poll(context.Background())
+
+	require.Len(t, repo.results, 1)
+	res := repo.results[0]
+	assert.Equal(t, DeliveryFailed, res.Status)
+	assert.Nil(t, res.StatusCode)
+	assert.Nil(t, res.NextAttemptAt)
+	assert.NotEmpty(t, res.Error)
+}
+
+func TestDispatcher_BackoffIsCapped(t *testing.T) {
+	d := newTestDispatcher(&fakeOutbox{})
+
+	assert.Equal(t, 10*time.Second, d.backoff(1))
+	assert.Equal(t, 20*time.Second, d.backoff(2))
+	assert.Equal(t, 40*time.Second, d.backoff(3))
+	assert.Equal(t, time.Minute, d.backoff(4))
+	assert.Equal(t, time.Minute, d.backoff(50))
+}
+
+func TestCreateEndpointRequest_Validate(t *testing.T) {
+	valid := CreateEndpointRequest{URL: "https://merchant.example/hooks", EventTypes: []EventType{EventPaymentCaptured}}
+	assert.NoError(t, valid.Validate())
+
+	for name, req := range map[string]CreateEndpointRequest{
+		"relative url":  {URL: "/hooks", EventTypes: []EventType{EventPaymentCaptured}},
+		"bad scheme":    {URL: "ftp://merchant.example", EventTypes: []EventType{EventPaymentCaptured}},
+		"no event type": {URL: "https://merchant.example/hooks"},
+		"unknown type":  {URL: "https://merchant.example/hooks", EventTypes: []EventType{"payment.capture_requested"}},
+		"localhost":     {URL: "http://localhost:8080/hooks", EventTypes: []EventType{EventPaymentCaptured}},
+		"loopback":      {URL: "http://127.0.0.1/hooks", EventTypes: []EventType{EventPaymentCaptured}},
+		"private":       {URL: "https://10.0.0.5/hooks", EventTypes: []EventType{EventPaymentCaptured}},
+		"link local":    {URL: "http://169.254.169.254/latest/meta-data", EventTypes: []EventType{EventPaymentCaptured}},
+		"ipv6 loopback": {URL: "http://[::1]/hooks", EventTypes: []EventType{EventPaymentCaptured}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, req.Validate(), ErrInvalidEndpoint)
+		})
+	}
+}
+
+func TestNewHTTPClient_RefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, err := NewHTTPClient(time.Second).Get(server.URL)
+	assert.ErrorIs(t, err, ErrInvalidEndpoint)
+}
@@ -0,0 +1,155 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// EventType is a merchant-facing event name that endpoints subscribe to.
+type EventType string
+
+const (
+	EventPaymentCreated           EventType = "payment.created"
+	EventPaymentAuthorized        EventType = "payment.authorized"
+	EventPaymentDeclined          EventType = "payment.declined"
+	EventPaymentCaptured          EventType = "payment.captured"
+	EventPaymentPartiallyCaptured EventType = "payment.partially_captured"
+	EventPaymentCaptureFailed     EventType = "payment.capture_failed"
+	EventPaymentVoided            EventType = "payment.voided"
+	EventPaymentExpired           EventType = "payment.expired"
+	EventPaymentRefunded          EventType = "payment.refunded"
+	EventPaymentRefundFailed      EventType = "payment.refund_failed"
+)
+
+// Routes maps payment event types in the unified events table to the event type
+// merchants see. Events not listed here (capture/refund requests) are internal.
+var Routes = map[string]EventType{
+	"payment.created":                EventPaymentCreated,
+	"transaction.authorized":         EventPaymentAuthorized,
+	"transaction.declined":           EventPaymentDeclined,
+	"payment.challenge_expired":      EventPaymentExpired,
+	"transaction.captured":           EventPaymentCaptured,
+	"transaction.partially_captured": EventPaymentPartiallyCaptured,
+	"transaction.capture_failed":     EventPaymentCaptureFailed,
+	"payment.voided":                 EventPaymentVoided,
+	"transaction.expired":            EventPaymentExpired,
+	"transaction.refunded":           EventPaymentRefunded,
+	"transaction.refund_failed":      EventPaymentRefundFailed,
+}
+
+func (t EventType) IsValid() bool {
+	for _, r := range Routes {
+		if r == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Endpoint is a merchant URL subscribed to a set of event types.
+// Secret is only returned when the endpoint is created.
+type Endpoint struct {
+	ID         string      `json:"id"`
+	MerchantID string      `json:"merchant_id"`
+	URL        string      `json:"url"`
+	Secret     string      `json:"secret,omitempty"`
+	EventTypes []EventType `json:"event_types"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type CreateEndpointRequest struct {
+	URL        string      `json:"url" binding:"required"`
+	EventTypes []EventType `json:"event_types" binding:"required,min=1"`
+}
+
+func (r CreateEndpointRequest) Validate() error {
+	u, err := url.Parse(r.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidEndpoint)
+	}
+	if err := checkHost(u.Hostname()); err != nil {
+		return err
+	}
+	if len(r.EventTypes) == 0 {
+		return fmt.Errorf("%w: at least one event type is required", ErrInvalidEndpoint)
+	}
+	for _, t := range r.EventTypes {
+		if !t.IsValid() {
+			return fmt.Errorf("%w: unknown event type %q", ErrInvalidEndpoint, t)
+		}
+	}
+	return nil
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one event queued for one endpoint.
+type Delivery struct {
+	ID             string         `json:"id"`
+	EndpointID     string         `json:"endpoint_id"`
+	EventID        string         `json:"event_id"`
+	EventType      EventType      `json:"event_type"`
+	Status         DeliveryStatus `json:"status"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  *time.Time     `json:"next_attempt_at,omitempty"`
+	LastStatusCode *int           `json:"last_status_code,omitempty"`
+	LastError      string         `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	// AttemptLog is only populated when a single delivery is fetched.
+	AttemptLog []Attempt `json:"attempt_log,omitempty"`
+}
+
+// Attempt is a single HTTP call made for a delivery.
+type Attempt struct {
+	ID         string    `json:"id"`
+	DeliveryID string    `json:"delivery_id"`
+	Attempt    int       `json:"attempt"`
+	StatusCode *int      `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMS int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DeliveryClaim is a due delivery leased to a dispatcher together with everything needed to send it.
+type DeliveryClaim struct {
+	Delivery
+	URL            string
+	Secret         string
+	PaymentID      string
+	Payload        json.RawMessage
+	EventCreatedAt time.Time
+}
+
+// AttemptResult is the outcome of sending a claimed delivery. NextAttemptAt is set
+// when Status is still pending.
+type AttemptResult struct {
+	Attempt
+	Status        DeliveryStatus
+	NextAttemptAt *time.Time
+}
+
+// Message is the JSON body POSTed to merchant endpoints. ID is the source event id and
+// stays the same across retries and redeliveries, so merchants can deduplicate on it.
+type Message struct {
+	ID        string          `json:"id"`
+	Type      EventType       `json:"type"`
+	PaymentID string          `json:"payment_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type DeliveryQuery struct {
+	Status DeliveryStatus `form:"status"`
+	Limit  int            `form:"limit"`
+}
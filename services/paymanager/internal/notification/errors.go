@@ -0,0 +1,9 @@
+package notification
+
+import "errors"
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidEndpoint  = errors.New("invalid webhook endpoint")
+)
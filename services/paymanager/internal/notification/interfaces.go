@@ -0,0 +1,31 @@
+package notification
+
+import (
+	"context"
+	"time"
+)
+
+// Repo is the persistence contract for endpoint management and the delivery log.
+type Repo interface {
+	CreateEndpoint(ctx context.Context, e Endpoint) error
+	ListEndpoints(ctx context.Context, merchantID string) ([]Endpoint, error)
+	// GetEndpoint returns ErrEndpointNotFound unless the endpoint belongs to merchantID.
+	GetEndpoint(ctx context.Context, merchantID, id string) (*Endpoint, error)
+	ListDeliveries(ctx context.Context, endpointID string, query DeliveryQuery) ([]Delivery, error)
+	// GetDelivery returns the delivery with its attempt log, or ErrDeliveryNotFound.
+	GetDelivery(ctx context.Context, endpointID, id string) (*Delivery, error)
+	// Redeliver resets the delivery to pending with a fresh attempt budget.
+	Redeliver(ctx context.Context, endpointID, id string) (*Delivery, error)
+}
+
+// OutboxRepo drives delivery from the unified events table.
+type OutboxRepo interface {
+	// FanOut creates a pending delivery for every subscribed endpoint of the next payment
+	// events after the fan-out cursor, created before `before`, and advances the cursor.
+	// Returns the number of deliveries created.
+	FanOut(ctx context.Context, routes map[string]EventType, before time.Time, limit int) (int, error)
+	// ClaimDueDeliveries leases pending deliveries whose next attempt is due and counts the attempt.
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]DeliveryClaim, error)
+	// CompleteAttempt appends the attempt to the delivery log and applies the outcome.
+	CompleteAttempt(ctx context.Context, result AttemptResult) error
+}
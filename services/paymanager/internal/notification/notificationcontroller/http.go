@@ -0,0 +1,122 @@
+package notificationcontroller
+
+import (
+	"errors"
+	"net/http"
+
+	"TestTaskJustPay/services/paymanager/internal/merchantauth"
+	"TestTaskJustPay/services/paymanager/internal/notification"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HTTPHandler struct {
+	service *notification.NotificationService
+}
+
+func NewHTTPHandler(s *notification.NotificationService) *HTTPHandler {
+	return &HTTPHandler{service: s}
+}
+
+func (h *HTTPHandler) CreateEndpoint(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	var req notification.CreateEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endpoint, err := h.service.CreateEndpoint(c.Request.Context(), m.ID, req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, endpoint)
+}
+
+func (h *HTTPHandler) ListEndpoints(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	endpoints, err := h.service.ListEndpoints(c.Request.Context(), m.ID)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": endpoints})
+}
+
+func (h *HTTPHandler) ListDeliveries(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	var query notification.DeliveryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), m.ID, c.Param("id"), query)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": deliveries})
+}
+
+func (h *HTTPHandler) GetDelivery(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	delivery, err := h.service.GetDelivery(c.Request.Context(), m.ID, c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, delivery)
+}
+
+func (h *HTTPHandler) Redeliver(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	delivery, err := h.service.Redeliver(c.Request.Context(), m.ID, c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func writeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, notification.ErrInvalidEndpoint):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, notification.ErrEndpointNotFound), errors.Is(err, notification.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,396 @@
+package notificationrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/paymanager/internal/notification"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var endpointColumns = []string{"id", "merchant_id", "url", "event_types", "created_at", "updated_at"}
+
+var deliveryColumns = []string{"id", "endpoint_id", "event_id", "event_type", "status", "attempts",
+	"next_attempt_at", "last_status_code", "last_error", "delivered_at", "created_at", "updated_at"}
+
+var attemptColumns = []string{"id", "delivery_id", "attempt", "status_code", "error", "duration_ms", "created_at"}
+
+type PgRepo struct {
+	pg      *postgres.Postgres
+	readDB  postgres.Executor
+	builder squirrel.StatementBuilderType
+}
+
+var (
+	_ notification.Repo       = (*PgRepo)(nil)
+	_ notification.OutboxRepo = (*PgRepo)(nil)
+)
+
+// New returns the notification repository. Outbox claims, endpoint lookups and
+// redeliveries go to the primary; delivery log listings come from readDB.
+func New(pg *postgres.Postgres, readDB postgres.Executor) *PgRepo {
+	return &PgRepo{pg: pg, readDB: readDB, builder: pg.Builder}
+}
+
+func (r *PgRepo) CreateEndpoint(ctx context.Context, e notification.Endpoint) error {
+	query, args, err := r.builder.Insert("webhook_endpoints").
+		Columns("id", "merchant_id", "url", "secret", "event_types", "created_at", "updated_at").
+		Values(e.ID, e.MerchantID, e.URL, e.Secret, eventTypesToStrings(e.EventTypes), e.CreatedAt, e.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert endpoint: %w", err)
+	}
+
+	if _, err := r.pg.Pool.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("insert endpoint: %w", err)
+	}
+	return nil
+}
+
+func (r *PgRepo) ListEndpoints(ctx context.Context, merchantID string) ([]notification.Endpoint, error) {
+	query, args, err := r.builder.
+		Select(endpointColumns...).
+		From("webhook_endpoints").
+		Where(squirrel.Eq{"merchant_id": merchantID}).
+		OrderBy("created_at ASC", "id ASC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select endpoints: %w", err)
+	}
+
+	rows, err := r.readDB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	endpoints := make([]notification.Endpoint, 0)
+	for rows.Next() {
+		e, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+func (r *PgRepo) GetEndpoint(ctx context.Context, merchantID, id string) (*notification.Endpoint, error) {
+	query, args, err := r.builder.
+		Select(endpointColumns...).
+		From("webhook_endpoints").
+		Where(squirrel.Eq{"id": id, "merchant_id": merchantID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select endpoint: %w", err)
+	}
+
+	e, err := scanEndpoint(r.pg.Pool.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notification.ErrEndpointNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *PgRepo) ListDeliveries(ctx context.Context, endpointID string, q notification.DeliveryQuery) ([]notification.Delivery, error) {
+	b := r.builder.
+		Select(deliveryColumns...).
+		From("webhook_deliveries").
+		Where(squirrel.Eq{"endpoint_id": endpointID}).
+		OrderBy("created_at DESC", "id DESC").
+		Limit(uint64(q.Limit))
+	if q.Status != "" {
+		b = b.Where(squirrel.Eq{"status": q.Status})
+	}
+
+	query, args, err := b.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select deliveries: %w", err)
+	}
+
+	rows, err := r.readDB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]notification.Delivery, 0)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (r *PgRepo) GetDelivery(ctx context.Context, endpointID, id string) (*notification.Delivery, error) {
+	query, args, err := r.builder.
+		Select(deliveryColumns...).
+		From("webhook_deliveries").
+		Where(squirrel.Eq{"id": id, "endpoint_id": endpointID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select delivery: %w", err)
+	}
+
+	d, err := scanDelivery(r.readDB.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notification.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err = r.builder.
+		Select(attemptColumns...).
+		From("webhook_delivery_attempts").
+		Where(squirrel.Eq{"delivery_id": id}).
+		OrderBy("created_at ASC", "id ASC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select attempts: %w", err)
+	}
+
+	rows, err := r.readDB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query attempts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a notification.Attempt
+		var errMsg *string
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &a.StatusCode, &errMsg, &a.DurationMS, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan attempt: %w", err)
+		}
+		if errMsg != nil {
+			a.Error = *errMsg
+		}
+		d.AttemptLog = append(d.AttemptLog, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate attempts: %w", err)
+	}
+	return d, nil
+}
+
+func (r *PgRepo) Redeliver(ctx context.Context, endpointID, id string) (*notification.Delivery, error) {
+	query, args, err := r.builder.Update("webhook_deliveries").
+		Set("status", notification.DeliveryPending).
+		Set("attempts", 0).
+		Set("next_attempt_at", squirrel.Expr("now()")).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id, "endpoint_id": endpointID}).
+		Suffix("RETURNING " + strings.Join(deliveryColumns, ", ")).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build redeliver: %w", err)
+	}
+
+	d, err := scanDelivery(r.pg.Pool.QueryRow(ctx, query, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, notification.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// FanOut advances the fan-out cursor over payment events and inserts one delivery per
+// subscribed endpoint, all in a single statement. The cursor row is locked by the
+// statement, so replicas take turns instead of racing. Only events created before `before` are read,
+// which leaves time for transactions that stamped created_at earlier to commit before the
+// cursor moves past them. Endpoints only receive events created after they were registered.
+func (r *PgRepo) FanOut(ctx context.Context, routes map[string]notification.EventType, before time.Time, limit int) (int, error) {
+	sources := make([]string, 0, len(routes))
+	types := make([]string, 0, len(routes))
+	for source, t := range routes {
+		sources = append(sources, source)
+		types = append(types, string(t))
+	}
+
+	query := `
+		WITH pos AS (
+			SELECT last_created_at, last_event_id
+			FROM webhook_fanout_cursor
+			FOR UPDATE
+		), batch AS (
+			SELECT e.id, e.event_type, e.created_at, p.merchant_id
+			FROM events e
+			JOIN payments p ON p.id::text = e.aggregate_id
+			CROSS JOIN pos cur
+			WHERE e.aggregate_type = 'payment'
+			  AND e.event_type = ANY($1)
+			  AND (e.created_at, e.id) > (cur.last_created_at, cur.last_event_id)
+			  AND e.created_at < $3
+			ORDER BY e.created_at ASC, e.id ASC
+			LIMIT $4
+		), advanced AS (
+			UPDATE webhook_fanout_cursor
+			SET last_created_at = newest.created_at,
+			    last_event_id = newest.id,
+			    updated_at = now()
+			FROM (SELECT id, created_at FROM batch ORDER BY created_at DESC, id DESC LIMIT 1) newest
+			RETURNING 1
+		)
+		INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, status, attempts, next_attempt_at, created_at, updated_at)
+		SELECT gen_random_uuid(), ep.id, b.id, m.type, 'pending', 0, now(), now(), now()
+		FROM batch b
+		JOIN unnest($1::text[], $2::text[]) AS m(source, type) ON m.source = b.event_type
+		JOIN webhook_endpoints ep
+		  ON ep.merchant_id = b.merchant_id
+		 AND m.type = ANY(ep.event_types)
+		 AND ep.created_at <= b.created_at
+		ON CONFLICT (endpoint_id, event_id) DO NOTHING`
+
+	tag, err := r.pg.Pool.Exec(ctx, query, sources, types, before.UTC(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("fan out events: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// ClaimDueDeliveries leases due pending deliveries by pushing next_attempt_at out by lease.
+// A dispatcher that dies mid-send leaves the delivery to be retried once the lease expires.
+func (r *PgRepo) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]notification.DeliveryClaim, error) {
+	query := `
+		WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET attempts = attempts + 1,
+			    next_attempt_at = now() + make_interval(secs => $2),
+			    updated_at = now()
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= now()
+				ORDER BY next_attempt_at ASC
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, endpoint_id, event_id, event_type, status, attempts, created_at
+		)
+		SELECT c.id, c.endpoint_id, c.event_id, c.event_type, c.status, c.attempts, c.created_at,
+		       ep.url, ep.secret, e.aggregate_id, e.payload, e.created_at
+		FROM claimed c
+		JOIN webhook_endpoints ep ON ep.id = c.endpoint_id
+		JOIN events e ON e.id = c.event_id`
+
+	rows, err := r.pg.Pool.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var claims []notification.DeliveryClaim
+	for rows.Next() {
+		var c notification.DeliveryClaim
+		if err := rows.Scan(&c.ID, &c.EndpointID, &c.EventID, &c.EventType, &c.Status, &c.Attempts, &c.CreatedAt,
+			&c.URL, &c.Secret, &c.PaymentID, &c.Payload, &c.EventCreatedAt); err != nil {
+			return nil, fmt.Errorf("scan delivery claim: %w", err)
+		}
+		claims = append(claims, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate delivery claims: %w", err)
+	}
+	return claims, nil
+}
+
+func (r *PgRepo) CompleteAttempt(ctx context.Context, res notification.AttemptResult) error {
+	return r.pg.InTransaction(ctx, pgx.ReadCommitted, func(tx postgres.Executor) error {
+		query, args, err := r.builder.Insert("webhook_delivery_attempts").
+			Columns(attemptColumns...).
+			Values(res.ID, res.DeliveryID, res.Attempt.Attempt, res.StatusCode, nilIfEmpty(res.Error), res.DurationMS, res.CreatedAt).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert attempt: %w", err)
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("insert attempt: %w", err)
+		}
+
+		update := r.builder.Update("webhook_deliveries").
+			Set("status", res.Status).
+			Set("next_attempt_at", res.NextAttemptAt).
+			Set("last_status_code", res.StatusCode).
+			Set("last_error", nilIfEmpty(res.Error)).
+			Set("updated_at", squirrel.Expr("now()")).
+			Where(squirrel.Eq{"id": res.DeliveryID})
+		if res.Status == notification.DeliverySucceeded {
+			update = update.Set("delivered_at", res.CreatedAt)
+		}
+
+		query, args, err = update.ToSql()
+		if err != nil {
+			return fmt.Errorf("build update delivery: %w", err)
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("update delivery: %w", err)
+		}
+		return nil
+	})
+}
+
+func scanEndpoint(row pgx.Row) (*notification.Endpoint, error) {
+	var e notification.Endpoint
+	var eventTypes []string
+	if err := row.Scan(&e.ID, &e.MerchantID, &e.URL, &eventTypes, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan endpoint: %w", err)
+	}
+	e.EventTypes = make([]notification.EventType, len(eventTypes))
+	for i, t := range eventTypes {
+		e.EventTypes[i] = notification.EventType(t)
+	}
+	return &e, nil
+}
+
+func scanDelivery(row pgx.Row) (*notification.Delivery, error) {
+	var d notification.Delivery
+	var lastError *string
+	if err := row.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &lastError, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("scan delivery: %w", err)
+	}
+	if lastError != nil {
+		d.LastError = *lastError
+	}
+	return &d, nil
+}
+
+func eventTypesToStrings(types []notification.EventType) []string {
+	out := make([]string, len(types))
+	for i, t := range types {
+		out[i] = string(t)
+	}
+	return out
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
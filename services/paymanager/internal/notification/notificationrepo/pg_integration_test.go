@@ -0,0 +1,117 @@
+//go:build integration
+
+package notificationrepo_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/pkg/testinfra"
+	paymanager "TestTaskJustPay/services/paymanager"
+	"TestTaskJustPay/services/paymanager/internal/notification"
+	"TestTaskJustPay/services/paymanager/internal/notification/notificationrepo"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pg *postgres.Postgres
+
+func TestMain(m *testing.M) {
+	ctx := context.Background()
+	pgContainer, err := testinfra.NewPostgresWithConfig(ctx, testinfra.PostgresConfig{
+		DBName:      "paymanager_notification_test",
+		MigrationFS: paymanager.MIGRATION_FS,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("postgres: %v", err))
+	}
+	pg = pgContainer.Pool
+	code := m.Run()
+	pgContainer.Cleanup(ctx)
+	os.Exit(code)
+}
+
+func insertPayment(t *testing.T, ctx context.Context, merchantID string) string {
+	t.Helper()
+	id := uuid.New()
+	_, err := pg.Pool.Exec(ctx, `
+		INSERT INTO payments (id, amount, currency, card_token, status, merchant_id)
+		VALUES ($1, 1000, 'USD', 'tok_test', 'authorized', $2)`, id, merchantID)
+	require.NoError(t, err)
+	return id.String()
+}
+
+func insertEvent(t *testing.T, ctx context.Context, paymentID, eventType string, createdAt time.Time) string {
+	t.Helper()
+	id := uuid.New()
+	_, err := pg.Pool.Exec(ctx, `
+		INSERT INTO events (id, aggregate_type, aggregate_id, event_type, idempotency_key, payload, created_at)
+		VALUES ($1, 'payment', $2, $3, $4, '{}', $5)`, id, paymentID, eventType, id.String(), createdAt)
+	require.NoError(t, err)
+	return id.String()
+}
+
+func deliveryEvents(t *testing.T, ctx context.Context, endpointID string) []string {
+	t.Helper()
+	rows, err := pg.Pool.Query(ctx, `
+		SELECT d.event_id FROM webhook_deliveries d
+		JOIN events e ON e.id = d.event_id
+		WHERE d.endpoint_id = $1
+		ORDER BY e.created_at`, endpointID)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id uuid.UUID
+		require.NoError(t, rows.Scan(&id))
+		ids = append(ids, id.String())
+	}
+	require.NoError(t, rows.Err())
+	return ids
+}
+
+func TestFanOut_AdvancesCursorOverPaymentEvents(t *testing.T) {
+	ctx := context.Background()
+	repo := notificationrepo.New(pg, pg.Pool)
+
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Microsecond)
+	endpoint := notification.Endpoint{
+		ID:         uuid.New().String(),
+		MerchantID: "merchant_1",
+		URL:        "https://merchant.example/hooks",
+		Secret:     "whsec_test",
+		EventTypes: []notification.EventType{notification.EventPaymentAuthorized, notification.EventPaymentVoided},
+		CreatedAt:  base,
+		UpdatedAt:  base,
+	}
+	require.NoError(t, repo.CreateEndpoint(ctx, endpoint))
+
+	paymentID := insertPayment(t, ctx, "merchant_1")
+	authorized := insertEvent(t, ctx, paymentID, "transaction.authorized", base.Add(time.Minute))
+	insertEvent(t, ctx, paymentID, "transaction.captured", base.Add(2*time.Minute)) // not subscribed
+	voided := insertEvent(t, ctx, paymentID, "payment.voided", base.Add(3*time.Minute))
+	late := insertEvent(t, ctx, paymentID, "payment.voided", base.Add(2*time.Hour))
+
+	created, err := repo.FanOut(ctx, notification.Routes, base.Add(time.Hour), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 2, created)
+	assert.Equal(t, []string{authorized, voided}, deliveryEvents(t, ctx, endpoint.ID))
+
+	// The cursor moved past the batch, so a second pass creates nothing new.
+	created, err = repo.FanOut(ctx, notification.Routes, base.Add(time.Hour), 100)
+	require.NoError(t, err)
+	assert.Zero(t, created)
+
+	// Events after `before` are picked up once the window reaches them.
+	created, err = repo.FanOut(ctx, notification.Routes, base.Add(3*time.Hour), 100)
+	require.NoError(t, err)
+	assert.Equal(t, 1, created)
+	assert.Equal(t, []string{authorized, voided, late}, deliveryEvents(t, ctx, endpoint.ID))
+}
@@ -0,0 +1,88 @@
+package notification
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationService manages merchant webhook endpoints and their delivery log.
+type NotificationService struct {
+	repo Repo
+}
+
+func NewNotificationService(repo Repo) *NotificationService {
+	return &NotificationService{repo: repo}
+}
+
+// CreateEndpoint registers an endpoint for merchantID with a freshly generated signing secret.
+func (s *NotificationService) CreateEndpoint(ctx context.Context, merchantID string, req CreateEndpointRequest) (*Endpoint, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	e := Endpoint{
+		ID:         uuid.New().String(),
+		MerchantID: merchantID,
+		URL:        req.URL,
+		Secret:     secret,
+		EventTypes: req.EventTypes,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := s.repo.CreateEndpoint(ctx, e); err != nil {
+		return nil, fmt.Errorf("create endpoint: %w", err)
+	}
+	return &e, nil
+}
+
+func (s *NotificationService) ListEndpoints(ctx context.Context, merchantID string) ([]Endpoint, error) {
+	return s.repo.ListEndpoints(ctx, merchantID)
+}
+
+func (s *NotificationService) ListDeliveries(ctx context.Context, merchantID, endpointID string, query DeliveryQuery) ([]Delivery, error) {
+	if _, err := s.repo.GetEndpoint(ctx, merchantID, endpointID); err != nil {
+		return nil, err
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = 10
+	}
+	if query.Limit > 1000 {
+		query.Limit = 1000
+	}
+	return s.repo.ListDeliveries(ctx, endpointID, query)
+}
+
+func (s *NotificationService) GetDelivery(ctx context.Context, merchantID, endpointID, deliveryID string) (*Delivery, error) {
+	if _, err := s.repo.GetEndpoint(ctx, merchantID, endpointID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetDelivery(ctx, endpointID, deliveryID)
+}
+
+// Redeliver queues a delivery to be sent again right away, regardless of its current status.
+func (s *NotificationService) Redeliver(ctx context.Context, merchantID, endpointID, deliveryID string) (*Delivery, error) {
+	if _, err := s.repo.GetEndpoint(ctx, merchantID, endpointID); err != nil {
+		return nil, err
+	}
+	return s.repo.Redeliver(ctx, endpointID, deliveryID)
+}
+
+func newSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
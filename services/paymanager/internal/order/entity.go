@@ -6,11 +6,14 @@ import (
 	"slices"
 	"time"
 
+	"TestTaskJustPay/pkg/money"
+
 	"github.com/google/uuid"
 )
 
 type Order struct {
 	OrderId    string    `json:"order_id"`
+	MerchantID string    `json:"merchant_id"`
 	UserId     uuid.UUID `json:"user_id"`
 	Status     Status    `json:"status"`
 	OnHold     bool      `json:"on_hold"`
@@ -28,6 +31,8 @@ type OrderUpdate struct {
 	UpdatedAt       time.Time         `json:"updated_at"`
 	CreatedAt       time.Time         `json:"created_at"`
 	Meta            map[string]string `json:"meta"`
+	// MerchantID is the provider's merchant id; empty for the default merchant.
+	MerchantID string `json:"merchant_id,omitempty"`
 }
 
 type Status string
@@ -67,6 +72,8 @@ type Pagination struct {
 }
 
 type OrdersQuery struct {
+	// MerchantID scopes the query; empty only for provider webhooks, which are not merchant-scoped.
+	MerchantID string
 	IDs        []string
 	UserIDs    []string
 	Statuses   []Status
@@ -102,6 +109,11 @@ func (b *OrdersQueryBuilder) Build() (*OrdersQuery, error) {
 	return b.query, nil
 }
 
+func (b *OrdersQueryBuilder) WithMerchantID(merchantID string) *OrdersQueryBuilder {
+	b.query.MerchantID = merchantID
+	return b
+}
+
 func (b *OrdersQueryBuilder) WithIDs(ids ...string) *OrdersQueryBuilder {
 	b.query.IDs = ids
 	return b
@@ -167,16 +179,20 @@ type UpdateOrderHoldRequest struct {
 	Reason  *string
 }
 
+// CaptureRequest.Amount is in minor units of Currency (cents for USD).
 type CaptureRequest struct {
-	Amount         float64 `json:"amount" binding:"required,min=1"`
-	Currency       string  `json:"currency" binding:"required,len=3"`
-	IdempotencyKey string  `json:"idempotency_key" binding:"required,min=1,max=255"`
+	Amount         int64          `json:"amount" binding:"required,min=1"`
+	Currency       money.Currency `json:"currency" binding:"required"`
+	IdempotencyKey string         `json:"idempotency_key" binding:"required,min=1,max=255"`
+}
+
+func (r CaptureRequest) Money() money.Money {
+	return money.Money{Amount: r.Amount, Currency: r.Currency}
 }
 
 type CaptureResponse struct {
-	OrderID      string    `json:"order_id"`
-	Amount       float64   `json:"amount"`
-	Currency     string    `json:"currency"`
+	OrderID string `json:"order_id"`
+	money.Money
 	Status       string    `json:"status"`
 	ProviderTxID string    `json:"provider_tx_id"`
 	CapturedAt   time.Time `json:"captured_at"`
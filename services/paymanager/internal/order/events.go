@@ -36,6 +36,9 @@ type OrderEventPage struct {
 }
 
 type OrderEventQuery struct {
+	// MerchantID restricts events to the merchant's orders; set from the authenticated merchant.
+	MerchantID string `json:"-" url:"-" form:"-"`
+
 	OrderIDs []string         `json:"order_ids" url:"order_ids" form:"order_ids,omitempty"`
 	Kinds    []OrderEventKind `json:"kinds" url:"kinds" form:"kinds,omitempty"`
 
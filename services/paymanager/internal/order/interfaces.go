@@ -8,7 +8,7 @@ import (
 
 // OrderRepo is the persistence contract for orders.
 type OrderRepo interface {
-	CreateOrder(ctx context.Context, update OrderUpdate) error
+	CreateOrder(ctx context.Context, merchantID string, update OrderUpdate) error
 	GetOrders(ctx context.Context, filter *OrdersQuery) ([]Order, error)
 	UpdateOrder(ctx context.Context, update OrderUpdate) error
 	UpdateOrderHold(ctx context.Context, request UpdateOrderHoldRequest) error
@@ -21,6 +21,11 @@ type OrderEvents interface {
 	GetOrderEvents(ctx context.Context, query OrderEventQuery) (OrderEventPage, error)
 }
 
+// Merchants maps provider merchant ids carried by webhooks to merchant ids.
+type Merchants interface {
+	ResolveProviderMerchant(ctx context.Context, providerMerchantID string) (string, error)
+}
+
 // Provider is the minimal interface this domain requires from the payment gateway.
 type Provider interface {
 	CapturePayment(ctx context.Context, req gateway.CaptureRequest) (gateway.CaptureResult, error)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"TestTaskJustPay/services/paymanager/internal/merchantauth"
 	"TestTaskJustPay/services/paymanager/internal/order"
 
 	"github.com/gin-gonic/gin"
@@ -19,13 +20,19 @@ func NewHTTPHandler(s *order.OrderService) *HTTPHandler {
 }
 
 func (h *HTTPHandler) Get(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
 	orderID := c.Param("order_id")
 	if orderID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing order_id"})
 		return
 	}
 	fmt.Println("get orderID:", orderID)
-	res, err := h.service.GetOrderByID(c, orderID)
+	res, err := h.service.GetOrderByID(c, m.ID, orderID)
 	if err != nil {
 		if errors.Is(err, order.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"message": err.Error()})
@@ -39,13 +46,19 @@ func (h *HTTPHandler) Get(c *gin.Context) {
 }
 
 func (h *HTTPHandler) GetEvents(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
 	var query order.OrderEventQuery
 	if err := c.ShouldBindQuery(&query); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
 		return
 	}
 
-	res, err := h.service.GetEvents(c, query)
+	res, err := h.service.GetEvents(c, m.ID, query)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
 		return
@@ -55,13 +68,19 @@ func (h *HTTPHandler) GetEvents(c *gin.Context) {
 }
 
 func (h *HTTPHandler) Filter(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
 	filter, err := h.createFilter(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	res, err := h.service.GetOrders(c, *filter)
+	res, err := h.service.GetOrders(c, m.ID, *filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -71,6 +90,12 @@ func (h *HTTPHandler) Filter(c *gin.Context) {
 }
 
 func (h *HTTPHandler) Hold(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
 	orderID := c.Param("order_id")
 	if orderID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing order_id"})
@@ -83,7 +108,7 @@ func (h *HTTPHandler) Hold(c *gin.Context) {
 		return
 	}
 
-	response, err := h.service.UpdateOrderHold(c, orderID, request)
+	response, err := h.service.UpdateOrderHold(c, m.ID, orderID, request)
 	if err != nil {
 		if errors.Is(err, order.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -97,6 +122,12 @@ func (h *HTTPHandler) Hold(c *gin.Context) {
 }
 
 func (h *HTTPHandler) Capture(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
 	orderID := c.Param("order_id")
 	if orderID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing order_id"})
@@ -109,7 +140,7 @@ func (h *HTTPHandler) Capture(c *gin.Context) {
 		return
 	}
 
-	response, err := h.service.CapturePayment(c, orderID, request)
+	response, err := h.service.CapturePayment(c, m.ID, orderID, request)
 	if err != nil {
 		if errors.Is(err, order.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
@@ -14,6 +14,7 @@ import (
 type orderUpdateRequest struct {
 	ProviderEventID string            `json:"provider_event_id"`
 	OrderID         string            `json:"order_id"`
+	MerchantID      string            `json:"merchant_id,omitempty"`
 	UserID          string            `json:"user_id"`
 	Status          string            `json:"status"`
 	UpdatedAt       time.Time         `json:"updated_at"`
@@ -40,6 +41,7 @@ func (h *HTTPHandler) HandleUpdate(c *gin.Context) {
 	webhook := order.OrderUpdate{
 		ProviderEventID: req.ProviderEventID,
 		OrderId:         req.OrderID,
+		MerchantID:      req.MerchantID,
 		UserId:          req.UserID,
 		Status:          order.Status(req.Status),
 		UpdatedAt:       req.UpdatedAt,
@@ -82,10 +82,10 @@ func (r *repo) UpdateOrderHold(ctx context.Context, request order.UpdateOrderHol
 	return nil
 }
 
-func (r *repo) CreateOrder(ctx context.Context, update order.OrderUpdate) error {
+func (r *repo) CreateOrder(ctx context.Context, merchantID string, update order.OrderUpdate) error {
 	query, args, err := r.builder.Insert("orders").
-		Columns("id", "user_id", "status", "created_at", "updated_at").
-		Values(update.OrderId, update.UserId, update.Status, update.CreatedAt, update.UpdatedAt).
+		Columns("id", "merchant_id", "user_id", "status", "created_at", "updated_at").
+		Values(update.OrderId, merchantID, update.UserId, update.Status, update.CreatedAt, update.UpdatedAt).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("build insert query: %w", err)
@@ -102,9 +102,13 @@ func (r *repo) CreateOrder(ctx context.Context, update order.OrderUpdate) error
 }
 
 func (r *repo) buildOrdersQuery(q *order.OrdersQuery) (string, []any) {
-	query := r.builder.Select("id", "user_id", "status", "on_hold", "hold_reason", "created_at", "updated_at").
+	query := r.builder.Select("id", "merchant_id", "user_id", "status", "on_hold", "hold_reason", "created_at", "updated_at").
 		From("orders")
 
+	if q.MerchantID != "" {
+		query = query.Where(squirrel.Eq{"merchant_id": q.MerchantID})
+	}
+
 	if len(q.IDs) > 0 {
 		query = query.Where(squirrel.Eq{"id": q.IDs})
 	}
@@ -135,7 +139,7 @@ func parseOrderRows(rows pgx.Rows) ([]order.Order, error) {
 	for rows.Next() {
 		var o order.Order
 		var rawStatus string
-		err := rows.Scan(&o.OrderId, &o.UserId, &rawStatus, &o.OnHold, &o.HoldReason, &o.CreatedAt, &o.UpdatedAt)
+		err := rows.Scan(&o.OrderId, &o.MerchantID, &o.UserId, &rawStatus, &o.OnHold, &o.HoldReason, &o.CreatedAt, &o.UpdatedAt)
 		if err != nil {
 			return nil, fmt.Errorf("scan order row: %w", err)
 		}
@@ -123,6 +123,10 @@ func (r *PgOrderEventRepo) buildOrderEventPageQuery(q order.OrderEventQuery) (st
 	b := r.builder.Select("id", "order_id", "kind", "provider_event_id", "data", "created_at").
 		From("order_events")
 
+	if q.MerchantID != "" {
+		b = b.Where("order_id IN (SELECT id FROM orders WHERE merchant_id = ?)", q.MerchantID)
+	}
+
 	if len(q.OrderIDs) > 0 {
 		b = b.Where(squirrel.Eq{"order_id": q.OrderIDs})
 	}
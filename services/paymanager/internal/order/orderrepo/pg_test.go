@@ -26,15 +26,16 @@ func TestGetOrders(t *testing.T) {
 		expectedTime := time.Now()
 
 		query := &order.OrdersQuery{
-			IDs: []string{"order-1", "order-2"},
+			MerchantID: "merchant_1",
+			IDs:        []string{"order-1", "order-2"},
 		}
 
-		rows := mock.NewRows([]string{"id", "user_id", "status", "on_hold", "hold_reason", "created_at", "updated_at"}).
-			AddRow("order-1", userId, "created", false, nil, expectedTime, expectedTime).
-			AddRow("order-2", userId, "updated", false, nil, expectedTime, expectedTime)
+		rows := mock.NewRows([]string{"id", "merchant_id", "user_id", "status", "on_hold", "hold_reason", "created_at", "updated_at"}).
+			AddRow("order-1", "merchant_1", userId, "created", false, nil, expectedTime, expectedTime).
+			AddRow("order-2", "merchant_1", userId, "updated", false, nil, expectedTime, expectedTime)
 
-		mock.ExpectQuery(`SELECT id, user_id, status, on_hold, hold_reason, created_at, updated_at FROM orders WHERE id IN \(\$1,\$2\)`).
-			WithArgs("order-1", "order-2").
+		mock.ExpectQuery(`SELECT id, merchant_id, user_id, status, on_hold, hold_reason, created_at, updated_at FROM orders WHERE merchant_id = \$1 AND id IN \(\$2,\$3\)`).
+			WithArgs("merchant_1", "order-1", "order-2").
 			WillReturnRows(rows)
 
 		result, err := r.GetOrders(ctx, query)
@@ -43,6 +44,7 @@ func TestGetOrders(t *testing.T) {
 		assert.Len(t, result, 2)
 		assert.Equal(t, "order-1", result[0].OrderId)
 		assert.Equal(t, "order-2", result[1].OrderId)
+		assert.Equal(t, "merchant_1", result[0].MerchantID)
 		assert.Equal(t, order.StatusCreated, result[0].Status)
 		assert.Equal(t, order.StatusUpdated, result[1].Status)
 	})
@@ -116,11 +118,11 @@ func TestCreateOrderByEvent(t *testing.T) {
 			UpdatedAt: updatedAt,
 		}
 
-		mock.ExpectExec(`INSERT INTO orders \(id,user_id,status,created_at,updated_at\) VALUES \(\$1,\$2,\$3,\$4,\$5\)`).
-			WithArgs("order-1", userId, order.StatusCreated, createdAt, updatedAt).
+		mock.ExpectExec(`INSERT INTO orders \(id,merchant_id,user_id,status,created_at,updated_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\)`).
+			WithArgs("order-1", "merchant_1", userId, order.StatusCreated, createdAt, updatedAt).
 			WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-		err := r.CreateOrder(ctx, event)
+		err := r.CreateOrder(ctx, "merchant_1", event)
 
 		require.NoError(t, err)
 	})
@@ -134,10 +136,10 @@ func TestCreateOrderByEvent(t *testing.T) {
 			Status:  order.StatusCreated,
 		}
 
-		mock.ExpectExec(`INSERT INTO orders \(id,user_id,status,created_at,updated_at\) VALUES \(\$1,\$2,\$3,\$4,\$5\)`).
+		mock.ExpectExec(`INSERT INTO orders \(id,merchant_id,user_id,status,created_at,updated_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\)`).
 			WillReturnError(assert.AnError)
 
-		err := r.CreateOrder(ctx, event)
+		err := r.CreateOrder(ctx, "merchant_1", event)
 
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "create order by event")
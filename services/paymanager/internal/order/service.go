@@ -23,6 +23,7 @@ type OrderService struct {
 	orderRepo    OrderRepo
 	provider     Provider
 	orderEvents  OrderEvents
+	merchants    Merchants
 }
 
 func NewOrderService(
@@ -32,6 +33,7 @@ func NewOrderService(
 	orderRepo OrderRepo,
 	provider Provider,
 	orderEvents OrderEvents,
+	merchants Merchants,
 ) *OrderService {
 	return &OrderService{
 		transactor:   transactor,
@@ -40,15 +42,19 @@ func NewOrderService(
 		orderRepo:    orderRepo,
 		provider:     provider,
 		orderEvents:  orderEvents,
+		merchants:    merchants,
 	}
 }
 
-func (s *OrderService) GetOrderByID(ctx context.Context, id string) (Order, error) {
-	return getOrderByID(ctx, s.orderRepo, id)
+func (s *OrderService) GetOrderByID(ctx context.Context, merchantID, id string) (Order, error) {
+	return getOrderByID(ctx, s.orderRepo, merchantID, id)
 }
 
-func getOrderByID(ctx context.Context, repo OrderRepo, id string) (Order, error) {
+// getOrderByID returns ErrNotFound for orders of other merchants. An empty merchantID
+// looks the order up across merchants.
+func getOrderByID(ctx context.Context, repo OrderRepo, merchantID, id string) (Order, error) {
 	query, _ := NewOrdersQueryBuilder().
+		WithMerchantID(merchantID).
 		WithIDs(id).
 		Build()
 
@@ -62,7 +68,8 @@ func getOrderByID(ctx context.Context, repo OrderRepo, id string) (Order, error)
 	return orders[0], nil
 }
 
-func (s *OrderService) GetOrders(ctx context.Context, query OrdersQuery) ([]Order, error) {
+func (s *OrderService) GetOrders(ctx context.Context, merchantID string, query OrdersQuery) ([]Order, error) {
+	query.MerchantID = merchantID
 	orders, err := s.orderRepo.GetOrders(ctx, &query)
 	if err != nil {
 		return nil, fmt.Errorf("filter orders: %w", err)
@@ -71,16 +78,25 @@ func (s *OrderService) GetOrders(ctx context.Context, query OrdersQuery) ([]Orde
 }
 
 func (s *OrderService) ProcessOrderUpdate(ctx context.Context, update OrderUpdate) error {
+	var merchantID string
+	if update.Status == StatusCreated {
+		var err error
+		merchantID, err = s.merchants.ResolveProviderMerchant(ctx, update.MerchantID)
+		if err != nil {
+			return fmt.Errorf("resolve order merchant: %w", err)
+		}
+	}
+
 	err := s.transactor.InTransaction(ctx, pgx.RepeatableRead, func(tx postgres.Executor) error {
 		txRepo := s.txOrderRepo(tx)
 		txEvents := s.txEventStore(tx)
 
 		if update.Status == StatusCreated {
-			if err := txRepo.CreateOrder(ctx, update); err != nil {
+			if err := txRepo.CreateOrder(ctx, merchantID, update); err != nil {
 				return fmt.Errorf("create order from event: %w", err)
 			}
 		} else {
-			order, err := getOrderByID(ctx, txRepo, update.OrderId)
+			order, err := getOrderByID(ctx, txRepo, "", update.OrderId)
 			if err != nil {
 				return fmt.Errorf("load order: %w", err)
 			}
@@ -113,7 +129,8 @@ func (s *OrderService) ProcessOrderUpdate(ctx context.Context, update OrderUpdat
 	return nil
 }
 
-func (s *OrderService) GetEvents(ctx context.Context, query OrderEventQuery) (OrderEventPage, error) {
+func (s *OrderService) GetEvents(ctx context.Context, merchantID string, query OrderEventQuery) (OrderEventPage, error) {
+	query.MerchantID = merchantID
 	if query.Limit <= 0 {
 		query.Limit = 10
 	}
@@ -125,7 +142,7 @@ func (s *OrderService) GetEvents(ctx context.Context, query OrderEventQuery) (Or
 	return eventPage, nil
 }
 
-func (s *OrderService) UpdateOrderHold(ctx context.Context, orderID string, request HoldRequest) (*HoldResponse, error) {
+func (s *OrderService) UpdateOrderHold(ctx context.Context, merchantID, orderID string, request HoldRequest) (*HoldResponse, error) {
 	if err := request.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid hold request: %w", err)
 	}
@@ -135,7 +152,7 @@ func (s *OrderService) UpdateOrderHold(ctx context.Context, orderID string, requ
 		txRepo := s.txOrderRepo(tx)
 		txEvents := s.txEventStore(tx)
 
-		order, err := getOrderByID(ctx, txRepo, orderID)
+		order, err := getOrderByID(ctx, txRepo, merchantID, orderID)
 		if err != nil {
 			return fmt.Errorf("load order: %w", err)
 		}
@@ -162,7 +179,7 @@ func (s *OrderService) UpdateOrderHold(ctx context.Context, orderID string, requ
 			return fmt.Errorf("update order hold status: %w", err)
 		}
 
-		updatedOrder, err := getOrderByID(ctx, txRepo, order.OrderId)
+		updatedOrder, err := getOrderByID(ctx, txRepo, merchantID, order.OrderId)
 		if err != nil {
 			return fmt.Errorf("get updated order: %w", err)
 		}
@@ -202,13 +219,13 @@ func (s *OrderService) UpdateOrderHold(ctx context.Context, orderID string, requ
 	return response, nil
 }
 
-func (s *OrderService) CapturePayment(ctx context.Context, orderID string, request CaptureRequest) (*CaptureResponse, error) {
+func (s *OrderService) CapturePayment(ctx context.Context, merchantID, orderID string, request CaptureRequest) (*CaptureResponse, error) {
 	var response *CaptureResponse
 	err := s.transactor.InTransaction(ctx, pgx.RepeatableRead, func(tx postgres.Executor) error {
 		txRepo := s.txOrderRepo(tx)
 		txEvents := s.txEventStore(tx)
 
-		order, err := getOrderByID(ctx, txRepo, orderID)
+		order, err := getOrderByID(ctx, txRepo, merchantID, orderID)
 		if err != nil {
 			return fmt.Errorf("load order: %w", err)
 		}
@@ -222,9 +239,9 @@ func (s *OrderService) CapturePayment(ctx context.Context, orderID string, reque
 		}
 
 		captureReq := gateway.CaptureRequest{
+			MerchantID:     order.MerchantID,
 			OrderID:        order.OrderId,
-			Amount:         request.Amount,
-			Currency:       request.Currency,
+			Money:          request.Money(),
 			IdempotencyKey: request.IdempotencyKey,
 		}
 
@@ -235,8 +252,7 @@ func (s *OrderService) CapturePayment(ctx context.Context, orderID string, reque
 
 		response = &CaptureResponse{
 			OrderID:      order.OrderId,
-			Amount:       request.Amount,
-			Currency:     request.Currency,
+			Money:        request.Money(),
 			Status:       string(result.Status),
 			ProviderTxID: result.ProviderTxID,
 			CapturedAt:   time.Now(),
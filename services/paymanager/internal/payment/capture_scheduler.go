@@ -0,0 +1,142 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"TestTaskJustPay/pkg/metrics"
+	"TestTaskJustPay/pkg/money"
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+)
+
+// CaptureSchedulerConfig holds configuration for the capture scheduler.
+type CaptureSchedulerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// LeaseTimeout is how long a claimed payment stays owned by one replica
+	// before another replica may retry it.
+	LeaseTimeout time.Duration
+	MaxAttempts  int
+	RetryBackoff time.Duration
+}
+
+// CaptureScheduler polls the payments table for due captures and submits them to the provider.
+// State lives entirely in Postgres, so scheduled captures survive restarts and
+// several replicas can run the scheduler concurrently.
+type CaptureScheduler struct {
+	repo      CaptureRepo
+	providers ProviderRouter
+	cfg       CaptureSchedulerConfig
+}
+
+// NewCaptureScheduler creates a new capture scheduler.
+func NewCaptureScheduler(repo CaptureRepo, providers ProviderRouter, cfg CaptureSchedulerConfig) *CaptureScheduler {
+	return &CaptureScheduler{
+		repo:      repo,
+		providers: providers,
+		cfg:       cfg,
+	}
+}
+
+// Start begins the polling loop. Blocks until ctx is cancelled.
+func (s *CaptureScheduler) Start(ctx context.Context) error {
+	slog.Info("Capture scheduler started",
+		"poll_interval", s.cfg.PollInterval,
+		"batch_size", s.cfg.BatchSize,
+		"lease_timeout", s.cfg.LeaseTimeout,
+		"max_attempts", s.cfg.MaxAttempts)
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Capture scheduler stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			s.poll(ctx)
+		}
+	}
+}
+
+func (s *CaptureScheduler) poll(ctx context.Context) {
+	recovered, err := s.repo.ReclaimStaleCaptures(ctx, s.cfg.BatchSize, s.cfg.LeaseTimeout)
+	if err != nil {
+		slog.Error("Failed to reclaim stale captures", slog.Any("error", err))
+	}
+	metrics.CaptureSchedulerClaimsTotal.WithLabelValues("recovered").Add(float64(len(recovered)))
+	for _, c := range recovered {
+		slog.Warn("Recovering stuck capture",
+			"payment_id", c.PaymentID,
+			"attempt", c.Attempts)
+		s.capture(ctx, c)
+	}
+
+	due, err := s.repo.ClaimDueCaptures(ctx, s.cfg.BatchSize, s.cfg.LeaseTimeout)
+	if err != nil {
+		slog.Error("Failed to claim due captures", slog.Any("error", err))
+		return
+	}
+	metrics.CaptureSchedulerClaimsTotal.WithLabelValues("due").Add(float64(len(due)))
+	for _, c := range due {
+		metrics.CaptureSchedulerLag.Observe(time.Since(c.CaptureAt).Seconds())
+		s.capture(ctx, c)
+	}
+}
+
+func (s *CaptureScheduler) capture(ctx context.Context, c CaptureClaim) {
+	err := s.submit(ctx, c)
+	if err == nil {
+		metrics.CaptureSchedulerAttemptsTotal.WithLabelValues("success").Inc()
+		if markErr := s.repo.MarkCaptureSubmitted(ctx, c.PaymentID); markErr != nil {
+			slog.Error("Failed to mark capture submitted",
+				"payment_id", c.PaymentID, slog.Any("error", markErr))
+		}
+		return
+	}
+
+	if c.Attempts >= s.cfg.MaxAttempts {
+		metrics.CaptureSchedulerAttemptsTotal.WithLabelValues("failed").Inc()
+		slog.Error("Capture failed permanently",
+			"payment_id", c.PaymentID,
+			"provider_tx_id", c.ProviderTxID,
+			"attempts", c.Attempts,
+			slog.Any("error", err))
+		if markErr := s.repo.MarkCaptureFailed(ctx, c.PaymentID, err.Error()); markErr != nil {
+			slog.Error("Failed to mark capture failed",
+				"payment_id", c.PaymentID, slog.Any("error", markErr))
+		}
+		return
+	}
+
+	metrics.CaptureSchedulerAttemptsTotal.WithLabelValues("retry").Inc()
+	slog.Warn("Capture attempt failed, will retry",
+		"payment_id", c.PaymentID,
+		"provider_tx_id", c.ProviderTxID,
+		"attempt", c.Attempts,
+		slog.Any("error", err))
+	retryAt := time.Now().UTC().Add(s.cfg.RetryBackoff)
+	if markErr := s.repo.ReleaseCapture(ctx, c.PaymentID, err.Error(), retryAt); markErr != nil {
+		slog.Error("Failed to release capture",
+			"payment_id", c.PaymentID, slog.Any("error", markErr))
+	}
+}
+
+// submit sends the capture to the provider that authorized the payment.
+func (s *CaptureScheduler) submit(ctx context.Context, c CaptureClaim) error {
+	provider, err := s.providers.Provider(c.Provider)
+	if err != nil {
+		return err
+	}
+	_, err = provider.CapturePayment(ctx, gateway.CaptureRequest{
+		MerchantID:     c.MerchantID,
+		OrderID:        c.ProviderTxID,
+		Money:          money.Money{Amount: c.Amount, Currency: money.Currency(c.Currency)},
+		Final:          true,
+		IdempotencyKey: fmt.Sprintf("capture_%s", c.PaymentID),
+	})
+	return err
+}
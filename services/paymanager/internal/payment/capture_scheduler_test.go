@@ -0,0 +1,119 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+	"TestTaskJustPay/services/paymanager/internal/routing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- fakes ---
+
+type fakeCaptureRepo struct {
+	due       []CaptureClaim
+	recovered []CaptureClaim
+	submitted []string
+	released  []string
+	failed    []string
+}
+
+func (f *fakeCaptureRepo) ClaimDueCaptures(_ context.Context, _ int, _ time.Duration) ([]CaptureClaim, error) {
+	return f.due, nil
+}
+
+func (f *fakeCaptureRepo) ReclaimStaleCaptures(_ context.Context, _ int, _ time.Duration) ([]CaptureClaim, error) {
+	return f.recovered, nil
+}
+
+func (f *fakeCaptureRepo) MarkCaptureSubmitted(_ context.Context, id string) error {
+	f.submitted = append(f.submitted, id)
+	return nil
+}
+
+func (f *fakeCaptureRepo) ReleaseCapture(_ context.Context, id string, _ string, _ time.Time) error {
+	f.released = append(f.released, id)
+	return nil
+}
+
+func (f *fakeCaptureRepo) MarkCaptureFailed(_ context.Context, id string, _ string) error {
+	f.failed = append(f.failed, id)
+	return nil
+}
+
+type fakeCaptureProvider struct {
+	Provider
+	captured []gateway.CaptureRequest
+	err      error
+}
+
+func (f *fakeCaptureProvider) CapturePayment(_ context.Context, req gateway.CaptureRequest) (gateway.CaptureResult, error) {
+	f.captured = append(f.captured, req)
+	if f.err != nil {
+		return gateway.CaptureResult{Status: gateway.CaptureStatusFailed}, f.err
+	}
+	return gateway.CaptureResult{ProviderTxID: req.OrderID, Status: gateway.CaptureStatusSuccess}, nil
+}
+
+func newTestScheduler(repo CaptureRepo, provider Provider) *CaptureScheduler {
+	providers, _ := routing.New("silvergate", map[string]Provider{"silvergate": provider}, nil)
+	return NewCaptureScheduler(repo, providers, CaptureSchedulerConfig{
+		PollInterval: 50 * time.Millisecond,
+		BatchSize:    10,
+		LeaseTimeout: time.Minute,
+		MaxAttempts:  3,
+		RetryBackoff: time.Second,
+	})
+}
+
+// --- tests ---
+
+func TestCaptureScheduler_Poll_CapturesDueAndRecovered(t *testing.T) {
+	repo := &fakeCaptureRepo{
+		due:       []CaptureClaim{{PaymentID: "pay-1", ProviderTxID: "tx-1", Amount: 1000, Currency: "USD", Attempts: 1}},
+		recovered: []CaptureClaim{{PaymentID: "pay-2", ProviderTxID: "tx-2", Amount: 500, Currency: "EUR", Attempts: 2}},
+	}
+	provider := &fakeCaptureProvider{}
+
+	newTestScheduler(repo, provider).poll(context.Background())
+
+	require.Len(t, provider.captured, 2)
+	assert.Equal(t, "tx-2", provider.captured[0].OrderID)
+	assert.Equal(t, "capture_pay-2", provider.captured[0].IdempotencyKey)
+	assert.Equal(t, "tx-1", provider.captured[1].OrderID)
+	assert.Equal(t, int64(1000), provider.captured[1].Amount)
+	assert.Equal(t, "capture_pay-1", provider.captured[1].IdempotencyKey)
+	assert.True(t, provider.captured[1].Final)
+	assert.ElementsMatch(t, []string{"pay-1", "pay-2"}, repo.submitted)
+	assert.Empty(t, repo.released)
+	assert.Empty(t, repo.failed)
+}
+
+func TestCaptureScheduler_Capture_ProviderErrorIsRetried(t *testing.T) {
+	repo := &fakeCaptureRepo{}
+	provider := &fakeCaptureProvider{err: errors.New("provider unavailable")}
+
+	newTestScheduler(repo, provider).capture(context.Background(),
+		CaptureClaim{PaymentID: "pay-1", ProviderTxID: "tx-1", Amount: 1000, Attempts: 1})
+
+	assert.Equal(t, []string{"pay-1"}, repo.released)
+	assert.Empty(t, repo.submitted)
+	assert.Empty(t, repo.failed)
+}
+
+func TestCaptureScheduler_Capture_MaxAttemptsMarksFailed(t *testing.T) {
+	repo := &fakeCaptureRepo{}
+	provider := &fakeCaptureProvider{err: errors.New("provider unavailable")}
+
+	newTestScheduler(repo, provider).capture(context.Background(),
+		CaptureClaim{PaymentID: "pay-1", ProviderTxID: "tx-1", Amount: 1000, Attempts: 3})
+
+	assert.Equal(t, []string{"pay-1"}, repo.failed)
+	assert.Empty(t, repo.released)
+	assert.Empty(t, repo.submitted)
+}
@@ -0,0 +1,99 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ChallengeExpirerConfig holds configuration for the challenge expirer.
+type ChallengeExpirerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// TTL is how long a payment may wait in requires_action. It should exceed the
+	// provider's own challenge lifetime so a late webhook is not cut off.
+	TTL time.Duration
+}
+
+// ChallengeExpirer expires payments whose cardholder never completed the authentication
+// challenge. The provider holds no funds for them, so nothing is sent to the provider.
+type ChallengeExpirer struct {
+	svc *PaymentService
+	cfg ChallengeExpirerConfig
+}
+
+// NewChallengeExpirer creates a new challenge expirer.
+func NewChallengeExpirer(svc *PaymentService, cfg ChallengeExpirerConfig) *ChallengeExpirer {
+	return &ChallengeExpirer{svc: svc, cfg: cfg}
+}
+
+// Start begins the polling loop. Blocks until ctx is cancelled.
+func (e *ChallengeExpirer) Start(ctx context.Context) error {
+	slog.Info("Challenge expirer started",
+		"poll_interval", e.cfg.PollInterval,
+		"batch_size", e.cfg.BatchSize,
+		"ttl", e.cfg.TTL)
+
+	ticker := time.NewTicker(e.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Challenge expirer stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			n, err := e.svc.ExpireAbandonedChallenges(ctx, time.Now().UTC().Add(-e.cfg.TTL), e.cfg.BatchSize)
+			if err != nil {
+				slog.Error("Failed to expire abandoned challenges", slog.Any("error", err))
+				continue
+			}
+			if n > 0 {
+				slog.Info("Expired abandoned challenges", "count", n)
+			}
+		}
+	}
+}
+
+// ExpireAbandonedChallenges moves up to limit payments that entered requires_action
+// before createdBefore to expired, and returns how many it expired.
+func (s *PaymentService) ExpireAbandonedChallenges(ctx context.Context, createdBefore time.Time, limit int) (int, error) {
+	var expired int
+	err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(tx postgres.Executor) error {
+		txRepo := s.txPaymentRepo(tx)
+		txEvents := s.txEventStore(tx)
+
+		ids, err := txRepo.LockAbandonedChallenges(ctx, createdBefore, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := txRepo.TransitionStatus(ctx, id, Transition{
+				From:   StatusRequiresAction,
+				To:     StatusExpired,
+				Source: SourceScheduler,
+				Reason: "challenge abandoned",
+			}); err != nil {
+				return fmt.Errorf("expire payment %s: %w", id, err)
+			}
+			if err := writePaymentEvent(ctx, txEvents, id, PaymentEventChallengeExpired, string(PaymentEventChallengeExpired), PaymentEventData{
+				OldStatus: StatusRequiresAction,
+				NewStatus: StatusExpired,
+			}); err != nil {
+				return err
+			}
+		}
+		expired = len(ids)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
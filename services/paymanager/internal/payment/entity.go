@@ -1,29 +1,52 @@
 package payment
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"time"
 
+	"TestTaskJustPay/pkg/money"
+
 	"github.com/google/uuid"
 )
 
 type Status string
 
 const (
+	// StatusRequiresAction means the provider holds the authorization until the cardholder
+	// completes an authentication challenge at the payment's challenge URL.
+	StatusRequiresAction    Status = "requires_action"
 	StatusAuthorized        Status = "authorized"
 	StatusDeclined          Status = "declined"
 	StatusCapturePending    Status = "capture_pending"
+	StatusPartiallyCaptured Status = "partially_captured"
 	StatusCaptured          Status = "captured"
 	StatusCaptureFailed     Status = "capture_failed"
 	StatusVoided            Status = "voided"
+	// StatusExpired means the provider voided the authorization after its hold lapsed uncaptured.
+	StatusExpired           Status = "expired"
 	StatusPartiallyRefunded Status = "partially_refunded"
 	StatusRefunded          Status = "refunded"
 )
 
+var allStatuses = []Status{
+	StatusRequiresAction, StatusAuthorized, StatusDeclined, StatusCapturePending, StatusPartiallyCaptured, StatusCaptured,
+	StatusCaptureFailed, StatusVoided, StatusExpired, StatusPartiallyRefunded, StatusRefunded,
+}
+
 var validTransitions = map[Status][]Status{
-	StatusAuthorized:        {StatusCapturePending, StatusVoided},
-	StatusCapturePending:    {StatusCaptured, StatusCaptureFailed},
+	StatusRequiresAction: {StatusAuthorized, StatusDeclined, StatusExpired},
+	StatusAuthorized:     {StatusCapturePending, StatusVoided, StatusExpired},
+	StatusCapturePending: {StatusCaptured, StatusCaptureFailed, StatusPartiallyCaptured, StatusExpired},
+	// A failed capture leaves the whole hold in place until it is voided or lapses.
+	StatusCaptureFailed: {StatusVoided, StatusExpired},
+	// A partially captured authorization can be captured further, or closed by a void or
+	// expiry that releases the remainder. Its captured part is refundable once it is closed.
+	StatusPartiallyCaptured: {StatusCapturePending, StatusVoided, StatusExpired},
 	StatusCaptured:          {StatusPartiallyRefunded, StatusRefunded},
 	StatusPartiallyRefunded: {StatusPartiallyRefunded, StatusRefunded},
+	StatusVoided:            {StatusPartiallyRefunded, StatusRefunded},
+	StatusExpired:           {StatusPartiallyRefunded, StatusRefunded},
 }
 
 func (s Status) CanTransitionTo(target Status) bool {
@@ -39,71 +62,168 @@ func (s Status) CanTransitionTo(target Status) bool {
 	return false
 }
 
+// Refundable reports whether refunds may be requested against the payment: it has
+// captured funds and its authorization is closed, so no capture is in flight or to come.
+func (p *Payment) Refundable() bool {
+	switch p.Status {
+	case StatusCaptured, StatusPartiallyRefunded:
+		return true
+	case StatusVoided, StatusExpired:
+		return p.CapturedAmount > 0
+	default:
+		return false
+	}
+}
+
+// DeclineReasonRiskBlocked is the decline reason of payments the risk engine blocked
+// before they reached a provider.
+const DeclineReasonRiskBlocked = "risk_blocked"
+
+// HoldReasonRisk marks payments whose capture is held because the risk engine sent them
+// to manual review. They are authorized but never captured automatically.
+const HoldReasonRisk = "risk"
+
 type Payment struct {
-	ID             string     `json:"id"`
-	Amount         int64      `json:"amount"`
-	Currency       string     `json:"currency"`
-	CardToken      string     `json:"card_token"`
-	Status         Status     `json:"status"`
-	DeclineReason  string     `json:"decline_reason,omitempty"`
-	ProviderTxID   string     `json:"provider_tx_id,omitempty"`
+	ID        string `json:"id"`
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+	CardToken string `json:"card_token"`
+	// CardFingerprint identifies the card across payments; it is reported by the provider's vault.
+	CardFingerprint string `json:"card_fingerprint"`
+	Status          Status `json:"status"`
+	DeclineReason   string `json:"decline_reason,omitempty"`
+	// ChallengeURL is where the cardholder authenticates a payment in requires_action.
+	ChallengeURL string `json:"challenge_url,omitempty"`
+	ProviderTxID string `json:"provider_tx_id,omitempty"`
+	// Provider is the PSP that authorized the payment; every later call goes to it.
+	Provider       string     `json:"provider"`
 	MerchantID     string     `json:"merchant_id"`
+	CapturedAmount int64      `json:"captured_amount"`
 	RefundedAmount int64      `json:"refunded_amount"`
 	CaptureAt      *time.Time `json:"capture_at,omitempty"`
-	CreatedAt      time.Time  `json:"created_at"`
-	UpdatedAt      time.Time  `json:"updated_at"`
+	// RiskDecisionID is the pre-authorization risk decision taken for the payment.
+	RiskDecisionID string    `json:"risk_decision_id,omitempty"`
+	HoldReason     string    `json:"hold_reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	// StatusHistory is only populated when requested with ?expand=history.
+	StatusHistory []StatusChange `json:"status_history,omitempty"`
+}
+
+// CardFingerprint is the hex SHA-256 of the card token. It stands in for the vault
+// fingerprint when the provider does not report one, and is replaced by the provider
+// fingerprint once the token is authorized at a vault that reports it.
+func CardFingerprint(cardToken string) string {
+	sum := sha256.Sum256([]byte(cardToken))
+	return hex.EncodeToString(sum[:])
 }
 
-func NewAuthorized(amount int64, currency, cardToken, providerTxID, merchantID string) Payment {
+func NewAuthorized(amount int64, currency, cardToken, cardFingerprint, providerTxID, merchantID string) Payment {
 	now := time.Now().UTC()
 	return Payment{
-		ID:           uuid.New().String(),
-		Amount:       amount,
-		Currency:     currency,
-		CardToken:    cardToken,
-		Status:       StatusAuthorized,
-		ProviderTxID: providerTxID,
-		MerchantID:   merchantID,
-		CreatedAt:    now,
-		UpdatedAt:    now,
+		ID:              uuid.New().String(),
+		Amount:          amount,
+		Currency:        currency,
+		CardToken:       cardToken,
+		CardFingerprint: cardFingerprint,
+		Status:          StatusAuthorized,
+		ProviderTxID:    providerTxID,
+		MerchantID:      merchantID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 }
 
-func NewDeclined(amount int64, currency, cardToken, providerTxID, merchantID, reason string) Payment {
+func NewDeclined(amount int64, currency, cardToken, cardFingerprint, providerTxID, merchantID, reason string) Payment {
 	now := time.Now().UTC()
 	return Payment{
-		ID:            uuid.New().String(),
-		Amount:        amount,
-		Currency:      currency,
-		CardToken:     cardToken,
-		Status:        StatusDeclined,
-		DeclineReason: reason,
-		ProviderTxID:  providerTxID,
-		MerchantID:    merchantID,
-		CreatedAt:     now,
-		UpdatedAt:     now,
+		ID:              uuid.New().String(),
+		Amount:          amount,
+		Currency:        currency,
+		CardToken:       cardToken,
+		CardFingerprint: cardFingerprint,
+		Status:          StatusDeclined,
+		DeclineReason:   reason,
+		ProviderTxID:    providerTxID,
+		MerchantID:      merchantID,
+		CreatedAt:       now,
+		UpdatedAt:       now,
 	}
 }
 
+// NewRequiresAction is a payment the provider holds until the cardholder completes the
+// challenge at challengeURL.
+func NewRequiresAction(amount int64, currency, cardToken, cardFingerprint, providerTxID, merchantID, challengeURL string) Payment {
+	p := NewAuthorized(amount, currency, cardToken, cardFingerprint, providerTxID, merchantID)
+	p.Status = StatusRequiresAction
+	p.ChallengeURL = challengeURL
+	return p
+}
+
+// CaptureClaim is a payment leased by the capture scheduler for a single capture attempt.
+type CaptureClaim struct {
+	PaymentID    string
+	MerchantID   string
+	Provider     string
+	ProviderTxID string
+	Amount       int64
+	Currency     string
+	CaptureAt    time.Time
+	Attempts     int
+}
+
 type CaptureWebhook struct {
-	Event         string `json:"event"`
-	TransactionID string `json:"transaction_id"`
-	RefundID      string `json:"refund_id,omitempty"`
-	OrderID       string `json:"order_id"`
-	MerchantID    string `json:"merchant_id"`
-	Status        string `json:"status"`
-	Amount        int64  `json:"amount"`
-	Currency      string `json:"currency"`
-	Timestamp     string `json:"timestamp"`
+	// Provider names the PSP the webhook came from; empty for the default provider.
+	Provider       string `json:"provider,omitempty"`
+	Event          string `json:"event"`
+	TransactionID  string `json:"transaction_id"`
+	RefundID       string `json:"refund_id,omitempty"`
+	CaptureID      string `json:"capture_id,omitempty"`
+	OrderID        string `json:"order_id"`
+	MerchantID     string `json:"merchant_id"`
+	Status         string `json:"status"`
+	Amount         int64  `json:"amount"`
+	CapturedAmount int64  `json:"captured_amount"`
+	FinalCapture   bool   `json:"final_capture,omitempty"`
+	Currency       string `json:"currency"`
+	DeclineReason  string `json:"decline_reason,omitempty"`
+	Timestamp      string `json:"timestamp"`
+}
+
+// CaptureRequest captures part or all of an authorized payment. Final closes the
+// authorization and releases the uncaptured remainder at the provider.
+type CaptureRequest struct {
+	Amount         int64  `json:"amount" binding:"required,min=1"`
+	Final          bool   `json:"final"`
+	IdempotencyKey string `json:"idempotency_key"`
 }
 
 type RefundRequest struct {
-	Amount int64 `json:"amount" binding:"required,min=1"`
+	Amount         int64  `json:"amount" binding:"required,min=1"`
+	Reason         string `json:"reason"`
+	IdempotencyKey string `json:"idempotency_key"`
 }
 
+type CaptureMethod string
+
+const (
+	CaptureMethodAutomatic CaptureMethod = "automatic"
+	CaptureMethodManual    CaptureMethod = "manual"
+)
+
 type CreatePaymentRequest struct {
-	Amount       int64  `json:"amount" binding:"required,min=1"`
-	Currency     string `json:"currency" binding:"required,len=3"`
-	CardToken    string `json:"card_token" binding:"required"`
-	CaptureDelay string `json:"capture_delay"`
+	Amount       int64          `json:"amount" binding:"required,min=1"`
+	Currency     money.Currency `json:"currency" binding:"required"`
+	CardToken    string         `json:"card_token" binding:"required"`
+	CaptureDelay string         `json:"capture_delay"`
+	// CaptureMethod manual leaves the payment for the merchant to capture, possibly in
+	// several partial captures; capture_delay is then ignored. Defaults to automatic.
+	CaptureMethod CaptureMethod `json:"capture_method" binding:"omitempty,oneof=automatic manual"`
+	// Country is the customer's ISO 3166-1 alpha-2 country, used by risk rules.
+	Country string `json:"country" binding:"omitempty,len=2,alpha"`
+	// CustomerIP is the cardholder's IP address as seen by the merchant, used by risk rules.
+	CustomerIP string `json:"customer_ip" binding:"omitempty,ip"`
+	// IdempotencyKey is the client's Idempotency-Key, if any. A retry under the same key
+	// sends the provider the same authorization, so it cannot authorize twice.
+	IdempotencyKey string `json:"-"`
 }
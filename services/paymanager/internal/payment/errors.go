@@ -3,8 +3,16 @@ package payment
 import "errors"
 
 var (
-	ErrNotFound            = errors.New("payment not found")
-	ErrAlreadyExists       = errors.New("payment already exists")
-	ErrInvalidStatus       = errors.New("invalid payment status transition")
-	ErrRefundExceedsAmount = errors.New("refund amount exceeds remaining balance")
+	ErrNotFound             = errors.New("payment not found")
+	ErrAlreadyExists        = errors.New("payment already exists")
+	ErrInvalidStatus        = errors.New("invalid payment status transition")
+	ErrRefundExceedsAmount  = errors.New("refund amount exceeds remaining balance")
+	ErrCaptureExceedsAmount = errors.New("capture amount exceeds remaining authorized amount")
+	ErrRefundNotFound       = errors.New("refund not found")
+	ErrRefundAlreadyExists  = errors.New("refund already exists")
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrIdempotencyMismatch  = errors.New("idempotency key reused with different parameters")
+	// ErrAuthorizationOpen rejects refunds of a partially captured payment: it must be voided
+	// or fully captured first, so a refund never silently releases the uncaptured remainder.
+	ErrAuthorizationOpen = errors.New("payment still holds an uncaptured remainder")
 )
@@ -0,0 +1,54 @@
+package payment
+
+import "time"
+
+// PaymentEventKind is the event_type of a payment event in the unified events table.
+// Provider webhooks are stored under their webhook event name (e.g. "transaction.captured").
+type PaymentEventKind string
+
+const (
+	PaymentEventCreated          PaymentEventKind = "payment.created"
+	PaymentEventCaptureRequested PaymentEventKind = "payment.capture_requested"
+	PaymentEventVoided           PaymentEventKind = "payment.voided"
+	PaymentEventRefundRequested  PaymentEventKind = "payment.refund_requested"
+	// PaymentEventChallengeExpired is written when the cardholder never completed the
+	// authentication challenge and the payment was expired locally.
+	PaymentEventChallengeExpired PaymentEventKind = "payment.challenge_expired"
+)
+
+// PaymentEventData is the payload stored with every payment event. Events written
+// before payloads were introduced decode to the zero value.
+type PaymentEventData struct {
+	OldStatus        Status `json:"old_status,omitempty"`
+	NewStatus        Status `json:"new_status,omitempty"`
+	Amount           int64  `json:"amount,omitempty"`
+	CapturedAmount   int64  `json:"captured_amount,omitempty"`
+	RefundedAmount   int64  `json:"refunded_amount,omitempty"`
+	CaptureID        string `json:"capture_id,omitempty"`
+	RefundID         string `json:"refund_id,omitempty"`
+	ProviderRefundID string `json:"provider_refund_id,omitempty"`
+	DeclineReason    string `json:"decline_reason,omitempty"`
+	WebhookTimestamp string `json:"webhook_timestamp,omitempty"`
+}
+
+type PaymentEvent struct {
+	EventID   string           `json:"event_id"`
+	PaymentID string           `json:"payment_id"`
+	Kind      PaymentEventKind `json:"kind"`
+	Data      PaymentEventData `json:"data"`
+	CreatedAt time.Time        `json:"created_at"`
+}
+
+type PaymentEventPage struct {
+	Items      []PaymentEvent `json:"items"`
+	NextCursor string         `json:"next_cursor"`
+	HasMore    bool           `json:"has_more"`
+}
+
+type PaymentEventQuery struct {
+	Kinds []PaymentEventKind `json:"kinds" url:"kind" form:"kind,omitempty"`
+
+	Limit   int    `json:"limit" url:"limit" form:"limit"`
+	Cursor  string `json:"cursor" url:"cursor" form:"cursor"`
+	SortAsc bool   `json:"sort_asc" url:"sort_asc" form:"sort_asc"`
+}
@@ -2,17 +2,80 @@ package payment
 
 import (
 	"context"
+	"time"
 
+	"TestTaskJustPay/pkg/money"
 	"TestTaskJustPay/services/paymanager/internal/gateway"
+	"TestTaskJustPay/services/paymanager/internal/risk"
 )
 
 // PaymentRepo is the persistence contract for payments.
 type PaymentRepo interface {
 	CreatePayment(ctx context.Context, payment Payment) error
-	GetPaymentByID(ctx context.Context, id string) (*Payment, error)
-	GetPaymentByProviderTxID(ctx context.Context, txID string) (*Payment, error)
-	UpdatePaymentStatus(ctx context.Context, id string, status Status, declineReason string) error
-	UpdatePaymentRefund(ctx context.Context, id string, status Status, refundedAmount int64) error
+	// GetPaymentByID and GetPaymentByIDForUpdate return ErrNotFound for payments of other merchants.
+	GetPaymentByID(ctx context.Context, merchantID, id string) (*Payment, error)
+	GetPaymentByIDForUpdate(ctx context.Context, merchantID, id string) (*Payment, error)
+	// ListPayments reads from the replica; results may lag the primary slightly.
+	ListPayments(ctx context.Context, query PaymentQuery) (PaymentPage, error)
+	// GetPaymentByProviderTxID looks the payment up by the transaction id the provider
+	// assigned. An empty provider matches payments of any provider.
+	GetPaymentByProviderTxID(ctx context.Context, provider, txID string) (*Payment, error)
+	// TransitionStatus, UpdatePaymentCapture and UpdatePaymentRefund apply t and append it to the
+	// payment's status history atomically. They return ErrInvalidStatus when the payment's
+	// current status does not allow t, and ErrNotFound when the payment does not exist.
+	TransitionStatus(ctx context.Context, id string, t Transition) error
+	UpdatePaymentCapture(ctx context.Context, id string, t Transition, capturedAmount int64) error
+	UpdatePaymentRefund(ctx context.Context, id string, t Transition, refundedAmount int64) error
+	// DeclinePayment applies t like TransitionStatus and records the decline reason.
+	DeclinePayment(ctx context.Context, id string, t Transition, reason string) error
+	// LockAbandonedChallenges locks up to limit payments still in requires_action that were
+	// created before createdBefore and returns their ids. Rows locked by another replica are skipped.
+	LockAbandonedChallenges(ctx context.Context, createdBefore time.Time, limit int) ([]string, error)
+	// FindCardFingerprint returns the provider fingerprint recorded for the merchant's most
+	// recent payment with cardToken, or "" when the token was not used before.
+	FindCardFingerprint(ctx context.Context, merchantID, cardToken string) (string, error)
+	// RekeyCardFingerprint backfills the provider fingerprint over the merchant's payments
+	// and risk decisions that still carry the token-hash stand-in for cardToken, so filters
+	// and velocity rules see the card's whole history under one fingerprint.
+	RekeyCardFingerprint(ctx context.Context, merchantID, cardToken, fingerprint string) error
+	// GetStatusHistory returns the payment's transitions, oldest first.
+	GetStatusHistory(ctx context.Context, paymentID string) ([]StatusChange, error)
+}
+
+// RefundRepo is the persistence contract for refunds.
+type RefundRepo interface {
+	CreateRefund(ctx context.Context, refund Refund) error
+	GetRefundByID(ctx context.Context, id string) (*Refund, error)
+	GetRefundByIdempotencyKey(ctx context.Context, paymentID, key string) (*Refund, error)
+	// GetRefundForWebhook resolves the refund a provider webhook refers to: by provider
+	// refund id first, falling back to the oldest pending refund of the same amount
+	// that has not been linked yet (webhook arrived before the provider response was stored).
+	GetRefundForWebhook(ctx context.Context, paymentID, providerRefundID string, amount int64) (*Refund, error)
+	ListRefundsByPaymentID(ctx context.Context, paymentID string) ([]Refund, error)
+	SumPendingRefunds(ctx context.Context, paymentID string) (int64, error)
+	SetProviderRefundID(ctx context.Context, id, providerRefundID string) error
+	UpdateRefundStatus(ctx context.Context, id string, status RefundStatus, providerRefundID, failureReason string) error
+}
+
+// CaptureRepo is the persistence contract for the capture scheduler.
+// Claims are leases: a claimed payment that is neither submitted nor released
+// before its lease expires is picked up again by ReclaimStaleCaptures.
+type CaptureRepo interface {
+	// ClaimDueCaptures moves up to limit authorized payments with capture_at <= now()
+	// to capture_pending and leases them for the given duration.
+	ClaimDueCaptures(ctx context.Context, limit int, lease time.Duration) ([]CaptureClaim, error)
+	// ReclaimStaleCaptures re-leases capture_pending payments whose lease expired
+	// before the provider accepted the capture.
+	ReclaimStaleCaptures(ctx context.Context, limit int, lease time.Duration) ([]CaptureClaim, error)
+	MarkCaptureSubmitted(ctx context.Context, id string) error
+	// ReleaseCapture records a failed attempt and keeps the payment leased until retryAt.
+	ReleaseCapture(ctx context.Context, id string, errMsg string, retryAt time.Time) error
+	MarkCaptureFailed(ctx context.Context, id string, errMsg string) error
+}
+
+// RiskAssessor decides whether a payment attempt may be authorized. Every call stores a decision.
+type RiskAssessor interface {
+	Evaluate(ctx context.Context, in risk.Input) (risk.Decision, error)
 }
 
 // Provider is the minimal interface this domain requires from the payment gateway.
@@ -22,3 +85,12 @@ type Provider interface {
 	VoidPayment(ctx context.Context, req gateway.VoidRequest) (gateway.VoidResult, error)
 	RefundPayment(ctx context.Context, req gateway.RefundRequest) (gateway.RefundResult, error)
 }
+
+// ProviderRouter picks the providers a new payment is authorized with and resolves the
+// provider recorded on an existing one.
+type ProviderRouter interface {
+	// Route returns provider names to try for a new payment, in failover order.
+	Route(merchantID string, amount money.Money) []string
+	// Provider returns the named provider; an empty name is the default provider.
+	Provider(name string) (Provider, error)
+}
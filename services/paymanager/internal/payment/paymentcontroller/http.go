@@ -2,9 +2,14 @@ package paymentcontroller
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+	"TestTaskJustPay/services/paymanager/internal/idempotency"
+	"TestTaskJustPay/services/paymanager/internal/merchantauth"
 	"TestTaskJustPay/services/paymanager/internal/payment"
 
 	"github.com/gin-gonic/gin"
@@ -19,14 +24,25 @@ func NewHTTPHandler(s *payment.PaymentService) *HTTPHandler {
 }
 
 func (h *HTTPHandler) Create(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
 	var req payment.CreatePaymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.IdempotencyKey = c.GetHeader(idempotency.HeaderName)
 
-	p, err := h.service.CreatePayment(c.Request.Context(), req)
+	p, err := h.service.CreatePayment(c.Request.Context(), m, req)
 	if err != nil {
+		if errors.Is(err, gateway.ErrUnknownCard) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "unknown card token"})
+			return
+		}
 		slog.Error("payment creation failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "payment creation failed"})
 		return
@@ -36,13 +52,39 @@ func (h *HTTPHandler) Create(c *gin.Context) {
 }
 
 func (h *HTTPHandler) Get(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
 	id := c.Param("id")
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment id"})
 		return
 	}
 
-	p, err := h.service.GetPaymentByID(c.Request.Context(), id)
+	expandHistory := false
+	for _, v := range c.QueryArray("expand") {
+		for _, field := range strings.Split(v, ",") {
+			switch strings.TrimSpace(field) {
+			case "history":
+				expandHistory = true
+			case "":
+			default:
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown expand field %q", field)})
+				return
+			}
+		}
+	}
+
+	var p *payment.Payment
+	var err error
+	if expandHistory {
+		p, err = h.service.GetPaymentWithHistory(c.Request.Context(), m.ID, id)
+	} else {
+		p, err = h.service.GetPaymentByID(c.Request.Context(), m.ID, id)
+	}
 	if err != nil {
 		if errors.Is(err, payment.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
@@ -55,14 +97,82 @@ func (h *HTTPHandler) Get(c *gin.Context) {
 	c.JSON(http.StatusOK, p)
 }
 
+func (h *HTTPHandler) List(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	var query payment.PaymentQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.ListPayments(c.Request.Context(), m.ID, query)
+	if err != nil {
+		if errors.Is(err, payment.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *HTTPHandler) GetEvents(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment id"})
+		return
+	}
+
+	var query payment.PaymentEventQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.GetPaymentEvents(c.Request.Context(), m.ID, id, query)
+	if err != nil {
+		if errors.Is(err, payment.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return
+		}
+		if errors.Is(err, payment.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cursor"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
 func (h *HTTPHandler) Void(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
 	id := c.Param("id")
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment id"})
 		return
 	}
 
-	p, err := h.service.VoidPayment(c.Request.Context(), id)
+	p, err := h.service.VoidPayment(c.Request.Context(), m.ID, id)
 	if err != nil {
 		if errors.Is(err, payment.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
@@ -80,7 +190,54 @@ func (h *HTTPHandler) Void(c *gin.Context) {
 	c.JSON(http.StatusOK, p)
 }
 
+func (h *HTTPHandler) Capture(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment id"})
+		return
+	}
+
+	var req payment.CaptureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	p, err := h.service.CapturePayment(c.Request.Context(), m.ID, id, req)
+	if err != nil {
+		if errors.Is(err, payment.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return
+		}
+		if errors.Is(err, payment.ErrInvalidStatus) {
+			c.JSON(http.StatusConflict, gin.H{"error": "payment cannot be captured in current state"})
+			return
+		}
+		if errors.Is(err, payment.ErrCaptureExceedsAmount) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "capture amount exceeds remaining authorized amount"})
+			return
+		}
+		slog.Error("payment capture failed", "payment_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "capture failed"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, p)
+}
+
 func (h *HTTPHandler) Refund(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
 	id := c.Param("id")
 	if id == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment id"})
@@ -93,7 +250,7 @@ func (h *HTTPHandler) Refund(c *gin.Context) {
 		return
 	}
 
-	p, err := h.service.RefundPayment(c.Request.Context(), id, req)
+	rf, err := h.service.RefundPayment(c.Request.Context(), m.ID, id, req)
 	if err != nil {
 		if errors.Is(err, payment.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
@@ -103,14 +260,74 @@ func (h *HTTPHandler) Refund(c *gin.Context) {
 			c.JSON(http.StatusConflict, gin.H{"error": "payment cannot be refunded in current state"})
 			return
 		}
+		if errors.Is(err, payment.ErrAuthorizationOpen) {
+			c.JSON(http.StatusConflict, gin.H{"error": "payment is partially captured: void it or capture the rest before refunding"})
+			return
+		}
 		if errors.Is(err, payment.ErrRefundExceedsAmount) {
 			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "refund amount exceeds remaining balance"})
 			return
 		}
+		if errors.Is(err, payment.ErrIdempotencyMismatch) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "idempotency key was already used for a different refund"})
+			return
+		}
 		slog.Error("payment refund failed", "payment_id", id, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "refund failed"})
 		return
 	}
 
-	c.JSON(http.StatusAccepted, p)
+	c.JSON(http.StatusAccepted, rf)
+}
+
+func (h *HTTPHandler) ListRefunds(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing payment id"})
+		return
+	}
+
+	refunds, err := h.service.GetRefundsByPaymentID(c.Request.Context(), m.ID, id)
+	if err != nil {
+		if errors.Is(err, payment.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "payment not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+func (h *HTTPHandler) GetRefund(c *gin.Context) {
+	m, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing refund id"})
+		return
+	}
+
+	rf, err := h.service.GetRefundByID(c.Request.Context(), m.ID, id)
+	if err != nil {
+		if errors.Is(err, payment.ErrRefundNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "refund not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rf)
 }
@@ -2,15 +2,19 @@ package paymentrepo
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"time"
 
 	"TestTaskJustPay/pkg/postgres"
 	"TestTaskJustPay/services/paymanager/internal/payment"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
 )
 
+var paymentColumns = []string{"id", "amount", "currency", "card_token", "card_fingerprint", "status", "decline_reason",
+	"challenge_url", "provider_tx_id", "provider", "merchant_id", "captured_amount", "refunded_amount", "capture_at", "risk_decision_id", "hold_reason", "created_at", "updated_at"}
+
 type PgPaymentRepo struct {
 	pg *postgres.Postgres
 	repo
@@ -37,15 +41,22 @@ type repo struct {
 
 func (r *repo) CreatePayment(ctx context.Context, p payment.Payment) error {
 	query, args, err := r.builder.Insert("payments").
-		Columns("id", "amount", "currency", "card_token", "status", "decline_reason",
-			"provider_tx_id", "merchant_id", "refunded_amount", "capture_at", "created_at", "updated_at").
-		Values(p.ID, p.Amount, p.Currency, p.CardToken, p.Status, nilIfEmpty(p.DeclineReason),
-			nilIfEmpty(p.ProviderTxID), p.MerchantID, p.RefundedAmount, p.CaptureAt, p.CreatedAt, p.UpdatedAt).
+		Columns(paymentColumns...).
+		Values(p.ID, p.Amount, p.Currency, p.CardToken, p.CardFingerprint, p.Status, nilIfEmpty(p.DeclineReason),
+			nilIfEmpty(p.ChallengeURL), nilIfEmpty(p.ProviderTxID), p.Provider, p.MerchantID, p.CapturedAmount, p.RefundedAmount, p.CaptureAt,
+			nilIfEmpty(p.RiskDecisionID), nilIfEmpty(p.HoldReason), p.CreatedAt, p.UpdatedAt).
+		Suffix("RETURNING id, status, created_at").
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("build insert: %w", err)
 	}
 
+	// The initial status is the first history entry, written in the same statement.
+	query = fmt.Sprintf(`WITH inserted AS (%s)
+		INSERT INTO payment_status_history (id, payment_id, from_status, to_status, source, created_at)
+		SELECT gen_random_uuid(), id, NULL, status, $%d, created_at FROM inserted`, query, len(args)+1)
+	args = append(args, payment.SourceAPI)
+
 	_, err = r.db.Exec(ctx, query, args...)
 	if err != nil {
 		if postgres.IsPgErrorUniqueViolation(err) {
@@ -56,95 +67,127 @@ func (r *repo) CreatePayment(ctx context.Context, p payment.Payment) error {
 	return nil
 }
 
-func (r *repo) GetPaymentByID(ctx context.Context, id string) (*payment.Payment, error) {
+// GetPaymentByID returns the payment only when it belongs to merchantID, so other
+// merchants' payments are indistinguishable from missing ones.
+func (r *repo) GetPaymentByID(ctx context.Context, merchantID, id string) (*payment.Payment, error) {
 	query, args, err := r.builder.
-		Select("id", "amount", "currency", "card_token", "status", "decline_reason",
-			"provider_tx_id", "merchant_id", "refunded_amount", "capture_at", "created_at", "updated_at").
+		Select(paymentColumns...).
 		From("payments").
-		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.Eq{"id": id, "merchant_id": merchantID}).
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("build select: %w", err)
 	}
 
-	return r.scanPayment(ctx, query, args...)
+	return r.scanPayment(ctx, r.readDB, query, args...)
 }
 
-func (r *repo) GetPaymentByProviderTxID(ctx context.Context, txID string) (*payment.Payment, error) {
+// GetPaymentByIDForUpdate locks the payment row until the surrounding transaction ends.
+// Always reads from the primary.
+func (r *repo) GetPaymentByIDForUpdate(ctx context.Context, merchantID, id string) (*payment.Payment, error) {
 	query, args, err := r.builder.
-		Select("id", "amount", "currency", "card_token", "status", "decline_reason",
-			"provider_tx_id", "merchant_id", "refunded_amount", "capture_at", "created_at", "updated_at").
+		Select(paymentColumns...).
 		From("payments").
-		Where(squirrel.Eq{"provider_tx_id": txID}).
+		Where(squirrel.Eq{"id": id, "merchant_id": merchantID}).
+		Suffix("FOR UPDATE").
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("build select: %w", err)
 	}
 
-	return r.scanPayment(ctx, query, args...)
+	return r.scanPayment(ctx, r.db, query, args...)
 }
 
-func (r *repo) UpdatePaymentStatus(ctx context.Context, id string, status payment.Status, declineReason string) error {
-	q := r.builder.Update("payments").
-		Set("status", status).
-		Set("updated_at", time.Now().UTC()).
-		Where(squirrel.Eq{"id": id})
-
-	if declineReason != "" {
-		q = q.Set("decline_reason", declineReason)
+func (r *repo) GetPaymentByProviderTxID(ctx context.Context, provider, txID string) (*payment.Payment, error) {
+	q := r.builder.
+		Select(paymentColumns...).
+		From("payments").
+		Where(squirrel.Eq{"provider_tx_id": txID})
+	if provider != "" {
+		q = q.Where(squirrel.Eq{"provider": provider})
 	}
 
 	query, args, err := q.ToSql()
 	if err != nil {
-		return fmt.Errorf("build update: %w", err)
+		return nil, fmt.Errorf("build select: %w", err)
 	}
 
-	result, err := r.db.Exec(ctx, query, args...)
+	return r.scanPayment(ctx, r.readDB, query, args...)
+}
+
+func (r *repo) scanPayment(ctx context.Context, db postgres.Executor, query string, args ...any) (*payment.Payment, error) {
+	rows, err := db.Query(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("update payment: %w", err)
+		return nil, fmt.Errorf("query payment: %w", err)
 	}
-	if result.RowsAffected() == 0 {
-		return payment.ErrNotFound
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, payment.ErrNotFound
 	}
-	return nil
+
+	return scanPaymentRow(rows)
 }
 
-func (r *repo) UpdatePaymentRefund(ctx context.Context, id string, status payment.Status, refundedAmount int64) error {
-	query, args, err := r.builder.Update("payments").
-		Set("status", status).
-		Set("refunded_amount", refundedAmount).
-		Set("updated_at", time.Now().UTC()).
-		Where(squirrel.Eq{"id": id}).
+func (r *repo) FindCardFingerprint(ctx context.Context, merchantID, cardToken string) (string, error) {
+	query, args, err := r.builder.
+		Select("card_fingerprint").
+		From("payments").
+		Where(squirrel.Eq{"merchant_id": merchantID, "card_token": cardToken}).
+		OrderBy("created_at DESC").
+		Limit(1).
 		ToSql()
 	if err != nil {
-		return fmt.Errorf("build update refund: %w", err)
+		return "", fmt.Errorf("build select: %w", err)
 	}
 
-	result, err := r.db.Exec(ctx, query, args...)
-	if err != nil {
-		return fmt.Errorf("update payment refund: %w", err)
+	var fingerprint string
+	err = r.db.QueryRow(ctx, query, args...).Scan(&fingerprint)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
 	}
-	if result.RowsAffected() == 0 {
-		return payment.ErrNotFound
+	if err != nil {
+		return "", fmt.Errorf("find card fingerprint: %w", err)
 	}
-	return nil
+	return fingerprint, nil
 }
 
-func (r *repo) scanPayment(ctx context.Context, query string, args ...any) (*payment.Payment, error) {
-	rows, err := r.readDB.Query(ctx, query, args...)
+func (r *repo) RekeyCardFingerprint(ctx context.Context, merchantID, cardToken, fingerprint string) error {
+	stale := payment.CardFingerprint(cardToken)
+	if stale == fingerprint {
+		return nil
+	}
+
+	query, args, err := r.builder.Update("payments").
+		Set("card_fingerprint", fingerprint).
+		Where(squirrel.Eq{"merchant_id": merchantID, "card_token": cardToken, "card_fingerprint": stale}).
+		ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("query payment: %w", err)
+		return fmt.Errorf("build update payments: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("rekey payments card fingerprint: %w", err)
 	}
-	defer rows.Close()
 
-	if !rows.Next() {
-		return nil, payment.ErrNotFound
+	query, args, err = r.builder.Update("risk_decisions").
+		Set("card_fingerprint", fingerprint).
+		Where(squirrel.Eq{"merchant_id": merchantID, "card_fingerprint": stale}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update risk decisions: %w", err)
 	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("rekey risk decisions card fingerprint: %w", err)
+	}
+	return nil
+}
 
+func scanPaymentRow(rows pgx.Rows) (*payment.Payment, error) {
 	var p payment.Payment
-	var declineReason, providerTxID *string
-	err = rows.Scan(&p.ID, &p.Amount, &p.Currency, &p.CardToken, &p.Status,
-		&declineReason, &providerTxID, &p.MerchantID, &p.RefundedAmount, &p.CaptureAt, &p.CreatedAt, &p.UpdatedAt)
+	var declineReason, challengeURL, providerTxID, riskDecisionID, holdReason *string
+	err := rows.Scan(&p.ID, &p.Amount, &p.Currency, &p.CardToken, &p.CardFingerprint, &p.Status,
+		&declineReason, &challengeURL, &providerTxID, &p.Provider, &p.MerchantID, &p.CapturedAmount, &p.RefundedAmount, &p.CaptureAt,
+		&riskDecisionID, &holdReason, &p.CreatedAt, &p.UpdatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("scan payment: %w", err)
 	}
@@ -152,9 +195,18 @@ func (r *repo) scanPayment(ctx context.Context, query string, args ...any) (*pay
 	if declineReason != nil {
 		p.DeclineReason = *declineReason
 	}
+	if challengeURL != nil {
+		p.ChallengeURL = *challengeURL
+	}
 	if providerTxID != nil {
 		p.ProviderTxID = *providerTxID
 	}
+	if riskDecisionID != nil {
+		p.RiskDecisionID = *riskDecisionID
+	}
+	if holdReason != nil {
+		p.HoldReason = *holdReason
+	}
 	return &p, nil
 }
 
@@ -0,0 +1,183 @@
+package paymentrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/paymanager/internal/payment"
+)
+
+// NewCaptureRepo returns the capture scheduler repository. Claims always go to the primary.
+func NewCaptureRepo(pg *postgres.Postgres) payment.CaptureRepo {
+	return &repo{db: pg.Pool, readDB: pg.Pool, builder: pg.Builder}
+}
+
+// ClaimDueCaptures atomically moves due authorized payments to capture_pending, leases them
+// and records the transition. Uses FOR UPDATE SKIP LOCKED so concurrent schedulers never
+// claim the same payment.
+func (r *repo) ClaimDueCaptures(ctx context.Context, limit int, lease time.Duration) ([]payment.CaptureClaim, error) {
+	t := payment.Transition{
+		From:   payment.StatusAuthorized,
+		To:     payment.StatusCapturePending,
+		Source: payment.SourceScheduler,
+		Reason: "scheduled capture due",
+	}
+	from, err := allowedFrom(t)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		WITH due AS (
+			SELECT id, status FROM payments
+			WHERE status = ANY($3) AND capture_at <= now()
+			ORDER BY capture_at ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE payments p
+			SET status = $4,
+			    capture_attempts = p.capture_attempts + 1,
+			    capture_locked_until = now() + make_interval(secs => $2),
+			    capture_error = NULL,
+			    updated_at = now()
+			FROM due
+			WHERE p.id = due.id
+			RETURNING p.id, p.merchant_id, p.provider, p.provider_tx_id, p.amount, p.currency, p.capture_at, p.capture_attempts,
+			          due.status AS from_status, p.updated_at
+		), history AS (
+			INSERT INTO payment_status_history (id, payment_id, from_status, to_status, source, reason, created_at)
+			SELECT gen_random_uuid(), id, from_status, $4, $5, $6, updated_at
+			FROM claimed
+		)
+		SELECT id, merchant_id, provider, provider_tx_id, amount, currency, capture_at, capture_attempts FROM claimed`
+
+	return r.queryCaptureClaims(ctx, query, limit, lease.Seconds(), from, t.To, t.Source, t.Reason)
+}
+
+// ReclaimStaleCaptures re-leases capture_pending payments whose lease expired before
+// the provider accepted the capture (scheduler crash, provider error awaiting retry).
+// The payment stays capture_pending, so no transition is recorded.
+func (r *repo) ReclaimStaleCaptures(ctx context.Context, limit int, lease time.Duration) ([]payment.CaptureClaim, error) {
+	query := `
+		UPDATE payments
+		SET capture_attempts = capture_attempts + 1,
+		    capture_locked_until = now() + make_interval(secs => $2),
+		    updated_at = now()
+		WHERE id IN (
+			SELECT id FROM payments
+			WHERE status = 'capture_pending'
+			  AND capture_submitted_at IS NULL
+			  AND capture_locked_until < now()
+			ORDER BY capture_locked_until ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, merchant_id, provider, provider_tx_id, amount, currency, capture_at, capture_attempts`
+
+	return r.queryCaptureClaims(ctx, query, limit, lease.Seconds())
+}
+
+// MarkCaptureSubmitted records that the provider accepted the capture.
+// The final status arrives later through the capture webhook.
+func (r *repo) MarkCaptureSubmitted(ctx context.Context, id string) error {
+	query := `
+		UPDATE payments
+		SET capture_submitted_at = now(), capture_locked_until = NULL, capture_error = NULL, updated_at = now()
+		WHERE id = $1`
+
+	tag, err := r.db.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("mark capture submitted: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return payment.ErrNotFound
+	}
+	return nil
+}
+
+// ReleaseCapture stores the attempt error and pushes the lease out to retryAt.
+func (r *repo) ReleaseCapture(ctx context.Context, id string, errMsg string, retryAt time.Time) error {
+	query := `
+		UPDATE payments
+		SET capture_error = $2, capture_locked_until = $3, updated_at = now()
+		WHERE id = $1 AND status = 'capture_pending'`
+
+	tag, err := r.db.Exec(ctx, query, id, errMsg, retryAt)
+	if err != nil {
+		return fmt.Errorf("release capture: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return payment.ErrInvalidStatus
+	}
+	return nil
+}
+
+// MarkCaptureFailed gives up on a capture and records the transition. Guarded by status
+// so a capture webhook that already moved the payment on is never overwritten.
+func (r *repo) MarkCaptureFailed(ctx context.Context, id string, errMsg string) error {
+	t := payment.Transition{
+		From:   payment.StatusCapturePending,
+		To:     payment.StatusCaptureFailed,
+		Source: payment.SourceScheduler,
+		Reason: errMsg,
+	}
+	from, err := allowedFrom(t)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		WITH prev AS (
+			SELECT id, status FROM payments WHERE id = $1 FOR UPDATE
+		), failed AS (
+			UPDATE payments p
+			SET status = $2, capture_error = $5, capture_locked_until = NULL, updated_at = now()
+			FROM prev
+			WHERE p.id = prev.id AND prev.status = ANY($3) AND p.capture_submitted_at IS NULL
+			RETURNING p.id, prev.status AS from_status, p.updated_at
+		)
+		INSERT INTO payment_status_history (id, payment_id, from_status, to_status, source, reason, created_at)
+		SELECT gen_random_uuid(), id, from_status, $2, $4, $5, updated_at FROM failed`
+
+	tag, err := r.db.Exec(ctx, query, id, t.To, from, t.Source, errMsg)
+	if err != nil {
+		return fmt.Errorf("mark capture failed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return payment.ErrInvalidStatus
+	}
+	return nil
+}
+
+func (r *repo) queryCaptureClaims(ctx context.Context, query string, args ...any) ([]payment.CaptureClaim, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("claim captures: %w", err)
+	}
+	defer rows.Close()
+
+	var claims []payment.CaptureClaim
+	for rows.Next() {
+		var c payment.CaptureClaim
+		var providerTxID *string
+		var captureAt *time.Time
+		if err := rows.Scan(&c.PaymentID, &c.MerchantID, &c.Provider, &providerTxID, &c.Amount, &c.Currency, &captureAt, &c.Attempts); err != nil {
+			return nil, fmt.Errorf("scan capture claim: %w", err)
+		}
+		if providerTxID != nil {
+			c.ProviderTxID = *providerTxID
+		}
+		if captureAt != nil {
+			c.CaptureAt = *captureAt
+		}
+		claims = append(claims, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate capture claims: %w", err)
+	}
+
+	return claims, nil
+}
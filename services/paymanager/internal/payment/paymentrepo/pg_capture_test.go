@@ -0,0 +1,66 @@
+package paymentrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/payment"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClaimDueCaptures(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repo{db: mock, readDB: mock, builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)}
+	captureAt := time.Now().UTC()
+	providerTxID := "tx-1"
+
+	mock.ExpectQuery(`WITH due AS .* status = ANY\(\$3\) .* SET status = \$4,.* INSERT INTO payment_status_history .* SELECT gen_random_uuid\(\), id, from_status, \$4, \$5, \$6`).
+		WithArgs(10, float64(30), []string{"authorized"}, payment.StatusCapturePending, payment.SourceScheduler, "scheduled capture due").
+		WillReturnRows(mock.NewRows([]string{"id", "merchant_id", "provider", "provider_tx_id", "amount", "currency", "capture_at", "capture_attempts"}).
+			AddRow("pay-1", "merchant_1", "silvergate", &providerTxID, int64(1000), "USD", &captureAt, 1))
+
+	claims, err := r.ClaimDueCaptures(context.Background(), 10, 30*time.Second)
+
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+	require.Len(t, claims, 1)
+	assert.Equal(t, "pay-1", claims[0].PaymentID)
+	assert.Equal(t, "tx-1", claims[0].ProviderTxID)
+}
+
+func TestMarkCaptureFailed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repo{db: mock, readDB: mock, builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)}
+	ctx := context.Background()
+
+	t.Run("moves the payment to capture_failed and records history", func(t *testing.T) {
+		mock.ExpectExec(`WITH prev AS .* SET status = \$2, capture_error = \$5,.* prev.status = ANY\(\$3\) .* INSERT INTO payment_status_history`).
+			WithArgs("pay-1", payment.StatusCaptureFailed, []string{"capture_pending"}, payment.SourceScheduler, "provider unavailable").
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		require.NoError(t, r.MarkCaptureFailed(ctx, "pay-1", "provider unavailable"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("payment already moved on", func(t *testing.T) {
+		mock.ExpectExec(`WITH prev AS`).
+			WithArgs("pay-1", payment.StatusCaptureFailed, []string{"capture_pending"}, payment.SourceScheduler, "provider unavailable").
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+		err := r.MarkCaptureFailed(ctx, "pay-1", "provider unavailable")
+
+		assert.ErrorIs(t, err, payment.ErrInvalidStatus)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,43 @@
+package paymentrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/payment"
+)
+
+func (r *repo) LockAbandonedChallenges(ctx context.Context, createdBefore time.Time, limit int) ([]string, error) {
+	query, args, err := r.builder.
+		Select("id").
+		From("payments").
+		Where("status = ?", payment.StatusRequiresAction).
+		Where("created_at < ?", createdBefore).
+		OrderBy("created_at").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query abandoned challenges: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan payment id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate abandoned challenges: %w", err)
+	}
+	return ids, nil
+}
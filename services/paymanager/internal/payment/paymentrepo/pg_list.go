@@ -0,0 +1,152 @@
+package paymentrepo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/payment"
+
+	"github.com/Masterminds/squirrel"
+)
+
+func (r *repo) ListPayments(ctx context.Context, query payment.PaymentQuery) (payment.PaymentPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = 10
+	}
+	if query.Limit > 1000 {
+		query.Limit = 1000
+	}
+
+	sqlQuery, args, err := r.buildPaymentPageQuery(query)
+	if err != nil {
+		return payment.PaymentPage{}, err
+	}
+
+	rows, err := r.readDB.Query(ctx, sqlQuery, args...)
+	if err != nil {
+		return payment.PaymentPage{}, fmt.Errorf("query payments: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]payment.Payment, 0)
+	for rows.Next() {
+		p, err := scanPaymentRow(rows)
+		if err != nil {
+			return payment.PaymentPage{}, err
+		}
+		items = append(items, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return payment.PaymentPage{}, fmt.Errorf("iterate payments: %w", err)
+	}
+
+	hasMore := len(items) > query.Limit
+	if hasMore {
+		items = items[:query.Limit]
+	}
+
+	var nextCursor string
+	if hasMore && len(items) > 0 {
+		lastItem := items[len(items)-1]
+		nextCursor = encodePaymentCursor(paymentCursor{
+			PaymentID: lastItem.ID,
+			CreatedAt: lastItem.CreatedAt,
+		})
+	}
+
+	return payment.PaymentPage{
+		Items:      items,
+		NextCursor: nextCursor,
+		HasMore:    hasMore,
+	}, nil
+}
+
+type paymentCursor struct {
+	PaymentID string    `json:"payment_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func encodePaymentCursor(c paymentCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func decodePaymentCursor(s string) (paymentCursor, error) {
+	var c paymentCursor
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	return c, json.Unmarshal(b, &c)
+}
+
+func (r *repo) buildPaymentPageQuery(q payment.PaymentQuery) (string, []any, error) {
+	b := r.builder.Select(paymentColumns...).
+		From("payments")
+
+	if q.MerchantID != "" {
+		b = b.Where(squirrel.Eq{"merchant_id": q.MerchantID})
+	}
+
+	if len(q.Statuses) > 0 {
+		b = b.Where(squirrel.Eq{"status": q.Statuses})
+	}
+
+	if len(q.Currencies) > 0 {
+		b = b.Where(squirrel.Eq{"currency": q.Currencies})
+	}
+
+	if q.ProviderTxID != "" {
+		b = b.Where(squirrel.Eq{"provider_tx_id": q.ProviderTxID})
+	}
+
+	if q.CardFingerprint != "" {
+		b = b.Where(squirrel.Eq{"card_fingerprint": q.CardFingerprint})
+	}
+
+	if q.AmountMin != nil {
+		b = b.Where("amount >= ?", *q.AmountMin)
+	}
+
+	if q.AmountMax != nil {
+		b = b.Where("amount <= ?", *q.AmountMax)
+	}
+
+	if q.CreatedFrom != nil {
+		b = b.Where("created_at >= ?", q.CreatedFrom.UTC())
+	}
+
+	if q.CreatedTo != nil {
+		b = b.Where("created_at < ?", q.CreatedTo.UTC())
+	}
+
+	if q.Cursor != "" {
+		cursor, err := decodePaymentCursor(q.Cursor)
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %v", payment.ErrInvalidCursor, err)
+		}
+
+		if q.SortAsc {
+			b = b.Where("(created_at, id) > (?, ?)", cursor.CreatedAt.UTC(), cursor.PaymentID)
+		} else {
+			b = b.Where("(created_at, id) < (?, ?)", cursor.CreatedAt.UTC(), cursor.PaymentID)
+		}
+	}
+
+	if q.SortAsc {
+		b = b.OrderBy("created_at ASC", "id ASC")
+	} else {
+		b = b.OrderBy("created_at DESC", "id DESC")
+	}
+
+	b = b.Limit(uint64(q.Limit + 1))
+
+	sql, args, err := b.ToSql()
+	if err != nil {
+		return "", nil, fmt.Errorf("build payment page query: %w", err)
+	}
+	return sql, args, nil
+}
@@ -0,0 +1,81 @@
+package paymentrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/payment"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListPayments(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repo{db: mock, readDB: mock, builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)}
+	ctx := context.Background()
+
+	paymentRow := func(rows *pgxmock.Rows, id string, createdAt time.Time) *pgxmock.Rows {
+		return rows.AddRow(id, int64(1000), "USD", "tok_1", payment.CardFingerprint("tok_1"), "captured",
+			nil, nil, nil, "silvergate", "merchant_1", int64(1000), int64(0), nil, nil, nil, createdAt, createdAt)
+	}
+
+	t.Run("applies filters and returns cursor when more rows exist", func(t *testing.T) {
+		now := time.Now().UTC()
+		minAmount := int64(500)
+
+		rows := mock.NewRows(paymentColumns)
+		rows = paymentRow(rows, "pay-1", now)
+		rows = paymentRow(rows, "pay-2", now.Add(-time.Second))
+
+		mock.ExpectQuery(`SELECT .* FROM payments WHERE merchant_id = \$1 AND status IN \(\$2\) AND currency IN \(\$3\) AND amount >= \$4 ORDER BY created_at DESC, id DESC LIMIT 2`).
+			WithArgs("merchant_1", payment.StatusCaptured, "USD", minAmount).
+			WillReturnRows(rows)
+
+		page, err := r.ListPayments(ctx, payment.PaymentQuery{
+			MerchantID: "merchant_1",
+			Statuses:   []payment.Status{payment.StatusCaptured},
+			Currencies: []string{"USD"},
+			AmountMin:  &minAmount,
+			Limit:      1,
+		})
+
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, "pay-1", page.Items[0].ID)
+		assert.True(t, page.HasMore)
+
+		cursor, err := decodePaymentCursor(page.NextCursor)
+		require.NoError(t, err)
+		assert.Equal(t, "pay-1", cursor.PaymentID)
+	})
+
+	t.Run("cursor continues after the last item", func(t *testing.T) {
+		createdAt := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		cursor := encodePaymentCursor(paymentCursor{PaymentID: "pay-1", CreatedAt: createdAt})
+
+		mock.ExpectQuery(`SELECT .* FROM payments WHERE \(created_at, id\) < \(\$1, \$2\) ORDER BY created_at DESC, id DESC LIMIT 11`).
+			WithArgs(createdAt, "pay-1").
+			WillReturnRows(mock.NewRows(paymentColumns))
+
+		page, err := r.ListPayments(ctx, payment.PaymentQuery{Cursor: cursor})
+
+		require.NoError(t, err)
+		assert.Empty(t, page.Items)
+		assert.False(t, page.HasMore)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	t.Run("malformed cursor returns ErrInvalidCursor", func(t *testing.T) {
+		_, err := r.ListPayments(ctx, payment.PaymentQuery{Cursor: "not-base64!"})
+		assert.ErrorIs(t, err, payment.ErrInvalidCursor)
+	})
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
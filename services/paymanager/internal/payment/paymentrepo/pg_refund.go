@@ -0,0 +1,228 @@
+package paymentrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/paymanager/internal/payment"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var refundColumns = []string{"id", "payment_id", "amount", "currency", "status", "reason",
+	"provider_refund_id", "failure_reason", "idempotency_key", "created_at", "updated_at"}
+
+// NewRefundRepo returns the refund repository. Reads go to readDB.
+func NewRefundRepo(pg *postgres.Postgres, readDB postgres.Executor) payment.RefundRepo {
+	return &repo{db: pg.Pool, readDB: readDB, builder: pg.Builder}
+}
+
+func RefundTxRepoFactory(builder squirrel.StatementBuilderType) func(postgres.Executor) payment.RefundRepo {
+	return func(tx postgres.Executor) payment.RefundRepo {
+		return &repo{db: tx, readDB: tx, builder: builder}
+	}
+}
+
+func (r *repo) CreateRefund(ctx context.Context, rf payment.Refund) error {
+	query, args, err := r.builder.Insert("refunds").
+		Columns(refundColumns...).
+		Values(rf.ID, rf.PaymentID, rf.Amount, rf.Currency, rf.Status, nilIfEmpty(rf.Reason),
+			nilIfEmpty(rf.ProviderRefundID), nilIfEmpty(rf.FailureReason), rf.IdempotencyKey, rf.CreatedAt, rf.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx, query, args...)
+	if err != nil {
+		if postgres.IsPgErrorUniqueViolation(err) {
+			return payment.ErrRefundAlreadyExists
+		}
+		return fmt.Errorf("insert refund: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) GetRefundByID(ctx context.Context, id string) (*payment.Refund, error) {
+	query, args, err := r.builder.Select(refundColumns...).
+		From("refunds").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	return r.scanRefund(ctx, query, args...)
+}
+
+func (r *repo) GetRefundByIdempotencyKey(ctx context.Context, paymentID, key string) (*payment.Refund, error) {
+	query, args, err := r.builder.Select(refundColumns...).
+		From("refunds").
+		Where(squirrel.Eq{"payment_id": paymentID, "idempotency_key": key}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	return r.scanRefund(ctx, query, args...)
+}
+
+func (r *repo) GetRefundForWebhook(ctx context.Context, paymentID, providerRefundID string, amount int64) (*payment.Refund, error) {
+	if providerRefundID != "" {
+		query, args, err := r.builder.Select(refundColumns...).
+			From("refunds").
+			Where(squirrel.Eq{"provider_refund_id": providerRefundID}).
+			ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("build select: %w", err)
+		}
+
+		rf, err := r.scanRefund(ctx, query, args...)
+		if !errors.Is(err, payment.ErrRefundNotFound) {
+			return rf, err
+		}
+	}
+
+	query, args, err := r.builder.Select(refundColumns...).
+		From("refunds").
+		Where(squirrel.Eq{"payment_id": paymentID, "amount": amount, "status": payment.RefundStatusPending, "provider_refund_id": nil}).
+		OrderBy("created_at ASC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	return r.scanRefund(ctx, query, args...)
+}
+
+func (r *repo) ListRefundsByPaymentID(ctx context.Context, paymentID string) ([]payment.Refund, error) {
+	query, args, err := r.builder.Select(refundColumns...).
+		From("refunds").
+		Where(squirrel.Eq{"payment_id": paymentID}).
+		OrderBy("created_at ASC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	rows, err := r.readDB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query refunds: %w", err)
+	}
+	defer rows.Close()
+
+	refunds := make([]payment.Refund, 0)
+	for rows.Next() {
+		rf, err := scanRefundRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		refunds = append(refunds, *rf)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate refunds: %w", err)
+	}
+
+	return refunds, nil
+}
+
+func (r *repo) SumPendingRefunds(ctx context.Context, paymentID string) (int64, error) {
+	query, args, err := r.builder.Select("COALESCE(SUM(amount), 0)").
+		From("refunds").
+		Where(squirrel.Eq{"payment_id": paymentID, "status": payment.RefundStatusPending}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build select: %w", err)
+	}
+
+	var sum int64
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&sum); err != nil {
+		return 0, fmt.Errorf("sum pending refunds: %w", err)
+	}
+	return sum, nil
+}
+
+func (r *repo) SetProviderRefundID(ctx context.Context, id, providerRefundID string) error {
+	query, args, err := r.builder.Update("refunds").
+		Set("provider_refund_id", providerRefundID).
+		Set("updated_at", time.Now().UTC()).
+		Where(squirrel.Eq{"id": id, "provider_refund_id": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update: %w", err)
+	}
+
+	// Zero rows is fine: the refund webhook may already have linked the provider id.
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("set provider refund id: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) UpdateRefundStatus(ctx context.Context, id string, status payment.RefundStatus, providerRefundID, failureReason string) error {
+	q := r.builder.Update("refunds").
+		Set("status", status).
+		Set("updated_at", time.Now().UTC()).
+		Where(squirrel.Eq{"id": id})
+
+	if providerRefundID != "" {
+		q = q.Set("provider_refund_id", providerRefundID)
+	}
+	if failureReason != "" {
+		q = q.Set("failure_reason", failureReason)
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return fmt.Errorf("build update: %w", err)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("update refund: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return payment.ErrRefundNotFound
+	}
+	return nil
+}
+
+func (r *repo) scanRefund(ctx context.Context, query string, args ...any) (*payment.Refund, error) {
+	rows, err := r.readDB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query refund: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, payment.ErrRefundNotFound
+	}
+
+	return scanRefundRow(rows)
+}
+
+func scanRefundRow(rows pgx.Rows) (*payment.Refund, error) {
+	var rf payment.Refund
+	var reason, providerRefundID, failureReason *string
+	err := rows.Scan(&rf.ID, &rf.PaymentID, &rf.Amount, &rf.Currency, &rf.Status, &reason,
+		&providerRefundID, &failureReason, &rf.IdempotencyKey, &rf.CreatedAt, &rf.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("scan refund: %w", err)
+	}
+
+	if reason != nil {
+		rf.Reason = *reason
+	}
+	if providerRefundID != nil {
+		rf.ProviderRefundID = *providerRefundID
+	}
+	if failureReason != nil {
+		rf.FailureReason = *failureReason
+	}
+	return &rf, nil
+}
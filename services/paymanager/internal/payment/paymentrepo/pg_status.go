@@ -0,0 +1,124 @@
+package paymentrepo
+
+import (
+	"context"
+	"fmt"
+
+	"TestTaskJustPay/services/paymanager/internal/payment"
+)
+
+// transitionQuery locks the payment, applies the update only when the current status is
+// in $3 and records the change in payment_status_history, all in one statement.
+// %s is the extra SET list for the update (may be empty).
+const transitionQuery = `
+	WITH prev AS (
+		SELECT id, status FROM payments WHERE id = $1 FOR UPDATE
+	), updated AS (
+		UPDATE payments p
+		SET status = $2, updated_at = now()%s
+		FROM prev
+		WHERE p.id = prev.id AND prev.status = ANY($3)
+		RETURNING p.id, prev.status AS from_status, p.updated_at
+	)
+	INSERT INTO payment_status_history (id, payment_id, from_status, to_status, source, reason, created_at)
+	SELECT gen_random_uuid(), id, from_status, $2, $4, $5, updated_at FROM updated`
+
+func (r *repo) TransitionStatus(ctx context.Context, id string, t payment.Transition) error {
+	return r.transition(ctx, id, t, "")
+}
+
+func (r *repo) UpdatePaymentCapture(ctx context.Context, id string, t payment.Transition, capturedAmount int64) error {
+	return r.transition(ctx, id, t, "captured_amount", capturedAmount)
+}
+
+func (r *repo) UpdatePaymentRefund(ctx context.Context, id string, t payment.Transition, refundedAmount int64) error {
+	return r.transition(ctx, id, t, "refunded_amount", refundedAmount)
+}
+
+func (r *repo) DeclinePayment(ctx context.Context, id string, t payment.Transition, reason string) error {
+	return r.transition(ctx, id, t, "decline_reason", nilIfEmpty(reason))
+}
+
+func (r *repo) transition(ctx context.Context, id string, t payment.Transition, column string, value ...any) error {
+	from, err := allowedFrom(t)
+	if err != nil {
+		return err
+	}
+
+	args := []any{id, t.To, from, t.Source, nilIfEmpty(t.Reason)}
+	set := ""
+	if column != "" {
+		set = fmt.Sprintf(", %s = $6", column)
+		args = append(args, value...)
+	}
+
+	tag, err := r.db.Exec(ctx, fmt.Sprintf(transitionQuery, set), args...)
+	if err != nil {
+		return fmt.Errorf("transition payment to %s: %w", t.To, err)
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	var exists bool
+	if err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM payments WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("check payment exists: %w", err)
+	}
+	if !exists {
+		return payment.ErrNotFound
+	}
+	return payment.ErrInvalidStatus
+}
+
+// allowedFrom lists the statuses t may start from as a query argument. It returns
+// ErrInvalidStatus when the state machine allows t from none of them.
+func allowedFrom(t payment.Transition) ([]string, error) {
+	allowed := t.AllowedFrom()
+	if len(allowed) == 0 {
+		return nil, payment.ErrInvalidStatus
+	}
+	from := make([]string, len(allowed))
+	for i, s := range allowed {
+		from[i] = string(s)
+	}
+	return from, nil
+}
+
+// GetStatusHistory returns the payment's status transitions, oldest first.
+func (r *repo) GetStatusHistory(ctx context.Context, paymentID string) ([]payment.StatusChange, error) {
+	query, args, err := r.builder.
+		Select("id", "from_status", "to_status", "source", "reason", "created_at").
+		From("payment_status_history").
+		Where("payment_id = ?", paymentID).
+		OrderBy("created_at ASC", "id ASC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	rows, err := r.readDB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query status history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []payment.StatusChange
+	for rows.Next() {
+		var c payment.StatusChange
+		var from, reason *string
+		if err := rows.Scan(&c.ID, &from, &c.ToStatus, &c.Source, &reason, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan status change: %w", err)
+		}
+		if from != nil {
+			c.FromStatus = payment.Status(*from)
+		}
+		if reason != nil {
+			c.Reason = *reason
+		}
+		history = append(history, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate status history: %w", err)
+	}
+	return history, nil
+}
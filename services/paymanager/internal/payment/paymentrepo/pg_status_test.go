@@ -0,0 +1,137 @@
+package paymentrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/payment"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitionStatus(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repo{db: mock, readDB: mock, builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)}
+	ctx := context.Background()
+
+	t.Run("updates status and records history", func(t *testing.T) {
+		mock.ExpectExec(`WITH prev AS .* UPDATE payments p SET status = \$2, updated_at = now\(\) FROM prev .* INSERT INTO payment_status_history`).
+			WithArgs("pay-1", payment.StatusVoided, []string{"authorized"}, payment.SourceAPI, (*string)(nil)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		err := r.TransitionStatus(ctx, "pay-1", payment.Transition{
+			From:   payment.StatusAuthorized,
+			To:     payment.StatusVoided,
+			Source: payment.SourceAPI,
+		})
+
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("sets extra column for captures", func(t *testing.T) {
+		reason := "transaction.captured"
+		mock.ExpectExec(`SET status = \$2, updated_at = now\(\), captured_amount = \$6`).
+			WithArgs("pay-1", payment.StatusCaptured, []string{"capture_pending"}, payment.SourceWebhook, &reason, int64(1000)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		err := r.UpdatePaymentCapture(ctx, "pay-1", payment.Transition{
+			To:     payment.StatusCaptured,
+			Source: payment.SourceWebhook,
+			Reason: reason,
+		}, 1000)
+
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("records decline reason", func(t *testing.T) {
+		reason := "transaction.declined"
+		declineReason := "authentication_failed"
+		mock.ExpectExec(`SET status = \$2, updated_at = now\(\), decline_reason = \$6`).
+			WithArgs("pay-1", payment.StatusDeclined, []string{"requires_action"}, payment.SourceWebhook, &reason, &declineReason).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		err := r.DeclinePayment(ctx, "pay-1", payment.Transition{
+			To:     payment.StatusDeclined,
+			Source: payment.SourceWebhook,
+			Reason: reason,
+		}, declineReason)
+
+		require.NoError(t, err)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("rejects transition from current status", func(t *testing.T) {
+		mock.ExpectExec(`WITH prev AS`).
+			WithArgs("pay-1", payment.StatusVoided, []string{"authorized", "partially_captured", "capture_failed"}, payment.SourceAPI, (*string)(nil)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WithArgs("pay-1").
+			WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+
+		err := r.TransitionStatus(ctx, "pay-1", payment.Transition{To: payment.StatusVoided, Source: payment.SourceAPI})
+
+		assert.ErrorIs(t, err, payment.ErrInvalidStatus)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("missing payment", func(t *testing.T) {
+		mock.ExpectExec(`WITH prev AS`).
+			WithArgs("pay-404", payment.StatusVoided, []string{"authorized", "partially_captured", "capture_failed"}, payment.SourceAPI, (*string)(nil)).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectQuery(`SELECT EXISTS`).
+			WithArgs("pay-404").
+			WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(false))
+
+		err := r.TransitionStatus(ctx, "pay-404", payment.Transition{To: payment.StatusVoided, Source: payment.SourceAPI})
+
+		assert.ErrorIs(t, err, payment.ErrNotFound)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("impossible transition never reaches the database", func(t *testing.T) {
+		err := r.TransitionStatus(ctx, "pay-1", payment.Transition{
+			From:   payment.StatusRefunded,
+			To:     payment.StatusAuthorized,
+			Source: payment.SourceAPI,
+		})
+
+		assert.ErrorIs(t, err, payment.ErrInvalidStatus)
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGetStatusHistory(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repo{db: mock, readDB: mock, builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)}
+	now := time.Now().UTC()
+	reason := "transaction.captured"
+
+	mock.ExpectQuery(`SELECT id, from_status, to_status, source, reason, created_at FROM payment_status_history WHERE payment_id = \$1 ORDER BY created_at ASC, id ASC`).
+		WithArgs("pay-1").
+		WillReturnRows(mock.NewRows([]string{"id", "from_status", "to_status", "source", "reason", "created_at"}).
+			AddRow("h-1", nil, payment.StatusAuthorized, payment.SourceAPI, nil, now).
+			AddRow("h-2", strPtr("authorized"), payment.StatusCaptured, payment.SourceWebhook, &reason, now))
+
+	history, err := r.GetStatusHistory(context.Background(), "pay-1")
+
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Empty(t, history[0].FromStatus)
+	assert.Equal(t, payment.StatusAuthorized, history[1].FromStatus)
+	assert.Equal(t, payment.StatusCaptured, history[1].ToStatus)
+	assert.Equal(t, reason, history[1].Reason)
+}
+
+func strPtr(s string) *string { return &s }
@@ -0,0 +1,39 @@
+package paymentrepo
+
+import (
+	"context"
+	"testing"
+
+	"TestTaskJustPay/services/paymanager/internal/payment"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRekeyCardFingerprint(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	r := &repo{db: mock, readDB: mock, builder: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)}
+	ctx := context.Background()
+	stale := payment.CardFingerprint("tok_1")
+
+	t.Run("replaces the token hash on payments and risk decisions", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE payments SET card_fingerprint = \$1 WHERE card_fingerprint = \$2 AND card_token = \$3 AND merchant_id = \$4`).
+			WithArgs("fp_vault", stale, "tok_1", "merchant_1").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+		mock.ExpectExec(`UPDATE risk_decisions SET card_fingerprint = \$1 WHERE card_fingerprint = \$2 AND merchant_id = \$3`).
+			WithArgs("fp_vault", stale, "merchant_1").
+			WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+		require.NoError(t, r.RekeyCardFingerprint(ctx, "merchant_1", "tok_1", "fp_vault"))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("no-op when the provider reported no fingerprint", func(t *testing.T) {
+		require.NoError(t, r.RekeyCardFingerprint(ctx, "merchant_1", "tok_1", stale))
+		require.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,31 @@
+package payment
+
+import "time"
+
+type PaymentPage struct {
+	Items      []Payment `json:"items"`
+	NextCursor string    `json:"next_cursor"`
+	HasMore    bool      `json:"has_more"`
+}
+
+// PaymentQuery filters the payment listing. Results are ordered by created_at
+// (newest first unless SortAsc) and paged with an opaque cursor.
+type PaymentQuery struct {
+	// MerchantID scopes the listing; set from the authenticated merchant, never from the query string.
+	MerchantID string `json:"-" url:"-" form:"-"`
+
+	Statuses        []Status `json:"statuses" url:"status" form:"status,omitempty"`
+	Currencies      []string `json:"currencies" url:"currency" form:"currency,omitempty"`
+	ProviderTxID    string   `json:"provider_tx_id" url:"provider_tx_id" form:"provider_tx_id"`
+	CardFingerprint string   `json:"card_fingerprint" url:"card_fingerprint" form:"card_fingerprint"`
+
+	AmountMin *int64 `json:"amount_min,omitempty" url:"amount_min,omitempty" form:"amount_min,omitempty"`
+	AmountMax *int64 `json:"amount_max,omitempty" url:"amount_max,omitempty" form:"amount_max,omitempty"`
+
+	CreatedFrom *time.Time `json:"created_from,omitempty" url:"created_from,omitempty" form:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty" url:"created_to,omitempty" form:"created_to,omitempty"`
+
+	Limit   int    `json:"limit" url:"limit" form:"limit"`
+	Cursor  string `json:"cursor" url:"cursor" form:"cursor"`
+	SortAsc bool   `json:"sort_asc" url:"sort_asc" form:"sort_asc"`
+}
@@ -0,0 +1,50 @@
+package payment
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "pending"
+	RefundStatusSucceeded RefundStatus = "succeeded"
+	RefundStatusFailed    RefundStatus = "failed"
+)
+
+// Refund is a single refund request against a captured payment.
+// Pending refunds reserve part of the refundable balance until the provider
+// reports the outcome through a refund webhook.
+type Refund struct {
+	ID               string       `json:"id"`
+	PaymentID        string       `json:"payment_id"`
+	Amount           int64        `json:"amount"`
+	Currency         string       `json:"currency"`
+	Status           RefundStatus `json:"status"`
+	Reason           string       `json:"reason,omitempty"`
+	ProviderRefundID string       `json:"provider_refund_id,omitempty"`
+	FailureReason    string       `json:"failure_reason,omitempty"`
+	IdempotencyKey   string       `json:"idempotency_key"`
+	CreatedAt        time.Time    `json:"created_at"`
+	UpdatedAt        time.Time    `json:"updated_at"`
+}
+
+func NewRefundPending(p *Payment, amount int64, reason, idempotencyKey string) Refund {
+	now := time.Now().UTC()
+	if idempotencyKey == "" {
+		idempotencyKey = uuid.New().String()
+	}
+	return Refund{
+		ID:             uuid.New().String(),
+		PaymentID:      p.ID,
+		Amount:         amount,
+		Currency:       p.Currency,
+		Status:         RefundStatusPending,
+		Reason:         reason,
+		IdempotencyKey: idempotencyKey,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
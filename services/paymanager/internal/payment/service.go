@@ -3,13 +3,18 @@ package payment
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
+	"TestTaskJustPay/pkg/metrics"
+	"TestTaskJustPay/pkg/money"
 	"TestTaskJustPay/pkg/postgres"
 	"TestTaskJustPay/services/paymanager/internal/eventstore"
 	"TestTaskJustPay/services/paymanager/internal/gateway"
+	"TestTaskJustPay/services/paymanager/internal/merchant"
+	"TestTaskJustPay/services/paymanager/internal/risk"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -19,31 +24,42 @@ type PaymentService struct {
 	transactor    postgres.Transactor
 	txPaymentRepo func(tx postgres.Executor) PaymentRepo
 	txEventStore  func(tx postgres.Executor) eventstore.Store
+	txRefundRepo  func(tx postgres.Executor) RefundRepo
 	paymentRepo   PaymentRepo
-	provider      Provider
-	merchantID    string
+	refundRepo    RefundRepo
+	events        eventstore.Reader
+	providers     ProviderRouter
+	risk          RiskAssessor
 }
 
 func NewPaymentService(
 	transactor postgres.Transactor,
 	txPaymentRepo func(tx postgres.Executor) PaymentRepo,
 	txEventStore func(tx postgres.Executor) eventstore.Store,
+	txRefundRepo func(tx postgres.Executor) RefundRepo,
 	paymentRepo PaymentRepo,
-	provider Provider,
-	merchantID string,
+	refundRepo RefundRepo,
+	events eventstore.Reader,
+	providers ProviderRouter,
+	risk RiskAssessor,
 ) *PaymentService {
 	return &PaymentService{
 		transactor:    transactor,
 		txPaymentRepo: txPaymentRepo,
 		txEventStore:  txEventStore,
+		txRefundRepo:  txRefundRepo,
 		paymentRepo:   paymentRepo,
-		provider:      provider,
-		merchantID:    merchantID,
+		refundRepo:    refundRepo,
+		events:        events,
+		providers:     providers,
+		risk:          risk,
 	}
 }
 
-func (s *PaymentService) CreatePayment(ctx context.Context, req CreatePaymentRequest) (*Payment, error) {
-	var captureDelay time.Duration
+// CreatePayment assesses the payment with the risk engine and authorizes it for m unless
+// it is blocked. Without an explicit capture_delay the merchant's default capture delay applies.
+func (s *PaymentService) CreatePayment(ctx context.Context, m merchant.Merchant, req CreatePaymentRequest) (*Payment, error) {
+	captureDelay := m.DefaultCaptureDelay
 	if req.CaptureDelay != "" {
 		var err error
 		captureDelay, err = time.ParseDuration(req.CaptureDelay)
@@ -52,33 +68,66 @@ func (s *PaymentService) CreatePayment(ctx context.Context, req CreatePaymentReq
 		}
 	}
 
-	orderID := uuid.New()
-	authResult, err := s.provider.AuthorizePayment(ctx, gateway.AuthRequest{
-		MerchantID: s.merchantID,
-		OrderID:    orderID.String(),
-		Amount:     req.Amount,
-		Currency:   req.Currency,
-		CardToken:  req.CardToken,
+	amount := money.Money{Amount: req.Amount, Currency: req.Currency}
+
+	// The provider fingerprint is only known once a token has been authorized; a token
+	// seen before resolves to its card, a new one stands for itself.
+	knownFingerprint, err := s.paymentRepo.FindCardFingerprint(ctx, m.ID, req.CardToken)
+	if err != nil {
+		return nil, err
+	}
+	if knownFingerprint == "" {
+		knownFingerprint = CardFingerprint(req.CardToken)
+	}
+
+	decision, err := s.risk.Evaluate(ctx, risk.Input{
+		MerchantID:      m.ID,
+		CardFingerprint: knownFingerprint,
+		IP:              req.CustomerIP,
+		Country:         req.Country,
+		Money:           amount,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("authorize payment: %w", err)
+		return nil, fmt.Errorf("assess payment risk: %w", err)
 	}
 
 	var p Payment
-	if authResult.Status == gateway.AuthStatusAuthorized {
-		p = NewAuthorized(req.Amount, req.Currency, req.CardToken, authResult.TransactionID, s.merchantID)
+	if decision.Outcome == risk.OutcomeBlock {
+		p = NewDeclined(req.Amount, req.Currency.String(), req.CardToken, knownFingerprint, "", m.ID, DeclineReasonRiskBlocked)
 	} else {
-		p = NewDeclined(req.Amount, req.Currency, req.CardToken, authResult.TransactionID, s.merchantID, authResult.DeclineReason)
+		p, err = s.authorizeNew(ctx, m, req, amount)
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	if p.Status == StatusAuthorized && captureDelay > 0 {
+	p.RiskDecisionID = decision.ID
+
+	// Captures are picked up by the CaptureScheduler once capture_at is due;
+	// an immediate capture is simply one that is due right away. A payment awaiting
+	// its challenge gets one too; the scheduler only claims it once it is authorized.
+	// Payments held for risk review get none and are captured by hand after review, as
+	// are manual-capture payments.
+	switch {
+	case decision.Outcome == risk.OutcomeReview && p.Status != StatusDeclined:
+		p.HoldReason = HoldReasonRisk
+	case req.CaptureMethod == CaptureMethodManual:
+	case p.Status == StatusAuthorized || p.Status == StatusRequiresAction:
 		captureAt := time.Now().UTC().Add(captureDelay)
 		p.CaptureAt = &captureAt
 	}
 
 	err = s.transactor.InTransaction(ctx, pgx.RepeatableRead, func(tx postgres.Executor) error {
 		txRepo := s.txPaymentRepo(tx)
-		return txRepo.CreatePayment(ctx, p)
+		if err := txRepo.CreatePayment(ctx, p); err != nil {
+			return err
+		}
+		if err := txRepo.RekeyCardFingerprint(ctx, m.ID, req.CardToken, p.CardFingerprint); err != nil {
+			return err
+		}
+		return writePaymentEvent(ctx, s.txEventStore(tx), p.ID, PaymentEventCreated, string(PaymentEventCreated), PaymentEventData{
+			NewStatus: p.Status,
+			Amount:    p.Amount,
+		})
 	})
 	if err != nil {
 		return nil, fmt.Errorf("save payment: %w", err)
@@ -87,50 +136,194 @@ func (s *PaymentService) CreatePayment(ctx context.Context, req CreatePaymentReq
 	slog.InfoContext(ctx, "payment created",
 		"payment_id", p.ID,
 		"status", p.Status,
+		"provider", p.Provider,
 		"provider_tx_id", p.ProviderTxID,
+		"risk_outcome", decision.Outcome,
 		"capture_delay", captureDelay,
 	)
 
-	if p.Status == StatusAuthorized {
-		if captureDelay == 0 {
-			p.Status = StatusCapturePending
-			p.UpdatedAt = time.Now().UTC()
-			if err := s.paymentRepo.UpdatePaymentStatus(ctx, p.ID, StatusCapturePending, ""); err != nil {
-				slog.ErrorContext(ctx, "failed to mark capture_pending", "payment_id", p.ID, "error", err)
-			} else {
-				go s.captureInBackground(p.ID, p.ProviderTxID, p.Amount)
-			}
-		} else {
-			go s.captureWithDelay(p.ID, p.ProviderTxID, p.Amount, captureDelay)
-		}
+	return &p, nil
+}
+
+// authorizeNew authorizes a new payment at the routed providers and builds it from the result.
+func (s *PaymentService) authorizeNew(ctx context.Context, m merchant.Merchant, req CreatePaymentRequest, amount money.Money) (Payment, error) {
+	providerName, authResult, err := s.authorize(ctx, gateway.AuthRequest{
+		MerchantID: m.ID,
+		OrderID:    authOrderID(m.ID, req.IdempotencyKey),
+		Money:      amount,
+		CardToken:  req.CardToken,
+	})
+	if err != nil {
+		return Payment{}, err
 	}
 
-	return &p, nil
+	fingerprint := authResult.CardFingerprint
+	if fingerprint == "" {
+		fingerprint = CardFingerprint(req.CardToken)
+	}
+
+	var p Payment
+	switch authResult.Status {
+	case gateway.AuthStatusAuthorized:
+		p = NewAuthorized(req.Amount, req.Currency.String(), req.CardToken, fingerprint, authResult.TransactionID, m.ID)
+	case gateway.AuthStatusRequiresAction:
+		p = NewRequiresAction(req.Amount, req.Currency.String(), req.CardToken, fingerprint, authResult.TransactionID, m.ID, authResult.ChallengeURL)
+	default:
+		p = NewDeclined(req.Amount, req.Currency.String(), req.CardToken, fingerprint, authResult.TransactionID, m.ID, authResult.DeclineReason)
+	}
+	p.Provider = providerName
+	return p, nil
 }
 
-func (s *PaymentService) GetPaymentByID(ctx context.Context, id string) (*Payment, error) {
-	return s.paymentRepo.GetPaymentByID(ctx, id)
+// authOrderNamespace derives provider order ids from client idempotency keys.
+var authOrderNamespace = uuid.MustParse("6f1c2a8e-3d4b-5e6f-9a0b-1c2d3e4f5a6b")
+
+// authOrderID is the provider order id of a new payment. The provider keys its
+// authorization idempotency on it, so it is derived from the client's idempotency key
+// when there is one and random otherwise.
+func authOrderID(merchantID, idempotencyKey string) string {
+	if idempotencyKey == "" {
+		return uuid.New().String()
+	}
+	return uuid.NewSHA1(authOrderNamespace, []byte(merchantID+"/"+idempotencyKey)).String()
 }
 
-func (s *PaymentService) VoidPayment(ctx context.Context, paymentID string) (*Payment, error) {
-	p, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+// authorize sends the authorization to the routed providers in turn, moving on only
+// when a provider is unavailable, i.e. certainly did not process the request.
+func (s *PaymentService) authorize(ctx context.Context, req gateway.AuthRequest) (string, gateway.AuthResult, error) {
+	candidates := s.providers.Route(req.MerchantID, req.Money)
+	for i, name := range candidates {
+		provider, err := s.providers.Provider(name)
+		if err != nil {
+			return "", gateway.AuthResult{}, err
+		}
+
+		result, err := provider.AuthorizePayment(ctx, req)
+		if err == nil {
+			return name, result, nil
+		}
+		if !errors.Is(err, gateway.ErrProviderUnavailable) || i == len(candidates)-1 {
+			return "", gateway.AuthResult{}, fmt.Errorf("authorize payment at %s: %w", name, err)
+		}
+
+		next := candidates[i+1]
+		metrics.ProviderFailoversTotal.WithLabelValues(name, next).Inc()
+		slog.WarnContext(ctx, "provider unavailable, failing over",
+			"provider", name, "next_provider", next, "error", err)
+	}
+	return "", gateway.AuthResult{}, fmt.Errorf("authorize payment: no provider routed for merchant %s", req.MerchantID)
+}
+
+func (s *PaymentService) GetPaymentByID(ctx context.Context, merchantID, id string) (*Payment, error) {
+	return s.paymentRepo.GetPaymentByID(ctx, merchantID, id)
+}
+
+// GetPaymentWithHistory returns the payment with its status transitions, oldest first.
+func (s *PaymentService) GetPaymentWithHistory(ctx context.Context, merchantID, id string) (*Payment, error) {
+	p, err := s.paymentRepo.GetPaymentByID(ctx, merchantID, id)
 	if err != nil {
 		return nil, err
 	}
 
-	if !p.Status.CanTransitionTo(StatusVoided) {
-		return nil, ErrInvalidStatus
+	p.StatusHistory, err = s.paymentRepo.GetStatusHistory(ctx, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get status history: %w", err)
 	}
+	return p, nil
+}
 
-	_, err = s.provider.VoidPayment(ctx, gateway.VoidRequest{
-		TransactionID: p.ProviderTxID,
+func (s *PaymentService) ListPayments(ctx context.Context, merchantID string, query PaymentQuery) (PaymentPage, error) {
+	query.MerchantID = merchantID
+	return s.paymentRepo.ListPayments(ctx, query)
+}
+
+// GetPaymentEvents returns the payment's event timeline: API actions and provider webhooks.
+func (s *PaymentService) GetPaymentEvents(ctx context.Context, merchantID, paymentID string, query PaymentEventQuery) (PaymentEventPage, error) {
+	if _, err := s.paymentRepo.GetPaymentByID(ctx, merchantID, paymentID); err != nil {
+		return PaymentEventPage{}, err
+	}
+
+	eventTypes := make([]string, 0, len(query.Kinds))
+	for _, k := range query.Kinds {
+		eventTypes = append(eventTypes, string(k))
+	}
+
+	page, err := s.events.ListEvents(ctx, eventstore.Query{
+		AggregateType: eventstore.AggregatePayment,
+		AggregateID:   paymentID,
+		EventTypes:    eventTypes,
+		Limit:         query.Limit,
+		Cursor:        query.Cursor,
+		SortAsc:       query.SortAsc,
 	})
+	if errors.Is(err, eventstore.ErrInvalidCursor) {
+		return PaymentEventPage{}, ErrInvalidCursor
+	}
 	if err != nil {
-		return nil, fmt.Errorf("void at provider: %w", err)
+		return PaymentEventPage{}, fmt.Errorf("list payment events: %w", err)
+	}
+
+	items := make([]PaymentEvent, 0, len(page.Items))
+	for _, e := range page.Items {
+		pe := PaymentEvent{
+			EventID:   e.ID,
+			PaymentID: e.AggregateID,
+			Kind:      PaymentEventKind(e.EventType),
+			CreatedAt: e.CreatedAt,
+		}
+		if err := json.Unmarshal(e.Payload, &pe.Data); err != nil {
+			return PaymentEventPage{}, fmt.Errorf("decode payment event %s: %w", e.ID, err)
+		}
+		items = append(items, pe)
 	}
 
-	if err := s.paymentRepo.UpdatePaymentStatus(ctx, p.ID, StatusVoided, ""); err != nil {
-		return nil, fmt.Errorf("update payment status: %w", err)
+	return PaymentEventPage{
+		Items:      items,
+		NextCursor: page.NextCursor,
+		HasMore:    page.HasMore,
+	}, nil
+}
+
+// VoidPayment voids an authorized payment. The transition is applied (and its validity
+// checked by the repository) before the provider call, in the same transaction, so a
+// payment that cannot be voided never reaches the provider and a provider error rolls it back.
+func (s *PaymentService) VoidPayment(ctx context.Context, merchantID, paymentID string) (*Payment, error) {
+	var p *Payment
+	err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(tx postgres.Executor) error {
+		txRepo := s.txPaymentRepo(tx)
+
+		var err error
+		p, err = txRepo.GetPaymentByIDForUpdate(ctx, merchantID, paymentID)
+		if err != nil {
+			return err
+		}
+
+		if err := txRepo.TransitionStatus(ctx, p.ID, Transition{
+			From:   p.Status,
+			To:     StatusVoided,
+			Source: SourceAPI,
+		}); err != nil {
+			return err
+		}
+
+		provider, err := s.providers.Provider(p.Provider)
+		if err != nil {
+			return err
+		}
+		if _, err := provider.VoidPayment(ctx, gateway.VoidRequest{
+			MerchantID:    p.MerchantID,
+			TransactionID: p.ProviderTxID,
+		}); err != nil {
+			return fmt.Errorf("void at provider: %w", err)
+		}
+
+		return writePaymentEvent(ctx, s.txEventStore(tx), p.ID, PaymentEventVoided, string(PaymentEventVoided), PaymentEventData{
+			OldStatus: p.Status,
+			NewStatus: StatusVoided,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	p.Status = StatusVoided
@@ -144,72 +337,277 @@ func (s *PaymentService) VoidPayment(ctx context.Context, paymentID string) (*Pa
 	return p, nil
 }
 
-func (s *PaymentService) RefundPayment(ctx context.Context, paymentID string, req RefundRequest) (*Payment, error) {
-	p, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
+// CapturePayment captures part or all of the remaining authorized amount.
+// The payment row is locked while the remaining balance is checked and the payment
+// moves to capture_pending, so only one capture is in flight at a time. The outcome
+// arrives through the capture webhook.
+func (s *PaymentService) CapturePayment(ctx context.Context, merchantID, paymentID string, req CaptureRequest) (*Payment, error) {
+	var p *Payment
+	var prevStatus Status
+
+	err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(tx postgres.Executor) error {
+		txRepo := s.txPaymentRepo(tx)
+
+		var err error
+		p, err = txRepo.GetPaymentByIDForUpdate(ctx, merchantID, paymentID)
+		if err != nil {
+			return err
+		}
+
+		if req.Amount > p.Amount-p.CapturedAmount {
+			return ErrCaptureExceedsAmount
+		}
+
+		prevStatus = p.Status
+		return txRepo.TransitionStatus(ctx, p.ID, Transition{
+			From:   p.Status,
+			To:     StatusCapturePending,
+			Source: SourceAPI,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyKey := req.IdempotencyKey
+	if idempotencyKey == "" {
+		idempotencyKey = uuid.New().String()
+	}
+
+	provider, err := s.providers.Provider(p.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = provider.CapturePayment(ctx, gateway.CaptureRequest{
+		MerchantID:     p.MerchantID,
+		OrderID:        p.ProviderTxID,
+		Money:          money.Money{Amount: req.Amount, Currency: money.Currency(p.Currency)},
+		Final:          req.Final,
+		IdempotencyKey: fmt.Sprintf("capture_%s_%s", p.ID, idempotencyKey),
+	})
+	if err != nil {
+		revert := Transition{
+			From:     StatusCapturePending,
+			To:       prevStatus,
+			Rollback: true,
+			Source:   SourceAPI,
+			Reason:   "capture rejected by provider",
+		}
+		if revertErr := s.paymentRepo.TransitionStatus(ctx, p.ID, revert); revertErr != nil {
+			slog.ErrorContext(ctx, "failed to revert payment status", "payment_id", p.ID, "error", revertErr)
+		}
+		return nil, fmt.Errorf("capture at provider: %w", err)
+	}
+
+	err = s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(tx postgres.Executor) error {
+		return writePaymentEvent(ctx, s.txEventStore(tx), p.ID, PaymentEventCaptureRequested,
+			fmt.Sprintf("%s_%s", PaymentEventCaptureRequested, idempotencyKey), PaymentEventData{
+				OldStatus:      prevStatus,
+				NewStatus:      StatusCapturePending,
+				Amount:         req.Amount,
+				CapturedAmount: p.CapturedAmount,
+			})
+	})
+	if err != nil {
+		// The capture is already with the provider; a missing timeline entry must not fail the request.
+		slog.ErrorContext(ctx, "failed to record capture request event", "payment_id", p.ID, "error", err)
+	}
+
+	p.Status = StatusCapturePending
+	p.UpdatedAt = time.Now().UTC()
+
+	slog.InfoContext(ctx, "capture initiated",
+		"payment_id", p.ID,
+		"amount", req.Amount,
+		"final", req.Final,
+		"provider_tx_id", p.ProviderTxID,
+	)
+
+	return p, nil
+}
+
+// RefundPayment records a pending refund and submits it to the provider.
+// The payment row is locked while the refundable balance is checked, so pending
+// refunds are always counted and concurrent requests cannot over-refund.
+func (s *PaymentService) RefundPayment(ctx context.Context, merchantID, paymentID string, req RefundRequest) (*Refund, error) {
+	var p *Payment
+	var refund Refund
+	var replayed bool
+
+	err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(tx postgres.Executor) error {
+		txRepo := s.txPaymentRepo(tx)
+		txRefunds := s.txRefundRepo(tx)
+
+		var err error
+		p, err = txRepo.GetPaymentByIDForUpdate(ctx, merchantID, paymentID)
+		if err != nil {
+			return err
+		}
+
+		if req.IdempotencyKey != "" {
+			existing, err := txRefunds.GetRefundByIdempotencyKey(ctx, p.ID, req.IdempotencyKey)
+			if err == nil {
+				if existing.Amount != req.Amount {
+					return ErrIdempotencyMismatch
+				}
+				refund = *existing
+				replayed = true
+				return nil
+			}
+			if !errors.Is(err, ErrRefundNotFound) {
+				return fmt.Errorf("lookup refund by idempotency key: %w", err)
+			}
+		}
+
+		if p.Status == StatusPartiallyCaptured {
+			return ErrAuthorizationOpen
+		}
+		if !p.Refundable() {
+			return ErrInvalidStatus
+		}
+
+		pending, err := txRefunds.SumPendingRefunds(ctx, p.ID)
+		if err != nil {
+			return fmt.Errorf("sum pending refunds: %w", err)
+		}
+
+		remaining := p.CapturedAmount - p.RefundedAmount - pending
+		if req.Amount > remaining {
+			return ErrRefundExceedsAmount
+		}
+
+		refund = NewRefundPending(p, req.Amount, req.Reason, req.IdempotencyKey)
+		if err := txRefunds.CreateRefund(ctx, refund); err != nil {
+			return err
+		}
+		return writePaymentEvent(ctx, s.txEventStore(tx), p.ID, PaymentEventRefundRequested,
+			fmt.Sprintf("%s_%s", PaymentEventRefundRequested, refund.ID), PaymentEventData{
+				OldStatus:      p.Status,
+				NewStatus:      p.Status,
+				Amount:         refund.Amount,
+				RefundedAmount: p.RefundedAmount,
+				RefundID:       refund.ID,
+			})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	if p.Status != StatusCaptured && p.Status != StatusPartiallyRefunded {
-		return nil, ErrInvalidStatus
+	if replayed {
+		return &refund, nil
 	}
 
-	remaining := p.Amount - p.RefundedAmount
-	if req.Amount > remaining {
-		return nil, ErrRefundExceedsAmount
+	provider, err := s.providers.Provider(p.Provider)
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = s.provider.RefundPayment(ctx, gateway.RefundRequest{
+	result, err := provider.RefundPayment(ctx, gateway.RefundRequest{
+		MerchantID:     p.MerchantID,
 		TransactionID:  p.ProviderTxID,
-		Amount:         req.Amount,
-		IdempotencyKey: fmt.Sprintf("refund_%s_%d", paymentID, req.Amount),
+		Money:          money.Money{Amount: refund.Amount, Currency: money.Currency(refund.Currency)},
+		IdempotencyKey: fmt.Sprintf("refund_%s", refund.ID),
 	})
+	if err != nil && !refundNotProcessed(err) {
+		// The provider may have acted on the refund: it stays pending until its
+		// webhook or the reconciler reports the outcome.
+		slog.WarnContext(ctx, "refund outcome unknown, left pending",
+			"payment_id", p.ID, "refund_id", refund.ID, "error", err)
+		return &refund, nil
+	}
 	if err != nil {
+		if markErr := s.refundRepo.UpdateRefundStatus(ctx, refund.ID, RefundStatusFailed, "", err.Error()); markErr != nil {
+			slog.ErrorContext(ctx, "failed to mark refund failed", "refund_id", refund.ID, "error", markErr)
+		}
 		return nil, fmt.Errorf("refund at provider: %w", err)
 	}
 
+	if err := s.refundRepo.SetProviderRefundID(ctx, refund.ID, result.RefundID); err != nil {
+		slog.ErrorContext(ctx, "failed to store provider refund id", "refund_id", refund.ID, "error", err)
+	}
+	refund.ProviderRefundID = result.RefundID
+
 	slog.InfoContext(ctx, "refund initiated",
 		"payment_id", p.ID,
-		"amount", req.Amount,
+		"refund_id", refund.ID,
+		"provider_refund_id", refund.ProviderRefundID,
+		"amount", refund.Amount,
 		"provider_tx_id", p.ProviderTxID,
 	)
 
-	return p, nil
+	return &refund, nil
+}
+
+// refundNotProcessed reports whether err guarantees the provider did not and will not
+// refund: it was unreachable or rejected the request.
+func refundNotProcessed(err error) bool {
+	return errors.Is(err, gateway.ErrProviderUnavailable) || errors.Is(err, gateway.ErrRequestRejected)
+}
+
+// GetRefundByID returns ErrRefundNotFound for refunds of other merchants' payments.
+func (s *PaymentService) GetRefundByID(ctx context.Context, merchantID, id string) (*Refund, error) {
+	rf, err := s.refundRepo.GetRefundByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.paymentRepo.GetPaymentByID(ctx, merchantID, rf.PaymentID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrRefundNotFound
+		}
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (s *PaymentService) GetRefundsByPaymentID(ctx context.Context, merchantID, paymentID string) ([]Refund, error) {
+	if _, err := s.paymentRepo.GetPaymentByID(ctx, merchantID, paymentID); err != nil {
+		return nil, err
+	}
+	return s.refundRepo.ListRefundsByPaymentID(ctx, paymentID)
 }
 
 func (s *PaymentService) ProcessCaptureWebhook(ctx context.Context, webhook CaptureWebhook) error {
 	return s.transactor.InTransaction(ctx, pgx.RepeatableRead, func(tx postgres.Executor) error {
 		txRepo := s.txPaymentRepo(tx)
 		txEvents := s.txEventStore(tx)
+		txRefunds := s.txRefundRepo(tx)
 
-		p, err := txRepo.GetPaymentByProviderTxID(ctx, webhook.TransactionID)
+		p, err := txRepo.GetPaymentByProviderTxID(ctx, webhook.Provider, webhook.TransactionID)
 		if err != nil {
 			return fmt.Errorf("lookup payment by provider_tx_id %s: %w", webhook.TransactionID, err)
 		}
 
 		if webhook.Event == "transaction.refunded" {
+			if err := s.settleRefund(ctx, txRefunds, p, webhook, RefundStatusSucceeded); err != nil {
+				return err
+			}
+
+			oldStatus := p.Status
 			p.RefundedAmount += webhook.Amount
 			var newStatus Status
-			if p.RefundedAmount >= p.Amount {
+			if p.RefundedAmount >= p.CapturedAmount {
 				newStatus = StatusRefunded
 			} else {
 				newStatus = StatusPartiallyRefunded
 			}
-			if err := txRepo.UpdatePaymentRefund(ctx, p.ID, newStatus, p.RefundedAmount); err != nil {
+			t := Transition{From: oldStatus, To: newStatus, Source: SourceWebhook, Reason: webhook.Event}
+			if err := txRepo.UpdatePaymentRefund(ctx, p.ID, t, p.RefundedAmount); err != nil {
 				return fmt.Errorf("update payment refund: %w", err)
 			}
 
 			idempotencyKey := fmt.Sprintf("webhook_%s_%s_%s", webhook.TransactionID, webhook.Event, webhook.RefundID)
-			_, err = txEvents.CreateEvent(ctx, eventstore.NewEvent{
-				AggregateType:  "payment",
-				AggregateID:    p.ID,
-				EventType:      webhook.Event,
-				IdempotencyKey: idempotencyKey,
-				Payload:        json.RawMessage(`{}`),
-				CreatedAt:      time.Now(),
+			err = writePaymentEvent(ctx, txEvents, p.ID, PaymentEventKind(webhook.Event), idempotencyKey, PaymentEventData{
+				OldStatus:        oldStatus,
+				NewStatus:        newStatus,
+				Amount:           webhook.Amount,
+				CapturedAmount:   p.CapturedAmount,
+				RefundedAmount:   p.RefundedAmount,
+				ProviderRefundID: webhook.RefundID,
+				WebhookTimestamp: webhook.Timestamp,
 			})
 			if err != nil {
-				return fmt.Errorf("write event: %w", err)
+				return err
 			}
 
 			slog.InfoContext(ctx, "payment refund webhook processed",
@@ -222,41 +620,78 @@ func (s *PaymentService) ProcessCaptureWebhook(ctx context.Context, webhook Capt
 
 		var newStatus Status
 		switch webhook.Status {
+		case "authorized":
+			newStatus = StatusAuthorized
+		case "declined":
+			newStatus = StatusDeclined
 		case "captured":
 			newStatus = StatusCaptured
+		case "partially_captured":
+			newStatus = StatusPartiallyCaptured
 		case "capture_failed":
 			newStatus = StatusCaptureFailed
 		case "voided":
 			newStatus = StatusVoided
+		case "expired":
+			newStatus = StatusExpired
 		case "refund_failed":
 			slog.WarnContext(ctx, "refund failed at provider",
 				"payment_id", p.ID, "transaction_id", webhook.TransactionID)
-			return nil
+			if err := s.settleRefund(ctx, txRefunds, p, webhook, RefundStatusFailed); err != nil {
+				return err
+			}
+			idempotencyKey := fmt.Sprintf("webhook_%s_%s_%s", webhook.TransactionID, webhook.Event, webhook.RefundID)
+			return writePaymentEvent(ctx, txEvents, p.ID, PaymentEventKind(webhook.Event), idempotencyKey, PaymentEventData{
+				OldStatus:        p.Status,
+				NewStatus:        p.Status,
+				Amount:           webhook.Amount,
+				RefundedAmount:   p.RefundedAmount,
+				ProviderRefundID: webhook.RefundID,
+				WebhookTimestamp: webhook.Timestamp,
+			})
 		default:
 			return fmt.Errorf("unknown webhook status: %s", webhook.Status)
 		}
 
-		if !p.Status.CanTransitionTo(newStatus) {
+		t := Transition{From: p.Status, To: newStatus, Source: SourceWebhook, Reason: webhook.Event}
+		capturedAmount := p.CapturedAmount
+		switch newStatus {
+		case StatusCaptured, StatusPartiallyCaptured:
+			capturedAmount = webhook.CapturedAmount
+			if capturedAmount == 0 && newStatus == StatusCaptured {
+				// Providers without partial capture support report no running total.
+				capturedAmount = p.Amount
+			}
+			err = txRepo.UpdatePaymentCapture(ctx, p.ID, t, capturedAmount)
+		case StatusDeclined:
+			err = txRepo.DeclinePayment(ctx, p.ID, t, webhook.DeclineReason)
+		default:
+			err = txRepo.TransitionStatus(ctx, p.ID, t)
+		}
+		if errors.Is(err, ErrInvalidStatus) {
 			slog.WarnContext(ctx, "ignoring webhook for invalid transition",
 				"payment_id", p.ID, "current", p.Status, "target", newStatus)
 			return nil
 		}
-
-		if err := txRepo.UpdatePaymentStatus(ctx, p.ID, newStatus, ""); err != nil {
+		if err != nil {
 			return fmt.Errorf("update payment status: %w", err)
 		}
 
 		idempotencyKey := fmt.Sprintf("webhook_%s_%s", webhook.TransactionID, webhook.Event)
-		_, err = txEvents.CreateEvent(ctx, eventstore.NewEvent{
-			AggregateType:  "payment",
-			AggregateID:    p.ID,
-			EventType:      webhook.Event,
-			IdempotencyKey: idempotencyKey,
-			Payload:        json.RawMessage(`{}`),
-			CreatedAt:      time.Now(),
+		if webhook.CaptureID != "" {
+			idempotencyKey = fmt.Sprintf("webhook_%s_%s_%s", webhook.TransactionID, webhook.Event, webhook.CaptureID)
+		}
+		err = writePaymentEvent(ctx, txEvents, p.ID, PaymentEventKind(webhook.Event), idempotencyKey, PaymentEventData{
+			OldStatus:        p.Status,
+			NewStatus:        newStatus,
+			Amount:           webhook.Amount,
+			CapturedAmount:   capturedAmount,
+			CaptureID:        webhook.CaptureID,
+			DeclineReason:    webhook.DeclineReason,
+			WebhookTimestamp: webhook.Timestamp,
 		})
 		if err != nil {
-			return fmt.Errorf("write event: %w", err)
+			return err
 		}
 
 		slog.InfoContext(ctx, "payment webhook processed",
@@ -269,44 +704,48 @@ func (s *PaymentService) ProcessCaptureWebhook(ctx context.Context, webhook Capt
 	})
 }
 
-func (s *PaymentService) captureInBackground(paymentID, providerTxID string, amount int64) {
-	ctx := context.Background()
-
-	_, err := s.provider.CapturePayment(ctx, gateway.CaptureRequest{
-		OrderID:        providerTxID,
-		Amount:         float64(amount),
-		IdempotencyKey: fmt.Sprintf("capture_%s", paymentID),
-	})
-	if err != nil {
-		slog.Error("background capture failed",
-			"payment_id", paymentID,
-			"provider_tx_id", providerTxID,
-			"error", err,
-		)
+// settleRefund moves the refund a webhook refers to out of pending. Refunds that
+// were not initiated through this service (no local record) are only logged.
+func (s *PaymentService) settleRefund(ctx context.Context, txRefunds RefundRepo, p *Payment, webhook CaptureWebhook, status RefundStatus) error {
+	rf, err := txRefunds.GetRefundForWebhook(ctx, p.ID, webhook.RefundID, webhook.Amount)
+	if errors.Is(err, ErrRefundNotFound) {
+		slog.WarnContext(ctx, "no refund record for refund webhook",
+			"payment_id", p.ID, "provider_refund_id", webhook.RefundID)
+		return nil
 	}
-}
-
-func (s *PaymentService) captureWithDelay(paymentID, providerTxID string, amount int64, delay time.Duration) {
-	time.Sleep(delay)
-
-	ctx := context.Background()
-
-	p, err := s.paymentRepo.GetPaymentByID(ctx, paymentID)
 	if err != nil {
-		slog.Error("delayed capture: failed to read payment", "payment_id", paymentID, "error", err)
-		return
+		return fmt.Errorf("lookup refund: %w", err)
 	}
 
-	if p.Status != StatusAuthorized {
-		slog.Info("delayed capture: payment no longer authorized, skipping",
-			"payment_id", paymentID, "status", p.Status)
-		return
+	var failureReason string
+	if status == RefundStatusFailed {
+		failureReason = "rejected by provider"
+	}
+	if err := txRefunds.UpdateRefundStatus(ctx, rf.ID, status, webhook.RefundID, failureReason); err != nil {
+		return fmt.Errorf("update refund status: %w", err)
 	}
+	return nil
+}
 
-	if err := s.paymentRepo.UpdatePaymentStatus(ctx, p.ID, StatusCapturePending, ""); err != nil {
-		slog.Error("delayed capture: failed to mark capture_pending", "payment_id", paymentID, "error", err)
-		return
+// writePaymentEvent appends a payment event to the unified events table. Duplicate
+// idempotency keys surface as eventstore.ErrEventAlreadyStored, which webhook
+// consumers rely on to drop redelivered webhooks.
+func writePaymentEvent(ctx context.Context, store eventstore.Store, paymentID string, kind PaymentEventKind, idempotencyKey string, data PaymentEventData) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal event payload: %w", err)
 	}
 
-	s.captureInBackground(paymentID, providerTxID, amount)
+	_, err = store.CreateEvent(ctx, eventstore.NewEvent{
+		AggregateType:  eventstore.AggregatePayment,
+		AggregateID:    paymentID,
+		EventType:      string(kind),
+		IdempotencyKey: idempotencyKey,
+		Payload:        payload,
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("write event: %w", err)
+	}
+	return nil
 }
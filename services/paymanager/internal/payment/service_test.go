@@ -0,0 +1,438 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"TestTaskJustPay/pkg/money"
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/paymanager/internal/eventstore"
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+	"TestTaskJustPay/services/paymanager/internal/merchant"
+	"TestTaskJustPay/services/paymanager/internal/risk"
+	"TestTaskJustPay/services/paymanager/internal/routing"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAuthProvider struct {
+	Provider
+	calls int
+	err   error
+}
+
+func (f *fakeAuthProvider) AuthorizePayment(_ context.Context, req gateway.AuthRequest) (gateway.AuthResult, error) {
+	f.calls++
+	if f.err != nil {
+		return gateway.AuthResult{}, f.err
+	}
+	return gateway.AuthResult{TransactionID: "tx-" + req.OrderID, Status: gateway.AuthStatusAuthorized}, nil
+}
+
+func newRoutedService(t *testing.T, primary, secondary Provider) *PaymentService {
+	t.Helper()
+	rules, err := routing.ParseRules(`[{"providers": [{"name": "primary"}], "failover": ["secondary"]}]`)
+	require.NoError(t, err)
+	router, err := routing.New("primary", map[string]Provider{"primary": primary, "secondary": secondary}, rules)
+	require.NoError(t, err)
+	return &PaymentService{providers: router}
+}
+
+var authReq = gateway.AuthRequest{MerchantID: "merchant_1", OrderID: "order-1", Money: money.Money{Amount: 1000, Currency: "USD"}}
+
+func TestAuthorize_FailsOverWhenProviderUnavailable(t *testing.T) {
+	primary := &fakeAuthProvider{err: fmt.Errorf("%w: connection refused", gateway.ErrProviderUnavailable)}
+	secondary := &fakeAuthProvider{}
+
+	name, result, err := newRoutedService(t, primary, secondary).authorize(context.Background(), authReq)
+
+	require.NoError(t, err)
+	assert.Equal(t, "secondary", name)
+	assert.Equal(t, gateway.AuthStatusAuthorized, result.Status)
+	assert.Equal(t, 1, primary.calls)
+	assert.Equal(t, 1, secondary.calls)
+}
+
+func TestAuthorize_DoesNotFailOverOtherErrors(t *testing.T) {
+	primary := &fakeAuthProvider{err: errors.New("auth provider 400 Bad Request")}
+	secondary := &fakeAuthProvider{}
+
+	_, _, err := newRoutedService(t, primary, secondary).authorize(context.Background(), authReq)
+
+	require.Error(t, err)
+	assert.Equal(t, 0, secondary.calls)
+}
+
+func TestAuthorize_ReturnsLastErrorWhenAllUnavailable(t *testing.T) {
+	unavailable := fmt.Errorf("%w: circuit breaker open", gateway.ErrProviderUnavailable)
+	primary := &fakeAuthProvider{err: unavailable}
+	secondary := &fakeAuthProvider{err: unavailable}
+
+	_, _, err := newRoutedService(t, primary, secondary).authorize(context.Background(), authReq)
+
+	assert.ErrorIs(t, err, gateway.ErrProviderUnavailable)
+	assert.Contains(t, err.Error(), "secondary")
+}
+
+// --- in-memory service ---
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) InTransaction(_ context.Context, _ pgx.TxIsoLevel, fn func(tx postgres.Executor) error) error {
+	return fn(nil)
+}
+
+type memPaymentRepo struct {
+	PaymentRepo
+	payments map[string]*Payment
+}
+
+func (r *memPaymentRepo) CreatePayment(_ context.Context, p Payment) error {
+	r.payments[p.ID] = &p
+	return nil
+}
+
+func (r *memPaymentRepo) GetPaymentByID(_ context.Context, merchantID, id string) (*Payment, error) {
+	p, ok := r.payments[id]
+	if !ok || p.MerchantID != merchantID {
+		return nil, ErrNotFound
+	}
+	cp := *p
+	return &cp, nil
+}
+
+func (r *memPaymentRepo) GetPaymentByIDForUpdate(ctx context.Context, merchantID, id string) (*Payment, error) {
+	return r.GetPaymentByID(ctx, merchantID, id)
+}
+
+func (r *memPaymentRepo) TransitionStatus(_ context.Context, id string, t Transition) error {
+	p, ok := r.payments[id]
+	if !ok {
+		return ErrNotFound
+	}
+	for _, from := range t.AllowedFrom() {
+		if p.Status == from {
+			p.Status = t.To
+			return nil
+		}
+	}
+	return ErrInvalidStatus
+}
+
+func (r *memPaymentRepo) FindCardFingerprint(context.Context, string, string) (string, error) {
+	return "", nil
+}
+
+func (r *memPaymentRepo) RekeyCardFingerprint(context.Context, string, string, string) error {
+	return nil
+}
+
+type memRefundRepo struct {
+	RefundRepo
+	refunds map[string]*Refund
+}
+
+func (r *memRefundRepo) CreateRefund(_ context.Context, rf Refund) error {
+	r.refunds[rf.ID] = &rf
+	return nil
+}
+
+func (r *memRefundRepo) GetRefundByIdempotencyKey(_ context.Context, paymentID, key string) (*Refund, error) {
+	for _, rf := range r.refunds {
+		if rf.PaymentID == paymentID && rf.IdempotencyKey == key {
+			cp := *rf
+			return &cp, nil
+		}
+	}
+	return nil, ErrRefundNotFound
+}
+
+func (r *memRefundRepo) SumPendingRefunds(_ context.Context, paymentID string) (int64, error) {
+	var sum int64
+	for _, rf := range r.refunds {
+		if rf.PaymentID == paymentID && rf.Status == RefundStatusPending {
+			sum += rf.Amount
+		}
+	}
+	return sum, nil
+}
+
+func (r *memRefundRepo) SetProviderRefundID(_ context.Context, id, providerRefundID string) error {
+	r.refunds[id].ProviderRefundID = providerRefundID
+	return nil
+}
+
+func (r *memRefundRepo) UpdateRefundStatus(_ context.Context, id string, status RefundStatus, providerRefundID, failureReason string) error {
+	rf := r.refunds[id]
+	rf.Status = status
+	if providerRefundID != "" {
+		rf.ProviderRefundID = providerRefundID
+	}
+	rf.FailureReason = failureReason
+	return nil
+}
+
+type memEventStore struct{}
+
+func (memEventStore) CreateEvent(_ context.Context, e eventstore.NewEvent) (*eventstore.Event, error) {
+	return &eventstore.Event{NewEvent: e}, nil
+}
+
+type allowAllRisk struct{}
+
+func (allowAllRisk) Evaluate(_ context.Context, in risk.Input) (risk.Decision, error) {
+	return risk.Decision{ID: "rd-1", MerchantID: in.MerchantID, Outcome: risk.OutcomeAllow}, nil
+}
+
+// fakeProvider authorizes every payment and refunds with refundErr.
+type fakeProvider struct {
+	Provider
+	refunds   []gateway.RefundRequest
+	refundErr error
+	captures  []gateway.CaptureRequest
+}
+
+func (f *fakeProvider) AuthorizePayment(_ context.Context, req gateway.AuthRequest) (gateway.AuthResult, error) {
+	return gateway.AuthResult{TransactionID: "tx-" + req.OrderID, Status: gateway.AuthStatusAuthorized}, nil
+}
+
+func (f *fakeProvider) VoidPayment(_ context.Context, req gateway.VoidRequest) (gateway.VoidResult, error) {
+	return gateway.VoidResult{TransactionID: req.TransactionID, Status: "voided"}, nil
+}
+
+func (f *fakeProvider) CapturePayment(_ context.Context, req gateway.CaptureRequest) (gateway.CaptureResult, error) {
+	f.captures = append(f.captures, req)
+	return gateway.CaptureResult{ProviderTxID: req.OrderID, CaptureID: fmt.Sprintf("cap_%d", len(f.captures))}, nil
+}
+
+func (f *fakeProvider) RefundPayment(_ context.Context, req gateway.RefundRequest) (gateway.RefundResult, error) {
+	f.refunds = append(f.refunds, req)
+	if f.refundErr != nil {
+		return gateway.RefundResult{}, f.refundErr
+	}
+	return gateway.RefundResult{RefundID: fmt.Sprintf("re_%d", len(f.refunds)), TransactionID: req.TransactionID, Amount: req.Amount}, nil
+}
+
+func newMemService(t *testing.T, provider Provider, payments ...*Payment) (*PaymentService, *memPaymentRepo, *memRefundRepo) {
+	t.Helper()
+	repo := &memPaymentRepo{payments: map[string]*Payment{}}
+	for _, p := range payments {
+		repo.payments[p.ID] = p
+	}
+	refunds := &memRefundRepo{refunds: map[string]*Refund{}}
+	router, err := routing.New("silvergate", map[string]Provider{"silvergate": provider}, nil)
+	require.NoError(t, err)
+
+	svc := NewPaymentService(
+		fakeTransactor{},
+		func(postgres.Executor) PaymentRepo { return repo },
+		func(postgres.Executor) eventstore.Store { return memEventStore{} },
+		func(postgres.Executor) RefundRepo { return refunds },
+		repo, refunds, nil, router, allowAllRisk{},
+	)
+	return svc, repo, refunds
+}
+
+func partiallyCapturedPayment() *Payment {
+	return &Payment{
+		ID: "pay-1", MerchantID: "merchant_1", Amount: 1000, CapturedAmount: 400,
+		Currency: "USD", Status: StatusPartiallyCaptured, Provider: "silvergate", ProviderTxID: "tx-1",
+	}
+}
+
+// --- partial capture ---
+
+func TestStatus_PartiallyCapturedIsRefundedOnlyOnceClosed(t *testing.T) {
+	for _, target := range []Status{StatusCapturePending, StatusVoided, StatusExpired} {
+		assert.True(t, StatusPartiallyCaptured.CanTransitionTo(target), "partially_captured -> %s", target)
+	}
+	for _, target := range []Status{StatusPartiallyRefunded, StatusRefunded} {
+		assert.False(t, StatusPartiallyCaptured.CanTransitionTo(target), "partially_captured -> %s", target)
+	}
+	for _, closed := range []Status{StatusVoided, StatusExpired} {
+		assert.True(t, closed.CanTransitionTo(StatusPartiallyRefunded), "%s -> partially_refunded", closed)
+		assert.False(t, closed.CanTransitionTo(StatusCapturePending), "%s -> capture_pending", closed)
+	}
+}
+
+func TestStatus_CaptureFailedCanBeClosed(t *testing.T) {
+	for _, target := range []Status{StatusVoided, StatusExpired} {
+		assert.True(t, StatusCaptureFailed.CanTransitionTo(target), "capture_failed -> %s", target)
+	}
+	assert.False(t, StatusCaptureFailed.CanTransitionTo(StatusPartiallyRefunded), "capture_failed -> partially_refunded")
+}
+
+func TestPayment_Refundable(t *testing.T) {
+	for name, tc := range map[string]struct {
+		status   Status
+		captured int64
+		want     bool
+	}{
+		"captured":                      {StatusCaptured, 1000, true},
+		"partially captured":            {StatusPartiallyCaptured, 400, false},
+		"voided after partial capture":  {StatusVoided, 400, true},
+		"expired after partial capture": {StatusExpired, 400, true},
+		"voided without capture":        {StatusVoided, 0, false},
+		"capture in flight":             {StatusCapturePending, 400, false},
+		"authorized":                    {StatusAuthorized, 0, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := Payment{Status: tc.status, CapturedAmount: tc.captured}
+			assert.Equal(t, tc.want, p.Refundable())
+		})
+	}
+}
+
+func TestRefundPayment_PartiallyCapturedIsRejectedAndStaysCapturable(t *testing.T) {
+	provider := &fakeProvider{}
+	svc, repo, _ := newMemService(t, provider, partiallyCapturedPayment())
+
+	_, err := svc.RefundPayment(context.Background(), "merchant_1", "pay-1", RefundRequest{Amount: 400})
+	assert.ErrorIs(t, err, ErrAuthorizationOpen)
+	assert.Empty(t, provider.refunds)
+	assert.Equal(t, StatusPartiallyCaptured, repo.payments["pay-1"].Status)
+
+	p, err := svc.CapturePayment(context.Background(), "merchant_1", "pay-1", CaptureRequest{Amount: 600, Final: true})
+	require.NoError(t, err)
+	assert.Equal(t, StatusCapturePending, p.Status)
+	require.Len(t, provider.captures, 1)
+	assert.Equal(t, int64(600), provider.captures[0].Money.Amount)
+}
+
+func TestRefundPayment_AfterVoidingPartialCapture(t *testing.T) {
+	provider := &fakeProvider{}
+	svc, _, _ := newMemService(t, provider, partiallyCapturedPayment())
+
+	_, err := svc.VoidPayment(context.Background(), "merchant_1", "pay-1")
+	require.NoError(t, err)
+
+	_, err = svc.RefundPayment(context.Background(), "merchant_1", "pay-1", RefundRequest{Amount: 500})
+	assert.ErrorIs(t, err, ErrRefundExceedsAmount, "only the captured part is refundable")
+
+	refund, err := svc.RefundPayment(context.Background(), "merchant_1", "pay-1", RefundRequest{Amount: 400})
+	require.NoError(t, err)
+	assert.Equal(t, int64(400), refund.Amount)
+	require.Len(t, provider.refunds, 1)
+}
+
+func TestVoidPayment_PartiallyCaptured(t *testing.T) {
+	svc, repo, _ := newMemService(t, &fakeProvider{}, partiallyCapturedPayment())
+
+	p, err := svc.VoidPayment(context.Background(), "merchant_1", "pay-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, StatusVoided, p.Status)
+	assert.Equal(t, StatusVoided, repo.payments["pay-1"].Status)
+	assert.True(t, repo.payments["pay-1"].Refundable(), "the captured part stays refundable")
+}
+
+func TestCreatePayment_ManualCaptureIsNotScheduled(t *testing.T) {
+	svc, _, _ := newMemService(t, &fakeProvider{})
+	m := merchant.Merchant{ID: "merchant_1"}
+
+	manual, err := svc.CreatePayment(context.Background(), m, CreatePaymentRequest{
+		Amount: 1000, Currency: "USD", CardToken: "tok_1", CaptureMethod: CaptureMethodManual,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusAuthorized, manual.Status)
+	assert.Nil(t, manual.CaptureAt)
+
+	automatic, err := svc.CreatePayment(context.Background(), m, CreatePaymentRequest{
+		Amount: 1000, Currency: "USD", CardToken: "tok_1",
+	})
+	require.NoError(t, err)
+	assert.NotNil(t, automatic.CaptureAt)
+}
+
+func TestCreatePayment_RetryUnderSameKeyReusesProviderOrder(t *testing.T) {
+	svc, _, _ := newMemService(t, &fakeProvider{})
+	m := merchant.Merchant{ID: "merchant_1"}
+	req := CreatePaymentRequest{Amount: 1000, Currency: "USD", CardToken: "tok_1", IdempotencyKey: "create-1"}
+
+	first, err := svc.CreatePayment(context.Background(), m, req)
+	require.NoError(t, err)
+	retry, err := svc.CreatePayment(context.Background(), m, req)
+	require.NoError(t, err)
+	req.IdempotencyKey = "create-2"
+	other, err := svc.CreatePayment(context.Background(), m, req)
+	require.NoError(t, err)
+
+	assert.Equal(t, first.ProviderTxID, retry.ProviderTxID, "the provider replays the first authorization")
+	assert.NotEqual(t, first.ProviderTxID, other.ProviderTxID)
+}
+
+// --- refunds ---
+
+func capturedPayment() *Payment {
+	p := partiallyCapturedPayment()
+	p.Status = StatusCaptured
+	p.CapturedAmount = p.Amount
+	return p
+}
+
+func TestRefundPayment_RejectsOverRefund(t *testing.T) {
+	provider := &fakeProvider{}
+	svc, _, _ := newMemService(t, provider, capturedPayment())
+	ctx := context.Background()
+
+	_, err := svc.RefundPayment(ctx, "merchant_1", "pay-1", RefundRequest{Amount: 600})
+	require.NoError(t, err)
+
+	_, err = svc.RefundPayment(ctx, "merchant_1", "pay-1", RefundRequest{Amount: 500})
+	assert.ErrorIs(t, err, ErrRefundExceedsAmount, "the pending 600 counts against the balance")
+	assert.Len(t, provider.refunds, 1)
+}
+
+func TestRefundPayment_IdempotentReplay(t *testing.T) {
+	provider := &fakeProvider{}
+	svc, _, refunds := newMemService(t, provider, capturedPayment())
+	ctx := context.Background()
+
+	first, err := svc.RefundPayment(ctx, "merchant_1", "pay-1", RefundRequest{Amount: 300, IdempotencyKey: "rf-key"})
+	require.NoError(t, err)
+
+	replay, err := svc.RefundPayment(ctx, "merchant_1", "pay-1", RefundRequest{Amount: 300, IdempotencyKey: "rf-key"})
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, replay.ID)
+
+	_, err = svc.RefundPayment(ctx, "merchant_1", "pay-1", RefundRequest{Amount: 400, IdempotencyKey: "rf-key"})
+	assert.ErrorIs(t, err, ErrIdempotencyMismatch)
+
+	assert.Len(t, provider.refunds, 1, "replays are not resubmitted")
+	assert.Len(t, refunds.refunds, 1)
+}
+
+func TestRefundPayment_ProviderFailure(t *testing.T) {
+	for name, tc := range map[string]struct {
+		err     error
+		wantErr bool
+		want    RefundStatus
+	}{
+		"rejected":     {fmt.Errorf("%w: 422", gateway.ErrRequestRejected), true, RefundStatusFailed},
+		"unavailable":  {fmt.Errorf("%w: refused", gateway.ErrProviderUnavailable), true, RefundStatusFailed},
+		"timeout":      {context.DeadlineExceeded, false, RefundStatusPending},
+		"server error": {errors.New("refund provider 502 Bad Gateway"), false, RefundStatusPending},
+	} {
+		t.Run(name, func(t *testing.T) {
+			svc, _, refunds := newMemService(t, &fakeProvider{refundErr: tc.err}, capturedPayment())
+
+			rf, err := svc.RefundPayment(context.Background(), "merchant_1", "pay-1", RefundRequest{Amount: 300})
+
+			if tc.wantErr {
+				assert.ErrorIs(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, RefundStatusPending, rf.Status)
+			}
+			require.Len(t, refunds.refunds, 1)
+			for _, stored := range refunds.refunds {
+				assert.Equal(t, tc.want, stored.Status)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package payment
+
+import "time"
+
+// TransitionSource records what triggered a status transition.
+type TransitionSource string
+
+const (
+	SourceAPI       TransitionSource = "api"
+	SourceWebhook   TransitionSource = "webhook"
+	SourceScheduler TransitionSource = "scheduler"
+)
+
+// Transition is a requested status change. The repository applies it only when the
+// payment's current status allows it and records it in payment_status_history.
+type Transition struct {
+	To Status
+	// From, when set, additionally requires the payment to currently be in this status.
+	From Status
+	// Rollback undoes a transition whose side effect failed (e.g. capture_pending back to
+	// authorized after the provider rejected the capture) and is checked against the reverse rule.
+	Rollback bool
+	Source   TransitionSource
+	Reason   string
+}
+
+// AllowedFrom lists the current statuses from which t may be applied.
+func (t Transition) AllowedFrom() []Status {
+	candidates := allStatuses
+	if t.From != "" {
+		candidates = []Status{t.From}
+	}
+
+	var allowed []Status
+	for _, s := range candidates {
+		if (t.Rollback && t.To.CanTransitionTo(s)) || (!t.Rollback && s.CanTransitionTo(t.To)) {
+			allowed = append(allowed, s)
+		}
+	}
+	return allowed
+}
+
+// StatusChange is one row of a payment's status history. FromStatus is empty for the
+// status the payment was created with.
+type StatusChange struct {
+	ID         string           `json:"id"`
+	FromStatus Status           `json:"from_status,omitempty"`
+	ToStatus   Status           `json:"to_status"`
+	Source     TransitionSource `json:"source"`
+	Reason     string           `json:"reason,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+}
@@ -0,0 +1,100 @@
+package reconciliation
+
+import (
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/payment"
+
+	"github.com/google/uuid"
+)
+
+type ItemKind string
+
+const (
+	// ItemKindFixed is a mismatch that was corrected by replaying the provider state.
+	ItemKindFixed ItemKind = "fixed"
+	// ItemKindManualReview is a mismatch that cannot be corrected automatically.
+	ItemKindManualReview ItemKind = "manual_review"
+)
+
+// Compared fields reported on items.
+const (
+	FieldStatus         = "status"
+	FieldCapturedAmount = "captured_amount"
+	FieldRefundedAmount = "refunded_amount"
+	FieldTransaction    = "transaction"
+)
+
+// Candidate is a payment whose state is compared against the provider.
+type Candidate struct {
+	PaymentID      string
+	MerchantID     string
+	Provider       string
+	ProviderTxID   string
+	Status         payment.Status
+	Amount         int64
+	CapturedAmount int64
+	RefundedAmount int64
+	// PendingRefunds is the sum of local refunds still awaiting a provider outcome.
+	PendingRefunds int64
+}
+
+// Run is the report of a single reconciliation pass.
+type Run struct {
+	ID           string     `json:"id"`
+	StartedAt    time.Time  `json:"started_at"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	Checked      int        `json:"checked"`
+	Fixed        int        `json:"fixed"`
+	ManualReview int        `json:"manual_review"`
+	Error        string     `json:"error,omitempty"`
+	Items        []Item     `json:"items,omitempty"`
+}
+
+// Item is a single mismatch found during a run.
+type Item struct {
+	ID            string    `json:"id"`
+	RunID         string    `json:"run_id"`
+	PaymentID     string    `json:"payment_id"`
+	ProviderTxID  string    `json:"provider_tx_id"`
+	Kind          ItemKind  `json:"kind"`
+	Field         string    `json:"field"`
+	LocalValue    string    `json:"local_value"`
+	ProviderValue string    `json:"provider_value"`
+	Detail        string    `json:"detail,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func NewRun(startedAt time.Time) *Run {
+	return &Run{
+		ID:        uuid.New().String(),
+		StartedAt: startedAt,
+	}
+}
+
+// Record appends a mismatch to the run and updates its counters.
+func (r *Run) Record(c Candidate, kind ItemKind, field, localValue, providerValue, detail string) {
+	r.Items = append(r.Items, Item{
+		ID:            uuid.New().String(),
+		RunID:         r.ID,
+		PaymentID:     c.PaymentID,
+		ProviderTxID:  c.ProviderTxID,
+		Kind:          kind,
+		Field:         field,
+		LocalValue:    localValue,
+		ProviderValue: providerValue,
+		Detail:        detail,
+		CreatedAt:     time.Now().UTC(),
+	})
+	switch kind {
+	case ItemKindFixed:
+		r.Fixed++
+	case ItemKindManualReview:
+		r.ManualReview++
+	}
+}
+
+// RunQuery pages through runs, newest first.
+type RunQuery struct {
+	Limit int `form:"limit"`
+}
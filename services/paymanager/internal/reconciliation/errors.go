@@ -0,0 +1,5 @@
+package reconciliation
+
+import "errors"
+
+var ErrRunNotFound = errors.New("reconciliation run not found")
@@ -0,0 +1,39 @@
+package reconciliation
+
+import (
+	"context"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+	"TestTaskJustPay/services/paymanager/internal/payment"
+)
+
+// Repo is the persistence contract for reconciliation candidates and reports.
+type Repo interface {
+	// ListCandidates returns open payments not updated for at least staleAfter,
+	// least recently reconciled first.
+	ListCandidates(ctx context.Context, staleAfter time.Duration, limit int) ([]Candidate, error)
+	MarkReconciled(ctx context.Context, paymentIDs []string, at time.Time) error
+	// SaveRun stores the run report together with its items.
+	SaveRun(ctx context.Context, run *Run) error
+	ListRuns(ctx context.Context, limit int) ([]Run, error)
+	// GetRun returns the run with its items. Returns ErrRunNotFound if absent.
+	GetRun(ctx context.Context, id string) (*Run, error)
+}
+
+// Provider exposes the provider-side transaction state. The provider only reports the
+// transactions of the merchant the query is made for.
+type Provider interface {
+	QueryTransactions(ctx context.Context, merchantID string, transactionIDs []string) ([]gateway.TransactionState, error)
+}
+
+// ProviderRouter resolves the provider recorded on a payment.
+type ProviderRouter interface {
+	Provider(name string) (Provider, error)
+}
+
+// WebhookProcessor applies provider state changes. Corrections go through the same
+// path as provider webhooks so the payment, its refunds and its events stay consistent.
+type WebhookProcessor interface {
+	ProcessCaptureWebhook(ctx context.Context, webhook payment.CaptureWebhook) error
+}
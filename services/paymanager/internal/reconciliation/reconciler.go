@@ -0,0 +1,320 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"TestTaskJustPay/pkg/metrics"
+	"TestTaskJustPay/services/paymanager/internal/eventstore"
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+	"TestTaskJustPay/services/paymanager/internal/payment"
+)
+
+// maxQueryBatch is the most transactions the provider returns per state query.
+const maxQueryBatch = 100
+
+// Config holds configuration for the reconciler.
+type Config struct {
+	Interval  time.Duration
+	BatchSize int
+	// StaleAfter keeps the reconciler away from payments that changed recently and
+	// may still have a webhook in flight.
+	StaleAfter time.Duration
+}
+
+// Reconciler periodically compares open payments with the provider's view of their
+// transactions. Mismatches the provider state can explain are replayed as webhooks;
+// everything else is reported for manual review.
+type Reconciler struct {
+	repo      Repo
+	providers ProviderRouter
+	webhooks  WebhookProcessor
+	cfg       Config
+}
+
+// NewReconciler creates a new reconciler.
+func NewReconciler(repo Repo, providers ProviderRouter, webhooks WebhookProcessor, cfg Config) *Reconciler {
+	return &Reconciler{
+		repo:      repo,
+		providers: providers,
+		webhooks:  webhooks,
+		cfg:       cfg,
+	}
+}
+
+// Start runs reconciliation on every tick. Blocks until ctx is cancelled.
+func (r *Reconciler) Start(ctx context.Context) error {
+	slog.Info("Reconciler started",
+		"interval", r.cfg.Interval,
+		"batch_size", r.cfg.BatchSize,
+		"stale_after", r.cfg.StaleAfter)
+
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Reconciler stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			if _, err := r.RunOnce(ctx); err != nil {
+				slog.Error("Reconciliation run failed", slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// RunOnce reconciles one batch of candidates and stores the run report.
+// Returns nil without storing anything when there is nothing to reconcile.
+func (r *Reconciler) RunOnce(ctx context.Context) (*Run, error) {
+	candidates, err := r.repo.ListCandidates(ctx, r.cfg.StaleAfter, r.cfg.BatchSize)
+	if err != nil {
+		metrics.ReconciliationRunsTotal.WithLabelValues("failed").Inc()
+		return nil, fmt.Errorf("list candidates: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	run := NewRun(time.Now().UTC())
+	checked := make([]string, 0, len(candidates))
+	for start := 0; start < len(candidates); start += maxQueryBatch {
+		end := min(start+maxQueryBatch, len(candidates))
+		batch := candidates[start:end]
+
+		states, err := r.queryStates(ctx, batch)
+		if err != nil {
+			run.Error = err.Error()
+			break
+		}
+		for _, c := range batch {
+			st, ok := states[c.ProviderTxID]
+			if !ok {
+				run.Record(c, ItemKindManualReview, FieldTransaction, c.ProviderTxID, "",
+					"transaction not found at provider")
+			} else {
+				r.reconcile(ctx, run, c, st)
+			}
+			run.Checked++
+			checked = append(checked, c.PaymentID)
+		}
+	}
+
+	if len(checked) > 0 {
+		if err := r.repo.MarkReconciled(ctx, checked, run.StartedAt); err != nil {
+			slog.Error("Failed to mark payments reconciled", slog.Any("error", err))
+		}
+	}
+
+	finishedAt := time.Now().UTC()
+	run.FinishedAt = &finishedAt
+	if err := r.repo.SaveRun(ctx, run); err != nil {
+		metrics.ReconciliationRunsTotal.WithLabelValues("failed").Inc()
+		return run, fmt.Errorf("save run: %w", err)
+	}
+
+	status := "success"
+	if run.Error != "" {
+		status = "failed"
+	}
+	metrics.ReconciliationRunsTotal.WithLabelValues(status).Inc()
+	metrics.ReconciliationItemsTotal.WithLabelValues(string(ItemKindFixed)).Add(float64(run.Fixed))
+	metrics.ReconciliationItemsTotal.WithLabelValues(string(ItemKindManualReview)).Add(float64(run.ManualReview))
+
+	slog.Info("Reconciliation run finished",
+		"run_id", run.ID,
+		"checked", run.Checked,
+		"fixed", run.Fixed,
+		"manual_review", run.ManualReview)
+
+	return run, nil
+}
+
+// queryStates asks each provider in the batch about its own transactions, one query
+// per merchant since a provider only shows a merchant its own transactions.
+func (r *Reconciler) queryStates(ctx context.Context, batch []Candidate) (map[string]gateway.TransactionState, error) {
+	type scope struct{ provider, merchantID string }
+	idsByScope := make(map[scope][]string)
+	for _, c := range batch {
+		s := scope{provider: c.Provider, merchantID: c.MerchantID}
+		idsByScope[s] = append(idsByScope[s], c.ProviderTxID)
+	}
+
+	byID := make(map[string]gateway.TransactionState, len(batch))
+	for s, ids := range idsByScope {
+		provider, err := r.providers.Provider(s.provider)
+		if err != nil {
+			return nil, err
+		}
+		states, err := provider.QueryTransactions(ctx, s.merchantID, ids)
+		if err != nil {
+			return nil, fmt.Errorf("query %s transactions of %s: %w", s.provider, s.merchantID, err)
+		}
+		for _, st := range states {
+			byID[st.TransactionID] = st
+		}
+	}
+	return byID, nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, run *Run, c Candidate, st gateway.TransactionState) {
+	local := capturePhase(c.Status)
+	remote := capturePhase(payment.Status(st.Status))
+	closedWithCapture := (c.Status == payment.StatusVoided || c.Status == payment.StatusExpired) && c.CapturedAmount > 0
+
+	switch {
+	case closedWithCapture && remote == payment.StatusCaptured:
+		// Refunding the captured part moved the provider to a refund status.
+	case local != remote:
+		if !r.reconcileStatus(ctx, run, &c, st, remote) {
+			return
+		}
+	case (remote == payment.StatusCaptured || remote == payment.StatusPartiallyCaptured) &&
+		c.CapturedAmount != st.CapturedAmount:
+		run.Record(c, ItemKindManualReview, FieldCapturedAmount,
+			strconv.FormatInt(c.CapturedAmount, 10), strconv.FormatInt(st.CapturedAmount, 10),
+			"captured amount differs with matching status")
+		return
+	}
+
+	if capturePhase(c.Status) == payment.StatusCaptured || closedWithCapture {
+		r.reconcileRefunds(ctx, run, c, st)
+	}
+}
+
+// reconcileStatus replays the provider's capture-phase status as a webhook.
+// Reports whether the candidate now matches the provider.
+func (r *Reconciler) reconcileStatus(ctx context.Context, run *Run, c *Candidate, st gateway.TransactionState, target payment.Status) bool {
+	switch target {
+	case payment.StatusCapturePending:
+		// Capture accepted by the provider but not settled yet; its webhook is still to come.
+		return false
+	case payment.StatusCaptured, payment.StatusPartiallyCaptured, payment.StatusCaptureFailed,
+		payment.StatusVoided, payment.StatusExpired:
+	default:
+		run.Record(*c, ItemKindManualReview, FieldStatus, string(c.Status), st.Status,
+			"provider status cannot be applied automatically")
+		return false
+	}
+
+	if !c.Status.CanTransitionTo(target) {
+		run.Record(*c, ItemKindManualReview, FieldStatus, string(c.Status), st.Status,
+			fmt.Sprintf("transition %s -> %s not allowed", c.Status, target))
+		return false
+	}
+
+	err := r.webhooks.ProcessCaptureWebhook(ctx, payment.CaptureWebhook{
+		Provider:       c.Provider,
+		Event:          "transaction." + string(target),
+		TransactionID:  st.TransactionID,
+		Status:         string(target),
+		Amount:         st.Amount,
+		CapturedAmount: st.CapturedAmount,
+		FinalCapture:   target == payment.StatusCaptured,
+		Currency:       st.Currency,
+		Timestamp:      st.UpdatedAt.UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		detail := err.Error()
+		if errors.Is(err, eventstore.ErrEventAlreadyStored) {
+			detail = "provider state already recorded as an earlier webhook"
+		}
+		run.Record(*c, ItemKindManualReview, FieldStatus, string(c.Status), st.Status, detail)
+		return false
+	}
+
+	run.Record(*c, ItemKindFixed, FieldStatus, string(c.Status), st.Status, "")
+	c.Status = target
+	if target == payment.StatusCaptured || target == payment.StatusPartiallyCaptured {
+		c.CapturedAmount = st.CapturedAmount
+	}
+	return true
+}
+
+// reconcileRefunds replays settled provider refunds. Refunds that were already
+// applied are dropped by the webhook path's event deduplication.
+func (r *Reconciler) reconcileRefunds(ctx context.Context, run *Run, c Candidate, st gateway.TransactionState) {
+	var settled int64
+	for _, rf := range st.Refunds {
+		if rf.Status == "refunded" {
+			settled += rf.Amount
+		}
+	}
+	if settled == c.RefundedAmount && c.PendingRefunds == 0 {
+		return
+	}
+
+	refunded := c.RefundedAmount
+	for _, rf := range st.Refunds {
+		if rf.Status != "refunded" && rf.Status != "refund_failed" {
+			continue
+		}
+
+		err := r.webhooks.ProcessCaptureWebhook(ctx, payment.CaptureWebhook{
+			Provider:       c.Provider,
+			Event:          "transaction." + rf.Status,
+			TransactionID:  st.TransactionID,
+			RefundID:       rf.RefundID,
+			Status:         rf.Status,
+			Amount:         rf.Amount,
+			CapturedAmount: st.CapturedAmount,
+			Currency:       st.Currency,
+			Timestamp:      rf.UpdatedAt.UTC().Format(time.RFC3339),
+		})
+		switch {
+		case errors.Is(err, eventstore.ErrEventAlreadyStored):
+			continue
+		case err != nil:
+			run.Record(c, ItemKindManualReview, FieldRefundedAmount,
+				strconv.FormatInt(refunded, 10), strconv.FormatInt(settled, 10),
+				fmt.Sprintf("replay refund %s: %s", rf.RefundID, err))
+			return
+		}
+
+		before := refunded
+		if rf.Status == "refunded" {
+			refunded += rf.Amount
+		}
+		run.Record(c, ItemKindFixed, FieldRefundedAmount,
+			strconv.FormatInt(before, 10), strconv.FormatInt(refunded, 10),
+			fmt.Sprintf("applied %s for refund %s", rf.Status, rf.RefundID))
+	}
+
+	if refunded != settled {
+		run.Record(c, ItemKindManualReview, FieldRefundedAmount,
+			strconv.FormatInt(refunded, 10), strconv.FormatInt(settled, 10),
+			"refunded amount differs after replaying provider refunds")
+	}
+}
+
+// capturePhase folds refund statuses into captured so capture state and refund
+// state can be compared separately.
+func capturePhase(s payment.Status) payment.Status {
+	switch s {
+	case payment.StatusPartiallyRefunded, payment.StatusRefunded:
+		return payment.StatusCaptured
+	}
+	return s
+}
+
+// ListRuns returns the most recent run reports without their items.
+func (r *Reconciler) ListRuns(ctx context.Context, query RunQuery) ([]Run, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+	return r.repo.ListRuns(ctx, limit)
+}
+
+// GetRun returns a run report with all of its items.
+func (r *Reconciler) GetRun(ctx context.Context, id string) (*Run, error) {
+	return r.repo.GetRun(ctx, id)
+}
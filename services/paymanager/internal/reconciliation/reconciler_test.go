@@ -0,0 +1,209 @@
+package reconciliation
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/paymanager/internal/eventstore"
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+	"TestTaskJustPay/services/paymanager/internal/payment"
+	"TestTaskJustPay/services/paymanager/internal/routing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- fakes ---
+
+type fakeRepo struct {
+	Repo
+	candidates []Candidate
+	reconciled []string
+	saved      []*Run
+}
+
+func (f *fakeRepo) ListCandidates(_ context.Context, _ time.Duration, _ int) ([]Candidate, error) {
+	return f.candidates, nil
+}
+
+func (f *fakeRepo) MarkReconciled(_ context.Context, ids []string, _ time.Time) error {
+	f.reconciled = append(f.reconciled, ids...)
+	return nil
+}
+
+func (f *fakeRepo) SaveRun(_ context.Context, run *Run) error {
+	f.saved = append(f.saved, run)
+	return nil
+}
+
+type fakeProvider struct {
+	states  []gateway.TransactionState
+	queried map[string][]string
+}
+
+func (f *fakeProvider) QueryTransactions(_ context.Context, merchantID string, ids []string) ([]gateway.TransactionState, error) {
+	if f.queried == nil {
+		f.queried = make(map[string][]string)
+	}
+	f.queried[merchantID] = append(f.queried[merchantID], ids...)
+	return f.states, nil
+}
+
+// fakeWebhooks records replayed webhooks; webhooks whose dedup key is in stored
+// fail like an already-processed webhook would.
+type fakeWebhooks struct {
+	applied []payment.CaptureWebhook
+	stored  map[string]bool
+}
+
+func (f *fakeWebhooks) ProcessCaptureWebhook(_ context.Context, w payment.CaptureWebhook) error {
+	if f.stored[w.Event+"_"+w.RefundID] {
+		return fmt.Errorf("write event: %w", eventstore.ErrEventAlreadyStored)
+	}
+	f.applied = append(f.applied, w)
+	return nil
+}
+
+func runOnce(t *testing.T, repo *fakeRepo, provider *fakeProvider, webhooks *fakeWebhooks) *Run {
+	t.Helper()
+	providers, err := routing.New("silvergate", map[string]Provider{"silvergate": provider}, nil)
+	require.NoError(t, err)
+	r := NewReconciler(repo, providers, webhooks, Config{Interval: time.Minute, BatchSize: 10, StaleAfter: time.Minute})
+	run, err := r.RunOnce(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, run)
+	require.Len(t, repo.saved, 1)
+	return run
+}
+
+// --- tests ---
+
+func TestReconciler_QueriesEachMerchantSeparately(t *testing.T) {
+	repo := &fakeRepo{candidates: []Candidate{
+		{PaymentID: "pay-1", MerchantID: "merchant_1", ProviderTxID: "tx-1", Status: payment.StatusAuthorized, Amount: 1000},
+		{PaymentID: "pay-2", MerchantID: "merchant_2", ProviderTxID: "tx-2", Status: payment.StatusAuthorized, Amount: 500},
+		{PaymentID: "pay-3", MerchantID: "merchant_1", ProviderTxID: "tx-3", Status: payment.StatusAuthorized, Amount: 700},
+	}}
+	provider := &fakeProvider{states: []gateway.TransactionState{
+		{TransactionID: "tx-1", Status: "authorized", Amount: 1000},
+		{TransactionID: "tx-2", Status: "authorized", Amount: 500},
+		{TransactionID: "tx-3", Status: "authorized", Amount: 700},
+	}}
+
+	run := runOnce(t, repo, provider, &fakeWebhooks{})
+
+	assert.Equal(t, map[string][]string{
+		"merchant_1": {"tx-1", "tx-3"},
+		"merchant_2": {"tx-2"},
+	}, provider.queried)
+	assert.Equal(t, 3, run.Checked)
+	assert.Empty(t, run.Items)
+}
+
+func TestReconciler_ReplaysLostCaptureWebhook(t *testing.T) {
+	repo := &fakeRepo{candidates: []Candidate{
+		{PaymentID: "pay-1", ProviderTxID: "tx-1", Status: payment.StatusCapturePending, Amount: 1000},
+	}}
+	provider := &fakeProvider{states: []gateway.TransactionState{
+		{TransactionID: "tx-1", Status: "captured", Amount: 1000, CapturedAmount: 1000},
+	}}
+	webhooks := &fakeWebhooks{}
+
+	run := runOnce(t, repo, provider, webhooks)
+
+	require.Len(t, webhooks.applied, 1)
+	assert.Equal(t, "transaction.captured", webhooks.applied[0].Event)
+	assert.Equal(t, "captured", webhooks.applied[0].Status)
+	assert.Equal(t, int64(1000), webhooks.applied[0].CapturedAmount)
+	assert.Equal(t, 1, run.Checked)
+	assert.Equal(t, 1, run.Fixed)
+	assert.Equal(t, 0, run.ManualReview)
+	assert.Equal(t, FieldStatus, run.Items[0].Field)
+	assert.Equal(t, []string{"pay-1"}, repo.reconciled)
+}
+
+func TestReconciler_InvalidTransitionNeedsManualReview(t *testing.T) {
+	repo := &fakeRepo{candidates: []Candidate{
+		{PaymentID: "pay-1", ProviderTxID: "tx-1", Status: payment.StatusCaptured, Amount: 1000, CapturedAmount: 1000},
+		{PaymentID: "pay-2", ProviderTxID: "tx-2", Status: payment.StatusAuthorized, Amount: 500},
+	}}
+	provider := &fakeProvider{states: []gateway.TransactionState{
+		{TransactionID: "tx-1", Status: "voided", Amount: 1000},
+	}}
+	webhooks := &fakeWebhooks{}
+
+	run := runOnce(t, repo, provider, webhooks)
+
+	assert.Empty(t, webhooks.applied)
+	assert.Equal(t, 2, run.Checked)
+	assert.Equal(t, 0, run.Fixed)
+	require.Equal(t, 2, run.ManualReview)
+	assert.Equal(t, FieldStatus, run.Items[0].Field)
+	assert.Equal(t, "captured", run.Items[0].LocalValue)
+	assert.Equal(t, "voided", run.Items[0].ProviderValue)
+	assert.Equal(t, FieldTransaction, run.Items[1].Field)
+	assert.Equal(t, "pay-2", run.Items[1].PaymentID)
+}
+
+func TestReconciler_ReplaysOnlyMissingRefunds(t *testing.T) {
+	repo := &fakeRepo{candidates: []Candidate{
+		{PaymentID: "pay-1", ProviderTxID: "tx-1", Status: payment.StatusPartiallyRefunded,
+			Amount: 1000, CapturedAmount: 1000, RefundedAmount: 300, PendingRefunds: 200},
+	}}
+	provider := &fakeProvider{states: []gateway.TransactionState{
+		{TransactionID: "tx-1", Status: "partially_refunded", Amount: 1000, CapturedAmount: 1000, RefundedAmount: 500,
+			Refunds: []gateway.RefundState{
+				{RefundID: "rf-1", Amount: 300, Status: "refunded"},
+				{RefundID: "rf-2", Amount: 200, Status: "refunded"},
+			}},
+	}}
+	webhooks := &fakeWebhooks{stored: map[string]bool{"transaction.refunded_rf-1": true}}
+
+	run := runOnce(t, repo, provider, webhooks)
+
+	require.Len(t, webhooks.applied, 1)
+	assert.Equal(t, "rf-2", webhooks.applied[0].RefundID)
+	assert.Equal(t, int64(200), webhooks.applied[0].Amount)
+	assert.Equal(t, 1, run.Fixed)
+	assert.Equal(t, 0, run.ManualReview)
+	assert.Equal(t, "300", run.Items[0].LocalValue)
+	assert.Equal(t, "500", run.Items[0].ProviderValue)
+}
+
+func TestReconciler_CaptureThenRefund(t *testing.T) {
+	repo := &fakeRepo{candidates: []Candidate{
+		{PaymentID: "pay-1", ProviderTxID: "tx-1", Status: payment.StatusCapturePending, Amount: 1000},
+	}}
+	provider := &fakeProvider{states: []gateway.TransactionState{
+		{TransactionID: "tx-1", Status: "refunded", Amount: 1000, CapturedAmount: 1000, RefundedAmount: 1000,
+			Refunds: []gateway.RefundState{{RefundID: "rf-1", Amount: 1000, Status: "refunded"}}},
+	}}
+	webhooks := &fakeWebhooks{}
+
+	run := runOnce(t, repo, provider, webhooks)
+
+	require.Len(t, webhooks.applied, 2)
+	assert.Equal(t, "transaction.captured", webhooks.applied[0].Event)
+	assert.Equal(t, "transaction.refunded", webhooks.applied[1].Event)
+	assert.Equal(t, 2, run.Fixed)
+	assert.Equal(t, 0, run.ManualReview)
+}
+
+func TestReconciler_InSyncWritesEmptyReport(t *testing.T) {
+	repo := &fakeRepo{candidates: []Candidate{
+		{PaymentID: "pay-1", ProviderTxID: "tx-1", Status: payment.StatusAuthorized, Amount: 1000},
+	}}
+	provider := &fakeProvider{states: []gateway.TransactionState{
+		{TransactionID: "tx-1", Status: "authorized", Amount: 1000},
+	}}
+	webhooks := &fakeWebhooks{}
+
+	run := runOnce(t, repo, provider, webhooks)
+
+	assert.Empty(t, webhooks.applied)
+	assert.Equal(t, 1, run.Checked)
+	assert.Empty(t, run.Items)
+	assert.NotNil(t, run.FinishedAt)
+}
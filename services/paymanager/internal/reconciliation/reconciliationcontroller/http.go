@@ -0,0 +1,54 @@
+package reconciliationcontroller
+
+import (
+	"errors"
+	"net/http"
+
+	"TestTaskJustPay/services/paymanager/internal/reconciliation"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HTTPHandler struct {
+	reconciler *reconciliation.Reconciler
+}
+
+func NewHTTPHandler(r *reconciliation.Reconciler) *HTTPHandler {
+	return &HTTPHandler{reconciler: r}
+}
+
+func (h *HTTPHandler) ListRuns(c *gin.Context) {
+	var query reconciliation.RunQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	runs, err := h.reconciler.ListRuns(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"items": runs})
+}
+
+func (h *HTTPHandler) GetRun(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing run id"})
+		return
+	}
+
+	run, err := h.reconciler.GetRun(c.Request.Context(), id)
+	if err != nil {
+		if errors.Is(err, reconciliation.ErrRunNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reconciliation run not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
@@ -0,0 +1,233 @@
+package reconciliationrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/paymanager/internal/payment"
+	"TestTaskJustPay/services/paymanager/internal/reconciliation"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// reconcilableStatuses are the payment statuses that can still change at the provider.
+var reconcilableStatuses = []payment.Status{
+	payment.StatusAuthorized,
+	payment.StatusCapturePending,
+	payment.StatusPartiallyCaptured,
+	payment.StatusCaptured,
+	payment.StatusPartiallyRefunded,
+}
+
+var runColumns = []string{"id", "started_at", "finished_at", "checked", "fixed", "manual_review", "error"}
+
+var itemColumns = []string{"id", "run_id", "payment_id", "provider_tx_id", "kind", "field",
+	"local_value", "provider_value", "detail", "created_at"}
+
+type PgRepo struct {
+	pg      *postgres.Postgres
+	readDB  postgres.Executor
+	builder squirrel.StatementBuilderType
+}
+
+// New returns the reconciliation repository. Candidates are always read from the
+// primary so replayed corrections are based on current state; reports come from readDB.
+func New(pg *postgres.Postgres, readDB postgres.Executor) reconciliation.Repo {
+	return &PgRepo{pg: pg, readDB: readDB, builder: pg.Builder}
+}
+
+func (r *PgRepo) ListCandidates(ctx context.Context, staleAfter time.Duration, limit int) ([]reconciliation.Candidate, error) {
+	query, args, err := r.builder.
+		Select("p.id", "p.merchant_id", "p.provider", "p.provider_tx_id", "p.status", "p.amount", "p.captured_amount", "p.refunded_amount",
+			"COALESCE((SELECT SUM(rf.amount) FROM refunds rf WHERE rf.payment_id = p.id AND rf.status = 'pending'), 0)").
+		From("payments p").
+		Where(squirrel.NotEq{"p.provider_tx_id": nil}).
+		Where(squirrel.Or{
+			squirrel.Eq{"p.status": reconcilableStatuses},
+			// Voided or expired payments keep their captured part, which can have refunds in flight.
+			squirrel.Expr("EXISTS (SELECT 1 FROM refunds rf WHERE rf.payment_id = p.id AND rf.status = 'pending')"),
+		}).
+		Where(squirrel.Lt{"p.updated_at": time.Now().UTC().Add(-staleAfter)}).
+		OrderBy("p.reconciled_at NULLS FIRST", "p.updated_at ASC").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select candidates: %w", err)
+	}
+
+	rows, err := r.pg.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []reconciliation.Candidate
+	for rows.Next() {
+		var c reconciliation.Candidate
+		if err := rows.Scan(&c.PaymentID, &c.MerchantID, &c.Provider, &c.ProviderTxID, &c.Status, &c.Amount,
+			&c.CapturedAmount, &c.RefundedAmount, &c.PendingRefunds); err != nil {
+			return nil, fmt.Errorf("scan candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+// MarkReconciled only touches reconciled_at so updated_at keeps reflecting payment changes.
+func (r *PgRepo) MarkReconciled(ctx context.Context, paymentIDs []string, at time.Time) error {
+	query, args, err := r.builder.Update("payments").
+		Set("reconciled_at", at).
+		Where(squirrel.Eq{"id": paymentIDs}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update reconciled_at: %w", err)
+	}
+
+	if _, err := r.pg.Pool.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("update reconciled_at: %w", err)
+	}
+	return nil
+}
+
+func (r *PgRepo) SaveRun(ctx context.Context, run *reconciliation.Run) error {
+	return r.pg.InTransaction(ctx, pgx.ReadCommitted, func(tx postgres.Executor) error {
+		query, args, err := r.builder.Insert("reconciliation_runs").
+			Columns(runColumns...).
+			Values(run.ID, run.StartedAt, run.FinishedAt, run.Checked, run.Fixed, run.ManualReview, nilIfEmpty(run.Error)).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert run: %w", err)
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("insert run: %w", err)
+		}
+
+		if len(run.Items) == 0 {
+			return nil
+		}
+
+		insert := r.builder.Insert("reconciliation_items").Columns(itemColumns...)
+		for _, it := range run.Items {
+			insert = insert.Values(it.ID, it.RunID, it.PaymentID, it.ProviderTxID, it.Kind, it.Field,
+				it.LocalValue, it.ProviderValue, nilIfEmpty(it.Detail), it.CreatedAt)
+		}
+		query, args, err = insert.ToSql()
+		if err != nil {
+			return fmt.Errorf("build insert items: %w", err)
+		}
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("insert items: %w", err)
+		}
+		return nil
+	})
+}
+
+func (r *PgRepo) ListRuns(ctx context.Context, limit int) ([]reconciliation.Run, error) {
+	query, args, err := r.builder.
+		Select(runColumns...).
+		From("reconciliation_runs").
+		OrderBy("started_at DESC").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select runs: %w", err)
+	}
+
+	rows, err := r.readDB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]reconciliation.Run, 0)
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate runs: %w", err)
+	}
+	return runs, nil
+}
+
+func (r *PgRepo) GetRun(ctx context.Context, id string) (*reconciliation.Run, error) {
+	query, args, err := r.builder.
+		Select(runColumns...).
+		From("reconciliation_runs").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select run: %w", err)
+	}
+
+	run, err := scanRun(r.readDB.QueryRow(ctx, query, args...))
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err = r.builder.
+		Select(itemColumns...).
+		From("reconciliation_items").
+		Where(squirrel.Eq{"run_id": id}).
+		OrderBy("created_at ASC", "id ASC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select items: %w", err)
+	}
+
+	rows, err := r.readDB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var it reconciliation.Item
+		var detail *string
+		if err := rows.Scan(&it.ID, &it.RunID, &it.PaymentID, &it.ProviderTxID, &it.Kind, &it.Field,
+			&it.LocalValue, &it.ProviderValue, &detail, &it.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan item: %w", err)
+		}
+		if detail != nil {
+			it.Detail = *detail
+		}
+		run.Items = append(run.Items, it)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate items: %w", err)
+	}
+	return run, nil
+}
+
+func scanRun(row pgx.Row) (*reconciliation.Run, error) {
+	var run reconciliation.Run
+	var errMsg *string
+	err := row.Scan(&run.ID, &run.StartedAt, &run.FinishedAt, &run.Checked, &run.Fixed, &run.ManualReview, &errMsg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, reconciliation.ErrRunNotFound
+		}
+		return nil, fmt.Errorf("scan run: %w", err)
+	}
+	if errMsg != nil {
+		run.Error = *errMsg
+	}
+	return &run, nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,124 @@
+package risk
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"TestTaskJustPay/pkg/metrics"
+	"TestTaskJustPay/pkg/postgres"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Engine assesses payment attempts against the current rule set before authorization.
+// Rules can be replaced at any time; an attempt is always assessed against one consistent set.
+type Engine struct {
+	transactor postgres.Transactor
+	txRepo     func(tx postgres.Executor) Repo
+	rules      atomic.Pointer[[]Rule]
+}
+
+// NewEngine creates an engine with the given initial rules.
+func NewEngine(transactor postgres.Transactor, txRepo func(tx postgres.Executor) Repo, rules []Rule) *Engine {
+	e := &Engine{transactor: transactor, txRepo: txRepo}
+	e.SetRules(rules)
+	return e
+}
+
+// SetRules atomically replaces the rule set.
+func (e *Engine) SetRules(rules []Rule) {
+	e.rules.Store(&rules)
+}
+
+// Rules returns the current rule set.
+func (e *Engine) Rules() []Rule {
+	return *e.rules.Load()
+}
+
+// Evaluate runs every rule against in and stores the decision. The outcome is the most
+// severe action among the rules that matched, or allow when none did.
+func (e *Engine) Evaluate(ctx context.Context, in Input) (Decision, error) {
+	d := Decision{
+		ID:              uuid.New().String(),
+		MerchantID:      in.MerchantID,
+		CardFingerprint: in.CardFingerprint,
+		IP:              in.IP,
+		Country:         in.Country,
+		Amount:          in.Amount,
+		Currency:        in.Currency.String(),
+		Outcome:         OutcomeAllow,
+		Hits:            []Hit{},
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	rules := e.Rules()
+	err := e.transactor.InTransaction(ctx, pgx.ReadCommitted, func(tx postgres.Executor) error {
+		repo := e.txRepo(tx)
+		// Concurrent attempts with the same velocity key value are assessed one at a time,
+		// so each one counts the decisions stored by the others.
+		for _, k := range velocityKeys(rules, in) {
+			if err := repo.LockKey(ctx, k, in.keyValue(k)); err != nil {
+				return err
+			}
+		}
+
+		for _, r := range rules {
+			matched, detail, err := r.evaluate(ctx, repo, in)
+			if err != nil {
+				return fmt.Errorf("evaluate rule %s: %w", r.Name, err)
+			}
+			if !matched {
+				continue
+			}
+			d.Hits = append(d.Hits, Hit{Rule: r.Name, Action: r.Action, Detail: detail})
+			if r.Action.severity() > d.Outcome.severity() {
+				d.Outcome = r.Action
+			}
+		}
+
+		if err := repo.CreateDecision(ctx, d); err != nil {
+			return fmt.Errorf("save risk decision: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return Decision{}, err
+	}
+	for _, h := range d.Hits {
+		metrics.RiskRuleHitsTotal.WithLabelValues(h.Rule, string(h.Action)).Inc()
+	}
+	metrics.RiskDecisionsTotal.WithLabelValues(string(d.Outcome)).Inc()
+
+	if d.Outcome != OutcomeAllow {
+		slog.InfoContext(ctx, "risk rules matched",
+			"decision_id", d.ID,
+			"merchant_id", d.MerchantID,
+			"outcome", d.Outcome,
+			"hits", len(d.Hits))
+	}
+	return d, nil
+}
+
+// velocityKeys returns the keys the velocity rules that apply to in count by, in a fixed
+// order so concurrent assessments lock them in the same order.
+func velocityKeys(rules []Rule, in Input) []Key {
+	var keys []Key
+	for _, k := range []Key{KeyCard, KeyIP, KeyMerchant} {
+		if in.keyValue(k) == "" {
+			continue
+		}
+		applies := slices.ContainsFunc(rules, func(r Rule) bool {
+			return r.Type == RuleVelocity && r.Key == k &&
+				(len(r.Merchants) == 0 || slices.Contains(r.Merchants, in.MerchantID))
+		})
+		if applies {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,143 @@
+package risk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/pkg/money"
+	"TestTaskJustPay/pkg/postgres"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRepo struct {
+	decisions []Decision
+	locked    []string
+}
+
+func (f *fakeRepo) LockKey(_ context.Context, key Key, value string) error {
+	f.locked = append(f.locked, string(key)+":"+value)
+	return nil
+}
+
+func (f *fakeRepo) CreateDecision(_ context.Context, d Decision) error {
+	f.decisions = append(f.decisions, d)
+	return nil
+}
+
+func (f *fakeRepo) CountDecisions(_ context.Context, key Key, value string, since time.Time) (int, error) {
+	n := 0
+	for _, d := range f.decisions {
+		in := Input{MerchantID: d.MerchantID, CardFingerprint: d.CardFingerprint, IP: d.IP}
+		if in.keyValue(key) == value && !d.CreatedAt.Before(since) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// fakeTransactor runs fn without a DB transaction.
+type fakeTransactor struct{}
+
+func (fakeTransactor) InTransaction(_ context.Context, _ pgx.TxIsoLevel, fn func(postgres.Executor) error) error {
+	return fn(nil)
+}
+
+func newTestEngine(repo *fakeRepo, rules []Rule) *Engine {
+	return NewEngine(fakeTransactor{}, func(postgres.Executor) Repo { return repo }, rules)
+}
+
+func attempt(amount int64, currency money.Currency) Input {
+	return Input{
+		MerchantID:      "merchant_1",
+		CardFingerprint: "fp_1",
+		IP:              "203.0.113.7",
+		Country:         "de",
+		Money:           money.Money{Amount: amount, Currency: currency},
+	}
+}
+
+func TestEngine_Evaluate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("no rules allows and records the decision", func(t *testing.T) {
+		repo := &fakeRepo{}
+
+		d, err := newTestEngine(repo, nil).Evaluate(ctx, attempt(1000, "EUR"))
+
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeAllow, d.Outcome)
+		assert.Empty(t, d.Hits)
+		require.Len(t, repo.decisions, 1)
+		assert.Equal(t, d.ID, repo.decisions[0].ID)
+	})
+
+	t.Run("most severe matching action wins", func(t *testing.T) {
+		rules := []Rule{
+			{Name: "big", Type: RuleAmount, MinAmount: 500, Action: OutcomeReview},
+			{Name: "mismatch", Type: RuleCountryCurrencyMismatch, Action: OutcomeBlock},
+			{Name: "other_merchant", Type: RuleAmount, MinAmount: 1, Action: OutcomeBlock, Merchants: []string{"merchant_2"}},
+		}
+
+		d, err := newTestEngine(&fakeRepo{}, rules).Evaluate(ctx, attempt(1000, "USD"))
+
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeBlock, d.Outcome)
+		require.Len(t, d.Hits, 2)
+		assert.Equal(t, "big", d.Hits[0].Rule)
+		assert.Equal(t, "mismatch", d.Hits[1].Rule)
+		assert.Equal(t, "country DE uses EUR, payment in USD", d.Hits[1].Detail)
+	})
+
+	t.Run("velocity counts the current attempt", func(t *testing.T) {
+		repo := &fakeRepo{}
+		engine := newTestEngine(repo, []Rule{
+			{Name: "card_velocity", Type: RuleVelocity, Key: KeyCard, Window: time.Hour, Max: 2, Action: OutcomeBlock},
+		})
+
+		var outcomes []Outcome
+		for range 3 {
+			d, err := engine.Evaluate(ctx, attempt(1000, "EUR"))
+			require.NoError(t, err)
+			outcomes = append(outcomes, d.Outcome)
+		}
+
+		assert.Equal(t, []Outcome{OutcomeAllow, OutcomeAllow, OutcomeBlock}, outcomes)
+	})
+
+	t.Run("velocity keys are locked before counting", func(t *testing.T) {
+		repo := &fakeRepo{}
+		engine := newTestEngine(repo, []Rule{
+			{Name: "ip_velocity", Type: RuleVelocity, Key: KeyIP, Window: time.Hour, Max: 5, Action: OutcomeReview},
+			{Name: "card_velocity", Type: RuleVelocity, Key: KeyCard, Window: time.Hour, Max: 2, Action: OutcomeBlock},
+			{Name: "other_merchant", Type: RuleVelocity, Key: KeyMerchant, Window: time.Hour, Max: 1, Action: OutcomeBlock, Merchants: []string{"merchant_2"}},
+		})
+
+		_, err := engine.Evaluate(ctx, attempt(1000, "EUR"))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"card:fp_1", "ip:203.0.113.7"}, repo.locked)
+	})
+
+	t.Run("denylist matches case-insensitively", func(t *testing.T) {
+		rules := []Rule{{Name: "countries", Type: RuleDenylist, Key: KeyCountry, Values: []string{"DE"}, Action: OutcomeReview}}
+
+		d, err := newTestEngine(&fakeRepo{}, rules).Evaluate(ctx, attempt(1000, "EUR"))
+
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeReview, d.Outcome)
+	})
+
+	t.Run("replaced rules apply to the next attempt", func(t *testing.T) {
+		engine := newTestEngine(&fakeRepo{}, nil)
+		engine.SetRules([]Rule{{Name: "big", Type: RuleAmount, MinAmount: 1, Action: OutcomeReview}})
+
+		d, err := engine.Evaluate(ctx, attempt(1000, "EUR"))
+
+		require.NoError(t, err)
+		assert.Equal(t, OutcomeReview, d.Outcome)
+	})
+}
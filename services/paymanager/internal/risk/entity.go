@@ -0,0 +1,67 @@
+package risk
+
+import (
+	"time"
+
+	"TestTaskJustPay/pkg/money"
+)
+
+// Outcome is what the risk engine decided for a payment attempt.
+type Outcome string
+
+const (
+	OutcomeAllow Outcome = "allow"
+	// OutcomeReview lets the authorization through but holds the capture for manual review.
+	OutcomeReview Outcome = "review"
+	// OutcomeBlock declines the payment without contacting the provider.
+	OutcomeBlock Outcome = "block"
+)
+
+func (o Outcome) severity() int {
+	switch o {
+	case OutcomeBlock:
+		return 2
+	case OutcomeReview:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func (o Outcome) isAction() bool {
+	return o == OutcomeReview || o == OutcomeBlock
+}
+
+// Input is the payment attempt being assessed.
+type Input struct {
+	MerchantID string
+	// CardFingerprint identifies the card across tokens when it is already known.
+	CardFingerprint string
+	IP              string
+	// Country is the customer's ISO 3166-1 alpha-2 country; empty when not supplied.
+	Country string
+	money.Money
+}
+
+// Hit is a rule that matched the attempt.
+type Hit struct {
+	Rule   string  `json:"rule"`
+	Action Outcome `json:"action"`
+	Detail string  `json:"detail,omitempty"`
+}
+
+// Decision is the stored result of assessing one payment attempt. Decisions are kept
+// with their rule hits so rules can be tuned against real traffic, and they are what
+// velocity rules count.
+type Decision struct {
+	ID              string    `json:"id"`
+	MerchantID      string    `json:"merchant_id"`
+	CardFingerprint string    `json:"card_fingerprint,omitempty"`
+	IP              string    `json:"ip,omitempty"`
+	Country         string    `json:"country,omitempty"`
+	Amount          int64     `json:"amount"`
+	Currency        string    `json:"currency"`
+	Outcome         Outcome   `json:"outcome"`
+	Hits            []Hit     `json:"hits"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package risk
+
+import (
+	"context"
+	"time"
+)
+
+// DecisionCounter counts stored decisions for velocity rules.
+type DecisionCounter interface {
+	// CountDecisions counts decisions whose key attribute equals value, created at or after since.
+	CountDecisions(ctx context.Context, key Key, value string, since time.Time) (int, error)
+}
+
+// Repo is the persistence contract for risk decisions. The engine binds it to the DB
+// transaction of an assessment.
+type Repo interface {
+	DecisionCounter
+	CreateDecision(ctx context.Context, d Decision) error
+	// LockKey holds back other assessments with the same key value until the DB transaction
+	// ends, so a velocity count and the decision it leads to are stored as one step.
+	LockKey(ctx context.Context, key Key, value string) error
+}
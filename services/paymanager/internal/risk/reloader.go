@@ -0,0 +1,79 @@
+package risk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// LoadRulesFile reads and validates the rules file at path and returns the rules with
+// the raw content they were parsed from.
+func LoadRulesFile(path string) ([]Rule, []byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read risk rules: %w", err)
+	}
+	rules, err := ParseRules(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rules, raw, nil
+}
+
+// Reloader hot-reloads the engine's rules from a YAML file. A file that fails to parse
+// or validate is logged and ignored, so the engine keeps running on the last good rules.
+type Reloader struct {
+	engine   *Engine
+	path     string
+	interval time.Duration
+	last     []byte
+}
+
+// NewReloader creates a reloader for path. initial is the file content the engine's
+// current rules were loaded from, so an unchanged file is not reloaded.
+func NewReloader(engine *Engine, path string, interval time.Duration, initial []byte) *Reloader {
+	return &Reloader{engine: engine, path: path, interval: interval, last: initial}
+}
+
+// Start polls the file until ctx is cancelled.
+func (r *Reloader) Start(ctx context.Context) error {
+	slog.Info("Risk rules reloader started", "path", r.path, "interval", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Risk rules reloader stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			r.reload()
+		}
+	}
+}
+
+func (r *Reloader) reload() {
+	raw, err := os.ReadFile(r.path)
+	if err != nil {
+		slog.Error("Failed to read risk rules", "path", r.path, slog.Any("error", err))
+		return
+	}
+	if bytes.Equal(raw, r.last) {
+		return
+	}
+
+	rules, err := ParseRules(raw)
+	if err != nil {
+		slog.Error("Ignoring invalid risk rules, keeping previous set", "path", r.path, slog.Any("error", err))
+		r.last = raw
+		return
+	}
+
+	r.engine.SetRules(rules)
+	r.last = raw
+	slog.Info("Risk rules reloaded", "path", r.path, "rules", len(rules))
+}
@@ -0,0 +1,34 @@
+package risk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloader_KeepsLastGoodRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	write := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+
+	write("rules: [{name: big, type: amount, min_amount: 100, action: review}]")
+	rules, raw, err := LoadRulesFile(path)
+	require.NoError(t, err)
+	engine := newTestEngine(&fakeRepo{}, rules)
+	reloader := NewReloader(engine, path, time.Second, raw)
+
+	write("rules: [{name: big, type: amount, min_amount: 100, action: block}]")
+	reloader.reload()
+	require.Len(t, engine.Rules(), 1)
+	assert.Equal(t, OutcomeBlock, engine.Rules()[0].Action)
+
+	write("rules: [{name: big, type: amount, action: block}]")
+	reloader.reload()
+	require.Len(t, engine.Rules(), 1)
+	assert.Equal(t, int64(100), engine.Rules()[0].MinAmount, "invalid file must not replace the rules")
+}
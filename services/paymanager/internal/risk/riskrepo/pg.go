@@ -0,0 +1,97 @@
+package riskrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/paymanager/internal/risk"
+
+	"github.com/Masterminds/squirrel"
+)
+
+// keyColumns maps velocity keys to the risk_decisions column they count by.
+var keyColumns = map[risk.Key]string{
+	risk.KeyCard:     "card_fingerprint",
+	risk.KeyIP:       "ip",
+	risk.KeyMerchant: "merchant_id",
+}
+
+type PgDecisionRepo struct {
+	db      postgres.Executor
+	builder squirrel.StatementBuilderType
+}
+
+func NewPgDecisionRepo(db postgres.Executor, builder squirrel.StatementBuilderType) *PgDecisionRepo {
+	return &PgDecisionRepo{db: db, builder: builder}
+}
+
+// TxRepoFactory binds the repository to the DB transaction of an assessment. Counts read
+// the primary: a velocity rule must see the attempts stored a moment ago.
+func TxRepoFactory(builder squirrel.StatementBuilderType) func(postgres.Executor) risk.Repo {
+	return func(tx postgres.Executor) risk.Repo {
+		return NewPgDecisionRepo(tx, builder)
+	}
+}
+
+// LockKey takes a transaction-scoped advisory lock on the key value.
+func (r *PgDecisionRepo) LockKey(ctx context.Context, key risk.Key, value string) error {
+	if _, err := r.db.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, 0))",
+		fmt.Sprintf("risk_decisions:%s:%s", key, value)); err != nil {
+		return fmt.Errorf("lock risk key %s: %w", key, err)
+	}
+	return nil
+}
+
+func (r *PgDecisionRepo) CreateDecision(ctx context.Context, d risk.Decision) error {
+	hits, err := json.Marshal(d.Hits)
+	if err != nil {
+		return fmt.Errorf("marshal rule hits: %w", err)
+	}
+
+	query, args, err := r.builder.Insert("risk_decisions").
+		Columns("id", "merchant_id", "card_fingerprint", "ip", "country", "amount", "currency", "outcome", "hits", "created_at").
+		Values(d.ID, d.MerchantID, nilIfEmpty(d.CardFingerprint), nilIfEmpty(d.IP), nilIfEmpty(d.Country),
+			d.Amount, d.Currency, d.Outcome, hits, d.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("insert risk decision: %w", err)
+	}
+	return nil
+}
+
+func (r *PgDecisionRepo) CountDecisions(ctx context.Context, key risk.Key, value string, since time.Time) (int, error) {
+	column, ok := keyColumns[key]
+	if !ok {
+		return 0, fmt.Errorf("no decision column for key %q", key)
+	}
+
+	query, args, err := r.builder.
+		Select("COUNT(*)").
+		From("risk_decisions").
+		Where(squirrel.Eq{column: value}).
+		Where(squirrel.GtOrEq{"created_at": since}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build count: %w", err)
+	}
+
+	var n int
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("count risk decisions: %w", err)
+	}
+	return n, nil
+}
+
+func nilIfEmpty(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,197 @@
+package risk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"TestTaskJustPay/pkg/money"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RuleType selects how a rule matches a payment attempt.
+type RuleType string
+
+const (
+	// RuleAmount matches attempts of at least MinAmount, optionally in one Currency.
+	RuleAmount RuleType = "amount"
+	// RuleVelocity matches when more than Max attempts with the same Key value were
+	// assessed within Window, counting the current one.
+	RuleVelocity RuleType = "velocity"
+	// RuleCountryCurrencyMismatch matches when the customer's country does not use the
+	// payment currency. Attempts without a country or from unmapped countries never match.
+	RuleCountryCurrencyMismatch RuleType = "country_currency_mismatch"
+	// RuleDenylist matches when the Key value is one of Values.
+	RuleDenylist RuleType = "denylist"
+)
+
+// Key is the attempt attribute velocity and denylist rules look at.
+type Key string
+
+const (
+	KeyCard     Key = "card"
+	KeyIP       Key = "ip"
+	KeyMerchant Key = "merchant"
+	KeyCountry  Key = "country"
+)
+
+// Rule is one entry of the risk rules file. Merchants, when set, limits it to those merchants.
+type Rule struct {
+	Name      string   `yaml:"name"`
+	Type      RuleType `yaml:"type"`
+	Action    Outcome  `yaml:"action"`
+	Merchants []string `yaml:"merchants,omitempty"`
+
+	MinAmount int64          `yaml:"min_amount,omitempty"`
+	Currency  money.Currency `yaml:"currency,omitempty"`
+
+	Key    Key           `yaml:"key,omitempty"`
+	Window time.Duration `yaml:"window,omitempty"`
+	Max    int           `yaml:"max,omitempty"`
+	Values []string      `yaml:"values,omitempty"`
+}
+
+// ruleFile is the YAML document rules are loaded from.
+type ruleFile struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// ParseRules decodes and validates a YAML rules document. An empty document means no rules.
+func ParseRules(raw []byte) ([]Rule, error) {
+	var f ruleFile
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return nil, fmt.Errorf("parse risk rules: %w", err)
+	}
+
+	seen := make(map[string]bool, len(f.Rules))
+	var errs []error
+	for i, r := range f.Rules {
+		if err := r.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("rule %d (%s): %w", i, r.Name, err))
+		}
+		if seen[r.Name] {
+			errs = append(errs, fmt.Errorf("rule %d: duplicate name %q", i, r.Name))
+		}
+		seen[r.Name] = true
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("invalid risk rules: %w", err)
+	}
+	return f.Rules, nil
+}
+
+func (r Rule) validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if !r.Action.isAction() {
+		return fmt.Errorf("action must be %q or %q", OutcomeReview, OutcomeBlock)
+	}
+
+	switch r.Type {
+	case RuleAmount:
+		if r.MinAmount <= 0 {
+			return errors.New("min_amount must be positive")
+		}
+		if r.Currency != "" && !r.Currency.IsValid() {
+			return fmt.Errorf("unknown currency %q", r.Currency)
+		}
+	case RuleVelocity:
+		if r.Key != KeyCard && r.Key != KeyIP && r.Key != KeyMerchant {
+			return fmt.Errorf("velocity key must be %q, %q or %q", KeyCard, KeyIP, KeyMerchant)
+		}
+		if r.Window <= 0 || r.Max <= 0 {
+			return errors.New("window and max must be positive")
+		}
+	case RuleCountryCurrencyMismatch:
+	case RuleDenylist:
+		if r.Key != KeyCard && r.Key != KeyIP && r.Key != KeyMerchant && r.Key != KeyCountry {
+			return fmt.Errorf("denylist key must be %q, %q, %q or %q", KeyCard, KeyIP, KeyMerchant, KeyCountry)
+		}
+		if len(r.Values) == 0 {
+			return errors.New("values must not be empty")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", r.Type)
+	}
+	return nil
+}
+
+// keyValue returns the attempt attribute k refers to.
+func (in Input) keyValue(k Key) string {
+	switch k {
+	case KeyCard:
+		return in.CardFingerprint
+	case KeyIP:
+		return in.IP
+	case KeyMerchant:
+		return in.MerchantID
+	case KeyCountry:
+		return strings.ToUpper(in.Country)
+	}
+	return ""
+}
+
+// evaluate reports whether r matches in, with a human-readable detail for the decision log.
+// The current attempt is not stored yet, so velocity counts it on top of stored decisions.
+func (r Rule) evaluate(ctx context.Context, counter DecisionCounter, in Input) (bool, string, error) {
+	if len(r.Merchants) > 0 && !slices.Contains(r.Merchants, in.MerchantID) {
+		return false, "", nil
+	}
+
+	switch r.Type {
+	case RuleAmount:
+		if r.Currency != "" && r.Currency != in.Currency {
+			return false, "", nil
+		}
+		if in.Amount < r.MinAmount {
+			return false, "", nil
+		}
+		return true, fmt.Sprintf("amount %d %s >= %d", in.Amount, in.Currency, r.MinAmount), nil
+
+	case RuleVelocity:
+		value := in.keyValue(r.Key)
+		if value == "" {
+			return false, "", nil
+		}
+		n, err := counter.CountDecisions(ctx, r.Key, value, time.Now().UTC().Add(-r.Window))
+		if err != nil {
+			return false, "", fmt.Errorf("count decisions by %s: %w", r.Key, err)
+		}
+		if n+1 <= r.Max {
+			return false, "", nil
+		}
+		return true, fmt.Sprintf("%d attempts by %s within %s (max %d)", n+1, r.Key, r.Window, r.Max), nil
+
+	case RuleCountryCurrencyMismatch:
+		country := strings.ToUpper(in.Country)
+		expected, ok := countryCurrencies[country]
+		if !ok || expected == in.Currency {
+			return false, "", nil
+		}
+		return true, fmt.Sprintf("country %s uses %s, payment in %s", country, expected, in.Currency), nil
+
+	case RuleDenylist:
+		value := in.keyValue(r.Key)
+		if value == "" || !slices.ContainsFunc(r.Values, func(v string) bool { return strings.EqualFold(v, value) }) {
+			return false, "", nil
+		}
+		return true, fmt.Sprintf("%s %s is denylisted", r.Key, value), nil
+	}
+	return false, "", nil
+}
+
+// countryCurrencies maps countries to the currency their cardholders normally pay in.
+var countryCurrencies = map[string]money.Currency{
+	"US": "USD", "CA": "CAD", "MX": "MXN", "BR": "BRL", "GB": "GBP", "CH": "CHF", "NO": "NOK",
+	"SE": "SEK", "DK": "DKK", "PL": "PLN", "CZ": "CZK", "HU": "HUF", "RO": "RON", "UA": "UAH",
+	"TR": "TRY", "JP": "JPY", "CN": "CNY", "IN": "INR", "AU": "AUD", "NZ": "NZD", "SG": "SGD",
+	"HK": "HKD", "KR": "KRW", "AE": "AED", "ZA": "ZAR", "IL": "ILS",
+	"AT": "EUR", "BE": "EUR", "CY": "EUR", "DE": "EUR", "EE": "EUR", "ES": "EUR", "FI": "EUR",
+	"FR": "EUR", "GR": "EUR", "HR": "EUR", "IE": "EUR", "IT": "EUR", "LT": "EUR", "LU": "EUR",
+	"LV": "EUR", "MT": "EUR", "NL": "EUR", "PT": "EUR", "SI": "EUR", "SK": "EUR",
+}
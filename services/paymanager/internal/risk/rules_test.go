@@ -0,0 +1,68 @@
+package risk
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRules(t *testing.T) {
+	t.Run("decodes every rule type", func(t *testing.T) {
+		rules, err := ParseRules([]byte(`
+rules:
+  - name: big
+    type: amount
+    min_amount: 100000
+    currency: USD
+    action: review
+  - name: card_velocity
+    type: velocity
+    key: card
+    window: 1h
+    max: 5
+    action: block
+  - name: mismatch
+    type: country_currency_mismatch
+    action: review
+  - name: bad_ips
+    type: denylist
+    key: ip
+    values: ["10.0.0.1"]
+    action: block
+    merchants: [merchant_1]
+`))
+
+		require.NoError(t, err)
+		require.Len(t, rules, 4)
+		assert.Equal(t, RuleAmount, rules[0].Type)
+		assert.Equal(t, time.Hour, rules[1].Window)
+		assert.Equal(t, OutcomeBlock, rules[1].Action)
+		assert.Equal(t, []string{"merchant_1"}, rules[3].Merchants)
+	})
+
+	t.Run("empty document means no rules", func(t *testing.T) {
+		rules, err := ParseRules(nil)
+
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+	})
+
+	invalid := map[string]string{
+		"unknown type":        "rules: [{name: a, type: magic, action: block}]",
+		"allow is no action":  "rules: [{name: a, type: amount, min_amount: 1, action: allow}]",
+		"missing name":        "rules: [{type: amount, min_amount: 1, action: block}]",
+		"velocity by country": "rules: [{name: a, type: velocity, key: country, window: 1m, max: 1, action: block}]",
+		"velocity no window":  "rules: [{name: a, type: velocity, key: ip, max: 1, action: block}]",
+		"empty denylist":      "rules: [{name: a, type: denylist, key: ip, action: block}]",
+		"duplicate name":      "rules: [{name: a, type: amount, min_amount: 1, action: block}, {name: a, type: amount, min_amount: 2, action: review}]",
+		"not yaml":            "rules: [",
+	}
+	for name, raw := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseRules([]byte(raw))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package routing
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+
+	"TestTaskJustPay/pkg/money"
+)
+
+var ErrUnknownProvider = errors.New("unknown provider")
+
+// Router holds the configured payment providers and picks the ones a new payment is
+// sent to. P is the provider interface of the domain using the router, so every
+// domain keeps its own narrow view of the same clients.
+type Router[P any] struct {
+	providers   map[string]P
+	defaultName string
+	rules       []Rule
+	pick        func(n int) int
+}
+
+// New validates that defaultName and every provider the rules reference are registered.
+func New[P any](defaultName string, providers map[string]P, rules []Rule) (*Router[P], error) {
+	if _, ok := providers[defaultName]; !ok {
+		return nil, fmt.Errorf("default provider %q: %w", defaultName, ErrUnknownProvider)
+	}
+	for i, rule := range rules {
+		if len(rule.Providers) == 0 {
+			return nil, fmt.Errorf("routing rule %d has no providers", i)
+		}
+		for _, t := range rule.Providers {
+			if _, ok := providers[t.Name]; !ok {
+				return nil, fmt.Errorf("routing rule %d provider %q: %w", i, t.Name, ErrUnknownProvider)
+			}
+			if t.Weight < 0 {
+				return nil, fmt.Errorf("routing rule %d provider %q has a negative weight", i, t.Name)
+			}
+		}
+		for _, name := range rule.Failover {
+			if _, ok := providers[name]; !ok {
+				return nil, fmt.Errorf("routing rule %d failover %q: %w", i, name, ErrUnknownProvider)
+			}
+		}
+	}
+	return &Router[P]{providers: providers, defaultName: defaultName, rules: rules, pick: rand.IntN}, nil
+}
+
+// Default returns the name of the provider used when no rule matches.
+func (r *Router[P]) Default() string {
+	return r.defaultName
+}
+
+// Provider returns the named provider. An empty name is the default provider, which
+// covers payments recorded before routing existed.
+func (r *Router[P]) Provider(name string) (P, error) {
+	if name == "" {
+		name = r.defaultName
+	}
+	p, ok := r.providers[name]
+	if !ok {
+		var zero P
+		return zero, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+	return p, nil
+}
+
+// Route returns the providers to try for a new payment, in failover order. The first
+// matching rule decides; without one the payment goes to the default provider only.
+func (r *Router[P]) Route(merchantID string, m money.Money) []string {
+	for _, rule := range r.rules {
+		if !rule.matches(merchantID, m) {
+			continue
+		}
+
+		targets := slices.Clone(rule.Providers)
+		first := r.weighted(targets)
+		names := []string{targets[first].Name}
+		targets = slices.Delete(targets, first, first+1)
+		// The rest are failovers, heaviest first.
+		slices.SortStableFunc(targets, func(a, b Target) int { return b.Weight - a.Weight })
+		for _, t := range targets {
+			names = appendUnique(names, t.Name)
+		}
+		for _, name := range rule.Failover {
+			names = appendUnique(names, name)
+		}
+		return names
+	}
+	return []string{r.defaultName}
+}
+
+// weighted picks an index proportionally to the targets' weights; all-zero weights pick the first.
+func (r *Router[P]) weighted(targets []Target) int {
+	total := 0
+	for _, t := range targets {
+		total += t.Weight
+	}
+	if total == 0 {
+		return 0
+	}
+	n := r.pick(total)
+	for i, t := range targets {
+		if n < t.Weight {
+			return i
+		}
+		n -= t.Weight
+	}
+	return len(targets) - 1
+}
+
+func appendUnique(names []string, name string) []string {
+	if slices.Contains(names, name) {
+		return names
+	}
+	return append(names, name)
+}
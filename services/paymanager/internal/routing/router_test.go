@@ -0,0 +1,65 @@
+package routing
+
+import (
+	"testing"
+
+	"TestTaskJustPay/pkg/money"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_Route(t *testing.T) {
+	providers := map[string]string{"primary": "p", "secondary": "s", "eu": "e"}
+	rules, err := ParseRules(`[
+		{"merchants": ["merchant_vip"], "providers": [{"name": "secondary"}], "failover": ["primary"]},
+		{"currencies": ["EUR"], "max_amount": 100000, "providers": [{"name": "eu"}]},
+		{"providers": [{"name": "primary", "weight": 80}, {"name": "secondary", "weight": 20}]}
+	]`)
+	require.NoError(t, err)
+
+	r, err := New("primary", providers, rules)
+	require.NoError(t, err)
+
+	usd := money.Money{Amount: 1000, Currency: "USD"}
+
+	t.Run("merchant rule with failover", func(t *testing.T) {
+		assert.Equal(t, []string{"secondary", "primary"}, r.Route("merchant_vip", usd))
+	})
+
+	t.Run("currency and amount rule", func(t *testing.T) {
+		assert.Equal(t, []string{"eu"}, r.Route("merchant_1", money.Money{Amount: 5000, Currency: "EUR"}))
+	})
+
+	t.Run("amount above the bound falls through to the weighted split", func(t *testing.T) {
+		r.pick = func(int) int { return 0 }
+		assert.Equal(t, []string{"primary", "secondary"}, r.Route("merchant_1", money.Money{Amount: 500000, Currency: "EUR"}))
+	})
+
+	t.Run("weighted split sends the tail of the range to the lighter provider", func(t *testing.T) {
+		r.pick = func(int) int { return 85 }
+		assert.Equal(t, []string{"secondary", "primary"}, r.Route("merchant_1", usd))
+	})
+}
+
+func TestRouter_DefaultWithoutRules(t *testing.T) {
+	r, err := New("primary", map[string]string{"primary": "p"}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"primary"}, r.Route("merchant_1", money.Money{Amount: 1, Currency: "USD"}))
+
+	p, err := r.Provider("")
+	require.NoError(t, err)
+	assert.Equal(t, "p", p)
+
+	_, err = r.Provider("missing")
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
+
+func TestNew_RejectsUnknownProviders(t *testing.T) {
+	_, err := New("primary", map[string]string{"primary": "p"}, []Rule{{Providers: []Target{{Name: "other"}}}})
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+
+	_, err = New("missing", map[string]string{"primary": "p"}, nil)
+	assert.ErrorIs(t, err, ErrUnknownProvider)
+}
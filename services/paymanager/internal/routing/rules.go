@@ -0,0 +1,56 @@
+package routing
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"TestTaskJustPay/pkg/money"
+)
+
+// Rule routes the payments matching all of its set conditions to Providers.
+type Rule struct {
+	Merchants  []string         `json:"merchants,omitempty"`
+	Currencies []money.Currency `json:"currencies,omitempty"`
+	// MinAmount and MaxAmount bound the amount in minor units; zero leaves the bound open.
+	MinAmount int64 `json:"min_amount,omitempty"`
+	MaxAmount int64 `json:"max_amount,omitempty"`
+	// Providers share the matching payments by weight. The first payment attempt goes to
+	// one of them; the others, then Failover, are tried when it is unavailable.
+	Providers []Target `json:"providers"`
+	Failover  []string `json:"failover,omitempty"`
+}
+
+// Target is a provider with its share of a rule's traffic.
+type Target struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// ParseRules decodes rules from their JSON configuration form. An empty string means no rules.
+func ParseRules(raw string) ([]Rule, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var rules []Rule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("parse routing rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r Rule) matches(merchantID string, m money.Money) bool {
+	if len(r.Merchants) > 0 && !slices.Contains(r.Merchants, merchantID) {
+		return false
+	}
+	if len(r.Currencies) > 0 && !slices.Contains(r.Currencies, m.Currency) {
+		return false
+	}
+	if r.MinAmount > 0 && m.Amount < r.MinAmount {
+		return false
+	}
+	if r.MaxAmount > 0 && m.Amount > r.MaxAmount {
+		return false
+	}
+	return true
+}
@@ -1,36 +1,140 @@
 package silvergateclient
 
 import (
-	"TestTaskJustPay/services/paymanager/internal/gateway"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"time"
+
+	"TestTaskJustPay/pkg/resilience"
+	"TestTaskJustPay/services/paymanager/internal/gateway"
 )
 
+// IdempotencyKeyHeader carries the key Silvergate requires on every mutating request.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// DefaultName is the provider name of the primary Silvergate instance; payments
+// recorded before provider routing belong to it.
+const DefaultName = "silvergate"
+
 type Client struct {
+	// Name identifies this Silvergate instance among the configured providers.
+	Name                   string
 	BaseURL                string
 	SubmitRepresentmentUrl string
 	CaptureUrl             string
 	AuthUrl                string
 	VoidUrl                string
+	TransactionsUrl        string
 	HTTP                   *http.Client
+	// Timeouts bounds every attempt; Retry applies to idempotent operations only.
+	Timeouts Timeouts
+	Retry    resilience.RetryConfig
+	// Breaker fails calls fast while Silvergate is unhealthy. Nil disables it.
+	Breaker *resilience.CircuitBreaker
+	// Credentials resolves the Silvergate account of the merchant a request is made for.
+	// When nil, the merchant id is forwarded as is and requests carry no API key.
+	Credentials CredentialsResolver
+}
+
+// CredentialsResolver supplies the provider credentials of a paymanager merchant.
+type CredentialsResolver interface {
+	ProviderCredentials(ctx context.Context, merchantID string) (gateway.Credentials, error)
 }
 
-func New(baseURL string, submitRepresentmentPath, capturePath, authPath, voidPath string, httpClient *http.Client) *Client {
+func New(baseURL string, submitRepresentmentPath, capturePath, authPath, voidPath, transactionsPath string, httpClient *http.Client) *Client {
 	if httpClient == nil {
 		httpClient = &http.Client{Timeout: 10 * time.Second}
 	}
 	return &Client{
+		Name:                   DefaultName,
 		BaseURL:                baseURL,
 		SubmitRepresentmentUrl: baseURL + submitRepresentmentPath,
 		CaptureUrl:             baseURL + capturePath,
 		AuthUrl:                baseURL + authPath,
 		VoidUrl:                baseURL + voidPath,
+		TransactionsUrl:        baseURL + transactionsPath,
 		HTTP:                   httpClient,
+		Timeouts:               DefaultTimeouts(),
+		Retry:                  resilience.DefaultRetryConfig(),
+		Breaker:                NewBreaker(DefaultName, resilience.DefaultBreakerConfig()),
+	}
+}
+
+// send issues one logical provider call and returns the 2xx response body. body is
+// JSON-encoded when non-nil; creds, when non-nil, and idempotencyKey, when not empty,
+// are set on every attempt so retries replay the first outcome.
+func (c *Client) send(ctx context.Context, op operation, method, url string, creds *gateway.Credentials, idempotencyKey string, body any) ([]byte, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshal %s request: %w", op, err)
+		}
+	}
+
+	var raw []byte
+	err := c.call(ctx, op, func(ctx context.Context) error {
+		var reader io.Reader
+		if payload != nil {
+			reader = bytes.NewReader(payload)
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, method, url, reader)
+		if err != nil {
+			return fmt.Errorf("create %s request: %w", op, err)
+		}
+		if payload != nil {
+			httpReq.Header.Set("Content-Type", "application/json")
+		}
+		if creds != nil {
+			setCredentials(httpReq, *creds)
+		}
+		if idempotencyKey != "" {
+			httpReq.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+		}
+
+		resp, err := c.HTTP.Do(httpReq)
+		if err != nil {
+			return fmt.Errorf("http %s request: %w", op, err)
+		}
+		defer resp.Body.Close()
+
+		raw, err = io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("read %s response: %w", op, err)
+		}
+		if resp.StatusCode/100 != 2 {
+			return &ProviderError{Op: string(op), StatusCode: resp.StatusCode, Status: resp.Status, Body: string(raw)}
+		}
+		return nil
+	})
+	return raw, err
+}
+
+// credentials resolves the provider credentials for merchantID.
+func (c *Client) credentials(ctx context.Context, merchantID string) (gateway.Credentials, error) {
+	if c.Credentials == nil || merchantID == "" {
+		return gateway.Credentials{MerchantID: merchantID}, nil
+	}
+	creds, err := c.Credentials.ProviderCredentials(ctx, merchantID)
+	if err != nil {
+		return gateway.Credentials{}, fmt.Errorf("resolve provider credentials: %w", err)
+	}
+	return creds, nil
+}
+
+func setCredentials(r *http.Request, creds gateway.Credentials) {
+	if creds.MerchantID != "" {
+		r.Header.Set("X-Merchant-ID", creds.MerchantID)
+	}
+	if creds.APIKey != "" {
+		r.Header.Set("Authorization", "Bearer "+creds.APIKey)
 	}
 }
 
@@ -52,21 +156,27 @@ type authReq struct {
 }
 
 type authResp struct {
-	TransactionID string `json:"transaction_id"`
-	Status        string `json:"status"`
-	OrderID       string `json:"order_id"`
-	DeclineReason string `json:"decline_reason,omitempty"`
+	TransactionID   string `json:"transaction_id"`
+	Status          string `json:"status"`
+	OrderID         string `json:"order_id"`
+	DeclineReason   string `json:"decline_reason,omitempty"`
+	CardFingerprint string `json:"card_fingerprint,omitempty"`
+	ChallengeID     string `json:"challenge_id,omitempty"`
+	ChallengeURL    string `json:"challenge_url,omitempty"`
 }
 
 type captureReq struct {
 	TransactionID  string `json:"transaction_id"`
 	Amount         int64  `json:"amount"`
+	FinalCapture   bool   `json:"final_capture"`
 	IdempotencyKey string `json:"idempotency_key"`
 }
 
 type captureResp struct {
-	TransactionID string `json:"transaction_id"`
-	Status        string `json:"status"`
+	TransactionID  string `json:"transaction_id"`
+	CaptureID      string `json:"capture_id"`
+	Status         string `json:"status"`
+	CapturedAmount int64  `json:"captured_amount"`
 }
 
 // todo: Integration tests with wiremock: validation, timeout
@@ -81,32 +191,14 @@ func (c *Client) SubmitRepresentment(ctx context.Context, req gateway.Representm
 		body.EvidencesFileID = append(body.EvidencesFileID, f.FileID)
 	}
 
-	j, err := json.Marshal(body)
+	creds, err := c.credentials(ctx, req.MerchantID)
 	if err != nil {
-		return gateway.RepresentmentResult{
-			ProviderSubmissionID: "",
-		}, fmt.Errorf("marshal: %w", err)
+		return gateway.RepresentmentResult{}, err
 	}
 
-	httpReq, _ := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		c.SubmitRepresentmentUrl,
-		bytes.NewReader(j),
-	)
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
+	raw, err := c.send(ctx, opRepresentment, http.MethodPost, c.SubmitRepresentmentUrl, &creds, "", body)
 	if err != nil {
-		return gateway.RepresentmentResult{}, fmt.Errorf("http: %w", err)
-	}
-	defer resp.Body.Close()
-	raw, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode/100 != 2 {
-		return gateway.RepresentmentResult{
-			ProviderSubmissionID: "",
-		}, fmt.Errorf("provider %s: %s", resp.Status, string(raw))
+		return gateway.RepresentmentResult{}, err
 	}
 
 	var out createResp
@@ -118,40 +210,23 @@ func (c *Client) SubmitRepresentment(ctx context.Context, req gateway.Representm
 }
 
 func (c *Client) CapturePayment(ctx context.Context, req gateway.CaptureRequest) (gateway.CaptureResult, error) {
-	body := captureReq{
-		TransactionID:  req.OrderID,
-		Amount:         int64(req.Amount),
-		IdempotencyKey: req.IdempotencyKey,
-	}
-
-	j, err := json.Marshal(body)
+	creds, err := c.credentials(ctx, req.MerchantID)
 	if err != nil {
-		return gateway.CaptureResult{}, fmt.Errorf("marshal capture request: %w", err)
+		return gateway.CaptureResult{}, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(
-		ctx,
-		http.MethodPost,
-		c.CaptureUrl,
-		bytes.NewReader(j),
-	)
-	if err != nil {
-		return gateway.CaptureResult{}, fmt.Errorf("create capture request: %w", err)
+	body := captureReq{
+		TransactionID:  req.OrderID,
+		Amount:         req.Amount,
+		FinalCapture:   req.Final,
+		IdempotencyKey: req.IdempotencyKey,
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.HTTP.Do(httpReq)
+	raw, err := c.send(ctx, opCapture, http.MethodPost, c.CaptureUrl, &creds, req.IdempotencyKey, body)
 	if err != nil {
-		return gateway.CaptureResult{}, fmt.Errorf("http capture request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	raw, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode/100 != 2 {
 		return gateway.CaptureResult{
 			Status: gateway.CaptureStatusFailed,
-		}, fmt.Errorf("capture provider %s: %s", resp.Status, string(raw))
+		}, err
 	}
 
 	var out captureResp
@@ -165,41 +240,34 @@ func (c *Client) CapturePayment(ctx context.Context, req gateway.CaptureRequest)
 	}
 
 	return gateway.CaptureResult{
-		ProviderTxID: out.TransactionID,
-		Status:       status,
+		ProviderTxID:   out.TransactionID,
+		CaptureID:      out.CaptureID,
+		CapturedAmount: out.CapturedAmount,
+		Status:         status,
 	}, nil
 }
 
 func (c *Client) AuthorizePayment(ctx context.Context, req gateway.AuthRequest) (gateway.AuthResult, error) {
+	creds, err := c.credentials(ctx, req.MerchantID)
+	if err != nil {
+		return gateway.AuthResult{}, err
+	}
+
 	body := authReq{
-		MerchantID: req.MerchantID,
+		MerchantID: creds.MerchantID,
 		OrderID:    req.OrderID,
 		Amount:     req.Amount,
-		Currency:   req.Currency,
+		Currency:   req.Currency.String(),
 		CardToken:  req.CardToken,
 	}
 
-	j, err := json.Marshal(body)
-	if err != nil {
-		return gateway.AuthResult{}, fmt.Errorf("marshal auth request: %w", err)
+	raw, err := c.send(ctx, opAuth, http.MethodPost, c.AuthUrl, &creds, "auth_"+req.OrderID, body)
+	var pe *ProviderError
+	if errors.As(err, &pe) && pe.StatusCode == http.StatusUnprocessableEntity {
+		return gateway.AuthResult{}, fmt.Errorf("%w: %w", gateway.ErrUnknownCard, err)
 	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.AuthUrl, bytes.NewReader(j))
 	if err != nil {
-		return gateway.AuthResult{}, fmt.Errorf("create auth request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
-	if err != nil {
-		return gateway.AuthResult{}, fmt.Errorf("http auth request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	raw, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode/100 != 2 {
-		return gateway.AuthResult{}, fmt.Errorf("auth provider %s: %s", resp.Status, string(raw))
+		return gateway.AuthResult{}, err
 	}
 
 	var out authResp
@@ -208,43 +276,47 @@ func (c *Client) AuthorizePayment(ctx context.Context, req gateway.AuthRequest)
 	}
 
 	status := gateway.AuthStatusDeclined
-	if out.Status == "authorized" {
+	switch out.Status {
+	case "authorized":
 		status = gateway.AuthStatusAuthorized
+	case "requires_action":
+		status = gateway.AuthStatusRequiresAction
 	}
 
 	return gateway.AuthResult{
-		TransactionID: out.TransactionID,
-		Status:        status,
-		DeclineReason: out.DeclineReason,
+		TransactionID:   out.TransactionID,
+		Status:          status,
+		DeclineReason:   out.DeclineReason,
+		CardFingerprint: out.CardFingerprint,
+		ChallengeID:     out.ChallengeID,
+		ChallengeURL:    out.ChallengeURL,
 	}, nil
 }
 
+// transactionStatusVoided is Silvergate's status of a voided transaction.
+const transactionStatusVoided = "voided"
+
 func (c *Client) VoidPayment(ctx context.Context, req gateway.VoidRequest) (gateway.VoidResult, error) {
+	creds, err := c.credentials(ctx, req.MerchantID)
+	if err != nil {
+		return gateway.VoidResult{}, err
+	}
+
 	body := struct {
 		TransactionID string `json:"transaction_id"`
 	}{TransactionID: req.TransactionID}
 
-	j, err := json.Marshal(body)
-	if err != nil {
-		return gateway.VoidResult{}, fmt.Errorf("marshal void request: %w", err)
+	raw, err := c.send(ctx, opVoid, http.MethodPost, c.VoidUrl, &creds, "void_"+req.TransactionID, body)
+	var pe *ProviderError
+	if errors.As(err, &pe) && pe.StatusCode == http.StatusConflict && !pe.inProgress() {
+		// The transaction is no longer authorized. If it was voided, by an earlier attempt
+		// whose response was lost or by the provider, the void has the outcome we asked for.
+		if voided, qerr := c.voided(ctx, &creds, req.TransactionID); qerr == nil && voided {
+			return gateway.VoidResult{TransactionID: req.TransactionID, Status: transactionStatusVoided}, nil
+		}
 	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.VoidUrl, bytes.NewReader(j))
-	if err != nil {
-		return gateway.VoidResult{}, fmt.Errorf("create void request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
 	if err != nil {
-		return gateway.VoidResult{}, fmt.Errorf("http void request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	raw, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode/100 != 2 {
-		return gateway.VoidResult{}, fmt.Errorf("void provider %s: %s", resp.Status, string(raw))
+		return gateway.VoidResult{}, err
 	}
 
 	var out struct {
@@ -262,6 +334,11 @@ func (c *Client) VoidPayment(ctx context.Context, req gateway.VoidRequest) (gate
 }
 
 func (c *Client) RefundPayment(ctx context.Context, req gateway.RefundRequest) (gateway.RefundResult, error) {
+	creds, err := c.credentials(ctx, req.MerchantID)
+	if err != nil {
+		return gateway.RefundResult{}, err
+	}
+
 	body := struct {
 		TransactionID  string `json:"transaction_id"`
 		Amount         int64  `json:"amount"`
@@ -272,27 +349,9 @@ func (c *Client) RefundPayment(ctx context.Context, req gateway.RefundRequest) (
 		IdempotencyKey: req.IdempotencyKey,
 	}
 
-	j, err := json.Marshal(body)
-	if err != nil {
-		return gateway.RefundResult{}, fmt.Errorf("marshal refund request: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/v1/refund", bytes.NewReader(j))
-	if err != nil {
-		return gateway.RefundResult{}, fmt.Errorf("create refund request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTP.Do(httpReq)
+	raw, err := c.send(ctx, opRefund, http.MethodPost, c.BaseURL+"/api/v1/refund", &creds, req.IdempotencyKey, body)
 	if err != nil {
-		return gateway.RefundResult{}, fmt.Errorf("http refund request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	raw, _ := io.ReadAll(resp.Body)
-
-	if resp.StatusCode/100 != 2 {
-		return gateway.RefundResult{}, fmt.Errorf("refund provider %s: %s", resp.Status, string(raw))
+		return gateway.RefundResult{}, err
 	}
 
 	var out struct {
@@ -312,3 +371,82 @@ func (c *Client) RefundPayment(ctx context.Context, req gateway.RefundRequest) (
 		Status:        out.Status,
 	}, nil
 }
+
+type transactionsResp struct {
+	Items []struct {
+		TransactionID  string    `json:"transaction_id"`
+		Status         string    `json:"status"`
+		Amount         int64     `json:"amount"`
+		CapturedAmount int64     `json:"captured_amount"`
+		RefundedAmount int64     `json:"refunded_amount"`
+		Currency       string    `json:"currency"`
+		UpdatedAt      time.Time `json:"updated_at"`
+		Refunds        []struct {
+			RefundID  string    `json:"refund_id"`
+			Amount    int64     `json:"amount"`
+			Status    string    `json:"status"`
+			UpdatedAt time.Time `json:"updated_at"`
+		} `json:"refunds"`
+	} `json:"items"`
+}
+
+// voided reports whether the provider has transactionID in the voided state.
+func (c *Client) voided(ctx context.Context, creds *gateway.Credentials, transactionID string) (bool, error) {
+	states, err := c.queryTransactions(ctx, creds, []string{transactionID})
+	if err != nil {
+		return false, err
+	}
+	return len(states) == 1 && states[0].Status == transactionStatusVoided, nil
+}
+
+// QueryTransactions fetches the current provider state of merchantID's transactions.
+// Transactions unknown to the provider or belonging to another merchant are absent from
+// the result.
+func (c *Client) QueryTransactions(ctx context.Context, merchantID string, transactionIDs []string) ([]gateway.TransactionState, error) {
+	creds, err := c.credentials(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	return c.queryTransactions(ctx, &creds, transactionIDs)
+}
+
+func (c *Client) queryTransactions(ctx context.Context, creds *gateway.Credentials, transactionIDs []string) ([]gateway.TransactionState, error) {
+	q := url.Values{}
+	for _, id := range transactionIDs {
+		q.Add("id", id)
+	}
+
+	raw, err := c.send(ctx, opTransactions, http.MethodGet, c.TransactionsUrl+"?"+q.Encode(), creds, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var out transactionsResp
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("unmarshal transactions response: %w", err)
+	}
+
+	states := make([]gateway.TransactionState, 0, len(out.Items))
+	for _, it := range out.Items {
+		st := gateway.TransactionState{
+			TransactionID:  it.TransactionID,
+			Status:         it.Status,
+			Amount:         it.Amount,
+			CapturedAmount: it.CapturedAmount,
+			RefundedAmount: it.RefundedAmount,
+			Currency:       it.Currency,
+			UpdatedAt:      it.UpdatedAt,
+			Refunds:        make([]gateway.RefundState, 0, len(it.Refunds)),
+		}
+		for _, rf := range it.Refunds {
+			st.Refunds = append(st.Refunds, gateway.RefundState{
+				RefundID:  rf.RefundID,
+				Amount:    rf.Amount,
+				Status:    rf.Status,
+				UpdatedAt: rf.UpdatedAt,
+			})
+		}
+		states = append(states, st)
+	}
+	return states, nil
+}
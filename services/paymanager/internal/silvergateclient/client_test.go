@@ -0,0 +1,279 @@
+package silvergateclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/pkg/money"
+	"TestTaskJustPay/pkg/resilience"
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := New(srv.URL, "/representments", "/capture", "/auth", "/void", "/transactions", srv.Client())
+	c.Retry = resilience.RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+	c.Breaker = NewBreaker("silvergate", resilience.BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	return c, &calls
+}
+
+var testCaptureReq = gateway.CaptureRequest{
+	MerchantID:     "merchant_1",
+	OrderID:        "tx-1",
+	Money:          money.Money{Amount: 1000, Currency: "USD"},
+	IdempotencyKey: "capture_1",
+}
+
+func TestClient_RetriesIdempotentOperations(t *testing.T) {
+	var failures atomic.Int32
+	var keys []string
+	c, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if failures.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"transaction_id":"tx-1","status":"success","captured_amount":1000}`))
+	})
+
+	res, err := c.CapturePayment(context.Background(), testCaptureReq)
+
+	require.NoError(t, err)
+	assert.Equal(t, gateway.CaptureStatusSuccess, res.Status)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, []string{"capture_1", "capture_1"}, keys, "every attempt carries the same key")
+}
+
+func TestClient_SendsIdempotencyKeyOnMutatingCalls(t *testing.T) {
+	keys := map[string]string{}
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		keys[r.URL.Path] = r.Header.Get(IdempotencyKeyHeader)
+		_, _ = w.Write([]byte(`{"status":"authorized"}`))
+	})
+	ctx := context.Background()
+	amount := money.Money{Amount: 1000, Currency: "USD"}
+
+	_, err := c.AuthorizePayment(ctx, gateway.AuthRequest{MerchantID: "merchant_1", OrderID: "o-1", Money: amount, CardToken: "tok_1"})
+	require.NoError(t, err)
+	_, err = c.VoidPayment(ctx, gateway.VoidRequest{MerchantID: "merchant_1", TransactionID: "tx-1"})
+	require.NoError(t, err)
+	_, err = c.RefundPayment(ctx, gateway.RefundRequest{MerchantID: "merchant_1", TransactionID: "tx-1", Money: amount, IdempotencyKey: "refund_1"})
+	require.NoError(t, err)
+	_, err = c.CapturePayment(ctx, testCaptureReq)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"/auth":          "auth_o-1",
+		"/void":          "void_tx-1",
+		"/api/v1/refund": "refund_1",
+		"/capture":       "capture_1",
+	}, keys)
+}
+
+func TestClient_DoesNotRetryRejectedRequests(t *testing.T) {
+	c, calls := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	_, err := c.CapturePayment(context.Background(), testCaptureReq)
+
+	var pe *ProviderError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, http.StatusBadRequest, pe.StatusCode)
+	assert.ErrorIs(t, err, gateway.ErrRequestRejected)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, resilience.StateClosed, c.Breaker.State(), "4xx must not count against the breaker")
+}
+
+func TestClient_DoesNotRetryAuthorization(t *testing.T) {
+	c, calls := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := c.AuthorizePayment(context.Background(), gateway.AuthRequest{
+		MerchantID: "merchant_1",
+		Money:      money.Money{Amount: 1000, Currency: "USD"},
+	})
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, gateway.ErrProviderUnavailable, "a 5xx may come after the provider authorized")
+	assert.NotErrorIs(t, err, gateway.ErrRequestRejected)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestClient_ConnectionRefusedIsUnavailable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	c := New(url, "/representments", "/capture", "/auth", "/void", "/transactions", http.DefaultClient)
+	_, err := c.AuthorizePayment(context.Background(), gateway.AuthRequest{
+		MerchantID: "merchant_1",
+		Money:      money.Money{Amount: 1000, Currency: "USD"},
+	})
+
+	assert.ErrorIs(t, err, gateway.ErrProviderUnavailable)
+}
+
+func TestClient_BreakerFailsFast(t *testing.T) {
+	c, calls := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	_, err := c.CapturePayment(context.Background(), testCaptureReq)
+	require.Error(t, err)
+	assert.Equal(t, int32(2), calls.Load(), "breaker opens after the threshold and stops the retries")
+	assert.Equal(t, resilience.StateOpen, c.Breaker.State())
+
+	_, err = c.VoidPayment(context.Background(), gateway.VoidRequest{MerchantID: "merchant_1", TransactionID: "tx-1"})
+	assert.True(t, errors.Is(err, resilience.ErrCircuitOpen))
+	assert.True(t, errors.Is(err, gateway.ErrProviderUnavailable))
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, "down", string(c.Breaker.Check(context.Background()).Status))
+}
+
+func TestClient_PerOperationTimeout(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(300 * time.Millisecond):
+		}
+	})
+	c.Timeouts.Auth = 20 * time.Millisecond
+
+	start := time.Now()
+	_, err := c.AuthorizePayment(context.Background(), gateway.AuthRequest{
+		MerchantID: "merchant_1",
+		Money:      money.Money{Amount: 1000, Currency: "USD"},
+	})
+
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, gateway.ErrProviderUnavailable, "a timed out authorization may have succeeded")
+	assert.Less(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestClient_AuthorizeReturnsCardFingerprint(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"transaction_id":"tx-1","status":"authorized","order_id":"o-1","card_fingerprint":"fp_1"}`))
+	})
+
+	res, err := c.AuthorizePayment(context.Background(), gateway.AuthRequest{
+		MerchantID: "merchant_1",
+		OrderID:    "o-1",
+		Money:      money.Money{Amount: 1000, Currency: "USD"},
+		CardToken:  "tok_1",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, gateway.AuthStatusAuthorized, res.Status)
+	assert.Equal(t, "fp_1", res.CardFingerprint)
+}
+
+func TestClient_AuthorizeRequiresAction(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"transaction_id":"tx-1","status":"requires_action","order_id":"o-1",` +
+			`"challenge_id":"ch-1","challenge_url":"http://sg/api/v1/challenges/ch-1"}`))
+	})
+
+	res, err := c.AuthorizePayment(context.Background(), gateway.AuthRequest{
+		MerchantID: "merchant_1",
+		OrderID:    "o-1",
+		Money:      money.Money{Amount: 1000, Currency: "USD"},
+		CardToken:  "tok_1",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, gateway.AuthStatusRequiresAction, res.Status)
+	assert.Equal(t, "ch-1", res.ChallengeID)
+	assert.Equal(t, "http://sg/api/v1/challenges/ch-1", res.ChallengeURL)
+}
+
+func TestClient_AuthorizeUnknownCard(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"error":"unknown card token","code":"card_not_found"}`))
+	})
+
+	_, err := c.AuthorizePayment(context.Background(), gateway.AuthRequest{
+		MerchantID: "merchant_1",
+		OrderID:    "o-1",
+		Money:      money.Money{Amount: 1000, Currency: "USD"},
+		CardToken:  "tok_unknown",
+	})
+
+	assert.ErrorIs(t, err, gateway.ErrUnknownCard)
+	assert.NotErrorIs(t, err, gateway.ErrProviderUnavailable)
+}
+
+func TestClient_RetriesVoidInProgress(t *testing.T) {
+	var voids atomic.Int32
+	c, calls := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "void_tx-1", r.Header.Get(IdempotencyKeyHeader))
+		if voids.Add(1) == 1 {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"a request with this Idempotency-Key is in progress"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"transaction_id":"tx-1","status":"voided"}`))
+	})
+
+	res, err := c.VoidPayment(context.Background(), gateway.VoidRequest{MerchantID: "merchant_1", TransactionID: "tx-1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "voided", res.Status)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestClient_VoidOfVoidedTransactionSucceeds(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/void":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"transaction cannot be voided in current state"}`))
+		case "/transactions":
+			assert.Equal(t, "tx-1", r.URL.Query().Get("id"))
+			_, _ = w.Write([]byte(`{"items":[{"transaction_id":"tx-1","status":"voided"}]}`))
+		}
+	})
+
+	res, err := c.VoidPayment(context.Background(), gateway.VoidRequest{MerchantID: "merchant_1", TransactionID: "tx-1"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "tx-1", res.TransactionID)
+	assert.Equal(t, "voided", res.Status)
+}
+
+func TestClient_VoidOfCapturedTransactionIsRejected(t *testing.T) {
+	c, _ := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/void":
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"transaction cannot be voided in current state"}`))
+		case "/transactions":
+			_, _ = w.Write([]byte(`{"items":[{"transaction_id":"tx-1","status":"captured"}]}`))
+		}
+	})
+
+	_, err := c.VoidPayment(context.Background(), gateway.VoidRequest{MerchantID: "merchant_1", TransactionID: "tx-1"})
+
+	var pe *ProviderError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, http.StatusConflict, pe.StatusCode)
+}
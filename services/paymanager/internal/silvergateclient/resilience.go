@@ -0,0 +1,197 @@
+package silvergateclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"TestTaskJustPay/pkg/resilience"
+	"TestTaskJustPay/services/paymanager/internal/gateway"
+)
+
+type operation string
+
+const (
+	opAuth          operation = "auth"
+	opCapture       operation = "capture"
+	opVoid          operation = "void"
+	opRefund        operation = "refund"
+	opRepresentment operation = "representment"
+	opTransactions  operation = "transactions"
+)
+
+// idempotent operations are safe to retry: captures, refunds and voids carry idempotency
+// keys and transaction queries do not change state.
+func (op operation) idempotent() bool {
+	switch op {
+	case opCapture, opRefund, opVoid, opTransactions:
+		return true
+	default:
+		return false
+	}
+}
+
+// Timeouts bounds each attempt of a provider call, per operation.
+type Timeouts struct {
+	Auth          time.Duration
+	Capture       time.Duration
+	Void          time.Duration
+	Refund        time.Duration
+	Representment time.Duration
+	Transactions  time.Duration
+}
+
+// DefaultTimeouts returns the per-operation timeouts used when none are configured.
+func DefaultTimeouts() Timeouts {
+	return Timeouts{
+		Auth:          10 * time.Second,
+		Capture:       10 * time.Second,
+		Void:          10 * time.Second,
+		Refund:        10 * time.Second,
+		Representment: 20 * time.Second,
+		Transactions:  15 * time.Second,
+	}
+}
+
+func (t Timeouts) of(op operation) time.Duration {
+	switch op {
+	case opAuth:
+		return t.Auth
+	case opCapture:
+		return t.Capture
+	case opVoid:
+		return t.Void
+	case opRefund:
+		return t.Refund
+	case opRepresentment:
+		return t.Representment
+	case opTransactions:
+		return t.Transactions
+	default:
+		return 0
+	}
+}
+
+// ProviderError is a non-2xx response from Silvergate.
+type ProviderError struct {
+	Op         string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s provider %s: %s", e.Op, e.Status, e.Body)
+}
+
+// serverSide reports whether the response says Silvergate itself is struggling.
+func (e *ProviderError) serverSide() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// rejected reports whether Silvergate refused the request without acting on it. A timeout,
+// an idempotency conflict or throttling says nothing about the outcome.
+func (e *ProviderError) rejected() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return false
+	}
+	return e.StatusCode >= 400 && e.StatusCode < 500
+}
+
+// inProgressError is the error Silvergate answers with while the first request under the
+// same idempotency key is still running.
+const inProgressError = "a request with this Idempotency-Key is in progress"
+
+// inProgress reports whether Silvergate turned a retry away because the first attempt is
+// still running. A later retry replays that attempt's outcome.
+func (e *ProviderError) inProgress() bool {
+	if e.StatusCode != http.StatusConflict {
+		return false
+	}
+	var body struct {
+		Error string `json:"error"`
+	}
+	return json.Unmarshal([]byte(e.Body), &body) == nil && body.Error == inProgressError
+}
+
+// isRetryable retries transport errors, timeouts, server-side responses and requests
+// still in progress under their idempotency key. Rejected requests and an open breaker
+// are returned immediately.
+func isRetryable(err error) bool {
+	if errors.Is(err, resilience.ErrCircuitOpen) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		return pe.serverSide() || pe.inProgress()
+	}
+	return true
+}
+
+// isProviderFailure decides what counts against the circuit breaker: a 4xx is the
+// caller's problem and a cancelled caller says nothing about Silvergate.
+func isProviderFailure(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		return pe.serverSide()
+	}
+	return true
+}
+
+// unavailable reports whether err guarantees Silvergate did not process the request:
+// the breaker refused the call, or the connection was never established. A 5xx, a timeout
+// or a broken read may come after the provider acted on the request, so they are not.
+func unavailable(err error) bool {
+	if errors.Is(err, resilience.ErrCircuitOpen) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial" && !opErr.Timeout()
+}
+
+// NewBreaker returns the circuit breaker guarding calls to one Silvergate instance.
+// It is also a health.Checker reporting under name.
+func NewBreaker(name string, cfg resilience.BreakerConfig) *resilience.CircuitBreaker {
+	cfg.IsFailure = isProviderFailure
+	return resilience.NewCircuitBreaker(name, cfg)
+}
+
+// call runs fn under the per-operation timeout and the circuit breaker, retrying
+// idempotent operations with jittered backoff.
+func (c *Client) call(ctx context.Context, op operation, fn func(ctx context.Context) error) error {
+	attempt := func(ctx context.Context) error {
+		if timeout := c.Timeouts.of(op); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		if c.Breaker == nil {
+			return fn(ctx)
+		}
+		return c.Breaker.Execute(ctx, fn)
+	}
+
+	var err error
+	if op.idempotent() {
+		err = resilience.Retry(ctx, c.Retry, isRetryable, attempt)
+	} else {
+		err = attempt(ctx)
+	}
+	if err != nil && unavailable(err) {
+		return fmt.Errorf("%w: %w", gateway.ErrProviderUnavailable, err)
+	}
+	var pe *ProviderError
+	if errors.As(err, &pe) && pe.rejected() {
+		return fmt.Errorf("%w: %w", gateway.ErrRequestRejected, err)
+	}
+	return err
+}
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS capture_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS capture_locked_until TIMESTAMPTZ;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS capture_submitted_at TIMESTAMPTZ;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS capture_error TEXT;
+
+-- Captures that were in flight under the old in-process goroutines have no lease.
+-- Give them an already-expired one so the scheduler's recovery path picks them up.
+UPDATE payments SET capture_locked_until = updated_at
+WHERE status = 'capture_pending' AND capture_locked_until IS NULL;
+
+CREATE INDEX idx_payments_capture_due ON payments(capture_at)
+    WHERE status = 'authorized' AND capture_at IS NOT NULL;
+CREATE INDEX idx_payments_capture_lease ON payments(capture_locked_until)
+    WHERE status = 'capture_pending' AND capture_submitted_at IS NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_payments_capture_lease;
+DROP INDEX IF EXISTS idx_payments_capture_due;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS capture_error;
+ALTER TABLE payments DROP COLUMN IF EXISTS capture_submitted_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS capture_locked_until;
+ALTER TABLE payments DROP COLUMN IF EXISTS capture_attempts;
+
+-- +goose StatementEnd
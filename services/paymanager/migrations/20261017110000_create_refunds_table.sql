@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE refunds (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id          UUID NOT NULL REFERENCES payments(id),
+    amount              BIGINT NOT NULL CHECK (amount > 0),
+    currency            TEXT NOT NULL CHECK (length(currency) = 3),
+    status              TEXT NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+    reason              TEXT,
+    provider_refund_id  TEXT,
+    failure_reason      TEXT,
+    idempotency_key     TEXT NOT NULL,
+    created_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at          TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (payment_id, idempotency_key)
+);
+
+CREATE INDEX idx_refunds_payment_id ON refunds(payment_id, created_at);
+CREATE UNIQUE INDEX idx_refunds_provider_refund_id ON refunds(provider_refund_id) WHERE provider_refund_id IS NOT NULL;
+CREATE INDEX idx_refunds_pending ON refunds(payment_id) WHERE status = 'pending';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS refunds;
+
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS captured_amount BIGINT NOT NULL DEFAULT 0
+    CONSTRAINT chk_payments_captured_amount_range CHECK (captured_amount >= 0 AND captured_amount <= amount);
+
+UPDATE payments SET captured_amount = amount
+WHERE status IN ('captured', 'partially_refunded', 'refunded');
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'partially_captured', 'captured', 'capture_failed',
+                      'voided', 'partially_refunded', 'refunded'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'captured', 'capture_failed', 'voided', 'partially_refunded', 'refunded'));
+
+ALTER TABLE payments DROP COLUMN IF EXISTS captured_amount;
+
+-- +goose StatementEnd
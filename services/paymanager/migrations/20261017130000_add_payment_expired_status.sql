@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'partially_captured', 'captured', 'capture_failed',
+                      'voided', 'expired', 'partially_refunded', 'refunded'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+UPDATE payments SET status = 'voided' WHERE status = 'expired';
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'partially_captured', 'captured', 'capture_failed',
+                      'voided', 'partially_refunded', 'refunded'));
+
+-- +goose StatementEnd
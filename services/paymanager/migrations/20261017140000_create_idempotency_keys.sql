@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE idempotency_keys (
+    merchant_id     TEXT NOT NULL,
+    key             TEXT NOT NULL,
+    endpoint        TEXT NOT NULL,
+    request_hash    TEXT NOT NULL,
+    response_status INTEGER,
+    response_body   BYTEA,
+    locked_at       TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (merchant_id, key)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS idempotency_keys;
+
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS card_fingerprint TEXT;
+
+UPDATE payments SET card_fingerprint = encode(sha256(card_token::bytea), 'hex')
+WHERE card_fingerprint IS NULL;
+
+ALTER TABLE payments ALTER COLUMN card_fingerprint SET NOT NULL;
+
+-- Keyset pagination over (created_at, id) in both directions.
+CREATE INDEX IF NOT EXISTS idx_payments_created_at_id ON payments(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_payments_card_fingerprint ON payments(card_fingerprint, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_payments_card_fingerprint;
+DROP INDEX IF EXISTS idx_payments_created_at_id;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS card_fingerprint;
+
+-- +goose StatementEnd
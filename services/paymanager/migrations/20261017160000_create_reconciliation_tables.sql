@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- reconciled_at rotates candidates so every open payment is eventually compared with the provider.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS reconciled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_payments_reconcile ON payments(reconciled_at NULLS FIRST)
+    WHERE provider_tx_id IS NOT NULL
+      AND status IN ('authorized', 'capture_pending', 'partially_captured', 'captured', 'partially_refunded');
+
+CREATE TABLE reconciliation_runs (
+    id            UUID PRIMARY KEY,
+    started_at    TIMESTAMPTZ NOT NULL,
+    finished_at   TIMESTAMPTZ,
+    checked       INT NOT NULL DEFAULT 0,
+    fixed         INT NOT NULL DEFAULT 0,
+    manual_review INT NOT NULL DEFAULT 0,
+    error         TEXT
+);
+
+CREATE INDEX idx_reconciliation_runs_started_at ON reconciliation_runs(started_at DESC);
+
+CREATE TABLE reconciliation_items (
+    id             UUID PRIMARY KEY,
+    run_id         UUID NOT NULL REFERENCES reconciliation_runs(id) ON DELETE CASCADE,
+    payment_id     UUID NOT NULL,
+    provider_tx_id TEXT NOT NULL,
+    kind           TEXT NOT NULL CHECK (kind IN ('fixed', 'manual_review')),
+    field          TEXT NOT NULL,
+    local_value    TEXT NOT NULL,
+    provider_value TEXT NOT NULL,
+    detail         TEXT,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_reconciliation_items_run_id ON reconciliation_items(run_id);
+CREATE INDEX idx_reconciliation_items_payment_id ON reconciliation_items(payment_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS reconciliation_items;
+DROP TABLE IF EXISTS reconciliation_runs;
+DROP INDEX IF EXISTS idx_payments_reconcile;
+ALTER TABLE payments DROP COLUMN IF EXISTS reconciled_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,65 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE merchants (
+    id                            TEXT PRIMARY KEY,
+    name                          TEXT NOT NULL,
+    status                        TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'disabled')),
+    -- SHA-256 of the merchant's API key; merchants without a key authenticate by header only.
+    api_key_hash                  TEXT UNIQUE,
+    provider_merchant_id          TEXT NOT NULL UNIQUE,
+    provider_api_key              TEXT,
+    default_capture_delay_seconds BIGINT NOT NULL DEFAULT 0 CHECK (default_capture_delay_seconds >= 0),
+    created_at                    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at                    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- merchant_1 is the single merchant paymanager served before multi-merchant support.
+INSERT INTO merchants (id, name, provider_merchant_id)
+VALUES ('merchant_1', 'Default merchant', 'merchant_1')
+ON CONFLICT (id) DO NOTHING;
+
+INSERT INTO merchants (id, name, provider_merchant_id)
+SELECT DISTINCT merchant_id, merchant_id, merchant_id FROM payments
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE payments
+    ADD CONSTRAINT fk_payments_merchant FOREIGN KEY (merchant_id) REFERENCES merchants(id);
+
+CREATE INDEX IF NOT EXISTS idx_payments_merchant_created_at ON payments(merchant_id, created_at DESC, id DESC);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS merchant_id TEXT;
+UPDATE orders SET merchant_id = 'merchant_1' WHERE merchant_id IS NULL;
+ALTER TABLE orders
+    ALTER COLUMN merchant_id SET NOT NULL,
+    ADD CONSTRAINT fk_orders_merchant FOREIGN KEY (merchant_id) REFERENCES merchants(id);
+
+CREATE INDEX IF NOT EXISTS idx_orders_merchant_created_at ON orders(merchant_id, created_at);
+
+ALTER TABLE disputes ADD COLUMN IF NOT EXISTS merchant_id TEXT;
+UPDATE disputes d SET merchant_id = o.merchant_id FROM orders o WHERE o.id = d.order_id AND d.merchant_id IS NULL;
+ALTER TABLE disputes
+    ALTER COLUMN merchant_id SET NOT NULL,
+    ADD CONSTRAINT fk_disputes_merchant FOREIGN KEY (merchant_id) REFERENCES merchants(id);
+
+CREATE INDEX IF NOT EXISTS idx_disputes_merchant_opened_at ON disputes(merchant_id, opened_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_disputes_merchant_opened_at;
+ALTER TABLE disputes DROP CONSTRAINT IF EXISTS fk_disputes_merchant;
+ALTER TABLE disputes DROP COLUMN IF EXISTS merchant_id;
+
+DROP INDEX IF EXISTS idx_orders_merchant_created_at;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS fk_orders_merchant;
+ALTER TABLE orders DROP COLUMN IF EXISTS merchant_id;
+
+DROP INDEX IF EXISTS idx_payments_merchant_created_at;
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS fk_payments_merchant;
+
+DROP TABLE IF EXISTS merchants;
+
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Amounts are stored as integer minor units of the row's currency (see pkg/money).
+ALTER TABLE disputes ALTER COLUMN amount TYPE BIGINT
+    USING round(amount * power(10, CASE
+        WHEN currency IN ('BIF','CLP','DJF','GNF','ISK','JPY','KMF','KRW','PYG','RWF','UGX','UYI','VND','VUV','XAF','XOF','XPF') THEN 0
+        WHEN currency IN ('BHD','IQD','JOD','KWD','LYD','OMR','TND') THEN 3
+        WHEN currency IN ('CLF','UYW') THEN 4
+        ELSE 2
+    END))::BIGINT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- NUMERIC without a fixed scale keeps sub-cent minor units of 3- and 4-exponent currencies.
+ALTER TABLE disputes ALTER COLUMN amount TYPE NUMERIC
+    USING amount / power(10, CASE
+        WHEN currency IN ('BIF','CLP','DJF','GNF','ISK','JPY','KMF','KRW','PYG','RWF','UGX','UYI','VND','VUV','XAF','XOF','XPF') THEN 0
+        WHEN currency IN ('BHD','IQD','JOD','KWD','LYD','OMR','TND') THEN 3
+        WHEN currency IN ('CLF','UYW') THEN 4
+        ELSE 2
+    END)::NUMERIC;
+
+-- +goose StatementEnd
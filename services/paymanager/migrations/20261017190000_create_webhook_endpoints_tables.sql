@@ -0,0 +1,72 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Merchant endpoints subscribed to outbound payment notifications.
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id           UUID         NOT NULL,
+    merchant_id  VARCHAR(255) NOT NULL REFERENCES merchants(id),
+    url          TEXT         NOT NULL,
+    secret       TEXT         NOT NULL,
+    event_types  TEXT[]       NOT NULL,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhook_endpoints_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_merchant ON webhook_endpoints(merchant_id, created_at);
+
+-- Events from the unified events table that have already been fanned out to endpoints.
+CREATE TABLE IF NOT EXISTS webhook_dispatched_events (
+    event_id      UUID        NOT NULL,
+    dispatched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhook_dispatched_events_pk PRIMARY KEY (event_id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               UUID         NOT NULL,
+    endpoint_id      UUID         NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+    event_id         UUID         NOT NULL,
+    event_type       VARCHAR(64)  NOT NULL,
+    status           VARCHAR(16)  NOT NULL,
+    attempts         INT          NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ,
+    last_status_code INT,
+    last_error       TEXT,
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhook_deliveries_pk PRIMARY KEY (id),
+    CONSTRAINT webhook_deliveries_endpoint_event_uq UNIQUE (endpoint_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+    ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint
+    ON webhook_deliveries(endpoint_id, created_at DESC);
+
+-- Per-attempt delivery log.
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id           UUID        NOT NULL,
+    delivery_id  UUID        NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    attempt      INT         NOT NULL,
+    status_code  INT,
+    error        TEXT,
+    duration_ms  BIGINT      NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhook_delivery_attempts_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery
+    ON webhook_delivery_attempts(delivery_id, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_dispatched_events;
+DROP TABLE IF EXISTS webhook_endpoints;
+
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE payment_status_history (
+    id          UUID PRIMARY KEY,
+    payment_id  UUID NOT NULL REFERENCES payments(id),
+    -- NULL for the status the payment was created with.
+    from_status TEXT,
+    to_status   TEXT NOT NULL,
+    source      VARCHAR(32) NOT NULL,
+    reason      TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_payment_status_history_payment ON payment_status_history(payment_id, created_at);
+
+-- Earlier transitions were not recorded; seed each payment with its current status.
+INSERT INTO payment_status_history (id, payment_id, from_status, to_status, source, created_at)
+SELECT gen_random_uuid(), id, NULL, status, 'migration', updated_at FROM payments;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS payment_status_history;
+
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Payments made before provider routing were all authorized by the primary Silvergate instance.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'silvergate';
+
+-- Transaction ids are only unique per provider; webhooks are resolved by both.
+DROP INDEX IF EXISTS idx_payments_provider_tx_id;
+CREATE INDEX idx_payments_provider_tx_id ON payments(provider_tx_id, provider) WHERE provider_tx_id IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_payments_provider_tx_id;
+CREATE INDEX idx_payments_provider_tx_id ON payments(provider_tx_id) WHERE provider_tx_id IS NOT NULL;
+ALTER TABLE payments DROP COLUMN IF EXISTS provider;
+
+-- +goose StatementEnd
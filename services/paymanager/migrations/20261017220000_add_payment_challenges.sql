@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Payments whose authorization needs cardholder authentication wait in requires_action;
+-- challenge_url is where the cardholder completes it.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS challenge_url TEXT;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('requires_action', 'authorized', 'declined', 'capture_pending', 'partially_captured', 'captured',
+                      'capture_failed', 'voided', 'expired', 'partially_refunded', 'refunded'));
+
+CREATE INDEX IF NOT EXISTS idx_payments_requires_action_created_at
+    ON payments(created_at)
+    WHERE status = 'requires_action';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_payments_requires_action_created_at;
+
+UPDATE payments SET status = 'expired' WHERE status = 'requires_action';
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'partially_captured', 'captured', 'capture_failed',
+                      'voided', 'expired', 'partially_refunded', 'refunded'));
+
+ALTER TABLE payments DROP COLUMN IF EXISTS challenge_url;
+
+-- +goose StatementEnd
@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Every pre-authorization risk assessment, with the rules that matched. Velocity rules
+-- count these rows, so blocked attempts count too.
+CREATE TABLE IF NOT EXISTS risk_decisions (
+    id               UUID        NOT NULL,
+    merchant_id      TEXT        NOT NULL,
+    card_fingerprint TEXT,
+    ip               TEXT,
+    country          TEXT,
+    amount           BIGINT      NOT NULL,
+    currency         TEXT        NOT NULL,
+    outcome          TEXT        NOT NULL CHECK (outcome IN ('allow', 'review', 'block')),
+    hits             JSONB       NOT NULL DEFAULT '[]',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT risk_decisions_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_risk_decisions_card ON risk_decisions(card_fingerprint, created_at);
+CREATE INDEX IF NOT EXISTS idx_risk_decisions_ip ON risk_decisions(ip, created_at);
+CREATE INDEX IF NOT EXISTS idx_risk_decisions_merchant ON risk_decisions(merchant_id, created_at);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS risk_decision_id UUID REFERENCES risk_decisions(id);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS hold_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_payments_merchant_card_token ON payments(merchant_id, card_token, created_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_payments_merchant_card_token;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS hold_reason;
+ALTER TABLE payments DROP COLUMN IF EXISTS risk_decision_id;
+
+DROP TABLE IF EXISTS risk_decisions;
+
+-- +goose StatementEnd
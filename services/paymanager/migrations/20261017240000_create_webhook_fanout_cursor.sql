@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Single-row cursor over the events table: the (created_at, id) of the last event
+-- fanned out to webhook endpoints. Replaces the per-event dispatched marker table,
+-- which had to be scanned over a lookback window on every poll.
+CREATE TABLE IF NOT EXISTS webhook_fanout_cursor (
+    id              BOOLEAN     NOT NULL DEFAULT TRUE,
+    last_created_at TIMESTAMPTZ NOT NULL,
+    last_event_id   UUID        NOT NULL,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhook_fanout_cursor_pk PRIMARY KEY (id),
+    CONSTRAINT webhook_fanout_cursor_single_row CHECK (id)
+);
+
+-- Resume after the newest event that was already dispatched.
+INSERT INTO webhook_fanout_cursor (id, last_created_at, last_event_id)
+SELECT TRUE,
+       COALESCE(last.created_at, '-infinity'::timestamptz),
+       COALESCE(last.id, '00000000-0000-0000-0000-000000000000'::uuid)
+FROM (SELECT 1) seed
+LEFT JOIN LATERAL (
+    SELECT e.created_at, e.id
+    FROM webhook_dispatched_events d
+    JOIN events e ON e.id = d.event_id
+    ORDER BY e.created_at DESC, e.id DESC
+    LIMIT 1
+) last ON TRUE
+ON CONFLICT (id) DO NOTHING;
+
+DROP TABLE IF EXISTS webhook_dispatched_events;
+
+CREATE INDEX IF NOT EXISTS idx_events_created_id ON events(created_at, id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+CREATE TABLE IF NOT EXISTS webhook_dispatched_events (
+    event_id      UUID        NOT NULL,
+    dispatched_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT webhook_dispatched_events_pk PRIMARY KEY (event_id)
+);
+
+INSERT INTO webhook_dispatched_events (event_id, dispatched_at)
+SELECT e.id, c.updated_at
+FROM events e
+CROSS JOIN webhook_fanout_cursor c
+WHERE e.aggregate_type = 'payment'
+  AND (e.created_at, e.id) <= (c.last_created_at, c.last_event_id)
+ON CONFLICT (event_id) DO NOTHING;
+
+DROP INDEX IF EXISTS idx_events_created_id;
+DROP TABLE IF EXISTS webhook_fanout_cursor;
+
+-- +goose StatementEnd
@@ -4,8 +4,10 @@ import (
 	"TestTaskJustPay/pkg/health"
 	"TestTaskJustPay/pkg/metrics"
 	"TestTaskJustPay/services/paymanager/internal/dispute/disputecontroller"
+	"TestTaskJustPay/services/paymanager/internal/notification/notificationcontroller"
 	"TestTaskJustPay/services/paymanager/internal/order/ordercontroller"
 	"TestTaskJustPay/services/paymanager/internal/payment/paymentcontroller"
+	"TestTaskJustPay/services/paymanager/internal/reconciliation/reconciliationcontroller"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -15,20 +17,35 @@ type Router struct {
 	order          *ordercontroller.HTTPHandler
 	dispute        *disputecontroller.HTTPHandler
 	payment        *paymentcontroller.HTTPHandler
+	reconciliation *reconciliationcontroller.HTTPHandler
+	notification   *notificationcontroller.HTTPHandler
 	healthRegistry *health.Registry
+	merchantAuth   gin.HandlerFunc
+	idempotency    gin.HandlerFunc
+	adminAuth      gin.HandlerFunc
 }
 
 func NewRouter(
 	order *ordercontroller.HTTPHandler,
 	dispute *disputecontroller.HTTPHandler,
 	payment *paymentcontroller.HTTPHandler,
+	reconciliation *reconciliationcontroller.HTTPHandler,
+	notification *notificationcontroller.HTTPHandler,
 	healthRegistry *health.Registry,
+	merchantAuth gin.HandlerFunc,
+	idempotency gin.HandlerFunc,
+	adminAuth gin.HandlerFunc,
 ) *Router {
 	return &Router{
 		order:          order,
 		dispute:        dispute,
 		payment:        payment,
+		reconciliation: reconciliation,
+		notification:   notification,
 		healthRegistry: healthRegistry,
+		merchantAuth:   merchantAuth,
+		idempotency:    idempotency,
+		adminAuth:      adminAuth,
 	}
 }
 
@@ -37,24 +54,44 @@ func (r *Router) SetUp(engine *gin.Engine) {
 	engine.GET("/health/ready", health.ReadinessHandler(r.healthRegistry, health.DefaultTimeout))
 	engine.GET("/metrics", gin.WrapH(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})))
 
+	// Merchant-scoped endpoints resolve the calling merchant first; idempotency keys are per merchant.
+	scoped := engine.Group("", r.merchantAuth)
+
 	// Legacy order endpoints
-	engine.GET("/orders", r.order.Filter)
-	engine.GET("/orders/:order_id", r.order.Get)
-	engine.GET("/orders/events", r.order.GetEvents)
-	engine.POST("/orders/:order_id/hold", r.order.Hold)
-	engine.POST("/orders/:order_id/capture", r.order.Capture)
+	scoped.GET("/orders", r.order.Filter)
+	scoped.GET("/orders/:order_id", r.order.Get)
+	scoped.GET("/orders/events", r.order.GetEvents)
+	scoped.POST("/orders/:order_id/hold", r.idempotency, r.order.Hold)
+	scoped.POST("/orders/:order_id/capture", r.idempotency, r.order.Capture)
 
 	// Dispute endpoints
-	engine.GET("/disputes", r.dispute.GetDisputes)
-	engine.GET("/disputes/:dispute_id", r.dispute.GetDispute)
-	engine.GET("/disputes/events", r.dispute.GetEvents)
-	engine.GET("/disputes/:dispute_id/evidence", r.dispute.GetEvidence)
-	engine.POST("/disputes/:dispute_id/evidence", r.dispute.UpsertEvidence)
-	engine.POST("/disputes/:dispute_id/submit", r.dispute.Submit)
-
-	// Payment endpoints
-	engine.POST("/api/v1/payments", r.payment.Create)
-	engine.GET("/api/v1/payments/:id", r.payment.Get)
-	engine.POST("/api/v1/payments/:id/void", r.payment.Void)
-	engine.POST("/api/v1/payments/:id/refund", r.payment.Refund)
+	scoped.GET("/disputes", r.dispute.GetDisputes)
+	scoped.GET("/disputes/:dispute_id", r.dispute.GetDispute)
+	scoped.GET("/disputes/events", r.dispute.GetEvents)
+	scoped.GET("/disputes/:dispute_id/evidence", r.dispute.GetEvidence)
+	scoped.POST("/disputes/:dispute_id/evidence", r.idempotency, r.dispute.UpsertEvidence)
+	scoped.POST("/disputes/:dispute_id/submit", r.idempotency, r.dispute.Submit)
+
+	// Payment endpoints. Write endpoints honour the Idempotency-Key header.
+	scoped.POST("/api/v1/payments", r.idempotency, r.payment.Create)
+	scoped.GET("/api/v1/payments", r.payment.List)
+	scoped.GET("/api/v1/payments/:id", r.payment.Get)
+	scoped.GET("/api/v1/payments/:id/events", r.payment.GetEvents)
+	scoped.POST("/api/v1/payments/:id/void", r.idempotency, r.payment.Void)
+	scoped.POST("/api/v1/payments/:id/capture", r.idempotency, r.payment.Capture)
+	scoped.POST("/api/v1/payments/:id/refund", r.idempotency, r.payment.Refund)
+	scoped.GET("/api/v1/payments/:id/refunds", r.payment.ListRefunds)
+	scoped.GET("/api/v1/refunds/:id", r.payment.GetRefund)
+
+	// Merchant webhook endpoints and their delivery log
+	scoped.POST("/api/v1/webhook-endpoints", r.idempotency, r.notification.CreateEndpoint)
+	scoped.GET("/api/v1/webhook-endpoints", r.notification.ListEndpoints)
+	scoped.GET("/api/v1/webhook-endpoints/:id/deliveries", r.notification.ListDeliveries)
+	scoped.GET("/api/v1/webhook-endpoints/:id/deliveries/:delivery_id", r.notification.GetDelivery)
+	scoped.POST("/api/v1/webhook-endpoints/:id/deliveries/:delivery_id/redeliver", r.idempotency, r.notification.Redeliver)
+
+	// Reconciliation reports cover every merchant's payments, so only operators read them.
+	admin := engine.Group("", r.adminAuth)
+	admin.GET("/api/v1/reconciliation/runs", r.reconciliation.ListRuns)
+	admin.GET("/api/v1/reconciliation/runs/:id", r.reconciliation.GetRun)
 }
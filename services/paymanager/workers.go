@@ -9,10 +9,13 @@ import (
 	"TestTaskJustPay/services/paymanager/config"
 	"TestTaskJustPay/services/paymanager/internal/dispute"
 	"TestTaskJustPay/services/paymanager/internal/dispute/disputecontroller"
+	"TestTaskJustPay/services/paymanager/internal/notification"
 	"TestTaskJustPay/services/paymanager/internal/order"
 	"TestTaskJustPay/services/paymanager/internal/order/ordercontroller"
 	"TestTaskJustPay/services/paymanager/internal/payment"
 	"TestTaskJustPay/services/paymanager/internal/payment/paymentcontroller"
+	"TestTaskJustPay/services/paymanager/internal/reconciliation"
+	"TestTaskJustPay/services/paymanager/internal/risk"
 )
 
 func StartWorkers(
@@ -98,3 +101,52 @@ func StartWorkers(
 		}
 	}()
 }
+
+// StartCaptureScheduler runs the durable capture scheduler until ctx is cancelled.
+// Safe to run on every replica: claims are coordinated through Postgres row locks.
+func StartCaptureScheduler(ctx context.Context, scheduler *payment.CaptureScheduler) {
+	go func() {
+		if err := scheduler.Start(ctx); err != nil {
+			slog.Info("Capture scheduler exited", slog.Any("error", err))
+		}
+	}()
+}
+
+// StartChallengeExpirer expires abandoned authentication challenges until ctx is cancelled.
+// Safe to run on every replica: payments are locked with SKIP LOCKED.
+func StartChallengeExpirer(ctx context.Context, expirer *payment.ChallengeExpirer) {
+	go func() {
+		if err := expirer.Start(ctx); err != nil {
+			slog.Info("Challenge expirer exited", slog.Any("error", err))
+		}
+	}()
+}
+
+// StartRiskRulesReloader hot-reloads the risk rules file until ctx is cancelled.
+func StartRiskRulesReloader(ctx context.Context, reloader *risk.Reloader) {
+	go func() {
+		if err := reloader.Start(ctx); err != nil {
+			slog.Info("Risk rules reloader exited", slog.Any("error", err))
+		}
+	}()
+}
+
+// StartReconciler runs payment reconciliation against Silvergate until ctx is cancelled.
+// Replays are idempotent through the payment event store, so replicas may overlap.
+func StartReconciler(ctx context.Context, reconciler *reconciliation.Reconciler) {
+	go func() {
+		if err := reconciler.Start(ctx); err != nil {
+			slog.Info("Reconciler exited", slog.Any("error", err))
+		}
+	}()
+}
+
+// StartWebhookDispatcher delivers merchant webhooks until ctx is cancelled.
+// Safe to run on every replica: events and deliveries are claimed with SKIP LOCKED.
+func StartWebhookDispatcher(ctx context.Context, dispatcher *notification.Dispatcher) {
+	go func() {
+		if err := dispatcher.Start(ctx); err != nil {
+			slog.Info("Webhook dispatcher exited", slog.Any("error", err))
+		}
+	}()
+}
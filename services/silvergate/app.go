@@ -3,6 +3,7 @@ package silvergate
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -15,13 +16,25 @@ import (
 	"TestTaskJustPay/pkg/postgres"
 	"TestTaskJustPay/services/silvergate/config"
 	"TestTaskJustPay/services/silvergate/internal/acquirer"
+	"TestTaskJustPay/services/silvergate/internal/idempotency"
+	"TestTaskJustPay/services/silvergate/internal/idempotency/idempotencyrepo"
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+	"TestTaskJustPay/services/silvergate/internal/ledger/ledgerrepo"
+	"TestTaskJustPay/services/silvergate/internal/pricing"
+	"TestTaskJustPay/services/silvergate/internal/pricing/pricingrepo"
 	"TestTaskJustPay/services/silvergate/internal/product"
 	"TestTaskJustPay/services/silvergate/internal/product/productrepo"
 	"TestTaskJustPay/services/silvergate/internal/purchase"
+	"TestTaskJustPay/services/silvergate/internal/settlement"
+	"TestTaskJustPay/services/silvergate/internal/settlement/settlementrepo"
 	"TestTaskJustPay/services/silvergate/internal/transaction"
 	"TestTaskJustPay/services/silvergate/internal/transaction/transactioncontroller"
 	"TestTaskJustPay/services/silvergate/internal/transaction/transactionrepo"
+	"TestTaskJustPay/services/silvergate/internal/vault"
+	"TestTaskJustPay/services/silvergate/internal/vault/vaultrepo"
 	"TestTaskJustPay/services/silvergate/internal/webhooksender"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender/endpointrepo"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender/outboxrepo"
 
 	"github.com/gin-gonic/gin"
 )
@@ -33,10 +46,16 @@ var migrationFS embed.FS
 func MigrationFS() embed.FS { return migrationFS }
 
 type App struct {
-	cfg    config.Config
-	log    *slog.Logger
-	server *http.Server
-	pg     *postgres.Postgres
+	cfg           config.Config
+	log           *slog.Logger
+	server        *http.Server
+	pg            *postgres.Postgres
+	vaultPG       *postgres.Postgres
+	expirySweeper *transaction.ExpirySweeper
+	reconciler    *transaction.IntentReconciler
+	settlement    *settlement.BatchWorker
+	webhooks      *webhooksender.DeliveryWorker
+	vault         *vault.Service
 }
 
 func NewApp(cfg config.Config) (*App, error) {
@@ -53,18 +72,77 @@ func NewApp(cfg config.Config) (*App, error) {
 		return nil, fmt.Errorf("connect to postgres: %w", err)
 	}
 
+	keyring, err := vault.NewKeyring(cfg.VaultKeys, cfg.VaultActiveKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("vault keyring: %w", err)
+	}
+	fingerprinter, err := vault.NewFingerprinter(cfg.VaultFingerprintKey)
+	if err != nil {
+		return nil, fmt.Errorf("vault fingerprinter: %w", err)
+	}
+	vaultPG := pg
+	if cfg.VaultPgURL != "" && cfg.VaultPgURL != cfg.PgURL {
+		vaultPG, err = postgres.New(cfg.VaultPgURL, postgres.MaxPoolSize(5))
+		if err != nil {
+			return nil, fmt.Errorf("connect to vault postgres: %w", err)
+		}
+	}
+	vaultSvc := vault.NewService(vaultrepo.NewPgCardRepo(vaultPG.Pool), keyring, fingerprinter, log)
+
 	txRepo := transactionrepo.NewPgTransactionRepo(pg.Pool)
 	acq := acquirer.NewMockAcquirer(cfg.AcquirerAuthApproveRate, cfg.AcquirerSettleSuccessRate, cfg.AcquirerSettleDelay)
-	webhookSender := webhooksender.NewSender(cfg.WebhookCallbackURL, log)
+	txWebhooksFactory := func(tx postgres.Executor) transaction.WebhookSender {
+		return webhooksender.NewSender(outboxrepo.NewPgOutboxRepo(tx), endpointrepo.NewPgEndpointRepo(tx))
+	}
+	webhookWorker := webhooksender.NewDeliveryWorker(outboxrepo.NewPgOutboxRepo(pg.Pool), webhooksender.DeliveryWorkerConfig{
+		CallbackURL:    cfg.WebhookCallbackURL,
+		SigningSecrets: cfg.WebhookSigningSecrets,
+		PollInterval:   cfg.WebhookDeliveryPollInterval,
+		BatchSize:      cfg.WebhookDeliveryBatchSize,
+		InitialBackoff: cfg.WebhookRetryInitialBackoff,
+		MaxBackoff:     cfg.WebhookRetryMaxBackoff,
+		MaxAge:         cfg.WebhookMaxAge,
+	}, log)
+	webhookSvc := webhooksender.NewService(endpointrepo.NewPgEndpointRepo(pg.Pool), outboxrepo.NewPgOutboxRepo(pg.Pool))
 	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
 		return transactionrepo.NewPgTransactionRepo(tx)
 	}
-	svc := transaction.NewService(txRepo, acq, webhookSender, log, pg, txRepoFactory)
+	txLedgerFactory := func(tx postgres.Executor) transaction.Ledger {
+		return ledgerrepo.NewPgLedgerRepo(tx)
+	}
+	challenges := transaction.ChallengePolicy{
+		AmountThreshold: cfg.ScaChallengeAmount,
+		CardLast4:       cfg.ScaChallengeCardLast4,
+		TTL:             cfg.ScaChallengeTTL,
+		URLBase:         cfg.PublicURL,
+	}
+	pricingSvc := pricing.NewService(pricingrepo.NewPgPricingRepo(pg.Pool), pricing.Plan{
+		PercentBps:    cfg.PricingDefaultPercentBps,
+		FixedFee:      cfg.PricingDefaultFixedFee,
+		RefundFee:     cfg.PricingDefaultRefundFee,
+		ChargebackFee: cfg.PricingDefaultChargebackFee,
+	})
+	svc := transaction.NewService(txRepo, acq, vaultSvc, txWebhooksFactory, log, pg, txRepoFactory, txLedgerFactory, pricingSvc, cfg.AuthValidityDefault, challenges)
+	expirySweeper := transaction.NewExpirySweeper(svc, transaction.ExpirySweeperConfig{
+		PollInterval: cfg.ExpirySweepInterval,
+		BatchSize:    cfg.ExpirySweepBatchSize,
+		RetryAfter:   cfg.ExpirySweepRetryAfter,
+	}, log)
+	intentReconciler := transaction.NewIntentReconciler(svc, transaction.IntentReconcilerConfig{
+		PollInterval:   cfg.IntentReconcileInterval,
+		StaleAfter:     cfg.IntentStaleAfter,
+		BatchSize:      cfg.IntentReconcileBatchSize,
+		InitialBackoff: cfg.IntentRetryInitialBackoff,
+		MaxBackoff:     cfg.IntentRetryMaxBackoff,
+	}, log)
 
 	authHandler := transactioncontroller.NewAuthHandler(svc)
 	captureHandler := transactioncontroller.NewCaptureHandler(svc)
 	voidHandler := transactioncontroller.NewVoidHandler(svc)
 	refundHandler := transactioncontroller.NewRefundHandler(svc)
+	queryHandler := transactioncontroller.NewQueryHandler(svc)
+	challengeHandler := transactioncontroller.NewChallengeHandler(svc)
+	disputeHandler := transactioncontroller.NewDisputeHandler(svc)
 
 	productRepo := productrepo.NewPgProductRepo(pg.Pool)
 	productRepoFactory := func(exec postgres.Executor) product.Repo {
@@ -72,11 +150,38 @@ func NewApp(cfg config.Config) (*App, error) {
 	}
 	productSvc := product.NewService(productRepo, log, pg, productRepoFactory)
 
-	purchaseSvc := purchase.NewService(productSvc, svc, svc, txRepo, txRepoFactory, pg, log)
+	purchaseSvc := purchase.NewService(productSvc, svc, svc, pg, log)
+
+	ledgerSvc := ledger.NewService(ledgerrepo.NewPgLedgerRepo(pg.Pool))
+
+	settlementRepoFactory := func(tx postgres.Executor) settlement.Repo {
+		return settlementrepo.NewPgSettlementRepo(tx)
+	}
+	settlementLedgerFactory := func(tx postgres.Executor) settlement.Ledger {
+		return ledgerrepo.NewPgLedgerRepo(tx)
+	}
+	settlementWebhooksFactory := func(tx postgres.Executor) settlement.WebhookSender {
+		return webhooksender.NewSender(outboxrepo.NewPgOutboxRepo(tx), endpointrepo.NewPgEndpointRepo(tx))
+	}
+	settlementSvc := settlement.NewService(
+		settlementrepo.NewPgSettlementRepo(pg.Pool), pg, settlementRepoFactory, settlementLedgerFactory, settlementWebhooksFactory,
+		settlement.Config{
+			Window:       cfg.SettlementWindow,
+			CutoffOffset: cfg.SettlementCutoffOffset,
+			ReportDir:    cfg.SettlementReportDir,
+		}, log)
+	settlementWorker := settlement.NewBatchWorker(settlementSvc, settlement.BatchWorkerConfig{
+		PollInterval:    cfg.SettlementPollInterval,
+		NotifyBatchSize: cfg.SettlementNotifyBatchSize,
+	}, log)
+
+	idempotencyMW := idempotency.Middleware(idempotencyrepo.NewPgIdempotencyRepo(pg.Pool), idempotency.Config{
+		LockTimeout: cfg.IdempotencyLockTimeout,
+	})
 
 	engine := gin.New()
 	engine.Use(gin.Recovery())
-	setupRouter(engine, authHandler, captureHandler, voidHandler, refundHandler, productSvc, purchaseSvc)
+	setupRouter(engine, idempotencyMW, authHandler, captureHandler, voidHandler, refundHandler, queryHandler, challengeHandler, disputeHandler, productSvc, purchaseSvc, vaultSvc, ledgerSvc, webhookSvc)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Port),
@@ -84,10 +189,16 @@ func NewApp(cfg config.Config) (*App, error) {
 	}
 
 	return &App{
-		cfg:    cfg,
-		log:    log,
-		server: server,
-		pg:     pg,
+		cfg:           cfg,
+		log:           log,
+		server:        server,
+		pg:            pg,
+		vaultPG:       vaultPG,
+		expirySweeper: expirySweeper,
+		reconciler:    intentReconciler,
+		settlement:    settlementWorker,
+		webhooks:      webhookWorker,
+		vault:         vaultSvc,
 	}, nil
 }
 
@@ -95,6 +206,45 @@ func (a *App) Run() error {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	go func() {
+		if err := a.expirySweeper.Start(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+			a.log.Error("expiry sweeper error", "error", err)
+		}
+	}()
+
+	go func() {
+		if err := a.reconciler.Start(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+			a.log.Error("intent reconciler error", "error", err)
+		}
+	}()
+
+	go func() {
+		if err := a.settlement.Start(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+			a.log.Error("settlement batch worker error", "error", err)
+		}
+	}()
+
+	go func() {
+		if err := a.webhooks.Start(workerCtx); err != nil && !errors.Is(err, context.Canceled) {
+			a.log.Error("webhook delivery worker error", "error", err)
+		}
+	}()
+
+	// Cards sealed with a retired key are re-encrypted with the active one in the background.
+	go func() {
+		rotated, err := a.vault.RotateKeys(workerCtx, a.cfg.VaultRotationBatchSize)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			a.log.Error("vault key rotation error", "error", err, "rotated", rotated)
+			return
+		}
+		if rotated > 0 {
+			a.log.Info("vault key rotation finished", "rotated", rotated)
+		}
+	}()
+
 	go func() {
 		a.log.Info("silvergate service starting", "port", a.cfg.Port)
 		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -104,6 +254,7 @@ func (a *App) Run() error {
 
 	<-quit
 	a.log.Info("shutting down...")
+	stopWorkers()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -111,6 +262,9 @@ func (a *App) Run() error {
 	if err := a.server.Shutdown(ctx); err != nil {
 		return fmt.Errorf("server shutdown: %w", err)
 	}
+	if a.vaultPG != a.pg {
+		a.vaultPG.Close()
+	}
 	a.pg.Close()
 
 	return nil
@@ -0,0 +1,46 @@
+// Command ledgercheck verifies the Silvergate ledger invariants against the database
+// at SILVERGATE_PG_URL and exits non-zero when any is violated.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+	"TestTaskJustPay/services/silvergate/internal/ledger/ledgerrepo"
+)
+
+func main() {
+	pgURL := os.Getenv("SILVERGATE_PG_URL")
+	if pgURL == "" {
+		log.Fatal("SILVERGATE_PG_URL is required")
+	}
+
+	pg, err := postgres.New(pgURL, postgres.MaxPoolSize(1))
+	if err != nil {
+		log.Fatalf("connect to postgres: %v", err)
+	}
+
+	report, err := ledger.NewService(ledgerrepo.NewPgLedgerRepo(pg.Pool)).Check(context.Background())
+	pg.Close()
+	if err != nil {
+		log.Fatalf("check ledger: %v", err)
+	}
+
+	for _, e := range report.UnbalancedEntries {
+		fmt.Printf("unbalanced entry %s: postings sum to %d\n", e.EntryID, e.Sum)
+	}
+	for _, m := range report.Mismatches {
+		fmt.Printf("transaction %s (%s): %s is %d on the row, %d in the ledger\n",
+			m.TransactionID, m.Status, m.Field, m.Expected, m.Ledger)
+	}
+	fmt.Printf("checked %d transactions: %d unbalanced entries, %d mismatches\n",
+		report.Transactions, len(report.UnbalancedEntries), len(report.Mismatches))
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
@@ -11,8 +11,77 @@ type Config struct {
 	PgURL    string `env:"SILVERGATE_PG_URL" required:"true"`
 	LogLevel string `env:"LOG_LEVEL" envDefault:"info"`
 
-	// Merchant webhook callback URL
+	// Platform webhook callback URL; every transaction event goes here as well as to subscribed merchant endpoints
 	WebhookCallbackURL string `env:"WEBHOOK_CALLBACK_URL" required:"true"`
+	// Secrets signing webhooks to WEBHOOK_CALLBACK_URL; merchant endpoints use their own secret.
+	// Every secret listed signs, so to rotate add the new one here and at the receiver, then
+	// drop the old one from both.
+	WebhookSigningSecrets []string `env:"WEBHOOK_SIGNING_SECRETS" envSeparator:"," required:"true"`
+
+	// Webhook delivery. Events are queued in the outbox and posted every WEBHOOK_DELIVERY_POLL_INTERVAL;
+	// a failed delivery is retried after WEBHOOK_RETRY_INITIAL_BACKOFF, doubling up to
+	// WEBHOOK_RETRY_MAX_BACKOFF, until the event is WEBHOOK_MAX_AGE old and marked dead.
+	WebhookDeliveryPollInterval time.Duration `env:"WEBHOOK_DELIVERY_POLL_INTERVAL" envDefault:"1s"`
+	WebhookDeliveryBatchSize    int           `env:"WEBHOOK_DELIVERY_BATCH_SIZE" envDefault:"100"`
+	WebhookRetryInitialBackoff  time.Duration `env:"WEBHOOK_RETRY_INITIAL_BACKOFF" envDefault:"5s"`
+	WebhookRetryMaxBackoff      time.Duration `env:"WEBHOOK_RETRY_MAX_BACKOFF" envDefault:"1h"`
+	WebhookMaxAge               time.Duration `env:"WEBHOOK_MAX_AGE" envDefault:"72h"`
+
+	// Authorization expiry. AUTH_VALIDITY_DEFAULT applies to merchants without an auth validity window.
+	AuthValidityDefault   time.Duration `env:"AUTH_VALIDITY_DEFAULT" envDefault:"168h"`
+	ExpirySweepInterval   time.Duration `env:"EXPIRY_SWEEP_INTERVAL" envDefault:"1m"`
+	ExpirySweepBatchSize  int           `env:"EXPIRY_SWEEP_BATCH_SIZE" envDefault:"100"`
+	ExpirySweepRetryAfter time.Duration `env:"EXPIRY_SWEEP_RETRY_AFTER" envDefault:"10m"`
+
+	// Acquirer intents. Every authorize, settle and refund call is recorded before it is made;
+	// calls whose outcome is still unknown INTENT_STALE_AFTER later (crash, failed commit,
+	// transport error) are resolved with the acquirer every INTENT_RECONCILE_INTERVAL. An intent
+	// that fails to resolve is retried with exponential backoff between the two INTENT_RETRY bounds.
+	IntentStaleAfter          time.Duration `env:"INTENT_STALE_AFTER" envDefault:"1m"`
+	IntentReconcileInterval   time.Duration `env:"INTENT_RECONCILE_INTERVAL" envDefault:"30s"`
+	IntentReconcileBatchSize  int           `env:"INTENT_RECONCILE_BATCH_SIZE" envDefault:"100"`
+	IntentRetryInitialBackoff time.Duration `env:"INTENT_RETRY_INITIAL_BACKOFF" envDefault:"30s"`
+	IntentRetryMaxBackoff     time.Duration `env:"INTENT_RETRY_MAX_BACKOFF" envDefault:"30m"`
+
+	// Strong customer authentication. /auth requests at or above SCA_CHALLENGE_AMOUNT (0 disables)
+	// or with a card ending in one of SCA_CHALLENGE_CARD_LAST4 return requires_action with a challenge
+	// the cardholder completes at PUBLIC_URL/api/v1/challenges/{id} within SCA_CHALLENGE_TTL.
+	PublicURL             string        `env:"PUBLIC_URL" envDefault:"http://localhost:3002"`
+	ScaChallengeAmount    int64         `env:"SCA_CHALLENGE_AMOUNT" envDefault:"0"`
+	ScaChallengeCardLast4 []string      `env:"SCA_CHALLENGE_CARD_LAST4" envSeparator:"," envDefault:"3184"`
+	ScaChallengeTTL       time.Duration `env:"SCA_CHALLENGE_TTL" envDefault:"15m"`
+
+	// Card vault. VAULT_KEYS holds base64 AES-256 keys as id:key pairs; new cards are sealed
+	// with VAULT_ACTIVE_KEY_ID and cards under other keys are re-encrypted on startup.
+	VaultKeys              map[string]string `env:"VAULT_KEYS" envSeparator:"," envKeyValSeparator:":" required:"true"`
+	VaultActiveKeyID       string            `env:"VAULT_ACTIVE_KEY_ID" required:"true"`
+	VaultFingerprintKey    string            `env:"VAULT_FINGERPRINT_KEY" required:"true"`
+	VaultRotationBatchSize int               `env:"VAULT_ROTATION_BATCH_SIZE" envDefault:"100"`
+	// VAULT_PG_URL points the vault at another instance's database, so instances that
+	// fail over to each other accept each other's card tokens. They must also share
+	// VAULT_KEYS and VAULT_FINGERPRINT_KEY. Empty keeps the vault in SILVERGATE_PG_URL.
+	VaultPgURL string `env:"VAULT_PG_URL"`
+
+	// Settlement. Captured funds are paid out in batches at cutoffs every SETTLEMENT_WINDOW from
+	// midnight UTC plus SETTLEMENT_CUTOFF_OFFSET; each batch writes CSV and JSON reports to
+	// SETTLEMENT_REPORT_DIR.
+	SettlementWindow          time.Duration `env:"SETTLEMENT_WINDOW" envDefault:"24h"`
+	SettlementCutoffOffset    time.Duration `env:"SETTLEMENT_CUTOFF_OFFSET" envDefault:"0s"`
+	SettlementPollInterval    time.Duration `env:"SETTLEMENT_POLL_INTERVAL" envDefault:"1m"`
+	SettlementReportDir       string        `env:"SETTLEMENT_REPORT_DIR" envDefault:"reports/settlement"`
+	SettlementNotifyBatchSize int           `env:"SETTLEMENT_NOTIFY_BATCH_SIZE" envDefault:"100"`
+
+	// Pricing. The default plan applies to merchants without a pricing plan in effect: captures
+	// are charged PRICING_DEFAULT_PERCENT_BPS basis points plus PRICING_DEFAULT_FIXED_FEE, refunds
+	// and chargebacks a flat fee. Fees are in minor units of the transaction currency.
+	PricingDefaultPercentBps    int64 `env:"PRICING_DEFAULT_PERCENT_BPS" envDefault:"290"`
+	PricingDefaultFixedFee      int64 `env:"PRICING_DEFAULT_FIXED_FEE" envDefault:"30"`
+	PricingDefaultRefundFee     int64 `env:"PRICING_DEFAULT_REFUND_FEE" envDefault:"0"`
+	PricingDefaultChargebackFee int64 `env:"PRICING_DEFAULT_CHARGEBACK_FEE" envDefault:"1500"`
+
+	// Idempotency. A request holding an Idempotency-Key that has not completed within
+	// IDEMPOTENCY_LOCK_TIMEOUT (e.g. after a crash) gives the key up to the next retry.
+	IdempotencyLockTimeout time.Duration `env:"IDEMPOTENCY_LOCK_TIMEOUT" envDefault:"1m"`
 
 	// Mock acquirer settings
 	AcquirerAuthApproveRate   float64       `env:"ACQUIRER_AUTH_APPROVE_RATE" envDefault:"0.9"`
@@ -16,15 +16,21 @@ import (
 	"time"
 
 	"TestTaskJustPay/pkg/testinfra"
+	"TestTaskJustPay/pkg/webhooksig"
 	silvergate "TestTaskJustPay/services/silvergate"
 	"TestTaskJustPay/services/silvergate/config"
 
 	"github.com/stretchr/testify/require"
 )
 
+// webhookSecret signs the webhooks Silvergate posts to the collector.
+const webhookSecret = "whsec_e2e"
+
 var (
 	silvergateURL string
-	webhooks      *webhookCollector
+	// secondaryURL is a failover instance with its own database sharing the primary's vault.
+	secondaryURL string
+	webhooks     *webhookCollector
 )
 
 // --- webhook collector ---
@@ -54,6 +60,11 @@ func startWebhookCollector(listener net.Listener) *webhookCollector {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/webhooks/silvergate", func(w http.ResponseWriter, r *http.Request) {
 		body, _ := io.ReadAll(r.Body)
+		err := webhooksig.Verify(r.Header.Get(webhooksig.Header), body, []string{webhookSecret}, time.Now(), time.Minute)
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
 		var evt webhookEvent
 		_ = json.Unmarshal(body, &evt)
 		wc.mu.Lock()
@@ -109,24 +120,58 @@ func TestMain(m *testing.M) {
 	webhooks = startWebhookCollector(whListener)
 
 	// 3. Silvergate service (random free port)
-	sgListener, err := net.Listen("tcp", "127.0.0.1:0")
+	// Defaults come from the env tags; only what the test depends on is set.
+	for k, v := range map[string]string{
+		"SILVERGATE_PG_URL":       pgContainer.DSN,
+		"WEBHOOK_CALLBACK_URL":    fmt.Sprintf("http://127.0.0.1:%d/webhooks/silvergate", whPort),
+		"WEBHOOK_SIGNING_SECRETS": webhookSecret,
+		"VAULT_KEYS":              "k1:AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=",
+		"VAULT_ACTIVE_KEY_ID":     "k1",
+		"VAULT_FINGERPRINT_KEY":   "ICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8=",
+		"SETTLEMENT_REPORT_DIR":   os.TempDir(),
+	} {
+		_ = os.Setenv(k, v)
+	}
+	cfg, err := config.New()
 	if err != nil {
-		panic(fmt.Sprintf("silvergate listener: %v", err))
+		panic(fmt.Sprintf("silvergate config: %v", err))
 	}
-	sgPort := sgListener.Addr().(*net.TCPAddr).Port
-	sgListener.Close() // release so the app can bind
-
-	silvergateURL = fmt.Sprintf("http://127.0.0.1:%d", sgPort)
-
-	cfg := config.Config{
-		Port:                      sgPort,
-		PgURL:                     pgContainer.DSN,
-		LogLevel:                  "debug",
-		WebhookCallbackURL:        fmt.Sprintf("http://127.0.0.1:%d/webhooks/silvergate", whPort),
-		AcquirerAuthApproveRate:   0.85,
-		AcquirerSettleSuccessRate: 0.90,
-		AcquirerSettleDelay:       100 * time.Millisecond,
+	cfg.LogLevel = "debug"
+	cfg.AcquirerAuthApproveRate = 0.85
+	cfg.AcquirerSettleSuccessRate = 0.90
+	cfg.AcquirerSettleDelay = 100 * time.Millisecond
+	silvergateURL = startSilvergate(cfg)
+
+	// 4. Failover instance: own database, the primary's vault
+	secondaryPG, err := testinfra.NewPostgresWithConfig(ctx, testinfra.PostgresConfig{
+		DBName:      "silvergate_secondary_test",
+		MigrationFS: silvergate.MigrationFS(),
+	})
+	if err != nil {
+		panic(fmt.Sprintf("secondary postgres: %v", err))
+	}
+	secondaryCfg := cfg
+	secondaryCfg.PgURL = secondaryPG.DSN
+	secondaryCfg.VaultPgURL = pgContainer.DSN
+	secondaryURL = startSilvergate(secondaryCfg)
+
+	code := m.Run()
+
+	webhooks.server.Close()
+	secondaryPG.Cleanup(ctx)
+	pgContainer.Cleanup(ctx)
+	os.Exit(code)
+}
+
+// startSilvergate runs an app on a random free port and returns its base URL once ready.
+func startSilvergate(cfg config.Config) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("silvergate listener: %v", err))
 	}
+	cfg.Port = listener.Addr().(*net.TCPAddr).Port
+	listener.Close() // release so the app can bind
+	baseURL := fmt.Sprintf("http://127.0.0.1:%d", cfg.Port)
 
 	app, err := silvergate.NewApp(cfg)
 	if err != nil {
@@ -134,24 +179,18 @@ func TestMain(m *testing.M) {
 	}
 	go app.Run()
 
-	// Wait for ready
 	for i := range 50 {
-		resp, err := http.Get(silvergateURL + "/health/ready")
+		resp, err := http.Get(baseURL + "/health/ready")
 		if err == nil && resp.StatusCode == 200 {
 			resp.Body.Close()
-			break
+			return baseURL
 		}
 		if i == 49 {
 			panic("silvergate not ready")
 		}
 		time.Sleep(100 * time.Millisecond)
 	}
-
-	code := m.Run()
-
-	webhooks.server.Close()
-	pgContainer.Cleanup(ctx)
-	os.Exit(code)
+	return baseURL
 }
 
 // --- helpers ---
@@ -171,6 +210,33 @@ type authResponse struct {
 	DeclineReason string `json:"decline_reason,omitempty"`
 }
 
+type tokenizeRequest struct {
+	Number   string `json:"number"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+	CVC      string `json:"cvc"`
+}
+
+type cardResponse struct {
+	Token string `json:"token"`
+}
+
+// tokenize vaults a test Visa card for merchantID and returns its token.
+func tokenize(t *testing.T, merchantID string) string {
+	t.Helper()
+	j, err := json.Marshal(tokenizeRequest{Number: "4242424242424242", ExpMonth: 12, ExpYear: time.Now().Year() + 2, CVC: "123"})
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodPost, silvergateURL+"/api/v1/cards", bytes.NewReader(j))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Merchant-ID", merchantID)
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("tok_%d", time.Now().UnixNano()))
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	return decodeJSON[cardResponse](t, resp).Token
+}
+
 type captureRequest struct {
 	TransactionID  string `json:"transaction_id"`
 	Amount         int64  `json:"amount"`
@@ -182,11 +248,17 @@ type captureResponse struct {
 	Status        string `json:"status"`
 }
 
-func postJSON(t *testing.T, url string, body any) *http.Response {
+// postJSON posts body as merchantID under the given Idempotency-Key.
+func postJSON(t *testing.T, url, merchantID, idempotencyKey string, body any) *http.Response {
 	t.Helper()
 	j, err := json.Marshal(body)
 	require.NoError(t, err)
-	resp, err := http.Post(url, "application/json", bytes.NewReader(j))
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(j))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Merchant-ID", merchantID)
+	req.Header.Set("Idempotency-Key", idempotencyKey)
+	resp, err := http.DefaultClient.Do(req)
 	require.NoError(t, err)
 	return resp
 }
@@ -222,12 +294,12 @@ func TestAuthCaptureFlow(t *testing.T) {
 		amount := int64((i + 1) * 1000)
 
 		// 1. Auth
-		resp := postJSON(t, silvergateURL+"/api/v1/auth", authRequest{
+		resp := postJSON(t, silvergateURL+"/api/v1/auth", "merchant_e2e", "auth_"+orderID, authRequest{
 			MerchantID: "merchant_e2e",
 			OrderID:    orderID,
 			Amount:     amount,
 			Currency:   "USD",
-			CardToken:  fmt.Sprintf("tok_%03d", i+1),
+			CardToken:  tokenize(t, "merchant_e2e"),
 		})
 		require.Equal(t, http.StatusOK, resp.StatusCode)
 		auth := decodeJSON[authResponse](t, resp)
@@ -241,7 +313,7 @@ func TestAuthCaptureFlow(t *testing.T) {
 
 		// 2. Capture if authorized
 		if auth.Status == "authorized" {
-			resp := postJSON(t, silvergateURL+"/api/v1/capture", captureRequest{
+			resp := postJSON(t, silvergateURL+"/api/v1/capture", "merchant_e2e", fmt.Sprintf("cap_%03d", i+1), captureRequest{
 				TransactionID:  auth.TransactionID,
 				Amount:         amount,
 				IdempotencyKey: fmt.Sprintf("cap_%03d", i+1),
@@ -336,3 +408,21 @@ func pct(n, total int) float64 {
 	}
 	return float64(n) / float64(total) * 100
 }
+
+// TestFailoverAcceptsVaultedToken authorizes at the failover instance with a token the
+// primary issued: the token must resolve there, whatever the acquirer decides.
+func TestFailoverAcceptsVaultedToken(t *testing.T) {
+	token := tokenize(t, "merchant_e2e")
+
+	resp := postJSON(t, secondaryURL+"/api/v1/auth", "merchant_e2e", "auth_ord_failover", authRequest{
+		MerchantID: "merchant_e2e",
+		OrderID:    "ord_failover",
+		Amount:     1000,
+		Currency:   "USD",
+		CardToken:  token,
+	})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	auth := decodeJSON[authResponse](t, resp)
+	require.Contains(t, []string{"authorized", "declined"}, auth.Status)
+	require.NotEmpty(t, auth.TransactionID)
+}
@@ -3,6 +3,7 @@ package acquirer
 import (
 	"context"
 	"math/rand/v2"
+	"sync"
 	"time"
 )
 
@@ -13,11 +14,21 @@ var declineReasons = []string{
 	"suspected_fraud",
 }
 
-// MockAcquirer simulates a bank with configurable approve/settle rates.
+// maxOperations bounds the references the mock remembers; the oldest are forgotten first.
+// It comfortably covers the references an intent can be reconciled with under load.
+const maxOperations = 100_000
+
+// MockAcquirer simulates a bank with configurable approve/settle rates. It remembers the
+// outcome of the last maxOperations references in memory, so repeats and lookups are
+// answered until they are evicted or the process restarts.
 type MockAcquirer struct {
 	AuthApproveRate   float64       // 0.0–1.0, probability of auth approval
 	SettleSuccessRate float64       // 0.0–1.0, probability of settle success
 	SettleDelay       time.Duration // simulated settlement processing time
+
+	mu         sync.Mutex
+	operations map[string]Operation
+	references []string // recorded references, oldest first
 }
 
 func NewMockAcquirer(authRate, settleRate float64, settleDelay time.Duration) *MockAcquirer {
@@ -25,37 +36,75 @@ func NewMockAcquirer(authRate, settleRate float64, settleDelay time.Duration) *M
 		AuthApproveRate:   authRate,
 		SettleSuccessRate: settleRate,
 		SettleDelay:       settleDelay,
+		operations:        make(map[string]Operation),
 	}
 }
 
-func (m *MockAcquirer) Authorize(_ context.Context, _ int64, _, _ string) (AuthResult, error) {
-	if rand.Float64() < m.AuthApproveRate {
-		return AuthResult{Approved: true}, nil
+func (m *MockAcquirer) Authorize(_ context.Context, reference string, _ int64, _, _ string) (AuthResult, error) {
+	op := Operation{Success: true}
+	if rand.Float64() >= m.AuthApproveRate {
+		op = Operation{Reason: declineReasons[rand.IntN(len(declineReasons))]}
 	}
-	reason := declineReasons[rand.IntN(len(declineReasons))]
-	return AuthResult{Approved: false, DeclineReason: reason}, nil
+	op = m.record(reference, op)
+	return AuthResult{Approved: op.Success, DeclineReason: op.Reason}, nil
 }
 
 func (m *MockAcquirer) Void(_ context.Context, _ string) (VoidResult, error) {
 	return VoidResult{Success: true}, nil
 }
 
-func (m *MockAcquirer) Refund(_ context.Context, _ string, _ int64) (RefundResult, error) {
+func (m *MockAcquirer) Release(_ context.Context, _ string, _ int64) (VoidResult, error) {
+	return VoidResult{Success: true}, nil
+}
+
+func (m *MockAcquirer) Refund(_ context.Context, reference, _ string, _ int64) (RefundResult, error) {
+	op := m.settle(reference, "refund_rejected")
+	return RefundResult(op), nil
+}
+
+func (m *MockAcquirer) Settle(_ context.Context, reference, _ string, _ int64) (SettleResult, error) {
+	op := m.settle(reference, "settlement_rejected")
+	return SettleResult(op), nil
+}
+
+func (m *MockAcquirer) Lookup(_ context.Context, reference string) (Operation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op, ok := m.operations[reference]
+	if !ok {
+		return Operation{}, ErrReferenceNotFound
+	}
+	return op, nil
+}
+
+// settle answers a repeated reference at once and otherwise simulates bank processing.
+func (m *MockAcquirer) settle(reference, rejectReason string) Operation {
+	if op, err := m.Lookup(context.Background(), reference); err == nil {
+		return op
+	}
 	if m.SettleDelay > 0 {
 		time.Sleep(m.SettleDelay)
 	}
-	if rand.Float64() < m.SettleSuccessRate {
-		return RefundResult{Success: true}, nil
+	op := Operation{Success: true}
+	if rand.Float64() >= m.SettleSuccessRate {
+		op = Operation{Reason: rejectReason}
 	}
-	return RefundResult{Success: false, Reason: "refund_rejected"}, nil
+	return m.record(reference, op)
 }
 
-func (m *MockAcquirer) Settle(_ context.Context, _ string, _ int64) (SettleResult, error) {
-	if m.SettleDelay > 0 {
-		time.Sleep(m.SettleDelay)
+// record stores the outcome of reference unless a concurrent call stored one first, and
+// returns the stored outcome. The oldest reference is evicted once maxOperations are stored.
+func (m *MockAcquirer) record(reference string, op Operation) Operation {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.operations[reference]; ok {
+		return existing
 	}
-	if rand.Float64() < m.SettleSuccessRate {
-		return SettleResult{Success: true}, nil
+	if len(m.references) >= maxOperations {
+		delete(m.operations, m.references[0])
+		m.references = m.references[1:]
 	}
-	return SettleResult{Success: false, Reason: "settlement_rejected"}, nil
+	m.operations[reference] = op
+	m.references = append(m.references, reference)
+	return op
 }
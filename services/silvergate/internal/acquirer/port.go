@@ -1,6 +1,12 @@
 package acquirer
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrReferenceNotFound is returned by Lookup for a reference the acquirer never received.
+var ErrReferenceNotFound = errors.New("acquirer reference not found")
 
 type AuthResult struct {
 	Approved      bool
@@ -22,10 +28,25 @@ type RefundResult struct {
 	Reason  string
 }
 
+// Operation is the acquirer's record of an authorization, settlement or refund. Success
+// is an approval for authorizations; Reason explains a decline or rejection.
+type Operation struct {
+	Success bool
+	Reason  string
+}
+
 // Acquirer represents a bank/card network that processes authorization and settlement.
+//
+// Authorize, Settle and Refund take a reference, the caller's idempotency key for the
+// operation: the acquirer carries out a reference once and answers repeats with the first
+// result, so a call whose outcome was lost can be retried or looked up.
 type Acquirer interface {
-	Authorize(ctx context.Context, amount int64, currency, cardToken string) (AuthResult, error)
-	Settle(ctx context.Context, txID string, amount int64) (SettleResult, error)
+	Authorize(ctx context.Context, reference string, amount int64, currency, cardToken string) (AuthResult, error)
+	Settle(ctx context.Context, reference, txID string, amount int64) (SettleResult, error)
 	Void(ctx context.Context, txID string) (VoidResult, error)
-	Refund(ctx context.Context, txID string, amount int64) (RefundResult, error)
+	// Release frees part of an authorization hold, e.g. the remainder after a final partial capture.
+	Release(ctx context.Context, txID string, amount int64) (VoidResult, error)
+	Refund(ctx context.Context, reference, txID string, amount int64) (RefundResult, error)
+	// Lookup returns the outcome of the operation sent with reference, or ErrReferenceNotFound.
+	Lookup(ctx context.Context, reference string) (Operation, error)
 }
@@ -0,0 +1,136 @@
+package idempotencyrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/idempotency"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+type PgIdempotencyRepo struct {
+	db postgres.Executor
+}
+
+var _ idempotency.Store = (*PgIdempotencyRepo)(nil)
+
+func NewPgIdempotencyRepo(db postgres.Executor) *PgIdempotencyRepo {
+	return &PgIdempotencyRepo{db: db}
+}
+
+func (r *PgIdempotencyRepo) Acquire(ctx context.Context, rec *idempotency.Record, lockTimeout time.Duration) (*idempotency.Record, bool, error) {
+	query, args, err := psql.
+		Insert("idempotency_keys").
+		Columns("merchant_id", "key", "endpoint", "fingerprint", "locked_at", "created_at", "updated_at").
+		Values(rec.MerchantID, rec.Key, rec.Endpoint, rec.Fingerprint,
+			sq.Expr("now()"), sq.Expr("now()"), sq.Expr("now()")).
+		Suffix("ON CONFLICT (merchant_id, key, endpoint) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("build insert idempotency key: %w", err)
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("exec insert idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+
+	// Take over a lock abandoned by a request that never completed (crash, lost connection).
+	tag, err = r.db.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET locked_at = now(), updated_at = now()
+		WHERE merchant_id = $1 AND key = $2 AND endpoint = $3
+		  AND fingerprint = $4
+		  AND response_status IS NULL
+		  AND locked_at < now() - make_interval(secs => $5)`,
+		rec.MerchantID, rec.Key, rec.Endpoint, rec.Fingerprint, lockTimeout.Seconds())
+	if err != nil {
+		return nil, false, fmt.Errorf("exec take over idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+
+	existing, err := r.get(ctx, rec)
+	if err != nil {
+		return nil, false, err
+	}
+	return existing, false, nil
+}
+
+func (r *PgIdempotencyRepo) Complete(ctx context.Context, rec *idempotency.Record) error {
+	query, args, err := psql.
+		Update("idempotency_keys").
+		Set("response_status", rec.ResponseStatus).
+		Set("response_content_type", rec.ResponseContentType).
+		Set("response_body", rec.ResponseBody).
+		Set("locked_at", nil).
+		Set("updated_at", sq.Expr("now()")).
+		Where(keyEq(rec)).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build complete idempotency key: %w", err)
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exec complete idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return idempotency.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PgIdempotencyRepo) Release(ctx context.Context, rec *idempotency.Record) error {
+	query, args, err := psql.
+		Delete("idempotency_keys").
+		Where(keyEq(rec)).
+		Where(sq.Eq{"response_status": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build release idempotency key: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *PgIdempotencyRepo) get(ctx context.Context, rec *idempotency.Record) (*idempotency.Record, error) {
+	query, args, err := psql.
+		Select("merchant_id", "key", "endpoint", "fingerprint", "response_status",
+			"COALESCE(response_content_type, '')", "response_body", "locked_at", "created_at", "updated_at").
+		From("idempotency_keys").
+		Where(keyEq(rec)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build get idempotency key: %w", err)
+	}
+
+	var out idempotency.Record
+	err = r.db.QueryRow(ctx, query, args...).Scan(&out.MerchantID, &out.Key, &out.Endpoint, &out.Fingerprint,
+		&out.ResponseStatus, &out.ResponseContentType, &out.ResponseBody, &out.LockedAt, &out.CreatedAt, &out.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, idempotency.ErrNotFound
+		}
+		return nil, fmt.Errorf("scan idempotency key: %w", err)
+	}
+	return &out, nil
+}
+
+func keyEq(rec *idempotency.Record) sq.Eq {
+	return sq.Eq{"merchant_id": rec.MerchantID, "key": rec.Key, "endpoint": rec.Endpoint}
+}
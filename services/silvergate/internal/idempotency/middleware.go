@@ -0,0 +1,174 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/merchantauth"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HeaderName is the request header carrying the client-chosen key.
+	HeaderName = "Idempotency-Key"
+	// ReplayedHeader is set on responses served from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+type Config struct {
+	// LockTimeout bounds how long an unfinished request holds its key.
+	LockTimeout time.Duration
+}
+
+// Middleware makes mutating routes safe to retry. Every POST/PUT/PATCH/DELETE must
+// carry an Idempotency-Key: the first request with a key runs the handler and its
+// response is stored; retries of the same request replay that response byte for byte,
+// a different request under the same key and endpoint gets 422, and retries while the
+// first request is still running get 409. GET/HEAD/OPTIONS requests pass through.
+//
+// Keys are scoped to the authenticated merchant and the endpoint, so the middleware must
+// run after merchantauth.Middleware.
+//
+// A 5xx response is not stored: the key is released so the client can retry. Handlers
+// that may have reached the acquirer before failing deduplicate the retry themselves
+// through their own idempotency keys and acquirer intents.
+func Middleware(store Store, cfg Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !mutating(c.Request.Method) {
+			c.Next()
+			return
+		}
+		key := c.GetHeader(HeaderName)
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "missing Idempotency-Key header"})
+			return
+		}
+		if len(key) > maxKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			return
+		}
+
+		merchantID, ok := merchantauth.FromContext(c.Request.Context())
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		rec := &Record{
+			MerchantID:  merchantID,
+			Key:         key,
+			Endpoint:    c.Request.Method + " " + c.FullPath(),
+			Fingerprint: fingerprint(c.Request, body),
+		}
+
+		ctx := c.Request.Context()
+		existing, acquired, err := store.Acquire(ctx, rec, cfg.LockTimeout)
+		if errors.Is(err, ErrNotFound) {
+			// The in-flight holder released the key between our insert and lookup.
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is in progress"})
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "idempotency key acquire failed", "key", key, "error", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "idempotency check failed"})
+			return
+		}
+
+		if !acquired {
+			switch {
+			case existing.Fingerprint != rec.Fingerprint:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity,
+					gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case !existing.Completed():
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is in progress"})
+			default:
+				c.Header(ReplayedHeader, "true")
+				c.Data(*existing.ResponseStatus, existing.ResponseContentType, existing.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		// Completion must not depend on the client staying connected.
+		storeCtx := context.WithoutCancel(ctx)
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		defer func() {
+			if p := recover(); p != nil {
+				if err := store.Release(storeCtx, rec); err != nil {
+					slog.ErrorContext(ctx, "idempotency key release failed", "key", key, "error", err)
+				}
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(storeCtx, rec); err != nil {
+				slog.ErrorContext(ctx, "idempotency key release failed", "key", key, "error", err)
+			}
+			return
+		}
+		rec.ResponseStatus = &status
+		rec.ResponseContentType = recorder.Header().Get("Content-Type")
+		rec.ResponseBody = recorder.body.Bytes()
+		if err := store.Complete(storeCtx, rec); err != nil {
+			slog.ErrorContext(ctx, "idempotency key completion failed", "key", key, "error", err)
+		}
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
+
+// fingerprint hashes what distinguishes two requests to the same endpoint: the concrete
+// path with its parameters, the query string and the body.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder tees the response body so it can be stored for replays.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,212 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/merchantauth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type fakeStore struct {
+	records map[string]*Record
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{records: map[string]*Record{}}
+}
+
+func storeKey(rec *Record) string {
+	return rec.MerchantID + "/" + rec.Key + "/" + rec.Endpoint
+}
+
+func (f *fakeStore) Acquire(_ context.Context, rec *Record, _ time.Duration) (*Record, bool, error) {
+	if existing, ok := f.records[storeKey(rec)]; ok {
+		return existing, false, nil
+	}
+	stored := *rec
+	f.records[storeKey(rec)] = &stored
+	return nil, true, nil
+}
+
+func (f *fakeStore) Complete(_ context.Context, rec *Record) error {
+	stored, ok := f.records[storeKey(rec)]
+	if !ok {
+		return ErrNotFound
+	}
+	stored.ResponseStatus = rec.ResponseStatus
+	stored.ResponseContentType = rec.ResponseContentType
+	stored.ResponseBody = append([]byte(nil), rec.ResponseBody...)
+	return nil
+}
+
+func (f *fakeStore) Release(_ context.Context, rec *Record) error {
+	delete(f.records, storeKey(rec))
+	return nil
+}
+
+func newTestEngine(store Store, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	api := engine.Group("/api/v1", merchantauth.Middleware(), Middleware(store, Config{LockTimeout: time.Minute}))
+	handler := func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusCreated, gin.H{"call": *calls, "at": time.Now().UnixNano()})
+	}
+	api.POST("/capture", handler)
+	api.POST("/refund", handler)
+	api.GET("/transactions", handler)
+	api.POST("/products/:id/archive", handler)
+	api.POST("/fail", func(c *gin.Context) {
+		*calls++
+		c.JSON(http.StatusInternalServerError, gin.H{"call": *calls})
+	})
+	return engine
+}
+
+func do(engine *gin.Engine, method, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(merchantauth.HeaderName, "merchant_1")
+	if key != "" {
+		req.Header.Set(HeaderName, key)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddleware_ReplaysStoredResponse(t *testing.T) {
+	var calls int
+	engine := newTestEngine(newFakeStore(), &calls)
+
+	first := do(engine, http.MethodPost, "/api/v1/capture", "k1", `{"amount":100}`)
+	second := do(engine, http.MethodPost, "/api/v1/capture", "k1", `{"amount":100}`)
+
+	if calls != 1 {
+		t.Fatalf("want the handler run once, got %d", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("want the first response replayed, got %d %q vs %q", second.Code, second.Body, first.Body)
+	}
+	if second.Header().Get("Content-Type") != first.Header().Get("Content-Type") || second.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("want replay headers, got %v", second.Header())
+	}
+}
+
+func TestMiddleware_RejectsKeyReuseWithDifferentRequest(t *testing.T) {
+	var calls int
+	engine := newTestEngine(newFakeStore(), &calls)
+
+	do(engine, http.MethodPost, "/api/v1/capture", "k1", `{"amount":100}`)
+	w := do(engine, http.MethodPost, "/api/v1/capture", "k1", `{"amount":200}`)
+
+	if w.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("want 422 without running the handler, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestMiddleware_FingerprintCoversPathParams(t *testing.T) {
+	var calls int
+	engine := newTestEngine(newFakeStore(), &calls)
+
+	do(engine, http.MethodPost, "/api/v1/products/p1/archive", "k1", "")
+	w := do(engine, http.MethodPost, "/api/v1/products/p2/archive", "k1", "")
+
+	if w.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("want 422 for another product under the same key, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestMiddleware_KeysAreScopedToEndpoint(t *testing.T) {
+	var calls int
+	engine := newTestEngine(newFakeStore(), &calls)
+
+	do(engine, http.MethodPost, "/api/v1/capture", "k1", `{"amount":100}`)
+	w := do(engine, http.MethodPost, "/api/v1/refund", "k1", `{"amount":100}`)
+
+	if w.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("want the key reusable on another endpoint, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestMiddleware_ConflictWhileInFlight(t *testing.T) {
+	var calls int
+	store := newFakeStore()
+	engine := newTestEngine(store, &calls)
+
+	body := `{"amount":100}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/capture", nil)
+	inFlight := &Record{
+		MerchantID:  "merchant_1",
+		Key:         "k1",
+		Endpoint:    "POST /api/v1/capture",
+		Fingerprint: fingerprint(req, []byte(body)),
+	}
+	store.records[storeKey(inFlight)] = inFlight
+
+	w := do(engine, http.MethodPost, "/api/v1/capture", "k1", body)
+
+	if w.Code != http.StatusConflict || calls != 0 {
+		t.Errorf("want 409 without running the handler, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestMiddleware_RequiresAuthenticatedMerchant(t *testing.T) {
+	var calls int
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	// Mounted without merchantauth: the X-Merchant-ID header alone must not scope keys.
+	engine.POST("/capture", Middleware(newFakeStore(), Config{LockTimeout: time.Minute}), func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+
+	w := do(engine, http.MethodPost, "/capture", "k1", `{}`)
+
+	if w.Code != http.StatusUnauthorized || calls != 0 {
+		t.Errorf("want 401 without an authenticated merchant, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestMiddleware_RequiresKeyOnMutatingRequests(t *testing.T) {
+	var calls int
+	engine := newTestEngine(newFakeStore(), &calls)
+
+	w := do(engine, http.MethodPost, "/api/v1/capture", "", `{"amount":100}`)
+
+	if w.Code != http.StatusBadRequest || calls != 0 {
+		t.Errorf("want 400 without Idempotency-Key, got %d after %d calls", w.Code, calls)
+	}
+}
+
+func TestMiddleware_ReleasesKeyOnServerError(t *testing.T) {
+	var calls int
+	store := newFakeStore()
+	engine := newTestEngine(store, &calls)
+
+	do(engine, http.MethodPost, "/api/v1/fail", "k1", `{}`)
+	w := do(engine, http.MethodPost, "/api/v1/fail", "k1", `{}`)
+
+	if calls != 2 || w.Header().Get(ReplayedHeader) != "" || len(store.records) != 0 {
+		t.Errorf("want the 5xx released and the retry run, got %d calls and %d records", calls, len(store.records))
+	}
+}
+
+func TestMiddleware_PassesThroughReads(t *testing.T) {
+	var calls int
+	store := newFakeStore()
+	engine := newTestEngine(store, &calls)
+
+	do(engine, http.MethodGet, "/api/v1/transactions", "", "")
+	do(engine, http.MethodGet, "/api/v1/transactions", "k1", "")
+	do(engine, http.MethodGet, "/api/v1/transactions", "k1", "")
+
+	if calls != 3 || len(store.records) != 0 {
+		t.Errorf("want every read handled and nothing stored, got %d calls and %d records", calls, len(store.records))
+	}
+}
@@ -0,0 +1,42 @@
+// Package idempotency makes mutating API requests safe to retry. A request carrying an
+// Idempotency-Key is recorded per merchant, key and endpoint together with a fingerprint
+// of the request; the response is stored and replayed to retries of the same request.
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Record is a stored Idempotency-Key. Endpoint is the route template (method and path
+// pattern), Fingerprint a hash of the concrete path, query and body. ResponseStatus is
+// nil while the first request holding the key is still in flight.
+type Record struct {
+	MerchantID          string
+	Key                 string
+	Endpoint            string
+	Fingerprint         string
+	ResponseStatus      *int
+	ResponseContentType string
+	ResponseBody        []byte
+	LockedAt            *time.Time
+	CreatedAt           time.Time
+	UpdatedAt           time.Time
+}
+
+// Completed reports whether a response has been stored for the key.
+func (r *Record) Completed() bool {
+	return r.ResponseStatus != nil
+}
+
+// Store persists idempotency records. Records are identified by MerchantID, Key and Endpoint.
+type Store interface {
+	// Acquire locks the key for a new request. When the key already exists it returns
+	// the stored record and acquired=false. An in-flight lock older than lockTimeout is
+	// taken over, so a crashed request does not block its key forever.
+	Acquire(ctx context.Context, rec *Record, lockTimeout time.Duration) (existing *Record, acquired bool, err error)
+	// Complete stores the response fields of rec and releases the lock.
+	Complete(ctx context.Context, rec *Record) error
+	// Release drops an in-flight key without a response so the request can be retried.
+	Release(ctx context.Context, rec *Record) error
+}
@@ -0,0 +1,163 @@
+// Package ledger keeps Silvergate's double-entry books: every money movement is an
+// immutable journal entry whose postings sum to zero.
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AccountType is one of the accounts every merchant has per currency.
+type AccountType string
+
+const (
+	// AccountPending holds authorized funds that are not captured yet.
+	AccountPending AccountType = "pending"
+	// AccountAvailable holds captured funds owed to the merchant.
+	AccountAvailable AccountType = "available"
+	// AccountFees holds processing fees charged to the merchant.
+	AccountFees AccountType = "fees"
+	// AccountRefundsReserve holds refunds accepted from the merchant and not yet settled.
+	AccountRefundsReserve AccountType = "refunds_reserve"
+	// AccountAcquirer is the counterweight of the merchant accounts: funds held by or
+	// returned to the acquirer. It is internal and never shown to merchants.
+	AccountAcquirer AccountType = "acquirer"
+)
+
+func (a AccountType) IsValid() bool {
+	switch a {
+	case AccountPending, AccountAvailable, AccountFees, AccountRefundsReserve, AccountAcquirer:
+		return true
+	}
+	return false
+}
+
+// EntryKind names the money movement an entry records.
+type EntryKind string
+
+const (
+	KindAuthorization EntryKind = "authorization"
+	KindCapture       EntryKind = "capture"
+	// KindRelease returns an uncaptured hold: a void, an expiry or the remainder of a final partial capture.
+	KindRelease        EntryKind = "release"
+	KindRefund         EntryKind = "refund"
+	KindRefundSettled  EntryKind = "refund_settled"
+	KindRefundReversal EntryKind = "refund_reversal"
+	// KindFee charges a processing fee to the merchant.
+	KindFee EntryKind = "fee"
+	// KindPayout pays available funds out to the merchant; it belongs to no transaction.
+	KindPayout EntryKind = "payout"
+)
+
+// Posting moves Amount into (positive) or out of (negative) an account of the entry's merchant.
+type Posting struct {
+	Account AccountType
+	Amount  int64
+}
+
+// Entry is one journal entry. Entries are never updated or deleted; a mistake is
+// corrected by a new entry.
+type Entry struct {
+	ID         uuid.UUID
+	MerchantID string
+	Currency   string
+	// TransactionID is nil for merchant-level entries such as payouts.
+	TransactionID *uuid.UUID
+	Kind          EntryKind
+	// ReferenceID is the capture, refund or payout the entry records, nil for
+	// authorization-level changes.
+	ReferenceID *uuid.UUID
+	Postings    []Posting
+	CreatedAt   time.Time
+}
+
+// Source identifies the transaction an entry belongs to.
+type Source struct {
+	MerchantID    string
+	Currency      string
+	TransactionID uuid.UUID
+}
+
+// Authorization puts an authorized hold into pending.
+func Authorization(src Source, amount int64) *Entry {
+	return newTransfer(src, KindAuthorization, nil, amount, AccountAcquirer, AccountPending)
+}
+
+// Capture moves a settled capture from pending to available.
+func Capture(src Source, captureID uuid.UUID, amount int64) *Entry {
+	return newTransfer(src, KindCapture, &captureID, amount, AccountPending, AccountAvailable)
+}
+
+// Release returns an uncaptured hold to the acquirer.
+func Release(src Source, amount int64) *Entry {
+	return newTransfer(src, KindRelease, nil, amount, AccountPending, AccountAcquirer)
+}
+
+// Refund reserves an accepted refund out of available.
+func Refund(src Source, refundID uuid.UUID, amount int64) *Entry {
+	return newTransfer(src, KindRefund, &refundID, amount, AccountAvailable, AccountRefundsReserve)
+}
+
+// RefundSettled pays a reserved refund out through the acquirer.
+func RefundSettled(src Source, refundID uuid.UUID, amount int64) *Entry {
+	return newTransfer(src, KindRefundSettled, &refundID, amount, AccountRefundsReserve, AccountAcquirer)
+}
+
+// RefundReversal returns a reserved refund the acquirer rejected to available.
+func RefundReversal(src Source, refundID uuid.UUID, amount int64) *Entry {
+	return newTransfer(src, KindRefundReversal, &refundID, amount, AccountRefundsReserve, AccountAvailable)
+}
+
+// Fee charges a processing fee for the capture, refund or dispute referenceID out of available.
+func Fee(src Source, referenceID uuid.UUID, amount int64) *Entry {
+	return newTransfer(src, KindFee, &referenceID, amount, AccountAvailable, AccountFees)
+}
+
+// Payout pays available funds out to the merchant through the acquirer.
+func Payout(merchantID, currency string, payoutID uuid.UUID, amount int64) *Entry {
+	e := newTransfer(Source{MerchantID: merchantID, Currency: currency}, KindPayout, &payoutID, amount, AccountAvailable, AccountAcquirer)
+	e.TransactionID = nil
+	return e
+}
+
+func newTransfer(src Source, kind EntryKind, referenceID *uuid.UUID, amount int64, from, to AccountType) *Entry {
+	return &Entry{
+		ID:            uuid.New(),
+		MerchantID:    src.MerchantID,
+		Currency:      src.Currency,
+		TransactionID: &src.TransactionID,
+		Kind:          kind,
+		ReferenceID:   referenceID,
+		Postings: []Posting{
+			{Account: from, Amount: -amount},
+			{Account: to, Amount: amount},
+		},
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// Validate checks that the entry is balanced and postable.
+func (e *Entry) Validate() error {
+	if e.MerchantID == "" || e.Currency == "" {
+		return fmt.Errorf("%w: merchant and currency are required", ErrInvalidEntry)
+	}
+	if len(e.Postings) < 2 {
+		return fmt.Errorf("%w: at least two postings are required", ErrInvalidEntry)
+	}
+	var sum int64
+	for _, p := range e.Postings {
+		if !p.Account.IsValid() {
+			return fmt.Errorf("%w: unknown account %q", ErrInvalidEntry, p.Account)
+		}
+		if p.Amount == 0 {
+			return fmt.Errorf("%w: zero posting to %s", ErrInvalidEntry, p.Account)
+		}
+		sum += p.Amount
+	}
+	if sum != 0 {
+		return fmt.Errorf("%w: postings sum to %d", ErrUnbalanced, sum)
+	}
+	return nil
+}
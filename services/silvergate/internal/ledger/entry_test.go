@@ -0,0 +1,89 @@
+package ledger
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEntryConstructors_AreBalanced(t *testing.T) {
+	src := Source{MerchantID: "m1", Currency: "USD", TransactionID: uuid.New()}
+	ref := uuid.New()
+
+	entries := []*Entry{
+		Authorization(src, 5000),
+		Capture(src, ref, 3000),
+		Release(src, 2000),
+		Refund(src, ref, 1000),
+		RefundSettled(src, ref, 1000),
+		RefundReversal(src, ref, 1000),
+		Fee(src, ref, 117),
+	}
+	for _, e := range entries {
+		if err := e.Validate(); err != nil {
+			t.Errorf("%s: %v", e.Kind, err)
+		}
+		if e.TransactionID == nil || *e.TransactionID != src.TransactionID || e.MerchantID != "m1" || e.Currency != "USD" {
+			t.Errorf("%s: source not carried over: %+v", e.Kind, e)
+		}
+	}
+}
+
+func TestPayout_HasNoTransaction(t *testing.T) {
+	payoutID := uuid.New()
+	e := Payout("m1", "USD", payoutID, 2500)
+
+	if err := e.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if e.TransactionID != nil {
+		t.Errorf("want no transaction, got %s", e.TransactionID)
+	}
+	if e.ReferenceID == nil || *e.ReferenceID != payoutID {
+		t.Errorf("want reference %s, got %v", payoutID, e.ReferenceID)
+	}
+	if e.Postings[0] != (Posting{Account: AccountAvailable, Amount: -2500}) {
+		t.Errorf("want available debited, got %+v", e.Postings[0])
+	}
+}
+
+func TestCapture_MovesPendingToAvailable(t *testing.T) {
+	e := Capture(Source{MerchantID: "m1", Currency: "USD", TransactionID: uuid.New()}, uuid.New(), 3000)
+
+	want := []Posting{{Account: AccountPending, Amount: -3000}, {Account: AccountAvailable, Amount: 3000}}
+	if len(e.Postings) != len(want) {
+		t.Fatalf("want %d postings, got %d", len(want), len(e.Postings))
+	}
+	for i := range want {
+		if e.Postings[i] != want[i] {
+			t.Errorf("posting %d: want %+v, got %+v", i, want[i], e.Postings[i])
+		}
+	}
+}
+
+func TestEntryValidate(t *testing.T) {
+	valid := func() *Entry {
+		return Authorization(Source{MerchantID: "m1", Currency: "USD", TransactionID: uuid.New()}, 100)
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(*Entry)
+		want   error
+	}{
+		{"balanced", func(*Entry) {}, nil},
+		{"unbalanced", func(e *Entry) { e.Postings[1].Amount = 90 }, ErrUnbalanced},
+		{"single posting", func(e *Entry) { e.Postings = e.Postings[:1] }, ErrInvalidEntry},
+		{"zero posting", func(e *Entry) { e.Postings = append(e.Postings, Posting{Account: AccountFees}) }, ErrInvalidEntry},
+		{"unknown account", func(e *Entry) { e.Postings[0].Account = "cash" }, ErrInvalidEntry},
+		{"missing merchant", func(e *Entry) { e.MerchantID = "" }, ErrInvalidEntry},
+	}
+	for _, tc := range cases {
+		e := valid()
+		tc.mutate(e)
+		if err := e.Validate(); !errors.Is(err, tc.want) {
+			t.Errorf("%s: want %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
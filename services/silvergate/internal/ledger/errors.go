@@ -0,0 +1,8 @@
+package ledger
+
+import "errors"
+
+var (
+	ErrInvalidEntry = errors.New("invalid ledger entry")
+	ErrUnbalanced   = errors.New("ledger entry is not balanced")
+)
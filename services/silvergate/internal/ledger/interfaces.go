@@ -0,0 +1,16 @@
+package ledger
+
+import "context"
+
+// Repo is the persistence contract for the journal.
+type Repo interface {
+	// Post validates e and writes it with its postings.
+	Post(ctx context.Context, e *Entry) error
+	// ListBalances returns the balance of every account of the merchant that has postings.
+	ListBalances(ctx context.Context, merchantID string) ([]AccountBalance, error)
+	// ListUnbalancedEntries returns entries whose stored postings do not sum to zero.
+	ListUnbalancedEntries(ctx context.Context) ([]UnbalancedEntry, error)
+	// ListTransactionTotals returns the ledger totals of every transaction that has entries,
+	// next to the amounts on the transaction row.
+	ListTransactionTotals(ctx context.Context) ([]TransactionTotals, error)
+}
@@ -0,0 +1,57 @@
+package ledgercontroller
+
+import (
+	"net/http"
+
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+	"TestTaskJustPay/services/silvergate/internal/merchantauth"
+
+	"github.com/gin-gonic/gin"
+)
+
+type balanceResponse struct {
+	Currency       string `json:"currency"`
+	Pending        int64  `json:"pending"`
+	Available      int64  `json:"available"`
+	Fees           int64  `json:"fees"`
+	RefundsReserve int64  `json:"refunds_reserve"`
+}
+
+type balancesResponse struct {
+	MerchantID string            `json:"merchant_id"`
+	Balances   []balanceResponse `json:"balances"`
+}
+
+type BalanceHandler struct {
+	svc *ledger.Service
+}
+
+func NewBalanceHandler(svc *ledger.Service) *BalanceHandler {
+	return &BalanceHandler{svc: svc}
+}
+
+func (h *BalanceHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	balances, err := h.svc.Balances(c.Request.Context(), merchantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return
+	}
+
+	resp := balancesResponse{MerchantID: merchantID, Balances: make([]balanceResponse, 0, len(balances))}
+	for _, b := range balances {
+		resp.Balances = append(resp.Balances, balanceResponse{
+			Currency:       b.Currency,
+			Pending:        b.Pending,
+			Available:      b.Available,
+			Fees:           b.Fees,
+			RefundsReserve: b.RefundsReserve,
+		})
+	}
+	c.JSON(http.StatusOK, resp)
+}
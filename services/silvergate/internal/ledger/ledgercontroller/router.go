@@ -0,0 +1,15 @@
+package ledgercontroller
+
+import (
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes constructs the balance handler from svc and mounts it on rg.
+// Callers wire the merchant-auth middleware on rg before calling this.
+func RegisterRoutes(rg *gin.RouterGroup, svc *ledger.Service) {
+	balances := NewBalanceHandler(svc)
+
+	rg.GET("", balances.Handle)
+}
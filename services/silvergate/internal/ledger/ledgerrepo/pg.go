@@ -0,0 +1,139 @@
+package ledgerrepo
+
+import (
+	"context"
+	"fmt"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+
+	sq "github.com/Masterminds/squirrel"
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+type PgLedgerRepo struct {
+	db postgres.Executor
+}
+
+func NewPgLedgerRepo(db postgres.Executor) *PgLedgerRepo {
+	return &PgLedgerRepo{db: db}
+}
+
+// Post writes the entry and its postings. Callers pass a tx-bound repo so the entry
+// commits together with the state change it records.
+func (r *PgLedgerRepo) Post(ctx context.Context, e *ledger.Entry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+
+	query, args, err := psql.
+		Insert("ledger_entries").
+		Columns("id", "merchant_id", "currency", "transaction_id", "kind", "reference_id", "created_at").
+		Values(e.ID, e.MerchantID, e.Currency, e.TransactionID, e.Kind, e.ReferenceID, e.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert entry: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec insert entry: %w", err)
+	}
+
+	insert := psql.
+		Insert("ledger_postings").
+		Columns("entry_id", "merchant_id", "currency", "account_type", "amount", "created_at")
+	for _, p := range e.Postings {
+		insert = insert.Values(e.ID, e.MerchantID, e.Currency, p.Account, p.Amount, e.CreatedAt)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert postings: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec insert postings: %w", err)
+	}
+	return nil
+}
+
+func (r *PgLedgerRepo) ListBalances(ctx context.Context, merchantID string) ([]ledger.AccountBalance, error) {
+	query, args, err := psql.
+		Select("currency", "account_type", "SUM(amount)::BIGINT").
+		From("ledger_postings").
+		Where(sq.Eq{"merchant_id": merchantID}).
+		GroupBy("currency", "account_type").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []ledger.AccountBalance
+	for rows.Next() {
+		b := ledger.AccountBalance{MerchantID: merchantID}
+		if err := rows.Scan(&b.Currency, &b.Account, &b.Balance); err != nil {
+			return nil, fmt.Errorf("scan balance: %w", err)
+		}
+		balances = append(balances, b)
+	}
+	return balances, rows.Err()
+}
+
+func (r *PgLedgerRepo) ListUnbalancedEntries(ctx context.Context) ([]ledger.UnbalancedEntry, error) {
+	const query = `SELECT e.id, COALESCE(SUM(p.amount), 0)::BIGINT
+		FROM ledger_entries e
+		LEFT JOIN ledger_postings p ON p.entry_id = e.id
+		GROUP BY e.id
+		HAVING COALESCE(SUM(p.amount), 0) <> 0 OR COUNT(p.id) < 2
+		ORDER BY e.id`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query unbalanced entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []ledger.UnbalancedEntry
+	for rows.Next() {
+		var e ledger.UnbalancedEntry
+		if err := rows.Scan(&e.EntryID, &e.Sum); err != nil {
+			return nil, fmt.Errorf("scan unbalanced entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (r *PgLedgerRepo) ListTransactionTotals(ctx context.Context) ([]ledger.TransactionTotals, error) {
+	const query = `SELECT t.id, t.status, t.amount, t.captured_amount, t.refunded_amount, t.fee_amount,
+		       COALESCE(SUM(p.amount) FILTER (WHERE p.account_type = 'pending'), 0)::BIGINT,
+		       COALESCE(SUM(p.amount) FILTER (WHERE p.account_type = 'available' AND e.kind = 'capture'), 0)::BIGINT,
+		       COALESCE(-SUM(p.amount) FILTER (WHERE p.account_type = 'available' AND e.kind IN ('refund', 'refund_reversal')), 0)::BIGINT,
+		       COALESCE(SUM(p.amount) FILTER (WHERE p.account_type = 'fees'), 0)::BIGINT
+		FROM transactions t
+		JOIN ledger_entries e ON e.transaction_id = t.id
+		JOIN ledger_postings p ON p.entry_id = e.id
+		GROUP BY t.id
+		ORDER BY t.id`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query transaction totals: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []ledger.TransactionTotals
+	for rows.Next() {
+		var t ledger.TransactionTotals
+		if err := rows.Scan(&t.TransactionID, &t.Status, &t.Amount, &t.CapturedAmount, &t.RefundedAmount, &t.FeeAmount,
+			&t.LedgerPending, &t.LedgerCaptured, &t.LedgerRefunded, &t.LedgerFees); err != nil {
+			return nil, fmt.Errorf("scan transaction totals: %w", err)
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
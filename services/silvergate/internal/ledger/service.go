@@ -0,0 +1,171 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type Service struct {
+	repo Repo
+}
+
+func NewService(repo Repo) *Service {
+	return &Service{repo: repo}
+}
+
+// AccountBalance is the sum of one account's postings.
+type AccountBalance struct {
+	MerchantID string
+	Currency   string
+	Account    AccountType
+	Balance    int64
+}
+
+// Balance is what a merchant sees of its accounts in one currency.
+type Balance struct {
+	Currency       string
+	Pending        int64
+	Available      int64
+	Fees           int64
+	RefundsReserve int64
+}
+
+// Balances returns the merchant's balances per currency, ordered by currency.
+func (s *Service) Balances(ctx context.Context, merchantID string) ([]Balance, error) {
+	accounts, err := s.repo.ListBalances(ctx, merchantID)
+	if err != nil {
+		return nil, fmt.Errorf("list balances: %w", err)
+	}
+
+	byCurrency := make(map[string]*Balance)
+	for _, a := range accounts {
+		b, ok := byCurrency[a.Currency]
+		if !ok {
+			b = &Balance{Currency: a.Currency}
+			byCurrency[a.Currency] = b
+		}
+		switch a.Account {
+		case AccountPending:
+			b.Pending = a.Balance
+		case AccountAvailable:
+			b.Available = a.Balance
+		case AccountFees:
+			b.Fees = a.Balance
+		case AccountRefundsReserve:
+			b.RefundsReserve = a.Balance
+		case AccountAcquirer:
+			// Internal counterweight, not a merchant balance.
+		}
+	}
+
+	balances := make([]Balance, 0, len(byCurrency))
+	for _, b := range byCurrency {
+		balances = append(balances, *b)
+	}
+	slices.SortFunc(balances, func(a, b Balance) int { return strings.Compare(a.Currency, b.Currency) })
+	return balances, nil
+}
+
+// UnbalancedEntry is a stored entry whose postings do not sum to zero.
+type UnbalancedEntry struct {
+	EntryID uuid.UUID
+	Sum     int64
+}
+
+// TransactionTotals compares a transaction row with what the ledger recorded for it.
+type TransactionTotals struct {
+	TransactionID  uuid.UUID
+	Status         string
+	Amount         int64
+	CapturedAmount int64
+	RefundedAmount int64
+	FeeAmount      int64
+	// LedgerPending is the transaction's share of the pending account.
+	LedgerPending int64
+	// LedgerCaptured is what its captures moved into available.
+	LedgerCaptured int64
+	// LedgerRefunded is what its refunds took out of available, net of reversals.
+	LedgerRefunded int64
+	// LedgerFees is what its fees moved into the fees account.
+	LedgerFees int64
+}
+
+// openHoldStatuses are the transaction statuses whose authorization hold is not released.
+var openHoldStatuses = map[string]bool{
+	"authorized":         true,
+	"capture_pending":    true,
+	"partially_captured": true,
+	"capture_failed":     true,
+}
+
+// Mismatch is a transaction whose row and ledger disagree on one amount.
+type Mismatch struct {
+	TransactionID uuid.UUID
+	Status        string
+	// Field is pending, captured, refunded or fees.
+	Field    string
+	Expected int64
+	Ledger   int64
+}
+
+// mismatches compares t's ledger totals with the amounts its row implies.
+func (t TransactionTotals) mismatches() []Mismatch {
+	var expectedPending int64
+	if openHoldStatuses[t.Status] {
+		expectedPending = t.Amount - t.CapturedAmount
+	}
+
+	var out []Mismatch
+	check := func(field string, expected, ledger int64) {
+		if expected != ledger {
+			out = append(out, Mismatch{
+				TransactionID: t.TransactionID,
+				Status:        t.Status,
+				Field:         field,
+				Expected:      expected,
+				Ledger:        ledger,
+			})
+		}
+	}
+	check("pending", expectedPending, t.LedgerPending)
+	check("captured", t.CapturedAmount, t.LedgerCaptured)
+	check("refunded", t.RefundedAmount, t.LedgerRefunded)
+	check("fees", t.FeeAmount, t.LedgerFees)
+	return out
+}
+
+// Report is the outcome of an invariant check.
+type Report struct {
+	UnbalancedEntries []UnbalancedEntry
+	Mismatches        []Mismatch
+	// Transactions is how many transactions with entries were compared.
+	Transactions int
+}
+
+func (r Report) OK() bool {
+	return len(r.UnbalancedEntries) == 0 && len(r.Mismatches) == 0
+}
+
+// Check verifies the ledger invariants: every entry sums to zero, so funds are conserved,
+// and every transaction's pending, captured, refunded and fee amounts match its postings.
+// Transactions that predate the ledger have no entries and are not compared.
+func (s *Service) Check(ctx context.Context) (Report, error) {
+	unbalanced, err := s.repo.ListUnbalancedEntries(ctx)
+	if err != nil {
+		return Report{}, fmt.Errorf("list unbalanced entries: %w", err)
+	}
+	totals, err := s.repo.ListTransactionTotals(ctx)
+	if err != nil {
+		return Report{}, fmt.Errorf("list transaction totals: %w", err)
+	}
+
+	report := Report{UnbalancedEntries: unbalanced, Transactions: len(totals)}
+	for _, t := range totals {
+		report.Mismatches = append(report.Mismatches, t.mismatches()...)
+	}
+	return report, nil
+}
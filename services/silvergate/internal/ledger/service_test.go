@@ -0,0 +1,92 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type fakeRepo struct {
+	balances   []AccountBalance
+	unbalanced []UnbalancedEntry
+	totals     []TransactionTotals
+}
+
+func (f *fakeRepo) Post(context.Context, *Entry) error { return nil }
+
+func (f *fakeRepo) ListBalances(context.Context, string) ([]AccountBalance, error) {
+	return f.balances, nil
+}
+
+func (f *fakeRepo) ListUnbalancedEntries(context.Context) ([]UnbalancedEntry, error) {
+	return f.unbalanced, nil
+}
+
+func (f *fakeRepo) ListTransactionTotals(context.Context) ([]TransactionTotals, error) {
+	return f.totals, nil
+}
+
+func TestBalances_GroupsAccountsByCurrency(t *testing.T) {
+	repo := &fakeRepo{balances: []AccountBalance{
+		{Currency: "USD", Account: AccountPending, Balance: 500},
+		{Currency: "EUR", Account: AccountAvailable, Balance: 700},
+		{Currency: "USD", Account: AccountAvailable, Balance: 2000},
+		{Currency: "USD", Account: AccountRefundsReserve, Balance: 100},
+		{Currency: "USD", Account: AccountAcquirer, Balance: -2600},
+	}}
+
+	got, err := NewService(repo).Balances(context.Background(), "m1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Balance{
+		{Currency: "EUR", Available: 700},
+		{Currency: "USD", Pending: 500, Available: 2000, RefundsReserve: 100},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("want %+v, got %+v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("balance %d: want %+v, got %+v", i, want[i], got[i])
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	consistent := TransactionTotals{
+		TransactionID: uuid.New(), Status: "partially_captured",
+		Amount: 5000, CapturedAmount: 2000, FeeAmount: 88, LedgerPending: 3000, LedgerCaptured: 2000, LedgerFees: 88,
+	}
+	closedHold := TransactionTotals{
+		TransactionID: uuid.New(), Status: "partially_refunded",
+		Amount: 5000, CapturedAmount: 3000, RefundedAmount: 1000,
+		LedgerPending: 2000, LedgerCaptured: 3000, LedgerRefunded: 1000,
+	}
+	unbalanced := UnbalancedEntry{EntryID: uuid.New(), Sum: 10}
+	repo := &fakeRepo{
+		unbalanced: []UnbalancedEntry{unbalanced},
+		totals:     []TransactionTotals{consistent, closedHold},
+	}
+
+	report, err := NewService(repo).Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.OK() {
+		t.Fatal("want violations")
+	}
+	if report.Transactions != 2 {
+		t.Errorf("want 2 transactions checked, got %d", report.Transactions)
+	}
+	if len(report.UnbalancedEntries) != 1 || report.UnbalancedEntries[0] != unbalanced {
+		t.Errorf("want unbalanced entry %+v, got %+v", unbalanced, report.UnbalancedEntries)
+	}
+	want := Mismatch{TransactionID: closedHold.TransactionID, Status: "partially_refunded", Field: "pending", Expected: 0, Ledger: 2000}
+	if len(report.Mismatches) != 1 || report.Mismatches[0] != want {
+		t.Errorf("want mismatch %+v, got %+v", want, report.Mismatches)
+	}
+}
@@ -0,0 +1,62 @@
+// Package pricing resolves what Silvergate charges a merchant for captures, refunds and
+// chargebacks.
+package pricing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FeeKind names the event a fee is charged for.
+type FeeKind string
+
+const (
+	FeeCapture    FeeKind = "capture"
+	FeeRefund     FeeKind = "refund"
+	FeeChargeback FeeKind = "chargeback"
+)
+
+// bpsDenominator converts basis points to a fraction: 290 bps is 2.9%.
+const bpsDenominator = 10_000
+
+// Plan is a merchant's pricing from EffectiveFrom on. Plans are never edited: a price change
+// is a new plan with a later EffectiveFrom, so fees already charged keep the plan they were
+// computed with. All amounts are in minor units of the currency.
+type Plan struct {
+	ID         uuid.UUID
+	MerchantID string
+	// Currency is nil for the merchant-wide plan; a currency plan overrides it.
+	Currency *string
+	// PercentBps and FixedFee price a capture: PercentBps basis points of the amount plus FixedFee.
+	PercentBps    int64
+	FixedFee      int64
+	RefundFee     int64
+	ChargebackFee int64
+	EffectiveFrom time.Time
+	CreatedAt     time.Time
+}
+
+// Fee is what the plan charges for an event of kind moving amount. The percentage part
+// is rounded half up to a whole minor unit.
+func (p Plan) Fee(kind FeeKind, amount int64) int64 {
+	switch kind {
+	case FeeCapture:
+		return (amount*p.PercentBps+bpsDenominator/2)/bpsDenominator + p.FixedFee
+	case FeeRefund:
+		return p.RefundFee
+	case FeeChargeback:
+		return p.ChargebackFee
+	}
+	return 0
+}
+
+// Event is a money movement to price.
+type Event struct {
+	MerchantID string
+	Currency   string
+	Kind       FeeKind
+	Amount     int64
+	// At selects the plan in effect; it is when the event happened.
+	At time.Time
+}
@@ -0,0 +1,28 @@
+package pricing
+
+import "testing"
+
+func TestPlanFee(t *testing.T) {
+	plan := Plan{PercentBps: 290, FixedFee: 30, RefundFee: 25, ChargebackFee: 1500}
+
+	tests := []struct {
+		name   string
+		kind   FeeKind
+		amount int64
+		want   int64
+	}{
+		{"capture is percentage plus fixed", FeeCapture, 10000, 320},
+		{"capture rounds half up", FeeCapture, 50, 31},
+		{"capture rounds down below half", FeeCapture, 17, 30},
+		{"refund is flat", FeeRefund, 10000, 25},
+		{"chargeback is flat", FeeChargeback, 1, 1500},
+		{"unknown kind is free", FeeKind("adjustment"), 10000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := plan.Fee(tt.kind, tt.amount); got != tt.want {
+				t.Errorf("want %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
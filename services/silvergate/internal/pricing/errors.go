@@ -0,0 +1,6 @@
+package pricing
+
+import "errors"
+
+// ErrPlanNotFound means the merchant has no plan in effect; the default plan applies.
+var ErrPlanNotFound = errors.New("pricing plan not found")
@@ -0,0 +1,13 @@
+package pricing
+
+import (
+	"context"
+	"time"
+)
+
+// Repo is the persistence contract for pricing plans. Plans are managed in SQL.
+type Repo interface {
+	// GetPlan returns the merchant's plan in effect at for currency, preferring a currency
+	// plan over the merchant-wide one. Returns ErrPlanNotFound when neither is in effect.
+	GetPlan(ctx context.Context, merchantID, currency string, at time.Time) (*Plan, error)
+}
@@ -0,0 +1,53 @@
+package pricingrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/pricing"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+type PgPricingRepo struct {
+	db postgres.Executor
+}
+
+func NewPgPricingRepo(db postgres.Executor) *PgPricingRepo {
+	return &PgPricingRepo{db: db}
+}
+
+func (r *PgPricingRepo) GetPlan(ctx context.Context, merchantID, currency string, at time.Time) (*pricing.Plan, error) {
+	query, args, err := psql.
+		Select("id", "merchant_id", "currency", "percent_bps", "fixed_fee", "refund_fee", "chargeback_fee",
+			"effective_from", "created_at").
+		From("pricing_plans").
+		Where(sq.Eq{"merchant_id": merchantID}).
+		Where(sq.Or{sq.Eq{"currency": currency}, sq.Eq{"currency": nil}}).
+		Where(sq.LtOrEq{"effective_from": at}).
+		OrderBy("currency NULLS LAST", "effective_from DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select pricing plan: %w", err)
+	}
+
+	var p pricing.Plan
+	err = r.db.QueryRow(ctx, query, args...).Scan(
+		&p.ID, &p.MerchantID, &p.Currency, &p.PercentBps, &p.FixedFee, &p.RefundFee, &p.ChargebackFee,
+		&p.EffectiveFrom, &p.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pricing.ErrPlanNotFound
+		}
+		return nil, fmt.Errorf("scan pricing plan: %w", err)
+	}
+	return &p, nil
+}
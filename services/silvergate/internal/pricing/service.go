@@ -0,0 +1,38 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+type Service struct {
+	repo Repo
+	// defaultPlan applies to merchants without a plan in effect.
+	defaultPlan Plan
+}
+
+func NewService(repo Repo, defaultPlan Plan) *Service {
+	return &Service{repo: repo, defaultPlan: defaultPlan}
+}
+
+// PlanFor returns the plan in effect for the event's merchant, currency and time.
+func (s *Service) PlanFor(ctx context.Context, ev Event) (Plan, error) {
+	plan, err := s.repo.GetPlan(ctx, ev.MerchantID, ev.Currency, ev.At)
+	if errors.Is(err, ErrPlanNotFound) {
+		return s.defaultPlan, nil
+	}
+	if err != nil {
+		return Plan{}, fmt.Errorf("get pricing plan: %w", err)
+	}
+	return *plan, nil
+}
+
+// Fee prices the event with the plan in effect when it happened.
+func (s *Service) Fee(ctx context.Context, ev Event) (int64, error) {
+	plan, err := s.PlanFor(ctx, ev)
+	if err != nil {
+		return 0, err
+	}
+	return plan.Fee(ev.Kind, ev.Amount), nil
+}
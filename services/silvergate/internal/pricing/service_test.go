@@ -0,0 +1,64 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeRepo struct {
+	plan *Plan
+	err  error
+	// at records the time the last plan was resolved for.
+	at time.Time
+}
+
+func (r *fakeRepo) GetPlan(_ context.Context, _, _ string, at time.Time) (*Plan, error) {
+	r.at = at
+	if r.err != nil {
+		return nil, r.err
+	}
+	if r.plan == nil {
+		return nil, ErrPlanNotFound
+	}
+	return r.plan, nil
+}
+
+func TestFee_UsesPlanInEffectAtEvent(t *testing.T) {
+	at := time.Date(2026, time.October, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeRepo{plan: &Plan{MerchantID: "m1", PercentBps: 100, FixedFee: 10}}
+	svc := NewService(repo, Plan{PercentBps: 290, FixedFee: 30})
+
+	fee, err := svc.Fee(context.Background(), Event{MerchantID: "m1", Currency: "USD", Kind: FeeCapture, Amount: 5000, At: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee != 60 {
+		t.Errorf("want 60, got %d", fee)
+	}
+	if !repo.at.Equal(at) {
+		t.Errorf("want plan resolved at %s, got %s", at, repo.at)
+	}
+}
+
+func TestFee_FallsBackToDefaultPlan(t *testing.T) {
+	svc := NewService(&fakeRepo{}, Plan{PercentBps: 290, FixedFee: 30, ChargebackFee: 1500})
+
+	fee, err := svc.Fee(context.Background(), Event{MerchantID: "m1", Currency: "EUR", Kind: FeeChargeback, At: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fee != 1500 {
+		t.Errorf("want 1500, got %d", fee)
+	}
+}
+
+func TestFee_RepoError(t *testing.T) {
+	boom := errors.New("boom")
+	svc := NewService(&fakeRepo{err: boom}, Plan{FixedFee: 30})
+
+	if _, err := svc.Fee(context.Background(), Event{Kind: FeeCapture, Amount: 100}); !errors.Is(err, boom) {
+		t.Errorf("want %v, got %v", boom, err)
+	}
+}
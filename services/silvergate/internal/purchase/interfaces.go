@@ -10,11 +10,11 @@ import (
 	"github.com/google/uuid"
 )
 
-// Authorizer composes the acquirer call and the transaction insert using the
-// caller-supplied repo so /purchase can run authorization atomically inside its
+// Authorizer composes the acquirer call and the transaction insert inside the
+// caller-supplied DB tx so /purchase can run authorization atomically inside its
 // own DB transaction.
 type Authorizer interface {
-	AuthorizeInTx(ctx context.Context, repo transaction.Repo, req transaction.AuthRequest) (*transaction.Transaction, error)
+	AuthorizeInTx(ctx context.Context, dbTx postgres.Executor, req transaction.AuthRequest) (*transaction.Transaction, error)
 }
 
 // Capturer kicks off the bank settlement leg of /purchase. Runs in its own DB
@@ -28,10 +28,3 @@ type ProductService interface {
 	Get(ctx context.Context, merchantID string, id uuid.UUID) (*product.Product, error)
 	MarkPurchasedInTx(ctx context.Context, exec postgres.Executor, merchantID string, id uuid.UUID) error
 }
-
-// TxLookup is the pre-check side of idempotency — finds an existing transaction
-// for (merchant_id, purchase_idempotency_key). Returns transaction.ErrNotFound
-// when no row exists.
-type TxLookup interface {
-	GetByPurchaseIdempotencyKey(ctx context.Context, merchantID, key string) (*transaction.Transaction, error)
-}
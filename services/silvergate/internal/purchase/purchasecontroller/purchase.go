@@ -4,17 +4,17 @@ import (
 	"errors"
 	"net/http"
 
+	"TestTaskJustPay/services/silvergate/internal/idempotency"
 	"TestTaskJustPay/services/silvergate/internal/merchantauth"
 	"TestTaskJustPay/services/silvergate/internal/product"
 	"TestTaskJustPay/services/silvergate/internal/purchase"
 	"TestTaskJustPay/services/silvergate/internal/transaction"
+	"TestTaskJustPay/services/silvergate/internal/vault"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-const idempotencyHeader = "Idempotency-Key"
-
 type purchaseRequest struct {
 	OrderID   string `json:"order_id" binding:"required"`
 	ProductID string `json:"product_id" binding:"required,uuid"`
@@ -56,11 +56,9 @@ func (h *Handler) Handle(c *gin.Context) {
 		return
 	}
 
-	idempotencyKey := c.GetHeader(idempotencyHeader)
-	if idempotencyKey == "" {
-		c.JSON(http.StatusBadRequest, errorResponse{Error: "missing Idempotency-Key header", Code: "missing_idempotency_key"})
-		return
-	}
+	// idempotency.Middleware requires the key and replays on it; the service also stores
+	// it on the transaction so a released idempotency record cannot double-charge.
+	idempotencyKey := c.GetHeader(idempotency.HeaderName)
 
 	var req purchaseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -93,10 +91,12 @@ func writeError(c *gin.Context, partial purchase.Response, err error) {
 	switch {
 	case errors.Is(err, product.ErrNotFound):
 		c.JSON(http.StatusNotFound, errorResponse{Error: "product not found", Code: "product_not_found"})
+	case errors.Is(err, vault.ErrNotFound):
+		c.JSON(http.StatusUnprocessableEntity, errorResponse{Error: "unknown card token", Code: "card_not_found"})
 	case errors.Is(err, purchase.ErrProductArchived):
 		c.JSON(http.StatusUnprocessableEntity, errorResponse{Error: "product is archived", Code: "product_archived"})
-	case errors.Is(err, purchase.ErrIdempotencyConflict):
-		c.JSON(http.StatusConflict, errorResponse{Error: "idempotency key reused with different request", Code: "idempotency_conflict"})
+	case errors.Is(err, transaction.ErrPurchaseIdempotencyConflict):
+		c.JSON(http.StatusConflict, errorResponse{Error: "idempotency key already used for a purchase", Code: "idempotency_conflict"})
 	case errors.Is(err, purchase.ErrCapturePartiallyApplied):
 		c.JSON(http.StatusInternalServerError, errorResponse{
 			Error:         "authorize succeeded but capture failed; manual recovery required",
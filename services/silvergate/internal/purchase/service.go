@@ -4,7 +4,6 @@ package purchase
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"log/slog"
 
@@ -19,8 +18,6 @@ type Service struct {
 	products   ProductService
 	authorizer Authorizer
 	capturer   Capturer
-	txLookup   TxLookup
-	txRepo     func(postgres.Executor) transaction.Repo
 	transactor postgres.Transactor
 	log        *slog.Logger
 }
@@ -29,8 +26,6 @@ func NewService(
 	products ProductService,
 	authorizer Authorizer,
 	capturer Capturer,
-	txLookup TxLookup,
-	txRepo func(postgres.Executor) transaction.Repo,
 	transactor postgres.Transactor,
 	log *slog.Logger,
 ) *Service {
@@ -38,8 +33,6 @@ func NewService(
 		products:   products,
 		authorizer: authorizer,
 		capturer:   capturer,
-		txLookup:   txLookup,
-		txRepo:     txRepo,
 		transactor: transactor,
 		log:        log,
 	}
@@ -63,22 +56,17 @@ type Response struct {
 	DeclineReason string
 }
 
-// Purchase composes a product purchase: idempotency pre-check → load product →
-// authorize + persist + mark-purchased in one tx → capture outside the tx. Returns:
-//   - cached Response, nil          when the idempotency key replays the same request
+// Purchase composes a product purchase: load product → authorize + persist +
+// mark-purchased in one tx → capture outside the tx. Replays of the same request are
+// served by idempotency.Middleware; the key is also stored on the transaction, so a
+// retry after a released key cannot authorize twice. Returns:
 //   - Response{capture_pending}     when the acquirer approves and capture is kicked off
 //   - Response{declined}            when the acquirer declines (no product mark, no capture)
 //   - ErrProductArchived            when the product is archived
 //   - ErrNotFound                   when the product does not exist for the merchant
-//   - ErrIdempotencyConflict        when the key was reused for a different request
+//   - transaction.ErrPurchaseIdempotencyConflict when a purchase already used the key
 //   - ErrCapturePartiallyApplied    when authorize persisted but the follow-up capture failed
 func (s *Service) Purchase(ctx context.Context, req Request) (Response, error) {
-	if cached, ok, err := s.checkIdempotency(ctx, req); err != nil {
-		return Response{}, err
-	} else if ok {
-		return cached, nil
-	}
-
 	p, err := s.products.Get(ctx, req.MerchantID, req.ProductID)
 	if err != nil {
 		return Response{}, err
@@ -89,7 +77,7 @@ func (s *Service) Purchase(ctx context.Context, req Request) (Response, error) {
 
 	var tx *transaction.Transaction
 	err = s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(exec postgres.Executor) error {
-		txInner, authErr := s.authorizer.AuthorizeInTx(ctx, s.txRepo(exec), transaction.AuthRequest{
+		txInner, authErr := s.authorizer.AuthorizeInTx(ctx, exec, transaction.AuthRequest{
 			MerchantID:             req.MerchantID,
 			OrderID:                req.OrderID,
 			Amount:                 p.Price,
@@ -111,9 +99,6 @@ func (s *Service) Purchase(ctx context.Context, req Request) (Response, error) {
 		return nil
 	})
 	if err != nil {
-		if errors.Is(err, transaction.ErrPurchaseIdempotencyConflict) {
-			return s.resolveRace(ctx, req)
-		}
 		return Response{}, err
 	}
 
@@ -141,53 +126,6 @@ func (s *Service) Purchase(ctx context.Context, req Request) (Response, error) {
 	return responseFromTx(tx, req.ProductID), nil
 }
 
-// checkIdempotency looks up a prior transaction by idempotency key. Returns:
-//   - not found:                  (zero, false, nil) — caller proceeds with a fresh purchase
-//   - found, same request:        (cached, true, nil) — idempotent replay
-//   - found, different request:   (zero, false, ErrIdempotencyConflict) — key reused for another intent
-func (s *Service) checkIdempotency(ctx context.Context, req Request) (Response, bool, error) {
-	existing, err := s.txLookup.GetByPurchaseIdempotencyKey(ctx, req.MerchantID, req.IdempotencyKey)
-	if err != nil {
-		if errors.Is(err, transaction.ErrNotFound) {
-			return Response{}, false, nil
-		}
-		return Response{}, false, err
-	}
-	if !sameRequest(existing, req) {
-		return Response{}, false, ErrIdempotencyConflict
-	}
-	productID := req.ProductID
-	if existing.ProductID != nil {
-		productID = *existing.ProductID
-	}
-	return responseFromTx(existing, productID), true, nil
-}
-
-func (s *Service) resolveRace(ctx context.Context, req Request) (Response, error) {
-	existing, err := s.txLookup.GetByPurchaseIdempotencyKey(ctx, req.MerchantID, req.IdempotencyKey)
-	if err != nil {
-		return Response{}, fmt.Errorf("resolve idempotency race: %w", err)
-	}
-	if !sameRequest(existing, req) {
-		return Response{}, ErrIdempotencyConflict
-	}
-	productID := req.ProductID
-	if existing.ProductID != nil {
-		productID = *existing.ProductID
-	}
-	return responseFromTx(existing, productID), nil
-}
-
-func sameRequest(tx *transaction.Transaction, req Request) bool {
-	if tx.OrderRef != req.OrderID || tx.CardToken != req.CardToken {
-		return false
-	}
-	if tx.ProductID == nil || *tx.ProductID != req.ProductID {
-		return false
-	}
-	return true
-}
-
 func responseFromTx(tx *transaction.Transaction, productID uuid.UUID) Response {
 	status := tx.Status
 	if status == transaction.StatusAuthorized {
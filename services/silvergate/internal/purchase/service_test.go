@@ -52,7 +52,7 @@ type fakeAuthorizer struct {
 	approved bool
 }
 
-func (f *fakeAuthorizer) AuthorizeInTx(_ context.Context, _ transaction.Repo, req transaction.AuthRequest) (*transaction.Transaction, error) {
+func (f *fakeAuthorizer) AuthorizeInTx(_ context.Context, _ postgres.Executor, req transaction.AuthRequest) (*transaction.Transaction, error) {
 	f.called = true
 	f.gotReq = req
 	return f.respTx, f.respErr
@@ -71,26 +71,6 @@ func (f *fakeCapturer) Capture(_ context.Context, req transaction.CaptureRequest
 	return f.resp, f.err
 }
 
-type fakeTxLookup struct {
-	calls   int
-	resp    *transaction.Transaction
-	err     error
-	respSeq []lookupResult
-}
-type lookupResult struct {
-	tx  *transaction.Transaction
-	err error
-}
-
-func (f *fakeTxLookup) GetByPurchaseIdempotencyKey(_ context.Context, _, _ string) (*transaction.Transaction, error) {
-	idx := f.calls
-	f.calls++
-	if idx < len(f.respSeq) {
-		return f.respSeq[idx].tx, f.respSeq[idx].err
-	}
-	return f.resp, f.err
-}
-
 type fakeTransactor struct {
 	callbackErr error
 }
@@ -104,20 +84,17 @@ func newServiceWithFakes(t *testing.T) (
 	*fakeProductService,
 	*fakeAuthorizer,
 	*fakeCapturer,
-	*fakeTxLookup,
 ) {
 	t.Helper()
 	products := &fakeProductService{}
 	authorizer := &fakeAuthorizer{}
 	capturer := &fakeCapturer{}
-	lookup := &fakeTxLookup{err: transaction.ErrNotFound}
 	svc := NewService(
-		products, authorizer, capturer, lookup,
-		func(postgres.Executor) transaction.Repo { return nil },
+		products, authorizer, capturer,
 		fakeTransactor{},
 		slog.New(slog.NewTextHandler(io.Discard, nil)),
 	)
-	return svc, products, authorizer, capturer, lookup
+	return svc, products, authorizer, capturer
 }
 
 // --- helpers ---
@@ -144,7 +121,7 @@ func authorizedTx(merchantID, orderID, cardToken string, amount int64, currency
 // --- tests ---
 
 func TestPurchase_HappyPath_Approved(t *testing.T) {
-	svc, products, auth, cap, _ := newServiceWithFakes(t)
+	svc, products, auth, cap := newServiceWithFakes(t)
 	p := activeProduct("m1", 1999)
 	products.getResp = p
 
@@ -173,6 +150,9 @@ func TestPurchase_HappyPath_Approved(t *testing.T) {
 	if cap.gotReq.IdempotencyKey != auth.respTx.ID.String()+"-cap" {
 		t.Errorf("capture idempotency key = %q, want %s-cap", cap.gotReq.IdempotencyKey, auth.respTx.ID)
 	}
+	if auth.gotReq.PurchaseIdempotencyKey != "K1" {
+		t.Errorf("purchase idempotency key = %q, want K1", auth.gotReq.PurchaseIdempotencyKey)
+	}
 	if auth.gotReq.Amount != p.Price || auth.gotReq.Currency != p.Currency {
 		t.Errorf("acquirer got amount=%d currency=%s, want %d %s",
 			auth.gotReq.Amount, auth.gotReq.Currency, p.Price, p.Currency)
@@ -180,7 +160,7 @@ func TestPurchase_HappyPath_Approved(t *testing.T) {
 }
 
 func TestPurchase_Declined_NoMarkOrCapture(t *testing.T) {
-	svc, products, auth, cap, _ := newServiceWithFakes(t)
+	svc, products, auth, cap := newServiceWithFakes(t)
 	p := activeProduct("m1", 500)
 	products.getResp = p
 
@@ -213,7 +193,7 @@ func TestPurchase_Declined_NoMarkOrCapture(t *testing.T) {
 }
 
 func TestPurchase_ArchivedProduct_NoAcquirerCall(t *testing.T) {
-	svc, products, auth, _, _ := newServiceWithFakes(t)
+	svc, products, auth, _ := newServiceWithFakes(t)
 	p := activeProduct("m1", 1000)
 	p.Status = product.StatusArchived
 	products.getResp = p
@@ -234,7 +214,7 @@ func TestPurchase_ArchivedProduct_NoAcquirerCall(t *testing.T) {
 }
 
 func TestPurchase_ProductNotFound_NoAcquirerCall(t *testing.T) {
-	svc, products, auth, _, _ := newServiceWithFakes(t)
+	svc, products, auth, _ := newServiceWithFakes(t)
 	products.getErr = product.ErrNotFound
 
 	_, err := svc.Purchase(context.Background(), Request{
@@ -250,89 +230,28 @@ func TestPurchase_ProductNotFound_NoAcquirerCall(t *testing.T) {
 	}
 }
 
-func TestPurchase_IdempotencyCacheHit_SameRequest_NoAcquirer(t *testing.T) {
-	svc, products, auth, cap, lookup := newServiceWithFakes(t)
-	p := activeProduct("m1", 1999)
-	cached := authorizedTx("m1", "ord1", "tok1", p.Price, p.Currency, p.ID, "K1")
-	lookup.err = nil
-	lookup.resp = cached
-
-	resp, err := svc.Purchase(context.Background(), Request{
-		MerchantID:     "m1",
-		OrderID:        "ord1",
-		ProductID:      p.ID,
-		CardToken:      "tok1",
-		IdempotencyKey: "K1",
-	})
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if resp.TransactionID != cached.ID {
-		t.Errorf("transaction_id = %s, want cached %s", resp.TransactionID, cached.ID)
-	}
-	if auth.called {
-		t.Error("acquirer should not be called on cache hit")
-	}
-	if cap.called {
-		t.Error("capture should not be called on cache hit")
-	}
-	if products.getCalled {
-		t.Error("product.Get should not be called when cache hits")
-	}
-}
-
-func TestPurchase_IdempotencyCacheHit_DifferentRequest_Returns409(t *testing.T) {
-	svc, _, _, _, lookup := newServiceWithFakes(t)
-	cached := authorizedTx("m1", "ord1", "tok1", 100, "USD", uuid.New(), "K1")
-	lookup.err = nil
-	lookup.resp = cached
-
-	_, err := svc.Purchase(context.Background(), Request{
-		MerchantID:     "m1",
-		OrderID:        "DIFFERENT",
-		ProductID:      *cached.ProductID, // matches cached, mismatch on order_id
-		CardToken:      "tok1",
-		IdempotencyKey: "K1",
-	})
-	if !errors.Is(err, ErrIdempotencyConflict) {
-		t.Fatalf("err = %v, want ErrIdempotencyConflict", err)
-	}
-}
-
-func TestPurchase_InsertRace_ResolvesByReFetch(t *testing.T) {
-	svc, products, auth, cap, lookup := newServiceWithFakes(t)
-	p := activeProduct("m1", 1999)
-	products.getResp = p
-
-	// Two lookup calls: first (pre-check) → not found, second (race resolve) → cached
-	cached := authorizedTx("m1", "ord1", "tok1", p.Price, p.Currency, p.ID, "K1")
-	lookup.respSeq = []lookupResult{
-		{tx: nil, err: transaction.ErrNotFound},
-		{tx: cached, err: nil},
-	}
-
+func TestPurchase_KeyAlreadyUsed_NoCapture(t *testing.T) {
+	svc, products, auth, cap := newServiceWithFakes(t)
+	products.getResp = activeProduct("m1", 1999)
 	auth.respErr = transaction.ErrPurchaseIdempotencyConflict
 
-	resp, err := svc.Purchase(context.Background(), Request{
+	_, err := svc.Purchase(context.Background(), Request{
 		MerchantID:     "m1",
 		OrderID:        "ord1",
-		ProductID:      p.ID,
+		ProductID:      products.getResp.ID,
 		CardToken:      "tok1",
 		IdempotencyKey: "K1",
 	})
-	if err != nil {
-		t.Fatalf("unexpected error: %v", err)
-	}
-	if resp.TransactionID != cached.ID {
-		t.Errorf("transaction_id = %s, want cached %s", resp.TransactionID, cached.ID)
+	if !errors.Is(err, transaction.ErrPurchaseIdempotencyConflict) {
+		t.Fatalf("err = %v, want ErrPurchaseIdempotencyConflict", err)
 	}
-	if cap.called {
-		t.Error("capture should not run on race resolution")
+	if products.markCalled || cap.called {
+		t.Error("a purchase that already used the key must not be marked or captured again")
 	}
 }
 
 func TestPurchase_AcquirerTransportError_Bubbles(t *testing.T) {
-	svc, products, auth, _, _ := newServiceWithFakes(t)
+	svc, products, auth, _ := newServiceWithFakes(t)
 	products.getResp = activeProduct("m1", 1000)
 
 	wantErr := errors.New("acquirer down")
@@ -351,7 +270,7 @@ func TestPurchase_AcquirerTransportError_Bubbles(t *testing.T) {
 }
 
 func TestPurchase_CaptureFailure_ReturnsPartial(t *testing.T) {
-	svc, products, auth, cap, _ := newServiceWithFakes(t)
+	svc, products, auth, cap := newServiceWithFakes(t)
 	p := activeProduct("m1", 1999)
 	products.getResp = p
 	auth.respTx = authorizedTx("m1", "ord1", "tok1", p.Price, p.Currency, p.ID, "K1")
@@ -376,7 +295,7 @@ func TestPurchase_CaptureFailure_ReturnsPartial(t *testing.T) {
 }
 
 func TestPurchase_MarkPurchasedError_RollsBack(t *testing.T) {
-	svc, products, auth, cap, _ := newServiceWithFakes(t)
+	svc, products, auth, cap := newServiceWithFakes(t)
 	p := activeProduct("m1", 1999)
 	products.getResp = p
 	auth.respTx = authorizedTx("m1", "ord1", "tok1", p.Price, p.Currency, p.ID, "K1")
@@ -0,0 +1,63 @@
+package settlement
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// BatchWorkerConfig holds configuration for the settlement batch worker.
+type BatchWorkerConfig struct {
+	PollInterval time.Duration
+	// NotifyBatchSize caps the payout webhooks queued per poll.
+	NotifyBatchSize int
+}
+
+// BatchWorker runs the batch of the latest cutoff and queues payout webhooks. Batches are
+// idempotent per cutoff, so polling often and running several replicas are both safe.
+type BatchWorker struct {
+	svc *Service
+	cfg BatchWorkerConfig
+	log *slog.Logger
+}
+
+func NewBatchWorker(svc *Service, cfg BatchWorkerConfig, log *slog.Logger) *BatchWorker {
+	return &BatchWorker{svc: svc, cfg: cfg, log: log}
+}
+
+// Start begins the settlement loop. Blocks until ctx is cancelled.
+func (w *BatchWorker) Start(ctx context.Context) error {
+	w.log.Info("settlement batch worker started",
+		"poll_interval", w.cfg.PollInterval,
+		"window", w.svc.cfg.Window,
+		"cutoff_offset", w.svc.cfg.CutoffOffset)
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info("settlement batch worker stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			w.run(ctx)
+		}
+	}
+}
+
+func (w *BatchWorker) run(ctx context.Context) {
+	cutoff := w.svc.cfg.LastCutoff(time.Now())
+	if _, err := w.svc.RunBatch(ctx, cutoff); err != nil {
+		w.log.Error("settlement batch failed", "cutoff", cutoff, "error", err)
+	}
+
+	n, err := w.svc.NotifyPaid(ctx, w.cfg.NotifyBatchSize)
+	if err != nil {
+		w.log.Error("payout notification failed", "error", err)
+		return
+	}
+	if n > 0 {
+		w.log.Info("payouts notified", "count", n)
+	}
+}
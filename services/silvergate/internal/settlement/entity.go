@@ -0,0 +1,117 @@
+// Package settlement pays merchants out: at each cutoff a batch nets what reached their
+// available balance — captures, refunds and fees — into one payout per merchant and currency.
+package settlement
+
+import (
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+
+	"github.com/google/uuid"
+)
+
+type BatchStatus string
+
+const (
+	BatchStatusOpen      BatchStatus = "open"
+	BatchStatusCompleted BatchStatus = "completed"
+)
+
+// Batch is the settlement run of one cutoff. It stays open until all its payouts are
+// made and its reports are written, so an interrupted run is resumed, never repeated.
+type Batch struct {
+	ID          uuid.UUID
+	Cutoff      time.Time
+	Status      BatchStatus
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+}
+
+func NewBatch(cutoff time.Time) *Batch {
+	return &Batch{
+		ID:        uuid.New(),
+		Cutoff:    cutoff,
+		Status:    BatchStatusOpen,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+func (b *Batch) Complete(now time.Time) {
+	b.Status = BatchStatusCompleted
+	b.CompletedAt = &now
+}
+
+// Payee is one merchant balance payouts are made from.
+type Payee struct {
+	MerchantID string
+	Currency   string
+}
+
+type PayoutStatus string
+
+// PayoutStatusPaid is the only status for now: the mock acquirer pays out instantly.
+const PayoutStatusPaid PayoutStatus = "paid"
+
+// Payout is one transfer of available funds to a merchant. Amount is the net of its items.
+type Payout struct {
+	ID         uuid.UUID
+	BatchID    uuid.UUID
+	Cutoff     time.Time
+	MerchantID string
+	Currency   string
+	Amount     int64
+	// CapturedAmount, RefundedAmount and FeeAmount break Amount down; refunds and fees are positive.
+	CapturedAmount int64
+	RefundedAmount int64
+	FeeAmount      int64
+	Status         PayoutStatus
+	Items          []Item
+	PaidAt         time.Time
+	// NotifiedAt is when the merchant received the payout.paid webhook.
+	NotifiedAt *time.Time
+	CreatedAt  time.Time
+}
+
+// Item is a ledger entry that moved the merchant's available balance, paid out by a payout.
+// Amount is the entry's effect on available: positive for captures, negative for refunds and fees.
+type Item struct {
+	PayoutID      uuid.UUID
+	EntryID       uuid.UUID
+	TransactionID *uuid.UUID
+	Kind          ledger.EntryKind
+	Amount        int64
+	CreatedAt     time.Time
+}
+
+// NewPayout nets items into a payout of batch.
+func NewPayout(batch *Batch, payee Payee, items []Item) *Payout {
+	now := time.Now().UTC()
+	p := &Payout{
+		ID:         uuid.New(),
+		BatchID:    batch.ID,
+		Cutoff:     batch.Cutoff,
+		MerchantID: payee.MerchantID,
+		Currency:   payee.Currency,
+		Status:     PayoutStatusPaid,
+		Items:      items,
+		PaidAt:     now,
+		CreatedAt:  now,
+	}
+	for i := range p.Items {
+		item := &p.Items[i]
+		item.PayoutID = p.ID
+		p.Amount += item.Amount
+
+		switch item.Kind {
+		case ledger.KindCapture:
+			p.CapturedAmount += item.Amount
+		case ledger.KindRefund, ledger.KindRefundReversal:
+			p.RefundedAmount -= item.Amount
+		case ledger.KindFee:
+			p.FeeAmount -= item.Amount
+		case ledger.KindAuthorization, ledger.KindRelease, ledger.KindRefundSettled, ledger.KindPayout:
+			// These never move available funds.
+		}
+	}
+	return p
+}
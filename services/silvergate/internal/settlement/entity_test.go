@@ -0,0 +1,58 @@
+package settlement
+
+import (
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+
+	"github.com/google/uuid"
+)
+
+func TestNewPayout_NetsRefundsAndFees(t *testing.T) {
+	batch := NewBatch(time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC))
+	items := []Item{
+		{EntryID: uuid.New(), Kind: ledger.KindCapture, Amount: 5000},
+		{EntryID: uuid.New(), Kind: ledger.KindCapture, Amount: 3000},
+		{EntryID: uuid.New(), Kind: ledger.KindRefund, Amount: -1500},
+		{EntryID: uuid.New(), Kind: ledger.KindRefundReversal, Amount: 500},
+		{EntryID: uuid.New(), Kind: ledger.KindFee, Amount: -240},
+	}
+
+	p := NewPayout(batch, Payee{MerchantID: "m1", Currency: "USD"}, items)
+
+	if p.Amount != 6760 {
+		t.Errorf("amount: want 6760, got %d", p.Amount)
+	}
+	if p.CapturedAmount != 8000 || p.RefundedAmount != 1000 || p.FeeAmount != 240 {
+		t.Errorf("breakdown: want 8000/1000/240, got %d/%d/%d", p.CapturedAmount, p.RefundedAmount, p.FeeAmount)
+	}
+	if p.BatchID != batch.ID || !p.Cutoff.Equal(batch.Cutoff) {
+		t.Errorf("want batch %s at %s, got %s at %s", batch.ID, batch.Cutoff, p.BatchID, p.Cutoff)
+	}
+	for _, item := range p.Items {
+		if item.PayoutID != p.ID {
+			t.Errorf("item %s not linked to payout", item.EntryID)
+		}
+	}
+}
+
+func TestConfigLastCutoff(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 9, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		cfg  Config
+		want time.Time
+	}{
+		{"daily at midnight", Config{Window: 24 * time.Hour}, time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)},
+		{"daily at 17:00 before the cutoff", Config{Window: 24 * time.Hour, CutoffOffset: 17 * time.Hour},
+			time.Date(2026, time.October, 16, 17, 0, 0, 0, time.UTC)},
+		{"hourly", Config{Window: time.Hour}, time.Date(2026, time.October, 17, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		if got := tc.cfg.LastCutoff(now); !got.Equal(tc.want) {
+			t.Errorf("%s: want %s, got %s", tc.name, tc.want, got)
+		}
+	}
+}
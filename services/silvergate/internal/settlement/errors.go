@@ -0,0 +1,6 @@
+package settlement
+
+import "errors"
+
+// ErrAlreadyPaid means a concurrent run paid the payee in this batch or paid one of its items.
+var ErrAlreadyPaid = errors.New("payout already made")
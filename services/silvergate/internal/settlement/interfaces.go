@@ -0,0 +1,41 @@
+package settlement
+
+import (
+	"context"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+
+	"github.com/google/uuid"
+)
+
+// Repo is the persistence contract for settlement batches and payouts.
+type Repo interface {
+	// GetOrCreateBatch returns the batch of cutoff, creating it open on first use.
+	GetOrCreateBatch(ctx context.Context, cutoff time.Time) (*Batch, error)
+	CompleteBatch(ctx context.Context, b *Batch) error
+	// ListUnpaidPayees returns the payees with available-balance entries created before
+	// cutoff that no payout has paid yet.
+	ListUnpaidPayees(ctx context.Context, cutoff time.Time) ([]Payee, error)
+	// ListUnpaidItems returns the payee's unpaid entries created before cutoff, oldest first.
+	ListUnpaidItems(ctx context.Context, payee Payee, cutoff time.Time) ([]Item, error)
+	// CreatePayout saves the payout with its items. Returns ErrAlreadyPaid when the payee
+	// already has a payout in the batch or an item is already paid.
+	CreatePayout(ctx context.Context, p *Payout) error
+	// ListPayouts returns the payouts of a batch with their items.
+	ListPayouts(ctx context.Context, batchID uuid.UUID) ([]*Payout, error)
+	// ListUnnotifiedPayouts returns up to limit payouts without a payout.paid webhook, without items.
+	ListUnnotifiedPayouts(ctx context.Context, limit int) ([]*Payout, error)
+	MarkPayoutNotified(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+// Ledger records payouts. The service binds it to the DB transaction that creates the payout.
+type Ledger interface {
+	Post(ctx context.Context, e *ledger.Entry) error
+}
+
+// WebhookSender notifies merchants of payouts. The service binds it to the DB transaction
+// that marks the payout notified.
+type WebhookSender interface {
+	SendPayoutPaid(ctx context.Context, p *Payout) error
+}
@@ -0,0 +1,155 @@
+package settlement
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// reportName is the file name, without extension, of a batch's reports.
+func reportName(b *Batch) string {
+	return "settlement_" + b.Cutoff.UTC().Format("20060102T150405Z")
+}
+
+var csvHeader = []string{
+	"batch_id", "cutoff", "payout_id", "merchant_id", "currency", "payout_amount",
+	"entry_id", "transaction_id", "kind", "amount", "entry_created_at",
+}
+
+// WriteCSV writes the batch report with one row per payout item.
+func WriteCSV(w io.Writer, b *Batch, payouts []*Payout) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, p := range payouts {
+		for _, item := range p.Items {
+			txID := ""
+			if item.TransactionID != nil {
+				txID = item.TransactionID.String()
+			}
+			err := cw.Write([]string{
+				b.ID.String(),
+				b.Cutoff.UTC().Format(time.RFC3339),
+				p.ID.String(),
+				p.MerchantID,
+				p.Currency,
+				strconv.FormatInt(p.Amount, 10),
+				item.EntryID.String(),
+				txID,
+				string(item.Kind),
+				strconv.FormatInt(item.Amount, 10),
+				item.CreatedAt.UTC().Format(time.RFC3339Nano),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonReport struct {
+	BatchID string       `json:"batch_id"`
+	Cutoff  time.Time    `json:"cutoff"`
+	Payouts []jsonPayout `json:"payouts"`
+}
+
+type jsonPayout struct {
+	PayoutID       string     `json:"payout_id"`
+	MerchantID     string     `json:"merchant_id"`
+	Currency       string     `json:"currency"`
+	Amount         int64      `json:"amount"`
+	CapturedAmount int64      `json:"captured_amount"`
+	RefundedAmount int64      `json:"refunded_amount"`
+	FeeAmount      int64      `json:"fee_amount"`
+	PaidAt         time.Time  `json:"paid_at"`
+	Items          []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	EntryID       string    `json:"entry_id"`
+	TransactionID string    `json:"transaction_id,omitempty"`
+	Kind          string    `json:"kind"`
+	Amount        int64     `json:"amount"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// WriteJSON writes the batch report as one JSON document.
+func WriteJSON(w io.Writer, b *Batch, payouts []*Payout) error {
+	report := jsonReport{
+		BatchID: b.ID.String(),
+		Cutoff:  b.Cutoff.UTC(),
+		Payouts: make([]jsonPayout, 0, len(payouts)),
+	}
+	for _, p := range payouts {
+		jp := jsonPayout{
+			PayoutID:       p.ID.String(),
+			MerchantID:     p.MerchantID,
+			Currency:       p.Currency,
+			Amount:         p.Amount,
+			CapturedAmount: p.CapturedAmount,
+			RefundedAmount: p.RefundedAmount,
+			FeeAmount:      p.FeeAmount,
+			PaidAt:         p.PaidAt.UTC(),
+			Items:          make([]jsonItem, 0, len(p.Items)),
+		}
+		for _, item := range p.Items {
+			ji := jsonItem{
+				EntryID:   item.EntryID.String(),
+				Kind:      string(item.Kind),
+				Amount:    item.Amount,
+				CreatedAt: item.CreatedAt.UTC(),
+			}
+			if item.TransactionID != nil {
+				ji.TransactionID = item.TransactionID.String()
+			}
+			jp.Items = append(jp.Items, ji)
+		}
+		report.Payouts = append(report.Payouts, jp)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// writeReports writes the CSV and JSON reports of b into dir. Each file is written to a
+// temporary name and renamed, so a rerun replaces a report without exposing a partial one.
+func writeReports(dir string, b *Batch, payouts []*Payout) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create report dir: %w", err)
+	}
+	name := reportName(b)
+	if err := writeFile(filepath.Join(dir, name+".csv"), func(w io.Writer) error { return WriteCSV(w, b, payouts) }); err != nil {
+		return fmt.Errorf("csv report: %w", err)
+	}
+	if err := writeFile(filepath.Join(dir, name+".json"), func(w io.Writer) error { return WriteJSON(w, b, payouts) }); err != nil {
+		return fmt.Errorf("json report: %w", err)
+	}
+	return nil
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	// Removing after the rename fails harmlessly.
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if err := write(tmp); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,41 @@
+package settlement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+
+	"github.com/google/uuid"
+)
+
+func TestWriteCSV_OneRowPerItem(t *testing.T) {
+	batch := NewBatch(time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC))
+	txID := uuid.New()
+	p := NewPayout(batch, Payee{MerchantID: "m1", Currency: "USD"}, []Item{
+		{EntryID: uuid.New(), TransactionID: &txID, Kind: ledger.KindCapture, Amount: 5000},
+		{EntryID: uuid.New(), TransactionID: &txID, Kind: ledger.KindRefund, Amount: -1000},
+	})
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, batch, []*Payout{p}); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("want header and 2 rows, got %d rows", len(rows))
+	}
+	want := []string{batch.ID.String(), "2026-10-17T00:00:00Z", p.ID.String(), "m1", "USD", "4000",
+		p.Items[1].EntryID.String(), txID.String(), "refund", "-1000"}
+	for i, v := range want {
+		if rows[2][i] != v {
+			t.Errorf("column %s: want %q, got %q", csvHeader[i], v, rows[2][i])
+		}
+	}
+}
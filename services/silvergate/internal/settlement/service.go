@@ -0,0 +1,182 @@
+package settlement
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Config holds the settlement schedule.
+type Config struct {
+	// Window is the settlement period. Cutoffs fall every Window counted from midnight UTC,
+	// shifted by CutoffOffset: a 24h window with a 17h offset settles daily at 17:00 UTC.
+	Window       time.Duration
+	CutoffOffset time.Duration
+	// ReportDir is where batch reports are written.
+	ReportDir string
+}
+
+// LastCutoff returns the latest cutoff at or before now.
+func (c Config) LastCutoff(now time.Time) time.Time {
+	return now.UTC().Add(-c.CutoffOffset).Truncate(c.Window).Add(c.CutoffOffset)
+}
+
+type Service struct {
+	repo       Repo
+	transactor postgres.Transactor
+	txRepo     func(postgres.Executor) Repo
+	txLedger   func(postgres.Executor) Ledger
+	txWebhooks func(postgres.Executor) WebhookSender
+	cfg        Config
+	log        *slog.Logger
+}
+
+func NewService(
+	repo Repo,
+	transactor postgres.Transactor,
+	txRepo func(postgres.Executor) Repo,
+	txLedger func(postgres.Executor) Ledger,
+	txWebhooks func(postgres.Executor) WebhookSender,
+	cfg Config,
+	log *slog.Logger,
+) *Service {
+	return &Service{
+		repo:       repo,
+		transactor: transactor,
+		txRepo:     txRepo,
+		txLedger:   txLedger,
+		txWebhooks: txWebhooks,
+		cfg:        cfg,
+		log:        log,
+	}
+}
+
+// RunBatch settles everything that reached merchants' available balances before cutoff:
+// one payout per merchant and currency with a positive net, recorded in the ledger, and
+// the batch reports. A payee whose net is not positive carries its items over to the next
+// batch. Rerunning a cutoff never pays twice: a completed batch is skipped, a payee is paid
+// at most once per batch and a ledger entry is paid out at most once.
+func (s *Service) RunBatch(ctx context.Context, cutoff time.Time) (*Batch, error) {
+	batch, err := s.repo.GetOrCreateBatch(ctx, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("get batch: %w", err)
+	}
+	if batch.Status == BatchStatusCompleted {
+		return batch, nil
+	}
+
+	payees, err := s.repo.ListUnpaidPayees(ctx, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("list unpaid payees: %w", err)
+	}
+
+	var errs []error
+	for _, payee := range payees {
+		p, err := s.pay(ctx, batch, payee)
+		if errors.Is(err, ErrAlreadyPaid) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pay %s %s: %w", payee.MerchantID, payee.Currency, err))
+			continue
+		}
+		if p != nil {
+			s.log.Info("payout made",
+				"payout_id", p.ID,
+				"batch_id", batch.ID,
+				"merchant_id", p.MerchantID,
+				"currency", p.Currency,
+				"amount", p.Amount,
+				"items", len(p.Items),
+			)
+		}
+	}
+	// The batch stays open so the next run retries the payees that failed.
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	payouts, err := s.repo.ListPayouts(ctx, batch.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list payouts: %w", err)
+	}
+	if err := writeReports(s.cfg.ReportDir, batch, payouts); err != nil {
+		return nil, fmt.Errorf("write reports: %w", err)
+	}
+
+	batch.Complete(time.Now().UTC())
+	if err := s.repo.CompleteBatch(ctx, batch); err != nil {
+		return nil, fmt.Errorf("complete batch: %w", err)
+	}
+
+	s.log.Info("settlement batch completed",
+		"batch_id", batch.ID,
+		"cutoff", batch.Cutoff,
+		"payouts", len(payouts),
+	)
+	return batch, nil
+}
+
+// pay makes the payee's payout for batch. Returns a nil payout when the net is not positive.
+func (s *Service) pay(ctx context.Context, batch *Batch, payee Payee) (*Payout, error) {
+	var payout *Payout
+
+	err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(dbTx postgres.Executor) error {
+		repo := s.txRepo(dbTx)
+
+		items, err := repo.ListUnpaidItems(ctx, payee, batch.Cutoff)
+		if err != nil {
+			return fmt.Errorf("list unpaid items: %w", err)
+		}
+
+		p := NewPayout(batch, payee, items)
+		if p.Amount <= 0 {
+			return nil
+		}
+		if err := repo.CreatePayout(ctx, p); err != nil {
+			return err
+		}
+		if err := s.txLedger(dbTx).Post(ctx, ledger.Payout(p.MerchantID, p.Currency, p.ID, p.Amount)); err != nil {
+			return fmt.Errorf("post payout ledger entry: %w", err)
+		}
+
+		payout = p
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return payout, nil
+}
+
+// NotifyPaid queues the payout.paid webhook for up to limit payouts that have not had it,
+// marking each notified in the same DB transaction. Returns how many were notified.
+func (s *Service) NotifyPaid(ctx context.Context, limit int) (int, error) {
+	payouts, err := s.repo.ListUnnotifiedPayouts(ctx, limit)
+	if err != nil {
+		return 0, fmt.Errorf("list unnotified payouts: %w", err)
+	}
+
+	notified := 0
+	for _, p := range payouts {
+		err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(dbTx postgres.Executor) error {
+			if err := s.txWebhooks(dbTx).SendPayoutPaid(ctx, p); err != nil {
+				return fmt.Errorf("send payout webhook: %w", err)
+			}
+			return s.txRepo(dbTx).MarkPayoutNotified(ctx, p.ID, time.Now().UTC())
+		})
+		if err != nil {
+			s.log.Error("failed to notify payout", "payout_id", p.ID, "error", err)
+			continue
+		}
+		notified++
+	}
+	return notified, nil
+}
@@ -0,0 +1,231 @@
+package settlement
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// fakeRepo keeps batches and payouts in memory; unpaid holds the items no payout has taken yet.
+type fakeRepo struct {
+	batches  map[time.Time]*Batch
+	unpaid   map[Payee][]Item
+	payouts  []*Payout
+	notified map[uuid.UUID]bool
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{
+		batches:  map[time.Time]*Batch{},
+		unpaid:   map[Payee][]Item{},
+		notified: map[uuid.UUID]bool{},
+	}
+}
+
+func (f *fakeRepo) GetOrCreateBatch(_ context.Context, cutoff time.Time) (*Batch, error) {
+	if b, ok := f.batches[cutoff]; ok {
+		return b, nil
+	}
+	b := NewBatch(cutoff)
+	f.batches[cutoff] = b
+	return b, nil
+}
+
+func (f *fakeRepo) CompleteBatch(context.Context, *Batch) error { return nil }
+
+func (f *fakeRepo) ListUnpaidPayees(context.Context, time.Time) ([]Payee, error) {
+	var payees []Payee
+	for payee, items := range f.unpaid {
+		if len(items) > 0 {
+			payees = append(payees, payee)
+		}
+	}
+	return payees, nil
+}
+
+func (f *fakeRepo) ListUnpaidItems(_ context.Context, payee Payee, _ time.Time) ([]Item, error) {
+	return append([]Item(nil), f.unpaid[payee]...), nil
+}
+
+func (f *fakeRepo) CreatePayout(_ context.Context, p *Payout) error {
+	for _, existing := range f.payouts {
+		if existing.BatchID == p.BatchID && existing.MerchantID == p.MerchantID && existing.Currency == p.Currency {
+			return ErrAlreadyPaid
+		}
+	}
+	f.payouts = append(f.payouts, p)
+	delete(f.unpaid, Payee{MerchantID: p.MerchantID, Currency: p.Currency})
+	return nil
+}
+
+func (f *fakeRepo) ListPayouts(_ context.Context, batchID uuid.UUID) ([]*Payout, error) {
+	var out []*Payout
+	for _, p := range f.payouts {
+		if p.BatchID == batchID {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepo) ListUnnotifiedPayouts(_ context.Context, limit int) ([]*Payout, error) {
+	var out []*Payout
+	for _, p := range f.payouts {
+		if !f.notified[p.ID] && len(out) < limit {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeRepo) MarkPayoutNotified(_ context.Context, id uuid.UUID, _ time.Time) error {
+	f.notified[id] = true
+	return nil
+}
+
+type fakeLedger struct {
+	entries []*ledger.Entry
+}
+
+func (f *fakeLedger) Post(_ context.Context, e *ledger.Entry) error {
+	if err := e.Validate(); err != nil {
+		return err
+	}
+	f.entries = append(f.entries, e)
+	return nil
+}
+
+type fakeWebhooks struct {
+	sent []uuid.UUID
+}
+
+func (f *fakeWebhooks) SendPayoutPaid(_ context.Context, p *Payout) error {
+	f.sent = append(f.sent, p.ID)
+	return nil
+}
+
+type fakeTransactor struct{}
+
+func (fakeTransactor) InTransaction(_ context.Context, _ pgx.TxIsoLevel, fn func(postgres.Executor) error) error {
+	return fn(nil)
+}
+
+func newTestService(t *testing.T) (*Service, *fakeRepo, *fakeLedger, *fakeWebhooks, string) {
+	t.Helper()
+	repo := newFakeRepo()
+	books := &fakeLedger{}
+	webhooks := &fakeWebhooks{}
+	dir := t.TempDir()
+	svc := NewService(
+		repo, fakeTransactor{},
+		func(postgres.Executor) Repo { return repo },
+		func(postgres.Executor) Ledger { return books },
+		func(postgres.Executor) WebhookSender { return webhooks },
+		Config{Window: 24 * time.Hour, ReportDir: dir},
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	return svc, repo, books, webhooks, dir
+}
+
+func TestRunBatch_PaysPositiveNetAndCarriesTheRest(t *testing.T) {
+	svc, repo, books, _, dir := newTestService(t)
+	paid := Payee{MerchantID: "m1", Currency: "USD"}
+	negative := Payee{MerchantID: "m2", Currency: "USD"}
+	repo.unpaid[paid] = []Item{
+		{EntryID: uuid.New(), Kind: ledger.KindCapture, Amount: 5000},
+		{EntryID: uuid.New(), Kind: ledger.KindRefund, Amount: -1000},
+	}
+	repo.unpaid[negative] = []Item{
+		{EntryID: uuid.New(), Kind: ledger.KindRefund, Amount: -700},
+	}
+	cutoff := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+
+	batch, err := svc.RunBatch(context.Background(), cutoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if batch.Status != BatchStatusCompleted {
+		t.Errorf("want completed batch, got %s", batch.Status)
+	}
+	if len(repo.payouts) != 1 || repo.payouts[0].MerchantID != "m1" || repo.payouts[0].Amount != 4000 {
+		t.Fatalf("want one payout of 4000 to m1, got %+v", repo.payouts)
+	}
+	if len(repo.unpaid[negative]) != 1 {
+		t.Errorf("want m2 items carried over, got %+v", repo.unpaid[negative])
+	}
+	if len(books.entries) != 1 || books.entries[0].Kind != ledger.KindPayout || *books.entries[0].ReferenceID != repo.payouts[0].ID {
+		t.Errorf("want payout ledger entry, got %+v", books.entries)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "settlement_20261017T000000Z.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report jsonReport
+	if err := json.Unmarshal(raw, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Payouts) != 1 || len(report.Payouts[0].Items) != 2 {
+		t.Errorf("want one payout with two items in the report, got %+v", report.Payouts)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "settlement_20261017T000000Z.csv")); err != nil {
+		t.Errorf("csv report: %v", err)
+	}
+}
+
+func TestRunBatch_RerunNeverPaysTwice(t *testing.T) {
+	svc, repo, books, _, _ := newTestService(t)
+	payee := Payee{MerchantID: "m1", Currency: "USD"}
+	item := Item{EntryID: uuid.New(), Kind: ledger.KindCapture, Amount: 5000}
+	repo.unpaid[payee] = []Item{item}
+	cutoff := time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+
+	if _, err := svc.RunBatch(context.Background(), cutoff); err != nil {
+		t.Fatal(err)
+	}
+	// A completed batch is skipped even if items show up again.
+	repo.unpaid[payee] = []Item{item}
+	if _, err := svc.RunBatch(context.Background(), cutoff); err != nil {
+		t.Fatal(err)
+	}
+	// An interrupted batch is resumed: a payee it already paid is skipped.
+	repo.batches[cutoff].Status = BatchStatusOpen
+	if _, err := svc.RunBatch(context.Background(), cutoff); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repo.payouts) != 1 || len(books.entries) != 1 {
+		t.Errorf("want one payout and one ledger entry, got %d and %d", len(repo.payouts), len(books.entries))
+	}
+}
+
+func TestNotifyPaid_SendsEachPayoutOnce(t *testing.T) {
+	svc, repo, _, webhooks, _ := newTestService(t)
+	repo.unpaid[Payee{MerchantID: "m1", Currency: "USD"}] = []Item{{EntryID: uuid.New(), Kind: ledger.KindCapture, Amount: 100}}
+	repo.unpaid[Payee{MerchantID: "m1", Currency: "EUR"}] = []Item{{EntryID: uuid.New(), Kind: ledger.KindCapture, Amount: 200}}
+	if _, err := svc.RunBatch(context.Background(), time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	for range 2 {
+		if _, err := svc.NotifyPaid(context.Background(), 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(webhooks.sent) != 2 {
+		t.Errorf("want 2 webhooks, got %d", len(webhooks.sent))
+	}
+}
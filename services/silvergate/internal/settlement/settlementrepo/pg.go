@@ -0,0 +1,280 @@
+package settlementrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/settlement"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+var batchColumns = []string{"id", "cutoff", "status", "created_at", "completed_at"}
+
+var payoutColumns = []string{
+	"p.id", "p.batch_id", "b.cutoff", "p.merchant_id", "p.currency", "p.amount",
+	"p.captured_amount", "p.refunded_amount", "p.fee_amount", "p.status",
+	"p.paid_at", "p.notified_at", "p.created_at",
+}
+
+// unpaidEntries selects the ledger entries that moved available funds and are not paid
+// out yet, with their effect on available as the item amount.
+const unpaidEntries = `FROM ledger_entries e
+	JOIN ledger_postings p ON p.entry_id = e.id AND p.account_type = 'available'
+	WHERE e.kind <> 'payout'
+	  AND e.created_at < $1
+	  AND NOT EXISTS (SELECT 1 FROM payout_items i WHERE i.entry_id = e.id)`
+
+type PgSettlementRepo struct {
+	db postgres.Executor
+}
+
+func NewPgSettlementRepo(db postgres.Executor) *PgSettlementRepo {
+	return &PgSettlementRepo{db: db}
+}
+
+func (r *PgSettlementRepo) GetOrCreateBatch(ctx context.Context, cutoff time.Time) (*settlement.Batch, error) {
+	b := settlement.NewBatch(cutoff)
+	query, args, err := psql.
+		Insert("settlement_batches").
+		Columns("id", "cutoff", "status", "created_at").
+		Values(b.ID, b.Cutoff, b.Status, b.CreatedAt).
+		Suffix("ON CONFLICT (cutoff) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build insert: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("exec insert: %w", err)
+	}
+
+	query, args, err = psql.
+		Select(batchColumns...).
+		From("settlement_batches").
+		Where(sq.Eq{"cutoff": cutoff}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	var batch settlement.Batch
+	err = r.db.QueryRow(ctx, query, args...).
+		Scan(&batch.ID, &batch.Cutoff, &batch.Status, &batch.CreatedAt, &batch.CompletedAt)
+	if err != nil {
+		return nil, fmt.Errorf("scan batch: %w", err)
+	}
+	return &batch, nil
+}
+
+func (r *PgSettlementRepo) CompleteBatch(ctx context.Context, b *settlement.Batch) error {
+	query, args, err := psql.
+		Update("settlement_batches").
+		Set("status", b.Status).
+		Set("completed_at", b.CompletedAt).
+		Where(sq.Eq{"id": b.ID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec update: %w", err)
+	}
+	return nil
+}
+
+func (r *PgSettlementRepo) ListUnpaidPayees(ctx context.Context, cutoff time.Time) ([]settlement.Payee, error) {
+	query := `SELECT DISTINCT e.merchant_id, e.currency ` + unpaidEntries + `
+		ORDER BY e.merchant_id, e.currency`
+
+	rows, err := r.db.Query(ctx, query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("query unpaid payees: %w", err)
+	}
+	defer rows.Close()
+
+	var payees []settlement.Payee
+	for rows.Next() {
+		var p settlement.Payee
+		if err := rows.Scan(&p.MerchantID, &p.Currency); err != nil {
+			return nil, fmt.Errorf("scan payee: %w", err)
+		}
+		payees = append(payees, p)
+	}
+	return payees, rows.Err()
+}
+
+func (r *PgSettlementRepo) ListUnpaidItems(ctx context.Context, payee settlement.Payee, cutoff time.Time) ([]settlement.Item, error) {
+	query := `SELECT e.id, e.transaction_id, e.kind, p.amount, e.created_at ` + unpaidEntries + `
+		  AND e.merchant_id = $2 AND e.currency = $3
+		ORDER BY e.created_at, e.id`
+
+	rows, err := r.db.Query(ctx, query, cutoff, payee.MerchantID, payee.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("query unpaid items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []settlement.Item
+	for rows.Next() {
+		var item settlement.Item
+		if err := rows.Scan(&item.EntryID, &item.TransactionID, &item.Kind, &item.Amount, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func (r *PgSettlementRepo) CreatePayout(ctx context.Context, p *settlement.Payout) error {
+	query, args, err := psql.
+		Insert("payouts").
+		Columns("id", "batch_id", "merchant_id", "currency", "amount", "captured_amount",
+			"refunded_amount", "fee_amount", "status", "paid_at", "created_at").
+		Values(p.ID, p.BatchID, p.MerchantID, p.Currency, p.Amount, p.CapturedAmount,
+			p.RefundedAmount, p.FeeAmount, p.Status, p.PaidAt, p.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert payout: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return settlement.ErrAlreadyPaid
+		}
+		return fmt.Errorf("exec insert payout: %w", err)
+	}
+
+	insert := psql.
+		Insert("payout_items").
+		Columns("payout_id", "entry_id", "transaction_id", "kind", "amount", "created_at")
+	for _, item := range p.Items {
+		insert = insert.Values(p.ID, item.EntryID, item.TransactionID, item.Kind, item.Amount, item.CreatedAt)
+	}
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert items: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		if isUniqueViolation(err) {
+			return settlement.ErrAlreadyPaid
+		}
+		return fmt.Errorf("exec insert items: %w", err)
+	}
+	return nil
+}
+
+func (r *PgSettlementRepo) ListPayouts(ctx context.Context, batchID uuid.UUID) ([]*settlement.Payout, error) {
+	query, args, err := psql.
+		Select(payoutColumns...).
+		From("payouts p").
+		Join("settlement_batches b ON b.id = p.batch_id").
+		Where(sq.Eq{"p.batch_id": batchID}).
+		OrderBy("p.merchant_id", "p.currency").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	payouts, err := r.queryPayouts(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(payouts) == 0 {
+		return payouts, nil
+	}
+
+	byID := make(map[uuid.UUID]*settlement.Payout, len(payouts))
+	ids := make([]uuid.UUID, 0, len(payouts))
+	for _, p := range payouts {
+		byID[p.ID] = p
+		ids = append(ids, p.ID)
+	}
+
+	query, args, err = psql.
+		Select("payout_id", "entry_id", "transaction_id", "kind", "amount", "created_at").
+		From("payout_items").
+		Where(sq.Eq{"payout_id": ids}).
+		OrderBy("created_at", "entry_id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select items: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item settlement.Item
+		if err := rows.Scan(&item.PayoutID, &item.EntryID, &item.TransactionID, &item.Kind, &item.Amount, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan item: %w", err)
+		}
+		p := byID[item.PayoutID]
+		p.Items = append(p.Items, item)
+	}
+	return payouts, rows.Err()
+}
+
+func (r *PgSettlementRepo) ListUnnotifiedPayouts(ctx context.Context, limit int) ([]*settlement.Payout, error) {
+	query, args, err := psql.
+		Select(payoutColumns...).
+		From("payouts p").
+		Join("settlement_batches b ON b.id = p.batch_id").
+		Where(sq.Eq{"p.notified_at": nil}).
+		OrderBy("p.created_at").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+	return r.queryPayouts(ctx, query, args...)
+}
+
+func (r *PgSettlementRepo) MarkPayoutNotified(ctx context.Context, id uuid.UUID, at time.Time) error {
+	query, args, err := psql.
+		Update("payouts").
+		Set("notified_at", at).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec update: %w", err)
+	}
+	return nil
+}
+
+func (r *PgSettlementRepo) queryPayouts(ctx context.Context, query string, args ...any) ([]*settlement.Payout, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query payouts: %w", err)
+	}
+	defer rows.Close()
+
+	var payouts []*settlement.Payout
+	for rows.Next() {
+		var p settlement.Payout
+		err := rows.Scan(&p.ID, &p.BatchID, &p.Cutoff, &p.MerchantID, &p.Currency, &p.Amount,
+			&p.CapturedAmount, &p.RefundedAmount, &p.FeeAmount, &p.Status,
+			&p.PaidAt, &p.NotifiedAt, &p.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("scan payout: %w", err)
+		}
+		payouts = append(payouts, &p)
+	}
+	return payouts, rows.Err()
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
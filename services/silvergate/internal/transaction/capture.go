@@ -0,0 +1,54 @@
+package transaction
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CaptureStatus string
+
+const (
+	CaptureStatusPending CaptureStatus = "capture_pending"
+	CaptureStatusDone    CaptureStatus = "captured"
+	CaptureStatusFailed  CaptureStatus = "capture_failed"
+)
+
+// Capture is a single (possibly partial) settlement against an authorization.
+// A final capture closes the authorization and releases any uncaptured remainder.
+// Fee is charged when the capture settles and is zero until then.
+type Capture struct {
+	ID             uuid.UUID
+	TransactionID  uuid.UUID
+	Amount         int64
+	Final          bool
+	Status         CaptureStatus
+	Fee            int64
+	IdempotencyKey string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func NewCapturePending(txID uuid.UUID, amount int64, final bool, idempotencyKey string) *Capture {
+	now := time.Now().UTC()
+	return &Capture{
+		ID:             uuid.New(),
+		TransactionID:  txID,
+		Amount:         amount,
+		Final:          final,
+		Status:         CaptureStatusPending,
+		IdempotencyKey: idempotencyKey,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+func (c *Capture) MarkCaptured() {
+	c.Status = CaptureStatusDone
+	c.UpdatedAt = time.Now().UTC()
+}
+
+func (c *Capture) MarkFailed() {
+	c.Status = CaptureStatusFailed
+	c.UpdatedAt = time.Now().UTC()
+}
@@ -0,0 +1,148 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+	"TestTaskJustPay/services/silvergate/internal/vault"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Decline reasons of authorizations that required a challenge.
+const (
+	DeclineAuthenticationFailed  = "authentication_failed"
+	DeclineAuthenticationExpired = "authentication_expired"
+)
+
+// ChallengePolicy decides which authorizations need a cardholder authentication challenge
+// (strong customer authentication) before they reach the acquirer.
+type ChallengePolicy struct {
+	// AmountThreshold challenges authorizations of at least this amount; 0 disables it.
+	AmountThreshold int64
+	// CardLast4 challenges cards ending in any of these digits, e.g. test cards.
+	CardLast4 []string
+	// TTL is how long the cardholder has to complete a challenge.
+	TTL time.Duration
+	// URLBase is the public Silvergate URL challenge links are built from.
+	URLBase string
+}
+
+// Requires reports whether an authorization of amount with card must be challenged.
+func (p ChallengePolicy) Requires(amount int64, card *vault.Card) bool {
+	if p.AmountThreshold > 0 && amount >= p.AmountThreshold {
+		return true
+	}
+	return slices.Contains(p.CardLast4, card.Last4)
+}
+
+// URL is where the cardholder completes the challenge.
+func (p ChallengePolicy) URL(challengeID uuid.UUID) string {
+	return p.URLBase + "/api/v1/challenges/" + challengeID.String()
+}
+
+type CompleteChallengeRequest struct {
+	ChallengeID uuid.UUID
+	// Passed is the simulated cardholder outcome.
+	Passed bool
+}
+
+// CompleteChallenge resumes an authorization parked in requires_action: a passed challenge
+// sends it to the acquirer, a failed one declines it. Returns ErrChallengeExpired (after
+// declining the transaction) when the challenge lapsed, and ErrChallengeCompleted when it
+// was already resolved.
+func (s *Service) CompleteChallenge(ctx context.Context, req CompleteChallengeRequest) (AuthResponse, error) {
+	var tx *Transaction
+	var intent *Intent
+	var expired bool
+
+	err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(dbTx postgres.Executor) error {
+		txRepo := s.txRepo(dbTx)
+
+		var err error
+		tx, err = txRepo.GetByChallengeIDForUpdate(ctx, req.ChallengeID)
+		if err != nil {
+			return err
+		}
+		if tx.Status != StatusRequiresAction {
+			return ErrChallengeCompleted
+		}
+
+		switch {
+		case tx.IsChallengeExpired(time.Now().UTC()):
+			expired = true
+			err = tx.MarkChallengeDeclined(DeclineAuthenticationExpired)
+		case !req.Passed:
+			err = tx.MarkChallengeDeclined(DeclineAuthenticationFailed)
+		default:
+			intent, err = s.authorizeChallenged(ctx, txRepo, tx)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := txRepo.CompleteChallenge(ctx, tx); err != nil {
+			return err
+		}
+		if err := finishIntent(ctx, txRepo, intent); err != nil {
+			return err
+		}
+		if tx.Status == StatusAuthorized {
+			if err := s.post(ctx, dbTx, ledger.Authorization(tx.ledgerSource(), tx.Amount)); err != nil {
+				return err
+			}
+		}
+		if err := s.txWebhooks(dbTx).SendAuthorizationResult(ctx, tx); err != nil {
+			return fmt.Errorf("send authorization webhook: %w", err)
+		}
+		return nil
+	})
+	if errors.Is(err, ErrStatusChanged) {
+		return AuthResponse{}, ErrChallengeCompleted
+	}
+	if err != nil {
+		return AuthResponse{}, err
+	}
+
+	s.log.Info("challenge completed",
+		"transaction_id", tx.ID,
+		"challenge_id", req.ChallengeID,
+		"status", tx.Status,
+		"decline_reason", tx.DeclineReason,
+	)
+
+	if expired {
+		return AuthResponse{}, ErrChallengeExpired
+	}
+	return AuthResponse{
+		TransactionID:   tx.ID,
+		OrderID:         tx.OrderRef,
+		Status:          tx.Status,
+		DeclineReason:   tx.DeclineReason,
+		CardFingerprint: tx.CardFingerprint,
+	}, nil
+}
+
+// authorizeChallenged runs the acquirer authorization a passed challenge was holding back
+// and returns its intent for the caller to finish with the transaction.
+func (s *Service) authorizeChallenged(ctx context.Context, repo Repo, tx *Transaction) (*Intent, error) {
+	intent := NewIntent(IntentAuthorize, tx.ID, nil, tx.Amount)
+	result, err := s.authorizeWithAcquirer(ctx, intent, tx.Currency, tx.CardToken)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Approved {
+		return intent, tx.MarkChallengeDeclined(result.DeclineReason)
+	}
+	validity, err := s.authValidity(ctx, repo, tx.MerchantID, tx.Currency)
+	if err != nil {
+		return nil, err
+	}
+	return intent, tx.MarkChallengeAuthorized(validity)
+}
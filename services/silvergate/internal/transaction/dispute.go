@@ -0,0 +1,96 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/pricing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Dispute is a chargeback the acquirer reported against a captured transaction. Opening
+// one charges the merchant the chargeback fee of their plan; the disputed funds themselves
+// stay in available until the dispute is decided.
+type Dispute struct {
+	ID            uuid.UUID
+	TransactionID uuid.UUID
+	Amount        int64
+	Reason        string
+	Fee           int64
+	CreatedAt     time.Time
+}
+
+func NewDispute(txID uuid.UUID, amount int64, reason string) *Dispute {
+	return &Dispute{
+		ID:            uuid.New(),
+		TransactionID: txID,
+		Amount:        amount,
+		Reason:        reason,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+type DisputeRequest struct {
+	MerchantID    string
+	TransactionID uuid.UUID
+	Amount        int64
+	Reason        string
+}
+
+// OpenDispute records a chargeback against a transaction of the merchant with captured
+// funds and charges its fee. Returns ErrNotDisputable when nothing was captured and
+// ErrDisputeExceedsAmount when the disputed amount is more than was captured and not
+// already disputed. The row lock serializes disputes of one transaction.
+func (s *Service) OpenDispute(ctx context.Context, req DisputeRequest) (*Dispute, error) {
+	var tx *Transaction
+	var dispute *Dispute
+
+	err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(dbTx postgres.Executor) error {
+		txRepo := s.txRepo(dbTx)
+
+		var err error
+		tx, err = txRepo.GetByIDForUpdate(ctx, req.TransactionID)
+		if err != nil {
+			return fmt.Errorf("get transaction: %w", err)
+		}
+		if tx.MerchantID != req.MerchantID {
+			return ErrNotFound
+		}
+		if tx.CapturedAmount == 0 {
+			return ErrNotDisputable
+		}
+		disputed, err := txRepo.SumDisputes(ctx, tx.ID)
+		if err != nil {
+			return fmt.Errorf("sum disputes: %w", err)
+		}
+		if req.Amount > tx.CapturedAmount-disputed {
+			return ErrDisputeExceedsAmount
+		}
+
+		dispute = NewDispute(tx.ID, req.Amount, req.Reason)
+		dispute.Fee, err = s.chargeFee(ctx, dbTx, tx, pricing.FeeChargeback, dispute.ID, dispute.Amount, dispute.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := txRepo.CreateDispute(ctx, dispute); err != nil {
+			return fmt.Errorf("create dispute: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("dispute opened",
+		"dispute_id", dispute.ID,
+		"transaction_id", tx.ID,
+		"amount", dispute.Amount,
+		"fee", dispute.Fee,
+		"reason", dispute.Reason,
+	)
+	return dispute, nil
+}
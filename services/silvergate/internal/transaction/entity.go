@@ -4,38 +4,55 @@ import (
 	"errors"
 	"time"
 
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+
 	"github.com/google/uuid"
 )
 
 type Status string
 
 const (
+	// StatusRequiresAction means the cardholder must pass an authentication challenge
+	// before the authorization is sent to the acquirer.
+	StatusRequiresAction    Status = "requires_action"
 	StatusAuthorized        Status = "authorized"
 	StatusDeclined          Status = "declined"
 	StatusCapturePending    Status = "capture_pending"
+	StatusPartiallyCaptured Status = "partially_captured"
 	StatusCaptured          Status = "captured"
 	StatusCaptureFailed     Status = "capture_failed"
 	StatusVoided            Status = "voided"
+	StatusExpired           Status = "expired"
 	StatusPartiallyRefunded Status = "partially_refunded"
 	StatusRefunded          Status = "refunded"
 )
 
 type Transaction struct {
-	ID             uuid.UUID
-	MerchantID     string
-	OrderRef       string
-	Amount         int64
-	Currency       string
-	CardToken      string
-	Status         Status
-	DeclineReason  string
-	IdempotencyKey string
+	ID         uuid.UUID
+	MerchantID string
+	OrderRef   string
+	Amount     int64
+	Currency   string
+	// CardToken is a vault token; CardFingerprint is empty for transactions that predate the vault.
+	CardToken       string
+	CardFingerprint string
+	Status          Status
+	DeclineReason   string
+	IdempotencyKey  string
 	// PurchaseIdempotencyKey dedups product purchases; empty for bare /auth transactions.
 	PurchaseIdempotencyKey string
 	ProductID              *uuid.UUID
+	CapturedAmount         int64
 	RefundedAmount         int64
-	CreatedAt              time.Time
-	UpdatedAt              time.Time
+	// FeeAmount is the total of the fees charged for the transaction's captures, refunds and disputes.
+	FeeAmount int64
+	// ExpiresAt is when the authorization hold lapses; nil for declined transactions.
+	ExpiresAt *time.Time
+	// ChallengeID and ChallengeExpiresAt are set for authorizations that required a challenge.
+	ChallengeID        *uuid.UUID
+	ChallengeExpiresAt *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
 }
 
 // MarkProductPurchase tags the transaction as a product purchase, linking the
@@ -76,36 +93,131 @@ func NewDeclined(merchantID, orderRef string, amount int64, currency, cardToken,
 	}
 }
 
-func (t *Transaction) MarkCapturePending(idempotencyKey string) error {
-	if t.Status != StatusAuthorized {
+// NewRequiresAction parks an authorization until the cardholder completes the challenge.
+func NewRequiresAction(merchantID, orderRef string, amount int64, currency, cardToken string, challengeTTL time.Duration) *Transaction {
+	now := time.Now().UTC()
+	challengeID := uuid.New()
+	challengeExpiresAt := now.Add(challengeTTL)
+	return &Transaction{
+		ID:                 uuid.New(),
+		MerchantID:         merchantID,
+		OrderRef:           orderRef,
+		Amount:             amount,
+		Currency:           currency,
+		CardToken:          cardToken,
+		Status:             StatusRequiresAction,
+		ChallengeID:        &challengeID,
+		ChallengeExpiresAt: &challengeExpiresAt,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+}
+
+// MarkChallengeAuthorized records the acquirer approval that followed a passed challenge.
+func (t *Transaction) MarkChallengeAuthorized(validity time.Duration) error {
+	if t.Status != StatusRequiresAction {
 		return ErrInvalidTransition
 	}
-	t.Status = StatusCapturePending
-	t.IdempotencyKey = idempotencyKey
+	t.Status = StatusAuthorized
 	t.UpdatedAt = time.Now().UTC()
+	expiresAt := t.UpdatedAt.Add(validity)
+	t.ExpiresAt = &expiresAt
 	return nil
 }
 
-func (t *Transaction) MarkCaptured() error {
-	if t.Status != StatusCapturePending {
+// MarkChallengeDeclined closes an authorization whose challenge failed, lapsed, or was
+// followed by an acquirer decline.
+func (t *Transaction) MarkChallengeDeclined(reason string) error {
+	if t.Status != StatusRequiresAction {
 		return ErrInvalidTransition
 	}
-	t.Status = StatusCaptured
+	t.Status = StatusDeclined
+	t.DeclineReason = reason
 	t.UpdatedAt = time.Now().UTC()
 	return nil
 }
 
-func (t *Transaction) MarkCaptureFailed() error {
+// IsChallengeExpired reports whether the cardholder ran out of time for the challenge.
+func (t *Transaction) IsChallengeExpired(now time.Time) bool {
+	return t.ChallengeExpiresAt != nil && !now.Before(*t.ChallengeExpiresAt)
+}
+
+// SetExpiry starts the authorization validity window from the transaction creation time.
+func (t *Transaction) SetExpiry(validity time.Duration) {
+	expiresAt := t.CreatedAt.Add(validity)
+	t.ExpiresAt = &expiresAt
+}
+
+// IsExpired reports whether the authorization hold has lapsed at now.
+func (t *Transaction) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// ledgerSource identifies the transaction on its ledger entries.
+func (t *Transaction) ledgerSource() ledger.Source {
+	return ledger.Source{MerchantID: t.MerchantID, Currency: t.Currency, TransactionID: t.ID}
+}
+
+// RemainingCapturable is the part of the authorization not yet captured.
+func (t *Transaction) RemainingCapturable() int64 {
+	return t.Amount - t.CapturedAmount
+}
+
+// Refundable reports whether the transaction holds captured funds that can be refunded.
+// A partially captured authorization must be voided or fully captured first.
+func (t *Transaction) Refundable() bool {
+	if t.CapturedAmount == 0 {
+		return false
+	}
+	return t.Status.CanTransitionTo(StatusPartiallyRefunded)
+}
+
+// MarkCapturePending starts a (possibly partial) capture. Captures are serialized:
+// only one can be in flight, and further captures are allowed while partially_captured.
+func (t *Transaction) MarkCapturePending(amount int64, idempotencyKey string) error {
+	if t.Status != StatusAuthorized && t.Status != StatusPartiallyCaptured {
+		return ErrInvalidTransition
+	}
+	if t.IsExpired(time.Now().UTC()) {
+		return ErrAuthorizationExpired
+	}
+	if amount > t.RemainingCapturable() {
+		return ErrCaptureExceedsAmount
+	}
+	t.Status = StatusCapturePending
+	t.IdempotencyKey = idempotencyKey
+	t.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// ApplyCaptureResult settles the in-flight capture. A successful capture closes the
+// authorization when it is final or nothing remains to capture; a failed capture on
+// an authorization that already has captured funds leaves it partially captured.
+func (t *Transaction) ApplyCaptureResult(c *Capture) error {
 	if t.Status != StatusCapturePending {
 		return ErrInvalidTransition
 	}
-	t.Status = StatusCaptureFailed
+	switch {
+	case c.Status == CaptureStatusDone:
+		t.CapturedAmount += c.Amount
+		if c.Final || t.RemainingCapturable() == 0 {
+			t.Status = StatusCaptured
+		} else {
+			t.Status = StatusPartiallyCaptured
+		}
+	case t.CapturedAmount > 0:
+		t.Status = StatusPartiallyCaptured
+	default:
+		t.Status = StatusCaptureFailed
+	}
 	t.UpdatedAt = time.Now().UTC()
 	return nil
 }
 
+// MarkVoided closes an authorization on the merchant's request. A partially captured
+// authorization keeps its captured funds; only the remainder of the hold is released.
 func (t *Transaction) MarkVoided() error {
-	if t.Status != StatusAuthorized {
+	if !t.holdsUncaptured() {
 		return ErrInvalidTransition
 	}
 	t.Status = StatusVoided
@@ -113,11 +225,42 @@ func (t *Transaction) MarkVoided() error {
 	return nil
 }
 
+// MarkExpired closes an authorization whose hold has lapsed before it was fully captured.
+// A partially captured one keeps its captured funds; only the remainder is released.
+func (t *Transaction) MarkExpired(now time.Time) error {
+	if !t.holdsUncaptured() {
+		return ErrInvalidTransition
+	}
+	if !t.IsExpired(now) {
+		return ErrNotExpired
+	}
+	t.Status = StatusExpired
+	t.UpdatedAt = now
+	return nil
+}
+
+// holdsUncaptured reports whether the authorization still holds funds that a void or expiry
+// releases. A failed capture leaves the whole hold in place.
+func (t *Transaction) holdsUncaptured() bool {
+	switch t.Status {
+	case StatusAuthorized, StatusPartiallyCaptured, StatusCaptureFailed:
+		return true
+	default:
+		return false
+	}
+}
+
 var validTransitions = map[Status][]Status{
-	StatusAuthorized:        {StatusCapturePending, StatusVoided},
-	StatusCapturePending:    {StatusCaptured, StatusCaptureFailed},
+	StatusRequiresAction:    {StatusAuthorized, StatusDeclined},
+	StatusAuthorized:        {StatusCapturePending, StatusVoided, StatusExpired},
+	StatusCapturePending:    {StatusCaptured, StatusCaptureFailed, StatusPartiallyCaptured},
+	StatusCaptureFailed:     {StatusVoided, StatusExpired},
+	StatusPartiallyCaptured: {StatusCapturePending, StatusVoided, StatusExpired},
 	StatusCaptured:          {StatusPartiallyRefunded, StatusRefunded},
 	StatusPartiallyRefunded: {StatusPartiallyRefunded, StatusRefunded},
+	// A voided or expired authorization that was partially captured keeps its captured funds.
+	StatusVoided:  {StatusPartiallyRefunded, StatusRefunded},
+	StatusExpired: {StatusPartiallyRefunded, StatusRefunded},
 }
 
 func (s Status) CanTransitionTo(target Status) bool {
@@ -4,10 +4,25 @@ import "errors"
 
 var (
 	ErrNotFound                    = errors.New("transaction not found")
+	ErrCaptureNotFound             = errors.New("capture not found")
+	ErrRefundNotFound              = errors.New("refund not found")
+	ErrIntentNotFound              = errors.New("acquirer intent not found")
+	ErrIntentResolved              = errors.New("acquirer intent was resolved by another operation")
 	ErrAlreadyCaptured             = errors.New("transaction already captured")
 	ErrDuplicateIdempotency        = errors.New("duplicate idempotency key")
 	ErrPurchaseIdempotencyConflict = errors.New("purchase idempotency key already used")
 	ErrRefundExceedsAmount         = errors.New("refund amount exceeds remaining balance")
+	ErrCaptureExceedsAmount        = errors.New("capture amount exceeds remaining authorized amount")
 	ErrNotRefundable               = errors.New("transaction is not in a refundable state")
+	ErrAuthorizationOpen           = errors.New("authorization still holds an uncaptured remainder")
 	ErrStatusChanged               = errors.New("transaction status was changed by another operation")
+	ErrAuthorizationExpired        = errors.New("authorization has expired")
+	ErrNotExpired                  = errors.New("authorization has not expired yet")
+	ErrValidityWindowNotFound      = errors.New("auth validity window not found")
+	ErrTooManyTransactionIDs       = errors.New("too many transaction ids in query")
+	ErrChallengeNotFound           = errors.New("challenge not found")
+	ErrChallengeCompleted          = errors.New("challenge already completed")
+	ErrChallengeExpired            = errors.New("challenge has expired")
+	ErrNotDisputable               = errors.New("transaction has no captured funds to dispute")
+	ErrDisputeExceedsAmount        = errors.New("dispute amount exceeds captured amount")
 )
@@ -0,0 +1,80 @@
+package transaction
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExpirySweeperConfig holds configuration for the authorization expiry sweeper.
+// A transaction that fails to expire is skipped for RetryAfter, so it does not hold up
+// the ones behind it.
+type ExpirySweeperConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	RetryAfter   time.Duration
+}
+
+// ExpirySweeper periodically voids authorizations whose hold has lapsed.
+// Each transaction is expired under a row lock, so several replicas can sweep concurrently.
+type ExpirySweeper struct {
+	svc *Service
+	cfg ExpirySweeperConfig
+	log *slog.Logger
+
+	// failing maps transactions that failed to expire to when they are tried again.
+	failing map[uuid.UUID]time.Time
+}
+
+func NewExpirySweeper(svc *Service, cfg ExpirySweeperConfig, log *slog.Logger) *ExpirySweeper {
+	return &ExpirySweeper{svc: svc, cfg: cfg, log: log, failing: make(map[uuid.UUID]time.Time)}
+}
+
+// Start begins the sweep loop. Blocks until ctx is cancelled.
+func (w *ExpirySweeper) Start(ctx context.Context) error {
+	w.log.Info("expiry sweeper started",
+		"poll_interval", w.cfg.PollInterval,
+		"batch_size", w.cfg.BatchSize)
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info("expiry sweeper stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			w.sweep(ctx)
+		}
+	}
+}
+
+func (w *ExpirySweeper) sweep(ctx context.Context) {
+	now := time.Now()
+	maps.DeleteFunc(w.failing, func(_ uuid.UUID, retryAt time.Time) bool {
+		return !now.Before(retryAt)
+	})
+
+	// Drain full batches so a backlog does not wait a whole interval per batch.
+	for {
+		n, failed, err := w.svc.ExpireAuthorizations(ctx, w.cfg.BatchSize, slices.Collect(maps.Keys(w.failing)))
+		if err != nil {
+			w.log.Error("expiry sweep failed", "error", err)
+			return
+		}
+		for _, id := range failed {
+			w.failing[id] = time.Now().Add(w.cfg.RetryAfter)
+		}
+		if n > 0 {
+			w.log.Info("expired authorizations", "count", n)
+		}
+		if n+len(failed) < w.cfg.BatchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package transaction
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type IntentKind string
+
+const (
+	IntentAuthorize IntentKind = "authorize"
+	IntentSettle    IntentKind = "settle"
+	IntentRefund    IntentKind = "refund"
+)
+
+type IntentStatus string
+
+const (
+	IntentAuthorizing IntentStatus = "authorizing"
+	IntentSettling    IntentStatus = "settling"
+	IntentRefunding   IntentStatus = "refunding"
+	IntentSucceeded   IntentStatus = "succeeded"
+	IntentFailed      IntentStatus = "failed"
+	// IntentReversed is an approved authorization whose transaction was never stored;
+	// the reconciler voided it with the acquirer.
+	IntentReversed IntentStatus = "reversed"
+	// IntentAbandoned is an authorization the acquirer never received.
+	IntentAbandoned IntentStatus = "abandoned"
+)
+
+// Intent records an acquirer call before it is made, so a call whose outcome is lost to a
+// crash or a failed commit can be found and resolved by the reconciler. It is stored in its
+// own DB transaction ahead of the call and finished in the DB transaction that stores the
+// outcome, so an intent still in flight after a timeout means the outcome was not stored.
+// ID is sent to the acquirer as the idempotency reference of the call.
+type Intent struct {
+	ID            uuid.UUID
+	Kind          IntentKind
+	Status        IntentStatus
+	TransactionID uuid.UUID
+	// OperationID is the capture or refund being settled; nil for authorizations.
+	OperationID *uuid.UUID
+	Amount      int64
+	Reason      string
+	// Attempts counts the reconcile attempts that failed to resolve the intent.
+	Attempts  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+var inFlightStatus = map[IntentKind]IntentStatus{
+	IntentAuthorize: IntentAuthorizing,
+	IntentSettle:    IntentSettling,
+	IntentRefund:    IntentRefunding,
+}
+
+func NewIntent(kind IntentKind, txID uuid.UUID, operationID *uuid.UUID, amount int64) *Intent {
+	now := time.Now().UTC()
+	return &Intent{
+		ID:            uuid.New(),
+		Kind:          kind,
+		Status:        inFlightStatus[kind],
+		TransactionID: txID,
+		OperationID:   operationID,
+		Amount:        amount,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+// Reference is the idempotency reference the acquirer call is made with.
+func (i *Intent) Reference() string {
+	return i.ID.String()
+}
+
+// InFlight reports whether the outcome of the call has not been stored yet.
+func (i *Intent) InFlight() bool {
+	return i.Status == inFlightStatus[i.Kind]
+}
+
+// Finish records the acquirer outcome: a success, or a decline or rejection with its reason.
+func (i *Intent) Finish(success bool, reason string) {
+	if success {
+		i.resolve(IntentSucceeded, "")
+		return
+	}
+	i.resolve(IntentFailed, reason)
+}
+
+func (i *Intent) MarkReversed() {
+	i.resolve(IntentReversed, "")
+}
+
+func (i *Intent) MarkAbandoned() {
+	i.resolve(IntentAbandoned, "not received by acquirer")
+}
+
+func (i *Intent) resolve(status IntentStatus, reason string) {
+	i.Status = status
+	i.Reason = reason
+	i.UpdatedAt = time.Now().UTC()
+}
@@ -0,0 +1,81 @@
+package transaction
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// IntentReconcilerConfig holds configuration for the acquirer intent reconciler.
+// StaleAfter must comfortably exceed the time an acquirer call and the commit of its
+// outcome take, or calls still running are resolved from under them.
+type IntentReconcilerConfig struct {
+	PollInterval time.Duration
+	StaleAfter   time.Duration
+	BatchSize    int
+	// InitialBackoff is the wait after the first failed reconcile attempt of an intent; it
+	// doubles per attempt up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Backoff returns the wait before the attempt after the given number of failed attempts.
+func (c IntentReconcilerConfig) Backoff(attempts int) time.Duration {
+	backoff := c.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= c.MaxBackoff {
+			return c.MaxBackoff
+		}
+	}
+	return min(backoff, c.MaxBackoff)
+}
+
+// IntentReconciler periodically resolves acquirer intents whose outcome was never stored.
+type IntentReconciler struct {
+	svc *Service
+	cfg IntentReconcilerConfig
+	log *slog.Logger
+}
+
+func NewIntentReconciler(svc *Service, cfg IntentReconcilerConfig, log *slog.Logger) *IntentReconciler {
+	return &IntentReconciler{svc: svc, cfg: cfg, log: log}
+}
+
+// Start begins the reconcile loop. Blocks until ctx is cancelled.
+func (w *IntentReconciler) Start(ctx context.Context) error {
+	w.log.Info("intent reconciler started",
+		"poll_interval", w.cfg.PollInterval,
+		"stale_after", w.cfg.StaleAfter,
+		"batch_size", w.cfg.BatchSize)
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info("intent reconciler stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			w.reconcile(ctx)
+		}
+	}
+}
+
+func (w *IntentReconciler) reconcile(ctx context.Context) {
+	// Drain full batches so a backlog does not wait a whole interval per batch.
+	for {
+		n, err := w.svc.ReconcileIntents(ctx, w.cfg)
+		if err != nil {
+			w.log.Error("intent reconcile failed", "error", err)
+			return
+		}
+		if n > 0 {
+			w.log.Info("reconciled acquirer intents", "count", n)
+		}
+		if n < w.cfg.BatchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
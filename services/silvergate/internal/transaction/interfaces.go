@@ -2,6 +2,11 @@ package transaction
 
 import (
 	"context"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+	"TestTaskJustPay/services/silvergate/internal/pricing"
+	"TestTaskJustPay/services/silvergate/internal/vault"
 
 	"github.com/google/uuid"
 )
@@ -11,19 +16,79 @@ type Repo interface {
 	Create(ctx context.Context, tx *Transaction) error
 	GetByID(ctx context.Context, id uuid.UUID) (*Transaction, error)
 	GetByIDForUpdate(ctx context.Context, id uuid.UUID) (*Transaction, error)
-	// GetByPurchaseIdempotencyKey is the pre-check lookup for /purchase idempotency.
-	// Returns ErrNotFound when no transaction exists for the (merchant_id, key) pair.
-	GetByPurchaseIdempotencyKey(ctx context.Context, merchantID, key string) (*Transaction, error)
+	// GetByChallengeIDForUpdate returns ErrChallengeNotFound when no transaction has the challenge.
+	GetByChallengeIDForUpdate(ctx context.Context, challengeID uuid.UUID) (*Transaction, error)
+	// CompleteChallenge writes the outcome of a challenge only if the row is still in requires_action.
+	CompleteChallenge(ctx context.Context, tx *Transaction) error
+	// GetByIDs returns the transactions of merchantID that exist among ids; unknown ids
+	// and other merchants' transactions are skipped.
+	GetByIDs(ctx context.Context, merchantID string, ids []uuid.UUID) ([]*Transaction, error)
 	UpdateStatus(ctx context.Context, tx *Transaction) error
 	CompareAndUpdateStatus(ctx context.Context, id uuid.UUID, expected, next Status) error
+	// CompareAndUpdateCapture writes status and captured_amount only if the row is still in expected status.
+	CompareAndUpdateCapture(ctx context.Context, tx *Transaction, expected Status) error
+	CreateCapture(ctx context.Context, capture *Capture) error
+	// GetCaptureByIdempotencyKey returns ErrCaptureNotFound when no capture exists for the (transaction_id, key) pair.
+	GetCaptureByIdempotencyKey(ctx context.Context, txID uuid.UUID, key string) (*Capture, error)
+	// GetCaptureByID returns ErrCaptureNotFound for unknown ids.
+	GetCaptureByID(ctx context.Context, id uuid.UUID) (*Capture, error)
+	UpdateCaptureStatus(ctx context.Context, capture *Capture) error
 	UpdateRefund(ctx context.Context, tx *Transaction) error
 	CreateRefund(ctx context.Context, refund *Refund) error
+	// GetRefundByID returns ErrRefundNotFound for unknown ids.
+	GetRefundByID(ctx context.Context, id uuid.UUID) (*Refund, error)
 	UpdateRefundStatus(ctx context.Context, refund *Refund) error
 	ReleaseRefundAmount(ctx context.Context, txID uuid.UUID, amount int64) error
+	// AddFee adds amount to the fees charged for the transaction.
+	AddFee(ctx context.Context, txID uuid.UUID, amount int64) error
+	CreateDispute(ctx context.Context, d *Dispute) error
+	// SumDisputes returns the total amount already disputed on the transaction.
+	SumDisputes(ctx context.Context, txID uuid.UUID) (int64, error)
+	// ListRefundsByTransactionIDs returns all refunds of the given transactions, oldest first.
+	ListRefundsByTransactionIDs(ctx context.Context, txIDs []uuid.UUID) ([]*Refund, error)
+	// GetAuthValidity resolves the merchant's validity window for currency, falling back to
+	// the merchant-wide window. Returns ErrValidityWindowNotFound when neither is configured.
+	GetAuthValidity(ctx context.Context, merchantID, currency string) (time.Duration, error)
+	// ListExpiredAuthorizations returns authorized and partially captured transactions whose
+	// hold lapsed at or before now, leaving out skip.
+	ListExpiredAuthorizations(ctx context.Context, now time.Time, skip []uuid.UUID, limit int) ([]uuid.UUID, error)
+	CreateIntent(ctx context.Context, intent *Intent) error
+	// GetIntent and GetIntentForUpdate return ErrIntentNotFound for unknown ids.
+	GetIntent(ctx context.Context, id uuid.UUID) (*Intent, error)
+	GetIntentForUpdate(ctx context.Context, id uuid.UUID) (*Intent, error)
+	// FinishIntent writes the outcome of an intent only if it is still in flight and
+	// returns ErrIntentResolved otherwise.
+	FinishIntent(ctx context.Context, intent *Intent) error
+	// ListStaleIntents returns intents still in flight that were created before the given
+	// time and are due for another attempt at now, oldest first.
+	ListStaleIntents(ctx context.Context, before, now time.Time, limit int) ([]*Intent, error)
+	// DeferIntent counts a failed reconcile attempt and holds the intent back until next.
+	DeferIntent(ctx context.Context, id uuid.UUID, next time.Time) error
+}
+
+// Ledger records the money movement of each state change. The service binds it to the
+// DB transaction of the state change, so an entry commits or rolls back with it.
+type Ledger interface {
+	Post(ctx context.Context, e *ledger.Entry) error
 }
 
-// WebhookSender notifies the merchant of transaction lifecycle events.
+// Pricing prices captures, refunds and disputes with the merchant's plan in effect at the time.
+type Pricing interface {
+	Fee(ctx context.Context, ev pricing.Event) (int64, error)
+}
+
+// WebhookSender notifies the merchant of transaction lifecycle events. The service binds it
+// to the DB transaction of the state change, so the event is queued only if the change commits.
 type WebhookSender interface {
-	SendCaptureResult(ctx context.Context, tx *Transaction) error
+	// SendCaptureResult reports a capture settlement, a void or an expiry; capture is nil for the latter two.
+	SendCaptureResult(ctx context.Context, tx *Transaction, capture *Capture) error
 	SendRefundResult(ctx context.Context, tx *Transaction, refund *Refund) error
+	// SendAuthorizationResult reports how an authorization that required a challenge ended.
+	SendAuthorizationResult(ctx context.Context, tx *Transaction) error
+}
+
+// CardVault resolves the card tokens transactions are authorized with.
+type CardVault interface {
+	// Get returns vault.ErrNotFound for unknown tokens and tokens of other merchants.
+	Get(ctx context.Context, merchantID, token string) (*vault.Card, error)
 }
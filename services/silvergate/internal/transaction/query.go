@@ -0,0 +1,58 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// MaxQueryTransactions caps how many transactions a single state query may return.
+const MaxQueryTransactions = 100
+
+// TransactionState is a transaction together with its refunds, as exposed to
+// merchants reconciling their records against ours.
+type TransactionState struct {
+	Transaction *Transaction
+	Refunds     []*Refund
+}
+
+// QueryTransactions returns the current state of the requested transactions of merchantID.
+// Unknown ids and other merchants' transactions are omitted from the result rather than
+// reported as errors, so the response does not reveal which ids exist.
+func (s *Service) QueryTransactions(ctx context.Context, merchantID string, ids []uuid.UUID) ([]TransactionState, error) {
+	if len(ids) > MaxQueryTransactions {
+		return nil, ErrTooManyTransactionIDs
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	txs, err := s.repo.GetByIDs(ctx, merchantID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get transactions: %w", err)
+	}
+	if len(txs) == 0 {
+		return nil, nil
+	}
+
+	found := make([]uuid.UUID, 0, len(txs))
+	for _, tx := range txs {
+		found = append(found, tx.ID)
+	}
+	refunds, err := s.repo.ListRefundsByTransactionIDs(ctx, found)
+	if err != nil {
+		return nil, fmt.Errorf("list refunds: %w", err)
+	}
+
+	byTx := make(map[uuid.UUID][]*Refund, len(txs))
+	for _, rf := range refunds {
+		byTx[rf.TransactionID] = append(byTx[rf.TransactionID], rf)
+	}
+
+	states := make([]TransactionState, 0, len(txs))
+	for _, tx := range txs {
+		states = append(states, TransactionState{Transaction: tx, Refunds: byTx[tx.ID]})
+	}
+	return states, nil
+}
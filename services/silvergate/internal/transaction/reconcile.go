@@ -0,0 +1,209 @@
+package transaction
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/acquirer"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ReconcileIntents resolves up to cfg.BatchSize acquirer intents still in flight
+// cfg.StaleAfter after they were recorded, and returns how many it resolved. An intent that
+// fails to resolve is retried after a backoff, so it does not hold up the intents behind it.
+func (s *Service) ReconcileIntents(ctx context.Context, cfg IntentReconcilerConfig) (int, error) {
+	now := time.Now().UTC()
+	intents, err := s.repo.ListStaleIntents(ctx, now.Add(-cfg.StaleAfter), now, cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("list stale intents: %w", err)
+	}
+
+	resolved := 0
+	for _, intent := range intents {
+		err := s.reconcile(ctx, intent)
+		if errors.Is(err, ErrIntentResolved) {
+			// Finished by the original call or another replica since it was listed.
+			continue
+		}
+		if err != nil {
+			next := time.Now().UTC().Add(cfg.Backoff(intent.Attempts + 1))
+			s.log.Error("failed to reconcile acquirer intent",
+				"intent_id", intent.ID, "attempt", intent.Attempts+1, "next_attempt_at", next, "error", err)
+			if err := s.repo.DeferIntent(ctx, intent.ID, next); err != nil {
+				s.log.Error("failed to defer acquirer intent", "intent_id", intent.ID, "error", err)
+			}
+			continue
+		}
+		resolved++
+	}
+	return resolved, nil
+}
+
+// ReconcileIntent resolves a single acquirer intent still in flight. It returns
+// ErrIntentResolved when the outcome was already stored.
+func (s *Service) ReconcileIntent(ctx context.Context, id uuid.UUID) error {
+	intent, err := s.repo.GetIntent(ctx, id)
+	if err != nil {
+		return fmt.Errorf("get intent: %w", err)
+	}
+	return s.reconcile(ctx, intent)
+}
+
+// reconcile asks the acquirer what became of an intent and stores the outcome:
+//   - an authorization the acquirer never received is abandoned and a declined one failed;
+//     an approved one has no stored transaction to hold it, so it is voided and reversed
+//   - a settlement or refund the acquirer never received is sent again under the same
+//     reference; its outcome is then stored as the original call would have stored it
+//
+// The lookup and resend run outside the DB transaction: the acquirer answers a reference
+// with its first outcome, so the original call and the reconciler see the same result, and
+// whichever stores it first under the intent's row lock wins.
+func (s *Service) reconcile(ctx context.Context, intent *Intent) error {
+	if !intent.InFlight() {
+		return ErrIntentResolved
+	}
+
+	var op acquirer.Operation
+	var err error
+	received := true
+	switch intent.Kind {
+	case IntentAuthorize:
+		op, err = s.acq.Lookup(ctx, intent.Reference())
+		if errors.Is(err, acquirer.ErrReferenceNotFound) {
+			received, err = false, nil
+		}
+		if err != nil {
+			return fmt.Errorf("acquirer lookup: %w", err)
+		}
+	case IntentSettle:
+		op, err = s.lookupOrResend(ctx, intent, func() (acquirer.Operation, error) {
+			result, err := s.acq.Settle(ctx, intent.Reference(), intent.TransactionID.String(), intent.Amount)
+			return acquirer.Operation(result), err
+		})
+	case IntentRefund:
+		op, err = s.lookupOrResend(ctx, intent, func() (acquirer.Operation, error) {
+			result, err := s.acq.Refund(ctx, intent.Reference(), intent.TransactionID.String(), intent.Amount)
+			return acquirer.Operation(result), err
+		})
+	default:
+		return fmt.Errorf("unknown intent kind %q", intent.Kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	var settled *Transaction
+	err = s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(dbTx postgres.Executor) error {
+		txRepo := s.txRepo(dbTx)
+
+		locked, err := txRepo.GetIntentForUpdate(ctx, intent.ID)
+		if err != nil {
+			return fmt.Errorf("get intent: %w", err)
+		}
+		if !locked.InFlight() {
+			return ErrIntentResolved
+		}
+
+		switch intent.Kind {
+		case IntentAuthorize:
+			return s.reconcileAuthorization(ctx, txRepo, intent, op, received)
+		case IntentSettle:
+			settled, err = s.reconcileSettlement(ctx, dbTx, intent, op)
+			return err
+		default:
+			return s.reconcileRefund(ctx, dbTx, intent, op)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log.Info("acquirer intent reconciled",
+		"intent_id", intent.ID,
+		"kind", intent.Kind,
+		"transaction_id", intent.TransactionID,
+		"status", intent.Status,
+		"reason", intent.Reason,
+	)
+
+	if settled != nil && settled.Status == StatusCaptured && settled.RemainingCapturable() > 0 {
+		s.releaseRemainder(ctx, settled)
+	}
+	return nil
+}
+
+func (s *Service) reconcileAuthorization(ctx context.Context, txRepo Repo, intent *Intent, op acquirer.Operation, received bool) error {
+	switch {
+	case !received:
+		intent.MarkAbandoned()
+	case !op.Success:
+		intent.Finish(false, op.Reason)
+	default:
+		// The void stays under the intent's row lock, so the original call cannot store the
+		// transaction once its hold is released.
+		result, err := s.acq.Void(ctx, intent.TransactionID.String())
+		if err != nil {
+			return fmt.Errorf("acquirer void: %w", err)
+		}
+		if !result.Success {
+			return fmt.Errorf("void rejected: %s", result.Reason)
+		}
+		intent.MarkReversed()
+	}
+	return finishIntent(ctx, txRepo, intent)
+}
+
+func (s *Service) reconcileSettlement(ctx context.Context, dbTx postgres.Executor, intent *Intent, op acquirer.Operation) (*Transaction, error) {
+	txRepo := s.txRepo(dbTx)
+	tx, err := txRepo.GetByIDForUpdate(ctx, intent.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("get transaction: %w", err)
+	}
+	capture, err := txRepo.GetCaptureByID(ctx, *intent.OperationID)
+	if err != nil {
+		return nil, fmt.Errorf("get capture: %w", err)
+	}
+	if err := applySettleOutcome(tx, capture, intent, op); err != nil {
+		return nil, err
+	}
+	if err := s.recordSettleResult(ctx, dbTx, tx, capture, intent); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+func (s *Service) reconcileRefund(ctx context.Context, dbTx postgres.Executor, intent *Intent, op acquirer.Operation) error {
+	txRepo := s.txRepo(dbTx)
+	tx, err := txRepo.GetByIDForUpdate(ctx, intent.TransactionID)
+	if err != nil {
+		return fmt.Errorf("get transaction: %w", err)
+	}
+	refund, err := txRepo.GetRefundByID(ctx, *intent.OperationID)
+	if err != nil {
+		return fmt.Errorf("get refund: %w", err)
+	}
+	applyRefundOutcome(refund, intent, op)
+	return s.recordRefundResult(ctx, dbTx, tx, refund, intent)
+}
+
+// lookupOrResend returns the acquirer outcome of intent, resending the call under the same
+// reference when the acquirer never received it.
+func (s *Service) lookupOrResend(ctx context.Context, intent *Intent, resend func() (acquirer.Operation, error)) (acquirer.Operation, error) {
+	op, err := s.acq.Lookup(ctx, intent.Reference())
+	if errors.Is(err, acquirer.ErrReferenceNotFound) {
+		op, err = resend()
+		if err != nil {
+			return acquirer.Operation{}, fmt.Errorf("acquirer %s: %w", intent.Kind, err)
+		}
+		return op, nil
+	}
+	if err != nil {
+		return acquirer.Operation{}, fmt.Errorf("acquirer lookup: %w", err)
+	}
+	return op, nil
+}
@@ -14,11 +14,14 @@ const (
 	RefundStatusFailed  RefundStatus = "refund_failed"
 )
 
+// Refund returns captured funds to the cardholder. Fee is charged when the refund
+// settles and stays zero for failed refunds.
 type Refund struct {
 	ID             uuid.UUID
 	TransactionID  uuid.UUID
 	Amount         int64
 	Status         RefundStatus
+	Fee            int64
 	IdempotencyKey string
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
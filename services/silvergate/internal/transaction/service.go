@@ -2,12 +2,15 @@ package transaction
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"time"
 
 	"TestTaskJustPay/pkg/postgres"
 	"TestTaskJustPay/services/silvergate/internal/acquirer"
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+	"TestTaskJustPay/services/silvergate/internal/pricing"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -16,27 +19,44 @@ import (
 type Service struct {
 	repo       Repo
 	acq        acquirer.Acquirer
-	webhooks   WebhookSender
+	cards      CardVault
+	// txWebhooks writes webhook events in the DB transaction of the state change they report.
+	txWebhooks func(postgres.Executor) WebhookSender
 	log        *slog.Logger
 	transactor postgres.Transactor
 	txRepo     func(postgres.Executor) Repo
+	txLedger   func(postgres.Executor) Ledger
+	pricing    Pricing
+	// defaultAuthValidity applies when the merchant has no auth validity window configured.
+	defaultAuthValidity time.Duration
+	challenges          ChallengePolicy
 }
 
 func NewService(
 	repo Repo,
 	acq acquirer.Acquirer,
-	webhooks WebhookSender,
+	cards CardVault,
+	txWebhooks func(postgres.Executor) WebhookSender,
 	log *slog.Logger,
 	transactor postgres.Transactor,
 	txRepo func(postgres.Executor) Repo,
+	txLedger func(postgres.Executor) Ledger,
+	pricing Pricing,
+	defaultAuthValidity time.Duration,
+	challenges ChallengePolicy,
 ) *Service {
 	return &Service{
-		repo:       repo,
-		acq:        acq,
-		webhooks:   webhooks,
-		log:        log,
-		transactor: transactor,
-		txRepo:     txRepo,
+		repo:                repo,
+		acq:                 acq,
+		cards:               cards,
+		txWebhooks:          txWebhooks,
+		log:                 log,
+		transactor:          transactor,
+		txRepo:              txRepo,
+		txLedger:            txLedger,
+		pricing:             pricing,
+		defaultAuthValidity: defaultAuthValidity,
+		challenges:          challenges,
 	}
 }
 
@@ -53,69 +73,238 @@ type AuthRequest struct {
 }
 
 type AuthResponse struct {
-	TransactionID uuid.UUID
-	Status        Status
-	DeclineReason string
+	TransactionID   uuid.UUID
+	OrderID         string
+	Status          Status
+	DeclineReason   string
+	CardFingerprint string
+	// ChallengeID and ChallengeURL are set when Status is StatusRequiresAction.
+	ChallengeID  *uuid.UUID
+	ChallengeURL string
 }
 
+// Authorize handles a bare /auth. Unlike /purchase composition it may park the
+// authorization in requires_action when the challenge policy asks for it.
 func (s *Service) Authorize(ctx context.Context, req AuthRequest) (AuthResponse, error) {
-	tx, err := s.AuthorizeInTx(ctx, s.repo, req)
+	tx, intent, err := s.authorize(ctx, s.repo, req, true)
 	if err != nil {
 		return AuthResponse{}, err
 	}
-	return AuthResponse{
-		TransactionID: tx.ID,
-		Status:        tx.Status,
-		DeclineReason: tx.DeclineReason,
-	}, nil
+	err = s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(dbTx postgres.Executor) error {
+		return s.create(ctx, dbTx, tx, intent)
+	})
+	if err != nil {
+		return AuthResponse{}, err
+	}
+	s.logAuthorization(tx)
+
+	resp := AuthResponse{
+		TransactionID:   tx.ID,
+		OrderID:         tx.OrderRef,
+		Status:          tx.Status,
+		DeclineReason:   tx.DeclineReason,
+		CardFingerprint: tx.CardFingerprint,
+	}
+	if tx.ChallengeID != nil {
+		resp.ChallengeID = tx.ChallengeID
+		resp.ChallengeURL = s.challenges.URL(*tx.ChallengeID)
+	}
+	return resp, nil
 }
 
-// AuthorizeInTx runs the acquirer call and persists the transaction via the given
-// repo. Callers inside an outer DB tx pass a tx-bound repo for atomicity.
-func (s *Service) AuthorizeInTx(ctx context.Context, repo Repo, req AuthRequest) (*Transaction, error) {
-	result, err := s.acq.Authorize(ctx, req.Amount, req.Currency, req.CardToken)
+// AuthorizeInTx resolves the card token, runs the acquirer call and persists the
+// transaction inside the caller's DB tx, so it commits atomically with the caller's
+// writes. The intent of the acquirer call is committed ahead of it and finished in the
+// caller's DB tx, so an approval the caller rolls back is reversed by the reconciler.
+// Returns vault.ErrNotFound when the token is not the merchant's.
+// It never requires a challenge: /purchase has no way to resume one yet.
+func (s *Service) AuthorizeInTx(ctx context.Context, dbTx postgres.Executor, req AuthRequest) (*Transaction, error) {
+	tx, intent, err := s.authorize(ctx, s.txRepo(dbTx), req, false)
 	if err != nil {
-		return nil, fmt.Errorf("acquirer authorize: %w", err)
+		return nil, err
+	}
+	if err := s.create(ctx, dbTx, tx, intent); err != nil {
+		return nil, err
+	}
+	s.logAuthorization(tx)
+	return tx, nil
+}
+
+// authorize builds the new transaction: parked in requires_action, or authorized or
+// declined by the acquirer. The caller persists it with the intent of the acquirer call,
+// which is nil when the acquirer was not called.
+func (s *Service) authorize(ctx context.Context, repo Repo, req AuthRequest, allowChallenge bool) (*Transaction, *Intent, error) {
+	card, err := s.cards.Get(ctx, req.MerchantID, req.CardToken)
+	if err != nil {
+		return nil, nil, fmt.Errorf("resolve card token: %w", err)
+	}
+
+	if allowChallenge && !card.IsExpired(time.Now().UTC()) && s.challenges.Requires(req.Amount, card) {
+		tx := NewRequiresAction(req.MerchantID, req.OrderID, req.Amount, req.Currency, req.CardToken, s.challenges.TTL)
+		tx.CardFingerprint = card.Fingerprint
+		return tx, nil, nil
+	}
+
+	// Expired cards are declined without a round trip to the acquirer.
+	result := acquirer.AuthResult{DeclineReason: "card_expired"}
+	var intent *Intent
+	if !card.IsExpired(time.Now().UTC()) {
+		intent = NewIntent(IntentAuthorize, uuid.New(), nil, req.Amount)
+		result, err = s.authorizeWithAcquirer(ctx, intent, req.Currency, req.CardToken)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	var tx *Transaction
 	if result.Approved {
+		validity, err := s.authValidity(ctx, repo, req.MerchantID, req.Currency)
+		if err != nil {
+			return nil, nil, err
+		}
 		tx = NewAuthorized(req.MerchantID, req.OrderID, req.Amount, req.Currency, req.CardToken)
+		tx.SetExpiry(validity)
 	} else {
 		tx = NewDeclined(req.MerchantID, req.OrderID, req.Amount, req.Currency, req.CardToken, result.DeclineReason)
 	}
+	tx.CardFingerprint = card.Fingerprint
+	if intent != nil {
+		// The intent was recorded under the transaction id before the transaction existed.
+		tx.ID = intent.TransactionID
+	}
 
 	if req.PurchaseIdempotencyKey != "" && req.ProductID != nil {
 		tx.MarkProductPurchase(req.PurchaseIdempotencyKey, *req.ProductID)
 	}
+	return tx, intent, nil
+}
+
+// authorizeWithAcquirer commits the intent, then authorizes with the intent as reference.
+// On a transport error the intent stays in flight: the acquirer may have approved the
+// authorization, and the reconciler finds out.
+func (s *Service) authorizeWithAcquirer(ctx context.Context, intent *Intent, currency, cardToken string) (acquirer.AuthResult, error) {
+	if err := s.repo.CreateIntent(ctx, intent); err != nil {
+		return acquirer.AuthResult{}, fmt.Errorf("record authorize intent: %w", err)
+	}
+	result, err := s.acq.Authorize(ctx, intent.Reference(), intent.Amount, currency, cardToken)
+	if err != nil {
+		return acquirer.AuthResult{}, fmt.Errorf("acquirer authorize: %w", err)
+	}
+	intent.Finish(result.Approved, result.DeclineReason)
+	return result, nil
+}
+
+// create saves a new transaction, the outcome of its acquirer intent and, for an approved
+// one, the ledger entry of its hold.
+func (s *Service) create(ctx context.Context, dbTx postgres.Executor, tx *Transaction, intent *Intent) error {
+	txRepo := s.txRepo(dbTx)
+	if err := txRepo.Create(ctx, tx); err != nil {
+		return fmt.Errorf("save transaction: %w", err)
+	}
+	if err := finishIntent(ctx, txRepo, intent); err != nil {
+		return err
+	}
+	if tx.Status != StatusAuthorized {
+		return nil
+	}
+	return s.post(ctx, dbTx, ledger.Authorization(tx.ledgerSource(), tx.Amount))
+}
 
-	if err := repo.Create(ctx, tx); err != nil {
-		return nil, fmt.Errorf("save transaction: %w", err)
+// finishIntent stores the outcome of intent; a nil intent is a no-op. It fails with
+// ErrIntentResolved when the reconciler got there first, which rolls back the DB tx
+// storing the outcome.
+func finishIntent(ctx context.Context, txRepo Repo, intent *Intent) error {
+	if intent == nil {
+		return nil
+	}
+	if err := txRepo.FinishIntent(ctx, intent); err != nil {
+		return fmt.Errorf("finish %s intent: %w", intent.Kind, err)
 	}
+	return nil
+}
 
+func (s *Service) logAuthorization(tx *Transaction) {
+	if tx.Status == StatusRequiresAction {
+		s.log.Info("authorization requires challenge",
+			"transaction_id", tx.ID,
+			"merchant_id", tx.MerchantID,
+			"challenge_id", tx.ChallengeID,
+		)
+		return
+	}
 	s.log.Info("authorization processed",
 		"transaction_id", tx.ID,
 		"merchant_id", tx.MerchantID,
 		"order_ref", tx.OrderRef,
 		"status", tx.Status,
 	)
+}
 
-	return tx, nil
+// post writes a ledger entry inside dbTx.
+func (s *Service) post(ctx context.Context, dbTx postgres.Executor, e *ledger.Entry) error {
+	if err := s.txLedger(dbTx).Post(ctx, e); err != nil {
+		return fmt.Errorf("post %s ledger entry: %w", e.Kind, err)
+	}
+	return nil
+}
+
+// chargeFee prices a capture, refund or dispute of tx with the merchant's plan in effect at,
+// and charges it inside dbTx. A zero fee posts no ledger entry.
+func (s *Service) chargeFee(ctx context.Context, dbTx postgres.Executor, tx *Transaction, kind pricing.FeeKind, referenceID uuid.UUID, amount int64, at time.Time) (int64, error) {
+	fee, err := s.pricing.Fee(ctx, pricing.Event{
+		MerchantID: tx.MerchantID,
+		Currency:   tx.Currency,
+		Kind:       kind,
+		Amount:     amount,
+		At:         at,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("price %s: %w", kind, err)
+	}
+	if fee == 0 {
+		return 0, nil
+	}
+	if err := s.txRepo(dbTx).AddFee(ctx, tx.ID, fee); err != nil {
+		return 0, fmt.Errorf("add %s fee: %w", kind, err)
+	}
+	if err := s.post(ctx, dbTx, ledger.Fee(tx.ledgerSource(), referenceID, fee)); err != nil {
+		return 0, err
+	}
+	return fee, nil
+}
+
+func (s *Service) authValidity(ctx context.Context, repo Repo, merchantID, currency string) (time.Duration, error) {
+	validity, err := repo.GetAuthValidity(ctx, merchantID, currency)
+	if errors.Is(err, ErrValidityWindowNotFound) {
+		return s.defaultAuthValidity, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get auth validity: %w", err)
+	}
+	return validity, nil
 }
 
 type CaptureRequest struct {
-	TransactionID  uuid.UUID
-	Amount         int64
+	TransactionID uuid.UUID
+	Amount        int64
+	// Final closes the authorization after this capture and releases the uncaptured remainder.
+	// A capture of the whole remaining amount is always final.
+	Final          bool
 	IdempotencyKey string
 }
 
 type CaptureResponse struct {
-	TransactionID uuid.UUID
-	Status        Status
+	TransactionID  uuid.UUID
+	CaptureID      uuid.UUID
+	Status         Status
+	CapturedAmount int64
 }
 
 func (s *Service) Capture(ctx context.Context, req CaptureRequest) (CaptureResponse, error) {
 	var tx *Transaction
+	var capture *Capture
+	var intent *Intent
+	var replayed bool
 
 	err := s.transactor.InTransaction(ctx, pgx.RepeatableRead, func(dbTx postgres.Executor) error {
 		txRepo := s.txRepo(dbTx)
@@ -126,7 +315,17 @@ func (s *Service) Capture(ctx context.Context, req CaptureRequest) (CaptureRespo
 			return fmt.Errorf("get transaction: %w", err)
 		}
 
-		if err := tx.MarkCapturePending(req.IdempotencyKey); err != nil {
+		existing, err := txRepo.GetCaptureByIdempotencyKey(ctx, tx.ID, req.IdempotencyKey)
+		if err == nil {
+			capture = existing
+			replayed = true
+			return nil
+		}
+		if !errors.Is(err, ErrCaptureNotFound) {
+			return fmt.Errorf("get capture: %w", err)
+		}
+
+		if err := tx.MarkCapturePending(req.Amount, req.IdempotencyKey); err != nil {
 			return err
 		}
 
@@ -134,23 +333,46 @@ func (s *Service) Capture(ctx context.Context, req CaptureRequest) (CaptureRespo
 			return fmt.Errorf("update transaction: %w", err)
 		}
 
+		capture = NewCapturePending(tx.ID, req.Amount, req.Final, req.IdempotencyKey)
+		if err := txRepo.CreateCapture(ctx, capture); err != nil {
+			return fmt.Errorf("create capture: %w", err)
+		}
+
+		intent = NewIntent(IntentSettle, tx.ID, &capture.ID, capture.Amount)
+		if err := txRepo.CreateIntent(ctx, intent); err != nil {
+			return fmt.Errorf("record settle intent: %w", err)
+		}
+
 		return nil
 	})
 	if err != nil {
 		return CaptureResponse{}, err
 	}
 
+	if replayed {
+		return CaptureResponse{
+			TransactionID:  tx.ID,
+			CaptureID:      capture.ID,
+			Status:         tx.Status,
+			CapturedAmount: tx.CapturedAmount,
+		}, nil
+	}
+
 	s.log.Info("capture initiated",
 		"transaction_id", tx.ID,
+		"capture_id", capture.ID,
 		"amount", req.Amount,
+		"final", req.Final,
 	)
 
 	// Settle asynchronously — bank processing + webhook
-	go s.settleAsync(tx, req.Amount)
+	go s.settleAsync(tx, capture, intent)
 
 	return CaptureResponse{
-		TransactionID: tx.ID,
-		Status:        StatusCapturePending,
+		TransactionID:  tx.ID,
+		CaptureID:      capture.ID,
+		Status:         StatusCapturePending,
+		CapturedAmount: tx.CapturedAmount,
 	}, nil
 }
 
@@ -170,6 +392,7 @@ type RefundResponse struct {
 func (s *Service) Refund(ctx context.Context, req RefundRequest) (RefundResponse, error) {
 	var tx *Transaction
 	var refund *Refund
+	var intent *Intent
 
 	err := s.transactor.InTransaction(ctx, pgx.RepeatableRead, func(dbTx postgres.Executor) error {
 		txRepo := s.txRepo(dbTx)
@@ -180,18 +403,21 @@ func (s *Service) Refund(ctx context.Context, req RefundRequest) (RefundResponse
 			return fmt.Errorf("get transaction: %w", err)
 		}
 
-		if tx.Status != StatusCaptured && tx.Status != StatusPartiallyRefunded {
+		if tx.Status == StatusPartiallyCaptured {
+			return ErrAuthorizationOpen
+		}
+		if !tx.Refundable() {
 			return ErrNotRefundable
 		}
 
-		remaining := tx.Amount - tx.RefundedAmount
+		remaining := tx.CapturedAmount - tx.RefundedAmount
 		if req.Amount > remaining {
 			return ErrRefundExceedsAmount
 		}
 
 		// Reserve refund amount within the same transaction
 		tx.RefundedAmount += req.Amount
-		if tx.RefundedAmount >= tx.Amount {
+		if tx.RefundedAmount >= tx.CapturedAmount {
 			tx.Status = StatusRefunded
 		} else {
 			tx.Status = StatusPartiallyRefunded
@@ -206,19 +432,23 @@ func (s *Service) Refund(ctx context.Context, req RefundRequest) (RefundResponse
 			return fmt.Errorf("create refund: %w", err)
 		}
 
-		return nil
+		intent = NewIntent(IntentRefund, tx.ID, &refund.ID, refund.Amount)
+		if err := txRepo.CreateIntent(ctx, intent); err != nil {
+			return fmt.Errorf("record refund intent: %w", err)
+		}
+
+		return s.post(ctx, dbTx, ledger.Refund(tx.ledgerSource(), refund.ID, refund.Amount))
 	})
 	if err != nil {
 		return RefundResponse{}, err
 	}
-
 	s.log.Info("refund initiated",
 		"refund_id", refund.ID,
 		"transaction_id", tx.ID,
 		"amount", req.Amount,
 	)
 
-	go s.refundAsync(tx, refund)
+	go s.refundAsync(tx, refund, intent)
 
 	return RefundResponse{
 		RefundID:      refund.ID,
@@ -230,46 +460,90 @@ func (s *Service) Refund(ctx context.Context, req RefundRequest) (RefundResponse
 
 func refundNow() time.Time { return time.Now().UTC() }
 
-func (s *Service) refundAsync(tx *Transaction, refund *Refund) {
+func (s *Service) refundAsync(tx *Transaction, refund *Refund, intent *Intent) {
 	ctx := context.Background()
 
-	result, err := s.acq.Refund(ctx, tx.ID.String(), refund.Amount)
+	result, err := s.acq.Refund(ctx, intent.Reference(), tx.ID.String(), refund.Amount)
 	if err != nil {
-		s.log.Error("acquirer refund failed", "refund_id", refund.ID, "error", err)
-		refund.MarkFailed()
-	} else if result.Success {
+		// The outcome is unknown: the intent stays in flight for the reconciler.
+		s.log.Error("acquirer refund failed", "refund_id", refund.ID, "intent_id", intent.ID, "error", err)
+		return
+	}
+	if !result.Success {
+		s.log.Warn("refund rejected", "refund_id", refund.ID, "reason", result.Reason)
+	}
+	applyRefundOutcome(refund, intent, acquirer.Operation(result))
+
+	// Record the outcome with retry: a failed refund must release the reserved amount
+	const maxRetries = 3
+	for attempt := range maxRetries {
+		err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(dbTx postgres.Executor) error {
+			return s.recordRefundResult(ctx, dbTx, tx, refund, intent)
+		})
+		if errors.Is(err, ErrIntentResolved) {
+			s.log.Info("refund result already recorded by reconciler", "refund_id", refund.ID)
+			return
+		}
+		if err == nil {
+			if attempt > 0 {
+				s.log.Info("refund result recorded after retry",
+					"refund_id", refund.ID, "attempt", attempt+1)
+			}
+			tx.FeeAmount += refund.Fee
+			break
+		}
+		s.log.Error("failed to record refund result",
+			"refund_id", refund.ID, "attempt", attempt+1, "error", err)
+		if attempt == maxRetries-1 {
+			return
+		}
+		time.Sleep(time.Duration(attempt+1) * 100 * time.Millisecond)
+	}
+}
+
+// applyRefundOutcome settles or fails the refund and its intent with the acquirer outcome.
+func applyRefundOutcome(refund *Refund, intent *Intent, op acquirer.Operation) {
+	if op.Success {
 		refund.MarkRefunded()
 	} else {
-		s.log.Warn("refund rejected", "refund_id", refund.ID, "reason", result.Reason)
 		refund.MarkFailed()
 	}
+	intent.Finish(op.Success, op.Reason)
+}
 
-	if err := s.repo.UpdateRefundStatus(ctx, refund); err != nil {
-		s.log.Error("failed to update refund status", "refund_id", refund.ID, "error", err)
-		return
+// recordRefundResult stores the refund outcome with its intent, ledger entries and webhook.
+// A settled refund is charged the refund fee; an acquirer rejection releases the reserved
+// amount back to the transaction and to available.
+func (s *Service) recordRefundResult(ctx context.Context, dbTx postgres.Executor, tx *Transaction, refund *Refund, intent *Intent) error {
+	txRepo := s.txRepo(dbTx)
+	if err := finishIntent(ctx, txRepo, intent); err != nil {
+		return err
+	}
+	if refund.Status == RefundStatusDone {
+		fee, err := s.chargeFee(ctx, dbTx, tx, pricing.FeeRefund, refund.ID, refund.Amount, refund.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		refund.Fee = fee
+	}
+	if err := txRepo.UpdateRefundStatus(ctx, refund); err != nil {
+		return fmt.Errorf("update refund status: %w", err)
 	}
 
-	// Acquirer rejected — release the reserved amount with retry
+	entry := ledger.RefundSettled(tx.ledgerSource(), refund.ID, refund.Amount)
 	if refund.Status == RefundStatusFailed {
-		const maxRetries = 3
-		for attempt := range maxRetries {
-			err := s.repo.ReleaseRefundAmount(ctx, tx.ID, refund.Amount)
-			if err == nil {
-				if attempt > 0 {
-					s.log.Info("refund amount released after retry",
-						"refund_id", refund.ID, "attempt", attempt+1)
-				}
-				break
-			}
-			s.log.Error("failed to release refund amount",
-				"refund_id", refund.ID, "attempt", attempt+1, "error", err)
-			time.Sleep(time.Duration(attempt+1) * 100 * time.Millisecond)
+		if err := txRepo.ReleaseRefundAmount(ctx, tx.ID, refund.Amount); err != nil {
+			return fmt.Errorf("release refund amount: %w", err)
 		}
+		entry = ledger.RefundReversal(tx.ledgerSource(), refund.ID, refund.Amount)
 	}
-
-	if err := s.webhooks.SendRefundResult(ctx, tx, refund); err != nil {
-		s.log.Error("failed to send refund webhook", "refund_id", refund.ID, "error", err)
+	if err := s.post(ctx, dbTx, entry); err != nil {
+		return err
 	}
+	if err := s.txWebhooks(dbTx).SendRefundResult(ctx, tx, refund); err != nil {
+		return fmt.Errorf("send refund webhook: %w", err)
+	}
+	return nil
 }
 
 type VoidResponse struct {
@@ -294,19 +568,11 @@ func (s *Service) Void(ctx context.Context, txID uuid.UUID) (VoidResponse, error
 		}
 
 		// Void with bank (sync) — row is locked, no concurrent capture can proceed
-		result, err := s.acq.Void(ctx, tx.ID.String())
-		if err != nil {
-			return fmt.Errorf("acquirer void: %w", err)
-		}
-		if !result.Success {
-			return fmt.Errorf("void rejected: %s", result.Reason)
-		}
-
-		if err := txRepo.UpdateStatus(ctx, tx); err != nil {
-			return fmt.Errorf("update transaction: %w", err)
+		if err := s.releaseHold(ctx, tx); err != nil {
+			return err
 		}
 
-		return nil
+		return s.recordRelease(ctx, dbTx, tx)
 	})
 	if err != nil {
 		return VoidResponse{}, err
@@ -314,40 +580,199 @@ func (s *Service) Void(ctx context.Context, txID uuid.UUID) (VoidResponse, error
 
 	s.log.Info("transaction voided", "transaction_id", tx.ID)
 
-	go func() {
-		if err := s.webhooks.SendCaptureResult(context.Background(), tx); err != nil {
-			s.log.Error("failed to send void webhook", "transaction_id", tx.ID, "error", err)
-		}
-	}()
-
 	return VoidResponse{
 		TransactionID: tx.ID,
-		Status:        StatusVoided,
+		Status:        tx.Status,
 	}, nil
 }
 
-func (s *Service) settleAsync(tx *Transaction, amount int64) {
-	ctx := context.Background()
+// ExpireAuthorizations releases up to limit authorizations whose hold has lapsed, other
+// than skip, and notifies the merchant. Returns how many were expired and the ones that
+// failed to expire, which the caller can skip for a while.
+func (s *Service) ExpireAuthorizations(ctx context.Context, limit int, skip []uuid.UUID) (expired int, failed []uuid.UUID, err error) {
+	ids, err := s.repo.ListExpiredAuthorizations(ctx, time.Now().UTC(), skip, limit)
+	if err != nil {
+		return 0, nil, fmt.Errorf("list expired authorizations: %w", err)
+	}
+
+	for _, id := range ids {
+		err := s.expire(ctx, id)
+		if errors.Is(err, ErrInvalidTransition) || errors.Is(err, ErrNotExpired) {
+			// Captured, voided or expired by another replica since it was listed.
+			continue
+		}
+		if err != nil {
+			s.log.Error("failed to expire authorization", "transaction_id", id, "error", err)
+			failed = append(failed, id)
+			continue
+		}
+		expired++
+	}
+	return expired, failed, nil
+}
+
+func (s *Service) expire(ctx context.Context, txID uuid.UUID) error {
+	var tx *Transaction
+
+	err := s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(dbTx postgres.Executor) error {
+		txRepo := s.txRepo(dbTx)
+
+		var err error
+		tx, err = txRepo.GetByIDForUpdate(ctx, txID)
+		if err != nil {
+			return fmt.Errorf("get transaction: %w", err)
+		}
+
+		if err := tx.MarkExpired(time.Now().UTC()); err != nil {
+			return err
+		}
 
-	result, err := s.acq.Settle(ctx, tx.ID.String(), amount)
+		// Release the hold with the bank (sync) — row is locked, no concurrent capture can proceed
+		if err := s.releaseHold(ctx, tx); err != nil {
+			return err
+		}
 
-	var nextStatus Status
+		return s.recordRelease(ctx, dbTx, tx)
+	})
 	if err != nil {
-		s.log.Error("acquirer settle failed", "transaction_id", tx.ID, "error", err)
-		nextStatus = StatusCaptureFailed
-	} else if result.Success {
-		nextStatus = StatusCaptured
+		return err
+	}
+
+	s.log.Info("authorization expired", "transaction_id", tx.ID, "expires_at", tx.ExpiresAt)
+	return nil
+}
+
+// releaseHold releases what is left of the hold with the acquirer: the whole authorization,
+// or only the uncaptured remainder once part of it has been captured.
+func (s *Service) releaseHold(ctx context.Context, tx *Transaction) error {
+	var result acquirer.VoidResult
+	var err error
+	if tx.CapturedAmount > 0 {
+		result, err = s.acq.Release(ctx, tx.ID.String(), tx.RemainingCapturable())
 	} else {
-		s.log.Warn("settlement rejected", "transaction_id", tx.ID, "reason", result.Reason)
-		nextStatus = StatusCaptureFailed
+		result, err = s.acq.Void(ctx, tx.ID.String())
+	}
+	if err != nil {
+		return fmt.Errorf("acquirer void: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("void rejected: %s", result.Reason)
+	}
+	return nil
+}
+
+// recordRelease stores a voided or expired transaction with the release of its hold and
+// the webhook reporting it.
+func (s *Service) recordRelease(ctx context.Context, dbTx postgres.Executor, tx *Transaction) error {
+	if err := s.txRepo(dbTx).UpdateStatus(ctx, tx); err != nil {
+		return fmt.Errorf("update transaction: %w", err)
+	}
+	if err := s.post(ctx, dbTx, ledger.Release(tx.ledgerSource(), tx.RemainingCapturable())); err != nil {
+		return err
+	}
+	if err := s.txWebhooks(dbTx).SendCaptureResult(ctx, tx, nil); err != nil {
+		return fmt.Errorf("send %s webhook: %w", tx.Status, err)
+	}
+	return nil
+}
+
+func (s *Service) settleAsync(tx *Transaction, capture *Capture, intent *Intent) {
+	ctx := context.Background()
+
+	result, err := s.acq.Settle(ctx, intent.Reference(), tx.ID.String(), capture.Amount)
+	if err != nil {
+		// The outcome is unknown: the intent stays in flight for the reconciler.
+		s.log.Error("acquirer settle failed", "transaction_id", tx.ID, "capture_id", capture.ID, "intent_id", intent.ID, "error", err)
+		return
+	}
+	if !result.Success {
+		s.log.Warn("settlement rejected", "transaction_id", tx.ID, "capture_id", capture.ID, "reason", result.Reason)
+	}
+
+	if err := applySettleOutcome(tx, capture, intent, acquirer.Operation(result)); err != nil {
+		s.log.Error("failed to apply capture result", "transaction_id", tx.ID, "error", err)
+		return
 	}
 
-	if err := s.repo.CompareAndUpdateStatus(ctx, tx.ID, StatusCapturePending, nextStatus); err != nil {
+	err = s.transactor.InTransaction(ctx, pgx.ReadCommitted, func(dbTx postgres.Executor) error {
+		return s.recordSettleResult(ctx, dbTx, tx, capture, intent)
+	})
+	if errors.Is(err, ErrIntentResolved) {
+		s.log.Info("capture result already recorded by reconciler", "transaction_id", tx.ID, "capture_id", capture.ID)
+		return
+	}
+	if err != nil {
 		s.log.Error("failed to update transaction after settle", "transaction_id", tx.ID, "error", err)
 		return
 	}
+	tx.FeeAmount += capture.Fee
+
+	if tx.Status == StatusCaptured && tx.RemainingCapturable() > 0 {
+		s.releaseRemainder(ctx, tx)
+	}
+}
+
+// applySettleOutcome settles or fails the capture and its intent with the acquirer outcome
+// and applies it to the transaction.
+func applySettleOutcome(tx *Transaction, capture *Capture, intent *Intent, op acquirer.Operation) error {
+	if op.Success {
+		capture.MarkCaptured()
+	} else {
+		capture.MarkFailed()
+	}
+	intent.Finish(op.Success, op.Reason)
+	return tx.ApplyCaptureResult(capture)
+}
+
+// recordSettleResult stores a capture outcome with its intent, ledger entries and webhook.
+func (s *Service) recordSettleResult(ctx context.Context, dbTx postgres.Executor, tx *Transaction, capture *Capture, intent *Intent) error {
+	txRepo := s.txRepo(dbTx)
+	if err := finishIntent(ctx, txRepo, intent); err != nil {
+		return err
+	}
+	if err := txRepo.CompareAndUpdateCapture(ctx, tx, StatusCapturePending); err != nil {
+		return err
+	}
+	if err := s.postCaptureResult(ctx, dbTx, tx, capture); err != nil {
+		return err
+	}
+	if err := txRepo.UpdateCaptureStatus(ctx, capture); err != nil {
+		return err
+	}
+	return s.txWebhooks(dbTx).SendCaptureResult(ctx, tx, capture)
+}
+
+// postCaptureResult records a settled capture with its fee, and the release of the remainder
+// when it closed the authorization. A failed capture moves no money.
+func (s *Service) postCaptureResult(ctx context.Context, dbTx postgres.Executor, tx *Transaction, capture *Capture) error {
+	if capture.Status != CaptureStatusDone {
+		return nil
+	}
+	if err := s.post(ctx, dbTx, ledger.Capture(tx.ledgerSource(), capture.ID, capture.Amount)); err != nil {
+		return err
+	}
+	fee, err := s.chargeFee(ctx, dbTx, tx, pricing.FeeCapture, capture.ID, capture.Amount, capture.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	capture.Fee = fee
+	if tx.Status == StatusCaptured && tx.RemainingCapturable() > 0 {
+		return s.post(ctx, dbTx, ledger.Release(tx.ledgerSource(), tx.RemainingCapturable()))
+	}
+	return nil
+}
 
-	if err := s.webhooks.SendCaptureResult(ctx, tx); err != nil {
-		s.log.Error("failed to send webhook", "transaction_id", tx.ID, "error", err)
+// releaseRemainder frees the part of the hold that a final partial capture left uncaptured.
+func (s *Service) releaseRemainder(ctx context.Context, tx *Transaction) {
+	remainder := tx.RemainingCapturable()
+	result, err := s.acq.Release(ctx, tx.ID.String(), remainder)
+	if err != nil {
+		s.log.Error("acquirer release failed", "transaction_id", tx.ID, "amount", remainder, "error", err)
+		return
+	}
+	if !result.Success {
+		s.log.Warn("release rejected", "transaction_id", tx.ID, "amount", remainder, "reason", result.Reason)
+		return
 	}
+	s.log.Info("authorization remainder released", "transaction_id", tx.ID, "amount", remainder)
 }
@@ -15,9 +15,18 @@ import (
 	"TestTaskJustPay/pkg/testinfra"
 	silvergate "TestTaskJustPay/services/silvergate"
 	"TestTaskJustPay/services/silvergate/internal/acquirer"
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+	"TestTaskJustPay/services/silvergate/internal/ledger/ledgerrepo"
+	"TestTaskJustPay/services/silvergate/internal/pricing"
+	"TestTaskJustPay/services/silvergate/internal/pricing/pricingrepo"
 	"TestTaskJustPay/services/silvergate/internal/transaction"
 	txrepo "TestTaskJustPay/services/silvergate/internal/transaction/transactionrepo"
+	"TestTaskJustPay/services/silvergate/internal/vault"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender/endpointrepo"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender/outboxrepo"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -40,48 +49,74 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
-// stubWebhooks tracks async completions via channels.
-type stubWebhooks struct {
-	captureDone chan struct{}
-	refundDone  chan struct{}
+func ledgerFactory(tx postgres.Executor) transaction.Ledger {
+	return ledgerrepo.NewPgLedgerRepo(tx)
 }
 
-func newStubWebhooks() *stubWebhooks {
-	return &stubWebhooks{
-		captureDone: make(chan struct{}, 10),
-		refundDone:  make(chan struct{}, 10),
-	}
+// stubCards resolves every token to a valid card.
+type stubCards struct{}
+
+func (stubCards) Get(_ context.Context, merchantID, token string) (*vault.Card, error) {
+	return &vault.Card{
+		Token:       token,
+		MerchantID:  merchantID,
+		Brand:       vault.BrandVisa,
+		Last4:       "4242",
+		ExpMonth:    12,
+		ExpYear:     time.Now().Year() + 1,
+		Fingerprint: "fp_" + token,
+	}, nil
+}
+
+// noFees prices every event at zero.
+type noFees struct{}
+
+func (noFees) Fee(context.Context, pricing.Event) (int64, error) { return 0, nil }
+
+// webhookFactory queues webhook events in the outbox, as the service does in production.
+func webhookFactory(tx postgres.Executor) transaction.WebhookSender {
+	return webhooksender.NewSender(outboxrepo.NewPgOutboxRepo(tx), endpointrepo.NewPgEndpointRepo(tx))
 }
 
-func (w *stubWebhooks) SendCaptureResult(_ context.Context, _ *transaction.Transaction) error {
-	w.captureDone <- struct{}{}
-	return nil
+// outboxWaiter tracks async completions through the webhook events they commit to the
+// outbox. Tests in this file run sequentially, so events since the waiter was created
+// belong to the running test.
+type outboxWaiter struct {
+	since time.Time
 }
 
-func (w *stubWebhooks) SendRefundResult(_ context.Context, _ *transaction.Transaction, _ *transaction.Refund) error {
-	w.refundDone <- struct{}{}
-	return nil
+func newOutboxWaiter() *outboxWaiter {
+	return &outboxWaiter{since: time.Now().UTC()}
 }
 
-func (w *stubWebhooks) waitCaptures(n int, t *testing.T) {
+func (w *outboxWaiter) waitCaptures(n int, t *testing.T) {
 	t.Helper()
-	for range n {
-		select {
-		case <-w.captureDone:
-		case <-time.After(5 * time.Second):
-			t.Fatal("timeout waiting for capture webhook")
-		}
-	}
+	w.wait(t, n, "capture_id", "capture")
 }
 
-func (w *stubWebhooks) waitRefunds(n int, t *testing.T) {
+func (w *outboxWaiter) waitRefunds(n int, t *testing.T) {
 	t.Helper()
-	for range n {
-		select {
-		case <-w.refundDone:
-		case <-time.After(5 * time.Second):
-			t.Fatal("timeout waiting for refund webhook")
+	w.wait(t, n, "refund_id", "refund")
+}
+
+// wait polls until at least n committed events since the waiter was created carry key.
+func (w *outboxWaiter) wait(t *testing.T, n int, key, what string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var got int
+		err := pg.Pool.QueryRow(context.Background(),
+			"SELECT COUNT(*) FROM webhook_outbox WHERE created_at >= $1 AND payload ? $2",
+			w.since, key,
+		).Scan(&got)
+		require.NoError(t, err)
+		if got >= n {
+			return
 		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s webhook: %d of %d", what, got, n)
+		}
+		time.Sleep(20 * time.Millisecond)
 	}
 }
 
@@ -96,11 +131,11 @@ func TestConcurrentRefund_Overdraft(t *testing.T) {
 
 	repo := txrepo.NewPgTransactionRepo(pg.Pool)
 	acq := acquirer.NewMockAcquirer(1.0, 1.0, 50*time.Millisecond)
-	wh := newStubWebhooks()
+	wh := newOutboxWaiter()
 	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
 		return txrepo.NewPgTransactionRepo(tx)
 	}
-	svc := transaction.NewService(repo, acq, wh, slog.Default(), pg, txRepoFactory)
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, noFees{}, 7*24*time.Hour, transaction.ChallengePolicy{})
 
 	// --- Setup: auth + capture a $50 transaction ---
 
@@ -195,11 +230,11 @@ func TestConcurrentCapture_DuplicateSettle(t *testing.T) {
 
 	repo := txrepo.NewPgTransactionRepo(pg.Pool)
 	acq := acquirer.NewMockAcquirer(1.0, 1.0, 50*time.Millisecond)
-	wh := newStubWebhooks()
+	wh := newOutboxWaiter()
 	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
 		return txrepo.NewPgTransactionRepo(tx)
 	}
-	svc := transaction.NewService(repo, acq, wh, slog.Default(), pg, txRepoFactory)
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, noFees{}, 7*24*time.Hour, transaction.ChallengePolicy{})
 
 	// Auth a $100 transaction
 	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
@@ -261,11 +296,10 @@ func TestSettleAsync_BlindUpdate(t *testing.T) {
 	repo := txrepo.NewPgTransactionRepo(pg.Pool)
 	// 200ms settle delay — gives us a window to modify DB while settleAsync waits
 	acq := acquirer.NewMockAcquirer(1.0, 1.0, 200*time.Millisecond)
-	wh := newStubWebhooks()
 	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
 		return txrepo.NewPgTransactionRepo(tx)
 	}
-	svc := transaction.NewService(repo, acq, wh, slog.Default(), pg, txRepoFactory)
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, noFees{}, 7*24*time.Hour, transaction.ChallengePolicy{})
 
 	// Auth
 	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
@@ -320,11 +354,10 @@ func TestVoidVsCapture_Race(t *testing.T) {
 	repo := txrepo.NewPgTransactionRepo(pg.Pool)
 	// Slow void (200ms), fast settle (50ms) — Void holds lock while calling acquirer
 	acq := acquirer.NewMockAcquirer(1.0, 1.0, 50*time.Millisecond)
-	wh := newStubWebhooks()
 	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
 		return txrepo.NewPgTransactionRepo(tx)
 	}
-	svc := transaction.NewService(repo, acq, wh, slog.Default(), pg, txRepoFactory)
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, noFees{}, 7*24*time.Hour, transaction.ChallengePolicy{})
 
 	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
 		MerchantID: "merchant_void_cap",
@@ -393,11 +426,11 @@ func TestReleaseRefundAmount_UpdatesStatus(t *testing.T) {
 
 	repo := txrepo.NewPgTransactionRepo(pg.Pool)
 	acq := acquirer.NewMockAcquirer(1.0, 0.0, 50*time.Millisecond) // 0% refund success
-	wh := newStubWebhooks()
+	wh := newOutboxWaiter()
 	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
 		return txrepo.NewPgTransactionRepo(tx)
 	}
-	svc := transaction.NewService(repo, acq, wh, slog.Default(), pg, txRepoFactory)
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, noFees{}, 7*24*time.Hour, transaction.ChallengePolicy{})
 
 	// Auth + Capture
 	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
@@ -439,3 +472,688 @@ func TestReleaseRefundAmount_UpdatesStatus(t *testing.T) {
 	assert.Equal(t, transaction.StatusCaptured, tx.Status,
 		"status should revert to 'captured' when all refunds released")
 }
+
+// TestExpireAuthorizations_VoidsLapsedHolds verifies that the expiry sweep voids
+// authorizations past their validity window, notifies the merchant, and that a
+// lapsed authorization can no longer be captured.
+func TestExpireAuthorizations_VoidsLapsedHolds(t *testing.T) {
+	ctx := context.Background()
+
+	repo := txrepo.NewPgTransactionRepo(pg.Pool)
+	acq := acquirer.NewMockAcquirer(1.0, 1.0, 50*time.Millisecond)
+	wh := newOutboxWaiter()
+	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
+		return txrepo.NewPgTransactionRepo(tx)
+	}
+	// Negative default window: every new authorization is already lapsed.
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, noFees{}, -time.Second, transaction.ChallengePolicy{})
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: "merchant_expiry",
+		OrderID:    fmt.Sprintf("expiry_%d", time.Now().UnixNano()),
+		Amount:     5000,
+		Currency:   "USD",
+		CardToken:  "tok_expiry",
+	})
+	require.NoError(t, err)
+	require.Equal(t, transaction.StatusAuthorized, auth.Status)
+
+	_, err = svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         5000,
+		IdempotencyKey: fmt.Sprintf("cap_exp_%d", time.Now().UnixNano()),
+	})
+	require.ErrorIs(t, err, transaction.ErrAuthorizationExpired)
+
+	_, _, err = svc.ExpireAuthorizations(ctx, 1000, nil)
+	require.NoError(t, err)
+	wh.waitCaptures(1, t)
+
+	tx, err := repo.GetByID(ctx, auth.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.StatusExpired, tx.Status)
+}
+
+// TestExpireAuthorizations_ReleasesPartialCaptureRemainder verifies that a partially
+// captured authorization whose hold lapses is expired: the uncaptured remainder is released
+// and the captured funds stay with the merchant.
+func TestExpireAuthorizations_ReleasesPartialCaptureRemainder(t *testing.T) {
+	ctx := context.Background()
+
+	svc, repo := newReconcileService(acquirer.NewMockAcquirer(1.0, 1.0, 10*time.Millisecond))
+	wh := newOutboxWaiter()
+	ledgerSvc := ledger.NewService(ledgerrepo.NewPgLedgerRepo(pg.Pool))
+	merchantID := fmt.Sprintf("merchant_partial_expiry_%d", time.Now().UnixNano())
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: merchantID,
+		OrderID:    "partial_expiry_order",
+		Amount:     5000,
+		Currency:   "USD",
+		CardToken:  "tok_partial",
+	})
+	require.NoError(t, err)
+
+	_, err = svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         2000,
+		IdempotencyKey: "partial_expiry_cap",
+	})
+	require.NoError(t, err)
+	wh.waitCaptures(1, t)
+
+	_, err = pg.Pool.Exec(ctx,
+		"UPDATE transactions SET expires_at = now() - interval '1 minute' WHERE id = $1",
+		auth.TransactionID)
+	require.NoError(t, err)
+
+	_, failed, err := svc.ExpireAuthorizations(ctx, 1000, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, failed, auth.TransactionID)
+	wh.waitCaptures(2, t)
+
+	tx, err := repo.GetByID(ctx, auth.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.StatusExpired, tx.Status)
+	assert.Equal(t, int64(2000), tx.CapturedAmount)
+
+	balances, err := ledgerSvc.Balances(ctx, merchantID)
+	require.NoError(t, err)
+	assert.Equal(t, []ledger.Balance{{Currency: "USD", Available: 2000}}, balances)
+}
+
+// TestExpireAuthorizations_ReleasesFailedCaptureHold verifies that an authorization whose
+// only capture failed still holds its funds until it lapses, and is then expired by the
+// sweep with its pending balance released.
+func TestExpireAuthorizations_ReleasesFailedCaptureHold(t *testing.T) {
+	ctx := context.Background()
+
+	svc, repo := newReconcileService(acquirer.NewMockAcquirer(1.0, 0.0, 10*time.Millisecond)) // 0% settle success
+	wh := newOutboxWaiter()
+	ledgerSvc := ledger.NewService(ledgerrepo.NewPgLedgerRepo(pg.Pool))
+	merchantID := fmt.Sprintf("merchant_failed_capture_expiry_%d", time.Now().UnixNano())
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: merchantID,
+		OrderID:    "failed_capture_expiry_order",
+		Amount:     5000,
+		Currency:   "USD",
+		CardToken:  "tok_failed_capture",
+	})
+	require.NoError(t, err)
+
+	_, err = svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         5000,
+		IdempotencyKey: "failed_capture_expiry_cap",
+	})
+	require.NoError(t, err)
+	wh.waitCaptures(1, t)
+
+	tx, err := repo.GetByID(ctx, auth.TransactionID)
+	require.NoError(t, err)
+	require.Equal(t, transaction.StatusCaptureFailed, tx.Status)
+
+	_, err = pg.Pool.Exec(ctx,
+		"UPDATE transactions SET expires_at = now() - interval '1 minute' WHERE id = $1",
+		auth.TransactionID)
+	require.NoError(t, err)
+
+	_, failed, err := svc.ExpireAuthorizations(ctx, 1000, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, failed, auth.TransactionID)
+
+	tx, err = repo.GetByID(ctx, auth.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.StatusExpired, tx.Status)
+
+	balances, err := ledgerSvc.Balances(ctx, merchantID)
+	require.NoError(t, err)
+	for _, b := range balances {
+		assert.Zero(t, b.Pending, "the failed capture's hold is released")
+	}
+}
+
+// TestCompleteChallenge_AuthorizesOnce verifies that a challenged authorization waits in
+// requires_action, reaches the acquirer only after a passed challenge, and that the
+// challenge cannot be completed twice.
+func TestCompleteChallenge_AuthorizesOnce(t *testing.T) {
+	ctx := context.Background()
+
+	repo := txrepo.NewPgTransactionRepo(pg.Pool)
+	acq := acquirer.NewMockAcquirer(1.0, 1.0, 50*time.Millisecond)
+	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
+		return txrepo.NewPgTransactionRepo(tx)
+	}
+	challenges := transaction.ChallengePolicy{CardLast4: []string{"4242"}, TTL: time.Minute, URLBase: "http://silvergate"}
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, noFees{}, 7*24*time.Hour, challenges)
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: "merchant_sca",
+		OrderID:    fmt.Sprintf("sca_%d", time.Now().UnixNano()),
+		Amount:     5000,
+		Currency:   "USD",
+		CardToken:  "tok_sca",
+	})
+	require.NoError(t, err)
+	require.Equal(t, transaction.StatusRequiresAction, auth.Status)
+	require.NotNil(t, auth.ChallengeID)
+	assert.Equal(t, "http://silvergate/api/v1/challenges/"+auth.ChallengeID.String(), auth.ChallengeURL)
+
+	_, err = svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         5000,
+		IdempotencyKey: fmt.Sprintf("cap_sca_%d", time.Now().UnixNano()),
+	})
+	require.Error(t, err, "a payment awaiting its challenge must not be capturable")
+
+	done, err := svc.CompleteChallenge(ctx, transaction.CompleteChallengeRequest{ChallengeID: *auth.ChallengeID, Passed: true})
+	require.NoError(t, err)
+	assert.Equal(t, transaction.StatusAuthorized, done.Status)
+
+	_, err = svc.CompleteChallenge(ctx, transaction.CompleteChallengeRequest{ChallengeID: *auth.ChallengeID, Passed: false})
+	assert.ErrorIs(t, err, transaction.ErrChallengeCompleted)
+
+	tx, err := repo.GetByID(ctx, auth.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.StatusAuthorized, tx.Status)
+	assert.NotNil(t, tx.ExpiresAt)
+}
+
+// TestLedger_FollowsTransactionLifecycle walks an authorization through a final partial
+// capture and a refund and checks that the ledger agrees with the transaction row.
+func TestLedger_FollowsTransactionLifecycle(t *testing.T) {
+	ctx := context.Background()
+
+	repo := txrepo.NewPgTransactionRepo(pg.Pool)
+	acq := acquirer.NewMockAcquirer(1.0, 1.0, 10*time.Millisecond)
+	wh := newOutboxWaiter()
+	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
+		return txrepo.NewPgTransactionRepo(tx)
+	}
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, noFees{}, 7*24*time.Hour, transaction.ChallengePolicy{})
+	ledgerSvc := ledger.NewService(ledgerrepo.NewPgLedgerRepo(pg.Pool))
+	merchantID := fmt.Sprintf("merchant_ledger_%d", time.Now().UnixNano())
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: merchantID,
+		OrderID:    "ledger_order",
+		Amount:     5000,
+		Currency:   "USD",
+		CardToken:  "tok_ledger",
+	})
+	require.NoError(t, err)
+	require.Equal(t, transaction.StatusAuthorized, auth.Status)
+
+	balances, err := ledgerSvc.Balances(ctx, merchantID)
+	require.NoError(t, err)
+	assert.Equal(t, []ledger.Balance{{Currency: "USD", Pending: 5000}}, balances)
+
+	_, err = svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         3000,
+		Final:          true,
+		IdempotencyKey: "ledger_cap",
+	})
+	require.NoError(t, err)
+	wh.waitCaptures(1, t)
+
+	_, err = svc.Refund(ctx, transaction.RefundRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         1000,
+		IdempotencyKey: "ledger_refund",
+	})
+	require.NoError(t, err)
+	wh.waitRefunds(1, t)
+
+	balances, err = ledgerSvc.Balances(ctx, merchantID)
+	require.NoError(t, err)
+	assert.Equal(t, []ledger.Balance{{Currency: "USD", Available: 2000}}, balances)
+
+	report, err := ledgerSvc.Check(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, report.UnbalancedEntries)
+	for _, m := range report.Mismatches {
+		assert.NotEqual(t, auth.TransactionID, m.TransactionID, "mismatch: %+v", m)
+	}
+}
+
+// TestPartialCapture_VoidThenRefund voids an authorization after a non-final partial
+// capture and refunds the captured part: the void releases only the remainder.
+func TestPartialCapture_VoidThenRefund(t *testing.T) {
+	ctx := context.Background()
+
+	svc, _ := newReconcileService(acquirer.NewMockAcquirer(1.0, 1.0, 10*time.Millisecond))
+	wh := newOutboxWaiter()
+	ledgerSvc := ledger.NewService(ledgerrepo.NewPgLedgerRepo(pg.Pool))
+	merchantID := fmt.Sprintf("merchant_partial_%d", time.Now().UnixNano())
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: merchantID,
+		OrderID:    "partial_order",
+		Amount:     5000,
+		Currency:   "USD",
+		CardToken:  "tok_partial",
+	})
+	require.NoError(t, err)
+
+	_, err = svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         2000,
+		IdempotencyKey: "partial_cap",
+	})
+	require.NoError(t, err)
+	wh.waitCaptures(1, t)
+
+	voided, err := svc.Void(ctx, auth.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.StatusVoided, voided.Status)
+
+	balances, err := ledgerSvc.Balances(ctx, merchantID)
+	require.NoError(t, err)
+	assert.Equal(t, []ledger.Balance{{Currency: "USD", Available: 2000}}, balances)
+
+	_, err = svc.Refund(ctx, transaction.RefundRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         2000,
+		IdempotencyKey: "partial_refund",
+	})
+	require.NoError(t, err)
+	wh.waitRefunds(1, t)
+
+	states, err := svc.QueryTransactions(ctx, merchantID, []uuid.UUID{auth.TransactionID})
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.Equal(t, transaction.StatusRefunded, states[0].Transaction.Status)
+
+	report, err := ledgerSvc.Check(ctx)
+	require.NoError(t, err)
+	for _, m := range report.Mismatches {
+		assert.NotEqual(t, auth.TransactionID, m.TransactionID, "mismatch: %+v", m)
+	}
+}
+
+// TestPartialCapture_RefundRejectedWhileHoldOpen verifies that a partially captured
+// authorization cannot be refunded until it is closed: the refund is rejected, the hold
+// stays capturable, and the captured funds are refundable once the final capture lands.
+func TestPartialCapture_RefundRejectedWhileHoldOpen(t *testing.T) {
+	ctx := context.Background()
+
+	svc, _ := newReconcileService(acquirer.NewMockAcquirer(1.0, 1.0, 10*time.Millisecond))
+	wh := newOutboxWaiter()
+	ledgerSvc := ledger.NewService(ledgerrepo.NewPgLedgerRepo(pg.Pool))
+	merchantID := fmt.Sprintf("merchant_partial_refund_%d", time.Now().UnixNano())
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: merchantID,
+		OrderID:    "partial_refund_order",
+		Amount:     5000,
+		Currency:   "USD",
+		CardToken:  "tok_partial",
+	})
+	require.NoError(t, err)
+
+	_, err = svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         3000,
+		IdempotencyKey: "partial_refund_cap",
+	})
+	require.NoError(t, err)
+	wh.waitCaptures(1, t)
+
+	_, err = svc.Refund(ctx, transaction.RefundRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         1000,
+		IdempotencyKey: "partial_refund_1",
+	})
+	require.ErrorIs(t, err, transaction.ErrAuthorizationOpen)
+
+	_, err = svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         2000,
+		IdempotencyKey: "partial_refund_cap_2",
+	})
+	require.NoError(t, err, "the rejected refund leaves the remainder capturable")
+	wh.waitCaptures(2, t)
+
+	_, err = svc.Refund(ctx, transaction.RefundRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         1000,
+		IdempotencyKey: "partial_refund_2",
+	})
+	require.NoError(t, err)
+	wh.waitRefunds(1, t)
+
+	balances, err := ledgerSvc.Balances(ctx, merchantID)
+	require.NoError(t, err)
+	assert.Equal(t, []ledger.Balance{{Currency: "USD", Available: 4000}}, balances)
+}
+
+func TestPricing_ChargesPlanInEffect(t *testing.T) {
+	ctx := context.Background()
+
+	repo := txrepo.NewPgTransactionRepo(pg.Pool)
+	acq := acquirer.NewMockAcquirer(1.0, 1.0, 10*time.Millisecond)
+	wh := newOutboxWaiter()
+	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
+		return txrepo.NewPgTransactionRepo(tx)
+	}
+	pricingSvc := pricing.NewService(pricingrepo.NewPgPricingRepo(pg.Pool), pricing.Plan{})
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, pricingSvc, 7*24*time.Hour, transaction.ChallengePolicy{})
+	ledgerSvc := ledger.NewService(ledgerrepo.NewPgLedgerRepo(pg.Pool))
+	merchantID := fmt.Sprintf("merchant_pricing_%d", time.Now().UnixNano())
+
+	// The superseded plan, the plan in effect and a scheduled change that must not apply yet.
+	_, err := pg.Pool.Exec(ctx, `INSERT INTO pricing_plans
+		(merchant_id, currency, percent_bps, fixed_fee, refund_fee, chargeback_fee, effective_from) VALUES
+		($1, NULL, 100, 10, 0, 0, now() - interval '30 days'),
+		($1, NULL, 250, 20, 50, 1500, now() - interval '1 day'),
+		($1, NULL, 900, 90, 90, 9000, now() + interval '1 day')`, merchantID)
+	require.NoError(t, err)
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: merchantID,
+		OrderID:    "pricing_order",
+		Amount:     10000,
+		Currency:   "USD",
+		CardToken:  "tok_pricing",
+	})
+	require.NoError(t, err)
+
+	_, err = svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         10000,
+		IdempotencyKey: "pricing_cap",
+	})
+	require.NoError(t, err)
+	wh.waitCaptures(1, t)
+
+	_, err = svc.Refund(ctx, transaction.RefundRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         1000,
+		IdempotencyKey: "pricing_refund",
+	})
+	require.NoError(t, err)
+	wh.waitRefunds(1, t)
+
+	dispute, err := svc.OpenDispute(ctx, transaction.DisputeRequest{
+		MerchantID:    merchantID,
+		TransactionID: auth.TransactionID,
+		Amount:        9000,
+		Reason:        "fraudulent",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1500), dispute.Fee)
+
+	// Only the 1000 left undisputed can be disputed again, and only by the merchant.
+	_, err = svc.OpenDispute(ctx, transaction.DisputeRequest{
+		MerchantID:    merchantID,
+		TransactionID: auth.TransactionID,
+		Amount:        1001,
+		Reason:        "fraudulent",
+	})
+	assert.ErrorIs(t, err, transaction.ErrDisputeExceedsAmount)
+	_, err = svc.OpenDispute(ctx, transaction.DisputeRequest{
+		MerchantID:    "merchant_other",
+		TransactionID: auth.TransactionID,
+		Amount:        1000,
+		Reason:        "fraudulent",
+	})
+	assert.ErrorIs(t, err, transaction.ErrNotFound)
+
+	// 2.5% of 10000 + 20 for the capture, 50 for the refund, 1500 for the chargeback.
+	states, err := svc.QueryTransactions(ctx, merchantID, []uuid.UUID{auth.TransactionID})
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.Equal(t, int64(270+50+1500), states[0].Transaction.FeeAmount)
+	require.Len(t, states[0].Refunds, 1)
+	assert.Equal(t, int64(50), states[0].Refunds[0].Fee)
+
+	balances, err := ledgerSvc.Balances(ctx, merchantID)
+	require.NoError(t, err)
+	assert.Equal(t, []ledger.Balance{{Currency: "USD", Available: 9000 - 1820, Fees: 1820}}, balances)
+
+	report, err := ledgerSvc.Check(ctx)
+	require.NoError(t, err)
+	for _, m := range report.Mismatches {
+		assert.NotEqual(t, auth.TransactionID, m.TransactionID, "mismatch: %+v", m)
+	}
+}
+
+func newReconcileService(acq acquirer.Acquirer) (*transaction.Service, *txrepo.PgTransactionRepo) {
+	repo := txrepo.NewPgTransactionRepo(pg.Pool)
+	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
+		return txrepo.NewPgTransactionRepo(tx)
+	}
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.Default(), pg, txRepoFactory, ledgerFactory, noFees{}, 7*24*time.Hour, transaction.ChallengePolicy{})
+	return svc, repo
+}
+
+// TestReconcileIntents_ResolvesAuthorizations covers authorizations whose outcome was never
+// stored: an approval is voided and reversed, a call the acquirer never received is abandoned.
+func TestReconcileIntents_ResolvesAuthorizations(t *testing.T) {
+	ctx := context.Background()
+	acq := acquirer.NewMockAcquirer(1.0, 1.0, 0)
+	svc, repo := newReconcileService(acq)
+
+	approved := transaction.NewIntent(transaction.IntentAuthorize, uuid.New(), nil, 5000)
+	require.NoError(t, repo.CreateIntent(ctx, approved))
+	_, err := acq.Authorize(ctx, approved.Reference(), 5000, "USD", "tok_lost")
+	require.NoError(t, err)
+
+	unsent := transaction.NewIntent(transaction.IntentAuthorize, uuid.New(), nil, 5000)
+	require.NoError(t, repo.CreateIntent(ctx, unsent))
+
+	require.NoError(t, svc.ReconcileIntent(ctx, approved.ID))
+	require.NoError(t, svc.ReconcileIntent(ctx, unsent.ID))
+	assert.ErrorIs(t, svc.ReconcileIntent(ctx, approved.ID), transaction.ErrIntentResolved)
+
+	got, err := repo.GetIntent(ctx, approved.ID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.IntentReversed, got.Status)
+	got, err = repo.GetIntent(ctx, unsent.ID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.IntentAbandoned, got.Status)
+}
+
+// TestReconcileIntents_ResendsLostSettlement covers a capture whose settle call never reached
+// the acquirer: the reconciler resends it under the same reference and stores the result.
+func TestReconcileIntents_ResendsLostSettlement(t *testing.T) {
+	ctx := context.Background()
+	acq := acquirer.NewMockAcquirer(1.0, 1.0, 0)
+	svc, repo := newReconcileService(acq)
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: "merchant_reconcile",
+		OrderID:    fmt.Sprintf("reconcile_%d", time.Now().UnixNano()),
+		Amount:     5000,
+		Currency:   "USD",
+		CardToken:  "tok_reconcile",
+	})
+	require.NoError(t, err)
+
+	// What Capture commits before handing the settle call to a goroutine that never ran.
+	tx, err := repo.GetByID(ctx, auth.TransactionID)
+	require.NoError(t, err)
+	require.NoError(t, tx.MarkCapturePending(5000, "cap_lost"))
+	require.NoError(t, repo.UpdateStatus(ctx, tx))
+	capture := transaction.NewCapturePending(tx.ID, 5000, true, "cap_lost")
+	require.NoError(t, repo.CreateCapture(ctx, capture))
+	intent := transaction.NewIntent(transaction.IntentSettle, tx.ID, &capture.ID, capture.Amount)
+	require.NoError(t, repo.CreateIntent(ctx, intent))
+
+	require.NoError(t, svc.ReconcileIntent(ctx, intent.ID))
+
+	tx, err = repo.GetByID(ctx, auth.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.StatusCaptured, tx.Status)
+	assert.Equal(t, int64(5000), tx.CapturedAmount)
+	got, err := repo.GetIntent(ctx, intent.ID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.IntentSucceeded, got.Status)
+	op, err := acq.Lookup(ctx, intent.Reference())
+	require.NoError(t, err)
+	assert.True(t, op.Success)
+}
+
+// TestReconcileIntents_ResendsLostRefund covers a refund whose call never reached the
+// acquirer: the reconciler resends it and settles the refund, or releases the reserved
+// amount when the acquirer rejects it.
+func TestReconcileIntents_ResendsLostRefund(t *testing.T) {
+	tests := []struct {
+		name           string
+		refundRate     float64
+		wantRefund     transaction.RefundStatus
+		wantIntent     transaction.IntentStatus
+		wantTxStatus   transaction.Status
+		wantRefundedTo int64
+	}{
+		{"accepted", 1.0, transaction.RefundStatusDone, transaction.IntentSucceeded, transaction.StatusPartiallyRefunded, 2000},
+		{"rejected", 0.0, transaction.RefundStatusFailed, transaction.IntentFailed, transaction.StatusCaptured, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc, repo := newReconcileService(acquirer.NewMockAcquirer(1.0, 1.0, 0))
+			wh := newOutboxWaiter()
+
+			auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+				MerchantID: "merchant_reconcile_refund",
+				OrderID:    fmt.Sprintf("reconcile_refund_%d", time.Now().UnixNano()),
+				Amount:     5000,
+				Currency:   "USD",
+				CardToken:  "tok_reconcile_refund",
+			})
+			require.NoError(t, err)
+			_, err = svc.Capture(ctx, transaction.CaptureRequest{
+				TransactionID:  auth.TransactionID,
+				Amount:         5000,
+				IdempotencyKey: fmt.Sprintf("cap_reconcile_refund_%d", time.Now().UnixNano()),
+			})
+			require.NoError(t, err)
+			wh.waitCaptures(1, t)
+
+			// What Refund commits before handing the refund call to a goroutine that never ran.
+			tx, err := repo.GetByID(ctx, auth.TransactionID)
+			require.NoError(t, err)
+			tx.RefundedAmount = 2000
+			tx.Status = transaction.StatusPartiallyRefunded
+			require.NoError(t, repo.UpdateRefund(ctx, tx))
+			refund := transaction.NewRefundPending(tx.ID, 2000, fmt.Sprintf("ref_lost_%d", time.Now().UnixNano()))
+			require.NoError(t, repo.CreateRefund(ctx, refund))
+			intent := transaction.NewIntent(transaction.IntentRefund, tx.ID, &refund.ID, refund.Amount)
+			require.NoError(t, repo.CreateIntent(ctx, intent))
+
+			reconciler, _ := newReconcileService(acquirer.NewMockAcquirer(1.0, tc.refundRate, 0))
+			require.NoError(t, reconciler.ReconcileIntent(ctx, intent.ID))
+
+			gotRefund, err := repo.GetRefundByID(ctx, refund.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantRefund, gotRefund.Status)
+			gotIntent, err := repo.GetIntent(ctx, intent.ID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantIntent, gotIntent.Status)
+			tx, err = repo.GetByID(ctx, auth.TransactionID)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantTxStatus, tx.Status)
+			assert.Equal(t, tc.wantRefundedTo, tx.RefundedAmount)
+		})
+	}
+}
+
+// heldSettle holds the first Settle call until release is closed, so the reconciler can
+// resolve the intent while the original call is still waiting on the acquirer.
+type heldSettle struct {
+	*acquirer.MockAcquirer
+	once    sync.Once
+	held    chan struct{}
+	release chan struct{}
+}
+
+func (a *heldSettle) Settle(ctx context.Context, reference, txID string, amount int64) (acquirer.SettleResult, error) {
+	first := false
+	a.once.Do(func() { first = true })
+	if first {
+		close(a.held)
+		<-a.release
+	}
+	return a.MockAcquirer.Settle(ctx, reference, txID, amount)
+}
+
+// logWaiter closes seen once a record with message is logged.
+type logWaiter struct {
+	slog.Handler
+	message string
+	once    sync.Once
+	seen    chan struct{}
+}
+
+func (h *logWaiter) Handle(ctx context.Context, r slog.Record) error {
+	if r.Message == h.message {
+		h.once.Do(func() { close(h.seen) })
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// TestReconcileIntents_RacesOriginalSettle lets the reconciler store a settlement while the
+// original settle call is still in flight: the original call must then leave the stored
+// outcome alone instead of recording the capture a second time.
+func TestReconcileIntents_RacesOriginalSettle(t *testing.T) {
+	ctx := context.Background()
+	acq := &heldSettle{
+		MockAcquirer: acquirer.NewMockAcquirer(1.0, 1.0, 0),
+		held:         make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	logs := &logWaiter{
+		Handler: slog.Default().Handler(),
+		message: "capture result already recorded by reconciler",
+		seen:    make(chan struct{}),
+	}
+	repo := txrepo.NewPgTransactionRepo(pg.Pool)
+	txRepoFactory := func(tx postgres.Executor) transaction.Repo {
+		return txrepo.NewPgTransactionRepo(tx)
+	}
+	svc := transaction.NewService(repo, acq, stubCards{}, webhookFactory, slog.New(logs), pg, txRepoFactory, ledgerFactory, noFees{}, 7*24*time.Hour, transaction.ChallengePolicy{})
+
+	auth, err := svc.Authorize(ctx, transaction.AuthRequest{
+		MerchantID: "merchant_reconcile_race",
+		OrderID:    fmt.Sprintf("reconcile_race_%d", time.Now().UnixNano()),
+		Amount:     5000,
+		Currency:   "USD",
+		CardToken:  "tok_reconcile_race",
+	})
+	require.NoError(t, err)
+	capture, err := svc.Capture(ctx, transaction.CaptureRequest{
+		TransactionID:  auth.TransactionID,
+		Amount:         5000,
+		IdempotencyKey: fmt.Sprintf("cap_reconcile_race_%d", time.Now().UnixNano()),
+	})
+	require.NoError(t, err)
+	<-acq.held
+
+	var intentID uuid.UUID
+	require.NoError(t, pg.Pool.QueryRow(ctx,
+		"SELECT id FROM acquirer_intents WHERE operation_id = $1", capture.CaptureID,
+	).Scan(&intentID))
+	require.NoError(t, svc.ReconcileIntent(ctx, intentID))
+
+	close(acq.release)
+	select {
+	case <-logs.seen:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for the original settle call to finish")
+	}
+
+	tx, err := repo.GetByID(ctx, auth.TransactionID)
+	require.NoError(t, err)
+	assert.Equal(t, transaction.StatusCaptured, tx.Status)
+	assert.Equal(t, int64(5000), tx.CapturedAmount)
+
+	var webhooks int
+	require.NoError(t, pg.Pool.QueryRow(ctx,
+		"SELECT COUNT(*) FROM webhook_outbox WHERE payload->>'capture_id' = $1", capture.CaptureID.String(),
+	).Scan(&webhooks))
+	assert.Equal(t, 1, webhooks, "capture result must be recorded once")
+}
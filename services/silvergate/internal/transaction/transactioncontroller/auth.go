@@ -1,9 +1,11 @@
 package transactioncontroller
 
 import (
+	"errors"
 	"net/http"
 
 	"TestTaskJustPay/services/silvergate/internal/transaction"
+	"TestTaskJustPay/services/silvergate/internal/vault"
 
 	"github.com/gin-gonic/gin"
 )
@@ -17,10 +19,14 @@ type authRequest struct {
 }
 
 type authResponse struct {
-	TransactionID string `json:"transaction_id"`
-	Status        string `json:"status"`
-	OrderID       string `json:"order_id"`
-	DeclineReason string `json:"decline_reason,omitempty"`
+	TransactionID   string `json:"transaction_id"`
+	Status          string `json:"status"`
+	OrderID         string `json:"order_id"`
+	DeclineReason   string `json:"decline_reason,omitempty"`
+	CardFingerprint string `json:"card_fingerprint,omitempty"`
+	// ChallengeID and ChallengeURL are set when status is requires_action.
+	ChallengeID  string `json:"challenge_id,omitempty"`
+	ChallengeURL string `json:"challenge_url,omitempty"`
 }
 
 type AuthHandler struct {
@@ -46,14 +52,28 @@ func (h *AuthHandler) Handle(c *gin.Context) {
 		CardToken:  req.CardToken,
 	})
 	if err != nil {
+		if errors.Is(err, vault.ErrNotFound) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "unknown card token", "code": "card_not_found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "authorization failed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, authResponse{
-		TransactionID: result.TransactionID.String(),
-		Status:        string(result.Status),
-		OrderID:       req.OrderID,
-		DeclineReason: result.DeclineReason,
-	})
+	c.JSON(http.StatusOK, toAuthResponse(result))
+}
+
+func toAuthResponse(result transaction.AuthResponse) authResponse {
+	resp := authResponse{
+		TransactionID:   result.TransactionID.String(),
+		Status:          string(result.Status),
+		OrderID:         result.OrderID,
+		DeclineReason:   result.DeclineReason,
+		CardFingerprint: result.CardFingerprint,
+		ChallengeURL:    result.ChallengeURL,
+	}
+	if result.ChallengeID != nil {
+		resp.ChallengeID = result.ChallengeID.String()
+	}
+	return resp
 }
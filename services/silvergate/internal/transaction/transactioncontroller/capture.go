@@ -13,12 +13,15 @@ import (
 type captureRequest struct {
 	TransactionID  string `json:"transaction_id" binding:"required"`
 	Amount         int64  `json:"amount" binding:"required,min=1"`
+	FinalCapture   bool   `json:"final_capture"`
 	IdempotencyKey string `json:"idempotency_key" binding:"required"`
 }
 
 type captureResponse struct {
-	TransactionID string `json:"transaction_id"`
-	Status        string `json:"status"`
+	TransactionID  string `json:"transaction_id"`
+	CaptureID      string `json:"capture_id"`
+	Status         string `json:"status"`
+	CapturedAmount int64  `json:"captured_amount"`
 }
 
 type CaptureHandler struct {
@@ -45,6 +48,7 @@ func (h *CaptureHandler) Handle(c *gin.Context) {
 	result, err := h.svc.Capture(c.Request.Context(), transaction.CaptureRequest{
 		TransactionID:  txID,
 		Amount:         req.Amount,
+		Final:          req.FinalCapture,
 		IdempotencyKey: req.IdempotencyKey,
 	})
 	if err != nil {
@@ -53,6 +57,10 @@ func (h *CaptureHandler) Handle(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
 		case errors.Is(err, transaction.ErrInvalidTransition):
 			c.JSON(http.StatusConflict, gin.H{"error": "transaction cannot be captured in current state"})
+		case errors.Is(err, transaction.ErrAuthorizationExpired):
+			c.JSON(http.StatusConflict, gin.H{"error": "authorization has expired"})
+		case errors.Is(err, transaction.ErrCaptureExceedsAmount):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "capture amount exceeds remaining authorized amount"})
 		case errors.Is(err, transaction.ErrDuplicateIdempotency):
 			c.JSON(http.StatusConflict, gin.H{"error": "duplicate idempotency key"})
 		default:
@@ -62,7 +70,9 @@ func (h *CaptureHandler) Handle(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusAccepted, captureResponse{
-		TransactionID: result.TransactionID.String(),
-		Status:        string(result.Status),
+		TransactionID:  result.TransactionID.String(),
+		CaptureID:      result.CaptureID.String(),
+		Status:         string(result.Status),
+		CapturedAmount: result.CapturedAmount,
 	})
 }
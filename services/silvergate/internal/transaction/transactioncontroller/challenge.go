@@ -0,0 +1,61 @@
+package transactioncontroller
+
+import (
+	"errors"
+	"net/http"
+
+	"TestTaskJustPay/services/silvergate/internal/transaction"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const challengeOutcomeSuccess = "success"
+
+type completeChallengeRequest struct {
+	Outcome string `json:"outcome" binding:"required,oneof=success failure"`
+}
+
+// ChallengeHandler completes a cardholder authentication challenge. It stands in for the
+// issuer's challenge page: the outcome is what the cardholder's bank would have decided.
+type ChallengeHandler struct {
+	svc *transaction.Service
+}
+
+func NewChallengeHandler(svc *transaction.Service) *ChallengeHandler {
+	return &ChallengeHandler{svc: svc}
+}
+
+func (h *ChallengeHandler) Handle(c *gin.Context) {
+	challengeID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid challenge id"})
+		return
+	}
+
+	var req completeChallengeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.svc.CompleteChallenge(c.Request.Context(), transaction.CompleteChallengeRequest{
+		ChallengeID: challengeID,
+		Passed:      req.Outcome == challengeOutcomeSuccess,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, transaction.ErrChallengeNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "challenge not found"})
+		case errors.Is(err, transaction.ErrChallengeCompleted):
+			c.JSON(http.StatusConflict, gin.H{"error": "challenge already completed"})
+		case errors.Is(err, transaction.ErrChallengeExpired):
+			c.JSON(http.StatusGone, gin.H{"error": "challenge expired"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "challenge completion failed"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, toAuthResponse(result))
+}
@@ -0,0 +1,85 @@
+package transactioncontroller
+
+import (
+	"errors"
+	"net/http"
+
+	"TestTaskJustPay/services/silvergate/internal/merchantauth"
+	"TestTaskJustPay/services/silvergate/internal/transaction"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type disputeRequest struct {
+	TransactionID string `json:"transaction_id" binding:"required"`
+	Amount        int64  `json:"amount" binding:"required,min=1"`
+	Reason        string `json:"reason" binding:"required"`
+}
+
+type disputeResponse struct {
+	DisputeID     string `json:"dispute_id"`
+	TransactionID string `json:"transaction_id"`
+	Amount        int64  `json:"amount"`
+	Reason        string `json:"reason"`
+	Fee           int64  `json:"fee"`
+}
+
+// DisputeHandler opens a chargeback against a transaction of the authenticated merchant.
+// It stands in for the acquirer's dispute notification: in production the card network
+// reports chargebacks.
+type DisputeHandler struct {
+	svc *transaction.Service
+}
+
+func NewDisputeHandler(svc *transaction.Service) *DisputeHandler {
+	return &DisputeHandler{svc: svc}
+}
+
+func (h *DisputeHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	var req disputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	txID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transaction_id"})
+		return
+	}
+
+	dispute, err := h.svc.OpenDispute(c.Request.Context(), transaction.DisputeRequest{
+		MerchantID:    merchantID,
+		TransactionID: txID,
+		Amount:        req.Amount,
+		Reason:        req.Reason,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, transaction.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		case errors.Is(err, transaction.ErrNotDisputable):
+			c.JSON(http.StatusConflict, gin.H{"error": "transaction has no captured funds to dispute"})
+		case errors.Is(err, transaction.ErrDisputeExceedsAmount):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "dispute amount exceeds undisputed captured amount"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "dispute failed"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, disputeResponse{
+		DisputeID:     dispute.ID.String(),
+		TransactionID: dispute.TransactionID.String(),
+		Amount:        dispute.Amount,
+		Reason:        dispute.Reason,
+		Fee:           dispute.Fee,
+	})
+}
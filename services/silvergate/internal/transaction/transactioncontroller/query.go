@@ -0,0 +1,114 @@
+package transactioncontroller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/merchantauth"
+	"TestTaskJustPay/services/silvergate/internal/transaction"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type queryRefund struct {
+	RefundID  string    `json:"refund_id"`
+	Amount    int64     `json:"amount"`
+	Status    string    `json:"status"`
+	Fee       int64     `json:"fee"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type queryTransaction struct {
+	TransactionID  string        `json:"transaction_id"`
+	OrderID        string        `json:"order_id"`
+	MerchantID     string        `json:"merchant_id"`
+	Status         string        `json:"status"`
+	Amount         int64         `json:"amount"`
+	CapturedAmount int64         `json:"captured_amount"`
+	RefundedAmount int64         `json:"refunded_amount"`
+	FeeAmount      int64         `json:"fee_amount"`
+	Currency       string        `json:"currency"`
+	UpdatedAt      time.Time     `json:"updated_at"`
+	Refunds        []queryRefund `json:"refunds"`
+}
+
+type queryResponse struct {
+	Items []queryTransaction `json:"items"`
+}
+
+type QueryHandler struct {
+	svc *transaction.Service
+}
+
+func NewQueryHandler(svc *transaction.Service) *QueryHandler {
+	return &QueryHandler{svc: svc}
+}
+
+// Handle serves GET /transactions?id=...&id=... with the current state of each
+// transaction of the calling merchant. Unknown ids are left out of the response.
+func (h *QueryHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "merchant context missing"})
+		return
+	}
+
+	rawIDs := c.QueryArray("id")
+	if len(rawIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one id is required"})
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(rawIDs))
+	for _, raw := range rawIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id: " + raw})
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	states, err := h.svc.QueryTransactions(c.Request.Context(), merchantID, ids)
+	if err != nil {
+		switch {
+		case errors.Is(err, transaction.ErrTooManyTransactionIDs):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		}
+		return
+	}
+
+	resp := queryResponse{Items: make([]queryTransaction, 0, len(states))}
+	for _, st := range states {
+		tx := st.Transaction
+		item := queryTransaction{
+			TransactionID:  tx.ID.String(),
+			OrderID:        tx.OrderRef,
+			MerchantID:     tx.MerchantID,
+			Status:         string(tx.Status),
+			Amount:         tx.Amount,
+			CapturedAmount: tx.CapturedAmount,
+			RefundedAmount: tx.RefundedAmount,
+			FeeAmount:      tx.FeeAmount,
+			Currency:       tx.Currency,
+			UpdatedAt:      tx.UpdatedAt,
+			Refunds:        make([]queryRefund, 0, len(st.Refunds)),
+		}
+		for _, rf := range st.Refunds {
+			item.Refunds = append(item.Refunds, queryRefund{
+				RefundID:  rf.ID.String(),
+				Amount:    rf.Amount,
+				Status:    string(rf.Status),
+				Fee:       rf.Fee,
+				UpdatedAt: rf.UpdatedAt,
+			})
+		}
+		resp.Items = append(resp.Items, item)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -53,6 +53,8 @@ func (h *RefundHandler) Handle(c *gin.Context) {
 		switch {
 		case errors.Is(err, transaction.ErrNotFound):
 			c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		case errors.Is(err, transaction.ErrAuthorizationOpen):
+			c.JSON(http.StatusConflict, gin.H{"error": "authorization is partially captured: void it or capture the rest before refunding"})
 		case errors.Is(err, transaction.ErrNotRefundable):
 			c.JSON(http.StatusConflict, gin.H{"error": "transaction is not in a refundable state"})
 		case errors.Is(err, transaction.ErrRefundExceedsAmount):
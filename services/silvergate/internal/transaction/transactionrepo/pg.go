@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"TestTaskJustPay/pkg/postgres"
 	"TestTaskJustPay/services/silvergate/internal/transaction"
@@ -29,14 +30,16 @@ func (r *PgTransactionRepo) Create(ctx context.Context, tx *transaction.Transact
 		Insert("transactions").
 		Columns(
 			"id", "merchant_id", "order_ref", "amount", "currency",
-			"card_token", "status", "decline_reason", "idempotency_key",
-			"purchase_idempotency_key", "product_id",
+			"card_token", "card_fingerprint", "status", "decline_reason", "idempotency_key",
+			"purchase_idempotency_key", "product_id", "expires_at",
+			"challenge_id", "challenge_expires_at",
 			"created_at", "updated_at",
 		).
 		Values(
 			tx.ID, tx.MerchantID, tx.OrderRef, tx.Amount, tx.Currency,
-			tx.CardToken, tx.Status, nilIfEmpty(tx.DeclineReason), nilIfEmpty(tx.IdempotencyKey),
-			nilIfEmpty(tx.PurchaseIdempotencyKey), tx.ProductID,
+			tx.CardToken, nilIfEmpty(tx.CardFingerprint), tx.Status, nilIfEmpty(tx.DeclineReason), nilIfEmpty(tx.IdempotencyKey),
+			nilIfEmpty(tx.PurchaseIdempotencyKey), tx.ProductID, tx.ExpiresAt,
+			tx.ChallengeID, tx.ChallengeExpiresAt,
 			tx.CreatedAt, tx.UpdatedAt,
 		).
 		ToSql()
@@ -56,19 +59,21 @@ func (r *PgTransactionRepo) Create(ctx context.Context, tx *transaction.Transact
 
 var transactionSelectColumns = []string{
 	"id", "merchant_id", "order_ref", "amount", "currency",
-	"card_token", "status", "decline_reason", "idempotency_key",
+	"card_token", "card_fingerprint", "status", "decline_reason", "idempotency_key",
 	"purchase_idempotency_key", "product_id",
-	"refunded_amount", "created_at", "updated_at",
+	"captured_amount", "refunded_amount", "fee_amount", "expires_at",
+	"challenge_id", "challenge_expires_at", "created_at", "updated_at",
 }
 
 func scanTransaction(row pgx.Row) (*transaction.Transaction, error) {
 	var tx transaction.Transaction
-	var declineReason, idempotencyKey, purchaseKey *string
+	var cardFingerprint, declineReason, idempotencyKey, purchaseKey *string
 	err := row.Scan(
 		&tx.ID, &tx.MerchantID, &tx.OrderRef, &tx.Amount, &tx.Currency,
-		&tx.CardToken, &tx.Status, &declineReason, &idempotencyKey,
+		&tx.CardToken, &cardFingerprint, &tx.Status, &declineReason, &idempotencyKey,
 		&purchaseKey, &tx.ProductID,
-		&tx.RefundedAmount, &tx.CreatedAt, &tx.UpdatedAt,
+		&tx.CapturedAmount, &tx.RefundedAmount, &tx.FeeAmount, &tx.ExpiresAt,
+		&tx.ChallengeID, &tx.ChallengeExpiresAt, &tx.CreatedAt, &tx.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -76,6 +81,9 @@ func scanTransaction(row pgx.Row) (*transaction.Transaction, error) {
 		}
 		return nil, fmt.Errorf("scan transaction: %w", err)
 	}
+	if cardFingerprint != nil {
+		tx.CardFingerprint = *cardFingerprint
+	}
 	if declineReason != nil {
 		tx.DeclineReason = *declineReason
 	}
@@ -115,17 +123,34 @@ func (r *PgTransactionRepo) GetByIDForUpdate(ctx context.Context, id uuid.UUID)
 	return scanTransaction(r.db.QueryRow(ctx, query, args...))
 }
 
-func (r *PgTransactionRepo) GetByPurchaseIdempotencyKey(ctx context.Context, merchantID, key string) (*transaction.Transaction, error) {
+func (r *PgTransactionRepo) GetByIDs(ctx context.Context, merchantID string, ids []uuid.UUID) ([]*transaction.Transaction, error) {
 	query, args, err := psql.
 		Select(transactionSelectColumns...).
 		From("transactions").
-		Where(sq.Eq{"merchant_id": merchantID, "purchase_idempotency_key": key}).
+		Where(sq.Eq{"merchant_id": merchantID, "id": ids}).
 		ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("build select by purchase key: %w", err)
+		return nil, fmt.Errorf("build select by ids: %w", err)
 	}
 
-	return scanTransaction(r.db.QueryRow(ctx, query, args...))
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query by ids: %w", err)
+	}
+	defer rows.Close()
+
+	var txs []*transaction.Transaction
+	for rows.Next() {
+		tx, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, tx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate by ids: %w", err)
+	}
+	return txs, nil
 }
 
 func (r *PgTransactionRepo) UpdateStatus(ctx context.Context, tx *transaction.Transaction) error {
@@ -154,6 +179,47 @@ func (r *PgTransactionRepo) UpdateStatus(ctx context.Context, tx *transaction.Tr
 	return nil
 }
 
+func (r *PgTransactionRepo) GetByChallengeIDForUpdate(ctx context.Context, challengeID uuid.UUID) (*transaction.Transaction, error) {
+	query, args, err := psql.
+		Select(transactionSelectColumns...).
+		From("transactions").
+		Where(sq.Eq{"challenge_id": challengeID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	tx, err := scanTransaction(r.db.QueryRow(ctx, query, args...))
+	if errors.Is(err, transaction.ErrNotFound) {
+		return nil, transaction.ErrChallengeNotFound
+	}
+	return tx, err
+}
+
+func (r *PgTransactionRepo) CompleteChallenge(ctx context.Context, tx *transaction.Transaction) error {
+	query, args, err := psql.
+		Update("transactions").
+		Set("status", tx.Status).
+		Set("decline_reason", nilIfEmpty(tx.DeclineReason)).
+		Set("expires_at", tx.ExpiresAt).
+		Set("updated_at", tx.UpdatedAt).
+		Where(sq.Eq{"id": tx.ID, "status": transaction.StatusRequiresAction}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update: %w", err)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exec update: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return transaction.ErrStatusChanged
+	}
+	return nil
+}
+
 func (r *PgTransactionRepo) CompareAndUpdateStatus(ctx context.Context, id uuid.UUID, expected, next transaction.Status) error {
 	query, args, err := psql.
 		Update("transactions").
@@ -176,6 +242,100 @@ func (r *PgTransactionRepo) CompareAndUpdateStatus(ctx context.Context, id uuid.
 	return nil
 }
 
+func (r *PgTransactionRepo) CompareAndUpdateCapture(ctx context.Context, tx *transaction.Transaction, expected transaction.Status) error {
+	query, args, err := psql.
+		Update("transactions").
+		Set("status", tx.Status).
+		Set("captured_amount", tx.CapturedAmount).
+		Set("updated_at", tx.UpdatedAt).
+		Where(sq.Eq{"id": tx.ID, "status": expected}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build compare-and-update capture: %w", err)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exec compare-and-update capture: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return transaction.ErrStatusChanged
+	}
+	return nil
+}
+
+func (r *PgTransactionRepo) CreateCapture(ctx context.Context, capture *transaction.Capture) error {
+	query, args, err := psql.
+		Insert("captures").
+		Columns("id", "transaction_id", "amount", "final", "status", "idempotency_key", "created_at", "updated_at").
+		Values(capture.ID, capture.TransactionID, capture.Amount, capture.Final, capture.Status,
+			capture.IdempotencyKey, capture.CreatedAt, capture.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert capture: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx, query, args...)
+	if err != nil {
+		if mapped := mapUniqueViolation(err); mapped != nil {
+			return mapped
+		}
+		return fmt.Errorf("exec insert capture: %w", err)
+	}
+	return nil
+}
+
+func (r *PgTransactionRepo) GetCaptureByIdempotencyKey(ctx context.Context, txID uuid.UUID, key string) (*transaction.Capture, error) {
+	return r.getCapture(ctx, sq.Eq{"transaction_id": txID, "idempotency_key": key})
+}
+
+func (r *PgTransactionRepo) GetCaptureByID(ctx context.Context, id uuid.UUID) (*transaction.Capture, error) {
+	return r.getCapture(ctx, sq.Eq{"id": id})
+}
+
+func (r *PgTransactionRepo) getCapture(ctx context.Context, where sq.Eq) (*transaction.Capture, error) {
+	query, args, err := psql.
+		Select("id", "transaction_id", "amount", "final", "status", "fee", "idempotency_key", "created_at", "updated_at").
+		From("captures").
+		Where(where).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select capture: %w", err)
+	}
+
+	var c transaction.Capture
+	err = r.db.QueryRow(ctx, query, args...).Scan(
+		&c.ID, &c.TransactionID, &c.Amount, &c.Final, &c.Status, &c.Fee, &c.IdempotencyKey, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, transaction.ErrCaptureNotFound
+		}
+		return nil, fmt.Errorf("scan capture: %w", err)
+	}
+	return &c, nil
+}
+
+func (r *PgTransactionRepo) UpdateCaptureStatus(ctx context.Context, capture *transaction.Capture) error {
+	query, args, err := psql.
+		Update("captures").
+		Set("status", capture.Status).
+		Set("fee", capture.Fee).
+		Set("updated_at", capture.UpdatedAt).
+		Where(sq.Eq{"id": capture.ID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update capture status: %w", err)
+	}
+
+	_, err = r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exec update capture status: %w", err)
+	}
+	return nil
+}
+
 func (r *PgTransactionRepo) UpdateRefund(ctx context.Context, tx *transaction.Transaction) error {
 	query, args, err := psql.
 		Update("transactions").
@@ -216,10 +376,37 @@ func (r *PgTransactionRepo) CreateRefund(ctx context.Context, refund *transactio
 	return nil
 }
 
+func (r *PgTransactionRepo) GetRefundByID(ctx context.Context, id uuid.UUID) (*transaction.Refund, error) {
+	query, args, err := psql.
+		Select("id", "transaction_id", "amount", "status", "fee", "idempotency_key", "created_at", "updated_at").
+		From("refunds").
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select refund: %w", err)
+	}
+
+	var rf transaction.Refund
+	var idempotencyKey *string
+	err = r.db.QueryRow(ctx, query, args...).Scan(&rf.ID, &rf.TransactionID, &rf.Amount, &rf.Status, &rf.Fee,
+		&idempotencyKey, &rf.CreatedAt, &rf.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, transaction.ErrRefundNotFound
+		}
+		return nil, fmt.Errorf("scan refund: %w", err)
+	}
+	if idempotencyKey != nil {
+		rf.IdempotencyKey = *idempotencyKey
+	}
+	return &rf, nil
+}
+
 func (r *PgTransactionRepo) UpdateRefundStatus(ctx context.Context, refund *transaction.Refund) error {
 	query, args, err := psql.
 		Update("refunds").
 		Set("status", refund.Status).
+		Set("fee", refund.Fee).
 		Set("updated_at", refund.UpdatedAt).
 		Where(sq.Eq{"id": refund.ID}).
 		ToSql()
@@ -247,6 +434,293 @@ func (r *PgTransactionRepo) ReleaseRefundAmount(ctx context.Context, txID uuid.U
 	return nil
 }
 
+func (r *PgTransactionRepo) AddFee(ctx context.Context, txID uuid.UUID, amount int64) error {
+	query, args, err := psql.
+		Update("transactions").
+		Set("fee_amount", sq.Expr("fee_amount + ?", amount)).
+		Where(sq.Eq{"id": txID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build add fee: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec add fee: %w", err)
+	}
+	return nil
+}
+
+func (r *PgTransactionRepo) CreateDispute(ctx context.Context, d *transaction.Dispute) error {
+	query, args, err := psql.
+		Insert("disputes").
+		Columns("id", "transaction_id", "amount", "reason", "fee", "created_at").
+		Values(d.ID, d.TransactionID, d.Amount, d.Reason, d.Fee, d.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert dispute: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec insert dispute: %w", err)
+	}
+	return nil
+}
+
+func (r *PgTransactionRepo) SumDisputes(ctx context.Context, txID uuid.UUID) (int64, error) {
+	query, args, err := psql.
+		Select("COALESCE(SUM(amount), 0)").
+		From("disputes").
+		Where(sq.Eq{"transaction_id": txID}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build sum disputes: %w", err)
+	}
+
+	var sum int64
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&sum); err != nil {
+		return 0, fmt.Errorf("query sum disputes: %w", err)
+	}
+	return sum, nil
+}
+
+func (r *PgTransactionRepo) ListRefundsByTransactionIDs(ctx context.Context, txIDs []uuid.UUID) ([]*transaction.Refund, error) {
+	query, args, err := psql.
+		Select("id", "transaction_id", "amount", "status", "fee", "idempotency_key", "created_at", "updated_at").
+		From("refunds").
+		Where(sq.Eq{"transaction_id": txIDs}).
+		OrderBy("created_at ASC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select refunds: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query refunds: %w", err)
+	}
+	defer rows.Close()
+
+	var refunds []*transaction.Refund
+	for rows.Next() {
+		var rf transaction.Refund
+		var idempotencyKey *string
+		if err := rows.Scan(&rf.ID, &rf.TransactionID, &rf.Amount, &rf.Status, &rf.Fee, &idempotencyKey,
+			&rf.CreatedAt, &rf.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan refund: %w", err)
+		}
+		if idempotencyKey != nil {
+			rf.IdempotencyKey = *idempotencyKey
+		}
+		refunds = append(refunds, &rf)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate refunds: %w", err)
+	}
+	return refunds, nil
+}
+
+func (r *PgTransactionRepo) GetAuthValidity(ctx context.Context, merchantID, currency string) (time.Duration, error) {
+	query, args, err := psql.
+		Select("validity_seconds").
+		From("auth_validity_windows").
+		Where(sq.Eq{"merchant_id": merchantID}).
+		Where(sq.Or{sq.Eq{"currency": currency}, sq.Eq{"currency": nil}}).
+		OrderBy("currency NULLS LAST").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("build select auth validity: %w", err)
+	}
+
+	var seconds int64
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&seconds); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, transaction.ErrValidityWindowNotFound
+		}
+		return 0, fmt.Errorf("scan auth validity: %w", err)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func (r *PgTransactionRepo) ListExpiredAuthorizations(ctx context.Context, now time.Time, skip []uuid.UUID, limit int) ([]uuid.UUID, error) {
+	q := psql.
+		Select("id").
+		From("transactions").
+		Where(sq.Eq{"status": []transaction.Status{transaction.StatusAuthorized, transaction.StatusPartiallyCaptured, transaction.StatusCaptureFailed}}).
+		Where(sq.LtOrEq{"expires_at": now})
+	if len(skip) > 0 {
+		q = q.Where(sq.NotEq{"id": skip})
+	}
+	query, args, err := q.
+		OrderBy("expires_at ASC").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select expired: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query expired: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan expired: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate expired: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *PgTransactionRepo) CreateIntent(ctx context.Context, intent *transaction.Intent) error {
+	query, args, err := psql.
+		Insert("acquirer_intents").
+		Columns("id", "kind", "status", "transaction_id", "operation_id", "amount", "created_at", "updated_at").
+		Values(intent.ID, intent.Kind, intent.Status, intent.TransactionID, intent.OperationID, intent.Amount,
+			intent.CreatedAt, intent.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert intent: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec insert intent: %w", err)
+	}
+	return nil
+}
+
+func (r *PgTransactionRepo) GetIntent(ctx context.Context, id uuid.UUID) (*transaction.Intent, error) {
+	query, args, err := psql.
+		Select(intentSelectColumns...).
+		From("acquirer_intents").
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select intent: %w", err)
+	}
+
+	return scanIntent(r.db.QueryRow(ctx, query, args...))
+}
+
+func (r *PgTransactionRepo) GetIntentForUpdate(ctx context.Context, id uuid.UUID) (*transaction.Intent, error) {
+	query, args, err := psql.
+		Select(intentSelectColumns...).
+		From("acquirer_intents").
+		Where(sq.Eq{"id": id}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select intent for update: %w", err)
+	}
+
+	return scanIntent(r.db.QueryRow(ctx, query, args...))
+}
+
+func (r *PgTransactionRepo) FinishIntent(ctx context.Context, intent *transaction.Intent) error {
+	query, args, err := psql.
+		Update("acquirer_intents").
+		Set("status", intent.Status).
+		Set("reason", nilIfEmpty(intent.Reason)).
+		Set("updated_at", intent.UpdatedAt).
+		Where(sq.Eq{"id": intent.ID, "status": inFlightIntentStatuses}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build finish intent: %w", err)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exec finish intent: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return transaction.ErrIntentResolved
+	}
+	return nil
+}
+
+func (r *PgTransactionRepo) ListStaleIntents(ctx context.Context, before, now time.Time, limit int) ([]*transaction.Intent, error) {
+	query, args, err := psql.
+		Select(intentSelectColumns...).
+		From("acquirer_intents").
+		Where(sq.Eq{"status": inFlightIntentStatuses}).
+		Where(sq.Lt{"created_at": before}).
+		Where(sq.LtOrEq{"next_attempt_at": now}).
+		OrderBy("created_at ASC").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select stale intents: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query stale intents: %w", err)
+	}
+	defer rows.Close()
+
+	var intents []*transaction.Intent
+	for rows.Next() {
+		in, err := scanIntent(rows)
+		if err != nil {
+			return nil, err
+		}
+		intents = append(intents, in)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate stale intents: %w", err)
+	}
+	return intents, nil
+}
+
+func (r *PgTransactionRepo) DeferIntent(ctx context.Context, id uuid.UUID, next time.Time) error {
+	query, args, err := psql.
+		Update("acquirer_intents").
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("next_attempt_at", next).
+		Where(sq.Eq{"id": id, "status": inFlightIntentStatuses}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build defer intent: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec defer intent: %w", err)
+	}
+	return nil
+}
+
+var intentSelectColumns = []string{
+	"id", "kind", "status", "transaction_id", "operation_id", "amount", "reason", "attempts", "created_at", "updated_at",
+}
+
+func scanIntent(row pgx.Row) (*transaction.Intent, error) {
+	var in transaction.Intent
+	var reason *string
+	err := row.Scan(&in.ID, &in.Kind, &in.Status, &in.TransactionID, &in.OperationID,
+		&in.Amount, &reason, &in.Attempts, &in.CreatedAt, &in.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, transaction.ErrIntentNotFound
+		}
+		return nil, fmt.Errorf("scan intent: %w", err)
+	}
+	if reason != nil {
+		in.Reason = *reason
+	}
+	return &in, nil
+}
+
+var inFlightIntentStatuses = []transaction.IntentStatus{
+	transaction.IntentAuthorizing, transaction.IntentSettling, transaction.IntentRefunding,
+}
+
 func nilIfEmpty(s string) *string {
 	if s == "" {
 		return nil
@@ -4,9 +4,9 @@ package transactionrepo_test
 
 import (
 	"context"
-	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	"TestTaskJustPay/services/silvergate/internal/product"
 	"TestTaskJustPay/services/silvergate/internal/product/productrepo"
@@ -84,7 +84,7 @@ func TestCreate_PurchaseIdempotencyConstraint(t *testing.T) {
 
 		// Simulate /capture overwrite path: MarkCapturePending mutates IdempotencyKey,
 		// UpdateStatus writes idempotency_key column.
-		require.NoError(t, tx.MarkCapturePending("CAPTURE_K"))
+		require.NoError(t, tx.MarkCapturePending(tx.Amount, "CAPTURE_K"))
 		require.NoError(t, repo.UpdateStatus(ctx, tx))
 
 		got, err := repo.GetByID(ctx, tx.ID)
@@ -94,39 +94,128 @@ func TestCreate_PurchaseIdempotencyConstraint(t *testing.T) {
 	})
 }
 
-func TestGetByPurchaseIdempotencyKey(t *testing.T) {
+func TestGetAuthValidity(t *testing.T) {
 	t.Parallel()
 	ctx := context.Background()
 	repo := transactionrepo.NewPgTransactionRepo(pg.Pool)
 
-	t.Run("found returns transaction with purchase context", func(t *testing.T) {
-		merchant := merchantID(t)
-		p := seedProduct(t, ctx, merchant)
-		tx := newPurchaseTx(merchant, "ord", "tok", p.ID, "LOOKUP_K")
-		require.NoError(t, repo.Create(ctx, tx))
+	merchant := merchantID(t)
+	_, err := pg.Pool.Exec(ctx,
+		`INSERT INTO auth_validity_windows (merchant_id, currency, validity_seconds) VALUES ($1, NULL, 86400), ($1, 'EUR', 3600)`,
+		merchant)
+	require.NoError(t, err)
 
-		got, err := repo.GetByPurchaseIdempotencyKey(ctx, merchant, "LOOKUP_K")
+	t.Run("currency window wins over merchant-wide window", func(t *testing.T) {
+		got, err := repo.GetAuthValidity(ctx, merchant, "EUR")
 		require.NoError(t, err)
-		assert.Equal(t, tx.ID, got.ID)
-		assert.Equal(t, "LOOKUP_K", got.PurchaseIdempotencyKey)
-		require.NotNil(t, got.ProductID)
-		assert.Equal(t, p.ID, *got.ProductID)
+		assert.Equal(t, time.Hour, got)
 	})
 
-	t.Run("not found returns ErrNotFound", func(t *testing.T) {
-		merchant := merchantID(t)
-		_, err := repo.GetByPurchaseIdempotencyKey(ctx, merchant, "DOES_NOT_EXIST")
-		assert.True(t, errors.Is(err, transaction.ErrNotFound), "want ErrNotFound, got %v", err)
+	t.Run("merchant-wide window applies to other currencies", func(t *testing.T) {
+		got, err := repo.GetAuthValidity(ctx, merchant, "USD")
+		require.NoError(t, err)
+		assert.Equal(t, 24*time.Hour, got)
 	})
 
-	t.Run("cross-merchant lookup with same key returns ErrNotFound", func(t *testing.T) {
-		merchantA := merchantID(t)
-		merchantB := merchantID(t)
-		pA := seedProduct(t, ctx, merchantA)
-		txA := newPurchaseTx(merchantA, "ord", "tok", pA.ID, "ISO_KEY")
-		require.NoError(t, repo.Create(ctx, txA))
-
-		_, err := repo.GetByPurchaseIdempotencyKey(ctx, merchantB, "ISO_KEY")
-		assert.True(t, errors.Is(err, transaction.ErrNotFound), "cross-merchant lookup must not leak; got %v", err)
+	t.Run("unknown merchant returns ErrValidityWindowNotFound", func(t *testing.T) {
+		_, err := repo.GetAuthValidity(ctx, merchantID(t), "USD")
+		assert.ErrorIs(t, err, transaction.ErrValidityWindowNotFound)
 	})
 }
+
+func TestListExpiredAuthorizations(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repo := transactionrepo.NewPgTransactionRepo(pg.Pool)
+	merchant := merchantID(t)
+
+	lapsed := transaction.NewAuthorized(merchant, "o1", 100, "USD", "tok")
+	lapsed.SetExpiry(-time.Minute)
+	live := transaction.NewAuthorized(merchant, "o2", 100, "USD", "tok")
+	live.SetExpiry(time.Hour)
+	partial := transaction.NewAuthorized(merchant, "o3", 100, "USD", "tok")
+	partial.SetExpiry(-time.Minute)
+	skipped := transaction.NewAuthorized(merchant, "o4", 100, "USD", "tok")
+	skipped.SetExpiry(-time.Minute)
+	for _, tx := range []*transaction.Transaction{lapsed, live, partial, skipped} {
+		require.NoError(t, repo.Create(ctx, tx))
+	}
+	partial.Status = transaction.StatusPartiallyCaptured
+	require.NoError(t, repo.UpdateStatus(ctx, partial))
+
+	ids, err := repo.ListExpiredAuthorizations(ctx, time.Now().UTC(), []uuid.UUID{skipped.ID}, 1000)
+	require.NoError(t, err)
+	assert.Contains(t, ids, lapsed.ID)
+	assert.Contains(t, ids, partial.ID)
+	assert.NotContains(t, ids, live.ID)
+	assert.NotContains(t, ids, skipped.ID)
+
+	got, err := repo.GetByID(ctx, lapsed.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.ExpiresAt)
+	assert.WithinDuration(t, *lapsed.ExpiresAt, *got.ExpiresAt, time.Millisecond)
+}
+
+func TestDeferIntent_HoldsBackStaleIntent(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repo := transactionrepo.NewPgTransactionRepo(pg.Pool)
+
+	failing := transaction.NewIntent(transaction.IntentSettle, uuid.New(), nil, 100)
+	due := transaction.NewIntent(transaction.IntentSettle, uuid.New(), nil, 100)
+	require.NoError(t, repo.CreateIntent(ctx, failing))
+	require.NoError(t, repo.CreateIntent(ctx, due))
+
+	now := time.Now().UTC()
+	require.NoError(t, repo.DeferIntent(ctx, failing.ID, now.Add(time.Minute)))
+
+	ids := func(at time.Time) []uuid.UUID {
+		intents, err := repo.ListStaleIntents(ctx, at, at, 1000)
+		require.NoError(t, err)
+		var ids []uuid.UUID
+		for _, in := range intents {
+			ids = append(ids, in.ID)
+		}
+		return ids
+	}
+	listed := ids(now.Add(time.Second))
+	assert.NotContains(t, listed, failing.ID)
+	assert.Contains(t, listed, due.ID)
+	assert.Contains(t, ids(now.Add(2*time.Minute)), failing.ID)
+
+	got, err := repo.GetIntent(ctx, failing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Attempts)
+}
+
+func TestGetByIDs_WithRefunds(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	repo := transactionrepo.NewPgTransactionRepo(pg.Pool)
+	merchant := merchantID(t)
+
+	refunded := transaction.NewAuthorized(merchant, "o1", 100, "USD", "tok")
+	other := transaction.NewAuthorized(merchant, "o2", 200, "USD", "tok")
+	require.NoError(t, repo.Create(ctx, refunded))
+	require.NoError(t, repo.Create(ctx, other))
+
+	first := transaction.NewRefundPending(refunded.ID, 30, "rf-1")
+	second := transaction.NewRefundPending(refunded.ID, 20, "rf-2")
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	require.NoError(t, repo.CreateRefund(ctx, first))
+	require.NoError(t, repo.CreateRefund(ctx, second))
+
+	foreign := transaction.NewAuthorized(merchantID(t), "o3", 300, "USD", "tok")
+	require.NoError(t, repo.Create(ctx, foreign))
+
+	txs, err := repo.GetByIDs(ctx, merchant, []uuid.UUID{refunded.ID, other.ID, foreign.ID, uuid.New()})
+	require.NoError(t, err)
+	require.Len(t, txs, 2, "unknown ids and other merchants' transactions are skipped")
+
+	refunds, err := repo.ListRefundsByTransactionIDs(ctx, []uuid.UUID{refunded.ID, other.ID})
+	require.NoError(t, err)
+	require.Len(t, refunds, 2)
+	assert.Equal(t, first.ID, refunds[0].ID)
+	assert.Equal(t, second.ID, refunds[1].ID)
+	assert.Equal(t, "rf-1", refunds[0].IdempotencyKey)
+}
@@ -0,0 +1,140 @@
+package vault
+
+import (
+	"crypto/rand"
+	"strings"
+	"time"
+)
+
+type Brand string
+
+const (
+	BrandVisa       Brand = "visa"
+	BrandMastercard Brand = "mastercard"
+	BrandAmex       Brand = "amex"
+	BrandDiscover   Brand = "discover"
+	BrandUnknown    Brand = "unknown"
+)
+
+// Card is a vaulted card. The PAN is only held encrypted; CVC is never stored.
+type Card struct {
+	Token      string
+	MerchantID string
+	Brand      Brand
+	Last4      string
+	ExpMonth   int
+	ExpYear    int
+	// Fingerprint is a keyed hash of the PAN: equal for the same card, stable across key rotation.
+	Fingerprint string
+	// KeyID names the keyring key PANCiphertext is sealed with.
+	KeyID         string
+	PANCiphertext []byte
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func newToken() string {
+	return "tok_" + strings.ToLower(rand.Text())
+}
+
+// IsExpired reports whether the card expired before now. A card is valid through the
+// last day of its expiry month.
+func (c *Card) IsExpired(now time.Time) bool {
+	return isExpired(c.ExpMonth, c.ExpYear, now)
+}
+
+func isExpired(month, year int, now time.Time) bool {
+	firstInvalid := time.Date(year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC)
+	return !now.Before(firstInvalid)
+}
+
+// normalizePAN strips the spaces and dashes cardholders type between digit groups.
+func normalizePAN(pan string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(pan)
+}
+
+// ValidatePAN checks length, digits and the Luhn checksum of a normalized PAN.
+func ValidatePAN(pan string) error {
+	if len(pan) < 12 || len(pan) > 19 {
+		return ErrInvalidPAN
+	}
+	sum := 0
+	double := false
+	for i := len(pan) - 1; i >= 0; i-- {
+		d := pan[i]
+		if d < '0' || d > '9' {
+			return ErrInvalidPAN
+		}
+		n := int(d - '0')
+		if double {
+			n *= 2
+			if n > 9 {
+				n -= 9
+			}
+		}
+		sum += n
+		double = !double
+	}
+	if sum%10 != 0 {
+		return ErrInvalidPAN
+	}
+	return nil
+}
+
+// ValidateExpiry checks the month range and that the card has not expired at now.
+// Two-digit years are read as 20YY.
+func ValidateExpiry(month, year int, now time.Time) (int, error) {
+	if month < 1 || month > 12 || year < 0 {
+		return 0, ErrInvalidExpiry
+	}
+	if year < 100 {
+		year += 2000
+	}
+	if year > now.Year()+20 {
+		return 0, ErrInvalidExpiry
+	}
+	if isExpired(month, year, now) {
+		return 0, ErrCardExpired
+	}
+	return year, nil
+}
+
+// ValidateCVC checks the CVC length for the brand: four digits for Amex, three otherwise.
+func ValidateCVC(cvc string, brand Brand) error {
+	want := 3
+	if brand == BrandAmex {
+		want = 4
+	}
+	if len(cvc) != want {
+		return ErrInvalidCVC
+	}
+	for i := range len(cvc) {
+		if cvc[i] < '0' || cvc[i] > '9' {
+			return ErrInvalidCVC
+		}
+	}
+	return nil
+}
+
+// DetectBrand infers the card network from the PAN's issuer prefix.
+func DetectBrand(pan string) Brand {
+	prefix := func(n int) int {
+		v := 0
+		for i := 0; i < n && i < len(pan); i++ {
+			v = v*10 + int(pan[i]-'0')
+		}
+		return v
+	}
+	switch {
+	case strings.HasPrefix(pan, "4"):
+		return BrandVisa
+	case prefix(2) >= 51 && prefix(2) <= 55, prefix(4) >= 2221 && prefix(4) <= 2720:
+		return BrandMastercard
+	case prefix(2) == 34 || prefix(2) == 37:
+		return BrandAmex
+	case prefix(4) == 6011 || prefix(2) == 65, prefix(3) >= 644 && prefix(3) <= 649:
+		return BrandDiscover
+	default:
+		return BrandUnknown
+	}
+}
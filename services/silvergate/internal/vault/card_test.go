@@ -0,0 +1,95 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidatePAN(t *testing.T) {
+	cases := []struct {
+		pan  string
+		want error
+	}{
+		{"4242424242424242", nil},
+		{"378282246310005", nil},
+		{"4242424242424241", ErrInvalidPAN},
+		{"42424242424a4242", ErrInvalidPAN},
+		{"42424242", ErrInvalidPAN},
+		{"", ErrInvalidPAN},
+	}
+	for _, tc := range cases {
+		if err := ValidatePAN(tc.pan); !errors.Is(err, tc.want) {
+			t.Errorf("ValidatePAN(%q): want %v, got %v", tc.pan, tc.want, err)
+		}
+	}
+}
+
+func TestValidateExpiry(t *testing.T) {
+	now := time.Date(2026, time.October, 17, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name        string
+		month, year int
+		wantYear    int
+		wantErr     error
+	}{
+		{"current month is still valid", 10, 2026, 2026, nil},
+		{"two-digit year", 3, 28, 2028, nil},
+		{"previous month expired", 9, 2026, 0, ErrCardExpired},
+		{"month out of range", 13, 2027, 0, ErrInvalidExpiry},
+		{"implausibly far future", 1, 2090, 0, ErrInvalidExpiry},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			year, err := ValidateExpiry(tc.month, tc.year, now)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("want %v, got %v", tc.wantErr, err)
+			}
+			if year != tc.wantYear {
+				t.Errorf("year: want %d, got %d", tc.wantYear, year)
+			}
+		})
+	}
+}
+
+func TestCard_IsExpired(t *testing.T) {
+	c := &Card{ExpMonth: 12, ExpYear: 2026}
+	if c.IsExpired(time.Date(2026, time.December, 31, 23, 59, 0, 0, time.UTC)) {
+		t.Error("card should be valid through the last day of its expiry month")
+	}
+	if !c.IsExpired(time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("card should be expired after its expiry month")
+	}
+}
+
+func TestDetectBrand(t *testing.T) {
+	cases := map[string]Brand{
+		"4242424242424242": BrandVisa,
+		"5555555555554444": BrandMastercard,
+		"2223003122003222": BrandMastercard,
+		"378282246310005":  BrandAmex,
+		"6011111111111117": BrandDiscover,
+		"3056930009020004": BrandUnknown,
+	}
+	for pan, want := range cases {
+		if got := DetectBrand(pan); got != want {
+			t.Errorf("DetectBrand(%s): want %s, got %s", pan, want, got)
+		}
+	}
+}
+
+func TestValidateCVC(t *testing.T) {
+	if err := ValidateCVC("123", BrandVisa); err != nil {
+		t.Errorf("visa 3 digits: unexpected err %v", err)
+	}
+	if err := ValidateCVC("1234", BrandAmex); err != nil {
+		t.Errorf("amex 4 digits: unexpected err %v", err)
+	}
+	if err := ValidateCVC("123", BrandAmex); !errors.Is(err, ErrInvalidCVC) {
+		t.Errorf("amex 3 digits: want ErrInvalidCVC, got %v", err)
+	}
+	if err := ValidateCVC("12a", BrandVisa); !errors.Is(err, ErrInvalidCVC) {
+		t.Errorf("non-digit: want ErrInvalidCVC, got %v", err)
+	}
+}
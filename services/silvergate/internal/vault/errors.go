@@ -0,0 +1,12 @@
+package vault
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("card not found")
+	ErrInvalidPAN    = errors.New("invalid card number")
+	ErrInvalidExpiry = errors.New("invalid card expiry")
+	ErrCardExpired   = errors.New("card has expired")
+	ErrInvalidCVC    = errors.New("invalid card security code")
+	ErrUnknownKey    = errors.New("unknown vault key")
+)
@@ -0,0 +1,15 @@
+package vault
+
+import "context"
+
+// Repo is the persistence contract for vaulted cards.
+type Repo interface {
+	Create(ctx context.Context, card *Card) error
+	// GetByToken returns ErrNotFound for tokens of other merchants.
+	GetByToken(ctx context.Context, merchantID, token string) (*Card, error)
+	// ListNotSealedWith returns up to limit cards whose PAN is sealed with a key other than keyID.
+	ListNotSealedWith(ctx context.Context, keyID string, limit int) ([]*Card, error)
+	// UpdateCiphertext replaces the sealed PAN only if the card is still sealed with fromKeyID,
+	// and reports whether it did.
+	UpdateCiphertext(ctx context.Context, token, fromKeyID, toKeyID string, ciphertext []byte) (bool, error)
+}
@@ -0,0 +1,105 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// Keyring holds the AES-256-GCM keys PANs are encrypted with. New cards are sealed
+// with the active key; the other keys stay available to open cards sealed before a
+// rotation until RotateKeys has re-encrypted them.
+type Keyring struct {
+	activeID string
+	keys     map[string]cipher.AEAD
+}
+
+// NewKeyring builds a keyring from base64-encoded 32-byte keys by id.
+func NewKeyring(encoded map[string]string, activeID string) (*Keyring, error) {
+	keys := make(map[string]cipher.AEAD, len(encoded))
+	for id, enc := range encoded {
+		raw, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil {
+			return nil, fmt.Errorf("decode vault key %q: %w", id, err)
+		}
+		if len(raw) != 32 {
+			return nil, fmt.Errorf("vault key %q: want 32 bytes, got %d", id, len(raw))
+		}
+		block, err := aes.NewCipher(raw)
+		if err != nil {
+			return nil, fmt.Errorf("vault key %q: %w", id, err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("vault key %q: %w", id, err)
+		}
+		keys[id] = aead
+	}
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("active vault key %q: %w", activeID, ErrUnknownKey)
+	}
+	return &Keyring{activeID: activeID, keys: keys}, nil
+}
+
+// ActiveKeyID names the key new ciphertexts are sealed with.
+func (k *Keyring) ActiveKeyID() string {
+	return k.activeID
+}
+
+// Seal encrypts plaintext with the active key. The token is bound as additional data,
+// so a ciphertext cannot be moved to another card.
+func (k *Keyring) Seal(token string, plaintext []byte) (string, []byte, error) {
+	aead := k.keys[k.activeID]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return k.activeID, aead.Seal(nonce, nonce, plaintext, []byte(token)), nil
+}
+
+// Open decrypts a ciphertext produced by Seal with the named key.
+func (k *Keyring) Open(keyID, token string, ciphertext []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("open card %s with key %q: %w", token, keyID, ErrUnknownKey)
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, fmt.Errorf("open card %s: ciphertext too short", token)
+	}
+	nonce, sealed := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, []byte(token))
+	if err != nil {
+		return nil, fmt.Errorf("open card %s: %w", token, err)
+	}
+	return plaintext, nil
+}
+
+// Fingerprinter derives card fingerprints with a dedicated HMAC key. It is kept apart
+// from the keyring so that fingerprints survive key rotation.
+type Fingerprinter struct {
+	key []byte
+}
+
+// NewFingerprinter builds a fingerprinter from a base64-encoded key of at least 32 bytes.
+func NewFingerprinter(encoded string) (*Fingerprinter, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode fingerprint key: %w", err)
+	}
+	if len(key) < 32 {
+		return nil, fmt.Errorf("fingerprint key: want at least 32 bytes, got %d", len(key))
+	}
+	return &Fingerprinter{key: key}, nil
+}
+
+// Fingerprint is the hex HMAC-SHA256 of the PAN.
+func (f *Fingerprinter) Fingerprint(pan string) string {
+	mac := hmac.New(sha256.New, f.key)
+	mac.Write([]byte(pan))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,119 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type Service struct {
+	repo         Repo
+	keys         *Keyring
+	fingerprints *Fingerprinter
+	log          *slog.Logger
+}
+
+func NewService(repo Repo, keys *Keyring, fingerprints *Fingerprinter, log *slog.Logger) *Service {
+	return &Service{
+		repo:         repo,
+		keys:         keys,
+		fingerprints: fingerprints,
+		log:          log,
+	}
+}
+
+type TokenizeRequest struct {
+	PAN      string
+	ExpMonth int
+	ExpYear  int
+	CVC      string
+}
+
+// Tokenize validates the card and stores its PAN encrypted under a new token. The CVC is
+// only validated: it must never be stored.
+func (s *Service) Tokenize(ctx context.Context, merchantID string, req TokenizeRequest) (*Card, error) {
+	now := time.Now().UTC()
+	pan := normalizePAN(req.PAN)
+	if err := ValidatePAN(pan); err != nil {
+		return nil, err
+	}
+	expYear, err := ValidateExpiry(req.ExpMonth, req.ExpYear, now)
+	if err != nil {
+		return nil, err
+	}
+	brand := DetectBrand(pan)
+	if err := ValidateCVC(req.CVC, brand); err != nil {
+		return nil, err
+	}
+
+	card := &Card{
+		Token:       newToken(),
+		MerchantID:  merchantID,
+		Brand:       brand,
+		Last4:       pan[len(pan)-4:],
+		ExpMonth:    req.ExpMonth,
+		ExpYear:     expYear,
+		Fingerprint: s.fingerprints.Fingerprint(pan),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	card.KeyID, card.PANCiphertext, err = s.keys.Seal(card.Token, []byte(pan))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, card); err != nil {
+		return nil, fmt.Errorf("save card: %w", err)
+	}
+
+	s.log.Info("card tokenized",
+		"token", card.Token,
+		"merchant_id", merchantID,
+		"brand", card.Brand,
+		"key_id", card.KeyID,
+	)
+	return card, nil
+}
+
+// Get returns the card behind token; ErrNotFound when it belongs to another merchant.
+func (s *Service) Get(ctx context.Context, merchantID, token string) (*Card, error) {
+	return s.repo.GetByToken(ctx, merchantID, token)
+}
+
+// RotateKeys re-encrypts every card sealed with a key other than the active one, batchSize
+// cards at a time, and returns how many it re-encrypted. Safe to run concurrently: a card
+// already rotated by someone else is skipped.
+func (s *Service) RotateKeys(ctx context.Context, batchSize int) (int, error) {
+	activeID := s.keys.ActiveKeyID()
+	rotated := 0
+	for {
+		cards, err := s.repo.ListNotSealedWith(ctx, activeID, batchSize)
+		if err != nil {
+			return rotated, fmt.Errorf("list cards to rotate: %w", err)
+		}
+		if len(cards) == 0 {
+			return rotated, nil
+		}
+
+		for _, card := range cards {
+			pan, err := s.keys.Open(card.KeyID, card.Token, card.PANCiphertext)
+			if err != nil {
+				return rotated, err
+			}
+			keyID, ciphertext, err := s.keys.Seal(card.Token, pan)
+			if err != nil {
+				return rotated, err
+			}
+			updated, err := s.repo.UpdateCiphertext(ctx, card.Token, card.KeyID, keyID, ciphertext)
+			if err != nil {
+				return rotated, fmt.Errorf("update card %s: %w", card.Token, err)
+			}
+			if updated {
+				rotated++
+			}
+		}
+
+		s.log.Info("vault key rotation progress", "rotated", rotated, "active_key_id", activeID)
+	}
+}
@@ -0,0 +1,185 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testKeyOld         = "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+	testKeyNew         = "HxsaGRgXFhUUExIREA8ODQwLCgkIBwYFBAMCAQAfHh0="
+	testFingerprintKey = "ICEiIyQlJicoKSorLC0uLzAxMjM0NTY3ODk6Ozw9Pj8="
+)
+
+// fakeRepo keeps cards in memory by token.
+type fakeRepo struct {
+	cards map[string]*Card
+}
+
+func newFakeRepo() *fakeRepo {
+	return &fakeRepo{cards: map[string]*Card{}}
+}
+
+func (r *fakeRepo) Create(_ context.Context, c *Card) error {
+	cp := *c
+	r.cards[c.Token] = &cp
+	return nil
+}
+func (r *fakeRepo) GetByToken(_ context.Context, merchantID, token string) (*Card, error) {
+	c, ok := r.cards[token]
+	if !ok || c.MerchantID != merchantID {
+		return nil, ErrNotFound
+	}
+	cp := *c
+	return &cp, nil
+}
+func (r *fakeRepo) ListNotSealedWith(_ context.Context, keyID string, limit int) ([]*Card, error) {
+	var out []*Card
+	for _, c := range r.cards {
+		if c.KeyID != keyID && len(out) < limit {
+			cp := *c
+			out = append(out, &cp)
+		}
+	}
+	return out, nil
+}
+func (r *fakeRepo) UpdateCiphertext(_ context.Context, token, fromKeyID, toKeyID string, ciphertext []byte) (bool, error) {
+	c, ok := r.cards[token]
+	if !ok || c.KeyID != fromKeyID {
+		return false, nil
+	}
+	c.KeyID = toKeyID
+	c.PANCiphertext = ciphertext
+	return true, nil
+}
+
+func newTestService(t *testing.T, repo Repo, keys map[string]string, activeID string) (*Service, *Keyring) {
+	t.Helper()
+	keyring, err := NewKeyring(keys, activeID)
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	fingerprints, err := NewFingerprinter(testFingerprintKey)
+	if err != nil {
+		t.Fatalf("fingerprinter: %v", err)
+	}
+	return NewService(repo, keyring, fingerprints, slog.New(slog.NewTextHandler(io.Discard, nil))), keyring
+}
+
+func validRequest() TokenizeRequest {
+	return TokenizeRequest{PAN: "4242 4242 4242 4242", ExpMonth: 12, ExpYear: time.Now().Year() + 1, CVC: "123"}
+}
+
+func TestService_Tokenize(t *testing.T) {
+	t.Run("stores only the encrypted PAN", func(t *testing.T) {
+		repo := newFakeRepo()
+		svc, keyring := newTestService(t, repo, map[string]string{"k1": testKeyOld}, "k1")
+
+		card, err := svc.Tokenize(context.Background(), "m1", validRequest())
+		if err != nil {
+			t.Fatalf("unexpected err: %v", err)
+		}
+		if !strings.HasPrefix(card.Token, "tok_") {
+			t.Errorf("token: want tok_ prefix, got %q", card.Token)
+		}
+		if card.Brand != BrandVisa || card.Last4 != "4242" {
+			t.Errorf("brand/last4: got %s/%s", card.Brand, card.Last4)
+		}
+		stored := repo.cards[card.Token]
+		if bytes.Contains(stored.PANCiphertext, []byte("4242424242424242")) {
+			t.Fatal("PAN stored in plaintext")
+		}
+		pan, err := keyring.Open(stored.KeyID, stored.Token, stored.PANCiphertext)
+		if err != nil || string(pan) != "4242424242424242" {
+			t.Fatalf("open: got %q, %v", pan, err)
+		}
+	})
+
+	t.Run("same card yields same fingerprint under a new token", func(t *testing.T) {
+		svc, _ := newTestService(t, newFakeRepo(), map[string]string{"k1": testKeyOld}, "k1")
+		a, _ := svc.Tokenize(context.Background(), "m1", validRequest())
+		b, _ := svc.Tokenize(context.Background(), "m1", validRequest())
+		if a.Token == b.Token {
+			t.Error("tokens should differ")
+		}
+		if a.Fingerprint != b.Fingerprint {
+			t.Error("fingerprints should match")
+		}
+	})
+
+	t.Run("invalid card rejected before repo.Create", func(t *testing.T) {
+		repo := newFakeRepo()
+		svc, _ := newTestService(t, repo, map[string]string{"k1": testKeyOld}, "k1")
+		req := validRequest()
+		req.PAN = "4242424242424241"
+		if _, err := svc.Tokenize(context.Background(), "m1", req); !errors.Is(err, ErrInvalidPAN) {
+			t.Fatalf("want ErrInvalidPAN, got %v", err)
+		}
+		if len(repo.cards) != 0 {
+			t.Error("repo.Create should not be called on validation error")
+		}
+	})
+
+	t.Run("token of another merchant not found", func(t *testing.T) {
+		svc, _ := newTestService(t, newFakeRepo(), map[string]string{"k1": testKeyOld}, "k1")
+		card, _ := svc.Tokenize(context.Background(), "m1", validRequest())
+		if _, err := svc.Get(context.Background(), "m2", card.Token); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("want ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestService_RotateKeys(t *testing.T) {
+	repo := newFakeRepo()
+	old, _ := newTestService(t, repo, map[string]string{"k1": testKeyOld}, "k1")
+	for range 3 {
+		if _, err := old.Tokenize(context.Background(), "m1", validRequest()); err != nil {
+			t.Fatalf("tokenize: %v", err)
+		}
+	}
+
+	svc, keyring := newTestService(t, repo, map[string]string{"k1": testKeyOld, "k2": testKeyNew}, "k2")
+	rotated, err := svc.RotateKeys(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+	if rotated != 3 {
+		t.Errorf("rotated: want 3, got %d", rotated)
+	}
+	for _, c := range repo.cards {
+		if c.KeyID != "k2" {
+			t.Errorf("card %s still sealed with %s", c.Token, c.KeyID)
+		}
+		if pan, err := keyring.Open("k2", c.Token, c.PANCiphertext); err != nil || string(pan) != "4242424242424242" {
+			t.Errorf("open rotated card: got %q, %v", pan, err)
+		}
+	}
+
+	again, err := svc.RotateKeys(context.Background(), 2)
+	if err != nil || again != 0 {
+		t.Errorf("second rotation: want 0, nil; got %d, %v", again, err)
+	}
+}
+
+func TestKeyring_OpenRejectsCiphertextOfAnotherCard(t *testing.T) {
+	keyring, err := NewKeyring(map[string]string{"k1": testKeyOld}, "k1")
+	if err != nil {
+		t.Fatalf("keyring: %v", err)
+	}
+	keyID, ciphertext, err := keyring.Seal("tok_a", []byte("4242424242424242"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if _, err := keyring.Open(keyID, "tok_b", ciphertext); err == nil {
+		t.Error("ciphertext must not open under another token")
+	}
+	if _, err := keyring.Open("k9", "tok_a", ciphertext); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("want ErrUnknownKey, got %v", err)
+	}
+}
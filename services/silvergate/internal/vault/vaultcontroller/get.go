@@ -0,0 +1,39 @@
+package vaultcontroller
+
+import (
+	"errors"
+	"net/http"
+
+	"TestTaskJustPay/services/silvergate/internal/merchantauth"
+	"TestTaskJustPay/services/silvergate/internal/vault"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GetHandler struct {
+	svc *vault.Service
+}
+
+func NewGetHandler(svc *vault.Service) *GetHandler {
+	return &GetHandler{svc: svc}
+}
+
+func (h *GetHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "merchant context missing"})
+		return
+	}
+
+	card, err := h.svc.Get(c.Request.Context(), merchantID, c.Param("token"))
+	if err != nil {
+		if errors.Is(err, vault.ErrNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusOK, toCardResponse(card))
+}
@@ -0,0 +1,35 @@
+package vaultcontroller
+
+import (
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/vault"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code,omitempty"`
+}
+
+// cardResponse never carries the PAN or its ciphertext.
+type cardResponse struct {
+	Token       string    `json:"token"`
+	Brand       string    `json:"brand"`
+	Last4       string    `json:"last4"`
+	ExpMonth    int       `json:"exp_month"`
+	ExpYear     int       `json:"exp_year"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func toCardResponse(c *vault.Card) cardResponse {
+	return cardResponse{
+		Token:       c.Token,
+		Brand:       string(c.Brand),
+		Last4:       c.Last4,
+		ExpMonth:    c.ExpMonth,
+		ExpYear:     c.ExpYear,
+		Fingerprint: c.Fingerprint,
+		CreatedAt:   c.CreatedAt,
+	}
+}
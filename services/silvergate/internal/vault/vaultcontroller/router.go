@@ -0,0 +1,17 @@
+package vaultcontroller
+
+import (
+	"TestTaskJustPay/services/silvergate/internal/vault"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes constructs the card vault handlers from svc and mounts them on rg.
+// Callers wire the merchant-auth middleware on rg before calling this.
+func RegisterRoutes(rg *gin.RouterGroup, svc *vault.Service) {
+	tokenize := NewTokenizeHandler(svc)
+	get := NewGetHandler(svc)
+
+	rg.POST("", tokenize.Handle)
+	rg.GET("/:token", get.Handle)
+}
@@ -0,0 +1,69 @@
+package vaultcontroller
+
+import (
+	"errors"
+	"net/http"
+
+	"TestTaskJustPay/services/silvergate/internal/merchantauth"
+	"TestTaskJustPay/services/silvergate/internal/vault"
+
+	"github.com/gin-gonic/gin"
+)
+
+type tokenizeRequest struct {
+	Number   string `json:"number" binding:"required"`
+	ExpMonth int    `json:"exp_month" binding:"required"`
+	ExpYear  int    `json:"exp_year" binding:"required"`
+	CVC      string `json:"cvc" binding:"required"`
+}
+
+type TokenizeHandler struct {
+	svc *vault.Service
+}
+
+func NewTokenizeHandler(svc *vault.Service) *TokenizeHandler {
+	return &TokenizeHandler{svc: svc}
+}
+
+func (h *TokenizeHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "merchant context missing"})
+		return
+	}
+
+	// Binding errors are not echoed back: they could quote the card number.
+	var req tokenizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "number, exp_month, exp_year and cvc are required", Code: "invalid_request"})
+		return
+	}
+
+	card, err := h.svc.Tokenize(c.Request.Context(), merchantID, vault.TokenizeRequest{
+		PAN:      req.Number,
+		ExpMonth: req.ExpMonth,
+		ExpYear:  req.ExpYear,
+		CVC:      req.CVC,
+	})
+	if err != nil {
+		writeTokenizeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toCardResponse(card))
+}
+
+func writeTokenizeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, vault.ErrInvalidPAN):
+		c.JSON(http.StatusUnprocessableEntity, errorResponse{Error: err.Error(), Code: "invalid_number"})
+	case errors.Is(err, vault.ErrInvalidExpiry):
+		c.JSON(http.StatusUnprocessableEntity, errorResponse{Error: err.Error(), Code: "invalid_expiry"})
+	case errors.Is(err, vault.ErrCardExpired):
+		c.JSON(http.StatusUnprocessableEntity, errorResponse{Error: err.Error(), Code: "card_expired"})
+	case errors.Is(err, vault.ErrInvalidCVC):
+		c.JSON(http.StatusUnprocessableEntity, errorResponse{Error: err.Error(), Code: "invalid_cvc"})
+	default:
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "internal error"})
+	}
+}
@@ -0,0 +1,123 @@
+package vaultrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/vault"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+var cardColumns = []string{
+	"token", "merchant_id", "brand", "last4", "exp_month", "exp_year",
+	"fingerprint", "key_id", "pan_ciphertext", "created_at", "updated_at",
+}
+
+type PgCardRepo struct {
+	db postgres.Executor
+}
+
+func NewPgCardRepo(db postgres.Executor) *PgCardRepo {
+	return &PgCardRepo{db: db}
+}
+
+func (r *PgCardRepo) Create(ctx context.Context, c *vault.Card) error {
+	query, args, err := psql.
+		Insert("cards").
+		Columns(cardColumns...).
+		Values(c.Token, c.MerchantID, c.Brand, c.Last4, c.ExpMonth, c.ExpYear,
+			c.Fingerprint, c.KeyID, c.PANCiphertext, c.CreatedAt, c.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec insert: %w", err)
+	}
+	return nil
+}
+
+func (r *PgCardRepo) GetByToken(ctx context.Context, merchantID, token string) (*vault.Card, error) {
+	query, args, err := psql.
+		Select(cardColumns...).
+		From("cards").
+		Where(sq.Eq{"token": token, "merchant_id": merchantID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	c, err := scanCard(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, vault.ErrNotFound
+		}
+		return nil, fmt.Errorf("scan card: %w", err)
+	}
+	return c, nil
+}
+
+func (r *PgCardRepo) ListNotSealedWith(ctx context.Context, keyID string, limit int) ([]*vault.Card, error) {
+	query, args, err := psql.
+		Select(cardColumns...).
+		From("cards").
+		Where(sq.NotEq{"key_id": keyID}).
+		OrderBy("created_at", "token").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build select: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query cards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []*vault.Card
+	for rows.Next() {
+		c, err := scanCard(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan card: %w", err)
+		}
+		cards = append(cards, c)
+	}
+	return cards, rows.Err()
+}
+
+func (r *PgCardRepo) UpdateCiphertext(ctx context.Context, token, fromKeyID, toKeyID string, ciphertext []byte) (bool, error) {
+	query, args, err := psql.
+		Update("cards").
+		Set("key_id", toKeyID).
+		Set("pan_ciphertext", ciphertext).
+		Set("updated_at", sq.Expr("now()")).
+		Where(sq.Eq{"token": token, "key_id": fromKeyID}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("build update: %w", err)
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("exec update: %w", err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+func scanCard(row pgx.Row) (*vault.Card, error) {
+	var c vault.Card
+	err := row.Scan(&c.Token, &c.MerchantID, &c.Brand, &c.Last4, &c.ExpMonth, &c.ExpYear,
+		&c.Fingerprint, &c.KeyID, &c.PANCiphertext, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
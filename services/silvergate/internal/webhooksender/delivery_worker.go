@@ -0,0 +1,186 @@
+package webhooksender
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"TestTaskJustPay/pkg/webhooksig"
+)
+
+// deliveryTimeout bounds a single webhook POST.
+const deliveryTimeout = 10 * time.Second
+
+// leaseMargin covers the outcome writes after each POST.
+const leaseMargin = time.Second
+
+// DeliveryWorkerConfig holds configuration for the webhook delivery worker.
+type DeliveryWorkerConfig struct {
+	// CallbackURL receives the events of merchants with no registered endpoints, signed
+	// with each of SigningSecrets.
+	CallbackURL    string
+	SigningSecrets []string
+	PollInterval   time.Duration
+	BatchSize      int
+	// InitialBackoff is the wait after the first failed attempt; it doubles per attempt
+	// up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// MaxAge is how long after the event delivery is retried before the message is dead.
+	MaxAge time.Duration
+}
+
+// Backoff returns the wait before the attempt after the given number of failed attempts.
+func (c DeliveryWorkerConfig) Backoff(attempts int) time.Duration {
+	backoff := c.InitialBackoff
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= c.MaxBackoff {
+			return c.MaxBackoff
+		}
+	}
+	return min(backoff, c.MaxBackoff)
+}
+
+// ClaimLease is how long a claimed batch is hidden from other workers: long enough for
+// every message in it to time out in turn. A worker that dies mid-delivery leaves the
+// rest of its batch to be retried once the lease lapses.
+func (c DeliveryWorkerConfig) ClaimLease() time.Duration {
+	return time.Duration(max(c.BatchSize, 1)) * (deliveryTimeout + leaseMargin)
+}
+
+// DeliveryWorker posts outbox messages to their merchant endpoint, or to the default
+// callback URL, until they are delivered or dead. Messages are claimed with a lease, so several replicas can deliver concurrently;
+// delivery is at least once.
+type DeliveryWorker struct {
+	repo   OutboxRepo
+	client *http.Client
+	cfg    DeliveryWorkerConfig
+	log    *slog.Logger
+}
+
+func NewDeliveryWorker(repo OutboxRepo, cfg DeliveryWorkerConfig, log *slog.Logger) *DeliveryWorker {
+	return &DeliveryWorker{
+		repo: repo,
+		client: &http.Client{
+			Timeout: deliveryTimeout,
+		},
+		cfg: cfg,
+		log: log,
+	}
+}
+
+// Start begins the delivery loop. Blocks until ctx is cancelled.
+func (w *DeliveryWorker) Start(ctx context.Context) error {
+	w.log.Info("webhook delivery worker started",
+		"poll_interval", w.cfg.PollInterval,
+		"batch_size", w.cfg.BatchSize,
+		"max_age", w.cfg.MaxAge)
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.log.Info("webhook delivery worker stopped")
+			return ctx.Err()
+		case <-ticker.C:
+			w.drain(ctx)
+		}
+	}
+}
+
+func (w *DeliveryWorker) drain(ctx context.Context) {
+	// Drain full batches so a backlog does not wait a whole interval per batch.
+	for {
+		n, err := w.DeliverDue(ctx)
+		if err != nil {
+			w.log.Error("webhook delivery failed", "error", err)
+			return
+		}
+		if n < w.cfg.BatchSize || ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// DeliverDue attempts every message due now, up to the batch size. Returns how many
+// were attempted.
+func (w *DeliveryWorker) DeliverDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	messages, err := w.repo.ClaimDue(ctx, now, w.cfg.ClaimLease(), w.cfg.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("claim due webhooks: %w", err)
+	}
+
+	for _, m := range messages {
+		if err := w.attempt(ctx, m); err != nil {
+			w.log.Error("failed to record webhook attempt", "message_id", m.ID, "error", err)
+		}
+	}
+	return len(messages), nil
+}
+
+// attempt posts m once, logs the delivery and records the outcome: delivered, retried
+// after a backoff, or dead once the message is older than the max age. Messages for a
+// disabled endpoint are dead without an attempt.
+func (w *DeliveryWorker) attempt(ctx context.Context, m *Message) error {
+	if m.EndpointDisabled {
+		w.log.Info("webhook endpoint disabled, dropping message", "message_id", m.ID, "endpoint_id", m.EndpointID)
+		return w.repo.MarkDead(ctx, m.ID, "endpoint disabled")
+	}
+
+	url, secrets := m.URL, []string{m.Secret}
+	if m.EndpointID == nil {
+		url, secrets = w.cfg.CallbackURL, w.cfg.SigningSecrets
+	}
+	started := time.Now()
+	code, sendErr := w.post(ctx, url, secrets, m)
+	if err := w.repo.RecordDelivery(ctx, newDelivery(m, url, code, time.Since(started), sendErr)); err != nil {
+		w.log.Error("failed to log webhook delivery", "message_id", m.ID, "error", err)
+	}
+
+	now := time.Now().UTC()
+	if sendErr == nil {
+		w.log.Info("webhook delivered", "message_id", m.ID, "event", m.EventType, "attempts", m.Attempts)
+		return w.repo.MarkDelivered(ctx, m.ID, now)
+	}
+
+	if now.Sub(m.CreatedAt) >= w.cfg.MaxAge {
+		w.log.Error("webhook dead, giving up",
+			"message_id", m.ID, "event", m.EventType, "attempts", m.Attempts, "error", sendErr)
+		return w.repo.MarkDead(ctx, m.ID, sendErr.Error())
+	}
+
+	next := now.Add(w.cfg.Backoff(m.Attempts))
+	w.log.Warn("webhook delivery failed, will retry",
+		"message_id", m.ID, "event", m.EventType, "attempts", m.Attempts, "next_attempt_at", next, "error", sendErr)
+	return w.repo.MarkRetry(ctx, m.ID, next, sendErr.Error())
+}
+
+// post sends m to url signed with secrets and returns the response status code, zero
+// when no response was received. The signature is timestamped per attempt, so retries
+// are not rejected as replays.
+func (w *DeliveryWorker) post(ctx context.Context, url string, secrets []string, m *Message) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(m.Payload))
+	if err != nil {
+		return 0, fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhooksig.Header, webhooksig.HeaderValue(secrets, time.Now().Unix(), m.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("webhook rejected: status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
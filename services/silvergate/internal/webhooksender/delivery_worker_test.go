@@ -0,0 +1,286 @@
+package webhooksender
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/pkg/webhooksig"
+
+	"github.com/google/uuid"
+)
+
+type fakeOutbox struct {
+	messages   map[uuid.UUID]*Message
+	deliveries []*Delivery
+}
+
+func newFakeOutbox() *fakeOutbox {
+	return &fakeOutbox{messages: map[uuid.UUID]*Message{}}
+}
+
+func (f *fakeOutbox) Enqueue(_ context.Context, m *Message) error {
+	f.messages[m.ID] = m
+	return nil
+}
+
+func (f *fakeOutbox) ClaimDue(_ context.Context, now time.Time, lease time.Duration, limit int) ([]*Message, error) {
+	var out []*Message
+	for _, m := range f.messages {
+		if m.Status == MessagePending && !m.NextAttemptAt.After(now) && len(out) < limit {
+			m.Attempts++
+			m.NextAttemptAt = now.Add(lease)
+			claimed := *m
+			out = append(out, &claimed)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeOutbox) MarkDelivered(_ context.Context, id uuid.UUID, at time.Time) error {
+	f.messages[id].Status = MessageDelivered
+	f.messages[id].DeliveredAt = &at
+	return nil
+}
+
+func (f *fakeOutbox) MarkRetry(_ context.Context, id uuid.UUID, next time.Time, lastErr string) error {
+	f.messages[id].NextAttemptAt = next
+	f.messages[id].LastError = lastErr
+	return nil
+}
+
+func (f *fakeOutbox) MarkDead(_ context.Context, id uuid.UUID, lastErr string) error {
+	f.messages[id].Status = MessageDead
+	f.messages[id].LastError = lastErr
+	return nil
+}
+
+func (f *fakeOutbox) Requeue(_ context.Context, id uuid.UUID, at time.Time) error {
+	f.messages[id].Status = MessagePending
+	f.messages[id].NextAttemptAt = at
+	return nil
+}
+
+func (f *fakeOutbox) RecordDelivery(_ context.Context, d *Delivery) error {
+	f.deliveries = append(f.deliveries, d)
+	return nil
+}
+
+func (f *fakeOutbox) GetDelivery(_ context.Context, merchantID string, id uuid.UUID) (*Delivery, error) {
+	for _, d := range f.deliveries {
+		if d.ID == id && d.MerchantID == merchantID {
+			return d, nil
+		}
+	}
+	return nil, ErrDeliveryNotFound
+}
+
+func (f *fakeOutbox) ListDeliveries(_ context.Context, merchantID string, _ DeliveryFilter) ([]*Delivery, *Cursor, error) {
+	var out []*Delivery
+	for _, d := range f.deliveries {
+		if d.MerchantID == merchantID {
+			out = append(out, d)
+		}
+	}
+	return out, nil, nil
+}
+
+func newTestWorker(t *testing.T, outbox OutboxRepo, status int) (*DeliveryWorker, *[][]byte) {
+	t.Helper()
+	var received [][]byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	w := NewDeliveryWorker(outbox, DeliveryWorkerConfig{
+		CallbackURL:    srv.URL,
+		SigningSecrets: []string{"whsec_default"},
+		BatchSize:      10,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		MaxAge:         time.Hour,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return w, &received
+}
+
+func TestDeliverDue_MarksDelivered(t *testing.T) {
+	outbox := newFakeOutbox()
+	m := NewMessage("m1", "transaction.captured", []byte(`{"event":"transaction.captured"}`))
+	outbox.messages[m.ID] = m
+	w, received := newTestWorker(t, outbox, http.StatusOK)
+
+	n, err := w.DeliverDue(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 || len(*received) != 1 || string((*received)[0]) != string(m.Payload) {
+		t.Errorf("want the payload posted once, got %d attempts and %q", n, *received)
+	}
+	if m.Status != MessageDelivered || m.DeliveredAt == nil {
+		t.Errorf("want delivered, got %s", m.Status)
+	}
+	if len(outbox.deliveries) != 1 || outbox.deliveries[0].ResponseCode != http.StatusOK || outbox.deliveries[0].Error != "" {
+		t.Errorf("want one successful delivery logged, got %+v", outbox.deliveries)
+	}
+}
+
+func TestDeliverDue_PostsToEndpointURL(t *testing.T) {
+	var hits int
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	t.Cleanup(endpoint.Close)
+
+	outbox := newFakeOutbox()
+	m := NewMessage("m1", "transaction.captured", []byte(`{}`))
+	endpointID := uuid.New()
+	m.EndpointID = &endpointID
+	m.URL = endpoint.URL
+	outbox.messages[m.ID] = m
+	w, defaultHits := newTestWorker(t, outbox, http.StatusOK)
+
+	if _, err := w.DeliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if hits != 1 || len(*defaultHits) != 0 {
+		t.Errorf("want the endpoint posted instead of the default callback, got %d and %d", hits, len(*defaultHits))
+	}
+	if d := outbox.deliveries[0]; d.URL != endpoint.URL || d.EndpointID == nil || *d.EndpointID != endpointID {
+		t.Errorf("want the delivery logged against the endpoint, got %+v", d)
+	}
+}
+
+func TestDeliverDue_SignsPayload(t *testing.T) {
+	var signatures []string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signatures = append(signatures, r.Header.Get(webhooksig.Header))
+	}))
+	t.Cleanup(endpoint.Close)
+
+	outbox := newFakeOutbox()
+	toDefault := NewMessage("m1", "transaction.captured", []byte(`{"n":1}`))
+	toEndpoint := NewMessage("m2", "transaction.captured", []byte(`{"n":2}`))
+	endpointID := uuid.New()
+	toEndpoint.EndpointID, toEndpoint.URL, toEndpoint.Secret = &endpointID, endpoint.URL, "whsec_endpoint"
+	outbox.messages[toDefault.ID] = toDefault
+	outbox.messages[toEndpoint.ID] = toEndpoint
+
+	w := NewDeliveryWorker(outbox, DeliveryWorkerConfig{
+		CallbackURL:    endpoint.URL,
+		SigningSecrets: []string{"whsec_new", "whsec_old"},
+		BatchSize:      10,
+		MaxAge:         time.Hour,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if _, err := w.DeliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	var defaultOK, endpointOK int
+	for _, sig := range signatures {
+		if webhooksig.Verify(sig, toDefault.Payload, []string{"whsec_old"}, now, time.Minute) == nil &&
+			webhooksig.Verify(sig, toDefault.Payload, []string{"whsec_new"}, now, time.Minute) == nil {
+			defaultOK++
+		}
+		if webhooksig.Verify(sig, toEndpoint.Payload, []string{"whsec_endpoint"}, now, time.Minute) == nil {
+			endpointOK++
+		}
+	}
+	if defaultOK != 1 || endpointOK != 1 {
+		t.Errorf("want the default callback signed with every secret and the endpoint with its own, got %q", signatures)
+	}
+}
+
+func TestDeliverDue_DropsMessagesOfDisabledEndpoint(t *testing.T) {
+	outbox := newFakeOutbox()
+	m := NewMessage("m1", "transaction.captured", []byte(`{}`))
+	m.EndpointDisabled = true
+	outbox.messages[m.ID] = m
+	w, received := newTestWorker(t, outbox, http.StatusOK)
+
+	if _, err := w.DeliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Status != MessageDead || len(*received) != 0 || len(outbox.deliveries) != 0 {
+		t.Errorf("want dead without an attempt, got %s after %d posts", m.Status, len(*received))
+	}
+}
+
+func TestDeliverDue_RetriesWithBackoff(t *testing.T) {
+	outbox := newFakeOutbox()
+	m := NewMessage("m1", "transaction.captured", []byte(`{}`))
+	outbox.messages[m.ID] = m
+	w, _ := newTestWorker(t, outbox, http.StatusServiceUnavailable)
+
+	if _, err := w.DeliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Status != MessagePending || m.Attempts != 1 || m.LastError == "" {
+		t.Fatalf("want pending after a failed attempt, got %+v", m)
+	}
+	if wait := time.Until(m.NextAttemptAt); wait <= 0 || wait > time.Second {
+		t.Errorf("want next attempt within the initial backoff, got %s", wait)
+	}
+	if d := outbox.deliveries[0]; d.ResponseCode != http.StatusServiceUnavailable || d.Error == "" {
+		t.Errorf("want the failed attempt logged, got %+v", d)
+	}
+
+	// Not due yet: nothing is attempted until the backoff passes.
+	if n, _ := w.DeliverDue(context.Background()); n != 0 {
+		t.Errorf("want no attempt before the backoff, got %d", n)
+	}
+}
+
+func TestDeliverDue_DeadAfterMaxAge(t *testing.T) {
+	outbox := newFakeOutbox()
+	m := NewMessage("m1", "transaction.captured", []byte(`{}`))
+	m.CreatedAt = time.Now().UTC().Add(-2 * time.Hour)
+	outbox.messages[m.ID] = m
+	w, _ := newTestWorker(t, outbox, http.StatusInternalServerError)
+
+	if _, err := w.DeliverDue(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Status != MessageDead {
+		t.Errorf("want dead, got %s", m.Status)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := DeliveryWorkerConfig{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+
+	for attempts, want := range map[int]time.Duration{
+		1:   time.Second,
+		2:   2 * time.Second,
+		4:   8 * time.Second,
+		5:   10 * time.Second,
+		100: 10 * time.Second,
+	} {
+		if got := cfg.Backoff(attempts); got != want {
+			t.Errorf("attempt %d: want %s, got %s", attempts, want, got)
+		}
+	}
+}
+
+func TestClaimLease_CoversWholeBatch(t *testing.T) {
+	cfg := DeliveryWorkerConfig{BatchSize: 50}
+
+	if got, want := cfg.ClaimLease(), 50*deliveryTimeout; got < want {
+		t.Errorf("lease %s shorter than a batch of timeouts %s", got, want)
+	}
+	if got := (DeliveryWorkerConfig{}).ClaimLease(); got < deliveryTimeout {
+		t.Errorf("lease %s shorter than one delivery", got)
+	}
+}
@@ -0,0 +1,81 @@
+package webhooksender
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EventTypes lists every event a merchant endpoint can subscribe to.
+var EventTypes = []string{
+	"transaction.authorized",
+	"transaction.declined",
+	"transaction.captured",
+	"transaction.partially_captured",
+	"transaction.capture_failed",
+	"transaction.voided",
+	"transaction.expired",
+	"transaction.refunded",
+	"transaction.refund_failed",
+	"payout.paid",
+}
+
+// Endpoint is a URL a merchant registered to receive webhooks. An endpoint with no
+// EventTypes receives every event. Secret signs the payloads posted to it and is only
+// shown to the merchant when the endpoint is created.
+type Endpoint struct {
+	ID         uuid.UUID
+	MerchantID string
+	URL        string
+	EventTypes []string
+	Secret     string
+	CreatedAt  time.Time
+	DisabledAt *time.Time
+}
+
+// NewEndpoint validates the URL and event types and generates a signing secret.
+func NewEndpoint(merchantID, rawURL string, eventTypes []string) (*Endpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, ErrInvalidEndpointURL
+	}
+	for _, t := range eventTypes {
+		if !slices.Contains(EventTypes, t) {
+			return nil, ErrUnknownEventType
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate secret: %w", err)
+	}
+
+	return &Endpoint{
+		ID:         uuid.New(),
+		MerchantID: merchantID,
+		URL:        rawURL,
+		EventTypes: eventTypes,
+		Secret:     "whsec_" + hex.EncodeToString(secret),
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// Subscribes reports whether the endpoint receives events of eventType.
+func (e *Endpoint) Subscribes(eventType string) bool {
+	return len(e.EventTypes) == 0 || slices.Contains(e.EventTypes, eventType)
+}
+
+// EndpointRepo is the persistence contract for merchant webhook endpoints.
+// All methods scope by merchantID; disabled endpoints are not returned.
+type EndpointRepo interface {
+	Create(ctx context.Context, e *Endpoint) error
+	List(ctx context.Context, merchantID string) ([]*Endpoint, error)
+	// Disable returns ErrEndpointNotFound for foreign, unknown or already disabled ids.
+	Disable(ctx context.Context, merchantID string, id uuid.UUID, at time.Time) error
+}
@@ -0,0 +1,98 @@
+package endpointrepo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+type PgEndpointRepo struct {
+	db postgres.Executor
+}
+
+func NewPgEndpointRepo(db postgres.Executor) *PgEndpointRepo {
+	return &PgEndpointRepo{db: db}
+}
+
+func (r *PgEndpointRepo) Create(ctx context.Context, e *webhooksender.Endpoint) error {
+	query, args, err := psql.
+		Insert("webhook_endpoints").
+		Columns("id", "merchant_id", "url", "event_types", "secret", "created_at").
+		Values(e.ID, e.MerchantID, e.URL, eventTypes(e.EventTypes), e.Secret, e.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert endpoint: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec insert endpoint: %w", err)
+	}
+	return nil
+}
+
+func (r *PgEndpointRepo) List(ctx context.Context, merchantID string) ([]*webhooksender.Endpoint, error) {
+	query, args, err := psql.
+		Select("id", "merchant_id", "url", "event_types", "secret", "created_at", "disabled_at").
+		From("webhook_endpoints").
+		Where(sq.Eq{"merchant_id": merchantID, "disabled_at": nil}).
+		OrderBy("created_at", "id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build list endpoints: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("exec list endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*webhooksender.Endpoint
+	for rows.Next() {
+		var e webhooksender.Endpoint
+		if err := rows.Scan(&e.ID, &e.MerchantID, &e.URL, &e.EventTypes, &e.Secret, &e.CreatedAt, &e.DisabledAt); err != nil {
+			return nil, fmt.Errorf("scan endpoint: %w", err)
+		}
+		endpoints = append(endpoints, &e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+func (r *PgEndpointRepo) Disable(ctx context.Context, merchantID string, id uuid.UUID, at time.Time) error {
+	query, args, err := psql.
+		Update("webhook_endpoints").
+		Set("disabled_at", at).
+		Where(sq.Eq{"merchant_id": merchantID, "id": id, "disabled_at": nil}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build disable endpoint: %w", err)
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("exec disable endpoint: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return webhooksender.ErrEndpointNotFound
+	}
+	return nil
+}
+
+// eventTypes keeps a nil subscription list from being written as NULL.
+func eventTypes(types []string) []string {
+	if types == nil {
+		return []string{}
+	}
+	return types
+}
@@ -0,0 +1,11 @@
+package webhooksender
+
+import "errors"
+
+var (
+	ErrInvalidEndpointURL = errors.New("endpoint url must be an absolute http or https url")
+	ErrUnknownEventType   = errors.New("unknown event type")
+	ErrEndpointNotFound   = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrLimitTooLarge      = errors.New("list limit exceeds maximum")
+)
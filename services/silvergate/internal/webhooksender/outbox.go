@@ -0,0 +1,125 @@
+package webhooksender
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageStatus is the delivery state of an outbox message.
+type MessageStatus string
+
+const (
+	MessagePending   MessageStatus = "pending"
+	MessageDelivered MessageStatus = "delivered"
+	// MessageDead means delivery was given up after the message outlived the max age.
+	MessageDead MessageStatus = "dead"
+)
+
+// Message is a webhook event waiting in the outbox. It is written in the DB transaction
+// of the state change it reports and delivered at least once by the DeliveryWorker.
+//
+// A message is bound to one merchant endpoint, or to the default callback URL when
+// EndpointID is nil. URL, Secret and EndpointDisabled are resolved from the endpoint
+// when the message is claimed; URL and Secret are empty for the default callback.
+type Message struct {
+	ID         uuid.UUID
+	MerchantID string
+	EventType  string
+	EndpointID *uuid.UUID
+	// Payload is the JSON body posted to the merchant.
+	Payload       []byte
+	Status        MessageStatus
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	CreatedAt     time.Time
+	DeliveredAt   *time.Time
+
+	URL              string
+	Secret           string
+	EndpointDisabled bool
+}
+
+func NewMessage(merchantID, eventType string, payload []byte) *Message {
+	now := time.Now().UTC()
+	return &Message{
+		ID:            uuid.New(),
+		MerchantID:    merchantID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        MessagePending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
+
+// OutboxRepo is the persistence contract for the webhook outbox.
+type OutboxRepo interface {
+	Enqueue(ctx context.Context, m *Message) error
+	// ClaimDue returns up to limit pending messages due at now, counting the attempt and
+	// pushing their next attempt a lease into the future so concurrent workers do not claim
+	// them too. A message whose worker dies mid-delivery is retried once the lease lapses.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Message, error)
+	MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error
+	// MarkRetry records a failed attempt and schedules the next one.
+	MarkRetry(ctx context.Context, id uuid.UUID, next time.Time, lastErr string) error
+	MarkDead(ctx context.Context, id uuid.UUID, lastErr string) error
+	// Requeue makes a message pending and due at once, whatever its state.
+	Requeue(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	RecordDelivery(ctx context.Context, d *Delivery) error
+	// GetDelivery returns ErrDeliveryNotFound for foreign or unknown ids.
+	GetDelivery(ctx context.Context, merchantID string, id uuid.UUID) (*Delivery, error)
+	ListDeliveries(ctx context.Context, merchantID string, filter DeliveryFilter) ([]*Delivery, *Cursor, error)
+}
+
+// Delivery is one attempt to post an outbox message. ResponseCode is zero when no
+// response was received.
+type Delivery struct {
+	ID           uuid.UUID
+	MessageID    uuid.UUID
+	MerchantID   string
+	EndpointID   *uuid.UUID
+	EventType    string
+	URL          string
+	RequestBody  []byte
+	ResponseCode int
+	Latency      time.Duration
+	Error        string
+	AttemptedAt  time.Time
+}
+
+func newDelivery(m *Message, url string, responseCode int, latency time.Duration, sendErr error) *Delivery {
+	d := &Delivery{
+		ID:           uuid.New(),
+		MessageID:    m.ID,
+		MerchantID:   m.MerchantID,
+		EndpointID:   m.EndpointID,
+		EventType:    m.EventType,
+		URL:          url,
+		RequestBody:  m.Payload,
+		ResponseCode: responseCode,
+		Latency:      latency,
+		AttemptedAt:  time.Now().UTC(),
+	}
+	if sendErr != nil {
+		d.Error = sendErr.Error()
+	}
+	return d
+}
+
+// DeliveryFilter narrows the delivery log; nil fields do not filter.
+type DeliveryFilter struct {
+	MessageID  *uuid.UUID
+	EndpointID *uuid.UUID
+	Cursor     *Cursor
+	Limit      int
+}
+
+// Cursor encodes keyset pagination position over (attempted_at, id).
+type Cursor struct {
+	AttemptedAt time.Time
+	ID          uuid.UUID
+}
@@ -0,0 +1,267 @@
+package outboxrepo
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"TestTaskJustPay/pkg/postgres"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var psql = sq.StatementBuilder.PlaceholderFormat(sq.Dollar)
+
+type PgOutboxRepo struct {
+	db postgres.Executor
+}
+
+func NewPgOutboxRepo(db postgres.Executor) *PgOutboxRepo {
+	return &PgOutboxRepo{db: db}
+}
+
+func (r *PgOutboxRepo) Enqueue(ctx context.Context, m *webhooksender.Message) error {
+	query, args, err := psql.
+		Insert("webhook_outbox").
+		Columns("id", "merchant_id", "event_type", "endpoint_id", "payload", "status", "attempts", "next_attempt_at", "created_at").
+		Values(m.ID, m.MerchantID, m.EventType, m.EndpointID, m.Payload, m.Status, m.Attempts, m.NextAttemptAt, m.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert outbox message: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec insert outbox message: %w", err)
+	}
+	return nil
+}
+
+func (r *PgOutboxRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*webhooksender.Message, error) {
+	const query = `WITH claimed AS (
+			UPDATE webhook_outbox
+			SET attempts = attempts + 1, next_attempt_at = $2
+			WHERE id IN (
+				SELECT id FROM webhook_outbox
+				WHERE status = 'pending' AND next_attempt_at <= $1
+				ORDER BY next_attempt_at
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, merchant_id, event_type, endpoint_id, payload, status, attempts, next_attempt_at,
+			          last_error, created_at, delivered_at
+		)
+		SELECT c.id, c.merchant_id, c.event_type, c.endpoint_id, c.payload, c.status, c.attempts, c.next_attempt_at,
+		       COALESCE(c.last_error, ''), c.created_at, c.delivered_at,
+		       COALESCE(e.url, ''), COALESCE(e.secret, ''), e.disabled_at IS NOT NULL
+		FROM claimed c
+		LEFT JOIN webhook_endpoints e ON e.id = c.endpoint_id`
+
+	rows, err := r.db.Query(ctx, query, now, now.Add(lease), limit)
+	if err != nil {
+		return nil, fmt.Errorf("claim outbox messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []*webhooksender.Message
+	for rows.Next() {
+		var m webhooksender.Message
+		if err := rows.Scan(&m.ID, &m.MerchantID, &m.EventType, &m.EndpointID, &m.Payload, &m.Status, &m.Attempts,
+			&m.NextAttemptAt, &m.LastError, &m.CreatedAt, &m.DeliveredAt, &m.URL, &m.Secret, &m.EndpointDisabled); err != nil {
+			return nil, fmt.Errorf("scan outbox message: %w", err)
+		}
+		messages = append(messages, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate outbox messages: %w", err)
+	}
+	return messages, nil
+}
+
+func (r *PgOutboxRepo) MarkDelivered(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.update(ctx, id, map[string]any{"status": webhooksender.MessageDelivered, "delivered_at": at, "last_error": nil})
+}
+
+func (r *PgOutboxRepo) MarkRetry(ctx context.Context, id uuid.UUID, next time.Time, lastErr string) error {
+	return r.update(ctx, id, map[string]any{"next_attempt_at": next, "last_error": lastErr})
+}
+
+func (r *PgOutboxRepo) MarkDead(ctx context.Context, id uuid.UUID, lastErr string) error {
+	return r.update(ctx, id, map[string]any{"status": webhooksender.MessageDead, "last_error": lastErr})
+}
+
+func (r *PgOutboxRepo) Requeue(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.update(ctx, id, map[string]any{"status": webhooksender.MessagePending, "next_attempt_at": at})
+}
+
+func (r *PgOutboxRepo) update(ctx context.Context, id uuid.UUID, set map[string]any) error {
+	query, args, err := psql.
+		Update("webhook_outbox").
+		SetMap(set).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build update outbox message: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec update outbox message: %w", err)
+	}
+	return nil
+}
+
+const deliveryColumns = "id, message_id, merchant_id, endpoint_id, event_type, url, request_body, " +
+	"response_code, latency_ms, error, attempted_at"
+
+func (r *PgOutboxRepo) RecordDelivery(ctx context.Context, d *webhooksender.Delivery) error {
+	var (
+		responseCode *int
+		errText      *string
+	)
+	if d.ResponseCode != 0 {
+		responseCode = &d.ResponseCode
+	}
+	if d.Error != "" {
+		errText = &d.Error
+	}
+
+	query, args, err := psql.
+		Insert("webhook_deliveries").
+		Columns("id", "message_id", "merchant_id", "endpoint_id", "event_type", "url", "request_body",
+			"response_code", "latency_ms", "error", "attempted_at").
+		Values(d.ID, d.MessageID, d.MerchantID, d.EndpointID, d.EventType, d.URL, d.RequestBody,
+			responseCode, d.Latency.Milliseconds(), errText, d.AttemptedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("build insert delivery: %w", err)
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("exec insert delivery: %w", err)
+	}
+	return nil
+}
+
+func (r *PgOutboxRepo) GetDelivery(ctx context.Context, merchantID string, id uuid.UUID) (*webhooksender.Delivery, error) {
+	query, args, err := psql.
+		Select(deliveryColumns).
+		From("webhook_deliveries").
+		Where(sq.Eq{"merchant_id": merchantID, "id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("build get delivery: %w", err)
+	}
+
+	d, err := scanDelivery(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, webhooksender.ErrDeliveryNotFound
+		}
+		return nil, fmt.Errorf("scan delivery: %w", err)
+	}
+	return d, nil
+}
+
+func (r *PgOutboxRepo) ListDeliveries(ctx context.Context, merchantID string, filter webhooksender.DeliveryFilter) ([]*webhooksender.Delivery, *webhooksender.Cursor, error) {
+	b := psql.
+		Select(deliveryColumns).
+		From("webhook_deliveries").
+		Where(sq.Eq{"merchant_id": merchantID}).
+		OrderBy("attempted_at DESC", "id DESC").
+		Limit(uint64(filter.Limit) + 1)
+
+	if filter.MessageID != nil {
+		b = b.Where(sq.Eq{"message_id": *filter.MessageID})
+	}
+	if filter.EndpointID != nil {
+		b = b.Where(sq.Eq{"endpoint_id": *filter.EndpointID})
+	}
+	if filter.Cursor != nil {
+		b = b.Where(sq.Expr("(attempted_at, id) < (?, ?)", filter.Cursor.AttemptedAt, filter.Cursor.ID))
+	}
+
+	query, args, err := b.ToSql()
+	if err != nil {
+		return nil, nil, fmt.Errorf("build list deliveries: %w", err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("exec list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*webhooksender.Delivery, 0, filter.Limit)
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("scan delivery row: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterate delivery rows: %w", err)
+	}
+
+	var next *webhooksender.Cursor
+	if len(deliveries) > filter.Limit {
+		last := deliveries[filter.Limit-1]
+		next = &webhooksender.Cursor{AttemptedAt: last.AttemptedAt, ID: last.ID}
+		deliveries = deliveries[:filter.Limit]
+	}
+	return deliveries, next, nil
+}
+
+func scanDelivery(row pgx.Row) (*webhooksender.Delivery, error) {
+	var (
+		d            webhooksender.Delivery
+		responseCode *int
+		latencyMs    int64
+		errText      *string
+	)
+	if err := row.Scan(
+		&d.ID, &d.MessageID, &d.MerchantID, &d.EndpointID, &d.EventType, &d.URL, &d.RequestBody,
+		&responseCode, &latencyMs, &errText, &d.AttemptedAt,
+	); err != nil {
+		return nil, err
+	}
+	if responseCode != nil {
+		d.ResponseCode = *responseCode
+	}
+	if errText != nil {
+		d.Error = *errText
+	}
+	d.Latency = time.Duration(latencyMs) * time.Millisecond
+	return &d, nil
+}
+
+// EncodeCursor serializes a cursor to a URL-safe base64 JSON token.
+// Handlers use this to publish next-page tokens to clients.
+func EncodeCursor(c webhooksender.Cursor) string {
+	payload := cursorPayload{AttemptedAt: c.AttemptedAt, ID: c.ID}
+	raw, _ := json.Marshal(payload)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a base64 JSON token produced by EncodeCursor.
+func DecodeCursor(token string) (*webhooksender.Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("decode cursor: %w", err)
+	}
+	var payload cursorPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("unmarshal cursor: %w", err)
+	}
+	return &webhooksender.Cursor{AttemptedAt: payload.AttemptedAt, ID: payload.ID}, nil
+}
+
+type cursorPayload struct {
+	AttemptedAt time.Time `json:"a"`
+	ID          uuid.UUID `json:"i"`
+}
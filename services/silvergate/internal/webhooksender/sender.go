@@ -1,14 +1,12 @@
 package webhooksender
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
-	"net/http"
 	"time"
 
+	"TestTaskJustPay/services/silvergate/internal/settlement"
 	"TestTaskJustPay/services/silvergate/internal/transaction"
 )
 
@@ -16,53 +14,103 @@ type Event struct {
 	Event         string `json:"event"`
 	TransactionID string `json:"transaction_id"`
 	RefundID      string `json:"refund_id,omitempty"`
+	CaptureID     string `json:"capture_id,omitempty"`
 	OrderID       string `json:"order_id"`
 	MerchantID    string `json:"merchant_id"`
 	Status        string `json:"status"`
 	Amount        int64  `json:"amount"`
-	Currency      string `json:"currency"`
-	Timestamp     string `json:"timestamp"`
+	// CapturedAmount is the running total captured against the authorization.
+	CapturedAmount int64  `json:"captured_amount"`
+	FinalCapture   bool   `json:"final_capture,omitempty"`
+	Fee            int64  `json:"fee,omitempty"`
+	Currency       string `json:"currency"`
+	DeclineReason  string `json:"decline_reason,omitempty"`
+	Timestamp      string `json:"timestamp"`
 }
 
+// PayoutEvent reports a payout of a settlement batch.
+type PayoutEvent struct {
+	Event          string `json:"event"`
+	PayoutID       string `json:"payout_id"`
+	BatchID        string `json:"batch_id"`
+	MerchantID     string `json:"merchant_id"`
+	Status         string `json:"status"`
+	Amount         int64  `json:"amount"`
+	CapturedAmount int64  `json:"captured_amount"`
+	RefundedAmount int64  `json:"refunded_amount"`
+	FeeAmount      int64  `json:"fee_amount"`
+	Currency       string `json:"currency"`
+	Cutoff         string `json:"cutoff"`
+	PaidAt         string `json:"paid_at"`
+	Timestamp      string `json:"timestamp"`
+}
+
+// Sender writes webhook events to the outbox. Bound to the DB transaction of a state
+// change, the event commits or rolls back with it; the DeliveryWorker posts it later.
 type Sender struct {
-	callbackURL string
-	client      *http.Client
-	log         *slog.Logger
+	outbox    OutboxRepo
+	endpoints EndpointRepo
 }
 
-func NewSender(callbackURL string, log *slog.Logger) *Sender {
-	return &Sender{
-		callbackURL: callbackURL,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-		log: log,
-	}
+func NewSender(outbox OutboxRepo, endpoints EndpointRepo) *Sender {
+	return &Sender{outbox: outbox, endpoints: endpoints}
 }
 
-func (s *Sender) SendCaptureResult(ctx context.Context, tx *transaction.Transaction) error {
+func (s *Sender) SendCaptureResult(ctx context.Context, tx *transaction.Transaction, capture *transaction.Capture) error {
 	var eventName string
-	switch tx.Status {
-	case transaction.StatusCaptured:
-		eventName = "transaction.captured"
-	case transaction.StatusCaptureFailed:
+	switch {
+	case capture != nil && capture.Status == transaction.CaptureStatusFailed:
 		eventName = "transaction.capture_failed"
-	case transaction.StatusVoided:
+	case tx.Status == transaction.StatusCaptured:
+		eventName = "transaction.captured"
+	case tx.Status == transaction.StatusPartiallyCaptured:
+		eventName = "transaction.partially_captured"
+	case tx.Status == transaction.StatusVoided:
 		eventName = "transaction.voided"
+	case tx.Status == transaction.StatusExpired:
+		eventName = "transaction.expired"
 	default:
 		eventName = "transaction." + string(tx.Status)
 	}
 
-	return s.sendEvent(ctx, Event{
-		Event:         eventName,
-		TransactionID: tx.ID.String(),
-		OrderID:       tx.OrderRef,
-		MerchantID:    tx.MerchantID,
-		Status:        string(tx.Status),
-		Amount:        tx.Amount,
-		Currency:      tx.Currency,
-		Timestamp:     time.Now().UTC().Format(time.RFC3339),
-	})
+	evt := Event{
+		Event:          eventName,
+		TransactionID:  tx.ID.String(),
+		OrderID:        tx.OrderRef,
+		MerchantID:     tx.MerchantID,
+		Status:         string(tx.Status),
+		Amount:         tx.Amount,
+		CapturedAmount: tx.CapturedAmount,
+		Currency:       tx.Currency,
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+	}
+	if capture != nil {
+		evt.CaptureID = capture.ID.String()
+		evt.Amount = capture.Amount
+		evt.Fee = capture.Fee
+		evt.FinalCapture = tx.Status == transaction.StatusCaptured
+	}
+
+	return s.sendEvent(ctx, evt)
+}
+
+// SendAuthorizationResult reports the outcome of an authorization that was held in
+// requires_action until the cardholder completed a challenge.
+func (s *Sender) SendAuthorizationResult(ctx context.Context, tx *transaction.Transaction) error {
+	evt := Event{
+		Event:          "transaction." + string(tx.Status),
+		TransactionID:  tx.ID.String(),
+		OrderID:        tx.OrderRef,
+		MerchantID:     tx.MerchantID,
+		Status:         string(tx.Status),
+		Amount:         tx.Amount,
+		CapturedAmount: tx.CapturedAmount,
+		Currency:       tx.Currency,
+		DeclineReason:  tx.DeclineReason,
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+	}
+
+	return s.sendEvent(ctx, evt)
 }
 
 func (s *Sender) SendRefundResult(ctx context.Context, tx *transaction.Transaction, refund *transaction.Refund) error {
@@ -72,47 +120,75 @@ func (s *Sender) SendRefundResult(ctx context.Context, tx *transaction.Transacti
 	}
 
 	evt := Event{
-		Event:         eventName,
-		TransactionID: tx.ID.String(),
-		RefundID:      refund.ID.String(),
-		OrderID:       tx.OrderRef,
-		MerchantID:    tx.MerchantID,
-		Status:        string(refund.Status),
-		Amount:        refund.Amount,
-		Currency:      tx.Currency,
-		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		Event:          eventName,
+		TransactionID:  tx.ID.String(),
+		RefundID:       refund.ID.String(),
+		OrderID:        tx.OrderRef,
+		MerchantID:     tx.MerchantID,
+		Status:         string(refund.Status),
+		Amount:         refund.Amount,
+		CapturedAmount: tx.CapturedAmount,
+		Fee:            refund.Fee,
+		Currency:       tx.Currency,
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
 	}
 
 	return s.sendEvent(ctx, evt)
 }
 
+// SendPayoutPaid tells the merchant a payout was made. Payouts are not transaction events,
+// so they only go to merchant endpoints, never to the platform callback.
+func (s *Sender) SendPayoutPaid(ctx context.Context, p *settlement.Payout) error {
+	evt := PayoutEvent{
+		Event:          "payout.paid",
+		PayoutID:       p.ID.String(),
+		BatchID:        p.BatchID.String(),
+		MerchantID:     p.MerchantID,
+		Status:         string(p.Status),
+		Amount:         p.Amount,
+		CapturedAmount: p.CapturedAmount,
+		RefundedAmount: p.RefundedAmount,
+		FeeAmount:      p.FeeAmount,
+		Currency:       p.Currency,
+		Cutoff:         p.Cutoff.UTC().Format(time.RFC3339),
+		PaidAt:         p.PaidAt.UTC().Format(time.RFC3339),
+		Timestamp:      time.Now().UTC().Format(time.RFC3339),
+	}
+
+	return s.enqueue(ctx, evt.MerchantID, evt.Event, evt, false)
+}
+
 func (s *Sender) sendEvent(ctx context.Context, evt Event) error {
-	body, err := json.Marshal(evt)
+	return s.enqueue(ctx, evt.MerchantID, evt.Event, evt, true)
+}
+
+// enqueue writes payload to the outbox once per merchant endpoint subscribed to the event
+// and, when platform is set, once more for the platform callback URL.
+func (s *Sender) enqueue(ctx context.Context, merchantID, eventType string, payload any, platform bool) error {
+	body, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("marshal webhook: %w", err)
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.callbackURL, bytes.NewReader(body))
+	endpoints, err := s.endpoints.List(ctx, merchantID)
 	if err != nil {
-		return fmt.Errorf("create webhook request: %w", err)
+		return fmt.Errorf("list webhook endpoints: %w", err)
 	}
-	req.Header.Set("Content-Type", "application/json")
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("send webhook: %w", err)
+	if platform {
+		if err := s.outbox.Enqueue(ctx, NewMessage(merchantID, eventType, body)); err != nil {
+			return fmt.Errorf("enqueue webhook: %w", err)
+		}
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode/100 != 2 {
-		return fmt.Errorf("webhook rejected: status %d", resp.StatusCode)
+	for _, e := range endpoints {
+		if !e.Subscribes(eventType) {
+			continue
+		}
+		m := NewMessage(merchantID, eventType, body)
+		m.EndpointID = &e.ID
+		if err := s.outbox.Enqueue(ctx, m); err != nil {
+			return fmt.Errorf("enqueue webhook for endpoint %s: %w", e.ID, err)
+		}
 	}
-
-	s.log.Info("webhook sent",
-		"event", evt.Event,
-		"transaction_id", evt.TransactionID,
-		"callback_url", s.callbackURL,
-	)
-
 	return nil
 }
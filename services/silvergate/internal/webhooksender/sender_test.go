@@ -0,0 +1,139 @@
+package webhooksender
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/settlement"
+	"TestTaskJustPay/services/silvergate/internal/transaction"
+
+	"github.com/google/uuid"
+)
+
+type fakeEndpoints struct {
+	endpoints []*Endpoint
+}
+
+func (f *fakeEndpoints) Create(_ context.Context, e *Endpoint) error {
+	f.endpoints = append(f.endpoints, e)
+	return nil
+}
+
+func (f *fakeEndpoints) List(_ context.Context, merchantID string) ([]*Endpoint, error) {
+	var out []*Endpoint
+	for _, e := range f.endpoints {
+		if e.MerchantID == merchantID && e.DisabledAt == nil {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeEndpoints) Disable(_ context.Context, merchantID string, id uuid.UUID, at time.Time) error {
+	for _, e := range f.endpoints {
+		if e.ID == id && e.MerchantID == merchantID && e.DisabledAt == nil {
+			e.DisabledAt = &at
+			return nil
+		}
+	}
+	return ErrEndpointNotFound
+}
+
+func voidedTx() *transaction.Transaction {
+	return &transaction.Transaction{ID: uuid.New(), MerchantID: "m1", Status: transaction.StatusVoided, Amount: 5000, Currency: "USD"}
+}
+
+func TestSender_QueuesEventInOutbox(t *testing.T) {
+	outbox := newFakeOutbox()
+	tx := voidedTx()
+
+	if err := NewSender(outbox, &fakeEndpoints{}).SendCaptureResult(context.Background(), tx, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outbox.messages) != 1 {
+		t.Fatalf("want 1 message, got %d", len(outbox.messages))
+	}
+	for _, m := range outbox.messages {
+		if m.MerchantID != "m1" || m.EventType != "transaction.voided" || m.Status != MessagePending || m.EndpointID != nil {
+			t.Errorf("unexpected message %+v", m)
+		}
+		var evt Event
+		if err := json.Unmarshal(m.Payload, &evt); err != nil {
+			t.Fatal(err)
+		}
+		if evt.TransactionID != tx.ID.String() {
+			t.Errorf("want transaction %s, got %s", tx.ID, evt.TransactionID)
+		}
+	}
+}
+
+func TestSender_FansOutToSubscribedEndpoints(t *testing.T) {
+	outbox := newFakeOutbox()
+	all := &Endpoint{ID: uuid.New(), MerchantID: "m1"}
+	voids := &Endpoint{ID: uuid.New(), MerchantID: "m1", EventTypes: []string{"transaction.voided"}}
+	refunds := &Endpoint{ID: uuid.New(), MerchantID: "m1", EventTypes: []string{"transaction.refunded"}}
+	endpoints := &fakeEndpoints{endpoints: []*Endpoint{all, voids, refunds}}
+
+	if err := NewSender(outbox, endpoints).SendCaptureResult(context.Background(), voidedTx(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[uuid.UUID]bool{}
+	callbacks := 0
+	for _, m := range outbox.messages {
+		if m.EndpointID == nil {
+			callbacks++
+			continue
+		}
+		got[*m.EndpointID] = true
+	}
+	if callbacks != 1 {
+		t.Errorf("want one platform callback message alongside endpoint messages, got %d", callbacks)
+	}
+	if len(got) != 2 || !got[all.ID] || !got[voids.ID] {
+		t.Errorf("want one message for each subscribed endpoint, got %v", got)
+	}
+}
+
+func TestSender_CallbackOnlyWhenNoEndpointSubscribes(t *testing.T) {
+	outbox := newFakeOutbox()
+	endpoints := &fakeEndpoints{endpoints: []*Endpoint{{ID: uuid.New(), MerchantID: "m1", EventTypes: []string{"payout.paid"}}}}
+
+	if err := NewSender(outbox, endpoints).SendCaptureResult(context.Background(), voidedTx(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outbox.messages) != 1 {
+		t.Fatalf("want only the platform callback message, got %d", len(outbox.messages))
+	}
+	for _, m := range outbox.messages {
+		if m.EndpointID != nil {
+			t.Errorf("want the platform callback message, got endpoint %s", m.EndpointID)
+		}
+	}
+}
+
+func TestSender_PayoutGoesOnlyToMerchantEndpoints(t *testing.T) {
+	outbox := newFakeOutbox()
+	payouts := &Endpoint{ID: uuid.New(), MerchantID: "m1", EventTypes: []string{"payout.paid"}}
+	voids := &Endpoint{ID: uuid.New(), MerchantID: "m1", EventTypes: []string{"transaction.voided"}}
+	endpoints := &fakeEndpoints{endpoints: []*Endpoint{payouts, voids}}
+	now := time.Now()
+	payout := &settlement.Payout{ID: uuid.New(), BatchID: uuid.New(), MerchantID: "m1", Currency: "USD", Amount: 4000, Status: settlement.PayoutStatusPaid, Cutoff: now, PaidAt: now}
+
+	if err := NewSender(outbox, endpoints).SendPayoutPaid(context.Background(), payout); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(outbox.messages) != 1 {
+		t.Fatalf("want only the subscribed endpoint message, got %d", len(outbox.messages))
+	}
+	for _, m := range outbox.messages {
+		if m.EndpointID == nil || *m.EndpointID != payouts.ID {
+			t.Errorf("want the payout endpoint message, got endpoint %v", m.EndpointID)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package webhooksender
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// Service lets merchants manage their webhook endpoints and inspect and replay
+// deliveries.
+type Service struct {
+	endpoints EndpointRepo
+	outbox    OutboxRepo
+}
+
+func NewService(endpoints EndpointRepo, outbox OutboxRepo) *Service {
+	return &Service{endpoints: endpoints, outbox: outbox}
+}
+
+// CreateEndpoint registers url for the given event types, or for every event when
+// eventTypes is empty. Events already queued are not fanned out to the new endpoint.
+func (s *Service) CreateEndpoint(ctx context.Context, merchantID, url string, eventTypes []string) (*Endpoint, error) {
+	e, err := NewEndpoint(merchantID, url, eventTypes)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.endpoints.Create(ctx, e); err != nil {
+		return nil, fmt.Errorf("create endpoint: %w", err)
+	}
+	return e, nil
+}
+
+func (s *Service) ListEndpoints(ctx context.Context, merchantID string) ([]*Endpoint, error) {
+	return s.endpoints.List(ctx, merchantID)
+}
+
+// DisableEndpoint stops new events going to the endpoint; messages already queued
+// for it are dropped by the DeliveryWorker.
+func (s *Service) DisableEndpoint(ctx context.Context, merchantID string, id uuid.UUID) error {
+	return s.endpoints.Disable(ctx, merchantID, id, time.Now().UTC())
+}
+
+func (s *Service) ListDeliveries(ctx context.Context, merchantID string, filter DeliveryFilter) ([]*Delivery, *Cursor, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultListLimit
+	}
+	if filter.Limit > maxListLimit {
+		return nil, nil, ErrLimitTooLarge
+	}
+	return s.outbox.ListDeliveries(ctx, merchantID, filter)
+}
+
+// Redeliver queues the message of the given delivery for another attempt. The new
+// attempt is recorded as a delivery of its own. A message past the max age gets a
+// single attempt and is dead again if it fails.
+func (s *Service) Redeliver(ctx context.Context, merchantID string, deliveryID uuid.UUID) (*Delivery, error) {
+	d, err := s.outbox.GetDelivery(ctx, merchantID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.outbox.Requeue(ctx, d.MessageID, time.Now().UTC()); err != nil {
+		return nil, fmt.Errorf("requeue message: %w", err)
+	}
+	return d, nil
+}
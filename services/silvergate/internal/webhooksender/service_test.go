@@ -0,0 +1,88 @@
+package webhooksender
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCreateEndpoint(t *testing.T) {
+	endpoints := &fakeEndpoints{}
+	svc := NewService(endpoints, newFakeOutbox())
+
+	e, err := svc.CreateEndpoint(context.Background(), "m1", "https://merchant.example/hooks", []string{"transaction.captured"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(e.Secret, "whsec_") || len(endpoints.endpoints) != 1 {
+		t.Errorf("want a stored endpoint with a signing secret, got %+v", e)
+	}
+	if !e.Subscribes("transaction.captured") || e.Subscribes("transaction.voided") {
+		t.Errorf("want only the requested subscription, got %v", e.EventTypes)
+	}
+}
+
+func TestCreateEndpoint_Validates(t *testing.T) {
+	svc := NewService(&fakeEndpoints{}, newFakeOutbox())
+
+	for name, tc := range map[string]struct {
+		url        string
+		eventTypes []string
+		want       error
+	}{
+		"relative url":       {url: "/hooks", want: ErrInvalidEndpointURL},
+		"unsupported scheme": {url: "ftp://merchant.example", want: ErrInvalidEndpointURL},
+		"unknown event":      {url: "https://merchant.example", eventTypes: []string{"order.created"}, want: ErrUnknownEventType},
+	} {
+		if _, err := svc.CreateEndpoint(context.Background(), "m1", tc.url, tc.eventTypes); !errors.Is(err, tc.want) {
+			t.Errorf("%s: want %v, got %v", name, tc.want, err)
+		}
+	}
+}
+
+func TestRedeliver_RequeuesMessage(t *testing.T) {
+	outbox := newFakeOutbox()
+	m := NewMessage("m1", "transaction.captured", []byte(`{}`))
+	m.Status = MessageDead
+	outbox.messages[m.ID] = m
+	d := newDelivery(m, "https://merchant.example", 500, time.Millisecond, errors.New("webhook rejected: status 500"))
+	outbox.deliveries = append(outbox.deliveries, d)
+	svc := NewService(&fakeEndpoints{}, outbox)
+
+	if _, err := svc.Redeliver(context.Background(), "m1", d.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Status != MessagePending || m.NextAttemptAt.After(time.Now()) {
+		t.Errorf("want the message pending and due, got %s at %s", m.Status, m.NextAttemptAt)
+	}
+}
+
+func TestRedeliver_ForeignDelivery(t *testing.T) {
+	outbox := newFakeOutbox()
+	m := NewMessage("m1", "transaction.captured", []byte(`{}`))
+	outbox.messages[m.ID] = m
+	d := newDelivery(m, "https://merchant.example", 200, time.Millisecond, nil)
+	outbox.deliveries = append(outbox.deliveries, d)
+	svc := NewService(&fakeEndpoints{}, outbox)
+
+	if _, err := svc.Redeliver(context.Background(), "m2", d.ID); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("want ErrDeliveryNotFound, got %v", err)
+	}
+	if _, err := svc.Redeliver(context.Background(), "m1", uuid.New()); !errors.Is(err, ErrDeliveryNotFound) {
+		t.Errorf("want ErrDeliveryNotFound, got %v", err)
+	}
+}
+
+func TestListDeliveries_LimitTooLarge(t *testing.T) {
+	svc := NewService(&fakeEndpoints{}, newFakeOutbox())
+
+	if _, _, err := svc.ListDeliveries(context.Background(), "m1", DeliveryFilter{Limit: maxListLimit + 1}); !errors.Is(err, ErrLimitTooLarge) {
+		t.Errorf("want ErrLimitTooLarge, got %v", err)
+	}
+}
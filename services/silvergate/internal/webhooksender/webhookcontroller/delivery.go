@@ -0,0 +1,177 @@
+package webhookcontroller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/merchantauth"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender/outboxrepo"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type deliveryResponse struct {
+	ID           string          `json:"id"`
+	MessageID    string          `json:"message_id"`
+	EndpointID   *string         `json:"endpoint_id"`
+	EventType    string          `json:"event_type"`
+	URL          string          `json:"url"`
+	RequestBody  json.RawMessage `json:"request_body"`
+	ResponseCode *int            `json:"response_code"`
+	LatencyMs    int64           `json:"latency_ms"`
+	Error        string          `json:"error,omitempty"`
+	AttemptedAt  string          `json:"attempted_at"`
+}
+
+type listDeliveriesResponse struct {
+	Items      []deliveryResponse `json:"items"`
+	NextCursor *string            `json:"next_cursor"`
+}
+
+type redeliverResponse struct {
+	MessageID string `json:"message_id"`
+	Status    string `json:"status"`
+}
+
+func toDeliveryResponse(d *webhooksender.Delivery) deliveryResponse {
+	resp := deliveryResponse{
+		ID:          d.ID.String(),
+		MessageID:   d.MessageID.String(),
+		EventType:   d.EventType,
+		URL:         d.URL,
+		RequestBody: d.RequestBody,
+		LatencyMs:   d.Latency.Milliseconds(),
+		Error:       d.Error,
+		AttemptedAt: d.AttemptedAt.UTC().Format(time.RFC3339),
+	}
+	if d.EndpointID != nil {
+		id := d.EndpointID.String()
+		resp.EndpointID = &id
+	}
+	if d.ResponseCode != 0 {
+		code := d.ResponseCode
+		resp.ResponseCode = &code
+	}
+	return resp
+}
+
+type ListDeliveriesHandler struct {
+	svc *webhooksender.Service
+}
+
+func NewListDeliveriesHandler(svc *webhooksender.Service) *ListDeliveriesHandler {
+	return &ListDeliveriesHandler{svc: svc}
+}
+
+func (h *ListDeliveriesHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "merchant context missing"})
+		return
+	}
+
+	filter, err := parseDeliveryFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	deliveries, next, err := h.svc.ListDeliveries(c.Request.Context(), merchantID, filter)
+	if err != nil {
+		if errors.Is(err, webhooksender.ErrLimitTooLarge) {
+			c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "internal error"})
+		return
+	}
+
+	resp := listDeliveriesResponse{Items: make([]deliveryResponse, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Items = append(resp.Items, toDeliveryResponse(d))
+	}
+	if next != nil {
+		token := outboxrepo.EncodeCursor(*next)
+		resp.NextCursor = &token
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func parseDeliveryFilter(c *gin.Context) (webhooksender.DeliveryFilter, error) {
+	var f webhooksender.DeliveryFilter
+
+	if raw := c.Query("message_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return f, errors.New("invalid message_id")
+		}
+		f.MessageID = &id
+	}
+
+	if raw := c.Query("endpoint_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return f, errors.New("invalid endpoint_id")
+		}
+		f.EndpointID = &id
+	}
+
+	if raw := c.Query("cursor"); raw != "" {
+		cur, err := outboxrepo.DecodeCursor(raw)
+		if err != nil {
+			return f, errors.New("invalid cursor")
+		}
+		f.Cursor = cur
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return f, errors.New("invalid limit")
+		}
+		f.Limit = n
+	}
+
+	return f, nil
+}
+
+type RedeliverHandler struct {
+	svc *webhooksender.Service
+}
+
+func NewRedeliverHandler(svc *webhooksender.Service) *RedeliverHandler {
+	return &RedeliverHandler{svc: svc}
+}
+
+// Handle queues the delivery's message for another attempt; the attempt shows up
+// in the delivery log once the worker has made it.
+func (h *RedeliverHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "merchant context missing"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid delivery id"})
+		return
+	}
+
+	d, err := h.svc.Redeliver(c.Request.Context(), merchantID, id)
+	if err != nil {
+		if errors.Is(err, webhooksender.ErrDeliveryNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "internal error"})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, redeliverResponse{MessageID: d.MessageID.String(), Status: string(webhooksender.MessagePending)})
+}
@@ -0,0 +1,146 @@
+package webhookcontroller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"TestTaskJustPay/services/silvergate/internal/merchantauth"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+type createEndpointRequest struct {
+	URL        string   `json:"url" binding:"required"`
+	EventTypes []string `json:"event_types"`
+}
+
+// endpointResponse omits the signing secret except in the create response.
+type endpointResponse struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	Secret     string   `json:"secret,omitempty"`
+	CreatedAt  string   `json:"created_at"`
+}
+
+type listEndpointsResponse struct {
+	Items []endpointResponse `json:"items"`
+}
+
+func toEndpointResponse(e *webhooksender.Endpoint) endpointResponse {
+	eventTypes := e.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return endpointResponse{
+		ID:         e.ID.String(),
+		URL:        e.URL,
+		EventTypes: eventTypes,
+		CreatedAt:  e.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+type CreateEndpointHandler struct {
+	svc *webhooksender.Service
+}
+
+func NewCreateEndpointHandler(svc *webhooksender.Service) *CreateEndpointHandler {
+	return &CreateEndpointHandler{svc: svc}
+}
+
+func (h *CreateEndpointHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "merchant context missing"})
+		return
+	}
+
+	var req createEndpointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+
+	e, err := h.svc.CreateEndpoint(c.Request.Context(), merchantID, req.URL, req.EventTypes)
+	if err != nil {
+		switch {
+		case errors.Is(err, webhooksender.ErrInvalidEndpointURL), errors.Is(err, webhooksender.ErrUnknownEventType):
+			c.JSON(http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, errorResponse{Error: "internal error"})
+		}
+		return
+	}
+
+	resp := toEndpointResponse(e)
+	resp.Secret = e.Secret
+	c.JSON(http.StatusCreated, resp)
+}
+
+type ListEndpointsHandler struct {
+	svc *webhooksender.Service
+}
+
+func NewListEndpointsHandler(svc *webhooksender.Service) *ListEndpointsHandler {
+	return &ListEndpointsHandler{svc: svc}
+}
+
+func (h *ListEndpointsHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "merchant context missing"})
+		return
+	}
+
+	endpoints, err := h.svc.ListEndpoints(c.Request.Context(), merchantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "internal error"})
+		return
+	}
+
+	resp := listEndpointsResponse{Items: make([]endpointResponse, 0, len(endpoints))}
+	for _, e := range endpoints {
+		resp.Items = append(resp.Items, toEndpointResponse(e))
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+type DisableEndpointHandler struct {
+	svc *webhooksender.Service
+}
+
+func NewDisableEndpointHandler(svc *webhooksender.Service) *DisableEndpointHandler {
+	return &DisableEndpointHandler{svc: svc}
+}
+
+func (h *DisableEndpointHandler) Handle(c *gin.Context) {
+	merchantID, ok := merchantauth.FromContext(c.Request.Context())
+	if !ok {
+		c.JSON(http.StatusUnauthorized, errorResponse{Error: "merchant context missing"})
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse{Error: "invalid endpoint id"})
+		return
+	}
+
+	if err := h.svc.DisableEndpoint(c.Request.Context(), merchantID, id); err != nil {
+		if errors.Is(err, webhooksender.ErrEndpointNotFound) {
+			c.JSON(http.StatusNotFound, errorResponse{Error: err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, errorResponse{Error: "internal error"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
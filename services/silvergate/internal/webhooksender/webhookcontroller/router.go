@@ -0,0 +1,29 @@
+package webhookcontroller
+
+import (
+	"TestTaskJustPay/services/silvergate/internal/webhooksender"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterEndpointRoutes mounts the webhook endpoint registry on rg.
+// Callers wire the merchant-auth middleware on rg before calling this.
+func RegisterEndpointRoutes(rg *gin.RouterGroup, svc *webhooksender.Service) {
+	create := NewCreateEndpointHandler(svc)
+	list := NewListEndpointsHandler(svc)
+	disable := NewDisableEndpointHandler(svc)
+
+	rg.POST("", create.Handle)
+	rg.GET("", list.Handle)
+	rg.DELETE("/:id", disable.Handle)
+}
+
+// RegisterDeliveryRoutes mounts the delivery log on rg.
+// Callers wire the merchant-auth middleware on rg before calling this.
+func RegisterDeliveryRoutes(rg *gin.RouterGroup, svc *webhooksender.Service) {
+	list := NewListDeliveriesHandler(svc)
+	redeliver := NewRedeliverHandler(svc)
+
+	rg.GET("", list.Handle)
+	rg.POST("/:id/redeliver", redeliver.Handle)
+}
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Track how much of the authorization has been settled. Refunds are bounded by
+-- captured_amount; the remainder of a final partial capture is released.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS captured_amount BIGINT NOT NULL DEFAULT 0
+    CONSTRAINT chk_captured_amount_range CHECK (captured_amount >= 0 AND captured_amount <= amount);
+
+UPDATE transactions SET captured_amount = amount
+WHERE status IN ('captured', 'partially_refunded', 'refunded');
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'partially_captured', 'captured', 'capture_failed',
+                      'voided', 'partially_refunded', 'refunded'));
+
+CREATE TABLE captures (
+    id              UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id  UUID NOT NULL REFERENCES transactions(id),
+    amount          BIGINT NOT NULL CHECK (amount > 0),
+    final           BOOLEAN NOT NULL,
+    status          TEXT NOT NULL CHECK (status IN ('capture_pending', 'captured', 'capture_failed')),
+    idempotency_key TEXT NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_captures_idempotency ON captures(transaction_id, idempotency_key);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS captures;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'captured', 'capture_failed', 'voided', 'partially_refunded', 'refunded'));
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS captured_amount;
+
+-- +goose StatementEnd
@@ -0,0 +1,51 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Authorization holds expire after a validity window. The window is resolved per
+-- merchant and currency at authorization time; a NULL currency row applies to
+-- every currency of the merchant, and the service default applies otherwise.
+CREATE TABLE auth_validity_windows (
+    merchant_id      TEXT NOT NULL,
+    currency         TEXT CHECK (currency IS NULL OR length(currency) = 3),
+    validity_seconds INTEGER NOT NULL CHECK (validity_seconds > 0),
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_auth_validity_windows_merchant_currency
+    ON auth_validity_windows(merchant_id, COALESCE(currency, ''));
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+-- Existing holds get the default 7 day window.
+UPDATE transactions SET expires_at = created_at + interval '7 days'
+WHERE status IN ('authorized', 'partially_captured', 'capture_failed');
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'partially_captured', 'captured', 'capture_failed',
+                      'voided', 'expired', 'partially_refunded', 'refunded'));
+
+CREATE INDEX idx_transactions_hold_expires_at
+    ON transactions(expires_at)
+    WHERE status IN ('authorized', 'partially_captured', 'capture_failed');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX IF EXISTS idx_transactions_hold_expires_at;
+
+UPDATE transactions SET status = 'voided' WHERE status = 'expired';
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'partially_captured', 'captured', 'capture_failed',
+                      'voided', 'partially_refunded', 'refunded'));
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS expires_at;
+
+DROP TABLE IF EXISTS auth_validity_windows;
+
+-- +goose StatementEnd
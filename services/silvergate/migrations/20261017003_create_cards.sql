@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Vaulted cards. The PAN is only stored AES-GCM encrypted under key_id; the CVC is never stored.
+CREATE TABLE cards (
+    token          TEXT PRIMARY KEY,
+    merchant_id    TEXT NOT NULL,
+    brand          TEXT NOT NULL,
+    last4          TEXT NOT NULL CHECK (length(last4) = 4),
+    exp_month      SMALLINT NOT NULL CHECK (exp_month BETWEEN 1 AND 12),
+    exp_year       SMALLINT NOT NULL,
+    fingerprint    TEXT NOT NULL,
+    key_id         TEXT NOT NULL,
+    pan_ciphertext BYTEA NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_cards_key_id ON cards(key_id);
+CREATE INDEX idx_cards_merchant_fingerprint ON cards(merchant_id, fingerprint);
+
+-- Transactions authorized with vault tokens record the card fingerprint; older rows have none.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS card_fingerprint TEXT;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS card_fingerprint;
+DROP TABLE IF EXISTS cards;
+
+-- +goose StatementEnd
@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Authorizations that need strong customer authentication wait in requires_action
+-- until the cardholder completes the challenge or it lapses at challenge_expires_at.
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS challenge_id UUID UNIQUE;
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS challenge_expires_at TIMESTAMPTZ;
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('requires_action', 'authorized', 'declined', 'capture_pending', 'partially_captured', 'captured',
+                      'capture_failed', 'voided', 'expired', 'partially_refunded', 'refunded'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+UPDATE transactions SET status = 'declined', decline_reason = 'authentication_expired'
+WHERE status = 'requires_action';
+
+ALTER TABLE transactions DROP CONSTRAINT IF EXISTS transactions_status_check;
+ALTER TABLE transactions ADD CONSTRAINT transactions_status_check
+    CHECK (status IN ('authorized', 'declined', 'capture_pending', 'partially_captured', 'captured', 'capture_failed',
+                      'voided', 'expired', 'partially_refunded', 'refunded'));
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS challenge_expires_at;
+ALTER TABLE transactions DROP COLUMN IF EXISTS challenge_id;
+
+-- +goose StatementEnd
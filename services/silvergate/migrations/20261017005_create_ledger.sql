@@ -0,0 +1,57 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Double-entry journal. Each entry records one transaction state change; its postings move
+-- money between the merchant's accounts (pending, available, fees, refunds_reserve) and the
+-- acquirer account, and always sum to zero. An account's balance is the sum of its postings.
+CREATE TABLE ledger_entries (
+    id             UUID PRIMARY KEY,
+    merchant_id    TEXT NOT NULL,
+    currency       TEXT NOT NULL CHECK (length(currency) = 3),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    kind           TEXT NOT NULL CHECK (kind IN ('authorization', 'capture', 'release', 'refund',
+                                                 'refund_settled', 'refund_reversal')),
+    reference_id   UUID,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_ledger_entries_transaction ON ledger_entries (transaction_id);
+
+CREATE TABLE ledger_postings (
+    id           BIGSERIAL PRIMARY KEY,
+    entry_id     UUID NOT NULL REFERENCES ledger_entries(id),
+    merchant_id  TEXT NOT NULL,
+    currency     TEXT NOT NULL CHECK (length(currency) = 3),
+    account_type TEXT NOT NULL CHECK (account_type IN ('pending', 'available', 'fees', 'refunds_reserve', 'acquirer')),
+    amount       BIGINT NOT NULL CHECK (amount <> 0),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_ledger_postings_entry ON ledger_postings (entry_id);
+CREATE INDEX idx_ledger_postings_account ON ledger_postings (merchant_id, currency, account_type);
+
+-- The journal is append-only: corrections are new entries.
+CREATE FUNCTION ledger_reject_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER ledger_entries_immutable
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+
+CREATE TRIGGER ledger_postings_immutable
+    BEFORE UPDATE OR DELETE ON ledger_postings
+    FOR EACH ROW EXECUTE FUNCTION ledger_reject_change();
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS ledger_entries;
+DROP FUNCTION IF EXISTS ledger_reject_change();
+
+-- +goose StatementEnd
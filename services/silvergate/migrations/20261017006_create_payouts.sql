@@ -0,0 +1,77 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Payouts are ledger entries too; they belong to a merchant, not a transaction.
+ALTER TABLE ledger_entries ALTER COLUMN transaction_id DROP NOT NULL;
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_kind_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_kind_check
+    CHECK (kind IN ('authorization', 'capture', 'release', 'refund', 'refund_settled', 'refund_reversal',
+                    'payout'));
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_transaction_check
+    CHECK ((kind = 'payout') = (transaction_id IS NULL));
+
+CREATE INDEX idx_ledger_entries_merchant_created ON ledger_entries (merchant_id, currency, created_at);
+
+-- One settlement batch per cutoff: rerunning the job for a cutoff reuses its batch.
+CREATE TABLE settlement_batches (
+    id           UUID PRIMARY KEY,
+    cutoff       TIMESTAMPTZ NOT NULL UNIQUE,
+    status       TEXT NOT NULL CHECK (status IN ('open', 'completed')),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE TABLE payouts (
+    id              UUID PRIMARY KEY,
+    batch_id        UUID NOT NULL REFERENCES settlement_batches(id),
+    merchant_id     TEXT NOT NULL,
+    currency        TEXT NOT NULL CHECK (length(currency) = 3),
+    amount          BIGINT NOT NULL CHECK (amount > 0),
+    captured_amount BIGINT NOT NULL,
+    refunded_amount BIGINT NOT NULL,
+    fee_amount      BIGINT NOT NULL,
+    status          TEXT NOT NULL CHECK (status IN ('paid')),
+    paid_at         TIMESTAMPTZ NOT NULL,
+    notified_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (batch_id, merchant_id, currency)
+);
+
+CREATE INDEX idx_payouts_not_notified ON payouts (created_at) WHERE notified_at IS NULL;
+
+-- A ledger entry is paid out at most once, whichever batch picks it up.
+CREATE TABLE payout_items (
+    id             BIGSERIAL PRIMARY KEY,
+    payout_id      UUID NOT NULL REFERENCES payouts(id),
+    entry_id       UUID NOT NULL UNIQUE REFERENCES ledger_entries(id),
+    transaction_id UUID,
+    kind           TEXT NOT NULL,
+    amount         BIGINT NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_payout_items_payout ON payout_items (payout_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS payout_items;
+DROP TABLE IF EXISTS payouts;
+DROP TABLE IF EXISTS settlement_batches;
+
+DROP INDEX IF EXISTS idx_ledger_entries_merchant_created;
+ALTER TABLE ledger_postings DISABLE TRIGGER ledger_postings_immutable;
+ALTER TABLE ledger_entries DISABLE TRIGGER ledger_entries_immutable;
+DELETE FROM ledger_postings WHERE entry_id IN (SELECT id FROM ledger_entries WHERE kind = 'payout');
+DELETE FROM ledger_entries WHERE kind = 'payout';
+ALTER TABLE ledger_entries ENABLE TRIGGER ledger_entries_immutable;
+ALTER TABLE ledger_postings ENABLE TRIGGER ledger_postings_immutable;
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_transaction_check;
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_kind_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_kind_check
+    CHECK (kind IN ('authorization', 'capture', 'release', 'refund', 'refund_settled', 'refund_reversal'));
+ALTER TABLE ledger_entries ALTER COLUMN transaction_id SET NOT NULL;
+
+-- +goose StatementEnd
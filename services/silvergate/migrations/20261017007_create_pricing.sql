@@ -0,0 +1,69 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Pricing plans are resolved per merchant and currency at the time of each fee event:
+-- a NULL currency row applies to every currency of the merchant, and the service default
+-- applies otherwise. Rows are never updated; a price change is a new row with a later
+-- effective_from, so fees already charged keep the plan they were computed with.
+CREATE TABLE pricing_plans (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    merchant_id    TEXT NOT NULL,
+    currency       TEXT CHECK (currency IS NULL OR length(currency) = 3),
+    percent_bps    BIGINT NOT NULL DEFAULT 0 CHECK (percent_bps >= 0),
+    fixed_fee      BIGINT NOT NULL DEFAULT 0 CHECK (fixed_fee >= 0),
+    refund_fee     BIGINT NOT NULL DEFAULT 0 CHECK (refund_fee >= 0),
+    chargeback_fee BIGINT NOT NULL DEFAULT 0 CHECK (chargeback_fee >= 0),
+    effective_from TIMESTAMPTZ NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_pricing_plans_merchant_currency_effective
+    ON pricing_plans(merchant_id, COALESCE(currency, ''), effective_from);
+
+-- Fees are ledger entries against the transaction they were charged for.
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_kind_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_kind_check
+    CHECK (kind IN ('authorization', 'capture', 'release', 'refund', 'refund_settled', 'refund_reversal',
+                    'fee', 'payout'));
+
+-- Fees charged, in minor units: per capture and refund, and in total per transaction.
+ALTER TABLE captures ADD COLUMN fee BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE refunds ADD COLUMN fee BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE transactions ADD COLUMN fee_amount BIGINT NOT NULL DEFAULT 0;
+
+-- Chargebacks reported by the acquirer against captured transactions.
+CREATE TABLE disputes (
+    id             UUID PRIMARY KEY,
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    amount         BIGINT NOT NULL CHECK (amount > 0),
+    reason         TEXT NOT NULL,
+    fee            BIGINT NOT NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_disputes_transaction ON disputes (transaction_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS disputes;
+ALTER TABLE transactions DROP COLUMN IF EXISTS fee_amount;
+ALTER TABLE refunds DROP COLUMN IF EXISTS fee;
+ALTER TABLE captures DROP COLUMN IF EXISTS fee;
+DROP TABLE IF EXISTS pricing_plans;
+
+ALTER TABLE ledger_postings DISABLE TRIGGER ledger_postings_immutable;
+ALTER TABLE ledger_entries DISABLE TRIGGER ledger_entries_immutable;
+DELETE FROM payout_items WHERE entry_id IN (SELECT id FROM ledger_entries WHERE kind = 'fee');
+DELETE FROM ledger_postings WHERE entry_id IN (SELECT id FROM ledger_entries WHERE kind = 'fee');
+DELETE FROM ledger_entries WHERE kind = 'fee';
+ALTER TABLE ledger_entries ENABLE TRIGGER ledger_entries_immutable;
+ALTER TABLE ledger_postings ENABLE TRIGGER ledger_postings_immutable;
+ALTER TABLE ledger_entries DROP CONSTRAINT IF EXISTS ledger_entries_kind_check;
+ALTER TABLE ledger_entries ADD CONSTRAINT ledger_entries_kind_check
+    CHECK (kind IN ('authorization', 'capture', 'release', 'refund', 'refund_settled', 'refund_reversal',
+                    'payout'));
+
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Webhook events are written here in the DB transaction of the state change they report
+-- and delivered at least once by the delivery worker: retried with backoff while pending,
+-- then delivered or, once older than the max age, dead.
+CREATE TABLE webhook_outbox (
+    id              UUID PRIMARY KEY,
+    merchant_id     TEXT NOT NULL,
+    event_type      TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    status          TEXT NOT NULL CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhook_outbox_due ON webhook_outbox (next_attempt_at) WHERE status = 'pending';
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS webhook_outbox;
+
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Merchant-registered webhook URLs. An empty event_types array subscribes to every
+-- event. Merchants without an active endpoint get their events at the default callback.
+CREATE TABLE webhook_endpoints (
+    id          UUID PRIMARY KEY,
+    merchant_id TEXT NOT NULL,
+    url         TEXT NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}',
+    secret      TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    disabled_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_webhook_endpoints_merchant ON webhook_endpoints (merchant_id) WHERE disabled_at IS NULL;
+
+-- Events are fanned out to one outbox message per subscribed endpoint, so each endpoint
+-- is retried on its own. NULL targets the default callback.
+ALTER TABLE webhook_outbox ADD COLUMN endpoint_id UUID REFERENCES webhook_endpoints (id);
+
+-- One row per delivery attempt. response_code is NULL when no response was received.
+CREATE TABLE webhook_deliveries (
+    id            UUID PRIMARY KEY,
+    message_id    UUID NOT NULL REFERENCES webhook_outbox (id),
+    merchant_id   TEXT NOT NULL,
+    endpoint_id   UUID REFERENCES webhook_endpoints (id),
+    event_type    TEXT NOT NULL,
+    url           TEXT NOT NULL,
+    request_body  JSONB NOT NULL,
+    response_code INTEGER,
+    latency_ms    BIGINT NOT NULL,
+    error         TEXT,
+    attempted_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_merchant ON webhook_deliveries (merchant_id, attempted_at DESC, id DESC);
+CREATE INDEX idx_webhook_deliveries_message ON webhook_deliveries (message_id);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS webhook_deliveries;
+ALTER TABLE webhook_outbox DROP COLUMN IF EXISTS endpoint_id;
+DROP TABLE IF EXISTS webhook_endpoints;
+
+-- +goose StatementEnd
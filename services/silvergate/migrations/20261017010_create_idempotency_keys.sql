@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Idempotency-Key records for mutating /api/v1 requests. A key is scoped to the merchant
+-- and the endpoint (method and route template); fingerprint hashes the concrete path,
+-- query and body so a key reused for another request is rejected. response_status is
+-- NULL while the first request is in flight and locked_at is when it took the key.
+CREATE TABLE idempotency_keys (
+    merchant_id           TEXT NOT NULL,
+    key                   TEXT NOT NULL,
+    endpoint              TEXT NOT NULL,
+    fingerprint           TEXT NOT NULL,
+    response_status       INTEGER,
+    response_content_type TEXT,
+    response_body         BYTEA,
+    locked_at             TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at            TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (merchant_id, key, endpoint)
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS idempotency_keys;
+
+-- +goose StatementEnd
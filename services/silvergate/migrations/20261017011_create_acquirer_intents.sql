@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Acquirer calls recorded before they are made. An intent is committed on its own ahead
+-- of the call and finished in the DB transaction that stores the outcome; the intent id is
+-- the idempotency reference sent to the acquirer. Authorize intents precede their
+-- transaction row, so transaction_id carries no foreign key. operation_id is the capture
+-- or refund of settle and refund intents. An intent the reconciler failed to resolve is
+-- retried with backoff at next_attempt_at, so it does not hold up the ones behind it.
+CREATE TABLE acquirer_intents (
+    id              UUID PRIMARY KEY,
+    kind            TEXT NOT NULL CHECK (kind IN ('authorize', 'settle', 'refund')),
+    status          TEXT NOT NULL CHECK (status IN ('authorizing', 'settling', 'refunding',
+                                                    'succeeded', 'failed', 'reversed', 'abandoned')),
+    transaction_id  UUID NOT NULL,
+    operation_id    UUID,
+    amount          BIGINT NOT NULL,
+    reason          TEXT,
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_acquirer_intents_in_flight ON acquirer_intents (next_attempt_at)
+    WHERE status IN ('authorizing', 'settling', 'refunding');
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE IF EXISTS acquirer_intents;
+
+-- +goose StatementEnd
@@ -1,40 +1,64 @@
 package silvergate
 
 import (
+	"TestTaskJustPay/services/silvergate/internal/ledger"
+	"TestTaskJustPay/services/silvergate/internal/ledger/ledgercontroller"
 	"TestTaskJustPay/services/silvergate/internal/merchantauth"
 	"TestTaskJustPay/services/silvergate/internal/product"
 	"TestTaskJustPay/services/silvergate/internal/product/productcontroller"
 	"TestTaskJustPay/services/silvergate/internal/purchase"
 	"TestTaskJustPay/services/silvergate/internal/purchase/purchasecontroller"
 	"TestTaskJustPay/services/silvergate/internal/transaction/transactioncontroller"
+	"TestTaskJustPay/services/silvergate/internal/vault"
+	"TestTaskJustPay/services/silvergate/internal/vault/vaultcontroller"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender"
+	"TestTaskJustPay/services/silvergate/internal/webhooksender/webhookcontroller"
 
 	"github.com/gin-gonic/gin"
 )
 
 func setupRouter(
 	engine *gin.Engine,
+	idempotencyMW gin.HandlerFunc,
 	authH *transactioncontroller.AuthHandler,
 	captureH *transactioncontroller.CaptureHandler,
 	voidH *transactioncontroller.VoidHandler,
 	refundH *transactioncontroller.RefundHandler,
+	queryH *transactioncontroller.QueryHandler,
+	challengeH *transactioncontroller.ChallengeHandler,
+	disputeH *transactioncontroller.DisputeHandler,
 	productSvc *product.Service,
 	purchaseSvc *purchase.Service,
+	vaultSvc *vault.Service,
+	ledgerSvc *ledger.Service,
+	webhookSvc *webhooksender.Service,
 ) {
 	api := engine.Group("/api/v1")
 	{
-		api.POST("/auth", authH.Handle)
-		api.POST("/capture", captureH.Handle)
-		api.POST("/void", voidH.Handle)
-		api.POST("/refund", refundH.Handle)
+		// Simulated cardholder challenge: in production this page is served by the card issuer.
+		// The cardholder has no merchant identity or Idempotency-Key; the challenge's own
+		// state rejects a second completion.
+		api.POST("/challenges/:id/complete", challengeH.Handle)
+	}
+
+	// Merchant routes act for and only see the authenticated merchant. Every mutating request
+	// must carry an Idempotency-Key scoped to that merchant; see idempotency.Middleware.
+	merchant := api.Group("", merchantauth.Middleware(), idempotencyMW)
+	{
+		merchant.GET("/transactions", queryH.Handle)
+		merchant.POST("/auth", authH.Handle)
+		merchant.POST("/capture", captureH.Handle)
+		merchant.POST("/void", voidH.Handle)
+		merchant.POST("/refund", refundH.Handle)
+		// Simulated chargeback notification: in production disputes are reported by the acquirer.
+		merchant.POST("/disputes", disputeH.Handle)
 
-		productcontroller.RegisterRoutes(
-			api.Group("/products", merchantauth.Middleware()),
-			productSvc,
-		)
-		purchasecontroller.RegisterRoutes(
-			api.Group("/purchase", merchantauth.Middleware()),
-			purchaseSvc,
-		)
+		productcontroller.RegisterRoutes(merchant.Group("/products"), productSvc)
+		purchasecontroller.RegisterRoutes(merchant.Group("/purchase"), purchaseSvc)
+		vaultcontroller.RegisterRoutes(merchant.Group("/cards"), vaultSvc)
+		ledgercontroller.RegisterRoutes(merchant.Group("/balances"), ledgerSvc)
+		webhookcontroller.RegisterEndpointRoutes(merchant.Group("/webhook-endpoints"), webhookSvc)
+		webhookcontroller.RegisterDeliveryRoutes(merchant.Group("/webhook-deliveries"), webhookSvc)
 	}
 
 	engine.GET("/health/live", func(c *gin.Context) {